@@ -3,6 +3,9 @@ package main
 import (
 	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 
@@ -10,21 +13,18 @@ import (
 	"klonekit/internal/errors"
 	"klonekit/internal/parser"
 	"klonekit/internal/provisioner"
+	"klonekit/internal/retry"
 	"klonekit/internal/runtime"
 	"klonekit/internal/scaffolder"
 	"klonekit/internal/scm"
+	"klonekit/internal/ui"
 )
 
-// findBlueprintFile searches for klonekit.yml or klonekit.yaml in the current directory
-func findBlueprintFile() string {
-	files := []string{"klonekit.yml", "klonekit.yaml"}
-	for _, file := range files {
-		if _, err := os.Stat(file); err == nil {
-			return file
-		}
-	}
-	return ""
-}
+// fileFlagUsage is the help text of the --file flag shared by the commands reading a blueprint
+var fileFlagUsage = fmt.Sprintf("Path to the blueprint YAML file (auto-detects klonekit.yml/klonekit.yaml, or the names listed in %s, if not specified)", parser.BlueprintNamesEnv)
+
+// explainDefaultsUsage is the help text of the --explain-defaults flag shared by apply, render and validate
+var explainDefaultsUsage = fmt.Sprintf("Print the effective configuration, with whether each value came from the blueprint, a flag, user config (e.g. %s) or a built-in default", provisioner.SkipCredentialCheckEnv)
 
 // getFileFlag gets the file flag value, falling back to auto-detection if not provided
 func getFileFlag(cmd *cobra.Command) (string, error) {
@@ -37,12 +37,13 @@ func getFileFlag(cmd *cobra.Command) (string, error) {
 	}
 
 	// Try to auto-detect blueprint file
-	autoDetected := findBlueprintFile()
+	autoDetected := parser.FindBlueprintFile(".")
 	if autoDetected == "" {
+		names := strings.Join(parser.BlueprintNames(), ", ")
 		return "", errors.NewBlueprintError(
 			"Failed to locate blueprint file",
-			"No klonekit.yml or klonekit.yaml file found in current directory",
-			"Create a blueprint file (klonekit.yml or klonekit.yaml) or specify one with -f flag",
+			fmt.Sprintf("No blueprint file (%s) found in current directory", names),
+			fmt.Sprintf("Create a blueprint file (%s), specify one with -f flag, or set %s to your naming convention", names, parser.BlueprintNamesEnv),
 			fmt.Errorf("no blueprint file found in current directory"),
 		)
 	}
@@ -50,6 +51,59 @@ func getFileFlag(cmd *cobra.Command) (string, error) {
 	return autoDetected, nil
 }
 
+// getStagingFlag resolves the --best-effort/--fail-fast flags into a staging mode.
+// An empty result means the blueprint setting is used.
+func getStagingFlag(cmd *cobra.Command) (string, error) {
+	bestEffort, err := cmd.Flags().GetBool("best-effort")
+	if err != nil {
+		return "", fmt.Errorf("failed to get best-effort flag: %w", err)
+	}
+	failFast, err := cmd.Flags().GetBool("fail-fast")
+	if err != nil {
+		return "", fmt.Errorf("failed to get fail-fast flag: %w", err)
+	}
+
+	switch {
+	case bestEffort:
+		return scm.StagingBestEffort, nil
+	case failFast:
+		return scm.StagingStrict, nil
+	default:
+		return "", nil
+	}
+}
+
+// getVisibilityFlag returns the --visibility override, or "" when the blueprint value applies.
+func getVisibilityFlag(cmd *cobra.Command) (string, error) {
+	visibility, err := cmd.Flags().GetString("visibility")
+	if err != nil {
+		return "", fmt.Errorf("failed to get visibility flag: %w", err)
+	}
+	if visibility == "" {
+		return "", nil
+	}
+	if err := scm.ValidateVisibility(visibility); err != nil {
+		return "", err
+	}
+	return visibility, nil
+}
+
+// getTimeoutFlag returns the --timeout override, or nil when the flag isn't given and the
+// blueprint value applies. A timeout of 0 disables the timeout of the blueprint.
+func getTimeoutFlag(cmd *cobra.Command) (*time.Duration, error) {
+	if !cmd.Flags().Changed("timeout") {
+		return nil, nil
+	}
+	timeout, err := cmd.Flags().GetDuration("timeout")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get timeout flag: %w", err)
+	}
+	if timeout < 0 {
+		return nil, fmt.Errorf("invalid timeout %s: must not be negative", timeout)
+	}
+	return &timeout, nil
+}
+
 // version is set at build time via ldflags
 var version = "dev"
 
@@ -59,6 +113,65 @@ var rootCmd = &cobra.Command{
 	Version: version,
 	Long: `KloneKit is a CLI tool that helps DevOps engineers provision infrastructure
 and set up GitLab projects using blueprint configurations.`,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		maxRetries, err := cmd.Flags().GetInt("max-retries")
+		if err != nil {
+			return fmt.Errorf("failed to get max-retries flag: %w", err)
+		}
+		explain, err := cmd.Flags().GetBool("explain")
+		if err != nil {
+			return fmt.Errorf("failed to get explain flag: %w", err)
+		}
+		errors.SetExplainMode(explain)
+
+		color, err := cmd.Flags().GetString("color")
+		if err != nil {
+			return fmt.Errorf("failed to get color flag: %w", err)
+		}
+		colorMode, err := ui.ParseColorMode(color)
+		if err != nil {
+			return err
+		}
+		noColor, err := cmd.Flags().GetBool("no-color")
+		if err != nil {
+			return fmt.Errorf("failed to get no-color flag: %w", err)
+		}
+		if noColor {
+			if cmd.Flags().Changed("color") && colorMode != ui.ColorNever {
+				return fmt.Errorf("--no-color conflicts with --color=%s", colorMode)
+			}
+			colorMode = ui.ColorNever
+		}
+		ui.SetColorMode(colorMode)
+		app.SetColorsEnabled(ui.ColorsEnabled())
+
+		suggestionsFile, err := cmd.Flags().GetString("suggestions-file")
+		if err != nil {
+			return fmt.Errorf("failed to get suggestions-file flag: %w", err)
+		}
+		if suggestionsFile == "" {
+			suggestionsFile = os.Getenv(errors.SuggestionsFileEnv)
+		}
+		if err := errors.LoadSuggestions(suggestionsFile); err != nil {
+			return err
+		}
+
+		gitLabTimeout, err := cmd.Flags().GetDuration("gitlab-timeout")
+		if err != nil {
+			return fmt.Errorf("failed to get gitlab-timeout flag: %w", err)
+		}
+		if value := os.Getenv(scm.GitLabTimeoutEnv); gitLabTimeout == 0 && value != "" {
+			gitLabTimeout, err = time.ParseDuration(value)
+			if err != nil {
+				return fmt.Errorf("invalid %s: %w", scm.GitLabTimeoutEnv, err)
+			}
+		}
+		if err := scm.SetGitLabTimeout(gitLabTimeout); err != nil {
+			return err
+		}
+
+		return retry.SetMaxRetries(maxRetries)
+	},
 }
 
 var applyCmd = &cobra.Command{
@@ -91,11 +204,140 @@ This orchestrates all individual commands (scaffold, scm, provision) in the corr
 			os.Exit(1)
 		}
 
-		// Execute the complete workflow via app orchestrator
-		if err := app.Apply(file, dryRun, retainState, autoApprove); err != nil {
+		skipCredentialCheck, err := cmd.Flags().GetBool("skip-credential-check")
+		if err != nil {
+			errors.HandleError(fmt.Errorf("failed to get skip-credential-check flag: %w", err))
+			os.Exit(1)
+		}
+		skipSourceCheck, err := cmd.Flags().GetBool("skip-source-check")
+		if err != nil {
+			errors.HandleError(fmt.Errorf("failed to get skip-source-check flag: %w", err))
+			os.Exit(1)
+		}
+		parallel, err := cmd.Flags().GetBool("parallel")
+		if err != nil {
+			errors.HandleError(fmt.Errorf("failed to get parallel flag: %w", err))
+			os.Exit(1)
+		}
+		planJSON, err := cmd.Flags().GetString("plan-json")
+		if err != nil {
+			errors.HandleError(fmt.Errorf("failed to get plan-json flag: %w", err))
+			os.Exit(1)
+		}
+		artifactsDir, err := cmd.Flags().GetString("artifacts-dir")
+		if err != nil {
+			errors.HandleError(fmt.Errorf("failed to get artifacts-dir flag: %w", err))
+			os.Exit(1)
+		}
+		timeout, err := getTimeoutFlag(cmd)
+		if err != nil {
+			errors.HandleError(err)
+			os.Exit(1)
+		}
+
+		skipStages, err := cmd.Flags().GetStringSlice("skip-stage")
+		if err != nil {
+			errors.HandleError(fmt.Errorf("failed to get skip-stage flag: %w", err))
+			os.Exit(1)
+		}
+		tracePath, err := cmd.Flags().GetString("trace")
+		if err != nil {
+			errors.HandleError(fmt.Errorf("failed to get trace flag: %w", err))
+			os.Exit(1)
+		}
+		scaffoldDir, err := cmd.Flags().GetString("dir")
+		if err != nil {
+			errors.HandleError(fmt.Errorf("failed to get dir flag: %w", err))
+			os.Exit(1)
+		}
+		planReal, err := cmd.Flags().GetBool("plan-real")
+		if err != nil {
+			errors.HandleError(fmt.Errorf("failed to get plan-real flag: %w", err))
+			os.Exit(1)
+		}
+		checkDocker, err := cmd.Flags().GetBool("check-docker")
+		if err != nil {
+			errors.HandleError(fmt.Errorf("failed to get check-docker flag: %w", err))
+			os.Exit(1)
+		}
+		bundleOnFailure, err := cmd.Flags().GetBool("bundle-on-failure")
+		if err != nil {
+			errors.HandleError(fmt.Errorf("failed to get bundle-on-failure flag: %w", err))
+			os.Exit(1)
+		}
+		forceResume, err := cmd.Flags().GetBool("force-resume")
+		if err != nil {
+			errors.HandleError(fmt.Errorf("failed to get force-resume flag: %w", err))
+			os.Exit(1)
+		}
+		force, err := cmd.Flags().GetBool("force")
+		if err != nil {
+			errors.HandleError(fmt.Errorf("failed to get force flag: %w", err))
+			os.Exit(1)
+		}
+		skipApplyConfirmation, err := cmd.Flags().GetBool("skip-apply-confirmation")
+		if err != nil {
+			errors.HandleError(fmt.Errorf("failed to get skip-apply-confirmation flag: %w", err))
+			os.Exit(1)
+		}
+		keepGoing, err := cmd.Flags().GetBool("keep-going")
+		if err != nil {
+			errors.HandleError(fmt.Errorf("failed to get keep-going flag: %w", err))
+			os.Exit(1)
+		}
+		explainDefaults, err := cmd.Flags().GetBool("explain-defaults")
+		if err != nil {
+			errors.HandleError(fmt.Errorf("failed to get explain-defaults flag: %w", err))
+			os.Exit(1)
+		}
+
+		staging, err := getStagingFlag(cmd)
+		if err != nil {
+			errors.HandleError(err)
+			os.Exit(1)
+		}
+		visibility, err := getVisibilityFlag(cmd)
+		if err != nil {
 			errors.HandleError(err)
 			os.Exit(1)
 		}
+
+		// Execute the complete workflow via app orchestrator
+		opts := app.ApplyOptions{
+			DryRun:                dryRun,
+			RetainState:           retainState,
+			AutoApprove:           autoApprove,
+			Staging:               staging,
+			SkipCredentialCheck:   skipCredentialCheck,
+			SkipSourceCheck:       skipSourceCheck,
+			Parallel:              parallel,
+			PlanJSON:              planJSON,
+			ArtifactsDir:          artifactsDir,
+			Timeout:               timeout,
+			SkipStages:            skipStages,
+			TracePath:             tracePath,
+			ScaffoldDir:           scaffoldDir,
+			PlanReal:              planReal,
+			CheckDocker:           checkDocker,
+			Visibility:            visibility,
+			BundleOnFailure:       bundleOnFailure,
+			ForceResume:           forceResume,
+			Force:                 force,
+			SkipApplyConfirmation: skipApplyConfirmation,
+			KeepGoing:             keepGoing,
+			Input:                 os.Stdin,
+		}
+		if explainDefaults {
+			if err := app.ExplainDefaults(os.Stdout, file, opts); err != nil {
+				errors.HandleError(err)
+				os.Exit(1)
+			}
+			return
+		}
+		if err := app.ApplyWithOptions(file, opts); err != nil {
+			errors.HandleError(err)
+			os.Exit(app.ExitCode(err))
+		}
 	},
 }
 
@@ -116,6 +358,31 @@ of Terraform files locally for verification before infrastructure creation.`,
 			errors.HandleError(fmt.Errorf("failed to get dry-run flag: %w", err))
 			os.Exit(1)
 		}
+		dir, err := cmd.Flags().GetString("dir")
+		if err != nil {
+			errors.HandleError(fmt.Errorf("failed to get dir flag: %w", err))
+			os.Exit(1)
+		}
+		validateOnly, err := cmd.Flags().GetBool("validate")
+		if err != nil {
+			errors.HandleError(fmt.Errorf("failed to get validate flag: %w", err))
+			os.Exit(1)
+		}
+		checkOnly, err := cmd.Flags().GetBool("check")
+		if err != nil {
+			errors.HandleError(fmt.Errorf("failed to get check flag: %w", err))
+			os.Exit(1)
+		}
+		force, err := cmd.Flags().GetBool("force")
+		if err != nil {
+			errors.HandleError(fmt.Errorf("failed to get force flag: %w", err))
+			os.Exit(1)
+		}
+		skipSourceCheck, err := cmd.Flags().GetBool("skip-source-check")
+		if err != nil {
+			errors.HandleError(fmt.Errorf("failed to get skip-source-check flag: %w", err))
+			os.Exit(1)
+		}
 
 		// Parse and validate the blueprint file
 		blueprint, err := parser.Parse(file)
@@ -123,11 +390,53 @@ of Terraform files locally for verification before infrastructure creation.`,
 			errors.HandleError(err)
 			os.Exit(1)
 		}
+		if err := scaffolder.DecryptVariables(&blueprint.Spec); err != nil {
+			errors.HandleError(err)
+			os.Exit(1)
+		}
+
+		// Override the destination for ad-hoc runs
+		if dir != "" {
+			blueprint.Spec.Scaffold.Destination = dir
+		}
+		if skipSourceCheck {
+			blueprint.Spec.Scaffold.SkipSourceCheck = true
+		}
+
+		// Compare the destination with a fresh scaffold to catch edits to generated files
+		if checkOnly {
+			fmt.Printf("Checking scaffold output of blueprint: %s\n", blueprint.Metadata.Name)
+			result, err := scaffolder.Check(&blueprint.Spec)
+			if err != nil {
+				errors.HandleError(err)
+				os.Exit(1)
+			}
+			for _, drift := range result.Drift {
+				fmt.Printf("  %-10s %s\n", drift.Kind+":", drift.Path)
+			}
+			if result.Diverged() {
+				errors.HandleError(fmt.Errorf("destination %s has drifted from the blueprint; re-run scaffold to regenerate it", blueprint.Spec.Scaffold.Destination))
+				os.Exit(1)
+			}
+			fmt.Println("Scaffold check passed. The destination matches the blueprint.")
+			return
+		}
+
+		// Scaffold into a throwaway directory to check that scaffolding would succeed
+		if validateOnly {
+			fmt.Printf("Validating scaffolding of blueprint: %s\n", blueprint.Metadata.Name)
+			if err := scaffolder.Validate(&blueprint.Spec); err != nil {
+				errors.HandleError(err)
+				os.Exit(1)
+			}
+			fmt.Println("Scaffold validation completed successfully. No files were written.")
+			return
+		}
 
 		// Process the blueprint with the scaffolder
 		fmt.Printf("Scaffolding blueprint: %s\n", blueprint.Metadata.Name)
 
-		if err := scaffolder.Scaffold(&blueprint.Spec, dryRun); err != nil {
+		if err := scaffolder.Scaffold(&blueprint.Spec, dryRun, force); err != nil {
 			errors.HandleError(err)
 			os.Exit(1)
 		}
@@ -142,9 +451,9 @@ of Terraform files locally for verification before infrastructure creation.`,
 
 var scmCmd = &cobra.Command{
 	Use:   "scm",
-	Short: "Create GitLab repository from scaffolded project",
+	Short: "Create GitLab or GitHub repository from scaffolded project",
 	Long: `SCM processes a scaffolded project directory and publishes it to a new
-GitLab repository using the GitLab API and git operations.`,
+GitLab or GitHub repository (spec.scm.provider) using its API and git operations.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		file, err := getFileFlag(cmd)
 		if err != nil {
@@ -158,11 +467,37 @@ GitLab repository using the GitLab API and git operations.`,
 			errors.HandleError(err)
 			os.Exit(1)
 		}
+		if !blueprint.Spec.SCM.Configured() {
+			errors.HandleError(fmt.Errorf("blueprint '%s' has no spec.scm to publish to", blueprint.Metadata.Name))
+			os.Exit(1)
+		}
+		if err := scaffolder.DecryptVariables(&blueprint.Spec); err != nil {
+			errors.HandleError(err)
+			os.Exit(1)
+		}
+
+		staging, err := getStagingFlag(cmd)
+		if err != nil {
+			errors.HandleError(err)
+			os.Exit(1)
+		}
+		if staging != "" {
+			blueprint.Spec.SCM.Staging = staging
+		}
+
+		visibility, err := getVisibilityFlag(cmd)
+		if err != nil {
+			errors.HandleError(err)
+			os.Exit(1)
+		}
+		if visibility != "" {
+			blueprint.Spec.SCM.Project.Visibility = visibility
+		}
 
-		// Create GitLab repository and push scaffolded files
-		fmt.Printf("Creating GitLab repository for: %s\n", blueprint.Metadata.Name)
+		// Create the repository and push scaffolded files
+		fmt.Printf("Creating %s repository for: %s\n", blueprint.Spec.SCM.Provider, blueprint.Metadata.Name)
 
-		provider, err := scm.NewGitLabProvider()
+		provider, err := app.NewProviderFactory().GetScmProvider(blueprint.Spec.SCM.Provider, blueprint.Spec.SCM.URL)
 		if err != nil {
 			errors.HandleError(err)
 			os.Exit(1)
@@ -173,7 +508,7 @@ GitLab repository using the GitLab API and git operations.`,
 			os.Exit(1)
 		}
 
-		fmt.Printf("Successfully created GitLab repository: %s\n", blueprint.Spec.SCM.Project.Name)
+		fmt.Printf("Successfully created %s repository: %s\n", blueprint.Spec.SCM.Provider, blueprint.Spec.SCM.Project.Name)
 	},
 }
 
@@ -182,7 +517,9 @@ var provisionCmd = &cobra.Command{
 	Short: "Provision infrastructure using containerized Terraform",
 	Long: `Provision executes Terraform commands within a Docker container to provision
 infrastructure defined in the scaffolded Terraform files. This ensures a consistent
-and isolated environment for infrastructure provisioning.`,
+and isolated environment for infrastructure provisioning. With --auto-approve, the
+outputs listed in spec.scm.project.exportOutputs are exported as CI/CD variables
+after the apply, as apply does.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		file, err := getFileFlag(cmd)
 		if err != nil {
@@ -195,6 +532,41 @@ and isolated environment for infrastructure provisioning.`,
 			errors.HandleError(fmt.Errorf("failed to get auto-approve flag: %w", err))
 			os.Exit(1)
 		}
+		skipCredentialCheck, err := cmd.Flags().GetBool("skip-credential-check")
+		if err != nil {
+			errors.HandleError(fmt.Errorf("failed to get skip-credential-check flag: %w", err))
+			os.Exit(1)
+		}
+		parallel, err := cmd.Flags().GetBool("parallel")
+		if err != nil {
+			errors.HandleError(fmt.Errorf("failed to get parallel flag: %w", err))
+			os.Exit(1)
+		}
+		planJSON, err := cmd.Flags().GetString("plan-json")
+		if err != nil {
+			errors.HandleError(fmt.Errorf("failed to get plan-json flag: %w", err))
+			os.Exit(1)
+		}
+		artifactsDir, err := cmd.Flags().GetString("artifacts-dir")
+		if err != nil {
+			errors.HandleError(fmt.Errorf("failed to get artifacts-dir flag: %w", err))
+			os.Exit(1)
+		}
+		timeout, err := getTimeoutFlag(cmd)
+		if err != nil {
+			errors.HandleError(err)
+			os.Exit(1)
+		}
+		skipApplyConfirmation, err := cmd.Flags().GetBool("skip-apply-confirmation")
+		if err != nil {
+			errors.HandleError(fmt.Errorf("failed to get skip-apply-confirmation flag: %w", err))
+			os.Exit(1)
+		}
+		useSavedPlan, err := cmd.Flags().GetBool("use-saved-plan")
+		if err != nil {
+			errors.HandleError(fmt.Errorf("failed to get use-saved-plan flag: %w", err))
+			os.Exit(1)
+		}
 
 		// Parse and validate the blueprint file
 		blueprint, err := parser.Parse(file)
@@ -202,6 +574,42 @@ and isolated environment for infrastructure provisioning.`,
 			errors.HandleError(err)
 			os.Exit(1)
 		}
+		if err := scaffolder.DecryptVariables(&blueprint.Spec); err != nil {
+			errors.HandleError(err)
+			os.Exit(1)
+		}
+		if skipCredentialCheck {
+			blueprint.Spec.Provision.SkipCredentialCheck = true
+		}
+		if parallel {
+			blueprint.Spec.Provision.Parallel = true
+		}
+		if planJSON != "" {
+			blueprint.Spec.Provision.PlanJSON = planJSON
+		}
+		if artifactsDir != "" {
+			blueprint.Spec.Provision.Artifacts.Dir = artifactsDir
+		}
+		if timeout != nil {
+			blueprint.Spec.Provision.Timeout = timeout.String()
+		}
+		if useSavedPlan {
+			blueprint.Spec.Provision.UseSavedPlan = true
+		}
+
+		// Make sure there is something to provision before starting Docker
+		if err := provisioner.ValidateScaffold(blueprint.Spec.Scaffold.Destination); err != nil {
+			errors.HandleError(err)
+			os.Exit(1)
+		}
+
+		// Production blueprints are confirmed before anything is applied
+		if autoApprove && !skipApplyConfirmation {
+			if err := app.ConfirmProductionApply(blueprint, os.Stdin); err != nil {
+				errors.HandleError(err)
+				os.Exit(1)
+			}
+		}
 
 		// Provision infrastructure using Docker
 		fmt.Printf("Provisioning infrastructure for: %s\n", blueprint.Metadata.Name)
@@ -221,6 +629,14 @@ and isolated environment for infrastructure provisioning.`,
 			os.Exit(1)
 		}
 
+		// Hand the outputs to downstream pipelines, as apply does
+		if autoApprove {
+			if err := app.ExportOutputs(blueprint, terraformProvisioner); err != nil {
+				errors.HandleError(fmt.Errorf("infrastructure was provisioned, but exporting its outputs failed: %w", err))
+				os.Exit(1)
+			}
+		}
+
 		if autoApprove {
 			fmt.Printf("Successfully provisioned infrastructure for: %s\n", blueprint.Metadata.Name)
 		} else {
@@ -229,23 +645,515 @@ and isolated environment for infrastructure provisioning.`,
 	},
 }
 
+var planCmd = &cobra.Command{
+	Use:   "plan",
+	Short: "Save a Terraform plan of the scaffolded project for review",
+	Long: `Plan runs terraform init and plan in the Terraform container and saves the plan to
+tfplan in the scaffold destination, so it can be reviewed (e.g. with
+"klonekit exec -- show tfplan") before anything changes. Apply exactly the reviewed
+plan with "klonekit provision --auto-approve --use-saved-plan".`,
+	Run: func(cmd *cobra.Command, args []string) {
+		file, err := getFileFlag(cmd)
+		if err != nil {
+			errors.HandleError(err)
+			os.Exit(1)
+		}
+
+		skipCredentialCheck, err := cmd.Flags().GetBool("skip-credential-check")
+		if err != nil {
+			errors.HandleError(fmt.Errorf("failed to get skip-credential-check flag: %w", err))
+			os.Exit(1)
+		}
+		parallel, err := cmd.Flags().GetBool("parallel")
+		if err != nil {
+			errors.HandleError(fmt.Errorf("failed to get parallel flag: %w", err))
+			os.Exit(1)
+		}
+		timeout, err := getTimeoutFlag(cmd)
+		if err != nil {
+			errors.HandleError(err)
+			os.Exit(1)
+		}
+
+		// Parse and validate the blueprint file
+		blueprint, err := parser.Parse(file)
+		if err != nil {
+			errors.HandleError(err)
+			os.Exit(1)
+		}
+		if err := scaffolder.DecryptVariables(&blueprint.Spec); err != nil {
+			errors.HandleError(err)
+			os.Exit(1)
+		}
+		if skipCredentialCheck {
+			blueprint.Spec.Provision.SkipCredentialCheck = true
+		}
+		if parallel {
+			blueprint.Spec.Provision.Parallel = true
+		}
+		if timeout != nil {
+			blueprint.Spec.Provision.Timeout = timeout.String()
+		}
+
+		if err := provisioner.ValidateScaffold(blueprint.Spec.Scaffold.Destination); err != nil {
+			errors.HandleError(err)
+			os.Exit(1)
+		}
+
+		// Create Docker runtime instance
+		dockerRuntime, err := runtime.NewDockerRuntime()
+		if err != nil {
+			errors.HandleError(err)
+			os.Exit(1)
+		}
+
+		terraformProvisioner := provisioner.NewTerraformDockerProvisioner(dockerRuntime)
+
+		if err := terraformProvisioner.Plan(&blueprint.Spec); err != nil {
+			errors.HandleError(err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("Plan saved to %s for: %s (apply it with 'klonekit provision --auto-approve --use-saved-plan')\n",
+			filepath.Join(blueprint.Spec.Scaffold.Destination, provisioner.SavedPlanFileName), blueprint.Metadata.Name)
+	},
+}
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Run preflight checks before an apply",
+	Long: `Doctor checks that the environment can run the blueprint before committing to an
+apply: that the blueprint is valid and the container runtime is reachable. With --pull,
+the Terraform image is pulled as well, so registry and authentication problems surface
+early; it is off by default to avoid unwanted network use.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		file, err := getFileFlag(cmd)
+		if err != nil {
+			errors.HandleError(err)
+			os.Exit(1)
+		}
+
+		pull, err := cmd.Flags().GetBool("pull")
+		if err != nil {
+			errors.HandleError(fmt.Errorf("failed to get pull flag: %w", err))
+			os.Exit(1)
+		}
+
+		// Parse and validate the blueprint file
+		blueprint, err := parser.Parse(file)
+		if err != nil {
+			errors.HandleError(err)
+			os.Exit(1)
+		}
+
+		if _, err := app.Doctor(cmd.Context(), os.Stdout, blueprint, app.NewProviderFactory(), app.DoctorOptions{PullImage: pull}); err != nil {
+			errors.HandleError(err)
+			os.Exit(1)
+		}
+	},
+}
+
+var execCmd = &cobra.Command{
+	Use:   "exec -- <terraform subcommand> [args...]",
+	Short: "Run a terraform subcommand in the provisioning container",
+	Long: `Exec runs an arbitrary Terraform subcommand (e.g. "terraform state list") inside the
+Terraform container against the scaffolded project, with the same mounts and credentials
+used for provisioning. Global options such as -chdir=DIR may precede the subcommand.
+Commands that modify infrastructure or state (apply, destroy, import, refresh, taint,
+untaint, force-unlock, state rm/mv/push/replace-provider and workspace delete) are
+refused unless --allow-mutating is given.`,
+	Example: "  klonekit exec -- terraform state list",
+	Args:    cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		file, err := getFileFlag(cmd)
+		if err != nil {
+			errors.HandleError(err)
+			os.Exit(1)
+		}
+
+		allowMutating, err := cmd.Flags().GetBool("allow-mutating")
+		if err != nil {
+			errors.HandleError(fmt.Errorf("failed to get allow-mutating flag: %w", err))
+			os.Exit(1)
+		}
+
+		// Parse and validate the blueprint file
+		blueprint, err := parser.Parse(file)
+		if err != nil {
+			errors.HandleError(err)
+			os.Exit(1)
+		}
+		if err := scaffolder.DecryptVariables(&blueprint.Spec); err != nil {
+			errors.HandleError(err)
+			os.Exit(1)
+		}
+
+		if err := provisioner.ValidateScaffold(blueprint.Spec.Scaffold.Destination); err != nil {
+			errors.HandleError(err)
+			os.Exit(1)
+		}
+
+		// Create Docker runtime instance
+		dockerRuntime, err := runtime.NewDockerRuntime()
+		if err != nil {
+			errors.HandleError(err)
+			os.Exit(1)
+		}
+
+		terraformProvisioner := provisioner.NewTerraformDockerProvisioner(dockerRuntime)
+
+		if err := terraformProvisioner.Exec(&blueprint.Spec, allowMutating, args...); err != nil {
+			errors.HandleError(err)
+			os.Exit(1)
+		}
+	},
+}
+
+var statusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show the status of the current apply run",
+	Long: `Status reports the progress recorded in the state file of an interrupted or retained
+apply run, including why each stage was skipped (already completed, excluded with
+--skip-stage, or its run condition was false, e.g. scm without spec.scm).`,
+	Run: func(cmd *cobra.Command, args []string) {
+		asJSON, err := cmd.Flags().GetBool("json")
+		if err != nil {
+			errors.HandleError(fmt.Errorf("failed to get json flag: %w", err))
+			os.Exit(1)
+		}
+
+		if err := app.Status(os.Stdout, asJSON); err != nil {
+			errors.HandleError(err)
+			os.Exit(1)
+		}
+	},
+}
+
+var stateCmd = &cobra.Command{
+	Use:   "state",
+	Short: "Move the state of an apply run between machines",
+	Long: `State exports and imports the execution state of an interrupted apply run, so a run
+started on one machine can be resumed on another (e.g. by the next job of a sharded CI
+pipeline). The scaffold output is referenced by the bundle but not included; restore it
+(e.g. from a CI artifact) before resuming.`,
+}
+
+var stateExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export the state of the current run to a portable bundle",
+	Run: func(cmd *cobra.Command, args []string) {
+		output, err := cmd.Flags().GetString("output")
+		if err != nil {
+			errors.HandleError(fmt.Errorf("failed to get output flag: %w", err))
+			os.Exit(1)
+		}
+
+		if output == "" || output == "-" {
+			if _, err := app.ExportState(os.Stdout); err != nil {
+				errors.HandleError(err)
+				os.Exit(1)
+			}
+			return
+		}
+
+		file, err := os.OpenFile(output, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600) // #nosec G304
+		if err != nil {
+			errors.HandleError(fmt.Errorf("failed to create state bundle: %w", err))
+			os.Exit(1)
+		}
+		bundle, err := app.ExportState(file)
+		if closeErr := file.Close(); err == nil && closeErr != nil {
+			err = fmt.Errorf("failed to write state bundle: %w", closeErr)
+		}
+		if err != nil {
+			errors.HandleError(err)
+			os.Exit(1)
+		}
+		fmt.Printf("Exported state of run %s to %s\n", bundle.RunID, output)
+	},
+}
+
+var stateImportCmd = &cobra.Command{
+	Use:   "import <bundle>",
+	Short: "Import a state bundle so the next apply resumes its run",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		runID, err := cmd.Flags().GetString("run-id")
+		if err != nil {
+			errors.HandleError(fmt.Errorf("failed to get run-id flag: %w", err))
+			os.Exit(1)
+		}
+		force, err := cmd.Flags().GetBool("force")
+		if err != nil {
+			errors.HandleError(fmt.Errorf("failed to get force flag: %w", err))
+			os.Exit(1)
+		}
+
+		file, err := os.Open(args[0])
+		if err != nil {
+			errors.HandleError(fmt.Errorf("failed to open state bundle: %w", err))
+			os.Exit(1)
+		}
+		defer file.Close()
+
+		bundle, err := app.ImportState(file, app.ImportOptions{RunID: runID, Force: force})
+		if err != nil {
+			errors.HandleError(err)
+			os.Exit(1)
+		}
+		fmt.Printf("Imported state of run %s. Run 'klonekit apply' to resume it.\n", bundle.RunID)
+	},
+}
+
+var explainCmd = &cobra.Command{
+	Use:   "explain [code]",
+	Short: "Explain an error code and how to resolve it",
+	Long: `Explain prints the meaning of a KloneKit error code (e.g. KK005) or type name
+(e.g. provision_failed) with guidance on resolving it. Without an argument every
+error type is listed. Run any command with --explain to print the full structured
+error, including its code, when it fails.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		asJSON, err := cmd.Flags().GetBool("json")
+		if err != nil {
+			errors.HandleError(fmt.Errorf("failed to get json flag: %w", err))
+			os.Exit(1)
+		}
+
+		var code string
+		if len(args) > 0 {
+			code = args[0]
+		}
+		if err := errors.ExplainCode(os.Stdout, code, asJSON); err != nil {
+			errors.HandleError(err)
+			os.Exit(1)
+		}
+	},
+}
+
+var renderCmd = &cobra.Command{
+	Use:   "render",
+	Short: "Print the resolved blueprint",
+	Long: `Render prints every blueprint in the file as KloneKit resolves it, with project name
+templates and environment variable references expanded, without applying anything. YAML
+output is the native blueprint format, so it can be diffed against the source blueprint;
+map keys are sorted in both formats. Tokens, masked CI variables and secret variables are
+printed masked. With --explain-defaults, the effective value of each field with a built-in
+default is printed instead, with whether it came from the blueprint, user config or the default.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		file, err := getFileFlag(cmd)
+		if err != nil {
+			errors.HandleError(err)
+			os.Exit(1)
+		}
+
+		format, err := cmd.Flags().GetString("output-format")
+		if err != nil {
+			errors.HandleError(fmt.Errorf("failed to get output-format flag: %w", err))
+			os.Exit(1)
+		}
+		explainDefaults, err := cmd.Flags().GetBool("explain-defaults")
+		if err != nil {
+			errors.HandleError(fmt.Errorf("failed to get explain-defaults flag: %w", err))
+			os.Exit(1)
+		}
+
+		if explainDefaults {
+			err = app.ExplainDefaults(os.Stdout, file, app.ApplyOptions{})
+		} else {
+			err = app.Render(os.Stdout, file, format)
+		}
+		if err != nil {
+			errors.HandleError(err)
+			os.Exit(1)
+		}
+	},
+}
+
+var validateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Check that the blueprint is valid",
+	Long: `Validate parses and validates every blueprint in the file without applying anything.
+With --explain-defaults, the effective value of each field with a built-in default is printed
+as well, with whether it came from the blueprint, user config or the default.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		file, err := getFileFlag(cmd)
+		if err != nil {
+			errors.HandleError(err)
+			os.Exit(1)
+		}
+
+		explainDefaults, err := cmd.Flags().GetBool("explain-defaults")
+		if err != nil {
+			errors.HandleError(fmt.Errorf("failed to get explain-defaults flag: %w", err))
+			os.Exit(1)
+		}
+
+		if err := app.Validate(os.Stdout, file); err != nil {
+			errors.HandleError(err)
+			os.Exit(1)
+		}
+		if explainDefaults {
+			fmt.Println()
+			if err := app.ExplainDefaults(os.Stdout, file, app.ApplyOptions{}); err != nil {
+				errors.HandleError(err)
+				os.Exit(1)
+			}
+		}
+	},
+}
+
+var schemaCmd = &cobra.Command{
+	Use:   "schema",
+	Short: "Print the JSON Schema of the blueprint",
+	Long: `Schema prints a JSON Schema describing the blueprint, derived from the same rules
+KloneKit validates blueprints with, for editors and CI to check blueprints before a run.
+Rules spanning several fields, such as requiring scaffold.source unless scaffold.targets
+is set, are only checked by KloneKit.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := parser.WriteSchema(os.Stdout); err != nil {
+			errors.HandleError(err)
+			os.Exit(1)
+		}
+	},
+}
+
+var abortCmd = &cobra.Command{
+	Use:   "abort",
+	Short: "Abort an interrupted apply run and clean up its state",
+	Long: `Abort cancels an interrupted apply run by removing the state and lock files,
+leaving a clean slate for the next run. With --rollback, the scaffolded files of the
+interrupted run are removed as well. A lock held by a process that is still running on this
+host is kept unless --force is given.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		rollback, err := cmd.Flags().GetBool("rollback")
+		if err != nil {
+			errors.HandleError(fmt.Errorf("failed to get rollback flag: %w", err))
+			os.Exit(1)
+		}
+		yes, err := cmd.Flags().GetBool("yes")
+		if err != nil {
+			errors.HandleError(fmt.Errorf("failed to get yes flag: %w", err))
+			os.Exit(1)
+		}
+
+		force, err := cmd.Flags().GetBool("force")
+		if err != nil {
+			errors.HandleError(fmt.Errorf("failed to get force flag: %w", err))
+			os.Exit(1)
+		}
+
+		if err := app.Abort(app.AbortOptions{Rollback: rollback, Yes: yes, Force: force, Input: os.Stdin}); err != nil {
+			errors.HandleError(err)
+			os.Exit(1)
+		}
+	},
+}
+
 func init() {
-	applyCmd.Flags().StringP("file", "f", "", "Path to the blueprint YAML file (auto-detects klonekit.yml/klonekit.yaml if not specified)")
+	rootCmd.PersistentFlags().Bool("explain", false, "On failure, also print the full error (code, type, context, cause, suggestion and error chain) as JSON to stderr")
+	rootCmd.PersistentFlags().String("color", string(ui.ColorAuto), "When to print ANSI colors: auto (on a terminal, unless the "+ui.NoColorEnv+" environment variable is set), always or never")
+	rootCmd.PersistentFlags().Bool("no-color", false, "Print plain text without ANSI colors, the same as --color=never")
+	rootCmd.PersistentFlags().String("suggestions-file", "", "YAML file mapping error types or codes to custom suggestion text (default $"+errors.SuggestionsFileEnv+")")
+	rootCmd.PersistentFlags().Duration("gitlab-timeout", 0, "Timeout of each GitLab API request, e.g. 1m (default $"+scm.GitLabTimeoutEnv+", else "+scm.DefaultGitLabTimeout.String()+")")
+	rootCmd.PersistentFlags().Int("max-retries", retry.DefaultMaxRetries, "Retries for network operations (GitLab API calls, image pulls, terraform init); 0 disables retrying")
+
+	applyCmd.Flags().StringP("file", "f", "", fileFlagUsage)
 	applyCmd.Flags().Bool("dry-run", false, "Simulate the workflow without making any changes")
+	applyCmd.Flags().Bool("plan-real", false, "With --dry-run, run a real terraform init and plan while scaffold and scm stay simulated")
+	applyCmd.Flags().Bool("check-docker", false, "With --dry-run, warn when the Docker daemon the provision stage needs is unreachable")
 	applyCmd.Flags().Bool("retain-state", false, "Keep the state file after successful completion for auditing purposes")
 	applyCmd.Flags().Bool("auto-approve", false, "Automatically approve terraform apply without prompting")
+	applyCmd.Flags().Bool("skip-credential-check", false, "Skip verifying cloud credentials before provisioning (e.g. when offline)")
+	applyCmd.Flags().Bool("skip-source-check", false, "Scaffold a source module without Terraform files in its root directory, e.g. one holding only modules in subdirectories")
+	applyCmd.Flags().Bool("parallel", false, "Pull the Terraform image concurrently with the local setup work")
+	applyCmd.Flags().String("plan-json", "", "Write the plan as JSON (terraform show -json) to this file before apply, for policy tools such as OPA or Sentinel")
+	applyCmd.Flags().String("artifacts-dir", "", "Keep each run's plan file, plan output and apply status under <dir>/<run-id> for audit (overrides spec.provision.artifacts.dir)")
+	applyCmd.Flags().Duration("timeout", 0, "Stop provisioning and remove the Terraform container if it takes longer than this, e.g. 45m; 0 disables the timeout (overrides spec.provision.timeout)")
+	applyCmd.Flags().StringSlice("skip-stage", nil, "Skip the given stages (scaffold, scm, provision); may be repeated")
+	applyCmd.Flags().String("dir", "", "Scaffold into this directory instead of spec.scaffold.destination (also used by the scm and provision stages)")
+	applyCmd.Flags().String("trace", "", "Write OpenTelemetry-style trace spans for the run and each stage to this file as JSON lines")
+	applyCmd.Flags().Bool("bundle-on-failure", false, "On failure, archive the log, state, state backups and captured output into klonekit-failure-<runid>.tar.gz")
+	applyCmd.Flags().Bool("force-resume", false, "Resume an interrupted run even though the blueprint changed since it started")
+	applyCmd.Flags().Bool("force", false, "Scaffold into a destination that already contains files, overwriting them")
+	applyCmd.Flags().Bool("skip-apply-confirmation", false, "Apply blueprints with spec.provision.confirmApply without typing the project name, for non-interactive runs")
+	applyCmd.Flags().Bool("keep-going", false, "With a multi-document blueprint, apply the remaining blueprints when one fails and print a summary; exits 2 if some blueprints failed and 3 if all failed")
+	applyCmd.Flags().Bool("explain-defaults", false, explainDefaultsUsage+", and exit without applying")
+	applyCmd.Flags().Bool("best-effort", false, "Skip files that cannot be staged instead of aborting the push")
+	applyCmd.Flags().Bool("fail-fast", false, "Abort the push if any file cannot be staged (default)")
+	applyCmd.Flags().String("visibility", "", "Override spec.scm.project.visibility (private, public or internal)")
+	applyCmd.MarkFlagsMutuallyExclusive("best-effort", "fail-fast")
 	rootCmd.AddCommand(applyCmd)
 
-	scaffoldCmd.Flags().StringP("file", "f", "", "Path to the blueprint YAML file (auto-detects klonekit.yml/klonekit.yaml if not specified)")
+	stateExportCmd.Flags().StringP("output", "o", "", "Write the bundle to this file instead of stdout")
+	stateImportCmd.Flags().String("run-id", "", "Refuse to import a bundle that doesn't belong to this run ID")
+	stateImportCmd.Flags().Bool("force", false, "Replace a local state file that belongs to a different run")
+	stateCmd.AddCommand(stateExportCmd, stateImportCmd)
+	rootCmd.AddCommand(stateCmd)
+
+	scaffoldCmd.Flags().StringP("file", "f", "", fileFlagUsage)
 	scaffoldCmd.Flags().Bool("dry-run", false, "Print files that would be created without actually writing them")
+	scaffoldCmd.Flags().String("dir", "", "Scaffold into this directory instead of spec.scaffold.destination")
+	scaffoldCmd.Flags().Bool("validate", false, "Run the full scaffolding into a temporary directory to check it succeeds, without writing to the destination")
+	scaffoldCmd.Flags().Bool("skip-source-check", false, "Scaffold a source module without Terraform files in its root directory, e.g. one holding only modules in subdirectories")
+	scaffoldCmd.Flags().Bool("force", false, "Scaffold into a destination that already contains files, overwriting them")
+	scaffoldCmd.Flags().Bool("check", false, "Compare the destination with what scaffolding would produce and fail if a scaffolded file was modified or removed, without writing to it")
 	rootCmd.AddCommand(scaffoldCmd)
 
-	scmCmd.Flags().StringP("file", "f", "", "Path to the blueprint YAML file (auto-detects klonekit.yml/klonekit.yaml if not specified)")
+	scmCmd.Flags().StringP("file", "f", "", fileFlagUsage)
+	scmCmd.Flags().Bool("best-effort", false, "Skip files that cannot be staged instead of aborting the push")
+	scmCmd.Flags().Bool("fail-fast", false, "Abort the push if any file cannot be staged (default)")
+	scmCmd.Flags().String("visibility", "", "Override spec.scm.project.visibility (private, public or internal)")
+	scmCmd.MarkFlagsMutuallyExclusive("best-effort", "fail-fast")
 	rootCmd.AddCommand(scmCmd)
 
-	provisionCmd.Flags().StringP("file", "f", "", "Path to the blueprint YAML file (auto-detects klonekit.yml/klonekit.yaml if not specified)")
+	doctorCmd.Flags().StringP("file", "f", "", fileFlagUsage)
+	doctorCmd.Flags().Bool("pull", false, "Also pull the Terraform image to check registry access and authentication")
+	rootCmd.AddCommand(doctorCmd)
+
+	provisionCmd.Flags().StringP("file", "f", "", fileFlagUsage)
 	provisionCmd.Flags().Bool("auto-approve", false, "Automatically approve terraform apply without prompting")
+	provisionCmd.Flags().Bool("skip-credential-check", false, "Skip verifying cloud credentials before provisioning (e.g. when offline)")
+	provisionCmd.Flags().Bool("parallel", false, "Pull the Terraform image concurrently with the local setup work")
+	provisionCmd.Flags().String("plan-json", "", "Write the plan as JSON (terraform show -json) to this file before apply, for policy tools such as OPA or Sentinel")
+	provisionCmd.Flags().String("artifacts-dir", "", "Keep each run's plan file, plan output and apply status under <dir>/<run-id> for audit (overrides spec.provision.artifacts.dir)")
+	provisionCmd.Flags().Duration("timeout", 0, "Stop provisioning and remove the Terraform container if it takes longer than this, e.g. 45m; 0 disables the timeout (overrides spec.provision.timeout)")
+	provisionCmd.Flags().Bool("skip-apply-confirmation", false, "Apply a blueprint with spec.provision.confirmApply without typing the project name, for non-interactive runs")
+	provisionCmd.Flags().Bool("use-saved-plan", false, "With --auto-approve, apply the plan saved by 'klonekit plan' instead of planning again, when present")
 	rootCmd.AddCommand(provisionCmd)
+
+	planCmd.Flags().StringP("file", "f", "", fileFlagUsage)
+	planCmd.Flags().Bool("skip-credential-check", false, "Skip verifying cloud credentials before planning (e.g. when offline)")
+	planCmd.Flags().Bool("parallel", false, "Pull the Terraform image concurrently with the local setup work")
+	planCmd.Flags().Duration("timeout", 0, "Stop provisioning and remove the Terraform container if it takes longer than this, e.g. 45m; 0 disables the timeout (overrides spec.provision.timeout)")
+	rootCmd.AddCommand(planCmd)
+
+	execCmd.Flags().StringP("file", "f", "", fileFlagUsage)
+	execCmd.Flags().Bool("allow-mutating", false, "Allow subcommands that modify infrastructure or state (e.g. apply, destroy, state rm)")
+	rootCmd.AddCommand(execCmd)
+
+	statusCmd.Flags().Bool("json", false, "Output the status as JSON")
+	rootCmd.AddCommand(statusCmd)
+
+	explainCmd.Flags().Bool("json", false, "Output the guidance as JSON")
+	rootCmd.AddCommand(explainCmd)
+
+	renderCmd.Flags().StringP("file", "f", "", fileFlagUsage)
+	renderCmd.Flags().String("output-format", app.RenderFormatJSON, "Output format: json or yaml")
+	renderCmd.Flags().Bool("explain-defaults", false, explainDefaultsUsage+" instead of the blueprint")
+	rootCmd.AddCommand(renderCmd)
+
+	validateCmd.Flags().StringP("file", "f", "", fileFlagUsage)
+	validateCmd.Flags().Bool("explain-defaults", false, explainDefaultsUsage)
+	rootCmd.AddCommand(validateCmd)
+
+	rootCmd.AddCommand(schemaCmd)
+
+	abortCmd.Flags().Bool("rollback", false, "Remove the scaffolded files of the interrupted run")
+	abortCmd.Flags().BoolP("yes", "y", false, "Skip the confirmation prompt")
+	abortCmd.Flags().Bool("force", false, "Remove the lock even if the process holding it is still running")
+	rootCmd.AddCommand(abortCmd)
 }
 
 func main() {
@@ -0,0 +1,181 @@
+package app
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// AbortOptions controls how an interrupted apply run is cleaned up.
+type AbortOptions struct {
+	// Rollback removes local side effects of the interrupted run (the scaffolded files)
+	Rollback bool
+	// Yes skips the confirmation prompt
+	Yes bool
+	// Force removes the lock even when it is held by a process that is still running
+	Force bool
+	// Input is read for the confirmation answer (defaults to os.Stdin)
+	Input io.Reader
+}
+
+// Abort cancels an interrupted apply run by removing the lock and state files,
+// optionally rolling back the last stage, leaving a clean slate for the next run.
+func Abort(opts AbortOptions) error {
+	state, err := loadState()
+	if err != nil {
+		return fmt.Errorf("failed to load execution state: %w", err)
+	}
+
+	_, lockErr := os.Stat(LockFileName)
+	hasLock := lockErr == nil
+
+	if state == nil && !hasLock {
+		fmt.Println("No interrupted run found - nothing to abort.")
+		return nil
+	}
+
+	if hasLock && !opts.Force {
+		hostname, _ := os.Hostname()
+		if holder := readLock(LockFileName); holder.running(hostname) {
+			return fmt.Errorf("%w: %s is held by %s, which is still running; wait for it to finish, or pass --force if it is not a klonekit run",
+				ErrRunLocked, LockFileName, holder.describe())
+		}
+	}
+
+	fmt.Printf("%s⚠️  Aborting KloneKit run%s\n", ColorYellow, ColorReset)
+	if state != nil {
+		lastStage := state.LastCompletedStage
+		if lastStage == "" {
+			lastStage = "none"
+		}
+		fmt.Printf("   Run ID: %s\n", state.RunID)
+		fmt.Printf("   Last completed stage: %s\n", lastStage)
+		fmt.Printf("   This will remove the state file: %s\n", StateFileName)
+	}
+	if hasLock {
+		fmt.Printf("   This will remove the lock file: %s\n", LockFileName)
+	}
+	if opts.Rollback && state != nil {
+		fmt.Println("   This will roll back the local changes of the interrupted run")
+	}
+
+	if !opts.Yes {
+		input := opts.Input
+		if input == nil {
+			input = os.Stdin
+		}
+		confirmed, err := confirm(input, "Proceed? [y/N]: ")
+		if err != nil {
+			return fmt.Errorf("failed to read confirmation: %w", err)
+		}
+		if !confirmed {
+			fmt.Println("Abort cancelled.")
+			return nil
+		}
+	}
+
+	if opts.Rollback && state != nil {
+		if err := rollbackLastStage(state); err != nil {
+			return fmt.Errorf("rollback failed: %w", err)
+		}
+	}
+
+	if err := removeStateFile(); err != nil {
+		return err
+	}
+	if err := removeLockFile(); err != nil {
+		return err
+	}
+
+	slog.Info("KloneKit run aborted", "rollback", opts.Rollback)
+	fmt.Printf("%s✅ Run aborted. The next apply will start from a clean slate.%s\n", ColorGreen, ColorReset)
+	return nil
+}
+
+// rollbackLastStage undoes the local side effects of an interrupted run.
+// Only scaffolding is rolled back; remote repositories and provisioned infrastructure are left untouched.
+func rollbackLastStage(state *ExecutionState) error {
+	switch state.LastCompletedStage {
+	case "", "scaffold":
+		destination, err := scaffoldDestination(state)
+		if err != nil {
+			return err
+		}
+		return removeScaffoldedFiles(destination, state)
+	default:
+		fmt.Printf("%s⚠️  Stage '%s' created remote resources that are not rolled back automatically%s\n",
+			ColorYellow, state.LastCompletedStage, ColorReset)
+		return nil
+	}
+}
+
+// removeScaffoldedFiles removes what the run scaffolded: the whole destination when the run
+// created it, otherwise only the files it added. Files the run overwrote in a destination that
+// already existed are left as they are. The working directory and its ancestors are refused.
+func removeScaffoldedFiles(destination string, state *ExecutionState) error {
+	absDest, err := filepath.Abs(destination)
+	if err != nil {
+		return fmt.Errorf("failed to resolve scaffold destination: %w", err)
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("failed to determine current directory: %w", err)
+	}
+	if rel, err := filepath.Rel(absDest, cwd); err == nil && filepath.IsLocal(rel) {
+		return fmt.Errorf("refusing to roll back scaffold destination %s: it contains the working directory", absDest)
+	}
+
+	if state.CreatedDestination {
+		if err := os.RemoveAll(absDest); err != nil {
+			return fmt.Errorf("failed to remove scaffold destination: %w", err)
+		}
+		fmt.Printf("🗑️  Removed scaffolded files: %s\n", destination)
+		return nil
+	}
+
+	if len(state.ScaffoldedFiles) == 0 {
+		fmt.Printf("%s⚠️  Scaffold destination %s existed before the run and no scaffolded files were recorded; it is left untouched%s\n",
+			ColorYellow, destination, ColorReset)
+		return nil
+	}
+	for _, file := range state.ScaffoldedFiles {
+		if !filepath.IsLocal(filepath.FromSlash(file)) {
+			return fmt.Errorf("refusing to remove scaffolded file outside the destination: %s", file)
+		}
+		path := filepath.Join(absDest, filepath.FromSlash(file))
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove scaffolded file: %w", err)
+		}
+		// Remove the directories the file was the last entry of, up to the destination
+		for dir := filepath.Dir(path); dir != absDest; dir = filepath.Dir(dir) {
+			if os.Remove(dir) != nil {
+				break
+			}
+		}
+	}
+	fmt.Printf("🗑️  Removed %d scaffolded files from: %s\n", len(state.ScaffoldedFiles), destination)
+	return nil
+}
+
+// removeLockFile removes the lock file if it exists.
+func removeLockFile() error {
+	if err := os.Remove(LockFileName); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove lock file: %w", err)
+	}
+	return nil
+}
+
+// confirm prints the prompt and reports whether the user answered yes.
+func confirm(input io.Reader, prompt string) (bool, error) {
+	fmt.Print(prompt)
+	answer, err := bufio.NewReader(input).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return false, err
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes", nil
+}
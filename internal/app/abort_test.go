@@ -0,0 +1,302 @@
+package app
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"klonekit/internal/parser"
+)
+
+// seedInterruptedRun writes a mid-run state file and lock file for the given blueprint
+func seedInterruptedRun(t *testing.T, blueprintFile, lastCompletedStage string) {
+	t.Helper()
+
+	state := newState(blueprintFile, "test-abort-run")
+	state.LastCompletedStage = lastCompletedStage
+	state.LastSuccessfulStage = ExecutionStage(lastCompletedStage)
+	state.CreatedDestination = true
+	if err := saveState(state); err != nil {
+		t.Fatalf("Failed to seed state file: %s", err)
+	}
+	if err := os.WriteFile(LockFileName, []byte(time.Now().String()), 0600); err != nil {
+		t.Fatalf("Failed to seed lock file: %s", err)
+	}
+}
+
+func TestAbort_ClearsLockAndState(t *testing.T) {
+	os.Remove(StateFileName)
+	os.Remove(LockFileName)
+	defer os.Remove(StateFileName)
+	defer os.Remove(LockFileName)
+
+	tempDir := t.TempDir()
+	blueprintFile, err := createValidTestBlueprint(tempDir)
+	if err != nil {
+		t.Fatalf("Failed to create test blueprint: %s", err)
+	}
+
+	seedInterruptedRun(t, blueprintFile, "scm")
+
+	if err := Abort(AbortOptions{Input: strings.NewReader("y\n")}); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	if _, err := os.Stat(StateFileName); !os.IsNotExist(err) {
+		t.Error("Expected state file to be removed")
+	}
+	if _, err := os.Stat(LockFileName); !os.IsNotExist(err) {
+		t.Error("Expected lock file to be removed")
+	}
+}
+
+func TestAbort_DeclinedConfirmation(t *testing.T) {
+	os.Remove(StateFileName)
+	os.Remove(LockFileName)
+	defer os.Remove(StateFileName)
+	defer os.Remove(LockFileName)
+
+	tempDir := t.TempDir()
+	blueprintFile, err := createValidTestBlueprint(tempDir)
+	if err != nil {
+		t.Fatalf("Failed to create test blueprint: %s", err)
+	}
+
+	seedInterruptedRun(t, blueprintFile, "scaffold")
+
+	if err := Abort(AbortOptions{Input: strings.NewReader("n\n")}); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	if _, err := os.Stat(StateFileName); err != nil {
+		t.Error("Expected state file to be kept when abort is declined")
+	}
+	if _, err := os.Stat(LockFileName); err != nil {
+		t.Error("Expected lock file to be kept when abort is declined")
+	}
+}
+
+func TestAbort_RollbackScaffold(t *testing.T) {
+	os.Remove(StateFileName)
+	os.Remove(LockFileName)
+	defer os.Remove(StateFileName)
+	defer os.Remove(LockFileName)
+
+	tempDir := t.TempDir()
+	blueprintFile, err := createValidTestBlueprint(tempDir)
+	if err != nil {
+		t.Fatalf("Failed to create test blueprint: %s", err)
+	}
+
+	// Simulate scaffolded output from the interrupted run
+	destDir := filepath.Join(tempDir, "destination")
+	if err := os.MkdirAll(destDir, 0755); err != nil {
+		t.Fatalf("Failed to create destination directory: %s", err)
+	}
+	if err := os.WriteFile(filepath.Join(destDir, "main.tf"), []byte("# scaffolded"), 0644); err != nil {
+		t.Fatalf("Failed to create scaffolded file: %s", err)
+	}
+
+	seedInterruptedRun(t, blueprintFile, "scaffold")
+
+	if err := Abort(AbortOptions{Rollback: true, Yes: true}); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	if _, err := os.Stat(destDir); !os.IsNotExist(err) {
+		t.Error("Expected scaffold destination to be removed on rollback")
+	}
+	if _, err := os.Stat(StateFileName); !os.IsNotExist(err) {
+		t.Error("Expected state file to be removed")
+	}
+	if _, err := os.Stat(LockFileName); !os.IsNotExist(err) {
+		t.Error("Expected lock file to be removed")
+	}
+}
+
+func TestAbort_RollbackRecordedDestination(t *testing.T) {
+	os.Remove(StateFileName)
+	os.Remove(LockFileName)
+	defer os.Remove(StateFileName)
+	defer os.Remove(LockFileName)
+
+	tempDir := t.TempDir()
+	blueprintFile, err := createValidTestBlueprint(tempDir)
+	if err != nil {
+		t.Fatalf("Failed to create test blueprint: %s", err)
+	}
+
+	// The interrupted run scaffolded to a --dir override rather than the blueprint's destination
+	blueprintDest := filepath.Join(tempDir, "destination")
+	overrideDest := filepath.Join(tempDir, "override")
+	for _, dir := range []string{blueprintDest, overrideDest} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	state := newState(blueprintFile, "test-abort-run")
+	state.LastCompletedStage = "scaffold"
+	state.ScaffoldDestination = overrideDest
+	state.CreatedDestination = true
+	if err := saveState(state); err != nil {
+		t.Fatalf("Failed to seed state file: %s", err)
+	}
+
+	if err := Abort(AbortOptions{Rollback: true, Yes: true}); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	if _, err := os.Stat(overrideDest); !os.IsNotExist(err) {
+		t.Error("Expected the recorded destination to be removed on rollback")
+	}
+	if _, err := os.Stat(blueprintDest); err != nil {
+		t.Error("Expected the blueprint's own destination to be left alone")
+	}
+}
+
+func TestAbort_RollbackMultiDocumentBlueprint(t *testing.T) {
+	os.Remove(StateFileName)
+	os.Remove(LockFileName)
+	defer os.Remove(StateFileName)
+	defer os.Remove(LockFileName)
+
+	tempDir := t.TempDir()
+	blueprintFile, err := createValidTestBlueprint(tempDir)
+	if err != nil {
+		t.Fatalf("Failed to create test blueprint: %s", err)
+	}
+	content, err := os.ReadFile(blueprintFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second := strings.Replace(string(content), filepath.Join(tempDir, "destination"), filepath.Join(tempDir, "second"), 1)
+	if err := os.WriteFile(blueprintFile, []byte(string(content)+"\n---\n"+second), 0644); err != nil {
+		t.Fatal(err)
+	}
+	secondDest := filepath.Join(tempDir, "second")
+	if err := os.MkdirAll(secondDest, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	// A state written before destinations were recorded, interrupted in the second blueprint
+	state := newState(blueprintFile, "test-abort-run")
+	state.BlueprintIndex = 1
+	state.CreatedDestination = true
+	if err := saveState(state); err != nil {
+		t.Fatalf("Failed to seed state file: %s", err)
+	}
+
+	if err := Abort(AbortOptions{Rollback: true, Yes: true}); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if _, err := os.Stat(secondDest); !os.IsNotExist(err) {
+		t.Error("Expected the second blueprint's destination to be removed on rollback")
+	}
+}
+
+func TestAbort_RollbackExistingDestination(t *testing.T) {
+	tempDir := chdirTemp(t)
+	blueprintFile, err := createValidTestBlueprint(tempDir)
+	if err != nil {
+		t.Fatalf("Failed to create test blueprint: %s", err)
+	}
+	bp, err := parser.Parse(blueprintFile)
+	if err != nil {
+		t.Fatalf("Failed to parse blueprint: %s", err)
+	}
+
+	// The destination is an existing repository the run scaffolds into with --force
+	destDir := bp.Spec.Scaffold.Destination
+	for path, content := range map[string]string{"README.md": "# mine", ".git/HEAD": "ref: refs/heads/main"} {
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(destDir, path)), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(destDir, path), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	state := newState(blueprintFile, "test-abort-run")
+	state.ScaffoldDestination = destDir
+	if err := NewScaffoldStage(bp, false, true).Execute(context.Background(), state); err != nil {
+		t.Fatalf("Scaffolding failed: %s", err)
+	}
+	if state.CreatedDestination {
+		t.Error("Expected the existing destination not to be recorded as created")
+	}
+	state.LastCompletedStage = "scaffold"
+	if err := saveState(state); err != nil {
+		t.Fatalf("Failed to save state: %s", err)
+	}
+
+	if err := Abort(AbortOptions{Rollback: true, Yes: true}); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(destDir, "main.tf")); !os.IsNotExist(err) {
+		t.Error("Expected the scaffolded file to be removed on rollback")
+	}
+	for _, path := range []string{"README.md", ".git/HEAD"} {
+		if _, err := os.Stat(filepath.Join(destDir, path)); err != nil {
+			t.Errorf("Expected %s, which existed before the run, to be kept: %v", path, err)
+		}
+	}
+}
+
+func TestAbort_RollbackRefusesWorkingDirectoryAncestor(t *testing.T) {
+	tempDir := chdirTemp(t)
+	workDir := filepath.Join(tempDir, "work")
+	if err := os.MkdirAll(workDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(workDir); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, destination := range []string{".", "..", "/"} {
+		state := newState("klonekit.yaml", "test-abort-run")
+		state.ScaffoldDestination = destination
+		state.CreatedDestination = true
+		err := rollbackLastStage(state)
+		if err == nil || !strings.Contains(err.Error(), "refusing") {
+			t.Errorf("Expected rolling back %q to be refused, got: %v", destination, err)
+		}
+	}
+	if _, err := os.Stat(workDir); err != nil {
+		t.Errorf("Expected the working directory to be kept: %v", err)
+	}
+}
+
+func TestAbort_KeepsLockOfRunningProcess(t *testing.T) {
+	chdirTemp(t)
+	hostname, _ := os.Hostname()
+	writeTestLock(t, runLock{PID: os.Getpid(), Hostname: hostname, CreatedAt: time.Now()})
+
+	err := Abort(AbortOptions{Yes: true})
+	if err == nil || !strings.Contains(err.Error(), "still running") {
+		t.Fatalf("Expected abort to refuse a lock held by a running process, got: %v", err)
+	}
+	if _, err := os.Stat(LockFileName); err != nil {
+		t.Error("Expected the lock of the running process to be kept")
+	}
+
+	if err := Abort(AbortOptions{Yes: true, Force: true}); err != nil {
+		t.Fatalf("Unexpected error with --force: %s", err)
+	}
+	if _, err := os.Stat(LockFileName); !os.IsNotExist(err) {
+		t.Error("Expected --force to remove the lock")
+	}
+}
+
+func TestAbort_NothingToAbort(t *testing.T) {
+	os.Remove(StateFileName)
+	os.Remove(LockFileName)
+
+	if err := Abort(AbortOptions{Yes: true}); err != nil {
+		t.Errorf("Expected no error when there is nothing to abort, got: %s", err)
+	}
+}
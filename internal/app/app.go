@@ -3,15 +3,24 @@ package app
 import (
 	"context"
 	"fmt"
+	"io"
 	"log/slog"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"klonekit/internal/parser"
+	"klonekit/internal/scaffolder"
+	"klonekit/internal/scm"
 	"klonekit/pkg/blueprint"
 )
 
-const (
-	// Color codes for console output
+var (
+	// Color codes for console output; empty once colors are disabled with SetColorsEnabled
 	ColorReset  = "\033[0m"
 	ColorRed    = "\033[31m"
 	ColorGreen  = "\033[32m"
@@ -22,31 +31,173 @@ const (
 	ColorWhite  = "\033[37m"
 )
 
+// SetColorsEnabled switches the color codes of the console output on or off, following the
+// --color mode.
+func SetColorsEnabled(enabled bool) {
+	if !enabled {
+		ColorReset, ColorRed, ColorGreen, ColorYellow = "", "", "", ""
+		ColorBlue, ColorPurple, ColorCyan, ColorWhite = "", "", "", ""
+		return
+	}
+	ColorReset, ColorRed, ColorGreen, ColorYellow = "\033[0m", "\033[31m", "\033[32m", "\033[33m"
+	ColorBlue, ColorPurple, ColorCyan, ColorWhite = "\033[34m", "\033[35m", "\033[36m", "\033[37m"
+}
+
+// ApplyOptions holds the settings that control an apply run.
+type ApplyOptions struct {
+	DryRun      bool
+	RetainState bool
+	AutoApprove bool
+
+	// Staging overrides spec.scm.staging when set ("strict" or "best-effort").
+	Staging string
+
+	// SkipCredentialCheck disables the credential check made before provisioning.
+	SkipCredentialCheck bool
+
+	// SkipSourceCheck disables the check that the scaffold source contains Terraform files.
+	SkipSourceCheck bool
+
+	// Parallel overlaps the Terraform image pull with the local setup work.
+	Parallel bool
+
+	// PlanJSON overrides spec.provision.planJSON when set.
+	PlanJSON string
+
+	// ArtifactsDir overrides spec.provision.artifacts.dir when set.
+	ArtifactsDir string
+
+	// Timeout overrides spec.provision.timeout when set; a zero timeout disables it.
+	Timeout *time.Duration
+
+	// SkipStages lists stages (scaffold, scm, provision) to exclude from the run.
+	SkipStages []string
+
+	// TracePath, when set, is the file the run's trace spans are written to.
+	TracePath string
+
+	// ScaffoldDir overrides spec.scaffold.destination when set. The SCM and provision
+	// stages use the same directory, since they operate on the scaffolded files.
+	ScaffoldDir string
+
+	// Visibility overrides spec.scm.project.visibility when set ("private", "public" or "internal").
+	Visibility string
+
+	// PlanReal modifies a dry run so the provision stage runs a real terraform init and
+	// plan against a temporary scaffold, while scaffold and SCM stay simulated.
+	PlanReal bool
+	// CheckDocker makes a dry run check that the Docker daemon the provision stage needs is
+	// reachable, warning when it isn't.
+	CheckDocker bool
+
+	// BundleOnFailure writes the log file, the execution and Terraform state, the state
+	// backups and the captured output to klonekit-failure-<runid>.tar.gz when the run fails.
+	BundleOnFailure bool
+	// ForceResume resumes a run even though the blueprint changed since the run started.
+	ForceResume bool
+	// Force scaffolds into a destination that already contains files, overwriting them.
+	Force bool
+
+	// SkipApplyConfirmation applies blueprints with spec.provision.confirmApply without
+	// asking for the project name, for non-interactive runs.
+	SkipApplyConfirmation bool
+	// KeepGoing continues with the next blueprint of a multi-document file when one fails,
+	// and reports the failures together once every blueprint was attempted.
+	KeepGoing bool
+	// Input is read for the apply confirmation (defaults to os.Stdin)
+	Input io.Reader
+}
+
 // Apply orchestrates the complete KloneKit workflow using a dynamic stage runner.
 // This function implements the Facade pattern over all internal components with resume capability.
 func Apply(blueprintPath string, isDryRun bool, retainState bool, autoApprove bool) error {
+	return ApplyWithOptions(blueprintPath, ApplyOptions{
+		DryRun:      isDryRun,
+		RetainState: retainState,
+		AutoApprove: autoApprove,
+	})
+}
+
+// ApplyWithOptions runs the apply workflow with the full set of options.
+func ApplyWithOptions(blueprintPath string, opts ApplyOptions) (retErr error) {
+	isDryRun := opts.DryRun
+	retainState := opts.RetainState
+	autoApprove := opts.AutoApprove
+
+	// Capture the run's output so a failure can be bundled with --bundle-on-failure
+	bundle := newFailureBundle(opts.BundleOnFailure)
+	defer func() { bundle.finish(retErr) }()
+
 	slog.Info("Starting KloneKit apply workflow", "blueprintPath", blueprintPath, "dryRun", isDryRun)
 
+	// Trace the whole run under a root span when --trace is given
+	tracer := newTracer(opts.TracePath)
+	ctx, rootSpan := tracer.startSpan(context.Background(), "klonekit.apply", map[string]string{
+		"klonekit.blueprint_path": blueprintPath,
+		"klonekit.dry_run":        fmt.Sprintf("%t", isDryRun),
+	})
+	defer func() {
+		rootSpan.end(retErr)
+		if err := tracer.flush(); err != nil {
+			slog.Warn("Failed to write trace file", "error", err)
+		} else if tracer != nil {
+			slog.Info("Trace written", "file", opts.TracePath)
+		}
+	}()
+
+	if err := validateSkipStages(opts.SkipStages); err != nil {
+		return err
+	}
+	if opts.PlanReal && !isDryRun {
+		return fmt.Errorf("--plan-real can only be used with --dry-run")
+	}
+	if opts.CheckDocker && !isDryRun {
+		return fmt.Errorf("--check-docker can only be used with --dry-run")
+	}
+	if opts.Visibility != "" {
+		if err := scm.ValidateVisibility(opts.Visibility); err != nil {
+			return err
+		}
+	}
+
+	// Guard the state file against a concurrent apply run in the same directory
+	releaseLock, err := acquireLock()
+	if err != nil {
+		return err
+	}
+	defer releaseLock()
+
 	// Load existing state or create new state
 	state, err := loadState()
 	if err != nil {
 		return fmt.Errorf("failed to load execution state: %w", err)
 	}
 
+	blueprintHash, err := hashBlueprint(blueprintPath)
+	if err != nil {
+		return fmt.Errorf("blueprint parsing failed: %w", err)
+	}
+
 	var isResume bool
 	if state == nil {
 		// Fresh start - create new state
 		runID := uuid.New().String()
 		state = newState(blueprintPath, runID)
+		state.BlueprintHash = blueprintHash
+		state.Overrides = runOverrides(opts)
 		slog.Info("Starting new KloneKit workflow", "runId", runID, "blueprintPath", blueprintPath)
 	} else {
-		// Resume existing run
+		// Resume existing run, unless the blueprint changed so its remaining stages may no longer match
+		if err := checkBlueprintUnchanged(state, blueprintPath, blueprintHash, runOverrides(opts), opts.ForceResume); err != nil {
+			return err
+		}
 		isResume = true
 		nextStage := state.getNextStage()
 		fmt.Printf("%s📋 State file found. Resuming from stage: %s%s\n", ColorYellow, nextStage, ColorReset)
 		slog.Info("Resuming KloneKit workflow", "runId", state.RunID, "nextStage", nextStage, "lastStage", state.LastSuccessfulStage)
 		fmt.Println()
 	}
+	bundle.setRunID(state.RunID)
 
 	if isDryRun {
 		fmt.Printf("%s🔍 DRY RUN MODE - No actual changes will be made%s\n", ColorYellow, ColorReset)
@@ -56,22 +207,50 @@ func Apply(blueprintPath string, isDryRun bool, retainState bool, autoApprove bo
 		fmt.Println()
 	}
 
-	// Parse blueprint (needed for all stages)
-	blueprint, err := parser.Parse(blueprintPath)
+	// Parse blueprints (needed for all stages). A multi-document file yields one blueprint per document.
+	blueprints, err := parser.ParseAll(blueprintPath)
 	if err != nil {
 		return fmt.Errorf("blueprint parsing failed: %w", err)
 	}
-	slog.Info("Blueprint parsed successfully", "name", blueprint.Metadata.Name, "kind", blueprint.Kind)
+	if opts.ScaffoldDir != "" && len(blueprints) > 1 {
+		return fmt.Errorf("--dir cannot be used with a file containing %d blueprints, as they would share one destination", len(blueprints))
+	}
+	for _, bp := range blueprints {
+		slog.Info("Blueprint parsed successfully", "name", bp.Metadata.Name, "kind", bp.Kind)
+		applyOverrides(bp, opts)
+		if err := checkProvisionable(bp, opts.SkipStages); err != nil {
+			return err
+		}
+		if err := scaffolder.DecryptVariables(&bp.Spec); err != nil {
+			return fmt.Errorf("failed to decrypt variables of blueprint '%s': %w", bp.Metadata.Name, err)
+		}
+		logEffectiveConfig(bp, opts)
+		bundle.addScaffoldDir(bp.Spec.Scaffold.Destination)
+	}
 
-	// Build the stages slice
-	providerFactory := NewProviderFactory()
-	stages := buildStages(blueprint, providerFactory, isDryRun, autoApprove)
+	// Production blueprints are confirmed before anything is changed
+	if err := confirmProductionApplies(blueprints, state, opts); err != nil {
+		return err
+	}
 
-	// Execute stages using the dynamic stage runner
-	ctx := context.Background()
-	if err := runStages(ctx, stages, state, isDryRun); err != nil {
-		return fmt.Errorf("stage execution failed: %w", err)
+	// Execute each blueprint's stages in order using the dynamic stage runner
+	providerFactory := NewProviderFactory()
+	stagesFor := func(bp *blueprint.Blueprint) []Stage {
+		return buildStages(bp, providerFactory, isDryRun, autoApprove, opts.PlanReal, opts.CheckDocker, opts.Force)
+	}
+	results, err := runBlueprints(ctx, blueprints, state, opts, stagesFor)
+	if err != nil {
+		return err
 	}
+	if opts.KeepGoing && len(blueprints) > 1 {
+		fmt.Println()
+		printBatchSummary(os.Stdout, results)
+		fmt.Println()
+		if batchErr := newBatchError(results); batchErr != nil {
+			return batchErr
+		}
+	}
+	blueprint := blueprints[len(blueprints)-1]
 
 	// Mark workflow as completed and clean up state file
 	state.LastSuccessfulStage = StageCompleted
@@ -98,43 +277,232 @@ func Apply(blueprintPath string, isDryRun bool, retainState bool, autoApprove bo
 		fmt.Printf("%sNo actual resources were created or modified.%s\n", ColorYellow, ColorReset)
 	} else {
 		fmt.Printf("%s🎉 KLONEKIT APPLY COMPLETED SUCCESSFULLY!%s\n", ColorGreen, ColorReset)
-		fmt.Printf("%s✨ Your infrastructure project '%s' is ready!%s\n", ColorWhite, blueprint.Metadata.Name, ColorReset)
+		for _, bp := range blueprints {
+			fmt.Printf("%s✨ Your infrastructure project '%s' is ready!%s\n", ColorWhite, bp.Metadata.Name, ColorReset)
+		}
+	}
+
+	slog.Info("KloneKit apply workflow completed successfully", "blueprintName", blueprint.Metadata.Name, "blueprints", len(blueprints), "dryRun", isDryRun)
+	return nil
+}
+
+// runBlueprints runs the stages of each blueprint an earlier attempt of the run hasn't completed.
+// The first failing blueprint ends the run with its error, unless opts.KeepGoing is set: then the
+// failure is recorded in the results and the run continues with the next blueprint. The outcome
+// of each blueprint is recorded in the state, which is left at the first failed blueprint, so
+// resuming retries the failed blueprints and skips the ones that succeeded.
+func runBlueprints(ctx context.Context, blueprints []*blueprint.Blueprint, state *ExecutionState, opts ApplyOptions, stagesFor func(*blueprint.Blueprint) []Stage) ([]BlueprintResult, error) {
+	isDryRun := opts.DryRun
+	results := make([]BlueprintResult, 0, len(blueprints))
+	firstFailed := -1
+	for i, bp := range blueprints {
+		if state.blueprintCompleted(i) {
+			fmt.Printf("%s⏭️  Blueprint %d/%d: %s (skipped - already completed)%s\n", ColorGreen, i+1, len(blueprints), bp.Metadata.Name, ColorReset)
+			results = append(results, BlueprintResult{Name: bp.Metadata.Name, Completed: true})
+			continue
+		}
+		if len(blueprints) > 1 {
+			fmt.Printf("%s📘 Blueprint %d/%d: %s%s\n", ColorBlue, i+1, len(blueprints), bp.Metadata.Name, ColorReset)
+			fmt.Println()
+		}
+		// A resumed run may retry an earlier failed blueprint than the one the state was at
+		if i != state.BlueprintIndex {
+			state.resetBlueprintProgress(i)
+		}
+
+		// Record where the files go before scaffolding, so abort --rollback removes them from there
+		state.ScaffoldDestination = bp.Spec.Scaffold.Destination
+		if !isDryRun {
+			if err := saveState(state); err != nil {
+				return results, fmt.Errorf("failed to save state for blueprint '%s': %w", bp.Metadata.Name, err)
+			}
+		}
+		stages := stagesFor(bp)
+		bpCtx, bpSpan := startChildSpan(ctx, "klonekit.blueprint", map[string]string{
+			"klonekit.blueprint": bp.Metadata.Name,
+			"cloud.provider":     bp.Spec.Cloud.Provider,
+			"cloud.region":       bp.Spec.Cloud.Region,
+			"scm.provider":       bp.Spec.SCM.Provider,
+		})
+		// Pull the Terraform image while the earlier stages run; a pull still running when
+		// the stages end is cancelled
+		prePullCtx, cancelPrePull := context.WithCancel(bpCtx)
+		startPrePull(prePullCtx, stages, state, isDryRun, opts.SkipStages)
+		err := runStages(bpCtx, stages, state, isDryRun, opts.SkipStages)
+		cancelPrePull()
+		bpSpan.end(err)
+		if err != nil {
+			if len(blueprints) == 1 {
+				return results, fmt.Errorf("stage execution failed: %w", err)
+			}
+			err = fmt.Errorf("stage execution failed for blueprint '%s': %w", bp.Metadata.Name, err)
+			if !opts.KeepGoing {
+				return results, err
+			}
+			fmt.Printf("%s❌ Blueprint %d/%d: %s failed, continuing with the next blueprint (--keep-going)%s\n", ColorRed, i+1, len(blueprints), bp.Metadata.Name, ColorReset)
+			fmt.Println()
+			slog.Error("Blueprint failed, continuing", "blueprint", bp.Metadata.Name, "error", err)
+			if firstFailed < 0 {
+				firstFailed = i
+			}
+		}
+		results = append(results, BlueprintResult{Name: bp.Metadata.Name, Err: err})
+		status := BlueprintStatusSucceeded
+		if err != nil {
+			status = BlueprintStatusFailed
+		}
+		state.recordBlueprintResult(i, status)
+
+		// Move on to the next blueprint with fresh stage progress
+		if i < len(blueprints)-1 {
+			state.resetBlueprintProgress(i + 1)
+		}
+		if !isDryRun {
+			if err := saveState(state); err != nil {
+				return results, fmt.Errorf("failed to save state after blueprint '%s': %w", bp.Metadata.Name, err)
+			}
+		}
+	}
+
+	if firstFailed >= 0 && !isDryRun {
+		state.resetBlueprintProgress(firstFailed)
+		state.ScaffoldDestination = blueprints[firstFailed].Spec.Scaffold.Destination
+		if err := saveState(state); err != nil {
+			return results, fmt.Errorf("failed to save state at failed blueprint '%s': %w", blueprints[firstFailed].Metadata.Name, err)
+		}
+	}
+	return results, nil
+}
+
+// checkBlueprintUnchanged guards a resume against an effective blueprint that differs from the
+// one the run started with: the blueprint file was edited, e.g. with a different scaffold
+// destination, another blueprint file was given, or the command-line overrides changed. With
+// force the change is logged as a warning and the state adopts the current blueprint. States
+// written before the hash was recorded resume as before.
+func checkBlueprintUnchanged(state *ExecutionState, blueprintPath, blueprintHash string, overrides *RunOverrides, force bool) error {
+	if state.BlueprintHash == "" {
+		return nil
 	}
 
-	slog.Info("KloneKit apply workflow completed successfully", "blueprintName", blueprint.Metadata.Name, "dryRun", isDryRun)
+	var changes []string
+	if !samePath(state.BlueprintPath, blueprintPath) {
+		changes = append(changes, fmt.Sprintf("blueprint file %s instead of %s", blueprintPath, state.BlueprintPath))
+	} else if state.BlueprintHash != blueprintHash {
+		changes = append(changes, "blueprint file edited")
+	}
+	if !reflect.DeepEqual(state.Overrides, overrides) {
+		changes = append(changes, "different --dir, --visibility or staging flags")
+	}
+	if len(changes) == 0 {
+		return nil
+	}
+	if !force {
+		return fmt.Errorf("blueprint has changed since run %s started (%s), so its remaining stages may no longer match; use --force-resume to resume anyway or 'klonekit abort' to start over", state.RunID, strings.Join(changes, ", "))
+	}
+
+	fmt.Printf("%s⚠️  Blueprint has changed since this run started; resuming anyway (--force-resume)%s\n", ColorYellow, ColorReset)
+	slog.Warn("Resuming with a changed blueprint", "runId", state.RunID, "changes", changes, "previousHash", state.BlueprintHash, "currentHash", blueprintHash)
+	state.BlueprintPath = blueprintPath
+	state.BlueprintHash = blueprintHash
+	state.Overrides = overrides
 	return nil
 }
 
-// buildStages constructs the slice of stages to be executed based on the blueprint
-func buildStages(blueprint *blueprint.Blueprint, providerFactory *ProviderFactory, isDryRun bool, autoApprove bool) []Stage {
+// runOverrides returns the overrides of opts recorded in the state, or nil when there are none.
+func runOverrides(opts ApplyOptions) *RunOverrides {
+	overrides := RunOverrides{ScaffoldDir: opts.ScaffoldDir, Visibility: opts.Visibility, Staging: opts.Staging}
+	if overrides == (RunOverrides{}) {
+		return nil
+	}
+	return &overrides
+}
+
+// samePath reports whether two paths name the same file, resolving relative paths against the
+// working directory.
+func samePath(a, b string) bool {
+	absA, errA := filepath.Abs(a)
+	absB, errB := filepath.Abs(b)
+	if errA != nil || errB != nil {
+		return filepath.Clean(a) == filepath.Clean(b)
+	}
+	return absA == absB
+}
+
+// applyOverrides applies command-line overrides on top of the parsed blueprint.
+func applyOverrides(bp *blueprint.Blueprint, opts ApplyOptions) {
+	if opts.Staging != "" {
+		bp.Spec.SCM.Staging = opts.Staging
+	}
+	if opts.ScaffoldDir != "" {
+		bp.Spec.Scaffold.Destination = opts.ScaffoldDir
+	}
+	if opts.Visibility != "" {
+		bp.Spec.SCM.Project.Visibility = opts.Visibility
+	}
+	if opts.SkipCredentialCheck {
+		bp.Spec.Provision.SkipCredentialCheck = true
+	}
+	if opts.SkipSourceCheck {
+		bp.Spec.Scaffold.SkipSourceCheck = true
+	}
+	if opts.Parallel {
+		bp.Spec.Provision.Parallel = true
+	}
+	if opts.PlanJSON != "" {
+		bp.Spec.Provision.PlanJSON = opts.PlanJSON
+	}
+	if opts.ArtifactsDir != "" {
+		bp.Spec.Provision.Artifacts.Dir = opts.ArtifactsDir
+	}
+	if opts.Timeout != nil {
+		bp.Spec.Provision.Timeout = opts.Timeout.String()
+	}
+}
+
+// buildStages constructs the slice of stages to be executed based on the blueprint.
+// With planReal a dry run's provision stage runs a real plan instead of a simulation, with
+// checkDocker it checks that Docker is reachable, and with force the scaffold stage overwrites the files already in the destination.
+func buildStages(blueprint *blueprint.Blueprint, providerFactory *ProviderFactory, isDryRun bool, autoApprove bool, planReal bool, checkDocker bool, force bool) []Stage {
+	provisionStage := NewProvisionStage(blueprint, providerFactory, isDryRun, autoApprove)
+	provisionStage.planReal = planReal
+	provisionStage.dockerCheck = checkDocker
+
 	stages := []Stage{
-		NewScaffoldStage(blueprint, isDryRun),
+		NewScaffoldStage(blueprint, isDryRun, force),
 		NewScmStage(blueprint, providerFactory, isDryRun),
-		NewProvisionStage(blueprint, providerFactory, isDryRun, autoApprove),
+		provisionStage,
 	}
 	return stages
 }
 
-// runStages executes the stages in order, skipping those already completed
-func runStages(ctx context.Context, stages []Stage, state *ExecutionState, isDryRun bool) error {
+// runStages executes the stages in order, skipping those already completed, excluded by the
+// user, or whose run condition doesn't hold. The outcome of every stage is recorded on the state.
+func runStages(ctx context.Context, stages []Stage, state *ExecutionState, isDryRun bool, skipStages []string) error {
 	for i, stage := range stages {
 		stageName := stage.Name()
 
 		// Check if this stage should be skipped
-		if shouldSkipStage(state, stageName) {
-			fmt.Printf("%s⏭️  Stage %d: %s (skipped - already completed)%s\n", ColorGreen, i+1, stageName, ColorReset)
+		if skip, ok := stageSkipResult(stage, state, skipStages); ok {
+			state.recordStageResult(skip)
+			fmt.Printf("%s⏭️  Stage %d: %s (skipped - %s)%s\n", ColorGreen, i+1, stageName, describeSkip(skip), ColorReset)
 			fmt.Println()
 			continue
 		}
 
 		// Execute the stage
 		fmt.Printf("%s🔄 Stage %d: %s%s\n", getStageColor(stageName), i+1, stageName, ColorReset)
-		if err := stage.Execute(ctx, state); err != nil {
+		stageCtx, span := startChildSpan(ctx, "klonekit.stage."+stageName, map[string]string{
+			"klonekit.stage": stageName,
+		})
+		err := stage.Execute(stageCtx, state)
+		span.end(err)
+		if err != nil {
 			return fmt.Errorf("stage '%s' failed: %w", stageName, err)
 		}
 
 		// Update state after successful completion
 		state.LastCompletedStage = stageName
+		state.recordStageResult(StageResult{Name: stageName, Status: StageStatusSucceeded})
 		// Update legacy field for backward compatibility
 		switch stageName {
 		case "scaffold":
@@ -155,6 +523,70 @@ func runStages(ctx context.Context, stages []Stage, state *ExecutionState, isDry
 	return nil
 }
 
+// stageSkipResult reports whether a stage should be skipped and records why
+func stageSkipResult(stage Stage, state *ExecutionState, skipStages []string) (StageResult, bool) {
+	stageName := stage.Name()
+	skipped := StageResult{Name: stageName, Status: StageStatusSkipped}
+
+	if shouldSkipStage(state, stageName) {
+		skipped.Reason = SkipReasonCompleted
+		return skipped, true
+	}
+
+	for _, name := range skipStages {
+		if name == stageName {
+			skipped.Reason = SkipReasonUserSkipped
+			return skipped, true
+		}
+	}
+
+	if conditional, ok := stage.(ConditionalStage); ok {
+		if run, detail := conditional.ShouldRun(state); !run {
+			skipped.Reason = SkipReasonConditionFalse
+			skipped.Detail = detail
+			return skipped, true
+		}
+	}
+
+	return StageResult{}, false
+}
+
+// checkProvisionable rejects a blueprint whose only root modules are scaffold.targets unless
+// the provision stage is skipped, since provisioning runs in scaffold.destination alone.
+func checkProvisionable(bp *blueprint.Blueprint, skipStages []string) error {
+	if bp.Spec.Scaffold.Source != "" || slices.Contains(skipStages, string(StageProvision)) {
+		return nil
+	}
+	return fmt.Errorf("blueprint '%s' has no scaffold.source, and the provision stage only runs in scaffold.destination, not in scaffold.targets: add a root module with scaffold.source or pass --skip-stage provision", bp.Metadata.Name)
+}
+
+// validateSkipStages checks that every stage named for skipping exists
+func validateSkipStages(skipStages []string) error {
+	for _, name := range skipStages {
+		switch ExecutionStage(name) {
+		case StageScaffold, StageSCM, StageProvision:
+		default:
+			return fmt.Errorf("unknown stage '%s' to skip (valid stages: scaffold, scm, provision)", name)
+		}
+	}
+	return nil
+}
+
+// describeSkip returns the console description of why a stage was skipped
+func describeSkip(result StageResult) string {
+	switch result.Reason {
+	case SkipReasonCompleted:
+		return "already completed"
+	case SkipReasonUserSkipped:
+		return "excluded with --skip-stage"
+	default:
+		if result.Detail != "" {
+			return result.Detail
+		}
+		return "condition not met"
+	}
+}
+
 // shouldSkipStage determines if a stage should be skipped based on the current state
 func shouldSkipStage(state *ExecutionState, stageName string) bool {
 	if state == nil || state.LastCompletedStage == "" {
@@ -202,7 +634,6 @@ func getStageColor(stageName string) string {
 	}
 }
 
-
 // ValidatePrerequisites checks that all required external dependencies are available.
 func ValidatePrerequisites() error {
 	slog.Info("Validating KloneKit prerequisites")
@@ -1,12 +1,22 @@
 package app
 
 import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
 	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
 	"time"
+
+	"klonekit/internal/errors"
+	"klonekit/pkg/blueprint"
 )
 
 func TestApply_DryRun(t *testing.T) {
@@ -341,6 +351,72 @@ func TestApply_FullWorkflowDryRun(t *testing.T) {
 	}
 }
 
+func TestApply_MultiDocumentDryRun(t *testing.T) {
+	os.Remove(StateFileName)
+	defer os.Remove(StateFileName)
+
+	tempDir := t.TempDir()
+	blueprintFile, err := createValidTestBlueprint(tempDir)
+	if err != nil {
+		t.Fatalf("Failed to create test blueprint: %s", err)
+	}
+
+	// Append a second document targeting another destination
+	content, err := os.ReadFile(blueprintFile)
+	if err != nil {
+		t.Fatalf("Failed to read test blueprint: %s", err)
+	}
+	second := strings.Replace(string(content), "/destination", "/destination-second", 1)
+	second = strings.Replace(second, "name: integration-test\n", "name: integration-test-second\n", 1)
+	if err := os.WriteFile(blueprintFile, []byte(string(content)+"---\n"+second), 0644); err != nil {
+		t.Fatalf("Failed to write multi-document blueprint: %s", err)
+	}
+
+	if err := Apply(blueprintFile, true, false, false); err != nil {
+		t.Errorf("Unexpected error in multi-document dry-run: %s", err)
+	}
+
+	if _, err := os.Stat(StateFileName); !os.IsNotExist(err) {
+		t.Error("State file should not be created in dry-run mode")
+	}
+}
+
+func TestApply_TargetsOnlyBlueprint(t *testing.T) {
+	tempDir := chdirTemp(t)
+	blueprintFile, err := createValidTestBlueprint(tempDir)
+	if err != nil {
+		t.Fatalf("Failed to create test blueprint: %s", err)
+	}
+
+	// Replace the root module with a target
+	content, err := os.ReadFile(blueprintFile)
+	if err != nil {
+		t.Fatalf("Failed to read test blueprint: %s", err)
+	}
+	sourceLine := "    source: " + filepath.Join(tempDir, "source") + "\n"
+	targets := "    targets:\n      - name: network\n        source: " + filepath.Join(tempDir, "source") + "\n        destination: infra/network\n"
+	if !strings.Contains(string(content), sourceLine) {
+		t.Fatalf("Expected the test blueprint to have a scaffold source, got:\n%s", content)
+	}
+	if err := os.WriteFile(blueprintFile, []byte(strings.Replace(string(content), sourceLine, targets, 1)), 0644); err != nil {
+		t.Fatalf("Failed to write targets-only blueprint: %s", err)
+	}
+
+	for _, dryRun := range []bool{false, true} {
+		err := ApplyWithOptions(blueprintFile, ApplyOptions{DryRun: dryRun})
+		if err == nil || !strings.Contains(err.Error(), "--skip-stage provision") {
+			t.Errorf("Expected a targets-only blueprint to be rejected (dry run %t), got: %v", dryRun, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(tempDir, "destination")); !os.IsNotExist(err) {
+		t.Error("Expected nothing to be scaffolded for a rejected blueprint")
+	}
+
+	if err := ApplyWithOptions(blueprintFile, ApplyOptions{DryRun: true, SkipStages: []string{"provision"}}); err != nil {
+		t.Errorf("Expected a targets-only blueprint to apply without the provision stage, got: %s", err)
+	}
+}
+
 func TestApply_StatefulExecution_FailureAfterScaffold(t *testing.T) {
 	// Test that simulates failure after scaffold stage and verifies resume behavior
 	tempDir, err := os.MkdirTemp("", "klonekit-stateful-test-*")
@@ -656,3 +732,437 @@ func TestStateFile_LoadSaveRemove(t *testing.T) {
 		t.Errorf("removeStateFile should not error when file doesn't exist, got: %s", err)
 	}
 }
+
+// failingWriter writes at most limit bytes and then fails, simulating a crash mid-write
+type failingWriter struct {
+	w     io.Writer
+	limit int
+}
+
+func (f *failingWriter) Write(p []byte) (int, error) {
+	if len(p) > f.limit {
+		n, _ := f.w.Write(p[:f.limit])
+		return n, fmt.Errorf("simulated interruption after %d bytes", n)
+	}
+	return f.w.Write(p)
+}
+
+func TestSaveState_InterruptedWriteKeepsPreviousState(t *testing.T) {
+	tempDir := t.TempDir()
+	originalDir, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get current directory: %s", err)
+	}
+	defer func() { _ = os.Chdir(originalDir) }()
+	if err := os.Chdir(tempDir); err != nil {
+		t.Fatalf("Failed to change to temp directory: %s", err)
+	}
+
+	previous := newState("test-blueprint.yaml", "previous-run")
+	previous.LastSuccessfulStage = StageScaffold
+	if err := saveState(previous); err != nil {
+		t.Fatalf("saveState failed: %s", err)
+	}
+
+	originalWrapper := wrapStateWriter
+	defer func() { wrapStateWriter = originalWrapper }()
+	wrapStateWriter = func(w io.Writer) io.Writer { return &failingWriter{w: w, limit: 10} }
+
+	next := newState("test-blueprint.yaml", "next-run")
+	next.LastSuccessfulStage = StageProvision
+	if err := saveState(next); err == nil {
+		t.Fatal("Expected saveState to fail when the write is interrupted")
+	}
+
+	loaded, err := loadState()
+	if err != nil {
+		t.Fatalf("Expected the previous state file to be intact, got: %s", err)
+	}
+	if loaded == nil || loaded.RunID != "previous-run" || loaded.LastSuccessfulStage != StageScaffold {
+		t.Errorf("Expected the previous state to be kept, got: %+v", loaded)
+	}
+
+	info, err := os.Stat(StateFileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("Expected state file mode 0600, got: %o", info.Mode().Perm())
+	}
+
+	entries, err := os.ReadDir(".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range entries {
+		if entry.Name() != StateFileName {
+			t.Errorf("Expected no temporary files to be left behind, found: %s", entry.Name())
+		}
+	}
+}
+
+func TestApply_ScaffoldDirOverride(t *testing.T) {
+	tempDir := t.TempDir()
+
+	originalDir, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get current directory: %s", err)
+	}
+	defer func() { _ = os.Chdir(originalDir) }()
+	if err := os.Chdir(tempDir); err != nil {
+		t.Fatalf("Failed to change to temp directory: %s", err)
+	}
+
+	blueprintFile, err := createValidTestBlueprint(tempDir)
+	if err != nil {
+		t.Fatalf("Failed to create test blueprint: %s", err)
+	}
+	overrideDir := filepath.Join(tempDir, "custom-out")
+
+	err = ApplyWithOptions(blueprintFile, ApplyOptions{
+		ScaffoldDir: overrideDir,
+		SkipStages:  []string{"scm", "provision"},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(overrideDir, "main.tf")); err != nil {
+		t.Errorf("Expected files to be scaffolded into the override directory: %s", err)
+	}
+	if _, err := os.Stat(filepath.Join(tempDir, "destination")); !os.IsNotExist(err) {
+		t.Error("Expected the blueprint destination to be ignored when --dir is set")
+	}
+}
+
+func TestApplyOverrides_ScaffoldDir(t *testing.T) {
+	bp := &blueprint.Blueprint{
+		Spec: blueprint.Spec{
+			Scaffold: blueprint.Scaffold{Destination: "./from-blueprint"},
+		},
+	}
+
+	applyOverrides(bp, ApplyOptions{})
+	if bp.Spec.Scaffold.Destination != "./from-blueprint" {
+		t.Errorf("Expected blueprint destination without override, got %s", bp.Spec.Scaffold.Destination)
+	}
+
+	applyOverrides(bp, ApplyOptions{ScaffoldDir: "./custom-out"})
+	if bp.Spec.Scaffold.Destination != "./custom-out" {
+		t.Errorf("Expected override destination, got %s", bp.Spec.Scaffold.Destination)
+	}
+}
+
+func TestApplyOverrides_Visibility(t *testing.T) {
+	bp := &blueprint.Blueprint{
+		Spec: blueprint.Spec{
+			SCM: blueprint.SCMProvider{Project: blueprint.ProjectConfig{Visibility: "private"}},
+		},
+	}
+
+	applyOverrides(bp, ApplyOptions{})
+	if bp.Spec.SCM.Project.Visibility != "private" {
+		t.Errorf("Expected blueprint visibility without override, got %s", bp.Spec.SCM.Project.Visibility)
+	}
+
+	applyOverrides(bp, ApplyOptions{Visibility: "public"})
+	if bp.Spec.SCM.Project.Visibility != "public" {
+		t.Errorf("Expected override visibility, got %s", bp.Spec.SCM.Project.Visibility)
+	}
+}
+
+func TestApplyOverrides_Timeout(t *testing.T) {
+	bp := &blueprint.Blueprint{
+		Spec: blueprint.Spec{
+			Provision: blueprint.Provision{Timeout: "45m"},
+		},
+	}
+
+	applyOverrides(bp, ApplyOptions{})
+	if bp.Spec.Provision.RunTimeout() != 45*time.Minute {
+		t.Errorf("Expected blueprint timeout without override, got %s", bp.Spec.Provision.Timeout)
+	}
+
+	disabled := time.Duration(0)
+	applyOverrides(bp, ApplyOptions{Timeout: &disabled})
+	if timeout := bp.Spec.Provision.RunTimeout(); timeout != 0 {
+		t.Errorf("Expected a zero timeout to disable the blueprint timeout, got %s", timeout)
+	}
+}
+
+func TestApply_InvalidVisibility(t *testing.T) {
+	os.Remove(StateFileName)
+	defer os.Remove(StateFileName)
+
+	err := ApplyWithOptions("unused.yaml", ApplyOptions{DryRun: true, Visibility: "secret"})
+	if err == nil || !strings.Contains(err.Error(), "invalid visibility") {
+		t.Errorf("Expected an invalid visibility to be rejected, got: %v", err)
+	}
+}
+
+func TestApply_BundleOnFailure(t *testing.T) {
+	tempDir := t.TempDir()
+	originalDir, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get current directory: %s", err)
+	}
+	defer func() { _ = os.Chdir(originalDir) }()
+	if err := os.Chdir(tempDir); err != nil {
+		t.Fatalf("Failed to change to temp directory: %s", err)
+	}
+
+	logDir := filepath.Join(tempDir, "logs")
+	if err := os.MkdirAll(logDir, 0750); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(logDir, errors.LogFileName), []byte(`{"msg":"earlier run"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("KLONEKIT_LOG_DIR", logDir)
+
+	// Reject every GitLab API call so the scm stage fails after scaffolding
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	blueprintFile, err := createValidTestBlueprint(tempDir)
+	if err != nil {
+		t.Fatalf("Failed to create test blueprint: %s", err)
+	}
+	content, err := os.ReadFile(blueprintFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	content = []byte(strings.Replace(string(content), "https://gitlab.com", server.URL, 1))
+	if err := os.WriteFile(blueprintFile, content, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	destDir := filepath.Join(tempDir, "destination")
+	if err := os.MkdirAll(destDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	backupName := "terraform.tfstate.backup.20260101-000000"
+	if err := os.WriteFile(filepath.Join(destDir, backupName), []byte(`{"version": 4}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ApplyWithOptions(blueprintFile, ApplyOptions{BundleOnFailure: true}); err == nil {
+		t.Fatal("Expected the apply to fail at the scm stage, got nil")
+	}
+
+	state, err := loadState()
+	if err != nil || state == nil {
+		t.Fatalf("Expected the execution state to be saved, got: %v", err)
+	}
+	members := readBundle(t, FailureBundlePrefix+state.RunID+".tar.gz")
+
+	for _, name := range []string{"output.log", errors.LogFileName, StateFileName, "scaffold/" + backupName} {
+		if _, ok := members[name]; !ok {
+			t.Errorf("Expected the failure bundle to contain %s, got members: %v", name, keys(members))
+		}
+	}
+	if !strings.Contains(members["output.log"], "Starting KloneKit apply workflow") {
+		t.Errorf("Expected output.log to hold the run's log output, got: %s", members["output.log"])
+	}
+	if !strings.Contains(members[errors.LogFileName], "earlier run") {
+		t.Errorf("Expected the log file to be bundled, got: %s", members[errors.LogFileName])
+	}
+}
+
+func TestApply_NoBundleWithoutFlag(t *testing.T) {
+	tempDir := t.TempDir()
+	originalDir, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get current directory: %s", err)
+	}
+	defer func() { _ = os.Chdir(originalDir) }()
+	if err := os.Chdir(tempDir); err != nil {
+		t.Fatalf("Failed to change to temp directory: %s", err)
+	}
+
+	if err := ApplyWithOptions("missing.yaml", ApplyOptions{}); err == nil {
+		t.Fatal("Expected the apply to fail for a missing blueprint, got nil")
+	}
+
+	bundles, _ := filepath.Glob(FailureBundlePrefix + "*")
+	if len(bundles) != 0 {
+		t.Errorf("Expected no failure bundle without --bundle-on-failure, got: %v", bundles)
+	}
+}
+
+func TestApply_ResumeGuardsChangedBlueprint(t *testing.T) {
+	tempDir := chdirTemp(t)
+	blueprintFile, err := createValidTestBlueprint(tempDir)
+	if err != nil {
+		t.Fatalf("Failed to create test blueprint: %s", err)
+	}
+	seedResumableState(t, blueprintFile)
+
+	// Point the blueprint at another destination after the run started
+	content, err := os.ReadFile(blueprintFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edited := strings.Replace(string(content), filepath.Join(tempDir, "destination"), filepath.Join(tempDir, "elsewhere"), 1)
+	if err := os.WriteFile(blueprintFile, []byte(edited), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	err = ApplyWithOptions(blueprintFile, ApplyOptions{DryRun: true})
+	if err == nil {
+		t.Fatal("Expected resuming with a changed blueprint to fail, got nil")
+	}
+	if !strings.Contains(err.Error(), "--force-resume") {
+		t.Errorf("Expected the error to mention --force-resume, got: %s", err)
+	}
+
+	output := captureLog(t, func() {
+		if err := ApplyWithOptions(blueprintFile, ApplyOptions{DryRun: true, ForceResume: true}); err != nil {
+			t.Errorf("Unexpected error with --force-resume: %s", err)
+		}
+	})
+	if !strings.Contains(output, "Resuming with a changed blueprint") {
+		t.Errorf("Expected a warning about the changed blueprint, got:\n%s", output)
+	}
+}
+
+func TestApply_ResumeWithUnchangedBlueprint(t *testing.T) {
+	tempDir := chdirTemp(t)
+	blueprintFile, err := createValidTestBlueprint(tempDir)
+	if err != nil {
+		t.Fatalf("Failed to create test blueprint: %s", err)
+	}
+	seedResumableState(t, blueprintFile)
+
+	output := captureLog(t, func() {
+		if err := ApplyWithOptions(blueprintFile, ApplyOptions{DryRun: true}); err != nil {
+			t.Errorf("Unexpected error resuming with an unchanged blueprint: %s", err)
+		}
+	})
+	if strings.Contains(output, "changed blueprint") {
+		t.Errorf("Expected no warning for an unchanged blueprint, got:\n%s", output)
+	}
+}
+
+func TestApply_ResumeGuardsChangedOverrides(t *testing.T) {
+	tempDir := chdirTemp(t)
+	blueprintFile, err := createValidTestBlueprint(tempDir)
+	if err != nil {
+		t.Fatalf("Failed to create test blueprint: %s", err)
+	}
+	seedResumableState(t, blueprintFile)
+
+	// The file is unchanged, but --dir points the run at another destination
+	err = ApplyWithOptions(blueprintFile, ApplyOptions{DryRun: true, ScaffoldDir: filepath.Join(tempDir, "elsewhere")})
+	if err == nil || !strings.Contains(err.Error(), "--dir") {
+		t.Fatalf("Expected resuming with a different --dir to fail, got: %v", err)
+	}
+
+	// So does another blueprint file with the same content
+	content, err := os.ReadFile(blueprintFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherFile := filepath.Join(tempDir, "other.yaml")
+	if err := os.WriteFile(otherFile, content, 0644); err != nil {
+		t.Fatal(err)
+	}
+	err = ApplyWithOptions(otherFile, ApplyOptions{DryRun: true})
+	if err == nil || !strings.Contains(err.Error(), "blueprint file "+otherFile) {
+		t.Fatalf("Expected resuming with another blueprint file to fail, got: %v", err)
+	}
+}
+
+// seedResumableState writes the state of a run interrupted after the SCM stage, recording
+// the hash of the blueprint as it is now
+func seedResumableState(t *testing.T, blueprintFile string) {
+	t.Helper()
+
+	blueprintHash, err := hashBlueprint(blueprintFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	state := newState(blueprintFile, "test-resume-hash-run")
+	state.LastSuccessfulStage = StageSCM
+	state.LastCompletedStage = string(StageSCM)
+	state.BlueprintHash = blueprintHash
+	if err := saveState(state); err != nil {
+		t.Fatalf("Failed to save test state: %s", err)
+	}
+}
+
+// captureLog returns the log output written while fn runs
+func captureLog(t *testing.T, fn func()) string {
+	t.Helper()
+
+	var buf bytes.Buffer
+	previous := log.Writer()
+	log.SetOutput(io.MultiWriter(previous, &buf))
+	defer log.SetOutput(previous)
+
+	fn()
+	return buf.String()
+}
+
+// readBundle returns the members of a failure bundle by name
+func readBundle(t *testing.T, path string) map[string]string {
+	t.Helper()
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("Expected failure bundle %s to be written: %s", path, err)
+	}
+	defer file.Close()
+
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		t.Fatalf("Expected a gzip archive: %s", err)
+	}
+	members := map[string]string{}
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Failed to read failure bundle: %s", err)
+		}
+		content, err := io.ReadAll(tr)
+		if err != nil {
+			t.Fatalf("Failed to read %s from failure bundle: %s", header.Name, err)
+		}
+		members[header.Name] = string(content)
+	}
+	return members
+}
+
+// keys returns the names of the bundle members
+func keys(members map[string]string) []string {
+	names := make([]string, 0, len(members))
+	for name := range members {
+		names = append(names, name)
+	}
+	return names
+}
+
+func TestSetColorsEnabled(t *testing.T) {
+	SetColorsEnabled(false)
+	defer SetColorsEnabled(true)
+
+	for _, stage := range []string{"scaffold", "scm", "provision", "other"} {
+		if color := getStageColor(stage); color != "" {
+			t.Errorf("Expected no color for stage %s with colors disabled, got %q", stage, color)
+		}
+	}
+	if line := fmt.Sprintf("%s✅ done%s", ColorGreen, ColorReset); line != "✅ done" {
+		t.Errorf("Expected plain text with colors disabled, got %q", line)
+	}
+
+	SetColorsEnabled(true)
+	if getStageColor("scaffold") != "\033[36m" || ColorReset != "\033[0m" {
+		t.Error("Expected the color codes to be restored")
+	}
+}
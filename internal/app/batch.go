@@ -0,0 +1,115 @@
+package app
+
+import (
+	stderrors "errors"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+// Exit codes of an apply run, so CI can tell a partially applied multi-document blueprint
+// from one where nothing was applied
+const (
+	ExitCodeSuccess        = 0
+	ExitCodeFailure        = 1 // The run failed before or while applying a blueprint
+	ExitCodePartialFailure = 2 // With --keep-going, some blueprints failed and others were applied
+	ExitCodeAllFailed      = 3 // With --keep-going, every blueprint failed
+)
+
+// blueprintStatusFailed is the summary table status of a blueprint whose stages failed
+const blueprintStatusFailed = "failed"
+
+// BlueprintResult records the outcome of one blueprint of a multi-document apply.
+type BlueprintResult struct {
+	Name string
+	// Completed is set for a blueprint applied by an earlier attempt of the resumed run
+	Completed bool
+	Err       error
+}
+
+// status returns the summary table status of the blueprint
+func (r BlueprintResult) status() string {
+	switch {
+	case r.Err != nil:
+		return blueprintStatusFailed
+	case r.Completed:
+		return StageStatusSkipped
+	default:
+		return StageStatusSucceeded
+	}
+}
+
+// BatchError reports the blueprints that failed in a --keep-going apply.
+type BatchError struct {
+	Results []BlueprintResult
+}
+
+// newBatchError returns a BatchError if any of the results failed, or nil.
+func newBatchError(results []BlueprintResult) *BatchError {
+	for _, result := range results {
+		if result.Err != nil {
+			return &BatchError{Results: results}
+		}
+	}
+	return nil
+}
+
+// Failed returns the names of the blueprints that failed.
+func (e *BatchError) Failed() []string {
+	var names []string
+	for _, result := range e.Results {
+		if result.Err != nil {
+			names = append(names, result.Name)
+		}
+	}
+	return names
+}
+
+func (e *BatchError) Error() string {
+	failed := e.Failed()
+	return fmt.Sprintf("%d of %d blueprints failed: %s", len(failed), len(e.Results), strings.Join(failed, ", "))
+}
+
+// ExitCode returns ExitCodeAllFailed when no blueprint was applied and ExitCodePartialFailure otherwise.
+func (e *BatchError) ExitCode() int {
+	if len(e.Failed()) == len(e.Results) {
+		return ExitCodeAllFailed
+	}
+	return ExitCodePartialFailure
+}
+
+// ExitCode returns the process exit code for the outcome of an apply run.
+func ExitCode(err error) int {
+	if err == nil {
+		return ExitCodeSuccess
+	}
+	var batchErr *BatchError
+	if stderrors.As(err, &batchErr) {
+		return batchErr.ExitCode()
+	}
+	return ExitCodeFailure
+}
+
+// printBatchSummary writes a table of each blueprint's outcome followed by the totals.
+func printBatchSummary(w io.Writer, results []BlueprintResult) {
+	counts := map[string]int{}
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "BLUEPRINT\tSTATUS\tDETAIL")
+	for _, result := range results {
+		status := result.status()
+		counts[status]++
+		detail := ""
+		switch {
+		case result.Err != nil:
+			detail = result.Err.Error()
+		case result.Completed:
+			detail = "already completed"
+		}
+		fmt.Fprintf(table, "%s\t%s\t%s\n", result.Name, status, detail)
+	}
+	table.Flush()
+
+	fmt.Fprintf(w, "\n%d blueprints: %d succeeded, %d failed, %d skipped\n",
+		len(results), counts[StageStatusSucceeded], counts[blueprintStatusFailed], counts[StageStatusSkipped])
+}
@@ -0,0 +1,199 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	stderrors "errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"klonekit/internal/scm"
+	"klonekit/pkg/blueprint"
+)
+
+// failingStage is a stage that fails with err
+type failingStage struct {
+	name string
+	err  error
+}
+
+func (s *failingStage) Name() string { return s.name }
+
+func (s *failingStage) Execute(ctx context.Context, state *ExecutionState) error { return s.err }
+
+// batchStages returns stages for runBlueprints where the scm stage fails for the named blueprints
+func batchStages(failing ...string) func(*blueprint.Blueprint) []Stage {
+	return func(bp *blueprint.Blueprint) []Stage {
+		scmStage := Stage(&fakeStage{name: "scm"})
+		for _, name := range failing {
+			if bp.Metadata.Name == name {
+				scmStage = &failingStage{name: "scm", err: stderrors.New("project already exists")}
+			}
+		}
+		return []Stage{&fakeStage{name: "scaffold"}, scmStage, &fakeStage{name: "provision"}}
+	}
+}
+
+func batchBlueprints(names ...string) []*blueprint.Blueprint {
+	var blueprints []*blueprint.Blueprint
+	for _, name := range names {
+		blueprints = append(blueprints, &blueprint.Blueprint{Metadata: blueprint.Metadata{Name: name}})
+	}
+	return blueprints
+}
+
+func TestRunBlueprints_KeepGoing(t *testing.T) {
+	tests := []struct {
+		name         string
+		failing      []string
+		wantExitCode int
+		wantCounts   string
+	}{
+		{name: "all succeed", wantExitCode: ExitCodeSuccess, wantCounts: "3 blueprints: 3 succeeded, 0 failed, 0 skipped"},
+		{name: "partial", failing: []string{"network"}, wantExitCode: ExitCodePartialFailure, wantCounts: "3 blueprints: 2 succeeded, 1 failed, 0 skipped"},
+		{name: "all fail", failing: []string{"network", "database", "cluster"}, wantExitCode: ExitCodeAllFailed, wantCounts: "3 blueprints: 0 succeeded, 3 failed, 0 skipped"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chdirTemp(t)
+			state := newState("klonekit.yaml", "run-1")
+
+			results, err := runBlueprints(context.Background(), batchBlueprints("network", "database", "cluster"), state, ApplyOptions{KeepGoing: true}, batchStages(tt.failing...))
+			if err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			if len(results) != 3 {
+				t.Fatalf("Expected a result for every blueprint, got %+v", results)
+			}
+
+			var batchErr error
+			if e := newBatchError(results); e != nil {
+				batchErr = e
+			}
+			if code := ExitCode(batchErr); code != tt.wantExitCode {
+				t.Errorf("Expected exit code %d, got %d", tt.wantExitCode, code)
+			}
+
+			var summary bytes.Buffer
+			printBatchSummary(&summary, results)
+			if !strings.Contains(summary.String(), tt.wantCounts) {
+				t.Errorf("Expected summary counts %q, got:\n%s", tt.wantCounts, summary.String())
+			}
+			for _, name := range tt.failing {
+				if !strings.Contains(summary.String(), fmt.Sprintf("stage execution failed for blueprint '%s'", name)) {
+					t.Errorf("Expected the summary to show why %s failed, got:\n%s", name, summary.String())
+				}
+			}
+		})
+	}
+}
+
+func TestRunBlueprints_KeepGoingLeavesStateAtFirstFailure(t *testing.T) {
+	chdirTemp(t)
+	state := newState("klonekit.yaml", "run-1")
+
+	if _, err := runBlueprints(context.Background(), batchBlueprints("network", "database", "cluster"), state, ApplyOptions{KeepGoing: true}, batchStages("database")); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	saved, err := loadState()
+	if err != nil || saved == nil {
+		t.Fatalf("Expected a saved state, got %v (%v)", saved, err)
+	}
+	if saved.BlueprintIndex != 1 || saved.LastCompletedStage != "" {
+		t.Errorf("Expected the state to resume from the failed blueprint with fresh progress, got index %d and stage %q", saved.BlueprintIndex, saved.LastCompletedStage)
+	}
+
+	// Resuming skips the blueprints applied before and after the failure
+	results, err := runBlueprints(context.Background(), batchBlueprints("network", "database", "cluster"), saved, ApplyOptions{KeepGoing: true}, batchStages())
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	var summary bytes.Buffer
+	printBatchSummary(&summary, results)
+	if !strings.Contains(summary.String(), "3 blueprints: 1 succeeded, 0 failed, 2 skipped") {
+		t.Errorf("Expected the completed blueprints to be skipped, got:\n%s", summary.String())
+	}
+}
+
+func TestRunBlueprints_KeepGoingResumeSkipsSucceededBlueprints(t *testing.T) {
+	chdirTemp(t)
+	state := newState("klonekit.yaml", "run-1")
+
+	if _, err := runBlueprints(context.Background(), batchBlueprints("network", "database", "cluster"), state, ApplyOptions{KeepGoing: true}, batchStages("network", "cluster")); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	saved, err := loadState()
+	if err != nil || saved == nil {
+		t.Fatalf("Expected a saved state, got %v (%v)", saved, err)
+	}
+	want := []string{BlueprintStatusFailed, BlueprintStatusSucceeded, BlueprintStatusFailed}
+	if fmt.Sprint(saved.BlueprintResults) != fmt.Sprint(want) {
+		t.Errorf("Expected blueprint results %v, got %v", want, saved.BlueprintResults)
+	}
+
+	// database succeeded after the first failure, so it isn't run again: it would fail if it were
+	results, err := runBlueprints(context.Background(), batchBlueprints("network", "database", "cluster"), saved, ApplyOptions{KeepGoing: true}, batchStages("database"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	var summary bytes.Buffer
+	printBatchSummary(&summary, results)
+	if !strings.Contains(summary.String(), "3 blueprints: 2 succeeded, 0 failed, 1 skipped") {
+		t.Errorf("Expected only the failed blueprints to be retried, got:\n%s", summary.String())
+	}
+}
+
+func TestResetBlueprintProgress_ClearsPerBlueprintRecords(t *testing.T) {
+	state := newState("klonekit.yaml", "run-1")
+	state.LastCompletedStage = string(StageSCM)
+	state.LastSuccessfulStage = StageSCM
+	state.recordStageResult(StageResult{Name: "scaffold", Status: StageStatusSucceeded})
+	state.CreatedProject = &scm.CreatedProject{ID: 42, Path: "platform/network"}
+	state.PushedCommit = &scm.PushResult{CommitHash: "abc123", Branch: "main"}
+	state.ProvisionStep = "plan"
+
+	state.resetBlueprintProgress(1)
+
+	if state.BlueprintIndex != 1 {
+		t.Errorf("Expected the state to move to blueprint 1, got %d", state.BlueprintIndex)
+	}
+	if state.LastCompletedStage != "" || state.LastSuccessfulStage != "" || state.StageResults != nil ||
+		state.CreatedProject != nil || state.PushedCommit != nil || state.ProvisionStep != "" {
+		t.Errorf("Expected the previous blueprint's progress to be cleared, got %+v", state)
+	}
+}
+
+func TestRunBlueprints_StopsAtFirstFailureByDefault(t *testing.T) {
+	chdirTemp(t)
+	state := newState("klonekit.yaml", "run-1")
+
+	results, err := runBlueprints(context.Background(), batchBlueprints("network", "database", "cluster"), state, ApplyOptions{}, batchStages("network"))
+	if err == nil || !strings.Contains(err.Error(), "stage execution failed for blueprint 'network'") {
+		t.Fatalf("Expected the first failure to end the run, got: %v", err)
+	}
+	if len(results) != 0 {
+		t.Errorf("Expected no further blueprints to run, got %+v", results)
+	}
+	if code := ExitCode(err); code != ExitCodeFailure {
+		t.Errorf("Expected exit code %d, got %d", ExitCodeFailure, code)
+	}
+}
+
+func TestBatchError(t *testing.T) {
+	err := fmt.Errorf("apply failed: %w", newBatchError([]BlueprintResult{
+		{Name: "network", Completed: true},
+		{Name: "database", Err: stderrors.New("boom")},
+		{Name: "cluster", Err: stderrors.New("boom")},
+	}))
+
+	if !strings.Contains(err.Error(), "2 of 3 blueprints failed: database, cluster") {
+		t.Errorf("Unexpected error message: %s", err)
+	}
+	if code := ExitCode(err); code != ExitCodePartialFailure {
+		t.Errorf("Expected a blueprint completed earlier in the run to make it a partial failure, got exit code %d", code)
+	}
+}
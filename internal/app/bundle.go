@@ -0,0 +1,172 @@
+package app
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+
+	"klonekit/internal/errors"
+	"klonekit/internal/provisioner"
+)
+
+const (
+	// FailureBundlePrefix starts the name of the archive written by --bundle-on-failure,
+	// which is followed by the run ID and ".tar.gz".
+	FailureBundlePrefix = "klonekit-failure-"
+
+	// bundleOutputName is the archive member holding the log output captured during the run,
+	// including the Terraform output.
+	bundleOutputName = "output.log"
+)
+
+// failureBundle captures the log output of a run and, if the run fails, archives it with the
+// log file, the execution state and the Terraform state and backups for a bug report.
+// A nil failureBundle does nothing, so bundling costs nothing unless --bundle-on-failure is given.
+type failureBundle struct {
+	runID        string
+	scaffoldDirs []string
+
+	output         bytes.Buffer
+	previousOutput io.Writer
+}
+
+// newFailureBundle starts capturing the log output when enabled, or returns nil.
+func newFailureBundle(enabled bool) *failureBundle {
+	if !enabled {
+		return nil
+	}
+
+	// slog's default handler writes through the standard logger, so teeing its writer
+	// captures every record without changing what is printed
+	b := &failureBundle{previousOutput: log.Writer()}
+	log.SetOutput(io.MultiWriter(b.previousOutput, &b.output))
+	return b
+}
+
+// setRunID records the run ID used in the archive name.
+func (b *failureBundle) setRunID(runID string) {
+	if b != nil {
+		b.runID = runID
+	}
+}
+
+// addScaffoldDir records a scaffold directory whose Terraform state and backups are archived.
+func (b *failureBundle) addScaffoldDir(dir string) {
+	if b != nil && dir != "" {
+		b.scaffoldDirs = append(b.scaffoldDirs, dir)
+	}
+}
+
+// finish stops capturing the log output and writes the archive when runErr is set.
+func (b *failureBundle) finish(runErr error) {
+	if b == nil {
+		return
+	}
+	log.SetOutput(b.previousOutput)
+	if runErr == nil {
+		return
+	}
+
+	path, err := b.write()
+	if err != nil {
+		slog.Warn("Failed to write failure bundle", "error", err)
+		return
+	}
+	fmt.Printf("%s📦 Failure bundle written to %s (it contains Terraform state, which may hold secrets)%s\n", ColorYellow, path, ColorReset)
+	slog.Info("Failure bundle written", "file", path)
+}
+
+// write creates the archive in the current directory and returns its path.
+func (b *failureBundle) write() (string, error) {
+	runID := b.runID
+	if runID == "" {
+		runID = time.Now().UTC().Format("20060102T150405Z")
+	}
+	path := FailureBundlePrefix + runID + ".tar.gz"
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600) // #nosec G304
+	if err != nil {
+		return "", fmt.Errorf("failed to create %s: %w", path, err)
+	}
+	defer file.Close()
+
+	gz := gzip.NewWriter(file)
+	tw := tar.NewWriter(gz)
+
+	if err := addBundleMember(tw, bundleOutputName, b.output.Bytes()); err != nil {
+		return "", err
+	}
+	for _, member := range b.files() {
+		content, err := os.ReadFile(member.source) // #nosec G304
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to read %s: %w", member.source, err)
+		}
+		if err := addBundleMember(tw, member.name, content); err != nil {
+			return "", err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return "", fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := gz.Close(); err != nil {
+		return "", fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return path, file.Close()
+}
+
+// bundleFile is a file on disk and its member name in the archive.
+type bundleFile struct {
+	source string
+	name   string
+}
+
+// files lists the files to archive; missing files are skipped when writing.
+func (b *failureBundle) files() []bundleFile {
+	files := []bundleFile{
+		{source: errors.LogFilePath(), name: errors.LogFileName},
+		{source: StateFileName, name: StateFileName},
+	}
+
+	for i, dir := range b.scaffoldDirs {
+		// Prefix members with the blueprint index so multi-document runs don't collide
+		prefix := "scaffold/"
+		if len(b.scaffoldDirs) > 1 {
+			prefix = fmt.Sprintf("scaffold-%d/", i+1)
+		}
+
+		files = append(files, bundleFile{source: filepath.Join(dir, provisioner.StateFileName), name: prefix + provisioner.StateFileName})
+		backups, _ := filepath.Glob(filepath.Join(dir, provisioner.StateFileName+".backup*"))
+		for _, backup := range backups {
+			files = append(files, bundleFile{source: backup, name: prefix + filepath.Base(backup)})
+		}
+	}
+	return files
+}
+
+// addBundleMember writes content to the archive as a regular file.
+func addBundleMember(tw *tar.Writer, name string, content []byte) error {
+	header := &tar.Header{
+		Name:    name,
+		Mode:    0600,
+		Size:    int64(len(content)),
+		ModTime: time.Now(),
+	}
+	if err := tw.WriteHeader(header); err != nil {
+		return fmt.Errorf("failed to add %s to failure bundle: %w", name, err)
+	}
+	if _, err := tw.Write(content); err != nil {
+		return fmt.Errorf("failed to add %s to failure bundle: %w", name, err)
+	}
+	return nil
+}
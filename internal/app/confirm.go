@@ -0,0 +1,81 @@
+package app
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"slices"
+	"strings"
+
+	"klonekit/pkg/blueprint"
+)
+
+// ConfirmProductionApply asks the user to type the project name before a blueprint with
+// spec.provision.confirmApply is applied, and returns an error unless it matches exactly.
+// Blueprints without confirmApply are applied without asking.
+func ConfirmProductionApply(bp *blueprint.Blueprint, input io.Reader) error {
+	if !bp.Spec.Provision.ConfirmApply {
+		return nil
+	}
+	if input == nil {
+		input = os.Stdin
+	}
+	return confirmProjectName(bp, bufio.NewReader(input))
+}
+
+// confirmProductionApplies confirms the apply of every blueprint in the run that requires it,
+// before any stage runs. Nothing is applied in a dry run, without auto-approve or when the
+// provision stage is skipped, and blueprints an earlier attempt already completed aren't
+// asked for again.
+func confirmProductionApplies(blueprints []*blueprint.Blueprint, state *ExecutionState, opts ApplyOptions) error {
+	if opts.DryRun || !opts.AutoApprove || slices.Contains(opts.SkipStages, string(StageProvision)) {
+		return nil
+	}
+
+	input := opts.Input
+	if input == nil {
+		input = os.Stdin
+	}
+	reader := bufio.NewReader(input)
+	for i, bp := range blueprints {
+		if state.blueprintCompleted(i) || !bp.Spec.Provision.ConfirmApply {
+			continue
+		}
+		if opts.SkipApplyConfirmation {
+			slog.Warn("Skipping the apply confirmation of a production blueprint", "blueprint", bp.Metadata.Name)
+			continue
+		}
+		if err := confirmProjectName(bp, reader); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// confirmProjectName prompts for the project name of bp and checks the answer read from reader.
+func confirmProjectName(bp *blueprint.Blueprint, reader *bufio.Reader) error {
+	// Without a project to publish to, the blueprint's own name is confirmed
+	projectName := bp.Spec.SCM.Project.Name
+	if projectName == "" {
+		projectName = bp.Metadata.Name
+	}
+	fmt.Printf("%s⚠️  Blueprint '%s' requires confirmation to apply (spec.provision.confirmApply).%s\n", ColorYellow, bp.Metadata.Name, ColorReset)
+	fmt.Printf("Type the project name '%s' to apply: ", projectName)
+
+	answer, err := reader.ReadString('\n')
+	if err != nil && err != io.EOF {
+		return fmt.Errorf("failed to read confirmation: %w", err)
+	}
+	answer = strings.TrimSpace(answer)
+	if answer == "" && err == io.EOF {
+		return fmt.Errorf("apply of blueprint '%s' needs confirmation, but no input was given; use --skip-apply-confirmation to apply non-interactively", bp.Metadata.Name)
+	}
+	if answer != projectName {
+		return fmt.Errorf("confirmation '%s' does not match the project name '%s'; apply cancelled", answer, projectName)
+	}
+
+	slog.Info("Apply confirmed", "blueprint", bp.Metadata.Name, "project", projectName)
+	return nil
+}
@@ -0,0 +1,137 @@
+package app
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"klonekit/pkg/blueprint"
+)
+
+func productionBlueprint(name, projectName string) *blueprint.Blueprint {
+	bp := &blueprint.Blueprint{Metadata: blueprint.Metadata{Name: name}}
+	bp.Spec.SCM.Project.Name = projectName
+	bp.Spec.Provision.ConfirmApply = true
+	return bp
+}
+
+func TestConfirmProductionApplies(t *testing.T) {
+	prod := productionBlueprint("prod", "payments-infra")
+	dev := &blueprint.Blueprint{Metadata: blueprint.Metadata{Name: "dev"}}
+
+	tests := []struct {
+		name       string
+		blueprints []*blueprint.Blueprint
+		opts       ApplyOptions
+		input      string
+		wantErr    string
+	}{
+		{
+			name:       "correct project name",
+			blueprints: []*blueprint.Blueprint{prod},
+			opts:       ApplyOptions{AutoApprove: true},
+			input:      "payments-infra\n",
+		},
+		{
+			name:       "wrong project name",
+			blueprints: []*blueprint.Blueprint{prod},
+			opts:       ApplyOptions{AutoApprove: true},
+			input:      "yes\n",
+			wantErr:    "confirmation 'yes' does not match the project name 'payments-infra'",
+		},
+		{
+			name:       "project name with different case",
+			blueprints: []*blueprint.Blueprint{prod},
+			opts:       ApplyOptions{AutoApprove: true},
+			input:      "Payments-Infra\n",
+			wantErr:    "does not match",
+		},
+		{
+			name:       "no input",
+			blueprints: []*blueprint.Blueprint{prod},
+			opts:       ApplyOptions{AutoApprove: true},
+			wantErr:    "use --skip-apply-confirmation",
+		},
+		{
+			name:       "skip flag bypasses the prompt",
+			blueprints: []*blueprint.Blueprint{prod},
+			opts:       ApplyOptions{AutoApprove: true, SkipApplyConfirmation: true},
+		},
+		{
+			name:       "nothing is applied without auto-approve",
+			blueprints: []*blueprint.Blueprint{prod},
+		},
+		{
+			name:       "nothing is applied when provision is skipped",
+			blueprints: []*blueprint.Blueprint{prod},
+			opts:       ApplyOptions{AutoApprove: true, SkipStages: []string{"provision"}},
+		},
+		{
+			name:       "blueprint without confirmApply",
+			blueprints: []*blueprint.Blueprint{dev},
+			opts:       ApplyOptions{AutoApprove: true},
+		},
+		{
+			name:       "each production blueprint is confirmed",
+			blueprints: []*blueprint.Blueprint{prod, dev, productionBlueprint("dr", "payments-dr")},
+			opts:       ApplyOptions{AutoApprove: true},
+			input:      "payments-infra\npayments-dr\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.opts.Input = strings.NewReader(tt.input)
+			err := confirmProductionApplies(tt.blueprints, &ExecutionState{}, tt.opts)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Unexpected error: %s", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected an error containing %q, got: %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestConfirmProductionApplies_SkipsCompletedBlueprints(t *testing.T) {
+	blueprints := []*blueprint.Blueprint{
+		productionBlueprint("prod", "payments-infra"),
+		productionBlueprint("dr", "payments-dr"),
+	}
+	opts := ApplyOptions{AutoApprove: true, Input: strings.NewReader("payments-dr\n")}
+	if err := confirmProductionApplies(blueprints, &ExecutionState{BlueprintIndex: 1}, opts); err != nil {
+		t.Errorf("Expected only the remaining blueprint to be confirmed, got: %s", err)
+	}
+}
+
+func TestApply_WrongConfirmationCancelsRun(t *testing.T) {
+	tempDir := chdirTemp(t)
+	blueprintFile, err := createValidTestBlueprint(tempDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, err := os.ReadFile(blueprintFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	content = []byte(strings.Replace(string(content), "  variables:", "  provision:\n    confirmApply: true\n  variables:", 1))
+	if err := os.WriteFile(blueprintFile, content, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	err = ApplyWithOptions(blueprintFile, ApplyOptions{AutoApprove: true, Input: strings.NewReader("integration-test\n")})
+	if err == nil || !strings.Contains(err.Error(), "does not match the project name 'integration-test-repo'") {
+		t.Fatalf("Expected the run to be cancelled, got: %v", err)
+	}
+
+	// No stage ran
+	if _, err := os.Stat("destination"); !os.IsNotExist(err) {
+		t.Error("Expected nothing to be scaffolded")
+	}
+	if _, err := os.Stat(StateFileName); !os.IsNotExist(err) {
+		t.Error("Expected no state file to be written")
+	}
+}
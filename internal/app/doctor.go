@@ -0,0 +1,96 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"klonekit/internal/provisioner"
+	"klonekit/pkg/blueprint"
+)
+
+// DoctorOptions configures the preflight checks run by Doctor.
+type DoctorOptions struct {
+	// PullImage pulls the Terraform image, so registry and authentication problems surface
+	// before an apply. It is off by default to avoid unwanted network use.
+	PullImage bool
+}
+
+// Outcomes of a preflight check, recorded in DoctorCheck.Status
+const (
+	DoctorStatusPassed  = "passed"
+	DoctorStatusFailed  = "failed"
+	DoctorStatusSkipped = "skipped"
+)
+
+// DoctorCheck records the outcome of one preflight check.
+type DoctorCheck struct {
+	Name   string
+	Status string
+	Detail string
+}
+
+// Doctor runs the preflight checks for a parsed blueprint, writes one line per check to w and
+// returns an error if any check failed.
+func Doctor(ctx context.Context, w io.Writer, bp *blueprint.Blueprint, factory *ProviderFactory, opts DoctorOptions) ([]DoctorCheck, error) {
+	checks := []DoctorCheck{
+		checkContainerRuntime(factory),
+		checkImagePull(ctx, &bp.Spec, factory, opts),
+	}
+
+	failed := 0
+	for _, check := range checks {
+		switch check.Status {
+		case DoctorStatusPassed:
+			fmt.Fprintf(w, "%s✅ %s: %s%s\n", ColorGreen, check.Name, check.Detail, ColorReset)
+		case DoctorStatusFailed:
+			failed++
+			fmt.Fprintf(w, "%s❌ %s: %s%s\n", ColorRed, check.Name, check.Detail, ColorReset)
+		default:
+			fmt.Fprintf(w, "%s⏭️  %s: %s%s\n", ColorYellow, check.Name, check.Detail, ColorReset)
+		}
+	}
+
+	if failed > 0 {
+		return checks, fmt.Errorf("%d of %d preflight checks failed for blueprint '%s'", failed, len(checks), bp.Metadata.Name)
+	}
+	return checks, nil
+}
+
+// checkContainerRuntime checks that the container runtime used for provisioning is reachable.
+func checkContainerRuntime(factory *ProviderFactory) DoctorCheck {
+	check := DoctorCheck{Name: "container runtime"}
+	if err := factory.CheckContainerRuntime(); err != nil {
+		check.Status, check.Detail = DoctorStatusFailed, err.Error()
+		return check
+	}
+	check.Status, check.Detail = DoctorStatusPassed, "reachable"
+	return check
+}
+
+// checkImagePull pulls the Terraform image the provision stage would run, when enabled.
+func checkImagePull(ctx context.Context, spec *blueprint.Spec, factory *ProviderFactory, opts DoctorOptions) DoctorCheck {
+	image := provisioner.TerraformImage(spec)
+	check := DoctorCheck{Name: "image pull"}
+	if !opts.PullImage {
+		check.Status, check.Detail = DoctorStatusSkipped, fmt.Sprintf("not pulling %s (use --pull to check it)", image)
+		return check
+	}
+
+	terraformProvisioner, err := factory.GetProvisioner(spec.Cloud.Provider)
+	if err != nil {
+		check.Status, check.Detail = DoctorStatusFailed, err.Error()
+		return check
+	}
+	puller, ok := terraformProvisioner.(provisioner.ImagePrePuller)
+	if !ok {
+		check.Status, check.Detail = DoctorStatusSkipped, fmt.Sprintf("the %s provisioner does not pull an image", spec.Cloud.Provider)
+		return check
+	}
+	if err := puller.PrePullImage(ctx, spec); err != nil {
+		check.Status, check.Detail = DoctorStatusFailed, fmt.Sprintf("failed to pull %s: %s", image, err)
+		return check
+	}
+	check.Status, check.Detail = DoctorStatusPassed, fmt.Sprintf("pulled %s", image)
+	return check
+}
@@ -0,0 +1,86 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	stderrors "errors"
+	"strings"
+	"testing"
+	"time"
+
+	"klonekit/internal/retry"
+	"klonekit/pkg/blueprint"
+)
+
+func doctorBlueprint() *blueprint.Blueprint {
+	return &blueprint.Blueprint{
+		Metadata: blueprint.Metadata{Name: "network"},
+		Spec: blueprint.Spec{
+			Cloud:    blueprint.CloudProvider{Provider: "aws", Region: "us-east-1"},
+			Scaffold: blueprint.Scaffold{Destination: "infra"},
+		},
+	}
+}
+
+func TestDoctor_ImagePull(t *testing.T) {
+	previous := retry.Current()
+	retry.SetPolicy(retry.Policy{MaxRetries: 1, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond})
+	t.Cleanup(func() { retry.SetPolicy(previous) })
+
+	tests := []struct {
+		name       string
+		pull       bool
+		pullErr    error
+		wantStatus string
+		wantDetail string
+		wantPulls  int
+	}{
+		{name: "pull succeeds", pull: true, wantStatus: DoctorStatusPassed, wantDetail: "pulled hashicorp/terraform:1.8.0", wantPulls: 1},
+		{name: "pull fails", pull: true, pullErr: stderrors.New("unauthorized: authentication required"), wantStatus: DoctorStatusFailed, wantDetail: "unauthorized: authentication required", wantPulls: 2},
+		{name: "pull not requested", wantStatus: DoctorStatusSkipped, wantDetail: "use --pull", wantPulls: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			containerRuntime := newPullingRuntime(tt.pullErr)
+			factory := &ProviderFactory{containerRuntime: containerRuntime}
+
+			var output bytes.Buffer
+			checks, err := Doctor(context.Background(), &output, doctorBlueprint(), factory, DoctorOptions{PullImage: tt.pull})
+			if (err != nil) != (tt.wantStatus == DoctorStatusFailed) {
+				t.Errorf("Unexpected error for status %s: %v", tt.wantStatus, err)
+			}
+
+			var pullCheck DoctorCheck
+			for _, check := range checks {
+				if check.Name == "image pull" {
+					pullCheck = check
+				}
+			}
+			if pullCheck.Status != tt.wantStatus || !strings.Contains(pullCheck.Detail, tt.wantDetail) {
+				t.Errorf("Expected image pull %s with %q, got %+v", tt.wantStatus, tt.wantDetail, pullCheck)
+			}
+			if !strings.Contains(output.String(), "image pull: ") {
+				t.Errorf("Expected the report to show the image pull check, got:\n%s", output.String())
+			}
+			if containerRuntime.pulls != tt.wantPulls {
+				t.Errorf("Expected %d pulls, got %d", tt.wantPulls, containerRuntime.pulls)
+			}
+		})
+	}
+}
+
+func TestDoctor_ContainerRuntimeUnavailable(t *testing.T) {
+	original := connectDocker
+	connectDocker = func() error { return stderrors.New("Cannot connect to the Docker daemon") }
+	defer func() { connectDocker = original }()
+
+	var output bytes.Buffer
+	_, err := Doctor(context.Background(), &output, doctorBlueprint(), NewProviderFactory(), DoctorOptions{})
+	if err == nil || !strings.Contains(err.Error(), "1 of 2 preflight checks failed for blueprint 'network'") {
+		t.Errorf("Expected the unreachable runtime to fail the preflight, got: %v", err)
+	}
+	if !strings.Contains(output.String(), "container runtime: Cannot connect to the Docker daemon") {
+		t.Errorf("Expected the report to show the runtime failure, got:\n%s", output.String())
+	}
+}
@@ -0,0 +1,138 @@
+package app
+
+import (
+	"log/slog"
+
+	"klonekit/internal/scm"
+	"klonekit/pkg/blueprint"
+)
+
+// logEffectiveConfig logs a redacted snapshot of the blueprint after overrides have been applied,
+// so a run can be reproduced and debugged from its log. Tokens, secret variables and backend
+// credentials are masked.
+func logEffectiveConfig(bp *blueprint.Blueprint, opts ApplyOptions) {
+	slog.Info("Effective configuration", effectiveConfigAttrs(bp, opts)...)
+}
+
+// effectiveConfigAttrs builds the log attributes describing the effective configuration.
+func effectiveConfigAttrs(bp *blueprint.Blueprint, opts ApplyOptions) []any {
+	spec := bp.Spec
+
+	var stages []string
+	for _, stage := range []ExecutionStage{StageScaffold, StageSCM, StageProvision} {
+		if !containsString(opts.SkipStages, string(stage)) {
+			stages = append(stages, string(stage))
+		}
+	}
+
+	backendEnv := make([]string, 0, len(spec.Provision.BackendEnv))
+	for _, env := range spec.Provision.BackendEnv {
+		backendEnv = append(backendEnv, env.Name)
+	}
+	env := make([]string, 0, len(spec.Provision.Env))
+	for _, variable := range spec.Provision.Env {
+		env = append(env, variable.Name)
+	}
+
+	return []any{
+		"blueprint", bp.Metadata.Name,
+		"kind", bp.Kind,
+		"stages", stages,
+		"skipStages", opts.SkipStages,
+		"dryRun", opts.DryRun,
+		"planReal", opts.PlanReal,
+		"checkDocker", opts.CheckDocker,
+		"autoApprove", opts.AutoApprove,
+		slog.Group("scm",
+			"provider", spec.SCM.Provider,
+			"url", spec.SCM.URL,
+			"token", maskSecret(spec.SCM.Token),
+			"project", spec.SCM.Project.Namespace+"/"+spec.SCM.Project.Name,
+			"visibility", spec.SCM.Project.Visibility,
+			"defaultBranch", spec.SCM.Project.DefaultBranch,
+			"lfsEnabled", spec.SCM.Project.LFS(),
+			"protectedBranches", spec.SCM.Project.BranchProtection.Branches,
+			"ciVariables", ciVariableKeys(spec.SCM.Project.CIVariables),
+			"webhooks", len(spec.SCM.Project.Webhooks),
+			"exportOutputs", len(spec.SCM.Project.ExportOutputs),
+			"staging", spec.SCM.Staging,
+			"protocol", spec.SCM.Protocol,
+			"signed", spec.SCM.Signing.KeyFile != "" || spec.SCM.Signing.KeyFromEnv != "",
+		),
+		slog.Group("cloud",
+			"provider", spec.Cloud.Provider,
+			"region", spec.Cloud.Region,
+			"profile", spec.Cloud.Profile,
+		),
+		slog.Group("scaffold",
+			"source", spec.Scaffold.Source,
+			"destination", spec.Scaffold.Destination,
+			"respectGitignore", spec.Scaffold.RespectGitignore,
+			"skipSourceCheck", spec.Scaffold.SkipSourceCheck,
+			"manifest", spec.Scaffold.ManifestPath(),
+		),
+		slog.Group("provision",
+			"image", spec.Provision.Image,
+			"engine", spec.Provision.Engine,
+			"terraformVersion", spec.Provision.Terraform.Version,
+			"dataDir", spec.Provision.DataDir,
+			"networkMode", spec.Provision.NetworkMode,
+			"memory", spec.Provision.Resources.Memory,
+			"cpus", spec.Provision.Resources.CPUs,
+			"timeout", spec.Provision.Timeout,
+			"skipCredentialCheck", spec.Provision.SkipCredentialCheck,
+			"parallel", spec.Provision.Parallel,
+			"prePull", spec.Provision.PrePull,
+			"skipPermissionFix", spec.Provision.SkipPermissionFix,
+			"useSavedPlan", spec.Provision.UseSavedPlan,
+			"confirmApply", spec.Provision.ConfirmApply,
+			"backendEnv", backendEnv,
+			"env", env,
+			"backendMigration", spec.Provision.BackendMigration,
+			"statePushURL", spec.Provision.StatePush.RedactedURL(),
+			"costEstimateCommand", spec.Provision.CostEstimateCommand,
+			"planJSON", spec.Provision.PlanJSON,
+			"policyCommand", spec.Provision.PolicyCommand,
+			"artifactsDir", spec.Provision.Artifacts.Dir,
+			"variablesMode", spec.Provision.VariablesMode,
+			"matrix", matrixNames(spec.Provision.Matrix),
+		),
+		"variables", blueprint.MaskSecretVariables(spec.Variables, spec.SecretVariables),
+	}
+}
+
+// maskSecret hides a secret value while still showing whether it was set.
+func maskSecret(value string) string {
+	if value == "" {
+		return ""
+	}
+	return scm.RedactedPlaceholder
+}
+
+// containsString reports whether values contains s.
+func containsString(values []string, s string) bool {
+	for _, value := range values {
+		if value == s {
+			return true
+		}
+	}
+	return false
+}
+
+// matrixNames returns the names of the provision matrix entries.
+func matrixNames(matrix []blueprint.MatrixEntry) []string {
+	names := make([]string, 0, len(matrix))
+	for _, entry := range matrix {
+		names = append(names, entry.Name)
+	}
+	return names
+}
+
+// ciVariableKeys returns the keys of the CI variables; their values may be secret.
+func ciVariableKeys(variables []blueprint.CIVariable) []string {
+	keys := make([]string, 0, len(variables))
+	for _, variable := range variables {
+		keys = append(keys, variable.Key)
+	}
+	return keys
+}
@@ -0,0 +1,57 @@
+package app
+
+import (
+	"bytes"
+	"log/slog"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestApply_LogsEffectiveConfiguration(t *testing.T) {
+	os.Remove(StateFileName)
+	defer os.Remove(StateFileName)
+
+	var logs bytes.Buffer
+	previous := slog.Default()
+	slog.SetDefault(slog.New(slog.NewJSONHandler(&logs, nil)))
+	defer slog.SetDefault(previous)
+
+	tempDir := t.TempDir()
+	blueprintFile, err := createValidTestBlueprint(tempDir)
+	if err != nil {
+		t.Fatalf("Failed to create test blueprint: %s", err)
+	}
+
+	if err := ApplyWithOptions(blueprintFile, ApplyOptions{DryRun: true, SkipStages: []string{"provision"}}); err != nil {
+		t.Fatalf("Unexpected error in dry-run mode: %s", err)
+	}
+
+	var entry string
+	for _, line := range strings.Split(logs.String(), "\n") {
+		if strings.Contains(line, `"msg":"Effective configuration"`) {
+			entry = line
+			break
+		}
+	}
+	if entry == "" {
+		t.Fatalf("Expected an effective configuration log entry, got:\n%s", logs.String())
+	}
+
+	for _, part := range []string{
+		`"blueprint":"integration-test"`,
+		`"stages":["scaffold","scm"]`,
+		`"skipStages":["provision"]`,
+		`"provider":"gitlab"`,
+		`"provider":"aws"`,
+		`"region":"us-east-1"`,
+		`"token":"REDACTED"`,
+	} {
+		if !strings.Contains(entry, part) {
+			t.Errorf("Expected log entry to contain %s, got: %s", part, entry)
+		}
+	}
+	if strings.Contains(logs.String(), "test-token") {
+		t.Errorf("Expected token value to be masked in logs, got: %s", entry)
+	}
+}
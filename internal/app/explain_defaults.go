@@ -0,0 +1,128 @@
+package app
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+
+	"klonekit/internal/parser"
+	"klonekit/internal/provisioner"
+	"klonekit/internal/scm"
+	"klonekit/pkg/blueprint"
+)
+
+// Sources of an effective configuration value, reported by --explain-defaults
+const (
+	ValueSourceBlueprint   = "blueprint"
+	ValueSourceFlag        = "flag"
+	ValueSourceUserConfig  = "user config"  // An environment variable of the user, e.g. KLONEKIT_SKIP_CREDENTIAL_CHECK
+	ValueSourceVersionFile = "version file" // The .terraform-version file of the scaffold source
+	ValueSourceDefault     = "default"
+)
+
+// ExplainedValue is the effective value of a blueprint field and where it came from.
+type ExplainedValue struct {
+	Field  string
+	Value  string
+	Source string
+}
+
+// ExplainDefaults writes the effective value of the blueprint fields that have built-in
+// defaults, command-line overrides or user config, with the source of each, for every
+// blueprint in the file. Nothing is applied.
+func ExplainDefaults(w io.Writer, blueprintPath string, opts ApplyOptions) error {
+	if opts.Visibility != "" {
+		if err := scm.ValidateVisibility(opts.Visibility); err != nil {
+			return err
+		}
+	}
+
+	blueprints, err := parser.ParseAll(blueprintPath)
+	if err != nil {
+		return fmt.Errorf("blueprint parsing failed: %w", err)
+	}
+
+	for i, bp := range blueprints {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintf(w, "Blueprint: %s\n", bp.Metadata.Name)
+		table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(table, "FIELD\tVALUE\tSOURCE")
+		for _, value := range explainValues(bp, opts) {
+			fmt.Fprintf(table, "%s\t%s\t%s\n", value.Field, value.Value, value.Source)
+		}
+		table.Flush()
+	}
+	return nil
+}
+
+// explainValues returns the effective values of a parsed blueprint once the overrides of opts
+// are applied. The blueprint itself is left untouched.
+func explainValues(bp *blueprint.Blueprint, opts ApplyOptions) []ExplainedValue {
+	effective := *bp
+	applyOverrides(&effective, opts)
+	spec := &bp.Spec
+	effectiveSpec := &effective.Spec
+
+	// source returns the flag source when a flag overrode the field, or else the blueprint
+	// source when the blueprint set it, or else the default source
+	source := func(flagged, set bool) string {
+		switch {
+		case flagged:
+			return ValueSourceFlag
+		case set:
+			return ValueSourceBlueprint
+		default:
+			return ValueSourceDefault
+		}
+	}
+
+	staging := effectiveSpec.SCM.Staging
+	if staging == "" {
+		staging = scm.StagingStrict
+	}
+	engine := spec.Provision.Engine
+	if engine == "" {
+		engine = provisioner.EngineTerraform
+	}
+	variablesMode := spec.Provision.VariablesMode
+	if variablesMode == "" {
+		variablesMode = blueprint.VariablesModeTfvars
+	}
+
+	skipCredentialCheck := effectiveSpec.Provision.SkipCredentialCheck
+	skipCredentialCheckSource := source(opts.SkipCredentialCheck, spec.Provision.SkipCredentialCheck)
+	if !skipCredentialCheck && provisioner.SkipCredentialCheckFromEnv() {
+		skipCredentialCheck = true
+		skipCredentialCheckSource = ValueSourceUserConfig
+	}
+
+	imageSource := ValueSourceDefault
+	switch {
+	case spec.Provision.Image != "" || spec.Provision.Terraform.Version != "":
+		imageSource = ValueSourceBlueprint
+	case engine == provisioner.EngineTerraform && provisioner.SourceTerraformVersion(spec.Scaffold.Source) != "":
+		imageSource = ValueSourceVersionFile
+	}
+
+	return []ExplainedValue{
+		{"scaffold.destination", effectiveSpec.Scaffold.Destination, source(opts.ScaffoldDir != "", true)},
+		{"scaffold.tfvarsFilename", spec.Scaffold.TfvarsFile(), source(false, spec.Scaffold.TfvarsFilename != "")},
+		{"scaffold.skipSourceCheck", strconv.FormatBool(effectiveSpec.Scaffold.SkipSourceCheck), source(opts.SkipSourceCheck, spec.Scaffold.SkipSourceCheck)},
+		{"scaffold.manifest", effectiveSpec.Scaffold.ManifestPath(), source(opts.ScaffoldDir != "" && spec.Scaffold.Manifest == "", spec.Scaffold.Manifest != "")},
+		{"scm.staging", staging, source(opts.Staging != "", spec.SCM.Staging != "")},
+		{"scm.project.visibility", effectiveSpec.SCM.Project.Visibility, source(opts.Visibility != "", true)},
+		{"scm.project.defaultBranch", spec.SCM.Project.Branch(), source(false, spec.SCM.Project.DefaultBranch != "")},
+		{"scm.project.lfsEnabled", strconv.FormatBool(spec.SCM.Project.LFS()), source(false, spec.SCM.Project.LFSEnabled != nil)},
+		{"provision.engine", engine, source(false, spec.Provision.Engine != "")},
+		{"provision.image", provisioner.TerraformImage(spec), imageSource},
+		{"provision.variablesMode", variablesMode, source(false, spec.Provision.VariablesMode != "")},
+		{"provision.skipCredentialCheck", strconv.FormatBool(skipCredentialCheck), skipCredentialCheckSource},
+		{"provision.parallel", strconv.FormatBool(effectiveSpec.Provision.Parallel), source(opts.Parallel, spec.Provision.Parallel)},
+		{"provision.planJSON", effectiveSpec.Provision.PlanJSON, source(opts.PlanJSON != "", spec.Provision.PlanJSON != "")},
+		{"provision.artifacts.dir", effectiveSpec.Provision.Artifacts.Dir, source(opts.ArtifactsDir != "", spec.Provision.Artifacts.Dir != "")},
+		{"provision.timeout", effectiveSpec.Provision.Timeout, source(opts.Timeout != nil, spec.Provision.Timeout != "")},
+	}
+}
@@ -0,0 +1,128 @@
+package app
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"klonekit/internal/provisioner"
+	"klonekit/pkg/blueprint"
+)
+
+func TestExplainValues(t *testing.T) {
+	source := t.TempDir()
+	if err := os.WriteFile(filepath.Join(source, ".terraform-version"), []byte("1.7.5\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	bp := &blueprint.Blueprint{
+		Metadata: blueprint.Metadata{Name: "network"},
+		Spec: blueprint.Spec{
+			SCM: blueprint.SCMProvider{
+				Staging: "best-effort",
+				Project: blueprint.ProjectConfig{Visibility: "private", DefaultBranch: "main"},
+			},
+			Cloud:     blueprint.CloudProvider{Provider: "aws", Region: "us-east-1"},
+			Scaffold:  blueprint.Scaffold{Source: source, Destination: "infra"},
+			Provision: blueprint.Provision{Parallel: true},
+		},
+	}
+
+	values := explainValues(bp, ApplyOptions{Visibility: "internal", ScaffoldDir: "build/infra"})
+
+	want := map[string]ExplainedValue{
+		"scaffold.destination":      {Value: "build/infra", Source: ValueSourceFlag},
+		"scaffold.tfvarsFilename":   {Value: "terraform.tfvars.json", Source: ValueSourceDefault},
+		"scaffold.manifest":         {Value: filepath.Join("build", "infra.klonekit.manifest.json"), Source: ValueSourceFlag},
+		"scm.staging":               {Value: "best-effort", Source: ValueSourceBlueprint},
+		"scm.project.visibility":    {Value: "internal", Source: ValueSourceFlag},
+		"scm.project.defaultBranch": {Value: "main", Source: ValueSourceBlueprint},
+		"scm.project.lfsEnabled":    {Value: "true", Source: ValueSourceDefault},
+		"provision.engine":          {Value: "terraform", Source: ValueSourceDefault},
+		"provision.image":           {Value: "hashicorp/terraform:1.7.5", Source: ValueSourceVersionFile},
+		"provision.variablesMode":   {Value: "tfvars", Source: ValueSourceDefault},
+		"provision.parallel":        {Value: "true", Source: ValueSourceBlueprint},
+	}
+	for _, value := range values {
+		expected, ok := want[value.Field]
+		if !ok {
+			continue
+		}
+		delete(want, value.Field)
+		if value.Value != expected.Value || value.Source != expected.Source {
+			t.Errorf("%s: expected %q from %s, got %q from %s", value.Field, expected.Value, expected.Source, value.Value, value.Source)
+		}
+	}
+	for field := range want {
+		t.Errorf("Expected %s to be explained", field)
+	}
+
+	if bp.Spec.Scaffold.Destination != "infra" || bp.Spec.SCM.Project.Visibility != "private" {
+		t.Errorf("Expected the blueprint to be left untouched, got %+v", bp.Spec)
+	}
+}
+
+func TestExplainDefaults(t *testing.T) {
+	tempDir := chdirTemp(t)
+	blueprintFile, err := createValidTestBlueprint(tempDir)
+	if err != nil {
+		t.Fatalf("Failed to create test blueprint: %s", err)
+	}
+
+	var output bytes.Buffer
+	if err := ExplainDefaults(&output, blueprintFile, ApplyOptions{Staging: "best-effort"}); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	for _, row := range []string{
+		`Blueprint: integration-test`,
+		`scm\.project\.visibility\s+private\s+blueprint`,
+		`scm\.staging\s+best-effort\s+flag`,
+		`scm\.project\.defaultBranch\s+main\s+default`,
+		`provision\.image\s+hashicorp/terraform:1\.8\.0\s+default`,
+	} {
+		if !regexp.MustCompile(row).MatchString(output.String()) {
+			t.Errorf("Expected a row matching %q, got:\n%s", row, output.String())
+		}
+	}
+	if _, err := os.Stat(filepath.Join(tempDir, StateFileName)); !os.IsNotExist(err) {
+		t.Error("Expected explaining the defaults not to start a run")
+	}
+}
+
+func TestExplainValues_UserConfig(t *testing.T) {
+	bp := &blueprint.Blueprint{
+		Metadata: blueprint.Metadata{Name: "network"},
+		Spec: blueprint.Spec{
+			Cloud:    blueprint.CloudProvider{Provider: "aws", Region: "us-east-1"},
+			Scaffold: blueprint.Scaffold{Source: t.TempDir(), Destination: "infra"},
+		},
+	}
+
+	tests := []struct {
+		name string
+		env  string
+		opts ApplyOptions
+		want ExplainedValue
+	}{
+		{name: "default", want: ExplainedValue{Value: "false", Source: ValueSourceDefault}},
+		{name: "user config", env: "true", want: ExplainedValue{Value: "true", Source: ValueSourceUserConfig}},
+		{name: "flag wins over user config", env: "true", opts: ApplyOptions{SkipCredentialCheck: true}, want: ExplainedValue{Value: "true", Source: ValueSourceFlag}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(provisioner.SkipCredentialCheckEnv, tt.env)
+			for _, value := range explainValues(bp, tt.opts) {
+				if value.Field != "provision.skipCredentialCheck" {
+					continue
+				}
+				if value.Value != tt.want.Value || value.Source != tt.want.Source {
+					t.Errorf("Expected %q from %s, got %q from %s", tt.want.Value, tt.want.Source, value.Value, value.Source)
+				}
+				return
+			}
+			t.Error("Expected provision.skipCredentialCheck to be explained")
+		})
+	}
+}
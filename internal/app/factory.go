@@ -6,12 +6,27 @@ import (
 	"klonekit/internal/provisioner"
 	"klonekit/internal/runtime"
 	"klonekit/internal/scm"
+	runtimePkg "klonekit/pkg/runtime"
 )
 
 // ProviderFactory provides methods to create SCM and provisioning providers
 // based on string identifiers. This implements the Factory pattern to decouple
 // the application orchestrator from concrete provider implementations.
-type ProviderFactory struct{}
+type ProviderFactory struct {
+	// containerRuntime replaces the Docker runtime of provisioners when set (used in tests).
+	containerRuntime runtimePkg.ContainerRuntime
+	// scmProvider replaces the SCM provider when set (used in tests).
+	scmProvider scm.ScmProvider
+}
+
+// connectDocker checks that a Docker daemon is reachable; tests replace it.
+var connectDocker = func() error {
+	dockerRuntime, err := runtime.NewDockerRuntime()
+	if err != nil {
+		return err
+	}
+	return dockerRuntime.Close()
+}
 
 // NewProviderFactory creates a new instance of ProviderFactory.
 func NewProviderFactory() *ProviderFactory {
@@ -19,15 +34,25 @@ func NewProviderFactory() *ProviderFactory {
 }
 
 // GetScmProvider returns the appropriate SCM provider implementation
-// based on the provider name from the blueprint configuration.
-func (f *ProviderFactory) GetScmProvider(providerName string) (scm.ScmProvider, error) {
+// based on the provider name from the blueprint configuration, for the
+// instance at url (spec.scm.url).
+func (f *ProviderFactory) GetScmProvider(providerName, url string) (scm.ScmProvider, error) {
+	if f.scmProvider != nil {
+		return f.scmProvider, nil
+	}
 	switch providerName {
 	case "gitlab":
-		provider, err := scm.NewGitLabProvider()
+		provider, err := scm.NewGitLabProviderWithURL(url)
 		if err != nil {
 			return nil, fmt.Errorf("failed to create GitLab provider: %w", err)
 		}
 		return provider, nil
+	case "github":
+		provider, err := scm.NewGitHubProviderWithURL(url)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create GitHub provider: %w", err)
+		}
+		return provider, nil
 	default:
 		return nil, fmt.Errorf("unsupported SCM provider: %s", providerName)
 	}
@@ -38,6 +63,10 @@ func (f *ProviderFactory) GetScmProvider(providerName string) (scm.ScmProvider,
 func (f *ProviderFactory) GetProvisioner(providerName string) (provisioner.Provisioner, error) {
 	switch providerName {
 	case "aws":
+		if f.containerRuntime != nil {
+			return provisioner.NewTerraformDockerProvisioner(f.containerRuntime), nil
+		}
+
 		// Create Docker runtime instance for Terraform
 		dockerRuntime, err := runtime.NewDockerRuntime()
 		if err != nil {
@@ -47,4 +76,13 @@ func (f *ProviderFactory) GetProvisioner(providerName string) (provisioner.Provi
 	default:
 		return nil, fmt.Errorf("unsupported provisioner: %s", providerName)
 	}
-}
\ No newline at end of file
+}
+
+// CheckContainerRuntime reports whether the container runtime used by provisioners is
+// available, without creating a provisioner.
+func (f *ProviderFactory) CheckContainerRuntime() error {
+	if f.containerRuntime != nil {
+		return nil
+	}
+	return connectDocker()
+}
@@ -1,13 +1,18 @@
 package app
 
 import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"strings"
 	"testing"
 
 	"klonekit/internal/scm"
+	"klonekit/pkg/blueprint"
 )
 
 func TestProviderFactory_GetScmProvider(t *testing.T) {
+	t.Setenv("GITHUB_TOKEN", "")
 	factory := NewProviderFactory()
 
 	tests := []struct {
@@ -24,10 +29,16 @@ func TestProviderFactory_GetScmProvider(t *testing.T) {
 			errorMsg:     "failed to create GitLab provider",
 		},
 		{
-			name:         "Unsupported provider",
+			name:         "Valid GitHub provider",
 			providerName: "github",
+			expectError:  true, // Expected in test environment due to missing GITHUB_TOKEN
+			errorMsg:     "failed to create GitHub provider",
+		},
+		{
+			name:         "Unsupported provider",
+			providerName: "bitbucket",
 			expectError:  true,
-			errorMsg:     "unsupported SCM provider: github",
+			errorMsg:     "unsupported SCM provider: bitbucket",
 		},
 		{
 			name:         "Empty provider name",
@@ -45,7 +56,7 @@ func TestProviderFactory_GetScmProvider(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			provider, err := factory.GetScmProvider(tt.providerName)
+			provider, err := factory.GetScmProvider(tt.providerName, "")
 
 			if tt.expectError {
 				if err == nil {
@@ -172,7 +183,7 @@ func TestNewProviderFactory(t *testing.T) {
 	}
 
 	// Verify factory can create providers
-	scmProvider, err := factory.GetScmProvider("gitlab")
+	scmProvider, err := factory.GetScmProvider("gitlab", "")
 	if err != nil && !strings.Contains(err.Error(), "GITLAB_PRIVATE_TOKEN") {
 		t.Errorf("Unexpected error from factory: %s", err)
 	}
@@ -193,7 +204,7 @@ func TestProviderFactory_Integration(t *testing.T) {
 	// Test that all supported providers can be created (even if they fail due to missing credentials)
 	supportedScmProviders := []string{"gitlab"}
 	for _, provider := range supportedScmProviders {
-		_, err := factory.GetScmProvider(provider)
+		_, err := factory.GetScmProvider(provider, "")
 		// We expect GitLab to fail with authentication error in test environment
 		if err != nil && !strings.Contains(err.Error(), "GITLAB_PRIVATE_TOKEN") {
 			t.Errorf("Unexpected error for SCM provider %s: %s", provider, err)
@@ -216,4 +227,33 @@ func TestProviderFactory_Integration(t *testing.T) {
 			t.Errorf("Expected provisioner for %s to be non-nil", provider)
 		}
 	}
-}
\ No newline at end of file
+}
+
+// TestProviderFactory_GetScmProviderUsesBlueprintURL verifies that the SCM provider talks to
+// the GitLab instance named by spec.scm.url
+func TestProviderFactory_GetScmProviderUsesBlueprintURL(t *testing.T) {
+	t.Setenv("GITLAB_PRIVATE_TOKEN", "test-token")
+
+	var lookedUp bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v4/projects/platform/infra" {
+			lookedUp = true
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusForbidden)
+		fmt.Fprint(w, `{"message":"403 Forbidden"}`)
+	}))
+	defer server.Close()
+
+	provider, err := NewProviderFactory().GetScmProvider("gitlab", server.URL+"/")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	spec := &blueprint.Spec{SCM: blueprint.SCMProvider{Project: blueprint.ProjectConfig{Name: "infra", Namespace: "platform"}}}
+	if err := provider.CreateRepo(spec); err == nil {
+		t.Fatal("Expected the refused requests to fail the repository creation")
+	}
+	if !lookedUp {
+		t.Error("Expected the project to be looked up on the blueprint's GitLab instance")
+	}
+}
@@ -0,0 +1,145 @@
+package app
+
+import (
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// ErrRunLocked is returned by an apply started while another apply run holds the lock file.
+var ErrRunLocked = stderrors.New("another apply run is in progress")
+
+// staleLockAge is how old a lock gets before it is taken to be left behind by a crashed run,
+// for locks whose process can't be checked (e.g. held from another host).
+const staleLockAge = 24 * time.Hour
+
+// runLock is the content of the lock file, identifying the apply run holding it
+type runLock struct {
+	PID       int       `json:"pid"`
+	Hostname  string    `json:"hostname"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// acquireLock creates the lock file guarding the state file and returns a function that
+// releases it. It fails with ErrRunLocked if another run holds the lock; a lock left behind
+// by a crashed run is taken over.
+func acquireLock() (func(), error) {
+	hostname, _ := os.Hostname()
+	lock := runLock{PID: os.Getpid(), Hostname: hostname, CreatedAt: time.Now()}
+	data, err := json.Marshal(lock)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize lock: %w", err)
+	}
+
+	for {
+		err := writeLockFile(data)
+		if err == nil {
+			break
+		}
+		if !os.IsExist(err) {
+			return nil, fmt.Errorf("failed to create lock file: %w", err)
+		}
+
+		holder := readLock(LockFileName)
+		if !holder.stale(hostname) {
+			return nil, fmt.Errorf("%w: %s is held by %s; wait for it to finish, or run 'klonekit abort' if it crashed",
+				ErrRunLocked, LockFileName, holder.describe())
+		}
+		fmt.Printf("%s⚠️  Removing a stale lock left by %s%s\n", ColorYellow, holder.describe(), ColorReset)
+		slog.Warn("Removing stale lock file", "path", LockFileName, "pid", holder.PID, "hostname", holder.Hostname, "createdAt", holder.CreatedAt)
+		if err := removeStaleLock(hostname); err != nil {
+			return nil, err
+		}
+	}
+
+	return func() {
+		if err := removeLockFile(); err != nil {
+			slog.Warn("Failed to release the lock file", "path", LockFileName, "error", err)
+		}
+	}, nil
+}
+
+// writeLockFile creates the lock file with data, failing if it already exists.
+func writeLockFile(data []byte) error {
+	file, err := os.OpenFile(LockFileName, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(data); err != nil {
+		_ = file.Close()
+		_ = os.Remove(LockFileName)
+		return err
+	}
+	return file.Close()
+}
+
+// removeStaleLock removes the lock file if it is still stale. The lock is first renamed aside
+// and checked again there, so that of several runs taking over the same stale lock only one
+// removes it, and a lock written in the meantime by a run that took it over first is put back.
+func removeStaleLock(hostname string) error {
+	aside, err := os.CreateTemp(filepath.Dir(LockFileName), filepath.Base(LockFileName)+".stale-*")
+	if err != nil {
+		return fmt.Errorf("failed to take over stale lock file: %w", err)
+	}
+	_ = aside.Close()
+	defer os.Remove(aside.Name())
+
+	if err := os.Rename(LockFileName, aside.Name()); err != nil {
+		if os.IsNotExist(err) {
+			// Another run removed the stale lock first
+			return nil
+		}
+		return fmt.Errorf("failed to take over stale lock file: %w", err)
+	}
+	if readLock(aside.Name()).stale(hostname) {
+		return nil
+	}
+
+	// Link rather than rename back, so a lock created since by yet another run is not replaced
+	if err := os.Link(aside.Name(), LockFileName); err != nil {
+		return fmt.Errorf("failed to restore the lock file of a running apply: %w", err)
+	}
+	return nil
+}
+
+// readLock returns the run holding the lock file at path. A lock file written by an older
+// version, without the holder's details, is dated by its modification time.
+func readLock(path string) runLock {
+	var lock runLock
+	if data, err := os.ReadFile(path); err == nil {
+		_ = json.Unmarshal(data, &lock)
+	}
+	if lock.CreatedAt.IsZero() {
+		if info, err := os.Stat(path); err == nil {
+			lock.CreatedAt = info.ModTime()
+		}
+	}
+	return lock
+}
+
+// stale reports whether the lock was left behind by a crashed run: its process on this host
+// is gone, or it is older than staleLockAge.
+func (l runLock) stale(hostname string) bool {
+	if l.PID > 0 && l.Hostname == hostname && !processAlive(l.PID) {
+		return true
+	}
+	return !l.CreatedAt.IsZero() && time.Since(l.CreatedAt) > staleLockAge
+}
+
+// running reports whether the lock is held by a process that is still running on this host.
+func (l runLock) running(hostname string) bool {
+	return l.PID > 0 && l.Hostname == hostname && processAlive(l.PID)
+}
+
+// describe returns the holder of the lock for messages, e.g.
+// "PID 4242 on build-7 since 2024-05-14T09:12:33Z".
+func (l runLock) describe() string {
+	if l.PID == 0 {
+		return fmt.Sprintf("a run started %s", l.CreatedAt.Format(time.RFC3339))
+	}
+	return fmt.Sprintf("PID %d on %s since %s", l.PID, l.Hostname, l.CreatedAt.Format(time.RFC3339))
+}
@@ -0,0 +1,9 @@
+//go:build !unix
+
+package app
+
+// processAlive reports true, as the process can't be checked on this platform; the lock's
+// age decides whether it is stale.
+func processAlive(pid int) bool {
+	return true
+}
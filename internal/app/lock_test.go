@@ -0,0 +1,162 @@
+package app
+
+import (
+	"encoding/json"
+	stderrors "errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// writeTestLock writes a lock file held by lock
+func writeTestLock(t *testing.T, lock runLock) {
+	t.Helper()
+	data, err := json.Marshal(lock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(LockFileName, data, 0600); err != nil {
+		t.Fatalf("Failed to write lock file: %s", err)
+	}
+}
+
+func TestApply_LockHeld(t *testing.T) {
+	tempDir := chdirTemp(t)
+	blueprintFile, err := createValidTestBlueprint(tempDir)
+	if err != nil {
+		t.Fatalf("Failed to create test blueprint: %s", err)
+	}
+	hostname, _ := os.Hostname()
+	writeTestLock(t, runLock{PID: os.Getpid(), Hostname: hostname, CreatedAt: time.Now()})
+
+	err = ApplyWithOptions(blueprintFile, ApplyOptions{DryRun: true})
+	if !stderrors.Is(err, ErrRunLocked) {
+		t.Fatalf("Expected a lock contention error, got: %v", err)
+	}
+	if !strings.Contains(err.Error(), "klonekit abort") {
+		t.Errorf("Expected the error to explain how to recover, got: %s", err)
+	}
+	if _, err := os.Stat(LockFileName); err != nil {
+		t.Error("Expected the other run's lock to be kept")
+	}
+	if _, err := os.Stat(StateFileName); !os.IsNotExist(err) {
+		t.Error("Expected the locked out run not to touch the state file")
+	}
+}
+
+func TestApply_ReleasesLock(t *testing.T) {
+	tempDir := chdirTemp(t)
+	blueprintFile, err := createValidTestBlueprint(tempDir)
+	if err != nil {
+		t.Fatalf("Failed to create test blueprint: %s", err)
+	}
+
+	if err := ApplyWithOptions(blueprintFile, ApplyOptions{DryRun: true}); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if _, err := os.Stat(LockFileName); !os.IsNotExist(err) {
+		t.Error("Expected the lock to be released once the run is done")
+	}
+}
+
+func TestApply_TakesOverStaleLock(t *testing.T) {
+	tempDir := chdirTemp(t)
+	blueprintFile, err := createValidTestBlueprint(tempDir)
+	if err != nil {
+		t.Fatalf("Failed to create test blueprint: %s", err)
+	}
+	writeTestLock(t, runLock{PID: 4242, Hostname: "build-agent-7", CreatedAt: time.Now().Add(-2 * staleLockAge)})
+
+	if err := ApplyWithOptions(blueprintFile, ApplyOptions{DryRun: true}); err != nil {
+		t.Fatalf("Expected the stale lock to be taken over, got: %s", err)
+	}
+}
+
+func TestAcquireLock_ConcurrentStaleTakeover(t *testing.T) {
+	chdirTemp(t)
+	writeTestLock(t, runLock{PID: 4242, Hostname: "build-agent-7", CreatedAt: time.Now().Add(-2 * staleLockAge)})
+
+	const runs = 8
+	var wg sync.WaitGroup
+	errs := make([]error, runs)
+	for i := range runs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, errs[i] = acquireLock()
+		}()
+	}
+	wg.Wait()
+
+	acquired := 0
+	for _, err := range errs {
+		switch {
+		case err == nil:
+			acquired++
+		case !stderrors.Is(err, ErrRunLocked):
+			t.Errorf("Expected the other runs to be locked out, got: %s", err)
+		}
+	}
+	if acquired != 1 {
+		t.Errorf("Expected exactly one run to take over the stale lock, got %d", acquired)
+	}
+	if holder := readLock(LockFileName); holder.PID != os.Getpid() {
+		t.Errorf("Expected the lock to be held by the run that took it over, got %+v", holder)
+	}
+	if leftovers, _ := filepath.Glob(LockFileName + ".stale-*"); len(leftovers) > 0 {
+		t.Errorf("Expected no stale lock files to be left behind, got %v", leftovers)
+	}
+}
+
+func TestRemoveStaleLock_RestoresLiveLock(t *testing.T) {
+	chdirTemp(t)
+	hostname, _ := os.Hostname()
+	live := runLock{PID: os.Getpid(), Hostname: hostname, CreatedAt: time.Now().UTC().Truncate(time.Second)}
+	writeTestLock(t, live)
+
+	if err := removeStaleLock(hostname); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if holder := readLock(LockFileName); holder != live {
+		t.Errorf("Expected the lock of the running apply to be put back, got %+v", holder)
+	}
+	if leftovers, _ := filepath.Glob(LockFileName + ".stale-*"); len(leftovers) > 0 {
+		t.Errorf("Expected no stale lock files to be left behind, got %v", leftovers)
+	}
+}
+
+func TestRunLock_Stale(t *testing.T) {
+	hostname, _ := os.Hostname()
+
+	type lockCase struct {
+		name  string
+		lock  runLock
+		stale bool
+	}
+	tests := []lockCase{
+		{name: "live process", lock: runLock{PID: os.Getpid(), Hostname: hostname, CreatedAt: time.Now()}},
+		{name: "other host", lock: runLock{PID: os.Getpid(), Hostname: "build-agent-7", CreatedAt: time.Now()}},
+		{name: "older than the stale age", lock: runLock{PID: os.Getpid(), Hostname: "build-agent-7", CreatedAt: time.Now().Add(-2 * staleLockAge)}, stale: true},
+		{name: "legacy lock without holder", lock: runLock{CreatedAt: time.Now()}},
+	}
+	if runtime.GOOS != "windows" {
+		exited := exec.Command(os.Args[0], "-test.run=^$")
+		if err := exited.Run(); err != nil {
+			t.Fatalf("Failed to run a short-lived process: %s", err)
+		}
+		tests = append(tests, lockCase{name: "exited process", lock: runLock{PID: exited.Process.Pid, Hostname: hostname, CreatedAt: time.Now()}, stale: true})
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.lock.stale(hostname); got != tt.stale {
+				t.Errorf("Expected stale %v, got %v", tt.stale, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+
+	"klonekit/internal/provisioner"
+	"klonekit/internal/scm"
+	"klonekit/pkg/blueprint"
+)
+
+// ExportOutputs writes the Terraform outputs listed in spec.scm.project.exportOutputs to the
+// SCM project as CI/CD variables after the standalone provision command applied the blueprint.
+func ExportOutputs(bp *blueprint.Blueprint, terraformProvisioner provisioner.Provisioner) error {
+	return exportOutputs(NewProviderFactory(), &bp.Spec, terraformProvisioner)
+}
+
+// exportOutputs writes the Terraform outputs listed in spec.scm.project.exportOutputs to the
+// SCM project as CI/CD variables after an apply.
+func (s *ProvisionStage) exportOutputs(terraformProvisioner provisioner.Provisioner) error {
+	return exportOutputs(s.providerFactory, &s.blueprint.Spec, terraformProvisioner)
+}
+
+// exportOutputs exports the outputs of spec reported by the provisioner, using the SCM
+// provider of providerFactory.
+func exportOutputs(providerFactory *ProviderFactory, spec *blueprint.Spec, terraformProvisioner provisioner.Provisioner) error {
+	exports := spec.SCM.Project.ExportOutputs
+	if len(exports) == 0 || len(spec.Provision.Matrix) > 0 {
+		return nil
+	}
+
+	reporter, ok := terraformProvisioner.(provisioner.OutputReporter)
+	if !ok {
+		slog.Warn("The provisioner doesn't report outputs, so none are exported", "provider", spec.Cloud.Provider)
+		return nil
+	}
+	variables, err := outputVariables(exports, reporter.Outputs())
+	if err != nil {
+		return err
+	}
+
+	provider, err := providerFactory.GetScmProvider(spec.SCM.Provider, spec.SCM.URL)
+	if err != nil {
+		return fmt.Errorf("SCM provider initialization failed: %w", err)
+	}
+	exporter, ok := provider.(scm.VariableExporter)
+	if !ok {
+		return fmt.Errorf("%s provider cannot export outputs as CI variables", spec.SCM.Provider)
+	}
+	if err := exporter.ExportVariables(spec, variables); err != nil {
+		return err
+	}
+
+	fmt.Printf("%s📤 Exported %d Terraform outputs as %s CI variables%s\n", ColorGreen, len(variables), spec.SCM.Provider, ColorReset)
+	return nil
+}
+
+// outputVariables builds the CI variables for the exported outputs. String outputs are
+// exported as is, other types JSON-encoded. Sensitive outputs must be exported masked.
+func outputVariables(exports []blueprint.ExportedOutput, outputs map[string]provisioner.Output) ([]blueprint.CIVariable, error) {
+	variables := make([]blueprint.CIVariable, 0, len(exports))
+	for _, export := range exports {
+		output, ok := outputs[export.Output]
+		if !ok {
+			return nil, fmt.Errorf("output '%s' to export is not an output of the Terraform module", export.Output)
+		}
+		if output.Sensitive && !export.Masked {
+			return nil, fmt.Errorf("output '%s' is sensitive; set masked: true to export it", export.Output)
+		}
+
+		var value string
+		if err := json.Unmarshal(output.Value, &value); err != nil {
+			var compact bytes.Buffer
+			if err := json.Compact(&compact, output.Value); err != nil {
+				return nil, fmt.Errorf("output '%s' has an invalid value: %w", export.Output, err)
+			}
+			value = compact.String()
+		}
+		variables = append(variables, blueprint.CIVariable{
+			Key:              export.VariableKey(),
+			Value:            value,
+			Protected:        export.Protected,
+			Masked:           export.Masked,
+			EnvironmentScope: export.EnvironmentScope,
+		})
+	}
+	return variables, nil
+}
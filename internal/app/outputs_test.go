@@ -0,0 +1,159 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/pkg/stdcopy"
+
+	"klonekit/pkg/blueprint"
+	runtimePkg "klonekit/pkg/runtime"
+)
+
+// outputsRuntime is a container runtime whose terraform output -json returns outputs
+type outputsRuntime struct {
+	outputs  string
+	commands []string
+}
+
+func (r *outputsRuntime) PullImage(ctx context.Context, image string) error {
+	return nil
+}
+
+func (r *outputsRuntime) RunContainer(ctx context.Context, opts runtimePkg.RunOptions) (io.ReadCloser, error) {
+	r.commands = append(r.commands, strings.Join(opts.Command, " "))
+	if strings.Join(opts.Command, " ") == "output -json" {
+		var buf bytes.Buffer
+		_, _ = stdcopy.NewStdWriter(&buf, stdcopy.Stdout).Write([]byte(r.outputs))
+		return io.NopCloser(&buf), nil
+	}
+	return io.NopCloser(strings.NewReader("ok")), nil
+}
+
+// exportingScmProvider records the variables it is asked to export
+type exportingScmProvider struct {
+	exported []blueprint.CIVariable
+}
+
+func (p *exportingScmProvider) CreateRepo(spec *blueprint.Spec) error { return nil }
+
+func (p *exportingScmProvider) ExportVariables(spec *blueprint.Spec, variables []blueprint.CIVariable) error {
+	p.exported = append(p.exported, variables...)
+	return nil
+}
+
+const terraformOutputs = `{
+  "api_url": {"sensitive": false, "type": "string", "value": "https://api.example.com"},
+  "subnet_ids": {"sensitive": false, "type": ["list", "string"], "value": ["subnet-a", "subnet-b"]},
+  "db_password": {"sensitive": true, "type": "string", "value": "hunter2-hunter2"}
+}
+`
+
+// outputsBlueprint returns a blueprint with a scaffolded destination exporting outputs
+func outputsBlueprint(t *testing.T, exports []blueprint.ExportedOutput) *blueprint.Blueprint {
+	t.Helper()
+
+	home := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(home, ".aws"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("HOME", home)
+
+	destDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(destDir, "main.tf"), []byte(`output "api_url" { value = "x" }`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return &blueprint.Blueprint{
+		Metadata: blueprint.Metadata{Name: "outputs"},
+		Spec: blueprint.Spec{
+			SCM: blueprint.SCMProvider{
+				Provider: "gitlab",
+				Project:  blueprint.ProjectConfig{Name: "infra", Namespace: "platform", ExportOutputs: exports},
+			},
+			Cloud:     blueprint.CloudProvider{Provider: "aws", Region: "us-east-1"},
+			Scaffold:  blueprint.Scaffold{Destination: destDir},
+			Provision: blueprint.Provision{SkipCredentialCheck: true, SkipPermissionFix: true},
+		},
+	}
+}
+
+func TestProvisionStage_ExportsOutputsAsCIVariables(t *testing.T) {
+	bp := outputsBlueprint(t, []blueprint.ExportedOutput{
+		{Output: "api_url", Key: "API_URL"},
+		{Output: "subnet_ids", Protected: true},
+		{Output: "db_password", Key: "DB_PASSWORD", Masked: true},
+	})
+	containerRuntime := &outputsRuntime{outputs: terraformOutputs}
+	scmProvider := &exportingScmProvider{}
+	factory := &ProviderFactory{containerRuntime: containerRuntime, scmProvider: scmProvider}
+
+	stage := NewProvisionStage(bp, factory, false, true)
+	if err := stage.Execute(context.Background(), newState("klonekit.yaml", "run-1")); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	if got := strings.Join(containerRuntime.commands, ","); !strings.Contains(got, "apply -auto-approve,output -json") {
+		t.Errorf("Expected the outputs to be read after the apply, got: %s", got)
+	}
+
+	want := []blueprint.CIVariable{
+		{Key: "API_URL", Value: "https://api.example.com"},
+		{Key: "subnet_ids", Value: `["subnet-a","subnet-b"]`, Protected: true},
+		{Key: "DB_PASSWORD", Value: "hunter2-hunter2", Masked: true},
+	}
+	if len(scmProvider.exported) != len(want) {
+		t.Fatalf("Expected %d exported variables, got %+v", len(want), scmProvider.exported)
+	}
+	for i, variable := range want {
+		if scmProvider.exported[i] != variable {
+			t.Errorf("Expected variable %+v, got %+v", variable, scmProvider.exported[i])
+		}
+	}
+}
+
+func TestProvisionStage_ExportOutputsErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		export  blueprint.ExportedOutput
+		wantErr string
+	}{
+		{name: "unknown output", export: blueprint.ExportedOutput{Output: "api_endpoint"}, wantErr: "output 'api_endpoint' to export is not an output"},
+		{name: "sensitive output unmasked", export: blueprint.ExportedOutput{Output: "db_password"}, wantErr: "set masked: true"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bp := outputsBlueprint(t, []blueprint.ExportedOutput{tt.export})
+			scmProvider := &exportingScmProvider{}
+			factory := &ProviderFactory{containerRuntime: &outputsRuntime{outputs: terraformOutputs}, scmProvider: scmProvider}
+
+			err := NewProvisionStage(bp, factory, false, true).Execute(context.Background(), newState("klonekit.yaml", "run-1"))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) || !strings.Contains(err.Error(), "infrastructure was provisioned") {
+				t.Errorf("Expected an error containing %q, got: %v", tt.wantErr, err)
+			}
+			if len(scmProvider.exported) != 0 {
+				t.Errorf("Expected nothing to be exported, got %+v", scmProvider.exported)
+			}
+		})
+	}
+}
+
+func TestProvisionStage_NoOutputsWithoutExports(t *testing.T) {
+	bp := outputsBlueprint(t, nil)
+	containerRuntime := &outputsRuntime{outputs: terraformOutputs}
+	factory := &ProviderFactory{containerRuntime: containerRuntime, scmProvider: &exportingScmProvider{}}
+
+	if err := NewProvisionStage(bp, factory, false, true).Execute(context.Background(), newState("klonekit.yaml", "run-1")); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	for _, command := range containerRuntime.commands {
+		if command == "output -json" {
+			t.Error("Expected the outputs not to be read without exportOutputs")
+		}
+	}
+}
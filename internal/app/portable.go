@@ -0,0 +1,208 @@
+package app
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// StateBundleVersion is the format version of portable state bundles.
+const StateBundleVersion = "1"
+
+// StateBundle is a portable copy of a run's execution state, so a run started on one
+// machine can be resumed on another (e.g. by a later job of a sharded CI pipeline).
+// The scaffold output itself isn't included; it is referenced by path and file digests so
+// the importing machine can check that it restored the same files.
+type StateBundle struct {
+	BundleVersion string             `json:"bundle_version"`
+	RunID         string             `json:"run_id"`
+	ExportedAt    time.Time          `json:"exported_at"`
+	State         ExecutionState     `json:"state"`
+	Scaffold      *ScaffoldReference `json:"scaffold,omitempty"`
+}
+
+// ScaffoldReference identifies the scaffold output of the exported run.
+type ScaffoldReference struct {
+	Destination string            `json:"destination"`
+	Files       map[string]string `json:"files"` // Slash-separated relative path to SHA-256 digest
+}
+
+// ImportOptions controls how a state bundle is imported.
+type ImportOptions struct {
+	// RunID, when set, is the run ID the bundle must belong to
+	RunID string
+	// Force replaces a local state file that belongs to a different run
+	Force bool
+}
+
+// ExportState writes the state of the current run to w as a portable bundle.
+func ExportState(w io.Writer) (*StateBundle, error) {
+	state, err := loadState()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load execution state: %w", err)
+	}
+	if state == nil {
+		return nil, fmt.Errorf("no run state found to export (%s is missing)", StateFileName)
+	}
+
+	bundle := &StateBundle{
+		BundleVersion: StateBundleVersion,
+		RunID:         state.RunID,
+		ExportedAt:    time.Now().UTC(),
+		State:         *state,
+	}
+
+	// Reference the scaffold output once the scaffold stage has produced it
+	if state.LastCompletedStage != "" {
+		destination, err := scaffoldDestination(state)
+		if err != nil {
+			return nil, err
+		}
+		reference, err := scaffoldReference(destination)
+		if err != nil {
+			return nil, err
+		}
+		bundle.Scaffold = reference
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(bundle); err != nil {
+		return nil, fmt.Errorf("failed to write state bundle: %w", err)
+	}
+
+	slog.Info("Exported run state", "runId", bundle.RunID, "lastCompletedStage", state.LastCompletedStage)
+	return bundle, nil
+}
+
+// ImportState reads a bundle written by ExportState and installs its state as the state of
+// the current directory, so the next apply resumes the run. The scaffold output referenced
+// by the bundle is checked and a warning is logged when it is missing or differs.
+func ImportState(r io.Reader, opts ImportOptions) (*StateBundle, error) {
+	var bundle StateBundle
+	if err := json.NewDecoder(r).Decode(&bundle); err != nil {
+		return nil, fmt.Errorf("failed to parse state bundle: %w", err)
+	}
+	if err := validateStateBundle(&bundle, opts.RunID); err != nil {
+		return nil, err
+	}
+
+	if _, err := os.Stat(LockFileName); err == nil {
+		return nil, fmt.Errorf("a run is in progress (%s exists); wait for it to finish or run 'klonekit abort'", LockFileName)
+	}
+
+	existing, err := loadState()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load execution state: %w", err)
+	}
+	if existing != nil && existing.RunID != bundle.RunID && !opts.Force {
+		return nil, fmt.Errorf("%s belongs to run %s, not %s; use --force to replace it", StateFileName, existing.RunID, bundle.RunID)
+	}
+
+	if bundle.Scaffold != nil {
+		if mismatched := bundle.Scaffold.verify(); len(mismatched) > 0 {
+			slog.Warn("Scaffold output differs from the exported run; restore it before resuming",
+				"destination", bundle.Scaffold.Destination, "files", mismatched)
+		}
+	}
+
+	state := bundle.State
+	if err := saveState(&state); err != nil {
+		return nil, fmt.Errorf("failed to save imported state: %w", err)
+	}
+
+	slog.Info("Imported run state", "runId", bundle.RunID, "lastCompletedStage", state.LastCompletedStage)
+	return &bundle, nil
+}
+
+// validateStateBundle checks that the bundle is complete and belongs to the expected run.
+func validateStateBundle(bundle *StateBundle, expectedRunID string) error {
+	if bundle.BundleVersion != StateBundleVersion {
+		return fmt.Errorf("unsupported state bundle version '%s' (expected %s)", bundle.BundleVersion, StateBundleVersion)
+	}
+	if bundle.RunID == "" {
+		return fmt.Errorf("state bundle has no run ID")
+	}
+	if bundle.State.RunID != bundle.RunID {
+		return fmt.Errorf("state bundle is inconsistent: bundle run ID %s, state run ID %s", bundle.RunID, bundle.State.RunID)
+	}
+	if expectedRunID != "" && bundle.RunID != expectedRunID {
+		return fmt.Errorf("state bundle belongs to run %s, not %s", bundle.RunID, expectedRunID)
+	}
+	return nil
+}
+
+// scaffoldReference records the scaffold destination of the run's current blueprint and
+// the digests of its files.
+func scaffoldReference(destination string) (*ScaffoldReference, error) {
+	files, err := digestFiles(destination)
+	if os.IsNotExist(err) {
+		// The scaffold stage was skipped, so there is no output to reference
+		files = map[string]string{}
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read scaffold output %s: %w", destination, err)
+	}
+	return &ScaffoldReference{Destination: destination, Files: files}, nil
+}
+
+// verify returns the referenced files that are missing or have changed, sorted by path.
+func (r *ScaffoldReference) verify() []string {
+	current, err := digestFiles(r.Destination)
+	if err != nil {
+		current = nil
+	}
+
+	var mismatched []string
+	for path, digest := range r.Files {
+		if current[path] != digest {
+			mismatched = append(mismatched, path)
+		}
+	}
+	sort.Strings(mismatched)
+	return mismatched
+}
+
+// digestFiles returns the SHA-256 digest of every regular file below dir, keyed by
+// slash-separated relative path. The .git and .terraform directories are skipped, as they
+// aren't scaffold output.
+func digestFiles(dir string) (map[string]string, error) {
+	files := map[string]string{}
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if d.Name() == ".git" || d.Name() == ".terraform" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		content, err := os.ReadFile(path) // #nosec G304
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		sum := sha256.Sum256(content)
+		files[filepath.ToSlash(rel)] = hex.EncodeToString(sum[:])
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return files, nil
+}
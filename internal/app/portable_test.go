@@ -0,0 +1,202 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// chdirTemp changes to a new temporary directory for the duration of the test
+func chdirTemp(t *testing.T) string {
+	t.Helper()
+
+	tempDir := t.TempDir()
+	originalDir, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get current directory: %s", err)
+	}
+	if err := os.Chdir(tempDir); err != nil {
+		t.Fatalf("Failed to change to temp directory: %s", err)
+	}
+	t.Cleanup(func() { _ = os.Chdir(originalDir) })
+	return tempDir
+}
+
+// seedScaffoldedRun writes the state of a run interrupted after the scaffold stage, with its scaffold output
+func seedScaffoldedRun(t *testing.T, tempDir, runID string) string {
+	t.Helper()
+
+	blueprintFile, err := createValidTestBlueprint(tempDir)
+	if err != nil {
+		t.Fatalf("Failed to create test blueprint: %s", err)
+	}
+	destDir := filepath.Join(tempDir, "destination")
+	if err := os.MkdirAll(destDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(destDir, "main.tf"), []byte("# scaffolded"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	state := newState(blueprintFile, runID)
+	state.LastCompletedStage = "scaffold"
+	state.LastSuccessfulStage = StageScaffold
+	state.recordStageResult(StageResult{Name: "scaffold", Status: StageStatusSucceeded})
+	if err := saveState(state); err != nil {
+		t.Fatalf("Failed to seed state file: %s", err)
+	}
+	return blueprintFile
+}
+
+func TestStateBundle_RoundTripAndResume(t *testing.T) {
+	tempDir := chdirTemp(t)
+	blueprintFile := seedScaffoldedRun(t, tempDir, "portable-run")
+
+	var exported bytes.Buffer
+	bundle, err := ExportState(&exported)
+	if err != nil {
+		t.Fatalf("Unexpected export error: %s", err)
+	}
+	if bundle.RunID != "portable-run" {
+		t.Errorf("Expected run ID portable-run, got %s", bundle.RunID)
+	}
+	if bundle.Scaffold == nil || bundle.Scaffold.Files["main.tf"] == "" {
+		t.Fatalf("Expected the bundle to reference the scaffold output, got %+v", bundle.Scaffold)
+	}
+
+	// Simulate the other machine: only the scaffold output has been restored
+	if err := os.Remove(StateFileName); err != nil {
+		t.Fatal(err)
+	}
+
+	imported, err := ImportState(bytes.NewReader(exported.Bytes()), ImportOptions{RunID: "portable-run"})
+	if err != nil {
+		t.Fatalf("Unexpected import error: %s", err)
+	}
+	if imported.RunID != "portable-run" {
+		t.Errorf("Expected imported run ID portable-run, got %s", imported.RunID)
+	}
+
+	// Resuming skips the scaffold stage completed on the first machine
+	err = ApplyWithOptions(blueprintFile, ApplyOptions{RetainState: true, SkipStages: []string{"scm", "provision"}})
+	if err != nil {
+		t.Fatalf("Unexpected error resuming the imported run: %s", err)
+	}
+	state, err := loadState()
+	if err != nil || state == nil {
+		t.Fatalf("Expected the retained state to load, got: %v", err)
+	}
+	if state.RunID != "portable-run" {
+		t.Errorf("Expected the resumed run to keep its run ID, got %s", state.RunID)
+	}
+	scaffold, _ := state.stageResult("scaffold")
+	if scaffold.Reason != SkipReasonCompleted {
+		t.Errorf("Expected the scaffold stage to be skipped as completed, got %+v", scaffold)
+	}
+}
+
+func TestImportState_ValidatesRunID(t *testing.T) {
+	tempDir := chdirTemp(t)
+	seedScaffoldedRun(t, tempDir, "portable-run")
+
+	var exported bytes.Buffer
+	if _, err := ExportState(&exported); err != nil {
+		t.Fatalf("Unexpected export error: %s", err)
+	}
+
+	if _, err := ImportState(bytes.NewReader(exported.Bytes()), ImportOptions{RunID: "other-run"}); err == nil || !strings.Contains(err.Error(), "other-run") {
+		t.Errorf("Expected a bundle of another run to be rejected, got: %v", err)
+	}
+
+	// A local state of a different run is only replaced with Force
+	other := newState("other.yaml", "local-run")
+	if err := saveState(other); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ImportState(bytes.NewReader(exported.Bytes()), ImportOptions{}); err == nil || !strings.Contains(err.Error(), "--force") {
+		t.Errorf("Expected the local state of another run to be protected, got: %v", err)
+	}
+	if _, err := ImportState(bytes.NewReader(exported.Bytes()), ImportOptions{Force: true}); err != nil {
+		t.Errorf("Expected Force to replace the local state, got: %v", err)
+	}
+
+	// A bundle whose state doesn't match its run ID is rejected
+	var bundle StateBundle
+	if err := json.Unmarshal(exported.Bytes(), &bundle); err != nil {
+		t.Fatal(err)
+	}
+	bundle.RunID = "tampered-run"
+	tampered, _ := json.Marshal(bundle)
+	if _, err := ImportState(bytes.NewReader(tampered), ImportOptions{Force: true}); err == nil || !strings.Contains(err.Error(), "inconsistent") {
+		t.Errorf("Expected an inconsistent bundle to be rejected, got: %v", err)
+	}
+}
+
+func TestExportState_ReferencesRecordedDestination(t *testing.T) {
+	tempDir := chdirTemp(t)
+	seedScaffoldedRun(t, tempDir, "portable-run")
+
+	// The run scaffolded to a --dir override rather than the blueprint's destination
+	overrideDir := filepath.Join(tempDir, "override")
+	if err := os.MkdirAll(overrideDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(overrideDir, "override.tf"), []byte("# scaffolded"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	state, err := loadState()
+	if err != nil || state == nil {
+		t.Fatalf("Expected the seeded state to load, got: %v", err)
+	}
+	state.ScaffoldDestination = overrideDir
+	if err := saveState(state); err != nil {
+		t.Fatal(err)
+	}
+
+	bundle, err := ExportState(&bytes.Buffer{})
+	if err != nil {
+		t.Fatalf("Unexpected export error: %s", err)
+	}
+	if bundle.Scaffold == nil || bundle.Scaffold.Destination != overrideDir || bundle.Scaffold.Files["override.tf"] == "" {
+		t.Errorf("Expected the bundle to reference the --dir destination, got %+v", bundle.Scaffold)
+	}
+}
+
+func TestExportState_NoState(t *testing.T) {
+	chdirTemp(t)
+
+	if _, err := ExportState(&bytes.Buffer{}); err == nil {
+		t.Error("Expected an error when there is no state to export")
+	}
+}
+
+func TestScaffoldReference_Verify(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "main.tf"), []byte("# scaffolded"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	files, err := digestFiles(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reference := &ScaffoldReference{Destination: dir, Files: files}
+
+	if mismatched := reference.verify(); len(mismatched) != 0 {
+		t.Errorf("Expected unchanged output to verify, got %v", mismatched)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "main.tf"), []byte("# edited"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if mismatched := reference.verify(); len(mismatched) != 1 || mismatched[0] != "main.tf" {
+		t.Errorf("Expected main.tf to be reported as changed, got %v", mismatched)
+	}
+
+	reference.Destination = filepath.Join(dir, "missing")
+	if mismatched := reference.verify(); len(mismatched) != 1 {
+		t.Errorf("Expected missing output to be reported, got %v", mismatched)
+	}
+}
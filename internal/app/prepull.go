@@ -0,0 +1,67 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"klonekit/internal/provisioner"
+)
+
+// startPrePull starts pulling the Terraform image of the provision stage in the background
+// when spec.provision.prePull is set and the stage is going to run, so the pull overlaps the
+// scaffold and scm stages. Nothing is pulled in a dry run.
+func startPrePull(ctx context.Context, stages []Stage, state *ExecutionState, isDryRun bool, skipStages []string) {
+	if isDryRun {
+		return
+	}
+	for _, stage := range stages {
+		provisionStage, ok := stage.(*ProvisionStage)
+		if !ok {
+			continue
+		}
+		if _, skip := stageSkipResult(stage, state, skipStages); !skip {
+			provisionStage.startPrePull(ctx)
+		}
+	}
+}
+
+// startPrePull creates the stage's provisioner and starts pulling its image. A provisioner
+// that can't be created or can't pull ahead is left to the stage to report or handle.
+func (s *ProvisionStage) startPrePull(ctx context.Context) {
+	spec := &s.blueprint.Spec
+	if !spec.Provision.PrePull {
+		return
+	}
+
+	terraformProvisioner, err := s.providerFactory.GetProvisioner(spec.Cloud.Provider)
+	if err != nil {
+		slog.Debug("Not pre-pulling the Terraform image", "error", err)
+		return
+	}
+	puller, ok := terraformProvisioner.(provisioner.ImagePrePuller)
+	if !ok {
+		return
+	}
+
+	s.provisioner = terraformProvisioner
+	s.prePull = make(chan error, 1)
+	slog.Info("Pulling the Terraform image in the background", "image", provisioner.TerraformImage(spec))
+	go func() {
+		s.prePull <- puller.PrePullImage(ctx, spec)
+	}()
+}
+
+// waitPrePull waits for the background image pull, if one was started, and returns its error.
+func (s *ProvisionStage) waitPrePull() error {
+	if s.prePull == nil {
+		return nil
+	}
+	err := <-s.prePull
+	s.prePull = nil
+	if err != nil {
+		return fmt.Errorf("background pull of the Terraform image failed: %w", err)
+	}
+	slog.Info("Terraform image pulled in the background", "image", provisioner.TerraformImage(&s.blueprint.Spec))
+	return nil
+}
@@ -0,0 +1,135 @@
+package app
+
+import (
+	"context"
+	stderrors "errors"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"klonekit/internal/retry"
+	runtimePkg "klonekit/pkg/runtime"
+)
+
+// pullingRuntime is a container runtime that signals when an image pull starts
+type pullingRuntime struct {
+	pullErr     error
+	pullStarted chan struct{}
+	once        sync.Once
+	mu          sync.Mutex
+	pulls       int
+	commands    int
+}
+
+func newPullingRuntime(pullErr error) *pullingRuntime {
+	return &pullingRuntime{pullErr: pullErr, pullStarted: make(chan struct{})}
+}
+
+func (r *pullingRuntime) PullImage(ctx context.Context, image string) error {
+	r.mu.Lock()
+	r.pulls++
+	r.mu.Unlock()
+	r.once.Do(func() { close(r.pullStarted) })
+	return r.pullErr
+}
+
+func (r *pullingRuntime) RunContainer(ctx context.Context, opts runtimePkg.RunOptions) (io.ReadCloser, error) {
+	r.mu.Lock()
+	r.commands++
+	r.mu.Unlock()
+	return io.NopCloser(strings.NewReader("ok")), nil
+}
+
+// awaitPullStage is an earlier stage that only finishes once the image pull has started
+type awaitPullStage struct {
+	pullStarted chan struct{}
+}
+
+func (s *awaitPullStage) Name() string { return "scaffold" }
+
+func (s *awaitPullStage) Execute(ctx context.Context, state *ExecutionState) error {
+	select {
+	case <-s.pullStarted:
+		return nil
+	case <-time.After(5 * time.Second):
+		return stderrors.New("image pull did not start while the scaffold stage ran")
+	}
+}
+
+func TestPrePull_StartsBeforeProvision(t *testing.T) {
+	chdirTemp(t)
+	bp := outputsBlueprint(t, nil)
+	bp.Spec.Provision.PrePull = true
+	containerRuntime := newPullingRuntime(nil)
+	factory := &ProviderFactory{containerRuntime: containerRuntime}
+
+	stages := []Stage{&awaitPullStage{pullStarted: containerRuntime.pullStarted}, NewProvisionStage(bp, factory, false, false)}
+	state := newState("klonekit.yaml", "run-1")
+	startPrePull(context.Background(), stages, state, false, nil)
+	if err := runStages(context.Background(), stages, state, false, nil); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	if containerRuntime.pulls != 1 {
+		t.Errorf("Expected the image to be pulled once, got %d pulls", containerRuntime.pulls)
+	}
+	if containerRuntime.commands == 0 {
+		t.Error("Expected terraform to run after the pre-pull")
+	}
+}
+
+func TestPrePull_ErrorReportedAtProvision(t *testing.T) {
+	chdirTemp(t)
+	bp := outputsBlueprint(t, nil)
+	bp.Spec.Provision.PrePull = true
+	previous := retry.Current()
+	retry.SetPolicy(retry.Policy{MaxRetries: 1, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond})
+	t.Cleanup(func() { retry.SetPolicy(previous) })
+	containerRuntime := newPullingRuntime(stderrors.New("manifest unknown"))
+	factory := &ProviderFactory{containerRuntime: containerRuntime}
+
+	scaffold := &awaitPullStage{pullStarted: containerRuntime.pullStarted}
+	stages := []Stage{scaffold, NewProvisionStage(bp, factory, false, false)}
+	state := newState("klonekit.yaml", "run-1")
+	startPrePull(context.Background(), stages, state, false, nil)
+	err := runStages(context.Background(), stages, state, false, nil)
+
+	if err == nil || !strings.Contains(err.Error(), "background pull of the Terraform image failed") || !strings.Contains(err.Error(), "manifest unknown") {
+		t.Fatalf("Expected the pull error to be reported by the provision stage, got: %v", err)
+	}
+	if result, ok := state.stageResult("scaffold"); !ok || result.Status != StageStatusSucceeded {
+		t.Errorf("Expected the scaffold stage to succeed despite the failed pull, got %+v", result)
+	}
+	if containerRuntime.commands != 0 {
+		t.Errorf("Expected terraform not to run after a failed pull, got %d commands", containerRuntime.commands)
+	}
+}
+
+func TestPrePull_OnlyWhenProvisionRuns(t *testing.T) {
+	tests := []struct {
+		name       string
+		prePull    bool
+		isDryRun   bool
+		skipStages []string
+	}{
+		{name: "not enabled", prePull: false},
+		{name: "dry run", prePull: true, isDryRun: true},
+		{name: "provision skipped", prePull: true, skipStages: []string{"provision"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bp := outputsBlueprint(t, nil)
+			bp.Spec.Provision.PrePull = tt.prePull
+			containerRuntime := newPullingRuntime(nil)
+			provisionStage := NewProvisionStage(bp, &ProviderFactory{containerRuntime: containerRuntime}, tt.isDryRun, false)
+
+			startPrePull(context.Background(), []Stage{provisionStage}, newState("klonekit.yaml", "run-1"), tt.isDryRun, tt.skipStages)
+			if provisionStage.prePull != nil || containerRuntime.pulls != 0 {
+				t.Error("Expected no background pull")
+			}
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"gopkg.in/yaml.v3"
+
+	"klonekit/internal/parser"
+	"klonekit/pkg/blueprint"
+)
+
+// Output formats of the render command
+const (
+	RenderFormatJSON = "json"
+	RenderFormatYAML = "yaml"
+)
+
+// Render writes every blueprint in the file as resolved by the parser (project name templates
+// expanded, documents validated) in the given format. YAML output is the native blueprint
+// format, so it can be diffed against the source blueprint; multiple documents are separated
+// by "---". Map keys are sorted in both formats, so the output is deterministic. Environment
+// variable references are expanded, so tokens, webhook tokens, masked CI variables and secret
+// variables are masked; encrypted variables are written as stored, not decrypted.
+func Render(w io.Writer, blueprintPath, format string) error {
+	if format != RenderFormatJSON && format != RenderFormatYAML {
+		return fmt.Errorf("invalid output format %q: must be %s or %s", format, RenderFormatJSON, RenderFormatYAML)
+	}
+
+	blueprints, err := parser.ParseAll(blueprintPath)
+	if err != nil {
+		return fmt.Errorf("blueprint parsing failed: %w", err)
+	}
+
+	for i, bp := range blueprints {
+		bp = maskRendered(bp)
+		var err error
+		if format == RenderFormatYAML {
+			if i > 0 {
+				fmt.Fprintln(w, "---")
+			}
+			err = renderYAML(w, bp)
+		} else {
+			err = renderJSON(w, bp)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to render blueprint '%s': %w", bp.Metadata.Name, err)
+		}
+	}
+	return nil
+}
+
+// maskRendered returns a copy of the blueprint with its secret values masked.
+func maskRendered(bp *blueprint.Blueprint) *blueprint.Blueprint {
+	masked := *bp
+	project := &masked.Spec.SCM.Project
+	masked.Spec.SCM.Token = maskSecret(bp.Spec.SCM.Token)
+
+	project.Webhooks = append([]blueprint.Webhook(nil), project.Webhooks...)
+	for i := range project.Webhooks {
+		project.Webhooks[i].Token = maskSecret(project.Webhooks[i].Token)
+	}
+	project.CIVariables = append([]blueprint.CIVariable(nil), project.CIVariables...)
+	for i := range project.CIVariables {
+		if project.CIVariables[i].Masked {
+			project.CIVariables[i].Value = maskSecret(project.CIVariables[i].Value)
+		}
+	}
+	if bp.Spec.Variables != nil {
+		masked.Spec.Variables = blueprint.MaskSecretVariables(bp.Spec.Variables, bp.Spec.SecretVariables)
+	}
+	return &masked
+}
+
+// renderYAML writes the blueprint as a YAML document. The encoder sorts map keys.
+func renderYAML(w io.Writer, bp *blueprint.Blueprint) error {
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(bp); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+// renderJSON writes the blueprint as an indented JSON object. The blueprint types only carry
+// YAML tags, so the blueprint goes through its YAML form to keep the same field names.
+func renderJSON(w io.Writer, bp *blueprint.Blueprint) error {
+	data, err := yaml.Marshal(bp)
+	if err != nil {
+		return err
+	}
+	var document map[string]interface{}
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(document)
+}
@@ -0,0 +1,183 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"klonekit/internal/parser"
+	"klonekit/internal/scm"
+)
+
+const renderBlueprint = `apiVersion: v1
+kind: Blueprint
+metadata:
+  name: network
+  labels:
+    team: platform
+    env: dev
+spec:
+  scm:
+    provider: gitlab
+    url: https://gitlab.com
+    token: test-token
+    project:
+      name: network-infra
+      namespace: platform
+      visibility: private
+  cloud:
+    provider: aws
+    region: us-east-1
+  scaffold:
+    source: ./modules/network
+    destination: ./build/network
+  variables:
+    zone: a
+    cidr: 10.0.0.0/16
+    tags:
+      owner: platform
+      cost_center: "42"
+`
+
+func writeRenderBlueprint(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "klonekit.yaml")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestRender_YAMLRoundTrips(t *testing.T) {
+	source := writeRenderBlueprint(t, renderBlueprint)
+	want, err := parser.Parse(source)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	want.Spec.SCM.Token = scm.RedactedPlaceholder
+
+	var out bytes.Buffer
+	if err := Render(&out, source, RenderFormatYAML); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	got, err := parser.Parse(writeRenderBlueprint(t, out.String()))
+	if err != nil {
+		t.Fatalf("Expected the rendered YAML to parse, got: %s\n%s", err, out.String())
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected the rendered blueprint to round-trip\nwant: %+v\ngot:  %+v", want, got)
+	}
+}
+
+func TestRender_YAMLSortsMapKeys(t *testing.T) {
+	source := writeRenderBlueprint(t, renderBlueprint)
+
+	var first, second bytes.Buffer
+	if err := Render(&first, source, RenderFormatYAML); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if err := Render(&second, source, RenderFormatYAML); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if first.String() != second.String() {
+		t.Error("Expected rendering to be deterministic")
+	}
+
+	output := first.String()
+	for _, pair := range [][2]string{{"env: dev", "team: platform"}, {"cidr:", "zone:"}, {"cost_center:", "owner:"}} {
+		if strings.Index(output, pair[0]) > strings.Index(output, pair[1]) {
+			t.Errorf("Expected %q before %q, got:\n%s", pair[0], pair[1], output)
+		}
+	}
+}
+
+func TestRender_MultipleDocuments(t *testing.T) {
+	second := strings.Replace(strings.Replace(renderBlueprint, "name: network", "name: database", 1), "network-infra", "database-infra", 1)
+	source := writeRenderBlueprint(t, renderBlueprint+"---\n"+second)
+	want, err := parser.ParseAll(source)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	for _, bp := range want {
+		bp.Spec.SCM.Token = scm.RedactedPlaceholder
+	}
+
+	var out bytes.Buffer
+	if err := Render(&out, source, RenderFormatYAML); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	got, err := parser.ParseAll(writeRenderBlueprint(t, out.String()))
+	if err != nil {
+		t.Fatalf("Expected the rendered YAML to parse, got: %s\n%s", err, out.String())
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected both documents to round-trip, got:\n%s", out.String())
+	}
+}
+
+func TestRender_JSON(t *testing.T) {
+	source := writeRenderBlueprint(t, renderBlueprint)
+
+	var out bytes.Buffer
+	if err := Render(&out, source, RenderFormatJSON); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	var document map[string]interface{}
+	if err := json.Unmarshal(out.Bytes(), &document); err != nil {
+		t.Fatalf("Expected valid JSON, got: %s\n%s", err, out.String())
+	}
+	if metadata, _ := document["metadata"].(map[string]interface{}); metadata["name"] != "network" {
+		t.Errorf("Expected the blueprint field names in the JSON output, got:\n%s", out.String())
+	}
+}
+
+func TestRender_MasksSecrets(t *testing.T) {
+	t.Setenv("KLONEKIT_TEST_RENDER_TOKEN", "glpat-from-env")
+	t.Setenv("KLONEKIT_TEST_RENDER_PASSWORD", "hunter2")
+	content := strings.Replace(renderBlueprint, "token: test-token", "token: ${KLONEKIT_TEST_RENDER_TOKEN}", 1)
+	content = strings.Replace(content, `      visibility: private
+`, `      visibility: private
+      ciVariables:
+        - key: DEPLOY_KEY
+          value: deploy-secret
+          masked: true
+        - key: REGION
+          value: eu-west-1
+      webhooks:
+        - url: https://hooks.example.com
+          token: hook-secret
+`, 1)
+	content = strings.Replace(content, "    zone: a\n", "    zone: a\n    db_password: ${KLONEKIT_TEST_RENDER_PASSWORD}\n", 1)
+	content += "  secretVariables: [db_password]\n"
+	source := writeRenderBlueprint(t, content)
+
+	for _, format := range []string{RenderFormatYAML, RenderFormatJSON} {
+		var out bytes.Buffer
+		if err := Render(&out, source, format); err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		output := out.String()
+		for _, secret := range []string{"glpat-from-env", "hunter2", "deploy-secret", "hook-secret"} {
+			if strings.Contains(output, secret) {
+				t.Errorf("Expected %q to be masked in the %s output, got:\n%s", secret, format, output)
+			}
+		}
+		if !strings.Contains(output, "eu-west-1") || !strings.Contains(output, scm.RedactedPlaceholder) {
+			t.Errorf("Expected unmasked values to be kept and secrets replaced in the %s output, got:\n%s", format, output)
+		}
+	}
+}
+
+func TestRender_InvalidFormat(t *testing.T) {
+	err := Render(&bytes.Buffer{}, writeRenderBlueprint(t, renderBlueprint), "toml")
+	if err == nil || !strings.Contains(err.Error(), "invalid output format") {
+		t.Errorf("Expected an invalid output format error, got: %v", err)
+	}
+}
@@ -0,0 +1,67 @@
+package app
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+
+	"klonekit/pkg/blueprint"
+)
+
+// setStageEnv sets the spec.stageEnv variables of a stage in the process environment, where
+// the stage's clients read their configuration, and returns a function restoring the
+// previous environment once the stage is done. Only the names are logged.
+func setStageEnv(stage ExecutionStage, spec *blueprint.Spec) (func(), error) {
+	env := spec.StageEnv[string(stage)]
+	if len(env) == 0 {
+		return func() {}, nil
+	}
+
+	type previousValue struct {
+		value string
+		set   bool
+	}
+	previous := make(map[string]previousValue, len(env))
+	restore := func() {
+		for name, prev := range previous {
+			if prev.set {
+				_ = os.Setenv(name, prev.value)
+			} else {
+				_ = os.Unsetenv(name)
+			}
+		}
+	}
+
+	names := make([]string, 0, len(env))
+	for _, variable := range env {
+		value, err := variable.Resolve()
+		if err != nil {
+			restore()
+			return nil, fmt.Errorf("environment variable %s of the %s stage: %w", variable.Name, stage, err)
+		}
+		if _, seen := previous[variable.Name]; !seen {
+			prev, set := os.LookupEnv(variable.Name)
+			previous[variable.Name] = previousValue{value: prev, set: set}
+		}
+		if err := os.Setenv(variable.Name, value); err != nil {
+			restore()
+			return nil, fmt.Errorf("failed to set environment variable %s of the %s stage: %w", variable.Name, stage, err)
+		}
+		names = append(names, variable.Name)
+	}
+	slog.Info("Using stage environment variables", "stage", stage, "env", names)
+	return restore, nil
+}
+
+// provisionSpec returns a copy of the spec whose provision.env has the variables of
+// spec.stageEnv.provision merged over it, so they reach the provision containers only.
+func provisionSpec(spec blueprint.Spec) blueprint.Spec {
+	stageEnv := spec.StageEnv[string(StageProvision)]
+	if len(stageEnv) == 0 {
+		return spec
+	}
+	env := make([]blueprint.EnvVar, 0, len(spec.Provision.Env)+len(stageEnv))
+	env = append(env, spec.Provision.Env...)
+	spec.Provision.Env = append(env, stageEnv...)
+	return spec
+}
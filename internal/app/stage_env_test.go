@@ -0,0 +1,104 @@
+package app
+
+import (
+	"context"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"klonekit/pkg/blueprint"
+	runtimePkg "klonekit/pkg/runtime"
+)
+
+// envScmProvider records the process environment it sees when creating the repository
+type envScmProvider struct {
+	seen map[string]string
+}
+
+func (p *envScmProvider) CreateRepo(spec *blueprint.Spec) error {
+	p.seen = map[string]string{}
+	for _, name := range []string{"GITLAB_PRIVATE_TOKEN", "AWS_PROFILE"} {
+		if value, ok := os.LookupEnv(name); ok {
+			p.seen[name] = value
+		}
+	}
+	return nil
+}
+
+// envRuntime records the environment of every container it runs
+type envRuntime struct {
+	envs []map[string]string
+}
+
+func (r *envRuntime) PullImage(ctx context.Context, image string) error { return nil }
+
+func (r *envRuntime) RunContainer(ctx context.Context, opts runtimePkg.RunOptions) (io.ReadCloser, error) {
+	r.envs = append(r.envs, opts.EnvVars)
+	return io.NopCloser(strings.NewReader("ok")), nil
+}
+
+func TestStageEnv_ScopedToStage(t *testing.T) {
+	t.Setenv("AWS_PROFILE", "")
+	os.Unsetenv("AWS_PROFILE")
+	t.Setenv("GITLAB_PRIVATE_TOKEN", "admin-token")
+	t.Setenv("TEST_READONLY_TOKEN", "read-only-token")
+
+	bp := outputsBlueprint(t, nil)
+	bp.Spec.StageEnv = map[string][]blueprint.EnvVar{
+		"scm":       {{Name: "GITLAB_PRIVATE_TOKEN", FromEnv: "TEST_READONLY_TOKEN"}},
+		"provision": {{Name: "AWS_PROFILE", Value: "provisioner"}},
+	}
+	scmProvider := &envScmProvider{}
+	containerRuntime := &envRuntime{}
+	factory := &ProviderFactory{containerRuntime: containerRuntime, scmProvider: scmProvider}
+	state := newState("klonekit.yaml", "run-1")
+
+	if err := NewScmStage(bp, factory, false).Execute(context.Background(), state); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if scmProvider.seen["GITLAB_PRIVATE_TOKEN"] != "read-only-token" {
+		t.Errorf("Expected the scm stage to use its token, got %v", scmProvider.seen)
+	}
+	if _, leaked := scmProvider.seen["AWS_PROFILE"]; leaked {
+		t.Errorf("Expected no provision env in the scm stage, got %v", scmProvider.seen)
+	}
+	if token := os.Getenv("GITLAB_PRIVATE_TOKEN"); token != "admin-token" {
+		t.Errorf("Expected the environment to be restored after the scm stage, got %q", token)
+	}
+
+	if err := NewProvisionStage(bp, factory, false, false).Execute(context.Background(), state); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if len(containerRuntime.envs) == 0 {
+		t.Fatal("Expected the provision stage to run containers")
+	}
+	for _, env := range containerRuntime.envs {
+		if env["AWS_PROFILE"] != "provisioner" {
+			t.Errorf("Expected the provision env in every container, got %v", env)
+		}
+		if _, leaked := env["GITLAB_PRIVATE_TOKEN"]; leaked {
+			t.Errorf("Expected no scm env in the provision containers, got %v", env)
+		}
+	}
+	if _, set := os.LookupEnv("AWS_PROFILE"); set {
+		t.Error("Expected the provision env to stay out of the process environment")
+	}
+	if len(bp.Spec.Provision.Env) != 0 {
+		t.Errorf("Expected the blueprint's provision env to be left untouched, got %v", bp.Spec.Provision.Env)
+	}
+}
+
+func TestSetStageEnv_MissingHostVariable(t *testing.T) {
+	spec := &blueprint.Spec{StageEnv: map[string][]blueprint.EnvVar{
+		"scm": {{Name: "KLONEKIT_TEST_STAGE_VALUE", Value: "set"}, {Name: "GITLAB_PRIVATE_TOKEN", FromEnv: "KLONEKIT_TEST_UNSET_VARIABLE"}},
+	}}
+
+	_, err := setStageEnv(StageSCM, spec)
+	if err == nil || !strings.Contains(err.Error(), "GITLAB_PRIVATE_TOKEN of the scm stage: host environment variable KLONEKIT_TEST_UNSET_VARIABLE is not set") {
+		t.Fatalf("Expected a missing host variable error, got: %v", err)
+	}
+	if _, set := os.LookupEnv("KLONEKIT_TEST_STAGE_VALUE"); set {
+		t.Error("Expected variables set before the failure to be restored")
+	}
+}
@@ -4,7 +4,11 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"os"
+	"path/filepath"
 
+	"klonekit/internal/provisioner"
+	"klonekit/internal/scaffolder"
 	"klonekit/pkg/blueprint"
 )
 
@@ -14,6 +18,14 @@ type ProvisionStage struct {
 	providerFactory *ProviderFactory
 	isDryRun        bool
 	autoApprove     bool
+	// planReal makes a dry run execute a real terraform init and plan (see ApplyOptions.PlanReal)
+	planReal bool
+	// dockerCheck makes a dry run check that the Docker daemon is reachable (see ApplyOptions.CheckDocker)
+	dockerCheck bool
+	// provisioner pulls the Terraform image in the background and then provisions (see startPrePull)
+	provisioner provisioner.Provisioner
+	// prePull receives the result of the background image pull
+	prePull chan error
 }
 
 // NewProvisionStage creates a new provision stage instance
@@ -33,8 +45,23 @@ func (s *ProvisionStage) Name() string {
 
 // Execute performs the provisioning stage logic
 func (s *ProvisionStage) Execute(ctx context.Context, state *ExecutionState) error {
+	if s.isDryRun && s.planReal {
+		if err := s.runRealPlan(); err != nil {
+			return err
+		}
+		fmt.Printf("%s✅ Real plan completed successfully (no changes applied)%s\n", ColorGreen, ColorReset)
+		slog.Info("Provisioning stage completed successfully", "provider", s.blueprint.Spec.Cloud.Provider, "region", s.blueprint.Spec.Cloud.Region, "dryRun", true, "planReal", true)
+		return nil
+	}
+
 	if s.isDryRun {
+		if s.dockerCheck {
+			s.checkDocker()
+		}
 		fmt.Printf("%s🔍 DRY RUN: Would pull Terraform Docker image%s\n", ColorYellow, ColorReset)
+		if !s.blueprint.Spec.Provision.SkipCredentialCheck {
+			fmt.Printf("%s🔍 DRY RUN: Would verify %s credentials before provisioning%s\n", ColorYellow, s.blueprint.Spec.Cloud.Provider, ColorReset)
+		}
 		fmt.Printf("%s🔍 DRY RUN: Would execute 'terraform init' in container%s\n", ColorYellow, ColorReset)
 		fmt.Printf("%s🔍 DRY RUN: Would execute 'terraform plan' in container%s\n", ColorYellow, ColorReset)
 		if s.autoApprove {
@@ -44,15 +71,54 @@ func (s *ProvisionStage) Execute(ctx context.Context, state *ExecutionState) err
 		} else {
 			fmt.Printf("%s🔍 DRY RUN: Would validate infrastructure (no apply without --auto-approve)%s\n", ColorYellow, ColorReset)
 		}
+		s.printDockerRunCommands()
 	} else {
-		provisioner, err := s.providerFactory.GetProvisioner(s.blueprint.Spec.Cloud.Provider)
-		if err != nil {
-			return fmt.Errorf("provisioner initialization failed: %w", err)
+		// Surface a failed background pull before doing anything else
+		if err := s.waitPrePull(); err != nil {
+			return err
 		}
 
-		if err := provisioner.Provision(&s.blueprint.Spec, s.autoApprove); err != nil {
+		if err := provisioner.ValidateScaffold(s.blueprint.Spec.Scaffold.Destination); err != nil {
+			return err
+		}
+
+		terraformProvisioner := s.provisioner
+		if terraformProvisioner == nil {
+			var err error
+			terraformProvisioner, err = s.providerFactory.GetProvisioner(s.blueprint.Spec.Cloud.Provider)
+			if err != nil {
+				return fmt.Errorf("provisioner initialization failed: %w", err)
+			}
+		}
+
+		// Keep plan artifacts under the ID of this run
+		if scoped, ok := terraformProvisioner.(provisioner.RunScoped); ok {
+			scoped.SetRunID(state.RunID)
+		}
+
+		// Record each step as it starts, so a run whose apply was cut short (e.g. the container
+		// was killed) reconciles the partial state instead of assuming a clean slate
+		if resumable, ok := terraformProvisioner.(provisioner.ResumableProvisioner); ok {
+			resumable.SetResumedStep(state.ProvisionStep)
+			resumable.OnStep(func(step string) {
+				state.ProvisionStep = step
+				if err := saveState(state); err != nil {
+					slog.Warn("Failed to record the provision step in the state file", "step", step, "error", err)
+				}
+			})
+		}
+
+		spec := provisionSpec(s.blueprint.Spec)
+		if err := terraformProvisioner.Provision(&spec, s.autoApprove); err != nil {
 			return fmt.Errorf("infrastructure provisioning failed: %w", err)
 		}
+
+		// Hand the outputs to downstream pipelines
+		if s.autoApprove {
+			if err := s.exportOutputs(terraformProvisioner); err != nil {
+				return fmt.Errorf("infrastructure was provisioned, but exporting its outputs failed: %w", err)
+			}
+		}
 	}
 
 	if s.isDryRun {
@@ -64,4 +130,72 @@ func (s *ProvisionStage) Execute(ctx context.Context, state *ExecutionState) err
 	}
 	slog.Info("Provisioning stage completed successfully", "provider", s.blueprint.Spec.Cloud.Provider, "region", s.blueprint.Spec.Cloud.Region, "dryRun", s.isDryRun)
 	return nil
-}
\ No newline at end of file
+}
+
+// printDockerRunCommands shows the docker run commands equivalent to the Terraform commands
+// a real run would execute, so users can reproduce them by hand.
+func (s *ProvisionStage) printDockerRunCommands() {
+	spec := provisionSpec(s.blueprint.Spec)
+	commands, err := provisioner.DockerRunCommands(&spec, s.autoApprove)
+	if err != nil {
+		fmt.Printf("%s⚠️  DRY RUN: Could not build the equivalent docker run commands: %v%s\n", ColorYellow, err, ColorReset)
+		return
+	}
+	fmt.Printf("%s🔍 DRY RUN: Equivalent docker run commands (secrets masked):%s\n", ColorYellow, ColorReset)
+	for _, command := range commands {
+		fmt.Printf("  %s\n", command)
+	}
+}
+
+// checkDocker warns during a dry run when Docker is unreachable, since the real provision
+// stage would fail on it. The dry run itself doesn't need Docker, so it carries on.
+func (s *ProvisionStage) checkDocker() {
+	if err := s.providerFactory.CheckContainerRuntime(); err != nil {
+		fmt.Printf("%s⚠️  DRY RUN: Docker is not available, so provisioning would fail: %v%s\n", ColorYellow, err, ColorReset)
+		slog.Warn("Docker is unavailable; the provision stage would fail", "error", err)
+		return
+	}
+	slog.Info("Docker daemon is reachable for provisioning")
+}
+
+// runRealPlan runs terraform init and plan against a scaffold generated in a temporary
+// directory, so a dry run shows a realistic plan without touching the real destination.
+// A local terraform.tfstate in the destination is copied in so the plan reflects existing
+// infrastructure.
+func (s *ProvisionStage) runRealPlan() error {
+	planDir, err := os.MkdirTemp("", "klonekit-plan-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary plan directory: %w", err)
+	}
+	defer os.RemoveAll(planDir)
+
+	spec := provisionSpec(s.blueprint.Spec)
+	spec.Scaffold.Destination = planDir
+	// Plan artifacts and the scaffold manifest are records of real runs, so keep the
+	// manifest in the temporary directory
+	spec.Provision.Artifacts = blueprint.Artifacts{}
+	spec.Scaffold.Manifest = filepath.Join(planDir, blueprint.DefaultManifestFilename)
+	fmt.Printf("%s🔍 DRY RUN: Running a real 'terraform plan' against a temporary scaffold%s\n", ColorYellow, ColorReset)
+
+	if err := scaffolder.Scaffold(&spec, false, false); err != nil {
+		return fmt.Errorf("scaffolding for the real plan failed: %w", err)
+	}
+
+	existingState := filepath.Join(s.blueprint.Spec.Scaffold.Destination, provisioner.StateFileName)
+	if content, err := os.ReadFile(existingState); err == nil { // #nosec G304
+		if err := os.WriteFile(filepath.Join(planDir, provisioner.StateFileName), content, 0600); err != nil {
+			return fmt.Errorf("failed to copy existing state for the real plan: %w", err)
+		}
+	}
+
+	provisioner, err := s.providerFactory.GetProvisioner(spec.Cloud.Provider)
+	if err != nil {
+		return fmt.Errorf("provisioner initialization failed: %w", err)
+	}
+
+	// Never apply: the plan is the only operation with a real effect, and it is read-only
+	if err := provisioner.Provision(&spec, false); err != nil {
+		return fmt.Errorf("real plan failed: %w", err)
+	}
+	return nil
+}
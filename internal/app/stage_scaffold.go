@@ -3,7 +3,10 @@ package app
 import (
 	"context"
 	"fmt"
+	"io/fs"
 	"log/slog"
+	"os"
+	"path/filepath"
 
 	"klonekit/internal/scaffolder"
 	"klonekit/pkg/blueprint"
@@ -13,13 +16,16 @@ import (
 type ScaffoldStage struct {
 	blueprint *blueprint.Blueprint
 	isDryRun  bool
+	// force overwrites the files already in the destination instead of refusing to scaffold
+	force bool
 }
 
 // NewScaffoldStage creates a new scaffold stage instance
-func NewScaffoldStage(blueprint *blueprint.Blueprint, isDryRun bool) *ScaffoldStage {
+func NewScaffoldStage(blueprint *blueprint.Blueprint, isDryRun bool, force bool) *ScaffoldStage {
 	return &ScaffoldStage{
 		blueprint: blueprint,
 		isDryRun:  isDryRun,
+		force:     force,
 	}
 }
 
@@ -30,15 +36,86 @@ func (s *ScaffoldStage) Name() string {
 
 // Execute performs the scaffolding stage logic
 func (s *ScaffoldStage) Execute(ctx context.Context, state *ExecutionState) error {
-	if err := scaffolder.Scaffold(&s.blueprint.Spec, s.isDryRun); err != nil {
+	restoreEnv, err := setStageEnv(StageScaffold, &s.blueprint.Spec)
+	if err != nil {
+		return err
+	}
+	defer restoreEnv()
+
+	// Record what the destination held before scaffolding, so abort --rollback only removes
+	// what this run added to it
+	var existing map[string]bool
+	if !s.isDryRun {
+		existing, err = s.recordDestination(state)
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := scaffolder.Scaffold(&s.blueprint.Spec, s.isDryRun, s.force); err != nil {
 		return fmt.Errorf("scaffolding failed: %w", err)
 	}
 
+	if !s.isDryRun && !state.CreatedDestination {
+		manifest, err := scaffolder.ReadManifest(&s.blueprint.Spec)
+		if err != nil {
+			return err
+		}
+		for _, file := range manifest.Files {
+			if !existing[file.Path] {
+				state.ScaffoldedFiles = append(state.ScaffoldedFiles, file.Path)
+			}
+		}
+	}
+
 	if s.isDryRun {
 		fmt.Printf("%s✅ Scaffolding simulation completed successfully%s\n", ColorGreen, ColorReset)
 	} else {
 		fmt.Printf("%s✅ Terraform files scaffolded to: %s%s\n", ColorGreen, s.blueprint.Spec.Scaffold.Destination, ColorReset)
+		for _, target := range s.blueprint.Spec.Scaffold.Targets {
+			fmt.Printf("%s   Module '%s' scaffolded to: %s%s\n", ColorGreen, target.Name, filepath.Join(s.blueprint.Spec.Scaffold.Destination, filepath.FromSlash(target.Destination)), ColorReset)
+		}
 	}
 	slog.Info("Scaffolding completed successfully", "destination", s.blueprint.Spec.Scaffold.Destination, "dryRun", s.isDryRun)
 	return nil
-}
\ No newline at end of file
+}
+
+// recordDestination records on the state whether the run creates the scaffold destination and,
+// when it already exists, returns the files in it. A destination created by an earlier attempt
+// of this run stays recorded as created.
+func (s *ScaffoldStage) recordDestination(state *ExecutionState) (map[string]bool, error) {
+	destination := s.blueprint.Spec.Scaffold.Destination
+	if _, err := os.Stat(destination); os.IsNotExist(err) {
+		state.CreatedDestination = true
+		if err := saveState(state); err != nil {
+			return nil, fmt.Errorf("failed to record the scaffold destination in the state file: %w", err)
+		}
+		return nil, nil
+	}
+	if state.CreatedDestination {
+		return nil, nil
+	}
+
+	existing := make(map[string]bool)
+	err := filepath.WalkDir(destination, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if d.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		relPath, err := filepath.Rel(destination, path)
+		if err != nil {
+			return err
+		}
+		existing[filepath.ToSlash(relPath)] = true
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the files in the scaffold destination: %w", err)
+	}
+	return existing, nil
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"log/slog"
 
+	"klonekit/internal/scm"
 	"klonekit/pkg/blueprint"
 )
 
@@ -29,6 +30,14 @@ func (s *ScmStage) Name() string {
 	return "scm"
 }
 
+// ShouldRun skips the stage for a blueprint without spec.scm, which has nowhere to publish to.
+func (s *ScmStage) ShouldRun(state *ExecutionState) (bool, string) {
+	if !s.blueprint.Spec.SCM.Configured() {
+		return false, "no spec.scm configured"
+	}
+	return true, ""
+}
+
 // Execute performs the SCM stage logic
 func (s *ScmStage) Execute(ctx context.Context, state *ExecutionState) error {
 	if s.isDryRun {
@@ -36,21 +45,53 @@ func (s *ScmStage) Execute(ctx context.Context, state *ExecutionState) error {
 			ColorYellow, s.blueprint.Spec.SCM.Provider, s.blueprint.Spec.SCM.Project.Name, s.blueprint.Spec.SCM.Project.Namespace, ColorReset)
 		fmt.Printf("%s🔍 DRY RUN: Would push scaffolded files to repository%s\n", ColorYellow, ColorReset)
 	} else {
-		provider, err := s.providerFactory.GetScmProvider(s.blueprint.Spec.SCM.Provider)
+		// Providers read their token and settings from the environment when created
+		restoreEnv, err := setStageEnv(StageSCM, &s.blueprint.Spec)
+		if err != nil {
+			return err
+		}
+		defer restoreEnv()
+
+		provider, err := s.providerFactory.GetScmProvider(s.blueprint.Spec.SCM.Provider, s.blueprint.Spec.SCM.URL)
 		if err != nil {
 			return fmt.Errorf("SCM provider initialization failed: %w", err)
 		}
 
+		// Record the repository once created, so a run interrupted before the push resumes
+		// the push instead of skipping the repository as already existing
+		if resumable, ok := provider.(scm.ResumableProvider); ok {
+			resumable.SetCreatedProject(state.CreatedProject)
+			resumable.OnProjectCreated(func(project scm.CreatedProject) {
+				state.CreatedProject = &project
+				if err := saveState(state); err != nil {
+					slog.Warn("Failed to record the created repository in the state file", "path", project.Path, "error", err)
+				}
+			})
+		}
+
+		// Link the pushed commit back to this run
+		if runScoped, ok := provider.(scm.RunScoped); ok {
+			runScoped.SetRunID(state.RunID)
+		}
+
 		if err := provider.CreateRepo(&s.blueprint.Spec); err != nil {
 			return fmt.Errorf("%s repository creation failed: %w", s.blueprint.Spec.SCM.Provider, err)
 		}
+
+		// Record the pushed commit, so the run reports a verifiable reference to it
+		if reporter, ok := provider.(scm.PushReporter); ok {
+			state.PushedCommit = reporter.LastPush()
+		}
 	}
 
 	if s.isDryRun {
 		fmt.Printf("%s✅ SCM simulation completed successfully%s\n", ColorGreen, ColorReset)
 	} else {
 		fmt.Printf("%s✅ %s repository created: %s%s\n", ColorGreen, s.blueprint.Spec.SCM.Provider, s.blueprint.Spec.SCM.Project.Name, ColorReset)
+		if pushed := state.PushedCommit; pushed != nil {
+			fmt.Printf("%s✅ Pushed commit %s to %s (branch %s)%s\n", ColorGreen, pushed.CommitHash, pushed.RemoteURL, pushed.Branch, ColorReset)
+		}
 	}
 	slog.Info("SCM stage completed successfully", "provider", s.blueprint.Spec.SCM.Provider, "repoName", s.blueprint.Spec.SCM.Project.Name, "dryRun", s.isDryRun)
 	return nil
-}
\ No newline at end of file
+}
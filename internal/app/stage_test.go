@@ -1,6 +1,9 @@
 package app
 
 import (
+	"context"
+	"errors"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
@@ -8,6 +11,10 @@ import (
 	"time"
 
 	"klonekit/internal/parser"
+	"klonekit/internal/provisioner"
+	"klonekit/internal/scm"
+	"klonekit/pkg/blueprint"
+	runtimePkg "klonekit/pkg/runtime"
 )
 
 // TestStageExecution_Integration verifies that the new stage runner properly executes all stages
@@ -80,7 +87,7 @@ spec:
 
 	// Test buildStages function
 	providerFactory := NewProviderFactory()
-	stages := buildStages(blueprint, providerFactory, true, false)
+	stages := buildStages(blueprint, providerFactory, true, false, false, false, false)
 
 	if len(stages) != 3 {
 		t.Errorf("Expected 3 stages, got %d", len(stages))
@@ -201,7 +208,7 @@ spec:
 	providerFactory := NewProviderFactory()
 
 	// Test ScaffoldStage
-	scaffoldStage := NewScaffoldStage(blueprint, true)
+	scaffoldStage := NewScaffoldStage(blueprint, true, false)
 	if scaffoldStage.Name() != "scaffold" {
 		t.Errorf("ScaffoldStage.Name() = %s, want 'scaffold'", scaffoldStage.Name())
 	}
@@ -229,4 +236,434 @@ spec:
 			t.Errorf("Stage %d has empty name", i)
 		}
 	}
-}
\ No newline at end of file
+}
+
+// fakeStage is a minimal Stage used to exercise the stage runner
+type fakeStage struct {
+	name     string
+	executed bool
+}
+
+func (s *fakeStage) Name() string { return s.name }
+
+func (s *fakeStage) Execute(ctx context.Context, state *ExecutionState) error {
+	s.executed = true
+	return nil
+}
+
+// conditionalFakeStage is a fakeStage with a run condition
+type conditionalFakeStage struct {
+	fakeStage
+	run    bool
+	detail string
+}
+
+func (s *conditionalFakeStage) ShouldRun(state *ExecutionState) (bool, string) {
+	return s.run, s.detail
+}
+
+// TestRunStages_RecordsSkipReasons verifies that each skip mechanism records its own reason
+func TestRunStages_RecordsSkipReasons(t *testing.T) {
+	scaffold := &fakeStage{name: "scaffold"}
+	scm := &fakeStage{name: "scm"}
+	provision := &conditionalFakeStage{fakeStage: fakeStage{name: "provision"}, detail: "no cloud credentials configured"}
+
+	state := newState("test-blueprint.yaml", "test-skip-run")
+	state.LastCompletedStage = "scaffold"
+	state.LastSuccessfulStage = StageScaffold
+
+	if err := runStages(context.Background(), []Stage{scaffold, scm, provision}, state, true, []string{"scm"}); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	if scaffold.executed || scm.executed || provision.executed {
+		t.Error("Expected all stages to be skipped")
+	}
+
+	expected := map[string]StageResult{
+		"scaffold":  {Name: "scaffold", Status: StageStatusSkipped, Reason: SkipReasonCompleted},
+		"scm":       {Name: "scm", Status: StageStatusSkipped, Reason: SkipReasonUserSkipped},
+		"provision": {Name: "provision", Status: StageStatusSkipped, Reason: SkipReasonConditionFalse, Detail: "no cloud credentials configured"},
+	}
+	if len(state.StageResults) != len(expected) {
+		t.Fatalf("Expected %d stage results, got %d: %+v", len(expected), len(state.StageResults), state.StageResults)
+	}
+	for name, want := range expected {
+		got, ok := state.stageResult(name)
+		if !ok {
+			t.Errorf("Expected a result for stage %s", name)
+			continue
+		}
+		if got != want {
+			t.Errorf("Stage %s: expected %+v, got %+v", name, want, got)
+		}
+	}
+}
+
+// TestApply_SkipsSCMWithoutSpecSCM verifies that a blueprint without spec.scm skips the scm
+// stage because its run condition is false, and records why
+func TestApply_SkipsSCMWithoutSpecSCM(t *testing.T) {
+	tempDir := chdirTemp(t)
+	blueprintFile, err := createValidTestBlueprint(tempDir)
+	if err != nil {
+		t.Fatalf("Failed to create test blueprint: %s", err)
+	}
+	bp, err := parser.Parse(blueprintFile)
+	if err != nil {
+		t.Fatalf("Failed to parse blueprint: %s", err)
+	}
+	bp.Spec.SCM = blueprint.SCMProvider{}
+
+	scm := NewScmStage(bp, NewProviderFactory(), true)
+	state := newState(blueprintFile, "test-no-scm-run")
+	if err := runStages(context.Background(), []Stage{NewScaffoldStage(bp, true, false), scm}, state, true, nil); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	want := StageResult{Name: "scm", Status: StageStatusSkipped, Reason: SkipReasonConditionFalse, Detail: "no spec.scm configured"}
+	if got, _ := state.stageResult("scm"); got != want {
+		t.Errorf("Expected %+v, got %+v", want, got)
+	}
+	if got, _ := state.stageResult("scaffold"); got.Status != StageStatusSucceeded {
+		t.Errorf("Expected the scaffold stage to run, got %+v", got)
+	}
+}
+
+// TestRunStages_RecordsExecutedStages verifies that stages that run are recorded as succeeded
+func TestRunStages_RecordsExecutedStages(t *testing.T) {
+	scaffold := &fakeStage{name: "scaffold"}
+	provision := &conditionalFakeStage{fakeStage: fakeStage{name: "provision"}, run: true}
+
+	state := newState("test-blueprint.yaml", "test-run")
+	if err := runStages(context.Background(), []Stage{scaffold, provision}, state, true, nil); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	for _, name := range []string{"scaffold", "provision"} {
+		result, ok := state.stageResult(name)
+		if !ok || result.Status != StageStatusSucceeded || result.Reason != "" {
+			t.Errorf("Expected stage %s to be recorded as succeeded, got %+v", name, result)
+		}
+	}
+}
+
+// TestApply_UnknownSkipStage verifies that --skip-stage rejects unknown stage names
+func TestApply_UnknownSkipStage(t *testing.T) {
+	os.Remove(StateFileName)
+	defer os.Remove(StateFileName)
+
+	err := ApplyWithOptions("unused.yaml", ApplyOptions{DryRun: true, SkipStages: []string{"deploy"}})
+	if err == nil || !strings.Contains(err.Error(), "unknown stage 'deploy'") {
+		t.Errorf("Expected unknown stage error, got: %v", err)
+	}
+}
+
+// recordingRuntime is a container runtime that records the commands it is asked to run
+type recordingRuntime struct {
+	commands [][]string
+	scaffold []string
+}
+
+func (r *recordingRuntime) PullImage(ctx context.Context, image string) error {
+	return nil
+}
+
+func (r *recordingRuntime) RunContainer(ctx context.Context, opts runtimePkg.RunOptions) (io.ReadCloser, error) {
+	r.commands = append(r.commands, opts.Command)
+	for hostDir, containerDir := range opts.VolumeMounts {
+		if containerDir == provisioner.WorkingDirectory {
+			r.scaffold = append(r.scaffold, hostDir)
+		}
+	}
+	return io.NopCloser(strings.NewReader("ok")), nil
+}
+
+// TestApply_DryRunPlanReal verifies that --plan-real keeps scaffold and scm simulated while the
+// provision stage runs a real init and plan
+func TestApply_DryRunPlanReal(t *testing.T) {
+	home := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(home, ".aws"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("HOME", home)
+
+	tempDir := t.TempDir()
+	sourceDir := filepath.Join(tempDir, "source")
+	destDir := filepath.Join(tempDir, "destination")
+	if err := os.MkdirAll(sourceDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(sourceDir, "main.tf"), []byte("# plan test"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	bp := &blueprint.Blueprint{
+		Metadata: blueprint.Metadata{Name: "plan-real"},
+		Spec: blueprint.Spec{
+			SCM: blueprint.SCMProvider{
+				Provider: "gitlab",
+				Project:  blueprint.ProjectConfig{Name: "plan-real", Namespace: "test-user"},
+			},
+			Cloud:     blueprint.CloudProvider{Provider: "aws", Region: "us-east-1"},
+			Scaffold:  blueprint.Scaffold{Source: sourceDir, Destination: destDir},
+			Provision: blueprint.Provision{SkipCredentialCheck: true},
+		},
+	}
+
+	containerRuntime := &recordingRuntime{}
+	factory := &ProviderFactory{containerRuntime: containerRuntime}
+	stages := buildStages(bp, factory, true, true, true, false, false)
+
+	state := newState("test-blueprint.yaml", "plan-real-run")
+	if err := runStages(context.Background(), stages, state, true, nil); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	// Scaffold and scm stay simulated: nothing is written to the destination
+	if _, err := os.Stat(destDir); !os.IsNotExist(err) {
+		t.Error("Expected the scaffold destination to be left untouched")
+	}
+
+	// Provision runs init and plan, but never apply even with auto-approve
+	var subcommands []string
+	for _, command := range containerRuntime.commands {
+		subcommands = append(subcommands, command[0])
+	}
+	if strings.Join(subcommands, ",") != "init,plan" {
+		t.Errorf("Expected a real init and plan, got: %v", containerRuntime.commands)
+	}
+
+	// The plan runs against a temporary scaffold that is removed afterwards
+	if len(containerRuntime.scaffold) == 0 {
+		t.Fatal("Expected the scaffold directory to be mounted")
+	}
+	for _, dir := range containerRuntime.scaffold {
+		if dir == destDir {
+			t.Error("Expected the plan to use a temporary scaffold, not the destination")
+		}
+		if _, err := os.Stat(dir); !os.IsNotExist(err) {
+			t.Errorf("Expected the temporary scaffold %s to be removed", dir)
+		}
+	}
+}
+
+// TestApply_PlanRealRequiresDryRun verifies that --plan-real is rejected outside a dry run
+func TestApply_PlanRealRequiresDryRun(t *testing.T) {
+	os.Remove(StateFileName)
+	defer os.Remove(StateFileName)
+
+	err := ApplyWithOptions("unused.yaml", ApplyOptions{PlanReal: true})
+	if err == nil || !strings.Contains(err.Error(), "--dry-run") {
+		t.Errorf("Expected --plan-real without --dry-run to be rejected, got: %v", err)
+	}
+}
+
+// TestApply_DryRunWarnsWhenDockerUnavailable verifies that a dry run with --check-docker
+// reports an unreachable Docker daemon as a warning and still completes
+func TestApply_DryRunWarnsWhenDockerUnavailable(t *testing.T) {
+	tempDir := chdirTemp(t)
+	blueprintFile, err := createValidTestBlueprint(tempDir)
+	if err != nil {
+		t.Fatalf("Failed to create test blueprint: %s", err)
+	}
+
+	original := connectDocker
+	connectDocker = func() error { return errors.New("Cannot connect to the Docker daemon") }
+	defer func() { connectDocker = original }()
+
+	output := captureLog(t, func() {
+		if err := ApplyWithOptions(blueprintFile, ApplyOptions{DryRun: true, CheckDocker: true}); err != nil {
+			t.Errorf("Expected the dry run to complete without Docker, got: %s", err)
+		}
+	})
+	if !strings.Contains(output, "Docker is unavailable") || !strings.Contains(output, "Cannot connect to the Docker daemon") {
+		t.Errorf("Expected a warning about Docker, got:\n%s", output)
+	}
+}
+
+// TestApply_DryRunChecksDockerOnlyForProvision verifies that Docker is checked only when
+// asked for and only when the provision stage runs
+func TestApply_DryRunChecksDockerOnlyForProvision(t *testing.T) {
+	tempDir := chdirTemp(t)
+	blueprintFile, err := createValidTestBlueprint(tempDir)
+	if err != nil {
+		t.Fatalf("Failed to create test blueprint: %s", err)
+	}
+
+	checks := 0
+	original := connectDocker
+	connectDocker = func() error {
+		checks++
+		return nil
+	}
+	defer func() { connectDocker = original }()
+
+	if err := ApplyWithOptions(blueprintFile, ApplyOptions{DryRun: true}); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if checks != 0 {
+		t.Errorf("Expected no Docker check without --check-docker, got %d", checks)
+	}
+	if err := ApplyWithOptions(blueprintFile, ApplyOptions{DryRun: true, CheckDocker: true, SkipStages: []string{"provision"}}); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if checks != 0 {
+		t.Errorf("Expected no Docker check without the provision stage, got %d", checks)
+	}
+
+	output := captureLog(t, func() {
+		if err := ApplyWithOptions(blueprintFile, ApplyOptions{DryRun: true, CheckDocker: true}); err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+	})
+	if checks != 1 {
+		t.Errorf("Expected one Docker check with the provision stage, got %d", checks)
+	}
+	if strings.Contains(output, "Docker is unavailable") {
+		t.Errorf("Expected no warning when Docker is reachable, got:\n%s", output)
+	}
+}
+
+// resumableScmProvider records the project it was told about and reports creating a project
+// before failing the push
+type resumableScmProvider struct {
+	resumed   *scm.CreatedProject
+	onCreated func(scm.CreatedProject)
+	pushErr   error
+}
+
+func (p *resumableScmProvider) SetCreatedProject(project *scm.CreatedProject) { p.resumed = project }
+
+func (p *resumableScmProvider) OnProjectCreated(fn func(scm.CreatedProject)) { p.onCreated = fn }
+
+func (p *resumableScmProvider) CreateRepo(spec *blueprint.Spec) error {
+	if p.resumed == nil {
+		p.onCreated(scm.CreatedProject{ID: 42, Path: "platform/infra", URL: "https://gitlab.example.com/platform/infra.git"})
+	}
+	return p.pushErr
+}
+
+func TestScmStage_RecordsCreatedProjectForResume(t *testing.T) {
+	chdirTemp(t)
+	bp := &blueprint.Blueprint{Spec: blueprint.Spec{SCM: blueprint.SCMProvider{Provider: "gitlab"}}}
+
+	// The first attempt creates the project, but the push fails
+	state := newState("klonekit.yaml", "run-1")
+	state.LastSuccessfulStage = StageScaffold
+	provider := &resumableScmProvider{pushErr: errors.New("connection reset")}
+	stage := NewScmStage(bp, &ProviderFactory{scmProvider: provider}, false)
+	if err := stage.Execute(context.Background(), state); err == nil {
+		t.Fatal("Expected the failed push to fail the stage")
+	}
+
+	saved, err := loadState()
+	if err != nil || saved == nil {
+		t.Fatalf("Expected the state to be saved when the project was created: %v", err)
+	}
+	if saved.CreatedProject == nil || saved.CreatedProject.ID != 42 {
+		t.Fatalf("Expected the created project to be recorded, got %+v", saved.CreatedProject)
+	}
+
+	// The resumed run tells the provider about the project, so it pushes instead of skipping
+	provider = &resumableScmProvider{}
+	stage = NewScmStage(bp, &ProviderFactory{scmProvider: provider}, false)
+	if err := stage.Execute(context.Background(), saved); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if provider.resumed == nil || provider.resumed.Path != "platform/infra" {
+		t.Errorf("Expected the resumed stage to pass on the created project, got %+v", provider.resumed)
+	}
+}
+
+// pushingScmProvider reports pushing a commit
+type pushingScmProvider struct{}
+
+func (p *pushingScmProvider) CreateRepo(spec *blueprint.Spec) error { return nil }
+
+func (p *pushingScmProvider) LastPush() *scm.PushResult {
+	return &scm.PushResult{CommitHash: "371c90f2d915e74828b20862498e2439f5b91a19", RemoteURL: "https://gitlab.example.com/platform/infra.git", Branch: "main"}
+}
+
+func TestScmStage_ReportsPushedCommit(t *testing.T) {
+	chdirTemp(t)
+	bp := &blueprint.Blueprint{Spec: blueprint.Spec{SCM: blueprint.SCMProvider{Provider: "gitlab"}}}
+	state := newState("klonekit.yaml", "run-1")
+	stage := NewScmStage(bp, &ProviderFactory{scmProvider: &pushingScmProvider{}}, false)
+
+	if err := stage.Execute(context.Background(), state); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if state.PushedCommit == nil || state.PushedCommit.CommitHash != "371c90f2d915e74828b20862498e2439f5b91a19" {
+		t.Fatalf("Expected the pushed commit to be recorded, got %+v", state.PushedCommit)
+	}
+
+	// The status report carries it
+	if err := saveState(state); err != nil {
+		t.Fatalf("Failed to save state: %s", err)
+	}
+	report, err := GetStatus()
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if report.PushedCommit == nil || report.PushedCommit.RemoteURL != "https://gitlab.example.com/platform/infra.git" || report.PushedCommit.Branch != "main" {
+		t.Errorf("Expected the pushed commit in the status report, got %+v", report.PushedCommit)
+	}
+}
+
+// resumableProvisioner records the step it was resumed at and reports starting the apply
+// before failing
+type resumableProvisioner struct {
+	resumed  string
+	onStep   func(string)
+	applyErr error
+}
+
+func (p *resumableProvisioner) SetResumedStep(step string) { p.resumed = step }
+
+func (p *resumableProvisioner) OnStep(fn func(string)) { p.onStep = fn }
+
+func (p *resumableProvisioner) Provision(spec *blueprint.Spec, autoApprove bool) error {
+	p.onStep(provisioner.StepInit)
+	p.onStep(provisioner.StepApply)
+	return p.applyErr
+}
+
+func TestProvisionStage_RecordsStepForResume(t *testing.T) {
+	chdirTemp(t)
+	scaffoldDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(scaffoldDir, "main.tf"), []byte("# resume test"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	bp := &blueprint.Blueprint{Spec: blueprint.Spec{
+		Cloud:    blueprint.CloudProvider{Provider: "aws"},
+		Scaffold: blueprint.Scaffold{Destination: scaffoldDir},
+	}}
+
+	// The first attempt's container is killed during the apply
+	state := newState("klonekit.yaml", "run-1")
+	state.LastSuccessfulStage = StageSCM
+	stage := NewProvisionStage(bp, NewProviderFactory(), false, true)
+	stage.provisioner = &resumableProvisioner{applyErr: errors.New("container exited with status 137")}
+	if err := stage.Execute(context.Background(), state); err == nil {
+		t.Fatal("Expected the killed apply to fail the stage")
+	}
+
+	saved, err := loadState()
+	if err != nil || saved == nil {
+		t.Fatalf("Expected the state to be saved as provisioning progressed: %v", err)
+	}
+	if saved.ProvisionStep != provisioner.StepApply {
+		t.Fatalf("Expected the apply step to be recorded, got %q", saved.ProvisionStep)
+	}
+
+	// The resumed run tells the provisioner the apply was cut short
+	resumed := &resumableProvisioner{}
+	stage = NewProvisionStage(bp, NewProviderFactory(), false, true)
+	stage.provisioner = resumed
+	if err := stage.Execute(context.Background(), saved); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if resumed.resumed != provisioner.StepApply {
+		t.Errorf("Expected the resumed stage to pass on the interrupted step, got %q", resumed.resumed)
+	}
+}
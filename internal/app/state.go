@@ -1,10 +1,17 @@
 package app
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
 	"time"
+
+	"klonekit/internal/parser"
+	"klonekit/internal/scm"
 )
 
 // ExecutionStage represents the stages of the apply workflow
@@ -19,18 +26,136 @@ const (
 
 // ExecutionState represents the state of a KloneKit apply run
 type ExecutionState struct {
-	SchemaVersion       string         `json:"schema_version"`
-	RunID               string         `json:"run_id"`
-	LastCompletedStage  string         `json:"last_completed_stage"`
-	LastSuccessfulStage ExecutionStage `json:"last_successful_stage"` // Kept for backward compatibility
-	BlueprintPath       string         `json:"blueprint_path"`
-	CreatedAt           time.Time      `json:"created_at"`
-	LastUpdatedAt       time.Time      `json:"last_updated_at"`
+	SchemaVersion       string              `json:"schema_version"`
+	RunID               string              `json:"run_id"`
+	LastCompletedStage  string              `json:"last_completed_stage"`
+	LastSuccessfulStage ExecutionStage      `json:"last_successful_stage"` // Kept for backward compatibility
+	BlueprintPath       string              `json:"blueprint_path"`
+	BlueprintIndex      int                 `json:"blueprint_index,omitempty"`      // Index of the current blueprint in a multi-document file
+	BlueprintHash       string              `json:"blueprint_hash,omitempty"`       // SHA-256 of the blueprint file the run started with
+	Overrides           *RunOverrides       `json:"overrides,omitempty"`            // Command-line overrides of the blueprint the run started with
+	ScaffoldDestination string              `json:"scaffold_destination,omitempty"` // Destination the current blueprint is scaffolded to, after --dir
+	CreatedDestination  bool                `json:"created_destination,omitempty"`  // Whether the scaffold stage created the destination directory
+	ScaffoldedFiles     []string            `json:"scaffolded_files,omitempty"`     // Files the scaffold stage added to a destination that already existed
+	BlueprintResults    []string            `json:"blueprint_results,omitempty"`    // Outcome of each blueprint attempted in this run, by index
+	StageResults        []StageResult       `json:"stage_results,omitempty"`        // Outcome of each stage in the current run
+	CreatedProject      *scm.CreatedProject `json:"created_project,omitempty"`      // Repository created by the SCM stage, recorded before the push
+	PushedCommit        *scm.PushResult     `json:"pushed_commit,omitempty"`        // Commit pushed by the SCM stage, confirmed against the remote
+	ProvisionStep       string              `json:"provision_step,omitempty"`       // Last step the provision stage started (init, plan or apply)
+	CreatedAt           time.Time           `json:"created_at"`
+	LastUpdatedAt       time.Time           `json:"last_updated_at"`
+}
+
+// RunOverrides records the command-line overrides that change what a run creates, so a resume
+// can tell whether it would apply a different effective blueprint than the run started with.
+type RunOverrides struct {
+	ScaffoldDir string `json:"scaffold_dir,omitempty"` // --dir
+	Visibility  string `json:"visibility,omitempty"`   // --visibility
+	Staging     string `json:"staging,omitempty"`      // --best-effort or --fail-fast
+}
+
+// Stage outcomes recorded in StageResult.Status
+const (
+	StageStatusSucceeded = "succeeded"
+	StageStatusSkipped   = "skipped"
+)
+
+// Reasons a stage was skipped, recorded in StageResult.Reason
+const (
+	SkipReasonCompleted      = "completed"       // Already completed by an earlier attempt of this run
+	SkipReasonUserSkipped    = "user-skipped"    // Excluded with --skip-stage
+	SkipReasonConditionFalse = "condition-false" // The stage's run condition did not hold
+)
+
+// Blueprint outcomes recorded in ExecutionState.BlueprintResults
+const (
+	BlueprintStatusSucceeded = "succeeded"
+	BlueprintStatusFailed    = "failed"
+)
+
+// StageResult records what happened to a stage during a run
+type StageResult struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	Reason string `json:"reason,omitempty"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// recordStageResult sets the result for a stage, replacing any earlier result with the same name
+func (s *ExecutionState) recordStageResult(result StageResult) {
+	for i := range s.StageResults {
+		if s.StageResults[i].Name == result.Name {
+			s.StageResults[i] = result
+			return
+		}
+	}
+	s.StageResults = append(s.StageResults, result)
+}
+
+// stageResult returns the recorded result for a stage, if any
+func (s *ExecutionState) stageResult(name string) (StageResult, bool) {
+	for _, result := range s.StageResults {
+		if result.Name == name {
+			return result, true
+		}
+	}
+	return StageResult{}, false
+}
+
+// recordBlueprintResult sets the outcome of the blueprint at index
+func (s *ExecutionState) recordBlueprintResult(index int, status string) {
+	for len(s.BlueprintResults) <= index {
+		s.BlueprintResults = append(s.BlueprintResults, "")
+	}
+	s.BlueprintResults[index] = status
+}
+
+// blueprintCompleted reports whether an earlier attempt of this run completed the blueprint at
+// index. States written before blueprint results were recorded only know that the blueprints
+// before the current one completed.
+func (s *ExecutionState) blueprintCompleted(index int) bool {
+	if s.BlueprintResults == nil {
+		return index < s.BlueprintIndex
+	}
+	return index < len(s.BlueprintResults) && s.BlueprintResults[index] == BlueprintStatusSucceeded
+}
+
+// scaffoldDestination returns where the run scaffolds its current blueprint, after --dir.
+// States written before the destination was recorded fall back to the blueprint's own destination.
+func scaffoldDestination(state *ExecutionState) (string, error) {
+	if state.ScaffoldDestination != "" {
+		return state.ScaffoldDestination, nil
+	}
+
+	blueprints, err := parser.ParseAll(state.BlueprintPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse blueprint to locate the scaffold output: %w", err)
+	}
+	if state.BlueprintIndex >= len(blueprints) {
+		return "", fmt.Errorf("blueprint %d of the run is no longer in %s", state.BlueprintIndex+1, state.BlueprintPath)
+	}
+	return blueprints[state.BlueprintIndex].Spec.Scaffold.Destination, nil
+}
+
+// resetBlueprintProgress moves the state to the blueprint at index with fresh stage progress
+func (s *ExecutionState) resetBlueprintProgress(index int) {
+	s.BlueprintIndex = index
+	s.LastCompletedStage = ""
+	s.LastSuccessfulStage = ""
+	s.StageResults = nil
+	s.CreatedDestination = false
+	s.ScaffoldedFiles = nil
+	s.CreatedProject = nil
+	s.PushedCommit = nil
+	s.ProvisionStep = ""
 }
 
 const (
 	StateFileName      = ".klonekit.state.json"
 	StateSchemaVersion = "1.0"
+
+	// LockFileName guards the state file against concurrent apply runs
+	LockFileName = ".klonekit.state.lock"
 )
 
 // loadState attempts to load the execution state from the state file.
@@ -62,13 +187,51 @@ func saveState(state *ExecutionState) error {
 		return fmt.Errorf("failed to serialize state: %w", err)
 	}
 
-	if err := os.WriteFile(StateFileName, data, 0600); err != nil {
+	if err := writeFileAtomic(StateFileName, data); err != nil {
 		return fmt.Errorf("failed to write state file: %w", err)
 	}
 
 	return nil
 }
 
+// wrapStateWriter wraps the writer used for the temporary state file; tests replace it to
+// simulate interrupted writes.
+var wrapStateWriter = func(w io.Writer) io.Writer { return w }
+
+// writeFileAtomic writes data to a temporary file next to path and renames it into place,
+// so a crash mid-write never leaves a torn file behind. The file is created with mode 0600.
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+
+	committed := false
+	defer func() {
+		if !committed {
+			_ = tmp.Close()
+			_ = os.Remove(tmpPath)
+		}
+	}()
+
+	if _, err := wrapStateWriter(tmp).Write(data); err != nil {
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return err
+	}
+
+	committed = true
+	return nil
+}
+
 // newState creates a new execution state for a fresh run
 func newState(blueprintPath, runID string) *ExecutionState {
 	now := time.Now()
@@ -82,6 +245,16 @@ func newState(blueprintPath, runID string) *ExecutionState {
 	}
 }
 
+// hashBlueprint returns the SHA-256 digest of the blueprint file, used to detect a blueprint
+// edited between the attempts of a run.
+func hashBlueprint(blueprintPath string) (string, error) {
+	content, err := os.ReadFile(blueprintPath) // #nosec G304
+	if err != nil {
+		return "", fmt.Errorf("failed to read blueprint: %w", err)
+	}
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:]), nil
+}
 
 // getNextStage returns the next stage to execute based on the current state
 func (s *ExecutionState) getNextStage() ExecutionStage {
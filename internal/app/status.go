@@ -0,0 +1,104 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"klonekit/internal/scm"
+)
+
+// StatusReport summarizes the state of the current (interrupted or retained) run.
+type StatusReport struct {
+	Found              bool            `json:"found"`
+	RunID              string          `json:"run_id,omitempty"`
+	BlueprintPath      string          `json:"blueprint_path,omitempty"`
+	LastCompletedStage string          `json:"last_completed_stage,omitempty"`
+	NextStage          string          `json:"next_stage,omitempty"`
+	ProvisionStep      string          `json:"provision_step,omitempty"` // Step an interrupted provision stage had started
+	Locked             bool            `json:"locked"`
+	Stages             []StageResult   `json:"stages,omitempty"`
+	PushedCommit       *scm.PushResult `json:"pushed_commit,omitempty"` // Commit pushed by the SCM stage
+	LastUpdatedAt      *time.Time      `json:"last_updated_at,omitempty"`
+}
+
+// GetStatus builds a status report from the state file in the current directory.
+func GetStatus() (*StatusReport, error) {
+	state, err := loadState()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load execution state: %w", err)
+	}
+
+	_, lockErr := os.Stat(LockFileName)
+	report := &StatusReport{Locked: lockErr == nil}
+	if state == nil {
+		return report, nil
+	}
+
+	report.Found = true
+	report.RunID = state.RunID
+	report.BlueprintPath = state.BlueprintPath
+	report.LastCompletedStage = state.LastCompletedStage
+	report.NextStage = string(state.getNextStage())
+	if state.getNextStage() == StageProvision {
+		report.ProvisionStep = state.ProvisionStep
+	}
+	report.Stages = state.StageResults
+	report.PushedCommit = state.PushedCommit
+	report.LastUpdatedAt = &state.LastUpdatedAt
+	return report, nil
+}
+
+// Status writes the status of the current run to w, as JSON when asJSON is set.
+func Status(w io.Writer, asJSON bool) error {
+	report, err := GetStatus()
+	if err != nil {
+		return err
+	}
+
+	if asJSON {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(report)
+	}
+
+	if !report.Found {
+		fmt.Fprintln(w, "No run state found.")
+		return nil
+	}
+
+	lastStage := report.LastCompletedStage
+	if lastStage == "" {
+		lastStage = "none"
+	}
+	fmt.Fprintf(w, "Run ID: %s\n", report.RunID)
+	fmt.Fprintf(w, "Blueprint: %s\n", report.BlueprintPath)
+	fmt.Fprintf(w, "Last completed stage: %s\n", lastStage)
+	fmt.Fprintf(w, "Next stage: %s\n", report.NextStage)
+	if report.ProvisionStep != "" {
+		fmt.Fprintf(w, "Interrupted during: terraform %s\n", report.ProvisionStep)
+	}
+	if report.Locked {
+		fmt.Fprintf(w, "Lock: held (%s)\n", LockFileName)
+	}
+
+	if len(report.Stages) > 0 {
+		fmt.Fprintln(w, "Stages:")
+		for _, stage := range report.Stages {
+			line := fmt.Sprintf("  %-10s %s", stage.Name, stage.Status)
+			if stage.Reason != "" {
+				line += fmt.Sprintf(" (%s)", stage.Reason)
+			}
+			if stage.Detail != "" {
+				line += fmt.Sprintf(": %s", stage.Detail)
+			}
+			fmt.Fprintln(w, line)
+		}
+	}
+	if report.PushedCommit != nil {
+		fmt.Fprintf(w, "Pushed commit: %s to %s (branch %s)\n", report.PushedCommit.CommitHash, report.PushedCommit.RemoteURL, report.PushedCommit.Branch)
+	}
+	return nil
+}
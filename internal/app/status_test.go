@@ -0,0 +1,65 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestStatus_ReportsStageResults(t *testing.T) {
+	os.Remove(StateFileName)
+	os.Remove(LockFileName)
+	defer os.Remove(StateFileName)
+
+	state := newState("blueprint.yaml", "test-status-run")
+	state.LastCompletedStage = "scm"
+	state.LastSuccessfulStage = StageSCM
+	state.recordStageResult(StageResult{Name: "scaffold", Status: StageStatusSkipped, Reason: SkipReasonUserSkipped})
+	state.recordStageResult(StageResult{Name: "scm", Status: StageStatusSucceeded})
+	state.ProvisionStep = "apply"
+	if err := saveState(state); err != nil {
+		t.Fatalf("Failed to save state: %s", err)
+	}
+
+	var out bytes.Buffer
+	if err := Status(&out, true); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	var report StatusReport
+	if err := json.Unmarshal(out.Bytes(), &report); err != nil {
+		t.Fatalf("Expected JSON output, got %q: %s", out.String(), err)
+	}
+	if !report.Found || report.RunID != "test-status-run" || report.NextStage != "provision" || report.ProvisionStep != "apply" {
+		t.Errorf("Unexpected report: %+v", report)
+	}
+	if len(report.Stages) != 2 || report.Stages[0].Reason != SkipReasonUserSkipped || report.Stages[1].Status != StageStatusSucceeded {
+		t.Errorf("Expected recorded stage results in report, got %+v", report.Stages)
+	}
+
+	out.Reset()
+	if err := Status(&out, false); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if !strings.Contains(out.String(), "scaffold   skipped (user-skipped)") {
+		t.Errorf("Expected skip reason in text output, got:\n%s", out.String())
+	}
+	if !strings.Contains(out.String(), "Interrupted during: terraform apply") {
+		t.Errorf("Expected the interrupted provision step in text output, got:\n%s", out.String())
+	}
+}
+
+func TestStatus_NoState(t *testing.T) {
+	os.Remove(StateFileName)
+	os.Remove(LockFileName)
+
+	var out bytes.Buffer
+	if err := Status(&out, true); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if !strings.Contains(out.String(), `"found": false`) {
+		t.Errorf("Expected found=false in JSON output, got: %s", out.String())
+	}
+}
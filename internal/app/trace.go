@@ -0,0 +1,155 @@
+package app
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	// SpanStatusOK marks a span whose operation succeeded.
+	SpanStatusOK = "ok"
+	// SpanStatusError marks a span whose operation failed.
+	SpanStatusError = "error"
+)
+
+// inheritedSpanAttributes are copied from a parent span to its children, so every
+// stage span carries the provider and region of the blueprint it belongs to.
+var inheritedSpanAttributes = []string{"klonekit.blueprint", "cloud.provider", "cloud.region", "scm.provider"}
+
+// Span is a single timed operation in an OpenTelemetry-style trace.
+type Span struct {
+	TraceID      string            `json:"traceId"`
+	SpanID       string            `json:"spanId"`
+	ParentSpanID string            `json:"parentSpanId,omitempty"`
+	Name         string            `json:"name"`
+	StartTime    time.Time         `json:"startTime"`
+	EndTime      time.Time         `json:"endTime"`
+	DurationMs   int64             `json:"durationMs"`
+	Attributes   map[string]string `json:"attributes,omitempty"`
+	Status       string            `json:"status"`
+	Error        string            `json:"error,omitempty"`
+
+	tracer *tracer
+}
+
+// tracer collects the spans of one run and writes them to a file as JSON lines.
+// A nil tracer records nothing, so tracing costs nothing unless --trace is given.
+type tracer struct {
+	path    string
+	traceID string
+
+	mu    sync.Mutex
+	spans []*Span
+}
+
+type spanContextKey struct{}
+
+// newTracer returns a tracer writing to path, or nil when path is empty.
+func newTracer(path string) *tracer {
+	if path == "" {
+		return nil
+	}
+	return &tracer{path: path, traceID: randomHex(16)}
+}
+
+// startSpan begins a span named name as a child of the span in ctx (if any) and returns
+// a context carrying the new span. Without a tracer it returns ctx and a nil span.
+func (t *tracer) startSpan(ctx context.Context, name string, attributes map[string]string) (context.Context, *Span) {
+	if t == nil {
+		return ctx, nil
+	}
+
+	span := &Span{
+		TraceID:    t.traceID,
+		SpanID:     randomHex(8),
+		Name:       name,
+		StartTime:  time.Now().UTC(),
+		Attributes: map[string]string{},
+		tracer:     t,
+	}
+	if parent := spanFromContext(ctx); parent != nil {
+		span.ParentSpanID = parent.SpanID
+		for _, key := range inheritedSpanAttributes {
+			if value, ok := parent.Attributes[key]; ok {
+				span.Attributes[key] = value
+			}
+		}
+	}
+	for key, value := range attributes {
+		span.Attributes[key] = value
+	}
+
+	return context.WithValue(ctx, spanContextKey{}, span), span
+}
+
+// startChildSpan begins a span under the span in ctx using that span's tracer.
+// Without a traced parent it returns ctx and a nil span.
+func startChildSpan(ctx context.Context, name string, attributes map[string]string) (context.Context, *Span) {
+	parent := spanFromContext(ctx)
+	if parent == nil {
+		return ctx, nil
+	}
+	return parent.tracer.startSpan(ctx, name, attributes)
+}
+
+// spanFromContext returns the current span, or nil if ctx is not traced.
+func spanFromContext(ctx context.Context) *Span {
+	span, _ := ctx.Value(spanContextKey{}).(*Span)
+	return span
+}
+
+// end records the span's end time and outcome.
+func (s *Span) end(err error) {
+	if s == nil {
+		return
+	}
+
+	s.EndTime = time.Now().UTC()
+	s.DurationMs = s.EndTime.Sub(s.StartTime).Milliseconds()
+	s.Status = SpanStatusOK
+	if err != nil {
+		s.Status = SpanStatusError
+		s.Error = err.Error()
+	}
+
+	s.tracer.mu.Lock()
+	s.tracer.spans = append(s.tracer.spans, s)
+	s.tracer.mu.Unlock()
+}
+
+// flush writes the ended spans to the trace file, one JSON event per line in the order they ended.
+func (t *tracer) flush() error {
+	if t == nil {
+		return nil
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	file, err := os.OpenFile(t.path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600) // #nosec G304
+	if err != nil {
+		return fmt.Errorf("failed to create trace file %s: %w", t.path, err)
+	}
+	defer file.Close()
+
+	encoder := json.NewEncoder(file)
+	for _, span := range t.spans {
+		if err := encoder.Encode(span); err != nil {
+			return fmt.Errorf("failed to write trace file %s: %w", t.path, err)
+		}
+	}
+	return nil
+}
+
+// randomHex returns n random bytes encoded as hex, as used for trace and span IDs.
+func randomHex(n int) string {
+	b := make([]byte, n)
+	_, _ = rand.Read(b) // crypto/rand.Read never fails
+	return hex.EncodeToString(b)
+}
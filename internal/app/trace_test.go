@@ -0,0 +1,132 @@
+package app
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// readTrace reads the spans written to a trace file
+func readTrace(t *testing.T, path string) []Span {
+	t.Helper()
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("Expected trace file to be written: %s", err)
+	}
+	defer file.Close()
+
+	var spans []Span
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var span Span
+		if err := json.Unmarshal(scanner.Bytes(), &span); err != nil {
+			t.Fatalf("Failed to parse trace event %q: %s", scanner.Text(), err)
+		}
+		spans = append(spans, span)
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatalf("Failed to read trace file: %s", err)
+	}
+	return spans
+}
+
+// TestApply_TraceDryRun verifies that --trace writes a root span, a blueprint span and one span per stage
+func TestApply_TraceDryRun(t *testing.T) {
+	os.Remove(StateFileName)
+	defer os.Remove(StateFileName)
+
+	tempDir := t.TempDir()
+	blueprintFile, err := createValidTestBlueprint(tempDir)
+	if err != nil {
+		t.Fatalf("Failed to create test blueprint: %s", err)
+	}
+	tracePath := filepath.Join(tempDir, "trace.jsonl")
+
+	if err := ApplyWithOptions(blueprintFile, ApplyOptions{DryRun: true, TracePath: tracePath}); err != nil {
+		t.Fatalf("Unexpected error in dry-run: %s", err)
+	}
+
+	spans := readTrace(t, tracePath)
+	byName := map[string]Span{}
+	for _, span := range spans {
+		byName[span.Name] = span
+	}
+	if len(spans) != 5 {
+		t.Fatalf("Expected 5 spans (apply, blueprint, 3 stages), got %d: %+v", len(spans), spans)
+	}
+
+	root, ok := byName["klonekit.apply"]
+	if !ok || root.ParentSpanID != "" {
+		t.Fatalf("Expected a root klonekit.apply span, got %+v", root)
+	}
+	blueprintSpan, ok := byName["klonekit.blueprint"]
+	if !ok || blueprintSpan.ParentSpanID != root.SpanID {
+		t.Fatalf("Expected the blueprint span to be a child of the root span, got %+v", blueprintSpan)
+	}
+
+	for _, stage := range []string{"scaffold", "scm", "provision"} {
+		span, ok := byName["klonekit.stage."+stage]
+		if !ok {
+			t.Errorf("Expected a span for stage %s", stage)
+			continue
+		}
+		if span.ParentSpanID != blueprintSpan.SpanID {
+			t.Errorf("Expected stage %s span to be a child of the blueprint span", stage)
+		}
+		if span.TraceID != root.TraceID {
+			t.Errorf("Expected stage %s span to share the trace ID", stage)
+		}
+		if span.Status != SpanStatusOK {
+			t.Errorf("Expected stage %s span status ok, got %s", stage, span.Status)
+		}
+		if span.Attributes["klonekit.stage"] != stage || span.Attributes["cloud.provider"] != "aws" || span.Attributes["cloud.region"] == "" {
+			t.Errorf("Expected stage %s span to carry stage, provider and region attributes, got %v", stage, span.Attributes)
+		}
+		if span.EndTime.Before(span.StartTime) {
+			t.Errorf("Expected stage %s span to end after it starts", stage)
+		}
+	}
+}
+
+// TestRunStages_TraceSkipsUnexecutedStages verifies that skipped stages get no span
+func TestRunStages_TraceSkipsUnexecutedStages(t *testing.T) {
+	tracePath := filepath.Join(t.TempDir(), "trace.jsonl")
+	tracer := newTracer(tracePath)
+	ctx, root := tracer.startSpan(context.Background(), "root", nil)
+
+	scaffold := &fakeStage{name: "scaffold"}
+	scm := &fakeStage{name: "scm"}
+	state := newState("test-blueprint.yaml", "test-trace-run")
+	if err := runStages(ctx, []Stage{scaffold, scm}, state, true, []string{"scm"}); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	root.end(nil)
+	if err := tracer.flush(); err != nil {
+		t.Fatalf("Unexpected error writing trace: %s", err)
+	}
+
+	spans := readTrace(t, tracePath)
+	if len(spans) != 2 {
+		t.Fatalf("Expected spans for the executed stage and the root, got %+v", spans)
+	}
+	if spans[0].Name != "klonekit.stage.scaffold" || spans[0].ParentSpanID != root.SpanID {
+		t.Errorf("Expected scaffold stage span under the root span, got %+v", spans[0])
+	}
+}
+
+// TestTracer_Disabled verifies that tracing is a no-op without a trace path
+func TestTracer_Disabled(t *testing.T) {
+	tracer := newTracer("")
+	ctx, span := tracer.startSpan(context.Background(), "root", nil)
+	if span != nil || spanFromContext(ctx) != nil {
+		t.Error("Expected no span without a tracer")
+	}
+	span.end(nil)
+	if err := tracer.flush(); err != nil {
+		t.Errorf("Expected flush of a disabled tracer to succeed, got: %s", err)
+	}
+}
@@ -9,4 +9,11 @@ import (
 type Stage interface {
 	Name() string
 	Execute(ctx context.Context, state *ExecutionState) error
-}
\ No newline at end of file
+}
+
+// ConditionalStage is implemented by stages that only run when a condition holds.
+type ConditionalStage interface {
+	Stage
+	// ShouldRun reports whether the stage should run and, if not, a human-readable explanation.
+	ShouldRun(state *ExecutionState) (bool, string)
+}
@@ -0,0 +1,21 @@
+package app
+
+import (
+	"fmt"
+	"io"
+
+	"klonekit/internal/parser"
+)
+
+// Validate parses and validates every blueprint in the file without applying anything, and
+// writes the name of each valid blueprint.
+func Validate(w io.Writer, blueprintPath string) error {
+	blueprints, err := parser.ParseAll(blueprintPath)
+	if err != nil {
+		return fmt.Errorf("blueprint validation failed: %w", err)
+	}
+	for _, bp := range blueprints {
+		fmt.Fprintf(w, "✅ Blueprint '%s' is valid\n", bp.Metadata.Name)
+	}
+	return nil
+}
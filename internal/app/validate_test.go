@@ -0,0 +1,41 @@
+package app
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	tempDir := chdirTemp(t)
+	blueprintFile, err := createValidTestBlueprint(tempDir)
+	if err != nil {
+		t.Fatalf("Failed to create test blueprint: %s", err)
+	}
+
+	var output bytes.Buffer
+	if err := Validate(&output, blueprintFile); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if !strings.Contains(output.String(), "Blueprint 'integration-test' is valid") {
+		t.Errorf("Expected the blueprint to be reported valid, got: %s", output.String())
+	}
+}
+
+func TestValidate_InvalidBlueprint(t *testing.T) {
+	blueprintFile := filepath.Join(t.TempDir(), "blueprint.yaml")
+	if err := os.WriteFile(blueprintFile, []byte("apiVersion: v1\nkind: Blueprint\nmetadata:\n  name: broken\nspec: {}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var output bytes.Buffer
+	err := Validate(&output, blueprintFile)
+	if err == nil || !strings.Contains(err.Error(), "blueprint validation failed") {
+		t.Fatalf("Expected a validation error, got: %v", err)
+	}
+	if output.Len() != 0 {
+		t.Errorf("Expected nothing to be reported valid, got: %s", output.String())
+	}
+}
@@ -5,8 +5,17 @@ import "sync"
 var (
 	defaultHandler *ErrorHandler
 	once           sync.Once
+
+	// explainMode makes Handle also print the machine-parseable explanation of each error
+	explainMode bool
 )
 
+// SetExplainMode enables printing a structured explanation (code, type, context, cause,
+// suggestion and error chain) to stderr whenever an error is handled.
+func SetExplainMode(enabled bool) {
+	explainMode = enabled
+}
+
 func GetDefaultHandler() (*ErrorHandler, error) {
 	var err error
 	once.Do(func() {
@@ -0,0 +1,223 @@
+package errors
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// TypeInfo describes an error type for `klonekit explain`.
+type TypeInfo struct {
+	Code     string   `json:"code"`
+	Type     string   `json:"type"`
+	Summary  string   `json:"summary"`
+	Guidance []string `json:"guidance"`
+}
+
+// typeInfos lists the error types in code order. Codes are stable: new types get new codes
+// and existing codes are never reused, so they can be quoted in tickets.
+var typeInfos = []struct {
+	err  error
+	info TypeInfo
+}{
+	{ErrBlueprintNotFound, TypeInfo{
+		Code: "KK001", Type: "blueprint_not_found",
+		Summary: "The blueprint file could not be found.",
+		Guidance: []string{
+			"Pass the blueprint explicitly with -f/--file.",
+			"Or create klonekit.yml/klonekit.yaml, or set KLONEKIT_BLUEPRINT_NAMES, for auto-detection.",
+		},
+	}},
+	{ErrBlueprintParseFailed, TypeInfo{
+		Code: "KK002", Type: "blueprint_parse_failed",
+		Summary: "The blueprint is not valid YAML or fails validation.",
+		Guidance: []string{
+			"Check the YAML syntax (indentation, quoting) at the reported location.",
+			"Make sure every required field is set and enum fields use an allowed value.",
+		},
+	}},
+	{ErrScaffoldFailed, TypeInfo{
+		Code: "KK003", Type: "scaffold_failed",
+		Summary: "The Terraform files could not be generated.",
+		Guidance: []string{
+			"Check that spec.scaffold.source is a directory or a built-in template name.",
+			"Check that spec.scaffold.destination is writable.",
+		},
+	}},
+	{ErrSCMFailed, TypeInfo{
+		Code: "KK004", Type: "scm_failed",
+		Summary: "The repository could not be created or pushed.",
+		Guidance: []string{
+			"Check that GITLAB_PRIVATE_TOKEN is set and has the api and write_repository scopes.",
+			"Check that the namespace exists and you may create projects in it.",
+		},
+	}},
+	{ErrProvisionFailed, TypeInfo{
+		Code: "KK005", Type: "provision_failed",
+		Summary: "Terraform failed while provisioning the infrastructure.",
+		Guidance: []string{
+			"Read the Terraform output in the log for the failing resource.",
+			"Run 'klonekit exec -- plan' to inspect the workspace, then re-run apply to resume.",
+		},
+	}},
+	{ErrRuntimeFailed, TypeInfo{
+		Code: "KK006", Type: "runtime_failed",
+		Summary: "The container runtime could not run Terraform.",
+		Guidance: []string{
+			"Check that Docker is installed and running, and that you can access its socket.",
+			"Check that the Terraform image can be pulled from this machine.",
+		},
+	}},
+	{ErrConfigInvalid, TypeInfo{
+		Code: "KK007", Type: "config_invalid",
+		Summary: "The configuration or credentials are invalid.",
+		Guidance: []string{
+			"Check the cloud credentials and profile in ~/.aws.",
+			"Check the option named in the error against the blueprint reference.",
+		},
+	}},
+	{ErrNetworkFailed, TypeInfo{
+		Code: "KK008", Type: "network_failed",
+		Summary: "A network operation failed.",
+		Guidance: []string{
+			"Check connectivity and proxy settings to the service in the error.",
+			"Transient failures are retried; raise the limit with --max-retries.",
+		},
+	}},
+	{ErrFileSystemFailed, TypeInfo{
+		Code: "KK009", Type: "filesystem_failed",
+		Summary: "A file or directory could not be read or written.",
+		Guidance: []string{
+			"Check the permissions and free space of the path in the error.",
+		},
+	}},
+}
+
+// unknownTypeInfo describes errors outside the KloneKit taxonomy.
+var unknownTypeInfo = TypeInfo{
+	Code:     "KK000",
+	Type:     "unknown",
+	Summary:  "An unclassified error occurred.",
+	Guidance: []string{"Check the error chain and the log file for details."},
+}
+
+// ErrorCode returns the stable code of an error type, e.g. "KK005" for ErrProvisionFailed.
+func ErrorCode(errType error) string {
+	return typeInfoFor(errType).Code
+}
+
+// LookupTypeInfo returns the description of an error type by code (e.g. "KK005") or
+// type name (e.g. "provision_failed").
+func LookupTypeInfo(codeOrType string) (TypeInfo, bool) {
+	for _, entry := range typeInfos {
+		if strings.EqualFold(entry.info.Code, codeOrType) || entry.info.Type == codeOrType {
+			return entry.info, true
+		}
+	}
+	return TypeInfo{}, false
+}
+
+// TypeInfos returns the descriptions of all error types in code order.
+func TypeInfos() []TypeInfo {
+	infos := make([]TypeInfo, 0, len(typeInfos))
+	for _, entry := range typeInfos {
+		infos = append(infos, entry.info)
+	}
+	return infos
+}
+
+// typeInfoFor returns the description of an error type, or unknownTypeInfo.
+func typeInfoFor(errType error) TypeInfo {
+	for _, entry := range typeInfos {
+		if entry.err == errType {
+			return entry.info
+		}
+	}
+	return unknownTypeInfo
+}
+
+// Explanation is the machine-parseable form of an error printed by --explain.
+type Explanation struct {
+	Code       string   `json:"code"`
+	Type       string   `json:"type"`
+	Context    string   `json:"context,omitempty"`
+	Cause      string   `json:"cause,omitempty"`
+	Suggestion string   `json:"suggestion,omitempty"`
+	Error      string   `json:"error"`
+	Chain      []string `json:"chain"`
+	Summary    string   `json:"summary"`
+	Guidance   []string `json:"guidance"`
+}
+
+// Explain builds the explanation of err, including the full chain of wrapped errors.
+func Explain(err error) Explanation {
+	info := unknownTypeInfo
+	explanation := Explanation{Error: err.Error()}
+
+	var kloneKitErr *KloneKitError
+	if errors.As(err, &kloneKitErr) {
+		info = typeInfoFor(kloneKitErr.Type)
+		explanation.Context = kloneKitErr.Context
+		explanation.Cause = kloneKitErr.Cause
+		explanation.Suggestion = suggestionFor(kloneKitErr)
+	}
+
+	explanation.Code = info.Code
+	explanation.Type = info.Type
+	explanation.Summary = info.Summary
+	explanation.Guidance = info.Guidance
+
+	for e := err; e != nil; e = errors.Unwrap(e) {
+		explanation.Chain = append(explanation.Chain, e.Error())
+	}
+	return explanation
+}
+
+// WriteExplanation writes the explanation of err to w as indented JSON.
+func WriteExplanation(w io.Writer, err error) error {
+	data, marshalErr := json.MarshalIndent(Explain(err), "", "  ")
+	if marshalErr != nil {
+		return fmt.Errorf("failed to marshal error explanation: %w", marshalErr)
+	}
+	_, writeErr := fmt.Fprintln(w, string(data))
+	return writeErr
+}
+
+// ExplainCode writes the guidance for an error code or type name to w, or for every
+// error type when codeOrType is empty.
+func ExplainCode(w io.Writer, codeOrType string, asJSON bool) error {
+	infos := TypeInfos()
+	if codeOrType != "" {
+		info, ok := LookupTypeInfo(codeOrType)
+		if !ok {
+			return fmt.Errorf("unknown error code or type '%s'", codeOrType)
+		}
+		infos = []TypeInfo{info}
+	}
+
+	if asJSON {
+		var value interface{} = infos
+		if codeOrType != "" {
+			value = infos[0]
+		}
+		data, err := json.MarshalIndent(value, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal error guidance: %w", err)
+		}
+		_, err = fmt.Fprintln(w, string(data))
+		return err
+	}
+
+	for i, info := range infos {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintf(w, "%s (%s): %s\n", info.Code, info.Type, info.Summary)
+		for _, step := range info.Guidance {
+			fmt.Fprintf(w, "  - %s\n", step)
+		}
+	}
+	return nil
+}
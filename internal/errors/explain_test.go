@@ -0,0 +1,100 @@
+package errors
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestExplain_KloneKitError(t *testing.T) {
+	root := errors.New("exit status 1")
+	err := fmt.Errorf("stage 'provision' failed: %w", NewProvisionError(
+		"Terraform apply failed",
+		"The S3 bucket name is already taken",
+		"Choose a globally unique bucket name",
+		fmt.Errorf("terraform apply failed: %w", root),
+	))
+
+	var buf bytes.Buffer
+	if writeErr := WriteExplanation(&buf, err); writeErr != nil {
+		t.Fatalf("Unexpected error: %v", writeErr)
+	}
+
+	var explanation Explanation
+	if jsonErr := json.Unmarshal(buf.Bytes(), &explanation); jsonErr != nil {
+		t.Fatalf("Expected JSON output, got %q: %v", buf.String(), jsonErr)
+	}
+
+	if explanation.Code != "KK005" || explanation.Type != "provision_failed" {
+		t.Errorf("Expected stable code KK005 and type provision_failed, got %s/%s", explanation.Code, explanation.Type)
+	}
+	if explanation.Context != "Terraform apply failed" ||
+		explanation.Cause != "The S3 bucket name is already taken" ||
+		explanation.Suggestion != "Choose a globally unique bucket name" {
+		t.Errorf("Expected context, cause and suggestion, got %+v", explanation)
+	}
+	if explanation.Error != err.Error() {
+		t.Errorf("Expected the error message, got %s", explanation.Error)
+	}
+	if len(explanation.Chain) != 4 || explanation.Chain[len(explanation.Chain)-1] != "exit status 1" {
+		t.Errorf("Expected the full error chain down to the root cause, got %v", explanation.Chain)
+	}
+	if explanation.Summary == "" || len(explanation.Guidance) == 0 {
+		t.Errorf("Expected guidance for the error type, got %+v", explanation)
+	}
+}
+
+func TestExplain_GenericError(t *testing.T) {
+	explanation := Explain(errors.New("something odd"))
+	if explanation.Code != "KK000" || explanation.Type != "unknown" {
+		t.Errorf("Expected the unknown code for a generic error, got %s/%s", explanation.Code, explanation.Type)
+	}
+}
+
+func TestErrorCodes_AreStableAndUnique(t *testing.T) {
+	expected := map[error]string{
+		ErrBlueprintNotFound:    "KK001",
+		ErrBlueprintParseFailed: "KK002",
+		ErrScaffoldFailed:       "KK003",
+		ErrSCMFailed:            "KK004",
+		ErrProvisionFailed:      "KK005",
+		ErrRuntimeFailed:        "KK006",
+		ErrConfigInvalid:        "KK007",
+		ErrNetworkFailed:        "KK008",
+		ErrFileSystemFailed:     "KK009",
+	}
+	for errType, code := range expected {
+		if got := ErrorCode(errType); got != code {
+			t.Errorf("Expected code %s for %v, got %s", code, errType, got)
+		}
+	}
+	if len(TypeInfos()) != len(expected) {
+		t.Errorf("Expected %d error types, got %d", len(expected), len(TypeInfos()))
+	}
+}
+
+func TestExplainCode(t *testing.T) {
+	var buf bytes.Buffer
+	if err := ExplainCode(&buf, "kk004", false); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !strings.HasPrefix(buf.String(), "KK004 (scm_failed):") || !strings.Contains(buf.String(), "GITLAB_PRIVATE_TOKEN") {
+		t.Errorf("Expected guidance for KK004, got: %s", buf.String())
+	}
+
+	buf.Reset()
+	if err := ExplainCode(&buf, "config_invalid", true); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	var info TypeInfo
+	if err := json.Unmarshal(buf.Bytes(), &info); err != nil || info.Code != "KK007" {
+		t.Errorf("Expected JSON guidance for config_invalid, got %q (%v)", buf.String(), err)
+	}
+
+	if err := ExplainCode(&buf, "KK999", false); err == nil {
+		t.Error("Expected error for an unknown code")
+	}
+}
@@ -12,6 +12,9 @@ import (
 	"klonekit/internal/ui"
 )
 
+// LogFileName is the name of the KloneKit log file in the log directory.
+const LogFileName = "klonekit.log"
+
 type ErrorHandler struct {
 	logger  *slog.Logger
 	console *ui.Console
@@ -159,15 +162,25 @@ func checkLogRotation(logPath string) error {
 	return nil
 }
 
+// LogFilePath returns the path of the KloneKit log file: the file in the OS-standard log
+// directory if it exists, otherwise the fallback in the current directory.
+func LogFilePath() string {
+	if logDir, err := getOSStandardLogDir(); err == nil {
+		logPath := filepath.Join(logDir, LogFileName)
+		if _, err := os.Stat(logPath); err == nil {
+			return logPath
+		}
+	}
+	return LogFileName
+}
+
 func createLogFile() (*os.File, error) {
 	logDir, _, err := createLogDirectoryWithFallback()
 	if err != nil {
 		return nil, fmt.Errorf("failed to create log directory: %w", err)
 	}
 
-	logFileName := "klonekit.log"
-
-	logPath := filepath.Join(logDir, logFileName)
+	logPath := filepath.Join(logDir, LogFileName)
 
 	// Check if log rotation is needed before opening the file
 	if err := checkLogRotation(logPath); err != nil {
@@ -188,12 +201,18 @@ func (h *ErrorHandler) Handle(err error) {
 	} else {
 		h.handleGenericError(err)
 	}
+
+	if explainMode {
+		if writeErr := WriteExplanation(os.Stderr, err); writeErr != nil {
+			h.logger.Error("Failed to write error explanation", "error", writeErr.Error())
+		}
+	}
 }
 
 func (h *ErrorHandler) handleKloneKitError(err *KloneKitError) {
 	h.logStructuredError(err)
 
-	message := h.console.FormatErrorMessage(err.Context, err.Cause, err.Suggestion)
+	message := h.console.FormatErrorMessage(err.Context, err.Cause, suggestionFor(err))
 	h.console.PrintError(message)
 }
 
@@ -210,6 +229,7 @@ func (h *ErrorHandler) logStructuredError(err *KloneKitError) {
 	logAttrs := []slog.Attr{
 		slog.String("error", err.OriginalErr.Error()),
 		slog.String("type", getErrorTypeName(err.Type)),
+		slog.String("code", ErrorCode(err.Type)),
 		slog.String("context", err.Context),
 	}
 
@@ -217,34 +237,13 @@ func (h *ErrorHandler) logStructuredError(err *KloneKitError) {
 		logAttrs = append(logAttrs, slog.String("cause", err.Cause))
 	}
 
-	if err.Suggestion != "" {
-		logAttrs = append(logAttrs, slog.String("suggestion", err.Suggestion))
+	if suggestion := suggestionFor(err); suggestion != "" {
+		logAttrs = append(logAttrs, slog.String("suggestion", suggestion))
 	}
 
 	h.logger.LogAttrs(context.TODO(), slog.LevelError, "KloneKit error occurred", logAttrs...)
 }
 
 func getErrorTypeName(errType error) string {
-	switch errType {
-	case ErrBlueprintNotFound:
-		return "blueprint_not_found"
-	case ErrBlueprintParseFailed:
-		return "blueprint_parse_failed"
-	case ErrScaffoldFailed:
-		return "scaffold_failed"
-	case ErrSCMFailed:
-		return "scm_failed"
-	case ErrProvisionFailed:
-		return "provision_failed"
-	case ErrRuntimeFailed:
-		return "runtime_failed"
-	case ErrConfigInvalid:
-		return "config_invalid"
-	case ErrNetworkFailed:
-		return "network_failed"
-	case ErrFileSystemFailed:
-		return "filesystem_failed"
-	default:
-		return "unknown"
-	}
-}
\ No newline at end of file
+	return typeInfoFor(errType).Type
+}
@@ -0,0 +1,61 @@
+package errors
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SuggestionsFileEnv names a rules file of custom suggestions when --suggestions-file is not
+// given.
+const SuggestionsFileEnv = "KLONEKIT_SUGGESTIONS_FILE"
+
+// customSuggestions maps error type names to the suggestion printed instead of the built-in one
+var customSuggestions map[string]string
+
+// LoadSuggestions reads a rules file mapping error types to custom suggestion text, e.g. to
+// point users at an internal runbook:
+//
+//	scm_failed: Request a GitLab token at https://wiki.example.com/gitlab-tokens
+//	KK006: Start Docker Desktop, or ask #platform for a remote Docker host.
+//
+// Keys are error type names or codes, as listed by `klonekit explain`. Types not in the file
+// keep their built-in suggestion. An empty path clears the custom suggestions.
+func LoadSuggestions(path string) error {
+	if path == "" {
+		customSuggestions = nil
+		return nil
+	}
+
+	content, err := os.ReadFile(path) // #nosec G304
+	if err != nil {
+		return fmt.Errorf("failed to read suggestions file: %w", err)
+	}
+	var rules map[string]string
+	if err := yaml.Unmarshal(content, &rules); err != nil {
+		return fmt.Errorf("failed to parse suggestions file %s: %w", path, err)
+	}
+
+	suggestions := make(map[string]string, len(rules))
+	for key, text := range rules {
+		info, ok := LookupTypeInfo(key)
+		if !ok {
+			return fmt.Errorf("suggestions file %s: unknown error code or type '%s'", path, key)
+		}
+		if text = strings.TrimSpace(text); text != "" {
+			suggestions[info.Type] = text
+		}
+	}
+	customSuggestions = suggestions
+	return nil
+}
+
+// suggestionFor returns the custom suggestion for the type of err, or its built-in suggestion.
+func suggestionFor(err *KloneKitError) string {
+	if text, ok := customSuggestions[getErrorTypeName(err.Type)]; ok {
+		return text
+	}
+	return err.Suggestion
+}
@@ -0,0 +1,88 @@
+package errors
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeSuggestionsFile(t *testing.T, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "suggestions.yaml")
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { customSuggestions = nil })
+	return path
+}
+
+func TestLoadSuggestions_OverridesBuiltInSuggestion(t *testing.T) {
+	t.Setenv("KLONEKIT_LOG_DIR", t.TempDir())
+	path := writeSuggestionsFile(t, `
+scm_failed: Request a GitLab token at https://wiki.example.com/gitlab-tokens
+KK006: Ask the platform team for a remote Docker host.
+`)
+	if err := LoadSuggestions(path); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	scmErr := NewSCMError("Failed to create repository", "401 Unauthorized", "Check your GitLab token", errors.New("401"))
+	runtimeErr := NewRuntimeError("Failed to pull image", "daemon not running", "Start Docker", errors.New("dial"))
+	provisionErr := NewProvisionError("Terraform apply failed", "exit status 1", "Read the Terraform output", errors.New("exit"))
+
+	tests := []struct {
+		err  *KloneKitError
+		want string
+	}{
+		{scmErr, "Request a GitLab token at https://wiki.example.com/gitlab-tokens"},
+		{runtimeErr, "Ask the platform team for a remote Docker host."},
+		{provisionErr, "Read the Terraform output"},
+	}
+	for _, tt := range tests {
+		if got := Explain(tt.err).Suggestion; got != tt.want {
+			t.Errorf("Expected suggestion %q for %s, got %q", tt.want, getErrorTypeName(tt.err.Type), got)
+		}
+	}
+
+	// The suggestion actually shown is the one logged
+	handler, err := NewErrorHandler()
+	if err != nil {
+		t.Fatalf("NewErrorHandler() failed: %v", err)
+	}
+	handler.Handle(scmErr)
+
+	content, err := os.ReadFile(filepath.Join(os.Getenv("KLONEKIT_LOG_DIR"), LogFileName))
+	if err != nil {
+		t.Fatalf("Failed to read log file: %s", err)
+	}
+	if !strings.Contains(string(content), "wiki.example.com/gitlab-tokens") || strings.Contains(string(content), "Check your GitLab token") {
+		t.Errorf("Expected the custom suggestion to be logged, got: %s", content)
+	}
+}
+
+func TestLoadSuggestions_RejectsUnknownType(t *testing.T) {
+	path := writeSuggestionsFile(t, "terraform_failed: Ask in #infra\n")
+
+	err := LoadSuggestions(path)
+	if err == nil || !strings.Contains(err.Error(), "unknown error code or type 'terraform_failed'") {
+		t.Errorf("Expected an unknown type error, got: %v", err)
+	}
+}
+
+func TestLoadSuggestions_EmptyPathClears(t *testing.T) {
+	path := writeSuggestionsFile(t, "scm_failed: Ask in #gitlab\n")
+	if err := LoadSuggestions(path); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if err := LoadSuggestions(""); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	scmErr := NewSCMError("context", "cause", "Check your GitLab token", errors.New("401"))
+	if got := suggestionFor(scmErr); got != "Check your GitLab token" {
+		t.Errorf("Expected the built-in suggestion, got %q", got)
+	}
+}
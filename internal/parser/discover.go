@@ -0,0 +1,46 @@
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// BlueprintNamesEnv overrides the file names searched for when no blueprint file is given.
+// It holds a comma-separated list of names or glob patterns, tried in order.
+const BlueprintNamesEnv = "KLONEKIT_BLUEPRINT_NAMES"
+
+// DefaultBlueprintNames are the file names searched for when BlueprintNamesEnv is not set.
+var DefaultBlueprintNames = []string{"klonekit.yml", "klonekit.yaml"}
+
+// BlueprintNames returns the candidate blueprint file names, from BlueprintNamesEnv when set.
+func BlueprintNames() []string {
+	var names []string
+	for _, name := range strings.Split(os.Getenv(BlueprintNamesEnv), ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return DefaultBlueprintNames
+	}
+	return names
+}
+
+// FindBlueprintFile returns the first file in dir matching the candidate blueprint names,
+// or an empty string if there is none. Patterns matching several files yield the first in
+// lexical order.
+func FindBlueprintFile(dir string) string {
+	for _, name := range BlueprintNames() {
+		matches, err := filepath.Glob(filepath.Join(dir, name))
+		if err != nil {
+			continue
+		}
+		for _, match := range matches {
+			if info, err := os.Stat(match); err == nil && !info.IsDir() {
+				return match
+			}
+		}
+	}
+	return ""
+}
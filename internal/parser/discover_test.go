@@ -0,0 +1,67 @@
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFindBlueprintFile_Defaults(t *testing.T) {
+	t.Setenv(BlueprintNamesEnv, "")
+	dir := t.TempDir()
+
+	if found := FindBlueprintFile(dir); found != "" {
+		t.Errorf("Expected no blueprint in an empty directory, got %s", found)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "klonekit.yaml"), []byte("kind: Blueprint"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if found := FindBlueprintFile(dir); found != filepath.Join(dir, "klonekit.yaml") {
+		t.Errorf("Expected klonekit.yaml to be detected, got %q", found)
+	}
+
+	// klonekit.yml takes precedence over klonekit.yaml
+	if err := os.WriteFile(filepath.Join(dir, "klonekit.yml"), []byte("kind: Blueprint"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if found := FindBlueprintFile(dir); found != filepath.Join(dir, "klonekit.yml") {
+		t.Errorf("Expected klonekit.yml to be detected, got %q", found)
+	}
+}
+
+func TestFindBlueprintFile_ConfiguredNames(t *testing.T) {
+	t.Setenv(BlueprintNamesEnv, "infra.blueprint.yaml, *.klonekit.yaml")
+	dir := t.TempDir()
+
+	// The defaults are no longer searched once names are configured
+	if err := os.WriteFile(filepath.Join(dir, "klonekit.yml"), []byte("kind: Blueprint"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if found := FindBlueprintFile(dir); found != "" {
+		t.Errorf("Expected default names to be ignored, got %s", found)
+	}
+
+	for _, name := range []string{"b.klonekit.yaml", "a.klonekit.yaml"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("kind: Blueprint"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if found := FindBlueprintFile(dir); found != filepath.Join(dir, "a.klonekit.yaml") {
+		t.Errorf("Expected the first pattern match to be detected, got %q", found)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "infra.blueprint.yaml"), []byte("kind: Blueprint"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if found := FindBlueprintFile(dir); found != filepath.Join(dir, "infra.blueprint.yaml") {
+		t.Errorf("Expected the first configured name to take precedence, got %q", found)
+	}
+}
+
+func TestBlueprintNames(t *testing.T) {
+	t.Setenv(BlueprintNamesEnv, " , ")
+	if names := BlueprintNames(); len(names) != 2 || names[0] != "klonekit.yml" {
+		t.Errorf("Expected default names for a blank setting, got %v", names)
+	}
+}
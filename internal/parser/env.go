@@ -0,0 +1,107 @@
+package parser
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// unexpandedFields are the string fields whose environment variable references are left for
+// the command they run to expand, keyed by their lowercase path. The cost estimate and
+// policy commands reference KLONEKIT_PLAN_FILE and KLONEKIT_PLAN_JSON, which are only set
+// when they run, and the decryption command's arguments are passed to it as written.
+var unexpandedFields = map[string]bool{
+	"spec.provision.costestimatecommand": true,
+	"spec.provision.policycommand":       true,
+	"spec.decryption.command":            true,
+}
+
+// expandEnvReferences replaces ${VAR} and $VAR references to environment variables in the
+// string values of a YAML document, so secrets such as spec.scm.token can be kept out of the
+// blueprint. Keys and non-string values are left as they are, and $$ stands for a literal $.
+// References whose name isn't an environment variable name, such as the IAM policy variable
+// ${aws:username} or the Terraform interpolation ${var.name}, are left as written. A
+// reference to an unset variable is an error, while a variable set to an empty value
+// expands to an empty string.
+func expandEnvReferences(document []byte) ([]byte, error) {
+	var node yaml.Node
+	if err := yaml.Unmarshal(document, &node); err != nil {
+		return nil, fmt.Errorf("failed to read blueprint file: %w", err)
+	}
+
+	var missing []string
+	expandNode(&node, "", &missing)
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("blueprint references unset environment variables: %s; set them, or write $$ for a literal $", strings.Join(missing, ", "))
+	}
+
+	return yaml.Marshal(&node)
+}
+
+// expandNode expands the environment variable references in the string scalars below node,
+// whose dotted path is path, adding each unset variable with the path referencing it to missing.
+func expandNode(node *yaml.Node, path string, missing *[]string) {
+	if unexpandedFields[strings.ToLower(path)] {
+		return
+	}
+
+	switch node.Kind {
+	case yaml.DocumentNode, yaml.SequenceNode:
+		for i, child := range node.Content {
+			childPath := path
+			if node.Kind == yaml.SequenceNode {
+				childPath = fmt.Sprintf("%s[%d]", path, i)
+			}
+			expandNode(child, childPath, missing)
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			childPath := node.Content[i].Value
+			if path != "" {
+				childPath = path + "." + childPath
+			}
+			expandNode(node.Content[i+1], childPath, missing)
+		}
+	case yaml.ScalarNode:
+		if node.Tag != "!!str" || !strings.Contains(node.Value, "$") {
+			return
+		}
+		node.Value = os.Expand(node.Value, func(name string) string {
+			if name == "$" {
+				return "$"
+			}
+			if !isEnvName(name) {
+				return unexpandedReference(name)
+			}
+			value, ok := os.LookupEnv(name)
+			if !ok {
+				*missing = append(*missing, fmt.Sprintf("%s (%s)", name, path))
+			}
+			return value
+		})
+	}
+}
+
+// isEnvName reports whether name can name an environment variable: letters, digits and
+// underscores, not starting with a digit.
+func isEnvName(name string) bool {
+	for i, r := range name {
+		isLetter := r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z'
+		if !isLetter && (i == 0 || r < '0' || r > '9') {
+			return false
+		}
+	}
+	return name != ""
+}
+
+// unexpandedReference returns a reference os.Expand passed for a name that isn't an
+// environment variable name as written. Only single characters, such as $1, are read
+// without braces.
+func unexpandedReference(name string) string {
+	if len(name) == 1 {
+		return "$" + name
+	}
+	return "${" + name + "}"
+}
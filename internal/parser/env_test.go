@@ -0,0 +1,153 @@
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"klonekit/pkg/blueprint"
+)
+
+// envBlueprintYaml is a valid blueprint whose token, region and variables are given by the test
+const envBlueprintYaml = `apiVersion: v1
+kind: Blueprint
+metadata:
+  name: test
+spec:
+  scm:
+    provider: gitlab
+    url: https://gitlab.com
+    token: %TOKEN%
+    project:
+      name: test
+      namespace: test
+      visibility: private
+  cloud:
+    provider: aws
+    region: %REGION%
+  scaffold:
+    source: ./src
+    destination: ./dst
+  variables:
+    %VARIABLES%
+  provision:
+    costEstimateCommand: infracost breakdown --path $KLONEKIT_PLAN_FILE
+    policyCommand: conftest test ${KLONEKIT_PLAN_JSON}
+`
+
+func parseEnvBlueprint(t *testing.T, token, region, variables string) (*blueprint.Blueprint, error) {
+	t.Helper()
+	content := strings.NewReplacer("%TOKEN%", token, "%REGION%", region, "%VARIABLES%", variables).Replace(envBlueprintYaml)
+	filePath := filepath.Join(t.TempDir(), "blueprint.yaml")
+	if err := os.WriteFile(filePath, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return Parse(filePath)
+}
+
+func TestParse_ExpandsEnvReferences(t *testing.T) {
+	t.Setenv("KLONEKIT_TEST_TOKEN", "glpat-secret")
+	t.Setenv("KLONEKIT_TEST_REGION", "eu-west-1")
+	t.Setenv("KLONEKIT_TEST_ENV", "staging")
+	t.Setenv("KLONEKIT_TEST_COUNT", "3")
+
+	bp, err := parseEnvBlueprint(t, "${KLONEKIT_TEST_TOKEN}", "$KLONEKIT_TEST_REGION",
+		"{name: \"app-${KLONEKIT_TEST_ENV}\", replicas: $KLONEKIT_TEST_COUNT, tags: [$KLONEKIT_TEST_ENV], count: 2}")
+	if err != nil {
+		t.Fatalf("Expected successful parsing, got error: %v", err)
+	}
+
+	if bp.Spec.SCM.Token != "glpat-secret" {
+		t.Errorf("Expected the token from the environment, got %q", bp.Spec.SCM.Token)
+	}
+	if bp.Spec.Cloud.Region != "eu-west-1" {
+		t.Errorf("Expected the region from the environment, got %q", bp.Spec.Cloud.Region)
+	}
+	if name := bp.Spec.Variables["name"]; name != "app-staging" {
+		t.Errorf("Expected a reference within a value to be expanded, got %v", name)
+	}
+	// Expanded values stay strings, while values without references keep their type
+	if replicas := bp.Spec.Variables["replicas"]; replicas != "3" {
+		t.Errorf("Expected replicas \"3\", got %#v", replicas)
+	}
+	if count := bp.Spec.Variables["count"]; count != 2 {
+		t.Errorf("Expected count 2, got %#v", count)
+	}
+	if tags, ok := bp.Spec.Variables["tags"].([]interface{}); !ok || len(tags) != 1 || tags[0] != "staging" {
+		t.Errorf("Expected references in lists to be expanded, got %#v", bp.Spec.Variables["tags"])
+	}
+	// The cost estimate and policy commands are expanded by their shell when they run
+	if command := bp.Spec.Provision.CostEstimateCommand; command != "infracost breakdown --path $KLONEKIT_PLAN_FILE" {
+		t.Errorf("Expected the cost estimate command to be left as written, got %q", command)
+	}
+	if command := bp.Spec.Provision.PolicyCommand; command != "conftest test ${KLONEKIT_PLAN_JSON}" {
+		t.Errorf("Expected the policy command to be left as written, got %q", command)
+	}
+}
+
+func TestParse_UnsetEnvReference(t *testing.T) {
+	_, err := parseEnvBlueprint(t, "${KLONEKIT_TEST_UNSET_TOKEN}", "us-east-1", "{}")
+	if err == nil {
+		t.Fatal("Expected an error for an unset environment variable, got nil")
+	}
+	if !strings.Contains(err.Error(), "KLONEKIT_TEST_UNSET_TOKEN (spec.scm.token)") {
+		t.Errorf("Expected the error to name the variable and field, got: %v", err)
+	}
+}
+
+func TestParse_EmptyEnvReference(t *testing.T) {
+	t.Setenv("KLONEKIT_TEST_EMPTY", "")
+
+	bp, err := parseEnvBlueprint(t, "token", "us-east-1", "{suffix: \"${KLONEKIT_TEST_EMPTY}\"}")
+	if err != nil {
+		t.Fatalf("Expected successful parsing, got error: %v", err)
+	}
+	if suffix := bp.Spec.Variables["suffix"]; suffix != "" {
+		t.Errorf("Expected a variable set to an empty value to expand to an empty string, got %#v", suffix)
+	}
+
+	// An empty value still has to pass validation
+	if _, err := parseEnvBlueprint(t, "${KLONEKIT_TEST_EMPTY}", "us-east-1", "{}"); err == nil || !strings.Contains(err.Error(), "'Token' is required") {
+		t.Errorf("Expected an empty token to fail validation, got: %v", err)
+	}
+}
+
+func TestParse_EscapedDollar(t *testing.T) {
+	t.Setenv("KLONEKIT_TEST_TOKEN", "glpat-secret")
+
+	bp, err := parseEnvBlueprint(t, "token", "us-east-1", "{password: \"pa$$word\", literal: \"$${KLONEKIT_TEST_TOKEN}\", price: \"5 $\"}")
+	if err != nil {
+		t.Fatalf("Expected successful parsing, got error: %v", err)
+	}
+
+	want := map[string]string{"password": "pa$word", "literal": "${KLONEKIT_TEST_TOKEN}", "price": "5 $"}
+	for key, value := range want {
+		if got := bp.Spec.Variables[key]; got != value {
+			t.Errorf("Expected %s %q, got %#v", key, value, got)
+		}
+	}
+}
+
+func TestParse_KeepsNonEnvReferences(t *testing.T) {
+	bp, err := parseEnvBlueprint(t, "token", "us-east-1",
+		"{policy: \"arn:aws:s3:::bucket/${aws:username}/*\", greeting: \"hello ${var.name}\", first: \"$1\"}\n"+
+			"  decryption:\n    command: [sh, -c, \"sops decrypt --input-type json ${KLONEKIT_TEST_UNSET_FILE}\"]")
+	if err != nil {
+		t.Fatalf("Expected references that aren't environment variables to parse, got error: %v", err)
+	}
+
+	want := map[string]string{
+		"policy":   "arn:aws:s3:::bucket/${aws:username}/*",
+		"greeting": "hello ${var.name}",
+		"first":    "$1",
+	}
+	for key, value := range want {
+		if got := bp.Spec.Variables[key]; got != value {
+			t.Errorf("Expected %s to be left as written, %q, got %#v", key, value, got)
+		}
+	}
+	if command := bp.Spec.Decryption.Command; len(command) != 3 || command[2] != "sops decrypt --input-type json ${KLONEKIT_TEST_UNSET_FILE}" {
+		t.Errorf("Expected the decryption command to be left as written, got %q", command)
+	}
+}
@@ -1,47 +1,226 @@
 package parser
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
+	"io"
+	"net/url"
 	"os"
+	"strings"
+	"time"
 
+	units "github.com/docker/go-units"
 	validator "github.com/go-playground/validator/v10"
 	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
 
 	"klonekit/pkg/blueprint"
+	"klonekit/pkg/runtime"
 )
 
 var validate *validator.Validate
 
 func init() {
 	validate = validator.New()
+	if err := validate.RegisterValidation("networkmode", validateNetworkMode); err != nil {
+		panic(fmt.Sprintf("failed to register networkmode validation: %v", err))
+	}
+	if err := validate.RegisterValidation("tfvarsfilename", validateTfvarsFilename); err != nil {
+		panic(fmt.Sprintf("failed to register tfvarsfilename validation: %v", err))
+	}
+	if err := validate.RegisterValidation("statepushurl", validateStatePushURL); err != nil {
+		panic(fmt.Sprintf("failed to register statepushurl validation: %v", err))
+	}
+	if err := validate.RegisterValidation("terraformversion", validateTerraformVersion); err != nil {
+		panic(fmt.Sprintf("failed to register terraformversion validation: %v", err))
+	}
+	if err := validate.RegisterValidation("memorysize", validateMemorySize); err != nil {
+		panic(fmt.Sprintf("failed to register memorysize validation: %v", err))
+	}
+	if err := validate.RegisterValidation("duration", validateDuration); err != nil {
+		panic(fmt.Sprintf("failed to register duration validation: %v", err))
+	}
+	if err := validate.RegisterValidation("workspacename", validateWorkspaceName); err != nil {
+		panic(fmt.Sprintf("failed to register workspacename validation: %v", err))
+	}
+	validate.RegisterStructValidation(validateSCMProject, blueprint.SCMProvider{})
+}
+
+// validateStatePushURL reports whether the field is an s3, http or https URL with a host.
+func validateStatePushURL(fl validator.FieldLevel) bool {
+	u, err := url.Parse(fl.Field().String())
+	if err != nil || u.Host == "" {
+		return false
+	}
+	switch u.Scheme {
+	case "s3", "http", "https":
+		return true
+	default:
+		return false
+	}
+}
+
+// validateTfvarsFilename reports whether the field is a plain file name ending in .tfvars or .tfvars.json.
+func validateTfvarsFilename(fl validator.FieldLevel) bool {
+	name := fl.Field().String()
+	if strings.ContainsAny(name, `/\`) {
+		return false
+	}
+	for _, ext := range []string{".tfvars", ".tfvars.json"} {
+		if strings.HasSuffix(name, ext) && len(name) > len(ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// validateTerraformVersion reports whether the field is a semantic version usable as an image tag.
+func validateTerraformVersion(fl validator.FieldLevel) bool {
+	return blueprint.IsTerraformVersion(fl.Field().String())
+}
+
+// validateMemorySize reports whether the field is a positive memory size such as "512m" or "2g".
+func validateMemorySize(fl validator.FieldLevel) bool {
+	size, err := units.RAMInBytes(fl.Field().String())
+	return err == nil && size > 0
+}
+
+// validateDuration reports whether the field is a positive duration such as "45m" or "1h30m".
+func validateDuration(fl validator.FieldLevel) bool {
+	duration, err := time.ParseDuration(fl.Field().String())
+	return err == nil && duration > 0
+}
+
+// validateWorkspaceName reports whether the field is a name Terraform accepts for a workspace,
+// which it checks by requiring the name to be a URL path segment that needs no escaping.
+func validateWorkspaceName(fl validator.FieldLevel) bool {
+	name := fl.Field().String()
+	return name == url.PathEscape(name)
+}
+
+// validateNetworkMode reports whether the field holds a supported container network mode.
+func validateNetworkMode(fl validator.FieldLevel) bool {
+	return runtime.ValidateNetworkMode(fl.Field().String()) == nil
 }
 
 // Parse reads and validates a blueprint YAML file, returning the parsed Blueprint struct or an error.
+// Files containing multiple YAML documents are rejected; use ParseAll to read them.
 func Parse(filePath string) (*blueprint.Blueprint, error) {
+	blueprints, err := ParseAll(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(blueprints) > 1 {
+		return nil, fmt.Errorf("blueprint file %s contains %d blueprints; multi-document files are only supported by apply", filePath, len(blueprints))
+	}
+
+	return blueprints[0], nil
+}
+
+// ParseAll reads and validates every blueprint in a YAML file. Documents are separated
+// with "---" as in Kubernetes manifests; a single-document file yields one blueprint.
+func ParseAll(filePath string) ([]*blueprint.Blueprint, error) {
 	// Check if file exists
 	if _, err := os.Stat(filePath); os.IsNotExist(err) {
 		return nil, fmt.Errorf("blueprint file not found: %s", filePath)
 	}
 
+	data, err := os.ReadFile(filePath) // #nosec G304
+	if err != nil {
+		return nil, fmt.Errorf("failed to read blueprint file: %w", err)
+	}
+
+	documents, err := splitDocuments(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read blueprint file: %w", err)
+	}
+	if len(documents) == 0 {
+		return nil, fmt.Errorf("failed to read blueprint file: %s contains no blueprint", filePath)
+	}
+
+	blueprints := make([]*blueprint.Blueprint, 0, len(documents))
+	for i, document := range documents {
+		bp, err := parseDocument(document)
+		if err != nil {
+			if len(documents) > 1 {
+				return nil, fmt.Errorf("blueprint document %d: %w", i+1, err)
+			}
+			return nil, err
+		}
+		blueprints = append(blueprints, bp)
+	}
+
+	return blueprints, nil
+}
+
+// splitDocuments splits a YAML stream into its non-empty documents.
+func splitDocuments(data []byte) ([][]byte, error) {
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+
+	var documents [][]byte
+	for {
+		var node yaml.Node
+		if err := decoder.Decode(&node); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, err
+		}
+		if len(node.Content) == 0 || node.Content[0].Kind == 0 {
+			continue // Empty document, e.g. a leading "---"
+		}
+
+		document, err := yaml.Marshal(&node)
+		if err != nil {
+			return nil, err
+		}
+		documents = append(documents, document)
+	}
+
+	return documents, nil
+}
+
+// parseDocument unmarshals and validates a single YAML blueprint document.
+func parseDocument(document []byte) (*blueprint.Blueprint, error) {
+	// Expand environment variable references before the values are decoded and validated
+	document, err := expandEnvReferences(document)
+	if err != nil {
+		return nil, err
+	}
+
+	// Turn a list of scaffold modules into the object form the blueprint types describe
+	document, err = normalizeScaffoldList(document)
+	if err != nil {
+		return nil, err
+	}
+
 	// Configure Viper
 	v := viper.New()
-	v.SetConfigFile(filePath)
 	v.SetConfigType("yaml")
 
-	// Read the file
-	if err := v.ReadInConfig(); err != nil {
-		if _, ok := err.(viper.ConfigFileNotFoundError); ok {
-			return nil, fmt.Errorf("blueprint file not found: %s", filePath)
-		}
+	// Read the document
+	if err := v.ReadConfig(bytes.NewReader(document)); err != nil {
 		return nil, fmt.Errorf("failed to read blueprint file: %w", err)
 	}
 
+	// The default branch is a project setting; reject it one level up rather than ignore it
+	if v.IsSet("spec.scm.defaultBranch") {
+		return nil, fmt.Errorf("validation error: field 'spec.scm.defaultBranch' is not supported; set spec.scm.project.defaultBranch instead")
+	}
+
 	// Unmarshal into Blueprint struct
 	var bp blueprint.Blueprint
 	if err := v.Unmarshal(&bp); err != nil {
 		return nil, fmt.Errorf("failed to parse blueprint file - malformed YAML: %w", err)
 	}
 
+	// Expand templated project names before they are validated
+	if err := expandProjectTemplates(&bp); err != nil {
+		return nil, err
+	}
+
 	// Validate the structure
 	if err := validate.Struct(&bp); err != nil {
 		return nil, formatValidationError(err)
@@ -85,6 +264,46 @@ func formatFieldError(e validator.FieldError) string {
 		return fmt.Sprintf("field '%s' must be one of: %s", field, e.Param())
 	case "url":
 		return fmt.Sprintf("field '%s' must be a valid URL", field)
+	case "min":
+		return fmt.Sprintf("field '%s' must be at least %s", field, e.Param())
+	case "email":
+		return fmt.Sprintf("field '%s' must be a valid email address", field)
+	case "required_without":
+		return fmt.Sprintf("field '%s' is required when '%s' is not set", field, e.Param())
+	case "unique":
+		return fmt.Sprintf("field '%s' must not contain duplicate entries", field)
+	case "excluded_with":
+		return fmt.Sprintf("field '%s' cannot be combined with '%s'", field, e.Param())
+	case "tfvarsfilename":
+		return fmt.Sprintf("field '%s' must be a file name ending in .tfvars or .tfvars.json", field)
+	case "statepushurl":
+		return fmt.Sprintf("field '%s' must be an s3://, http:// or https:// URL", field)
+	case "gitlabpath":
+		message := fmt.Sprintf("field '%s' is '%v', which is not a valid GitLab path (lowercase letters, digits, '_', '-' and '.', not starting or ending with a special character, without consecutive special characters and not ending in .git or .atom)", field, e.Value())
+		if suggestion := suggestGitLabPath(fmt.Sprint(e.Value())); suggestion != "" {
+			message += fmt.Sprintf("; use '%s' instead", suggestion)
+		}
+		return message
+	case "gitlabnamespace":
+		return fmt.Sprintf("field '%s' is '%v', which is not a valid GitLab namespace (paths of letters, digits, '_', '-' and '.' separated by '/')", field, e.Value())
+	case "githubrepo":
+		return fmt.Sprintf("field '%s' is '%v', which is not a valid GitHub repository name (at most 100 letters, digits, '_', '-' and '.', not ending in .git)", field, e.Value())
+	case "githubowner":
+		return fmt.Sprintf("field '%s' is '%v', which is not a valid GitHub owner (at most 39 letters, digits and '-', not starting or ending with '-')", field, e.Value())
+	case "gitlabonly":
+		return fmt.Sprintf("field '%s' is only supported with the gitlab provider", field)
+	case "terraformversion":
+		return fmt.Sprintf("field '%s' is '%v', which is not a Terraform version (a semantic version such as 1.8.0 or 1.9.0-beta2)", field, e.Value())
+	case "memorysize":
+		return fmt.Sprintf("field '%s' is '%v', which is not a memory size (bytes or a number with a unit, e.g. 512m or 2g)", field, e.Value())
+	case "duration":
+		return fmt.Sprintf("field '%s' is '%v', which is not a positive duration (e.g. 45m or 1h30m)", field, e.Value())
+	case "gt":
+		return fmt.Sprintf("field '%s' must be greater than %s", field, e.Param())
+	case "workspacename":
+		return fmt.Sprintf("field '%s' is '%v', which is not a valid Terraform workspace name (letters, digits and - _ . ~ $ & + : = @)", field, e.Value())
+	case "networkmode":
+		return fmt.Sprintf("field '%s' must be default, bridge, host, none, container:<name> or a network name", field)
 	default:
 		return fmt.Sprintf("field '%s' failed validation (%s)", field, tag)
 	}
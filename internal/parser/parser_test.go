@@ -218,8 +218,8 @@ metadata:
   name: test
 spec:
   scm:
-    provider: github
-    url: https://gitlab.com
+    provider: bitbucket
+    url: https://bitbucket.org
     token: token
     project:
       name: test
@@ -231,7 +231,7 @@ spec:
     source: ./src
     destination: ./dst
 `,
-			expectedError: "field 'Provider' must be one of: gitlab",
+			expectedError: "field 'Provider' must be one of: gitlab github",
 		},
 		{
 			name: "invalid URL",
@@ -256,6 +256,161 @@ spec:
 `,
 			expectedError: "field 'URL' must be a valid URL",
 		},
+		{
+			name: "invalid network mode",
+			yaml: `apiVersion: v1
+kind: Blueprint
+metadata:
+  name: test
+spec:
+  scm:
+    provider: gitlab
+    url: https://gitlab.com
+    token: token
+    project:
+      name: test
+      namespace: test
+  cloud:
+    provider: aws
+    region: us-east-1
+  scaffold:
+    source: ./src
+    destination: ./dst
+  provision:
+    networkMode: "not a network"
+`,
+			expectedError: "field 'NetworkMode' must be default, bridge, host, none",
+		},
+		{
+			name: "invalid backend migration",
+			yaml: `apiVersion: v1
+kind: Blueprint
+metadata:
+  name: test
+spec:
+  scm:
+    provider: gitlab
+    url: https://gitlab.com
+    token: token
+    project:
+      name: test
+      namespace: test
+  cloud:
+    provider: aws
+    region: us-east-1
+  scaffold:
+    source: ./src
+    destination: ./dst
+  provision:
+    backendMigration: copy
+`,
+			expectedError: "field 'BackendMigration' must be one of: migrate reconfigure",
+		},
+		{
+			name: "stage env for an unknown stage",
+			yaml: `apiVersion: v1
+kind: Blueprint
+metadata:
+  name: test
+spec:
+  scm:
+    provider: gitlab
+    url: https://gitlab.com
+    token: token
+    project:
+      name: test
+      namespace: test
+  cloud:
+    provider: aws
+    region: us-east-1
+  scaffold:
+    source: ./src
+    destination: ./dst
+  stageEnv:
+    deploy:
+      - name: AWS_PROFILE
+        value: deploy
+`,
+			expectedError: "must be one of: scaffold scm provision",
+		},
+		{
+			name: "invalid commit email",
+			yaml: `apiVersion: v1
+kind: Blueprint
+metadata:
+  name: test
+spec:
+  scm:
+    provider: gitlab
+    url: https://gitlab.com
+    token: token
+    project:
+      name: test
+      namespace: test
+    commit:
+      author: Platform Bot
+      email: platform-bot
+  cloud:
+    provider: aws
+    region: us-east-1
+  scaffold:
+    source: ./src
+    destination: ./dst
+`,
+			expectedError: "field 'Email' must be a valid email address",
+		},
+		{
+			name: "negative max output bytes",
+			yaml: `apiVersion: v1
+kind: Blueprint
+metadata:
+  name: test
+spec:
+  scm:
+    provider: gitlab
+    url: https://gitlab.com
+    token: token
+    project:
+      name: test
+      namespace: test
+  cloud:
+    provider: aws
+    region: us-east-1
+  scaffold:
+    source: ./src
+    destination: ./dst
+  provision:
+    maxOutputBytes: -1
+`,
+			expectedError: "field 'MaxOutputBytes' must be at least 1",
+		},
+		{
+			name: "image with terraform version",
+			yaml: `apiVersion: v1
+kind: Blueprint
+metadata:
+  name: test
+spec:
+  scm:
+    provider: gitlab
+    url: https://gitlab.com
+    token: token
+    project:
+      name: test
+      namespace: test
+  cloud:
+    provider: aws
+    region: us-east-1
+  scaffold:
+    source: ./src
+    destination: ./dst
+  provision:
+    image: example.com/terraform:1.8.0
+    terraform:
+      version: "1.5.7"
+`,
+			expectedError: "field 'Image' cannot be combined with 'Terraform.Version'",
+		},
 	}
 
 	for _, tt := range tests {
@@ -281,3 +436,834 @@ spec:
 		})
 	}
 }
+
+// multiDocumentYaml contains two valid blueprints separated by a document marker
+const multiDocumentYaml = `apiVersion: v1
+kind: Blueprint
+metadata:
+  name: network
+spec:
+  scm:
+    provider: gitlab
+    url: https://gitlab.example.com
+    token: glpat-token123
+    project:
+      name: network
+      namespace: my-org
+      visibility: private
+  cloud:
+    provider: aws
+    region: us-east-1
+  scaffold:
+    source: ./network
+    destination: ./output/network
+---
+apiVersion: v1
+kind: Blueprint
+metadata:
+  name: compute
+spec:
+  scm:
+    provider: gitlab
+    url: https://gitlab.example.com
+    token: glpat-token123
+    project:
+      name: compute
+      namespace: my-org
+      visibility: private
+  cloud:
+    provider: aws
+    region: eu-west-1
+  scaffold:
+    source: ./compute
+    destination: ./output/compute
+`
+
+func TestParseAll_MultipleDocuments(t *testing.T) {
+	tmpDir := t.TempDir()
+	filePath := filepath.Join(tmpDir, "multi-blueprint.yaml")
+	if err := os.WriteFile(filePath, []byte(multiDocumentYaml), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	blueprints, err := ParseAll(filePath)
+	if err != nil {
+		t.Fatalf("Expected successful parsing, got error: %v", err)
+	}
+	if len(blueprints) != 2 {
+		t.Fatalf("Expected 2 blueprints, got %d", len(blueprints))
+	}
+	if blueprints[0].Metadata.Name != "network" || blueprints[1].Metadata.Name != "compute" {
+		t.Errorf("Expected blueprints in file order, got '%s' and '%s'", blueprints[0].Metadata.Name, blueprints[1].Metadata.Name)
+	}
+	if blueprints[1].Spec.Cloud.Region != "eu-west-1" {
+		t.Errorf("Expected second blueprint region 'eu-west-1', got '%s'", blueprints[1].Spec.Cloud.Region)
+	}
+}
+
+func TestParseAll_InvalidDocumentIsReported(t *testing.T) {
+	tmpDir := t.TempDir()
+	content := multiDocumentYaml + "---\napiVersion: v1\nkind: Blueprint\nmetadata:\n  name: broken\n"
+	filePath := filepath.Join(tmpDir, "multi-blueprint.yaml")
+	if err := os.WriteFile(filePath, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := ParseAll(filePath)
+	if err == nil {
+		t.Fatal("Expected validation error for incomplete document, got nil")
+	}
+	if !strings.Contains(err.Error(), "blueprint document 3") {
+		t.Errorf("Expected error to identify the failing document, got: %v", err)
+	}
+}
+
+func TestParse_RejectsMultipleDocuments(t *testing.T) {
+	tmpDir := t.TempDir()
+	filePath := filepath.Join(tmpDir, "multi-blueprint.yaml")
+	if err := os.WriteFile(filePath, []byte(multiDocumentYaml), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := Parse(filePath)
+	if err == nil {
+		t.Fatal("Expected error for multi-document file, got nil")
+	}
+	if !strings.Contains(err.Error(), "contains 2 blueprints") {
+		t.Errorf("Expected multi-document error, got: %v", err)
+	}
+}
+
+func TestParse_BackendEnv(t *testing.T) {
+	tmpDir := t.TempDir()
+	content := `apiVersion: v1
+kind: Blueprint
+metadata:
+  name: test
+spec:
+  scm:
+    provider: gitlab
+    url: https://gitlab.com
+    token: token
+    project:
+      name: test
+      namespace: test
+      visibility: private
+  cloud:
+    provider: aws
+    region: us-east-1
+  scaffold:
+    source: ./src
+    destination: ./dst
+  provision:
+    backendEnv:
+      - name: AWS_ACCESS_KEY_ID
+        value: backend-key
+      - name: AWS_SECRET_ACCESS_KEY
+        fromEnv: BACKEND_SECRET
+`
+	filePath := filepath.Join(tmpDir, "blueprint.yaml")
+	if err := os.WriteFile(filePath, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	bp, err := Parse(filePath)
+	if err != nil {
+		t.Fatalf("Expected successful parsing, got error: %v", err)
+	}
+
+	backendEnv := bp.Spec.Provision.BackendEnv
+	if len(backendEnv) != 2 {
+		t.Fatalf("Expected 2 backend env vars, got %d", len(backendEnv))
+	}
+	// Variable names keep their case
+	if backendEnv[0].Name != "AWS_ACCESS_KEY_ID" || backendEnv[0].Value != "backend-key" {
+		t.Errorf("Unexpected first backend env var: %+v", backendEnv[0])
+	}
+	if backendEnv[1].Name != "AWS_SECRET_ACCESS_KEY" || backendEnv[1].FromEnv != "BACKEND_SECRET" {
+		t.Errorf("Unexpected second backend env var: %+v", backendEnv[1])
+	}
+}
+
+func TestParse_RejectsSCMDefaultBranch(t *testing.T) {
+	content := `apiVersion: v1
+kind: Blueprint
+metadata:
+  name: test
+spec:
+  scm:
+    provider: gitlab
+    url: https://gitlab.com
+    token: token
+    defaultBranch: trunk
+    project:
+      name: test
+      namespace: test
+      visibility: private
+  cloud:
+    provider: aws
+    region: us-east-1
+  scaffold:
+    source: ./src
+    destination: ./dst
+`
+	filePath := filepath.Join(t.TempDir(), "blueprint.yaml")
+	if err := os.WriteFile(filePath, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := Parse(filePath)
+	if err == nil || !strings.Contains(err.Error(), "set spec.scm.project.defaultBranch instead") {
+		t.Fatalf("Expected spec.scm.defaultBranch to be rejected in favour of spec.scm.project.defaultBranch, got: %v", err)
+	}
+}
+
+func TestParse_WithoutSCM(t *testing.T) {
+	tmpDir := t.TempDir()
+	content := `apiVersion: v1
+kind: Blueprint
+metadata:
+  name: test
+spec:
+  cloud:
+    provider: aws
+    region: us-east-1
+  scaffold:
+    source: ./src
+    destination: ./dst
+`
+	filePath := filepath.Join(tmpDir, "blueprint.yaml")
+	if err := os.WriteFile(filePath, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	bp, err := Parse(filePath)
+	if err != nil {
+		t.Fatalf("Expected a blueprint without spec.scm to parse, got error: %v", err)
+	}
+	if bp.Spec.SCM.Configured() {
+		t.Errorf("Expected no SCM to be configured, got %+v", bp.Spec.SCM)
+	}
+}
+
+func TestParse_TfvarsFilename(t *testing.T) {
+	tests := []struct {
+		filename string
+		wantErr  bool
+	}{
+		{filename: "prod.tfvars", wantErr: false},
+		{filename: "prod.auto.tfvars.json", wantErr: false},
+		{filename: "prod.json", wantErr: true},
+		{filename: "vars/prod.tfvars", wantErr: true},
+		{filename: ".tfvars", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.filename, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			content := `apiVersion: v1
+kind: Blueprint
+metadata:
+  name: test
+spec:
+  scm:
+    provider: gitlab
+    url: https://gitlab.com
+    token: token
+    project:
+      name: test
+      namespace: test
+      visibility: private
+  cloud:
+    provider: aws
+    region: us-east-1
+  scaffold:
+    source: ./src
+    destination: ./dst
+    tfvarsFilename: ` + tt.filename + `
+`
+			filePath := filepath.Join(tmpDir, "blueprint.yaml")
+			if err := os.WriteFile(filePath, []byte(content), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			bp, err := Parse(filePath)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("Expected validation error, got nil")
+				}
+				if !strings.Contains(err.Error(), "must be a file name ending in .tfvars or .tfvars.json") {
+					t.Errorf("Expected tfvars filename error, got: %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected successful parsing, got error: %v", err)
+			}
+			if bp.Spec.Scaffold.TfvarsFile() != tt.filename {
+				t.Errorf("Expected tfvars file %s, got %s", tt.filename, bp.Spec.Scaffold.TfvarsFile())
+			}
+		})
+	}
+}
+
+func TestParse_TerraformVersion(t *testing.T) {
+	tests := []struct {
+		version string
+		wantErr bool
+	}{
+		{version: "1.8.0", wantErr: false},
+		{version: "1.5.7", wantErr: false},
+		{version: "1.9.0-beta2", wantErr: false},
+		{version: "1.8", wantErr: true},
+		{version: "v1.8.0", wantErr: true},
+		{version: "latest", wantErr: true},
+		{version: "1.8.0@sha256:abc", wantErr: true},
+		{version: "1.8.0 --privileged", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			content := `apiVersion: v1
+kind: Blueprint
+metadata:
+  name: test
+spec:
+  scm:
+    provider: gitlab
+    url: https://gitlab.com
+    token: token
+    project:
+      name: test
+      namespace: test
+      visibility: private
+  cloud:
+    provider: aws
+    region: us-east-1
+  scaffold:
+    source: ./src
+    destination: ./dst
+  provision:
+    terraform:
+      version: "` + tt.version + `"
+`
+			filePath := filepath.Join(tmpDir, "blueprint.yaml")
+			if err := os.WriteFile(filePath, []byte(content), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			bp, err := Parse(filePath)
+			if tt.wantErr {
+				if err == nil || !strings.Contains(err.Error(), "which is not a Terraform version") {
+					t.Errorf("Expected terraform version error, got: %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected successful parsing, got error: %v", err)
+			}
+			if bp.Spec.Provision.Terraform.Version != tt.version {
+				t.Errorf("Expected terraform version %s, got %s", tt.version, bp.Spec.Provision.Terraform.Version)
+			}
+		})
+	}
+}
+
+func TestParse_ProvisionResources(t *testing.T) {
+	tests := []struct {
+		name      string
+		resources string
+		wantErr   string
+	}{
+		{name: "memory with unit and fractional CPUs", resources: "{memory: 2g, cpus: 1.5}"},
+		{name: "memory in bytes", resources: "{memory: \"536870912\"}"},
+		{name: "unset", resources: "{}"},
+		{name: "invalid memory", resources: "{memory: lots}", wantErr: "which is not a memory size"},
+		{name: "zero memory", resources: "{memory: 0m}", wantErr: "which is not a memory size"},
+		{name: "negative CPUs", resources: "{cpus: -1}", wantErr: "field 'CPUs' must be greater than 0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			content := `apiVersion: v1
+kind: Blueprint
+metadata:
+  name: test
+spec:
+  scm:
+    provider: gitlab
+    url: https://gitlab.com
+    token: token
+    project:
+      name: test
+      namespace: test
+      visibility: private
+  cloud:
+    provider: aws
+    region: us-east-1
+  scaffold:
+    source: ./src
+    destination: ./dst
+  provision:
+    resources: ` + tt.resources + `
+`
+			filePath := filepath.Join(tmpDir, "blueprint.yaml")
+			if err := os.WriteFile(filePath, []byte(content), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			_, err := Parse(filePath)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Expected an error containing %q, got: %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected successful parsing, got error: %v", err)
+			}
+		})
+	}
+}
+
+func TestParse_ProvisionTimeout(t *testing.T) {
+	tests := []struct {
+		name    string
+		timeout string
+		wantErr string
+	}{
+		{name: "minutes", timeout: "45m"},
+		{name: "hours and minutes", timeout: "1h30m"},
+		{name: "missing unit", timeout: "\"90\"", wantErr: "which is not a positive duration"},
+		{name: "zero", timeout: "0s", wantErr: "which is not a positive duration"},
+		{name: "negative", timeout: "-5m", wantErr: "which is not a positive duration"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bp, err := parseScaffoldBlueprint(t, "    source: ./src\n    destination: ./dst\n  provision:\n    timeout: "+tt.timeout+"\n")
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Expected an error containing %q, got: %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected successful parsing, got error: %v", err)
+			}
+			if bp.Spec.Provision.RunTimeout() <= 0 {
+				t.Errorf("Expected a positive run timeout for %q", tt.timeout)
+			}
+		})
+	}
+}
+
+func TestParse_ProvisionMatrix(t *testing.T) {
+	tests := []struct {
+		name    string
+		matrix  string
+		wantErr bool
+	}{
+		{name: "distinct entries", matrix: "      - name: dev\n        variables:\n          env: dev\n      - name: prod\n", wantErr: false},
+		{name: "duplicate entries", matrix: "      - name: dev\n      - name: dev\n", wantErr: true},
+		{name: "missing name", matrix: "      - variables:\n          env: dev\n", wantErr: true},
+		{name: "invalid workspace name", matrix: "      - name: eu/prod\n", wantErr: true},
+		{name: "workspace name with spaces", matrix: "      - name: eu prod\n", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			content := `apiVersion: v1
+kind: Blueprint
+metadata:
+  name: test
+spec:
+  scm:
+    provider: gitlab
+    url: https://gitlab.com
+    token: token
+    project:
+      name: test
+      namespace: test
+      visibility: private
+  cloud:
+    provider: aws
+    region: us-east-1
+  scaffold:
+    source: ./src
+    destination: ./dst
+  provision:
+    matrix:
+` + tt.matrix
+			filePath := filepath.Join(t.TempDir(), "blueprint.yaml")
+			if err := os.WriteFile(filePath, []byte(content), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			bp, err := Parse(filePath)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("Expected validation error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected successful parsing, got error: %v", err)
+			}
+			matrix := bp.Spec.Provision.Matrix
+			if len(matrix) != 2 || matrix[0].Name != "dev" || matrix[0].Variables["env"] != "dev" {
+				t.Errorf("Unexpected matrix: %+v", matrix)
+			}
+		})
+	}
+}
+
+func TestParse_ProjectOptions(t *testing.T) {
+	content := `apiVersion: v1
+kind: Blueprint
+metadata:
+  name: test
+spec:
+  scm:
+    provider: gitlab
+    url: https://gitlab.com
+    token: token
+    project:
+      name: test
+      namespace: test
+      visibility: private
+      defaultBranch: main
+      lfsEnabled: false
+      requestAccessEnabled: true
+  cloud:
+    provider: aws
+    region: us-east-1
+  scaffold:
+    source: ./src
+    destination: ./dst
+`
+	filePath := filepath.Join(t.TempDir(), "blueprint.yaml")
+	if err := os.WriteFile(filePath, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	bp, err := Parse(filePath)
+	if err != nil {
+		t.Fatalf("Expected successful parsing, got error: %v", err)
+	}
+	project := bp.Spec.SCM.Project
+	if project.DefaultBranch != "main" {
+		t.Errorf("Expected defaultBranch main, got %q", project.DefaultBranch)
+	}
+	if project.LFS() {
+		t.Error("Expected lfsEnabled: false to disable LFS")
+	}
+	if !project.RequestAccessEnabled {
+		t.Error("Expected requestAccessEnabled to be true")
+	}
+}
+
+func TestParse_ProjectSettings(t *testing.T) {
+	blueprintWithSettings := func(event string) string {
+		return `apiVersion: v1
+kind: Blueprint
+metadata:
+  name: test
+spec:
+  scm:
+    provider: gitlab
+    url: https://gitlab.com
+    token: token
+    project:
+      name: test
+      namespace: test
+      visibility: private
+      ciVariables:
+        - key: AWS_ROLE_ARN
+          value: arn:aws:iam::123456789012:role/ci
+        - key: DEPLOY_TOKEN
+          value: secret
+          masked: true
+          protected: true
+      webhooks:
+        - url: https://chat.example.com/hooks/abc
+          events: [` + event + `]
+  cloud:
+    provider: aws
+    region: us-east-1
+  scaffold:
+    source: ./src
+    destination: ./dst
+`
+	}
+
+	filePath := filepath.Join(t.TempDir(), "blueprint.yaml")
+	if err := os.WriteFile(filePath, []byte(blueprintWithSettings("pipeline")), 0644); err != nil {
+		t.Fatal(err)
+	}
+	bp, err := Parse(filePath)
+	if err != nil {
+		t.Fatalf("Expected successful parsing, got error: %v", err)
+	}
+	project := bp.Spec.SCM.Project
+	if len(project.CIVariables) != 2 || project.CIVariables[1].Key != "DEPLOY_TOKEN" || !project.CIVariables[1].Masked {
+		t.Errorf("Expected the CI variables to be parsed, got %+v", project.CIVariables)
+	}
+	if len(project.Webhooks) != 1 || project.Webhooks[0].Events[0] != "pipeline" {
+		t.Errorf("Expected the webhook to be parsed, got %+v", project.Webhooks)
+	}
+
+	if err := os.WriteFile(filePath, []byte(blueprintWithSettings("issues")), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Parse(filePath); err == nil || !strings.Contains(err.Error(), "must be one of") {
+		t.Errorf("Expected an unsupported webhook event to be rejected, got: %v", err)
+	}
+}
+
+func TestParse_TemplatedProjectName(t *testing.T) {
+	blueprintWithProject := func(name, namespace string) string {
+		return `apiVersion: v1
+kind: Blueprint
+metadata:
+  name: billing
+spec:
+  scm:
+    provider: gitlab
+    url: https://gitlab.com
+    token: token
+    project:
+      name: "` + name + `"
+      namespace: "` + namespace + `"
+      visibility: private
+  cloud:
+    provider: aws
+    region: us-east-1
+  scaffold:
+    source: ./src
+    destination: ./dst
+  variables:
+    team: payments
+    db_password: hunter2
+  secretVariables:
+    - db_password
+`
+	}
+
+	tests := []struct {
+		name          string
+		project       string
+		namespace     string
+		wantName      string
+		wantNamespace string
+		wantErr       string
+	}{
+		{
+			name:          "metadata and variables",
+			project:       "{{ .Metadata.Name }}-infra",
+			namespace:     "platform/{{ .Variables.team }}",
+			wantName:      "billing-infra",
+			wantNamespace: "platform/payments",
+		},
+		{
+			name:          "plain values are kept",
+			project:       "billing-infra",
+			namespace:     "platform",
+			wantName:      "billing-infra",
+			wantNamespace: "platform",
+		},
+		{
+			name:      "expansion producing an invalid path",
+			project:   "{{ .Metadata.Name }} infra",
+			namespace: "platform",
+			wantErr:   "'billing infra', which is not a valid GitLab path",
+		},
+		{
+			name:      "name with spaces",
+			project:   "Payments Infra",
+			namespace: "platform",
+			wantErr:   "not a valid GitLab path (lowercase letters, digits, '_', '-' and '.', not starting or ending with a special character, without consecutive special characters and not ending in .git or .atom); use 'payments-infra' instead",
+		},
+		{
+			name:      "uppercase name",
+			project:   "BILLING",
+			namespace: "platform",
+			wantErr:   "use 'billing' instead",
+		},
+		{
+			name:      "consecutive special characters",
+			project:   "billing--infra",
+			namespace: "platform",
+			wantErr:   "use 'billing-infra' instead",
+		},
+		{
+			name:      "name ending in a special character",
+			project:   "billing_",
+			namespace: "platform",
+			wantErr:   "use 'billing' instead",
+		},
+		{
+			name:          "name with dots and underscores",
+			project:       "billing_infra.v2",
+			namespace:     "Platform/Payments",
+			wantName:      "billing_infra.v2",
+			wantNamespace: "Platform/Payments",
+		},
+		{
+			name:      "invalid namespace segment",
+			project:   "billing",
+			namespace: "platform/{{ .Variables.team }}.git",
+			wantErr:   "not a valid GitLab namespace",
+		},
+		{
+			name:      "missing variable",
+			project:   "{{ .Variables.service }}-infra",
+			namespace: "platform",
+			wantErr:   "failed to expand spec.scm.project.name",
+		},
+		{
+			name:      "secret variables are not available",
+			project:   "{{ .Variables.db_password }}",
+			namespace: "platform",
+			wantErr:   "failed to expand spec.scm.project.name",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filePath := filepath.Join(t.TempDir(), "blueprint.yaml")
+			if err := os.WriteFile(filePath, []byte(blueprintWithProject(tt.project, tt.namespace)), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			bp, err := Parse(filePath)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Expected an error containing %q, got: %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected successful parsing, got error: %v", err)
+			}
+			project := bp.Spec.SCM.Project
+			if project.Name != tt.wantName || project.Namespace != tt.wantNamespace {
+				t.Errorf("Expected %s/%s, got %s/%s", tt.wantNamespace, tt.wantName, project.Namespace, project.Name)
+			}
+		})
+	}
+}
+
+func TestParse_GitHubProject(t *testing.T) {
+	blueprintWithProject := func(name, namespace, extra string) string {
+		return `apiVersion: v1
+kind: Blueprint
+metadata:
+  name: billing
+spec:
+  scm:
+    provider: github
+    url: https://api.github.com
+    token: token
+    project:
+      name: "` + name + `"
+      namespace: "` + namespace + `"
+      visibility: private
+` + extra + `  cloud:
+    provider: aws
+    region: us-east-1
+  scaffold:
+    source: ./src
+    destination: ./dst
+`
+	}
+
+	tests := []struct {
+		name      string
+		project   string
+		namespace string
+		extra     string
+		wantErr   string
+	}{
+		// Valid GitHub names that break the GitLab project path rules
+		{name: "uppercase repository", project: "Billing-Infra", namespace: "Payments-Team"},
+		{name: "consecutive and trailing special characters", project: "billing--infra_", namespace: "payments"},
+		{name: "leading dot", project: ".github", namespace: "payments"},
+		{
+			name:      "repository with spaces",
+			project:   "billing infra",
+			namespace: "payments",
+			wantErr:   "'billing infra', which is not a valid GitHub repository name",
+		},
+		{
+			name:      "repository ending in .git",
+			project:   "billing.git",
+			namespace: "payments",
+			wantErr:   "not a valid GitHub repository name",
+		},
+		{
+			name:      "nested owner",
+			project:   "billing",
+			namespace: "payments/platform",
+			wantErr:   "'payments/platform', which is not a valid GitHub owner",
+		},
+		{
+			name:      "owner ending in a hyphen",
+			project:   "billing",
+			namespace: "payments-",
+			wantErr:   "not a valid GitHub owner",
+		},
+		{
+			name:      "exported outputs",
+			project:   "billing",
+			namespace: "payments",
+			extra:     "      exportOutputs:\n        - output: vpc_id\n",
+			wantErr:   "field 'ExportOutputs' is only supported with the gitlab provider",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filePath := filepath.Join(t.TempDir(), "blueprint.yaml")
+			if err := os.WriteFile(filePath, []byte(blueprintWithProject(tt.project, tt.namespace, tt.extra)), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			_, err := Parse(filePath)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Expected successful parsing, got error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected an error containing %q, got: %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestSuggestGitLabPath(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{name: "Payments Infra", want: "payments-infra"},
+		{name: "  billing  ", want: "billing"},
+		{name: "team/service", want: "team-service"},
+		{name: "infra.git", want: "infra"},
+		{name: "-._billing_.-", want: "billing"},
+		{name: "Café Backend!", want: "caf-backend"},
+		{name: "!!!", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := suggestGitLabPath(tt.name); got != tt.want {
+				t.Errorf("suggestGitLabPath(%q) = %q, want %q", tt.name, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,155 @@
+package parser
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+	"text/template"
+
+	validator "github.com/go-playground/validator/v10"
+
+	"klonekit/pkg/blueprint"
+)
+
+var (
+	// gitlabPathPattern matches a single GitLab project or group path.
+	gitlabPathPattern = regexp.MustCompile(`^[a-zA-Z0-9_][a-zA-Z0-9_.-]*$`)
+	// specialRunPattern matches consecutive special characters, which project paths can't contain.
+	specialRunPattern = regexp.MustCompile(`[_.-]{2,}`)
+	// invalidPathCharsPattern matches runs of characters a project path can't contain.
+	invalidPathCharsPattern = regexp.MustCompile(`[^a-z0-9_.-]+`)
+	// githubRepoPattern matches a GitHub repository name.
+	githubRepoPattern = regexp.MustCompile(`^[a-zA-Z0-9_.-]{1,100}$`)
+	// githubOwnerPattern matches a GitHub user or organization name.
+	githubOwnerPattern = regexp.MustCompile(`^[a-zA-Z0-9]([a-zA-Z0-9-]{0,37}[a-zA-Z0-9])?$`)
+)
+
+// isGitLabPath reports whether path follows the GitLab path rules.
+func isGitLabPath(path string) bool {
+	return gitlabPathPattern.MatchString(path) && !strings.HasSuffix(path, ".git") && !strings.HasSuffix(path, ".atom")
+}
+
+// isGitLabProjectPath reports whether path is a valid path for a new project. Project paths
+// are lowercase, and neither start nor end with a special character nor contain consecutive
+// special characters.
+func isGitLabProjectPath(path string) bool {
+	return isGitLabPath(path) &&
+		path == strings.ToLower(path) &&
+		!strings.ContainsAny(path[:1]+path[len(path)-1:], "_.-") &&
+		!specialRunPattern.MatchString(path)
+}
+
+// validateSCMProject checks the project name and namespace against the path rules of the SCM
+// provider, and rejects project settings the provider doesn't support. Missing values are
+// left to the required rule.
+func validateSCMProject(sl validator.StructLevel) {
+	scm := sl.Current().Interface().(blueprint.SCMProvider)
+	project := scm.Project
+
+	if scm.Provider == "github" {
+		if project.Name != "" && !isGitHubRepoName(project.Name) {
+			sl.ReportError(project.Name, "Name", "Name", "githubrepo", "")
+		}
+		if project.Namespace != "" && !githubOwnerPattern.MatchString(project.Namespace) {
+			sl.ReportError(project.Namespace, "Namespace", "Namespace", "githubowner", "")
+		}
+		// Outputs are exported as GitLab CI/CD variables, which would fail after the apply
+		if len(project.ExportOutputs) > 0 {
+			sl.ReportError(project.ExportOutputs, "ExportOutputs", "ExportOutputs", "gitlabonly", "")
+		}
+		return
+	}
+
+	// The project name is also used as its path when the project is created
+	if project.Name != "" && !isGitLabProjectPath(project.Name) {
+		sl.ReportError(project.Name, "Name", "Name", "gitlabpath", "")
+	}
+	if project.Namespace != "" && !isGitLabNamespace(project.Namespace) {
+		sl.ReportError(project.Namespace, "Namespace", "Namespace", "gitlabnamespace", "")
+	}
+}
+
+// suggestGitLabPath turns an invalid project name into a valid path, e.g. "Payments Infra"
+// into "payments-infra", or returns "" when nothing usable is left.
+func suggestGitLabPath(name string) string {
+	path := strings.ToLower(name)
+	path = strings.TrimSuffix(strings.TrimSuffix(path, ".git"), ".atom")
+	path = invalidPathCharsPattern.ReplaceAllString(path, "-")
+	path = specialRunPattern.ReplaceAllStringFunc(path, func(run string) string { return run[:1] })
+	path = strings.Trim(path, "_.-")
+	if !isGitLabProjectPath(path) {
+		return ""
+	}
+	return path
+}
+
+// isGitLabNamespace reports whether namespace is a group or user path, with subgroups
+// separated by "/".
+func isGitLabNamespace(namespace string) bool {
+	for _, segment := range strings.Split(namespace, "/") {
+		if !isGitLabPath(segment) {
+			return false
+		}
+	}
+	return true
+}
+
+// isGitHubRepoName reports whether name is a valid GitHub repository name.
+func isGitHubRepoName(name string) bool {
+	return githubRepoPattern.MatchString(name) && name != "." && name != ".." && !strings.HasSuffix(name, ".git")
+}
+
+// projectTemplateData is the data available to templated project names and namespaces.
+type projectTemplateData struct {
+	Metadata  blueprint.Metadata
+	Variables map[string]interface{}
+}
+
+// expandProjectTemplates renders spec.scm.project.name and namespace when they contain
+// template actions, so one blueprint can derive its repository from its metadata or
+// variables. Secret variables are left out, as project paths are visible in GitLab.
+func expandProjectTemplates(bp *blueprint.Blueprint) error {
+	project := &bp.Spec.SCM.Project
+	if !strings.Contains(project.Name, "{{") && !strings.Contains(project.Namespace, "{{") {
+		return nil
+	}
+
+	secret := make(map[string]bool, len(bp.Spec.SecretVariables))
+	for _, key := range bp.Spec.SecretVariables {
+		secret[key] = true
+	}
+	data := projectTemplateData{Metadata: bp.Metadata, Variables: map[string]interface{}{}}
+	for key, value := range bp.Spec.Variables {
+		if !secret[key] {
+			data.Variables[key] = value
+		}
+	}
+
+	for field, value := range map[string]*string{"name": &project.Name, "namespace": &project.Namespace} {
+		expanded, err := expandTemplate(*value, data)
+		if err != nil {
+			return fmt.Errorf("failed to expand spec.scm.project.%s: %w", field, err)
+		}
+		*value = expanded
+	}
+	return nil
+}
+
+// expandTemplate renders a template string. Referencing a missing key fails rather than
+// rendering "<no value>".
+func expandTemplate(text string, data projectTemplateData) (string, error) {
+	if !strings.Contains(text, "{{") {
+		return text, nil
+	}
+
+	tmpl, err := template.New("project").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
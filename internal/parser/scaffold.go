@@ -0,0 +1,145 @@
+package parser
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// normalizeScaffoldList rewrites a spec.scaffold list of modules, each with a source and a
+// destination, into the object form: the modules' common parent directory becomes
+// scaffold.destination and each module a target scaffolded into its subdirectory, named by
+// that subdirectory unless it has a name. A module whose destination is the parent itself
+// becomes scaffold.source. Documents whose spec.scaffold is an object are left as they are.
+func normalizeScaffoldList(document []byte) ([]byte, error) {
+	var node yaml.Node
+	if err := yaml.Unmarshal(document, &node); err != nil {
+		return nil, fmt.Errorf("failed to read blueprint file: %w", err)
+	}
+	if len(node.Content) == 0 {
+		return document, nil
+	}
+
+	spec := mappingValue(node.Content[0], "spec")
+	if spec == nil {
+		return document, nil
+	}
+	scaffold := mappingValue(spec, "scaffold")
+	if scaffold == nil || scaffold.Kind != yaml.SequenceNode {
+		return document, nil
+	}
+
+	normalized, err := scaffoldFromList(scaffold)
+	if err != nil {
+		return nil, err
+	}
+	*scaffold = *normalized
+	return yaml.Marshal(&node)
+}
+
+// scaffoldFromList returns the spec.scaffold mapping equivalent to the list of modules.
+func scaffoldFromList(list *yaml.Node) (*yaml.Node, error) {
+	if len(list.Content) == 0 {
+		return nil, fmt.Errorf("spec.scaffold must list at least one module")
+	}
+
+	destinations := make([]string, len(list.Content))
+	for i, module := range list.Content {
+		if module.Kind != yaml.MappingNode {
+			return nil, fmt.Errorf("spec.scaffold[%d] must be a module with a source and destination", i)
+		}
+		destination := mappingValue(module, "destination")
+		if destination == nil || destination.Kind != yaml.ScalarNode || destination.Value == "" {
+			return nil, fmt.Errorf("spec.scaffold[%d]: destination is required", i)
+		}
+		destinations[i] = filepath.Clean(filepath.FromSlash(destination.Value))
+	}
+
+	root := commonParent(destinations)
+	if root == "" || root == "." || root == string(filepath.Separator) {
+		return nil, fmt.Errorf("spec.scaffold module destinations must share a parent directory, which becomes the repository (e.g. infra/network and infra/compute)")
+	}
+
+	scaffold := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	targets := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+	for i, module := range list.Content {
+		if destinations[i] == root {
+			if mappingValue(scaffold, "source") != nil {
+				return nil, fmt.Errorf("spec.scaffold[%d]: only one module can be scaffolded into %s", i, root)
+			}
+			if mappingValue(module, "variables") != nil {
+				return nil, fmt.Errorf("spec.scaffold[%d]: variables are only supported for modules in subdirectories; set spec.variables instead", i)
+			}
+			for j := 0; j+1 < len(module.Content); j += 2 {
+				if !strings.EqualFold(module.Content[j].Value, "destination") {
+					scaffold.Content = append(scaffold.Content, module.Content[j], module.Content[j+1])
+				}
+			}
+			continue
+		}
+
+		relative, err := filepath.Rel(root, destinations[i])
+		if err != nil {
+			return nil, fmt.Errorf("spec.scaffold[%d]: %w", i, err)
+		}
+		relative = filepath.ToSlash(relative)
+
+		target := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		if mappingValue(module, "name") == nil {
+			target.Content = append(target.Content, scalarNode("name"), scalarNode(relative))
+		}
+		for j := 0; j+1 < len(module.Content); j += 2 {
+			value := module.Content[j+1]
+			if strings.EqualFold(module.Content[j].Value, "destination") {
+				value = scalarNode(relative)
+			}
+			target.Content = append(target.Content, module.Content[j], value)
+		}
+		targets.Content = append(targets.Content, target)
+	}
+
+	scaffold.Content = append(scaffold.Content, scalarNode("destination"), scalarNode(filepath.ToSlash(root)))
+	if len(targets.Content) > 0 {
+		scaffold.Content = append(scaffold.Content, scalarNode("targets"), targets)
+	}
+	return scaffold, nil
+}
+
+// commonParent returns the deepest directory containing all of the cleaned paths: the path
+// itself for a single one, and "" when an absolute path is mixed with relative ones.
+func commonParent(paths []string) string {
+	parts := strings.Split(paths[0], string(filepath.Separator))
+	for _, path := range paths[1:] {
+		other := strings.Split(path, string(filepath.Separator))
+		n := 0
+		for n < len(parts) && n < len(other) && parts[n] == other[n] {
+			n++
+		}
+		parts = parts[:n]
+	}
+	if len(parts) == 1 && parts[0] == "" {
+		return string(filepath.Separator)
+	}
+	return strings.Join(parts, string(filepath.Separator))
+}
+
+// mappingValue returns the value of key in a mapping node, matching the key case-insensitively
+// as viper does, or nil if the node isn't a mapping or lacks the key.
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if strings.EqualFold(node.Content[i].Value, key) {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// scalarNode returns a string scalar node holding value.
+func scalarNode(value string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}
+}
@@ -0,0 +1,145 @@
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"klonekit/pkg/blueprint"
+)
+
+// scaffoldBlueprintYaml is a valid blueprint whose spec.scaffold is given by the test
+const scaffoldBlueprintYaml = `apiVersion: v1
+kind: Blueprint
+metadata:
+  name: test
+spec:
+  scm:
+    provider: gitlab
+    url: https://gitlab.com
+    token: token
+    project:
+      name: test
+      namespace: test
+      visibility: private
+  cloud:
+    provider: aws
+    region: us-east-1
+  variables:
+    environment: staging
+  scaffold:
+`
+
+func parseScaffoldBlueprint(t *testing.T, scaffold string) (*blueprint.Blueprint, error) {
+	t.Helper()
+	filePath := filepath.Join(t.TempDir(), "blueprint.yaml")
+	if err := os.WriteFile(filePath, []byte(scaffoldBlueprintYaml+scaffold), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return Parse(filePath)
+}
+
+func TestParse_ScaffoldObject(t *testing.T) {
+	bp, err := parseScaffoldBlueprint(t, `    source: ./modules/app
+    destination: ./out
+    tfvarsFilename: app.tfvars
+`)
+	if err != nil {
+		t.Fatalf("Expected successful parsing, got error: %v", err)
+	}
+
+	want := blueprint.Scaffold{Source: "./modules/app", Destination: "./out", TfvarsFilename: "app.tfvars"}
+	if !reflect.DeepEqual(bp.Spec.Scaffold, want) {
+		t.Errorf("Expected scaffold %+v, got %+v", want, bp.Spec.Scaffold)
+	}
+}
+
+func TestParse_ScaffoldList(t *testing.T) {
+	bp, err := parseScaffoldBlueprint(t, `    - source: ./modules/network
+      destination: ./out/infra/network
+      variables:
+        cidr: 10.0.0.0/16
+    - name: compute
+      source: ./modules/compute
+      destination: ./out/infra/compute
+`)
+	if err != nil {
+		t.Fatalf("Expected successful parsing, got error: %v", err)
+	}
+
+	want := blueprint.Scaffold{
+		Destination: "out/infra",
+		Targets: []blueprint.ScaffoldTarget{
+			{Name: "network", Source: "./modules/network", Destination: "network", Variables: map[string]interface{}{"cidr": "10.0.0.0/16"}},
+			{Name: "compute", Source: "./modules/compute", Destination: "compute"},
+		},
+	}
+	if !reflect.DeepEqual(bp.Spec.Scaffold, want) {
+		t.Errorf("Expected scaffold %+v, got %+v", want, bp.Spec.Scaffold)
+	}
+}
+
+func TestParse_ScaffoldListWithRootModule(t *testing.T) {
+	bp, err := parseScaffoldBlueprint(t, `    - source: ./modules/root
+      destination: ./out
+    - source: ./modules/network
+      destination: ./out/infra/network
+`)
+	if err != nil {
+		t.Fatalf("Expected successful parsing, got error: %v", err)
+	}
+
+	want := blueprint.Scaffold{
+		Source:      "./modules/root",
+		Destination: "out",
+		Targets:     []blueprint.ScaffoldTarget{{Name: "infra/network", Source: "./modules/network", Destination: "infra/network"}},
+	}
+	if !reflect.DeepEqual(bp.Spec.Scaffold, want) {
+		t.Errorf("Expected scaffold %+v, got %+v", want, bp.Spec.Scaffold)
+	}
+}
+
+func TestParse_ScaffoldListErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		scaffold string
+		wantErr  string
+	}{
+		{
+			name:     "empty list",
+			scaffold: "    []\n",
+			wantErr:  "spec.scaffold must list at least one module",
+		},
+		{
+			name:     "missing destination",
+			scaffold: "    - source: ./modules/network\n",
+			wantErr:  "spec.scaffold[0]: destination is required",
+		},
+		{
+			name:     "no common parent",
+			scaffold: "    - {source: ./a, destination: ./network}\n    - {source: ./b, destination: ./compute}\n",
+			wantErr:  "must share a parent directory",
+		},
+		{
+			name:     "variables of the root module",
+			scaffold: "    - {source: ./a, destination: ./out, variables: {cidr: 10.0.0.0/16}}\n    - {source: ./b, destination: ./out/compute}\n",
+			wantErr:  "spec.scaffold[0]: variables are only supported for modules in subdirectories",
+		},
+		{
+			name:     "missing source",
+			scaffold: "    - {destination: ./out/network}\n    - {source: ./b, destination: ./out/compute}\n",
+			wantErr:  "'Source' is required",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseScaffoldBlueprint(t, tt.scaffold)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected an error containing %q, got: %v", tt.wantErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,303 @@
+package parser
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"klonekit/pkg/blueprint"
+)
+
+// jsonSchemaDialect is the JSON Schema draft the blueprint schema is written in.
+const jsonSchemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+// jsonSchema is the subset of JSON Schema the blueprint schema uses.
+type jsonSchema struct {
+	Schema               string                 `json:"$schema,omitempty"`
+	Title                string                 `json:"title,omitempty"`
+	Type                 string                 `json:"type,omitempty"`
+	Properties           map[string]*jsonSchema `json:"properties,omitempty"`
+	Required             []string               `json:"required,omitempty"`
+	AdditionalProperties *jsonSchema            `json:"additionalProperties,omitempty"`
+	PropertyNames        *jsonSchema            `json:"propertyNames,omitempty"`
+	Items                *jsonSchema            `json:"items,omitempty"`
+	OneOf                []*jsonSchema          `json:"oneOf,omitempty"`
+	Const                string                 `json:"const,omitempty"`
+	Enum                 []string               `json:"enum,omitempty"`
+	Pattern              string                 `json:"pattern,omitempty"`
+	Format               string                 `json:"format,omitempty"`
+	MinLength            *int                   `json:"minLength,omitempty"`
+	MinItems             *int                   `json:"minItems,omitempty"`
+	MinProperties        *int                   `json:"minProperties,omitempty"`
+	Minimum              *float64               `json:"minimum,omitempty"`
+	ExclusiveMinimum     *float64               `json:"exclusiveMinimum,omitempty"`
+}
+
+// schemaRule adds the JSON Schema counterpart of a validate tag to the schema of a value of
+// type t. param is the tag's parameter, e.g. "1" for min=1.
+type schemaRule func(schema *jsonSchema, t reflect.Type, param string) error
+
+// schemaRules maps the validate tags of the blueprint types to schema keywords. Tags mapped to
+// nil have no per-value counterpart: cross-field rules and checks too involved for a
+// pattern. Tags missing from the map fail schema generation, so each new validation has to
+// be considered here. Project names and namespaces are checked per SCM provider, by a
+// struct-level rule the schema leaves out.
+var schemaRules = map[string]schemaRule{
+	"eq": func(schema *jsonSchema, _ reflect.Type, param string) error {
+		schema.Const = param
+		return nil
+	},
+	"oneof": func(schema *jsonSchema, _ reflect.Type, param string) error {
+		schema.Enum = strings.Fields(param)
+		return nil
+	},
+	"min": minimumRule,
+	"gte": minimumRule,
+	"gt": func(schema *jsonSchema, t reflect.Type, param string) error {
+		bound, err := strconv.ParseFloat(param, 64)
+		if err != nil || schema.Type != "integer" && schema.Type != "number" {
+			return fmt.Errorf("unsupported gt=%s on %s", param, t)
+		}
+		schema.ExclusiveMinimum = &bound
+		return nil
+	},
+	"url":   formatRule("uri"),
+	"email": formatRule("email"),
+
+	"terraformversion": patternRule(blueprint.TerraformVersionPattern),
+	"tfvarsfilename":   patternRule(`^[^/\\]+\.tfvars(\.json)?$`),
+	"statepushurl":     patternRule(`^(s3|https?)://[^/?#]+`),
+	"memorysize":       patternRule(`^[0-9]+(\.[0-9]+)* ?[kKmMgGtTpP]?[iI]?[bB]?$`),
+	"workspacename":    patternRule(`^[A-Za-z0-9_.~$&+:=@-]*$`),
+	"duration":         patternRule(`^([0-9]+(\.[0-9]+)?(ns|us|µs|ms|s|m|h))+$`),
+
+	// Named networks make any name a valid network mode
+	"networkmode": nil,
+
+	"unique":           nil,
+	"excluded_with":    nil,
+	"required_without": nil,
+}
+
+// minimumRule maps min and gte to the lower bound of a number, or the minimum length of a
+// string, list or map.
+func minimumRule(schema *jsonSchema, t reflect.Type, param string) error {
+	bound, err := strconv.Atoi(param)
+	if err != nil {
+		return fmt.Errorf("unsupported minimum %q on %s", param, t)
+	}
+	switch schema.Type {
+	case "integer", "number":
+		minimum := float64(bound)
+		schema.Minimum = &minimum
+	case "string":
+		schema.MinLength = &bound
+	case "array":
+		schema.MinItems = &bound
+	case "object":
+		schema.MinProperties = &bound
+	default:
+		return fmt.Errorf("unsupported minimum on %s", t)
+	}
+	return nil
+}
+
+// formatRule returns a rule setting the format of a string.
+func formatRule(format string) schemaRule {
+	return func(schema *jsonSchema, _ reflect.Type, _ string) error {
+		schema.Format = format
+		return nil
+	}
+}
+
+// patternRule returns a rule matching a string against pattern.
+func patternRule(pattern string) schemaRule {
+	return func(schema *jsonSchema, _ reflect.Type, _ string) error {
+		schema.Pattern = pattern
+		return nil
+	}
+}
+
+// WriteSchema writes the JSON Schema of a blueprint, derived from the blueprint types and
+// their validate tags, for editors and CI to check blueprints against. Keys are matched
+// case-sensitively and unknown keys are allowed. Values are described after environment
+// references are expanded, and empty optional values, which KloneKit treats as unset, are
+// held to the field's rules. Rules spanning several fields are only checked by KloneKit.
+func WriteSchema(w io.Writer) error {
+	schema, err := blueprintSchema()
+	if err != nil {
+		return fmt.Errorf("failed to generate the blueprint schema: %w", err)
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(schema)
+}
+
+// blueprintSchema returns the JSON Schema of a blueprint document.
+func blueprintSchema() (*jsonSchema, error) {
+	schema, err := typeSchema(reflect.TypeOf(blueprint.Blueprint{}))
+	if err != nil {
+		return nil, err
+	}
+	schema.Schema = jsonSchemaDialect
+	schema.Title = "KloneKit blueprint"
+
+	// spec.scaffold may also be a list of modules, see normalizeScaffoldList
+	module, err := typeSchema(reflect.TypeOf(blueprint.ScaffoldTarget{}))
+	if err != nil {
+		return nil, err
+	}
+	module.Required = []string{"source", "destination"}
+	minItems := 1
+	spec := schema.Properties["spec"]
+	spec.Properties["scaffold"] = &jsonSchema{OneOf: []*jsonSchema{
+		spec.Properties["scaffold"],
+		{Type: "array", Items: module, MinItems: &minItems},
+	}}
+
+	return schema, nil
+}
+
+// typeSchema returns the schema of values of type t. interface{} values may be anything.
+func typeSchema(t reflect.Type) (*jsonSchema, error) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return &jsonSchema{Type: "string"}, nil
+	case reflect.Bool:
+		return &jsonSchema{Type: "boolean"}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &jsonSchema{Type: "integer"}, nil
+	case reflect.Float32, reflect.Float64:
+		return &jsonSchema{Type: "number"}, nil
+	case reflect.Interface:
+		return &jsonSchema{}, nil
+	case reflect.Slice:
+		items, err := typeSchema(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return &jsonSchema{Type: "array", Items: items}, nil
+	case reflect.Map:
+		schema := &jsonSchema{Type: "object"}
+		if t.Elem().Kind() != reflect.Interface {
+			values, err := typeSchema(t.Elem())
+			if err != nil {
+				return nil, err
+			}
+			schema.AdditionalProperties = values
+		}
+		return schema, nil
+	case reflect.Struct:
+		return structSchema(t)
+	default:
+		return nil, fmt.Errorf("unsupported type %s", t)
+	}
+}
+
+// structSchema returns the schema of a blueprint struct, with its fields named by their yaml
+// tags and constrained by their validate tags.
+func structSchema(t reflect.Type) (*jsonSchema, error) {
+	schema := &jsonSchema{Type: "object", Properties: map[string]*jsonSchema{}}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		if !field.IsExported() || name == "-" {
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+
+		fieldSchema, err := typeSchema(field.Type)
+		if err != nil {
+			return nil, fmt.Errorf("%s.%s: %w", t.Name(), field.Name, err)
+		}
+		required, err := applyValidateTag(fieldSchema, field.Type, field.Tag.Get("validate"))
+		if err != nil {
+			return nil, fmt.Errorf("%s.%s: %w", t.Name(), field.Name, err)
+		}
+
+		schema.Properties[name] = fieldSchema
+		if required {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+	return schema, nil
+}
+
+// applyValidateTag adds the rules of a validate tag to the schema of a field of type t,
+// following dive into list items and map values and keys into map keys. It reports whether
+// the field is required: tagged required, or restricted by oneof to values excluding the
+// empty one.
+func applyValidateTag(schema *jsonSchema, t reflect.Type, tag string) (bool, error) {
+	if tag == "" {
+		return false, nil
+	}
+
+	target, targetType := schema, t
+	var mapSchema *jsonSchema
+	var mapType reflect.Type
+	omitEmpty, required, dived := false, false, false
+
+	for _, rule := range strings.Split(tag, ",") {
+		name, param, _ := strings.Cut(rule, "=")
+		for targetType.Kind() == reflect.Pointer {
+			targetType = targetType.Elem()
+		}
+
+		switch name {
+		case "omitempty":
+			omitEmpty = omitEmpty || !dived
+		case "required":
+			required = required || !dived
+		case "dive":
+			dived = true
+			switch targetType.Kind() {
+			case reflect.Slice:
+				target, targetType = target.Items, targetType.Elem()
+			case reflect.Map:
+				if target.AdditionalProperties == nil {
+					target.AdditionalProperties = &jsonSchema{}
+				}
+				mapSchema, mapType = target, targetType
+				target, targetType = target.AdditionalProperties, targetType.Elem()
+			default:
+				return false, fmt.Errorf("dive on %s", targetType)
+			}
+		case "keys":
+			if mapSchema == nil {
+				return false, fmt.Errorf("keys outside a map")
+			}
+			mapSchema.PropertyNames = &jsonSchema{Type: "string"}
+			target, targetType = mapSchema.PropertyNames, mapType.Key()
+		case "endkeys":
+			if mapSchema == nil {
+				return false, fmt.Errorf("endkeys outside a map")
+			}
+			target, targetType = mapSchema.AdditionalProperties, mapType.Elem()
+		default:
+			apply, ok := schemaRules[name]
+			if !ok {
+				return false, fmt.Errorf("validate tag %q has no schema rule", name)
+			}
+			if apply != nil {
+				if err := apply(target, targetType, param); err != nil {
+					return false, err
+				}
+			}
+			if name == "oneof" && !dived && !omitEmpty {
+				required = true
+			}
+		}
+	}
+	return required, nil
+}
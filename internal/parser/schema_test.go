@@ -0,0 +1,299 @@
+package parser
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+// schemaBlueprintYaml is a valid blueprint using most sections of the schema
+const schemaBlueprintYaml = `apiVersion: v1
+kind: Blueprint
+metadata:
+  name: payments
+  labels:
+    team: platform
+spec:
+  scm:
+    provider: gitlab
+    url: https://gitlab.com
+    token: token
+    project:
+      name: payments-infra
+      namespace: platform/infra
+      visibility: private
+      branchProtection:
+        branches: [main]
+        waitSeconds: 10
+      webhooks:
+        - url: https://hooks.example.com
+          events: [push, pipeline]
+  cloud:
+    provider: aws
+    region: us-east-1
+  scaffold:
+    source: ./modules/app
+    destination: ./out
+    tfvarsFilename: app.tfvars
+    requiredProviders:
+      aws:
+        source: hashicorp/aws
+        version: "~> 5.0"
+  provision:
+    terraform:
+      version: 1.8.0
+    resources:
+      memory: 2g
+      cpus: 1.5
+    maxOutputBytes: 1048576
+    env:
+      - name: TF_LOG
+        value: INFO
+  variables:
+    environment: staging
+    replicas: 3
+  stageEnv:
+    scm:
+      - name: GITLAB_PRIVATE_TOKEN
+        fromEnv: CI_JOB_TOKEN
+`
+
+// loadSchema returns the emitted schema decoded from JSON.
+func loadSchema(t *testing.T) map[string]interface{} {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := WriteSchema(&buf); err != nil {
+		t.Fatalf("Failed to write the schema: %s", err)
+	}
+	var schema map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &schema); err != nil {
+		t.Fatalf("Schema is not valid JSON: %s", err)
+	}
+	return schema
+}
+
+// yamlDocument decodes a YAML document into the values JSON decoding produces.
+func yamlDocument(t *testing.T, document string) interface{} {
+	t.Helper()
+	var value interface{}
+	if err := yaml.Unmarshal([]byte(document), &value); err != nil {
+		t.Fatal(err)
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded interface{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	return decoded
+}
+
+// checkSchema validates value against the subset of JSON Schema the blueprint schema uses,
+// returning the first violation found.
+func checkSchema(schema map[string]interface{}, value interface{}, path string) error {
+	if kind, ok := schema["type"].(string); ok && !hasJSONType(value, kind) {
+		return fmt.Errorf("%s: expected %s, got %T", path, kind, value)
+	}
+	if constant, ok := schema["const"]; ok && value != constant {
+		return fmt.Errorf("%s: expected %v", path, constant)
+	}
+	if enum, ok := schema["enum"].([]interface{}); ok {
+		found := false
+		for _, allowed := range enum {
+			found = found || value == allowed
+		}
+		if !found {
+			return fmt.Errorf("%s: %v is not one of %v", path, value, enum)
+		}
+	}
+	if pattern, ok := schema["pattern"].(string); ok {
+		if s, isString := value.(string); isString && !regexp.MustCompile(pattern).MatchString(s) {
+			return fmt.Errorf("%s: %q does not match %s", path, s, pattern)
+		}
+	}
+	if number, isNumber := value.(float64); isNumber {
+		if minimum, ok := schema["minimum"].(float64); ok && number < minimum {
+			return fmt.Errorf("%s: %v is below %v", path, number, minimum)
+		}
+		if minimum, ok := schema["exclusiveMinimum"].(float64); ok && number <= minimum {
+			return fmt.Errorf("%s: %v is not above %v", path, number, minimum)
+		}
+	}
+	if options, ok := schema["oneOf"].([]interface{}); ok {
+		matched := 0
+		for _, option := range options {
+			if checkSchema(option.(map[string]interface{}), value, path) == nil {
+				matched++
+			}
+		}
+		if matched != 1 {
+			return fmt.Errorf("%s: matches %d of the oneOf schemas", path, matched)
+		}
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for _, name := range asSlice(schema["required"]) {
+			if _, ok := v[name.(string)]; !ok {
+				return fmt.Errorf("%s: missing required property %s", path, name)
+			}
+		}
+		properties, _ := schema["properties"].(map[string]interface{})
+		for key, child := range v {
+			if names, ok := schema["propertyNames"].(map[string]interface{}); ok {
+				if err := checkSchema(names, key, path+"."+key); err != nil {
+					return err
+				}
+			}
+			childSchema, ok := properties[key].(map[string]interface{})
+			if !ok {
+				childSchema, ok = schema["additionalProperties"].(map[string]interface{})
+			}
+			if ok {
+				if err := checkSchema(childSchema, child, path+"."+key); err != nil {
+					return err
+				}
+			}
+		}
+	case []interface{}:
+		if minItems, ok := schema["minItems"].(float64); ok && float64(len(v)) < minItems {
+			return fmt.Errorf("%s: expected at least %v items", path, minItems)
+		}
+		if items, ok := schema["items"].(map[string]interface{}); ok {
+			for i, item := range v {
+				if err := checkSchema(items, item, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func hasJSONType(value interface{}, kind string) bool {
+	switch v := value.(type) {
+	case string:
+		return kind == "string"
+	case bool:
+		return kind == "boolean"
+	case float64:
+		return kind == "number" || kind == "integer" && v == math.Trunc(v)
+	case []interface{}:
+		return kind == "array"
+	case map[string]interface{}:
+		return kind == "object"
+	}
+	return false
+}
+
+func asSlice(value interface{}) []interface{} {
+	s, _ := value.([]interface{})
+	return s
+}
+
+func TestWriteSchema_ValidatesBlueprints(t *testing.T) {
+	schema := loadSchema(t)
+	if schema["$schema"] != jsonSchemaDialect {
+		t.Errorf("Expected the schema to declare its dialect, got %v", schema["$schema"])
+	}
+
+	scaffoldList := strings.Replace(schemaBlueprintYaml, `    source: ./modules/app
+    destination: ./out
+    tfvarsFilename: app.tfvars
+    requiredProviders:
+      aws:
+        source: hashicorp/aws
+        version: "~> 5.0"
+`, `    - source: ./modules/network
+      destination: ./out/network
+    - source: ./modules/compute
+      destination: ./out/compute
+`, 1)
+
+	tests := []struct {
+		name     string
+		document string
+		wantErr  string
+	}{
+		{name: "valid blueprint", document: schemaBlueprintYaml},
+		{name: "scaffold list", document: scaffoldList},
+		{
+			name:     "missing metadata name",
+			document: strings.Replace(schemaBlueprintYaml, "  name: payments\n", "", 1),
+			wantErr:  "$.metadata: missing required property name",
+		},
+		{
+			name:     "missing visibility",
+			document: strings.Replace(schemaBlueprintYaml, "      visibility: private\n", "", 1),
+			wantErr:  "missing required property visibility",
+		},
+		{
+			name:     "unknown cloud provider",
+			document: strings.Replace(schemaBlueprintYaml, "provider: aws", "provider: gcp", 1),
+			wantErr:  "$.spec.cloud.provider: gcp is not one of",
+		},
+		{
+			name:     "invalid terraform version",
+			document: strings.Replace(schemaBlueprintYaml, "version: 1.8.0", "version: latest", 1),
+			wantErr:  "$.spec.provision.terraform.version",
+		},
+		{
+			name:     "unknown stage",
+			document: strings.Replace(schemaBlueprintYaml, "  stageEnv:\n    scm:", "  stageEnv:\n    deploy:", 1),
+			wantErr:  "$.spec.stageEnv.deploy",
+		},
+		{
+			name:     "negative cpus",
+			document: strings.Replace(schemaBlueprintYaml, "cpus: 1.5", "cpus: -1", 1),
+			wantErr:  "$.spec.provision.resources.cpus: -1 is not above 0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schemaErr := checkSchema(schema, yamlDocument(t, tt.document), "$")
+
+			// The schema must agree with the parser's validation
+			filePath := filepath.Join(t.TempDir(), "blueprint.yaml")
+			if err := os.WriteFile(filePath, []byte(tt.document), 0644); err != nil {
+				t.Fatal(err)
+			}
+			_, parseErr := Parse(filePath)
+
+			if tt.wantErr == "" {
+				if schemaErr != nil {
+					t.Errorf("Expected the blueprint to match the schema, got: %s", schemaErr)
+				}
+				if parseErr != nil {
+					t.Errorf("Expected the blueprint to parse, got: %s", parseErr)
+				}
+				return
+			}
+			if schemaErr == nil || !strings.Contains(schemaErr.Error(), tt.wantErr) {
+				t.Errorf("Expected a schema violation containing %q, got: %v", tt.wantErr, schemaErr)
+			}
+			if parseErr == nil {
+				t.Error("Expected the parser to reject the blueprint too")
+			}
+		})
+	}
+}
+
+func TestApplyValidateTag_UnknownTag(t *testing.T) {
+	schema := &jsonSchema{Type: "string"}
+	_, err := applyValidateTag(schema, reflect.TypeOf(""), "omitempty,hostname")
+	if err == nil || !strings.Contains(err.Error(), `validate tag "hostname" has no schema rule`) {
+		t.Errorf("Expected an error for a tag without a schema rule, got: %v", err)
+	}
+}
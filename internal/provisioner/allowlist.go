@@ -0,0 +1,62 @@
+package provisioner
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"klonekit/internal/errors"
+)
+
+// ImageAllowlistEnv restricts the images KloneKit runs to a comma-separated allowlist, for
+// environments where only approved images may run. An entry allows the exact reference,
+// every tag and digest of a repository without a tag (e.g. "hashicorp/terraform"), or every
+// image under a prefix ending in "/" or "*" (e.g. "registry.example.com/approved/"). Every
+// image is allowed when it is unset.
+const ImageAllowlistEnv = "KLONEKIT_IMAGE_ALLOWLIST"
+
+// imageAllowlist returns the entries of ImageAllowlistEnv, or nil when it is unset.
+func imageAllowlist() []string {
+	var entries []string
+	for _, entry := range strings.Split(os.Getenv(ImageAllowlistEnv), ",") {
+		if entry = strings.TrimSpace(entry); entry != "" {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// checkImageAllowed returns an error unless the image matches the allowlist in
+// ImageAllowlistEnv. It is called before each image KloneKit pulls (Terraform, and the AWS
+// CLI and busybox helpers), so a disallowed image never reaches the host.
+func checkImageAllowed(image string) error {
+	allowlist := imageAllowlist()
+	if allowlist == nil {
+		return nil
+	}
+	for _, entry := range allowlist {
+		if imageMatches(image, entry) {
+			return nil
+		}
+	}
+	return errors.NewConfigError(
+		"Refusing to run a container image that isn't allowed",
+		fmt.Sprintf("Image '%s' does not match any entry of %s (%s)", image, ImageAllowlistEnv, strings.Join(allowlist, ",")),
+		fmt.Sprintf("Use an approved Terraform image with spec.provision.image, or add the image to %s", ImageAllowlistEnv),
+		fmt.Errorf("image '%s' is not in the image allowlist", image),
+	)
+}
+
+// imageMatches reports whether the image reference is allowed by an allowlist entry.
+func imageMatches(image, entry string) bool {
+	switch {
+	case image == entry:
+		return true
+	case strings.HasSuffix(entry, "*"):
+		return strings.HasPrefix(image, strings.TrimSuffix(entry, "*"))
+	case strings.HasSuffix(entry, "/"):
+		return strings.HasPrefix(image, entry)
+	default:
+		return strings.HasPrefix(image, entry+":") || strings.HasPrefix(image, entry+"@")
+	}
+}
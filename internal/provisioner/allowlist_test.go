@@ -0,0 +1,112 @@
+package provisioner
+
+import (
+	stderrors "errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+
+	"klonekit/internal/errors"
+	"klonekit/pkg/blueprint"
+)
+
+func TestCheckImageAllowed(t *testing.T) {
+	tests := []struct {
+		name      string
+		allowlist string
+		image     string
+		wantErr   bool
+	}{
+		{name: "no allowlist", allowlist: "", image: "example.com/anything:latest"},
+		{name: "exact reference", allowlist: "hashicorp/terraform:1.8.0", image: "hashicorp/terraform:1.8.0"},
+		{name: "other tag of an exact reference", allowlist: "hashicorp/terraform:1.8.0", image: "hashicorp/terraform:1.9.0", wantErr: true},
+		{name: "repository allows any tag", allowlist: "hashicorp/terraform", image: "hashicorp/terraform:1.9.0"},
+		{name: "repository allows a digest", allowlist: "hashicorp/terraform", image: "hashicorp/terraform@sha256:abc123"},
+		{name: "repository does not allow a longer name", allowlist: "hashicorp/terraform", image: "hashicorp/terraform-evil:1.8.0", wantErr: true},
+		{name: "registry prefix", allowlist: "registry.example.com/approved/", image: "registry.example.com/approved/terraform:1.8.0"},
+		{name: "outside the registry prefix", allowlist: "registry.example.com/approved/", image: "registry.example.com/other/terraform:1.8.0", wantErr: true},
+		{name: "wildcard prefix", allowlist: "registry.example.com/terraform-*", image: "registry.example.com/terraform-aws:1.8.0"},
+		{name: "any entry of a list", allowlist: " amazon/aws-cli , hashicorp/terraform ", image: "hashicorp/terraform:1.8.0"},
+		{name: "not in a list", allowlist: "amazon/aws-cli,busybox", image: "hashicorp/terraform:1.8.0", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(ImageAllowlistEnv, tt.allowlist)
+
+			err := checkImageAllowed(tt.image)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("checkImageAllowed(%q) with allowlist %q: error = %v, wantErr %t", tt.image, tt.allowlist, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestTerraformDockerProvisioner_AllowedImageProceeds(t *testing.T) {
+	t.Setenv(ImageAllowlistEnv, "registry.example.com/approved/")
+
+	spec := &blueprint.Spec{
+		Scaffold:  blueprint.Scaffold{Destination: t.TempDir()},
+		Cloud:     blueprint.CloudProvider{Provider: "aws", Region: "us-east-1"},
+		Provision: blueprint.Provision{Image: "registry.example.com/approved/terraform:1.8.0", SkipPermissionFix: true},
+	}
+
+	mockRuntime := new(MockContainerRuntime)
+	mockRuntime.On("PullImage", mock.Anything, "registry.example.com/approved/terraform:1.8.0").Return(nil)
+	mockRuntime.On("RunContainer", mock.Anything, mock.Anything).Return(&MockReadCloser{data: []byte("ok")}, nil)
+
+	provisioner := NewTerraformDockerProvisioner(mockRuntime)
+	if err := provisioner.Provision(spec, false); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	mockRuntime.AssertCalled(t, "PullImage", mock.Anything, "registry.example.com/approved/terraform:1.8.0")
+}
+
+func TestTerraformDockerProvisioner_DisallowedImageRejected(t *testing.T) {
+	t.Setenv(ImageAllowlistEnv, "registry.example.com/approved/")
+
+	spec := &blueprint.Spec{
+		Scaffold:  blueprint.Scaffold{Destination: t.TempDir()},
+		Cloud:     blueprint.CloudProvider{Provider: "aws", Region: "us-east-1"},
+		Provision: blueprint.Provision{SkipPermissionFix: true},
+	}
+
+	mockRuntime := new(MockContainerRuntime)
+	provisioner := NewTerraformDockerProvisioner(mockRuntime)
+	err := provisioner.Provision(spec, false)
+
+	var kkErr *errors.KloneKitError
+	if !stderrors.As(err, &kkErr) || kkErr.Type != errors.ErrConfigInvalid {
+		t.Fatalf("Expected a config error, got: %v", err)
+	}
+	if !strings.Contains(err.Error(), "image 'hashicorp/terraform:1.8.0' is not in the image allowlist") {
+		t.Errorf("Expected the error to name the image, got: %s", err)
+	}
+
+	// Nothing is pulled or run
+	mockRuntime.AssertNotCalled(t, "PullImage", mock.Anything, mock.Anything)
+	mockRuntime.AssertNotCalled(t, "RunContainer", mock.Anything, mock.Anything)
+}
+
+func TestTerraformDockerProvisioner_DisallowedCredentialCheckImage(t *testing.T) {
+	t.Setenv(SkipCredentialCheckEnv, "")
+	t.Setenv(ImageAllowlistEnv, "hashicorp/terraform")
+
+	spec := &blueprint.Spec{
+		Scaffold:  blueprint.Scaffold{Destination: t.TempDir()},
+		Cloud:     blueprint.CloudProvider{Provider: "aws", Region: "us-east-1"},
+		Provision: blueprint.Provision{SkipPermissionFix: true},
+	}
+
+	mockRuntime := new(MockContainerRuntime)
+	mockRuntime.On("PullImage", mock.Anything, TerraformDockerImage).Return(nil)
+
+	provisioner := NewTerraformDockerProvisioner(mockRuntime)
+	err := provisioner.Provision(spec, false)
+	if err == nil || !strings.Contains(err.Error(), "image '"+AWSCLIDockerImage+"' is not in the image allowlist") {
+		t.Fatalf("Expected the credential check image to be rejected, got: %v", err)
+	}
+	mockRuntime.AssertNotCalled(t, "PullImage", mock.Anything, AWSCLIDockerImage)
+	mockRuntime.AssertNotCalled(t, "RunContainer", mock.Anything, mock.Anything)
+}
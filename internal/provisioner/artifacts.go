@@ -0,0 +1,164 @@
+package provisioner
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"klonekit/pkg/blueprint"
+	"klonekit/pkg/runtime"
+)
+
+// DefaultArtifactsRetain is the number of runs whose plan artifacts are kept when
+// spec.provision.artifacts.retain is not set.
+const DefaultArtifactsRetain = 10
+
+// Files written to a run's artifacts directory
+const (
+	artifactPlanFile = "plan.tfplan"
+	artifactPlanText = "plan.txt"
+	artifactSummary  = "summary.json"
+)
+
+// planSummary records when a run's plan was made and whether it was applied.
+type planSummary struct {
+	RunID     string     `json:"run_id"`
+	Workspace string     `json:"workspace,omitempty"`
+	PlannedAt time.Time  `json:"planned_at"`
+	AppliedAt *time.Time `json:"applied_at,omitempty"`
+}
+
+// SetRunID sets the run the plan artifacts are kept under. Without it, a run is named
+// after the time of its first plan.
+func (p *TerraformDockerProvisioner) SetRunID(runID string) {
+	p.runID = runID
+}
+
+// savePlanArtifacts copies the saved plan and its text rendering (terraform show) to the
+// run's artifacts directory, writes its summary and prunes the runs beyond the retention
+// count. A matrix entry's artifacts are kept in a subdirectory named after its workspace.
+// It returns the directory written.
+func (p *TerraformDockerProvisioner) savePlanArtifacts(ctx context.Context, baseOpts runtime.RunOptions, spec *blueprint.Spec, absScaffoldDir, workspace string) (string, error) {
+	if p.runID == "" {
+		p.runID = time.Now().UTC().Format("20060102T150405Z")
+	}
+	root := spec.Provision.Artifacts.Dir
+	runDir := filepath.Join(root, p.runID)
+	dir := filepath.Join(runDir, workspace)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", fmt.Errorf("failed to create plan artifacts directory: %w", err)
+	}
+
+	plan, err := os.ReadFile(filepath.Join(absScaffoldDir, PlanFileName)) // #nosec G304
+	if err != nil {
+		return "", fmt.Errorf("failed to read plan file for the artifacts: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, artifactPlanFile), plan, 0600); err != nil {
+		return "", fmt.Errorf("failed to write plan artifact: %w", err)
+	}
+
+	text, err := p.captureTerraformCommand(ctx, baseOpts, "show", "-no-color", PlanFileName)
+	if err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(filepath.Join(dir, artifactPlanText), text, 0600); err != nil {
+		return "", fmt.Errorf("failed to write plan artifact: %w", err)
+	}
+
+	summary := planSummary{RunID: p.runID, Workspace: workspace, PlannedAt: time.Now().UTC()}
+	if err := writePlanSummary(dir, summary); err != nil {
+		return "", err
+	}
+	slog.Info("Plan artifacts saved", "dir", dir, "runId", p.runID)
+
+	retain := spec.Provision.Artifacts.Retain
+	if retain == 0 {
+		retain = DefaultArtifactsRetain
+	}
+	if err := pruneArtifacts(root, runDir, retain); err != nil {
+		slog.Warn("Failed to prune old plan artifacts", "dir", root, "error", err)
+	}
+	return dir, nil
+}
+
+// markPlanApplied records in the summary of an artifacts directory that its plan was applied.
+func markPlanApplied(dir string) error {
+	content, err := os.ReadFile(filepath.Join(dir, artifactSummary)) // #nosec G304
+	if err != nil {
+		return err
+	}
+	var summary planSummary
+	if err := json.Unmarshal(content, &summary); err != nil {
+		return err
+	}
+	now := time.Now().UTC()
+	summary.AppliedAt = &now
+	return writePlanSummary(dir, summary)
+}
+
+// writePlanSummary writes the summary file of an artifacts directory.
+func writePlanSummary(dir string, summary planSummary) error {
+	content, err := json.MarshalIndent(summary, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to serialize plan summary: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, artifactSummary), content, 0600); err != nil {
+		return fmt.Errorf("failed to write plan artifact: %w", err)
+	}
+	return nil
+}
+
+// pruneArtifacts removes the oldest run directories under root so that at most retain runs
+// are kept. Only directories holding plan artifacts are considered, and the current run is
+// never removed.
+func pruneArtifacts(root, current string, retain int) error {
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return err
+	}
+
+	type run struct {
+		path    string
+		modTime time.Time
+	}
+	var runs []run
+	for _, entry := range entries {
+		path := filepath.Join(root, entry.Name())
+		if !entry.IsDir() || path == current || !holdsPlanArtifacts(path) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		runs = append(runs, run{path: path, modTime: info.ModTime()})
+	}
+
+	// The current run counts towards the retention count
+	if len(runs) < retain {
+		return nil
+	}
+	sort.Slice(runs, func(i, j int) bool { return runs[i].modTime.After(runs[j].modTime) })
+	for _, old := range runs[retain-1:] {
+		if err := os.RemoveAll(old.path); err != nil {
+			return err
+		}
+		slog.Info("Pruned old plan artifacts", "dir", old.path)
+	}
+	return nil
+}
+
+// holdsPlanArtifacts reports whether a run directory has a summary, either directly or in
+// a matrix entry's subdirectory.
+func holdsPlanArtifacts(dir string) bool {
+	if _, err := os.Stat(filepath.Join(dir, artifactSummary)); err == nil {
+		return true
+	}
+	matches, _ := filepath.Glob(filepath.Join(dir, "*", artifactSummary))
+	return len(matches) > 0
+}
@@ -0,0 +1,213 @@
+package provisioner
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+
+	"klonekit/pkg/blueprint"
+	runtimePkg "klonekit/pkg/runtime"
+)
+
+// artifactsRuntime returns a mock runtime whose plan writes a plan file to scaffoldDir and
+// whose terraform show renders it
+func artifactsRuntime(scaffoldDir string) *MockContainerRuntime {
+	mockRuntime := new(MockContainerRuntime)
+	mockRuntime.On("PullImage", mock.Anything, TerraformDockerImage).Return(nil)
+	mockRuntime.On("RunContainer", mock.Anything, mock.MatchedBy(isShowCommand)).
+		Return(&MockReadCloser{data: multiplexed("Plan: 1 to add, 0 to change, 0 to destroy.\n", "")}, nil)
+	mockRuntime.On("RunContainer", mock.Anything, mock.MatchedBy(func(opts runtimePkg.RunOptions) bool {
+		if opts.Command[0] == "plan" {
+			_ = os.WriteFile(filepath.Join(scaffoldDir, PlanFileName), []byte("binary plan"), 0600)
+		}
+		return true
+	})).Return(&MockReadCloser{data: []byte("ok")}, nil)
+	return mockRuntime
+}
+
+func readPlanSummary(t *testing.T, dir string) planSummary {
+	t.Helper()
+
+	content, err := os.ReadFile(filepath.Join(dir, artifactSummary))
+	if err != nil {
+		t.Fatalf("Expected a plan summary in %s: %s", dir, err)
+	}
+	var summary planSummary
+	if err := json.Unmarshal(content, &summary); err != nil {
+		t.Fatalf("Failed to parse plan summary: %s", err)
+	}
+	return summary
+}
+
+func TestTerraformDockerProvisioner_SavesPlanArtifacts(t *testing.T) {
+	scaffoldDir := t.TempDir()
+	artifactsDir := t.TempDir()
+	spec := &blueprint.Spec{
+		Scaffold: blueprint.Scaffold{Destination: scaffoldDir},
+		Cloud:    blueprint.CloudProvider{Provider: "aws", Region: "us-east-1"},
+		Provision: blueprint.Provision{
+			SkipCredentialCheck: true,
+			Artifacts:           blueprint.Artifacts{Dir: artifactsDir},
+		},
+	}
+
+	provisioner := NewTerraformDockerProvisioner(artifactsRuntime(scaffoldDir))
+	provisioner.SetRunID("run-1")
+	if err := provisioner.Provision(spec, true); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	runDir := filepath.Join(artifactsDir, "run-1")
+	plan, err := os.ReadFile(filepath.Join(runDir, artifactPlanFile))
+	if err != nil || string(plan) != "binary plan" {
+		t.Errorf("Expected the plan file to be kept, got %q (%v)", plan, err)
+	}
+	text, err := os.ReadFile(filepath.Join(runDir, artifactPlanText))
+	if err != nil || !strings.Contains(string(text), "Plan: 1 to add") {
+		t.Errorf("Expected the rendered plan to be kept, got %q (%v)", text, err)
+	}
+
+	summary := readPlanSummary(t, runDir)
+	if summary.RunID != "run-1" || summary.PlannedAt.IsZero() {
+		t.Errorf("Expected the summary to record the run, got %+v", summary)
+	}
+	if summary.AppliedAt == nil {
+		t.Error("Expected the summary to record the apply")
+	}
+	if _, err := os.Stat(filepath.Join(scaffoldDir, PlanFileName)); !os.IsNotExist(err) {
+		t.Error("Expected the plan file to be removed from the scaffold directory")
+	}
+}
+
+func TestTerraformDockerProvisioner_AppliesAuditedPlan(t *testing.T) {
+	scaffoldDir := t.TempDir()
+	spec := &blueprint.Spec{
+		Scaffold: blueprint.Scaffold{Destination: scaffoldDir},
+		Cloud:    blueprint.CloudProvider{Provider: "aws", Region: "us-east-1"},
+		Provision: blueprint.Provision{
+			SkipCredentialCheck: true,
+			VariablesMode:       blueprint.VariablesModeFlags,
+			Artifacts:           blueprint.Artifacts{Dir: t.TempDir()},
+		},
+		Variables: map[string]interface{}{"environment": "staging"},
+	}
+
+	var commands []string
+	mockRuntime := new(MockContainerRuntime)
+	mockRuntime.On("PullImage", mock.Anything, TerraformDockerImage).Return(nil)
+	mockRuntime.On("RunContainer", mock.Anything, mock.MatchedBy(isShowCommand)).
+		Return(&MockReadCloser{data: multiplexed("Plan: 1 to add, 0 to change, 0 to destroy.\n", "")}, nil)
+	mockRuntime.On("RunContainer", mock.Anything, mock.MatchedBy(func(opts runtimePkg.RunOptions) bool {
+		commands = append(commands, strings.Join(opts.Command, " "))
+		if opts.Command[0] == "plan" {
+			_ = os.WriteFile(filepath.Join(scaffoldDir, PlanFileName), []byte("binary plan"), 0600)
+		}
+		return true
+	})).Return(&MockReadCloser{data: []byte("ok")}, nil)
+
+	if err := NewTerraformDockerProvisioner(mockRuntime).Provision(spec, true); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	// The kept plan is the one applied, without planning again
+	want := "apply -auto-approve " + PlanFileName
+	if got := commands[len(commands)-1]; got != want {
+		t.Errorf("Expected the saved plan to be applied with %q, got %q (commands: %v)", want, got, commands)
+	}
+	plans := 0
+	for _, command := range commands {
+		if strings.HasPrefix(command, "plan") {
+			plans++
+		}
+	}
+	if plans != 1 {
+		t.Errorf("Expected a single plan, got %d (commands: %v)", plans, commands)
+	}
+}
+
+func TestTerraformDockerProvisioner_PrunesPlanArtifacts(t *testing.T) {
+	scaffoldDir := t.TempDir()
+	artifactsDir := t.TempDir()
+
+	// Four earlier runs, each an hour apart, and a directory that isn't a run
+	for i, runID := range []string{"run-a", "run-b", "run-c", "run-d"} {
+		dir := filepath.Join(artifactsDir, runID)
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := writePlanSummary(dir, planSummary{RunID: runID}); err != nil {
+			t.Fatal(err)
+		}
+		modTime := time.Now().Add(time.Duration(i-5) * time.Hour)
+		if err := os.Chtimes(dir, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.MkdirAll(filepath.Join(artifactsDir, "notes"), 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	spec := &blueprint.Spec{
+		Scaffold: blueprint.Scaffold{Destination: scaffoldDir},
+		Cloud:    blueprint.CloudProvider{Provider: "aws", Region: "us-east-1"},
+		Provision: blueprint.Provision{
+			SkipCredentialCheck: true,
+			Artifacts:           blueprint.Artifacts{Dir: artifactsDir, Retain: 3},
+		},
+	}
+
+	provisioner := NewTerraformDockerProvisioner(artifactsRuntime(scaffoldDir))
+	provisioner.SetRunID("run-e")
+	if err := provisioner.Provision(spec, false); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	entries, err := os.ReadDir(artifactsDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var kept []string
+	for _, entry := range entries {
+		kept = append(kept, entry.Name())
+	}
+	sort.Strings(kept)
+	if strings.Join(kept, ",") != "notes,run-c,run-d,run-e" {
+		t.Errorf("Expected the 3 newest runs and unrelated directories to be kept, got %v", kept)
+	}
+
+	if summary := readPlanSummary(t, filepath.Join(artifactsDir, "run-e")); summary.AppliedAt != nil {
+		t.Error("Expected no apply to be recorded without auto-approve")
+	}
+}
+
+func TestTerraformDockerProvisioner_MatrixPlanArtifacts(t *testing.T) {
+	scaffoldDir := t.TempDir()
+	artifactsDir := t.TempDir()
+	spec := &blueprint.Spec{
+		Scaffold: blueprint.Scaffold{Destination: scaffoldDir},
+		Cloud:    blueprint.CloudProvider{Provider: "aws", Region: "us-east-1"},
+		Provision: blueprint.Provision{
+			SkipCredentialCheck: true,
+			Artifacts:           blueprint.Artifacts{Dir: artifactsDir},
+			Matrix:              []blueprint.MatrixEntry{{Name: "dev"}, {Name: "prod"}},
+		},
+	}
+
+	provisioner := NewTerraformDockerProvisioner(artifactsRuntime(scaffoldDir))
+	provisioner.SetRunID("run-1")
+	if err := provisioner.Provision(spec, false); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	for _, workspace := range []string{"dev", "prod"} {
+		if summary := readPlanSummary(t, filepath.Join(artifactsDir, "run-1", workspace)); summary.Workspace != workspace {
+			t.Errorf("Expected the summary of workspace %s, got %+v", workspace, summary)
+		}
+	}
+}
@@ -0,0 +1,274 @@
+package provisioner
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"klonekit/internal/errors"
+	"klonekit/pkg/blueprint"
+)
+
+// ProviderTerraformMinimum records that releases of a provider from ProviderVersion on need at
+// least TerraformVersion.
+type ProviderTerraformMinimum struct {
+	Source           string // Provider source address, e.g. "hashicorp/awscc"
+	ProviderVersion  string // First provider release with the requirement
+	TerraformVersion string // Oldest Terraform release those provider releases support
+}
+
+// ProviderTerraformMinimums lists the known minimum Terraform versions of provider releases.
+// Before init, the Terraform version is checked against them for each provider in the
+// module's required_providers, at the oldest provider version its constraint allows.
+// OpenTofu versions are compared as they are: OpenTofu 1.6, its first release, supports the
+// provider protocols of Terraform 1.6, and later releases keep that numbering.
+var ProviderTerraformMinimums = []ProviderTerraformMinimum{
+	// Releases built on the plugin SDK v2 (protocol 5) only install on Terraform 0.12 and later
+	{Source: "hashicorp/aws", ProviderVersion: "3.0.0", TerraformVersion: "0.12.0"},
+	{Source: "hashicorp/azurerm", ProviderVersion: "2.0.0", TerraformVersion: "0.12.0"},
+	{Source: "hashicorp/google", ProviderVersion: "3.0.0", TerraformVersion: "0.12.0"},
+	{Source: "hashicorp/random", ProviderVersion: "3.0.0", TerraformVersion: "0.12.0"},
+	// Cloud Control providers use protocol 6, which Terraform supports from 1.0
+	{Source: "hashicorp/awscc", ProviderVersion: "0.0.0", TerraformVersion: "1.0.7"},
+}
+
+// moduleProvider is a provider declared in a module's required_providers block.
+type moduleProvider struct {
+	Name       string
+	Source     string
+	Constraint string
+}
+
+var (
+	// requiredProvidersRegex matches the opening of a required_providers block.
+	requiredProvidersRegex = regexp.MustCompile(`\brequired_providers\s*\{`)
+	// providerEntryRegex matches the start of a provider entry: an object, or the legacy
+	// version constraint string.
+	providerEntryRegex = regexp.MustCompile(`(?m)^\s*([A-Za-z][A-Za-z0-9_-]*)\s*=\s*(\{|"([^"]*)")`)
+	// providerSourceRegex and providerVersionRegex match the attributes of a provider entry.
+	providerSourceRegex  = regexp.MustCompile(`\bsource\s*=\s*"([^"]*)"`)
+	providerVersionRegex = regexp.MustCompile(`\bversion\s*=\s*"([^"]*)"`)
+	// constraintRegex matches one version constraint, e.g. "~> 5.0" or ">= 1.2.3".
+	constraintRegex = regexp.MustCompile(`^(~>|>=|<=|!=|>|<|=)?\s*v?([0-9]+(\.[0-9]+){0,2})`)
+)
+
+// checkProviderCompatibility warns when the Terraform version of image is older than a
+// provider of the module in dir supports, or fails when
+// spec.provision.terraform.strictCompatibility is set. Images whose tag isn't a version
+// are not checked.
+func checkProviderCompatibility(spec *blueprint.Spec, dir, image string) error {
+	version := imageVersion(image)
+	if version == "" {
+		slog.Debug("Skipping the provider compatibility check for an image without a version tag", "image", image)
+		return nil
+	}
+
+	providers, err := requiredProviders(dir)
+	if err != nil {
+		return fmt.Errorf("failed to read the module's required providers: %w", err)
+	}
+
+	for _, provider := range providers {
+		providerVersion := constraintLowerBound(provider.Constraint)
+		minimum := terraformMinimum(provider.Source, providerVersion)
+		if minimum == "" || compareVersions(version, minimum) >= 0 {
+			continue
+		}
+
+		if spec.Provision.Terraform.StrictCompatibility {
+			return errors.NewProvisionError(
+				"Cannot provision infrastructure",
+				fmt.Sprintf("Provider %s %s requires Terraform %s or later, but the image runs %s", provider.Source, providerVersion, minimum, version),
+				fmt.Sprintf("Set spec.provision.terraform.version to %s or later, or unset spec.provision.terraform.strictCompatibility to only warn", minimum),
+				fmt.Errorf("terraform %s is older than provider %s %s supports (%s)", version, provider.Source, providerVersion, minimum),
+			)
+		}
+		slog.Warn("Terraform version is older than a provider of the module supports; terraform init may fail",
+			"terraform", version, "provider", provider.Source, "providerVersion", providerVersion, "minimumTerraform", minimum)
+	}
+	return nil
+}
+
+// imageVersion returns the semantic version an image is tagged with, or "" for other tags.
+func imageVersion(image string) string {
+	name, _, _ := strings.Cut(image, "@")
+	i := strings.LastIndex(name, ":")
+	if i < 0 || strings.Contains(name[i+1:], "/") {
+		return ""
+	}
+	tag := strings.TrimPrefix(name[i+1:], "v")
+	if !blueprint.IsTerraformVersion(tag) {
+		return ""
+	}
+	return tag
+}
+
+// terraformMinimum returns the minimum Terraform version ProviderTerraformMinimums gives the
+// provider release, or "" when none is known.
+func terraformMinimum(source, providerVersion string) string {
+	minimum := ""
+	for _, known := range ProviderTerraformMinimums {
+		if !strings.EqualFold(known.Source, source) || compareVersions(providerVersion, known.ProviderVersion) < 0 {
+			continue
+		}
+		if minimum == "" || compareVersions(known.TerraformVersion, minimum) > 0 {
+			minimum = known.TerraformVersion
+		}
+	}
+	return minimum
+}
+
+// requiredProviders returns the providers declared in the required_providers blocks of the
+// top-level .tf files in dir, sorted by name. A provider without a source is from the
+// hashicorp namespace, and the registry host is dropped from sources.
+func requiredProviders(dir string) ([]moduleProvider, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var providers []moduleProvider
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".tf") {
+			continue
+		}
+		content, err := os.ReadFile(filepath.Join(dir, entry.Name())) // #nosec G304
+		if err != nil {
+			return nil, err
+		}
+		text := stripComments(string(content))
+		for _, match := range requiredProvidersRegex.FindAllStringIndex(text, -1) {
+			providers = append(providers, parseProviderEntries(blockBody(text, match[1]-1))...)
+		}
+	}
+
+	sort.Slice(providers, func(i, j int) bool { return providers[i].Name < providers[j].Name })
+	return providers, nil
+}
+
+// parseProviderEntries parses the entries of a required_providers block body.
+func parseProviderEntries(body string) []moduleProvider {
+	var providers []moduleProvider
+	for offset := 0; offset < len(body); {
+		match := providerEntryRegex.FindStringSubmatchIndex(body[offset:])
+		if match == nil {
+			break
+		}
+		provider := moduleProvider{Name: body[offset+match[2] : offset+match[3]]}
+		next := offset + match[1]
+
+		if body[offset+match[4]] == '{' {
+			attributes := blockBody(body, offset+match[4])
+			if source := providerSourceRegex.FindStringSubmatch(attributes); source != nil {
+				provider.Source = source[1]
+			}
+			if version := providerVersionRegex.FindStringSubmatch(attributes); version != nil {
+				provider.Constraint = version[1]
+			}
+			next = offset + match[4] + len(attributes) + 2
+		} else {
+			provider.Constraint = body[offset+match[6] : offset+match[7]]
+		}
+
+		provider.Source = normalizeProviderSource(provider.Name, provider.Source)
+		providers = append(providers, provider)
+		offset = next
+	}
+	return providers
+}
+
+// normalizeProviderSource returns source as namespace/type, defaulting to hashicorp/name.
+func normalizeProviderSource(name, source string) string {
+	if source == "" {
+		return "hashicorp/" + name
+	}
+	parts := strings.Split(strings.ToLower(source), "/")
+	if len(parts) == 3 {
+		parts = parts[1:]
+	}
+	return strings.Join(parts, "/")
+}
+
+// blockBody returns the text between the brace at open and its matching closing brace, or the
+// rest of text when the block isn't closed.
+func blockBody(text string, open int) string {
+	depth := 0
+	for i := open; i < len(text); i++ {
+		switch text[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return text[open+1 : i]
+			}
+		}
+	}
+	return text[open+1:]
+}
+
+// stripComments removes the # and // line comments of HCL text.
+func stripComments(text string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, "//") {
+			lines[i] = ""
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// constraintLowerBound returns the oldest version a version constraint such as "~> 5.0, < 6"
+// allows, as a three-part version; "0.0.0" when the constraint sets no lower bound.
+func constraintLowerBound(constraint string) string {
+	lower := "0.0.0"
+	for _, part := range strings.Split(constraint, ",") {
+		match := constraintRegex.FindStringSubmatch(strings.TrimSpace(part))
+		if match == nil {
+			continue
+		}
+		switch match[1] {
+		case "", "=", ">=", ">", "~>":
+			version := match[2]
+			for strings.Count(version, ".") < 2 {
+				version += ".0"
+			}
+			if compareVersions(version, lower) > 0 {
+				lower = version
+			}
+		}
+	}
+	return lower
+}
+
+// compareVersions compares the major, minor and patch numbers of two versions, ignoring
+// pre-release suffixes, returning -1, 0 or 1.
+func compareVersions(a, b string) int {
+	aParts := versionNumbers(a)
+	bParts := versionNumbers(b)
+	for i := range aParts {
+		if aParts[i] != bParts[i] {
+			if aParts[i] < bParts[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+// versionNumbers returns the major, minor and patch numbers of a version, missing ones as 0.
+func versionNumbers(version string) [3]int {
+	var numbers [3]int
+	version, _, _ = strings.Cut(version, "-")
+	for i, part := range strings.SplitN(version, ".", 3) {
+		numbers[i], _ = strconv.Atoi(part)
+	}
+	return numbers
+}
@@ -0,0 +1,166 @@
+package provisioner
+
+import (
+	stderrors "errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"klonekit/internal/errors"
+	"klonekit/pkg/blueprint"
+)
+
+// newerTerraformModule requires a provider release that needs a newer Terraform than 1.5.7
+const newerTerraformModule = `terraform {
+  required_providers {
+    # example = { source = "hashicorp/commented" }
+    aws = {
+      source  = "registry.terraform.io/hashicorp/aws"
+      version = "~> 6.2"
+      configuration_aliases = [aws.replica]
+    }
+    random = "~> 3.0"
+  }
+}
+`
+
+// withProviderMinimums replaces ProviderTerraformMinimums for the test
+func withProviderMinimums(t *testing.T, minimums []ProviderTerraformMinimum) {
+	t.Helper()
+	previous := ProviderTerraformMinimums
+	ProviderTerraformMinimums = minimums
+	t.Cleanup(func() { ProviderTerraformMinimums = previous })
+}
+
+func TestRequiredProviders(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "versions.tf"), []byte(newerTerraformModule), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	providers, err := requiredProviders(dir)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	want := []moduleProvider{
+		{Name: "aws", Source: "hashicorp/aws", Constraint: "~> 6.2"},
+		{Name: "random", Source: "hashicorp/random", Constraint: "~> 3.0"},
+	}
+	if !reflect.DeepEqual(providers, want) {
+		t.Errorf("Expected providers %+v, got %+v", want, providers)
+	}
+}
+
+func TestConstraintLowerBound(t *testing.T) {
+	tests := map[string]string{
+		"":                "0.0.0",
+		"~> 5.0":          "5.0.0",
+		">= 4.2, < 6":     "4.2.0",
+		"6.1.3":           "6.1.3",
+		"< 6.0":           "0.0.0",
+		"!= 5.1.0, >= 5":  "5.0.0",
+		">= 5.0, ~> 5.31": "5.31.0",
+	}
+	for constraint, want := range tests {
+		if got := constraintLowerBound(constraint); got != want {
+			t.Errorf("constraintLowerBound(%q) = %s, want %s", constraint, got, want)
+		}
+	}
+}
+
+func TestImageVersion(t *testing.T) {
+	tests := map[string]string{
+		"hashicorp/terraform:1.8.0":                  "1.8.0",
+		"ghcr.io/opentofu/opentofu:1.7.2":            "1.7.2",
+		"registry.example.com:5000/terraform:v1.9.0": "1.9.0",
+		"registry.example.com:5000/terraform":        "",
+		"hashicorp/terraform:latest":                 "",
+		"hashicorp/terraform:1.8.0@sha256:abcdef":    "1.8.0",
+	}
+	for image, want := range tests {
+		if got := imageVersion(image); got != want {
+			t.Errorf("imageVersion(%q) = %q, want %q", image, got, want)
+		}
+	}
+}
+
+func TestTerraformMinimum(t *testing.T) {
+	tests := []struct {
+		source, providerVersion, want string
+	}{
+		{source: "hashicorp/aws", providerVersion: "5.31.0", want: "0.12.0"},
+		{source: "hashicorp/aws", providerVersion: "2.70.0", want: ""},
+		{source: "hashicorp/random", providerVersion: "3.0.0", want: "0.12.0"},
+		{source: "hashicorp/awscc", providerVersion: "1.0.0", want: "1.0.7"},
+		{source: "example/unknown", providerVersion: "1.0.0", want: ""},
+	}
+	for _, tt := range tests {
+		if got := terraformMinimum(tt.source, tt.providerVersion); got != tt.want {
+			t.Errorf("terraformMinimum(%q, %q) = %q, want %q", tt.source, tt.providerVersion, got, tt.want)
+		}
+	}
+}
+
+func TestTerraformDockerProvisioner_ProviderCompatibility(t *testing.T) {
+	withProviderMinimums(t, []ProviderTerraformMinimum{
+		{Source: "hashicorp/aws", ProviderVersion: "6.0.0", TerraformVersion: "1.6.0"},
+		{Source: "hashicorp/aws", ProviderVersion: "7.0.0", TerraformVersion: "1.10.0"},
+	})
+
+	tests := []struct {
+		name     string
+		engine   string
+		version  string
+		strict   bool
+		wantWarn bool
+		wantErr  bool
+	}{
+		{name: "older terraform", version: "1.5.7", wantWarn: true},
+		{name: "older terraform in strict mode", version: "1.5.7", strict: true, wantErr: true},
+		{name: "supported terraform", version: "1.6.0"},
+		{name: "newer terraform", version: "1.10.1"},
+		{name: "opentofu compared by its own version", engine: EngineOpenTofu, version: "1.6.2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scaffoldDir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(scaffoldDir, "versions.tf"), []byte(newerTerraformModule), 0644); err != nil {
+				t.Fatal(err)
+			}
+			spec := &blueprint.Spec{
+				Scaffold:  blueprint.Scaffold{Destination: scaffoldDir},
+				Cloud:     blueprint.CloudProvider{Provider: "aws", Region: "us-east-1"},
+				Provision: blueprint.Provision{Engine: tt.engine, Terraform: blueprint.TerraformConfig{Version: tt.version, StrictCompatibility: tt.strict}},
+			}
+			var ran []string
+			provisioner := NewTerraformDockerProvisioner(inspectingRuntime(nil, &ran))
+
+			logs, err := provisionWithLogs(t, provisioner, spec)
+
+			if tt.wantErr {
+				var kloneKitErr *errors.KloneKitError
+				if !stderrors.As(err, &kloneKitErr) || !strings.Contains(kloneKitErr.Cause, "Provider hashicorp/aws 6.2.0 requires Terraform 1.6.0 or later") {
+					t.Fatalf("Expected a compatibility error, got: %v", err)
+				}
+				if len(ran) != 0 {
+					t.Errorf("Expected init not to run, ran %v", ran)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			warned := strings.Contains(logs, "Terraform version is older than a provider of the module supports")
+			if warned != tt.wantWarn {
+				t.Errorf("Expected warning %v, got logs:\n%s", tt.wantWarn, logs)
+			}
+			if tt.wantWarn && !strings.Contains(logs, "minimumTerraform=1.6.0") {
+				t.Errorf("Expected the warning to name the minimum Terraform version, got logs:\n%s", logs)
+			}
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package provisioner
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"klonekit/pkg/blueprint"
+)
+
+const (
+	// PlanFileName is the plan file written by terraform plan when a cost estimate command,
+	// a plan JSON path, a policy command or plan artifacts are configured
+	PlanFileName = "klonekit.tfplan"
+
+	// PlanFileEnv is the environment variable holding the host path of the plan file for the cost hook
+	PlanFileEnv = "KLONEKIT_PLAN_FILE"
+
+	// ScaffoldDirEnv is the environment variable holding the host scaffold directory for the cost hook
+	ScaffoldDirEnv = "KLONEKIT_SCAFFOLD_DIR"
+)
+
+// costHookOutput is where the cost estimate is surfaced; tests replace it.
+var costHookOutput io.Writer = os.Stdout
+
+// savesPlan reports whether terraform plan saves the plan to PlanFileName, which is needed
+// when a cost estimate command, the plan JSON export, a policy command or the plan artifacts
+// read it. A saved plan is then applied as is.
+func savesPlan(spec *blueprint.Spec) bool {
+	return spec.Provision.CostEstimateCommand != "" || spec.Provision.PlanJSON != "" ||
+		spec.Provision.PolicyCommand != "" || spec.Provision.Artifacts.Dir != ""
+}
+
+// planArgs returns the terraform plan arguments with the given variable arguments, saving
+// the plan to PlanFileName when savesPlan.
+func planArgs(spec *blueprint.Spec, varArgs []string) []string {
+	args := []string{"plan"}
+	if savesPlan(spec) {
+		args = append(args, "-out="+PlanFileName)
+	}
+	return append(args, varArgs...)
+}
+
+// removePlanFile removes the saved plan, since it can contain sensitive values.
+func removePlanFile(scaffoldDir string) {
+	planFile := filepath.Join(scaffoldDir, PlanFileName)
+	if err := os.Remove(planFile); err != nil && !os.IsNotExist(err) {
+		slog.Warn("Failed to remove plan file", "path", planFile, "error", err)
+	}
+}
+
+// runCostHook runs the configured cost estimate command on the host after terraform plan,
+// with the plan file path in KLONEKIT_PLAN_FILE, and surfaces its output. The estimate is
+// informational, so a failing command is logged rather than failing provisioning.
+func runCostHook(ctx context.Context, command, scaffoldDir string, out io.Writer) {
+	planFile := filepath.Join(scaffoldDir, PlanFileName)
+	slog.Info("Running cost estimate command", "command", command, "planFile", planFile)
+
+	cmd := exec.CommandContext(ctx, "sh", "-c", command) // #nosec G204 -- the command comes from the user's blueprint
+	cmd.Dir = scaffoldDir
+	cmd.Env = append(os.Environ(), PlanFileEnv+"="+planFile, ScaffoldDirEnv+"="+scaffoldDir)
+
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	err := cmd.Run()
+
+	fmt.Fprintln(out, "💰 Cost estimate:")
+	scanner := bufio.NewScanner(&output)
+	for scanner.Scan() {
+		fmt.Fprintf(out, "   %s\n", scanner.Text())
+	}
+
+	if err != nil {
+		slog.Warn("Cost estimate command failed", "command", command, "error", err, "output", strings.TrimSpace(output.String()))
+	}
+}
@@ -0,0 +1,104 @@
+package provisioner
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+
+	"klonekit/pkg/blueprint"
+	runtimePkg "klonekit/pkg/runtime"
+)
+
+func TestTerraformDockerProvisioner_CostEstimateHook(t *testing.T) {
+	scaffoldDir := t.TempDir()
+	absScaffoldDir, err := filepath.Abs(scaffoldDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	received := filepath.Join(t.TempDir(), "received-plan-path")
+
+	spec := &blueprint.Spec{
+		Scaffold: blueprint.Scaffold{Destination: scaffoldDir},
+		Cloud:    blueprint.CloudProvider{Provider: "aws", Region: "us-east-1"},
+		Provision: blueprint.Provision{
+			CostEstimateCommand: `echo "$KLONEKIT_PLAN_FILE" > ` + received + `; echo "Monthly cost: \$42.00"`,
+		},
+	}
+
+	var planCommand []string
+	mockRuntime := new(MockContainerRuntime)
+	mockRuntime.On("PullImage", mock.Anything, TerraformDockerImage).Return(nil)
+	mockRuntime.On("RunContainer", mock.Anything, mock.MatchedBy(func(opts runtimePkg.RunOptions) bool {
+		if opts.Command[0] == "plan" {
+			planCommand = opts.Command
+			// Stand in for the plan file terraform writes into the mounted workspace
+			_ = os.WriteFile(filepath.Join(scaffoldDir, PlanFileName), []byte("plan"), 0600)
+		}
+		return true
+	})).Return(&MockReadCloser{data: []byte("ok")}, nil)
+
+	var output bytes.Buffer
+	original := costHookOutput
+	costHookOutput = &output
+	defer func() { costHookOutput = original }()
+
+	if err := NewTerraformDockerProvisioner(mockRuntime).Provision(spec, false); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	if strings.Join(planCommand, " ") != "plan -out="+PlanFileName {
+		t.Errorf("Expected plan to be saved to %s, got: %v", PlanFileName, planCommand)
+	}
+
+	path, err := os.ReadFile(received)
+	if err != nil {
+		t.Fatalf("Expected the hook to run: %s", err)
+	}
+	if strings.TrimSpace(string(path)) != filepath.Join(absScaffoldDir, PlanFileName) {
+		t.Errorf("Expected the hook to receive the plan path, got: %s", path)
+	}
+	if !strings.Contains(output.String(), "Monthly cost: $42.00") {
+		t.Errorf("Expected the hook output to be surfaced, got: %s", output.String())
+	}
+	if _, err := os.Stat(filepath.Join(scaffoldDir, PlanFileName)); !os.IsNotExist(err) {
+		t.Error("Expected the plan file to be removed after the hook")
+	}
+}
+
+func TestTerraformDockerProvisioner_NoCostEstimateHookByDefault(t *testing.T) {
+	spec := &blueprint.Spec{
+		Scaffold: blueprint.Scaffold{Destination: t.TempDir()},
+		Cloud:    blueprint.CloudProvider{Provider: "aws", Region: "us-east-1"},
+	}
+
+	var planCommand []string
+	mockRuntime := new(MockContainerRuntime)
+	mockRuntime.On("PullImage", mock.Anything, TerraformDockerImage).Return(nil)
+	mockRuntime.On("RunContainer", mock.Anything, mock.MatchedBy(func(opts runtimePkg.RunOptions) bool {
+		if opts.Command[0] == "plan" {
+			planCommand = opts.Command
+		}
+		return true
+	})).Return(&MockReadCloser{data: []byte("ok")}, nil)
+
+	if err := NewTerraformDockerProvisioner(mockRuntime).Provision(spec, false); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if len(planCommand) != 1 {
+		t.Errorf("Expected a plain terraform plan without a plan file, got: %v", planCommand)
+	}
+}
+
+func TestRunCostHook_FailureIsNotFatal(t *testing.T) {
+	var output bytes.Buffer
+	runCostHook(context.Background(), `echo "infracost: not logged in"; exit 3`, t.TempDir(), &output)
+
+	if !strings.Contains(output.String(), "infracost: not logged in") {
+		t.Errorf("Expected the failing hook's output to be surfaced, got: %s", output.String())
+	}
+}
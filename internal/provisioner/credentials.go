@@ -0,0 +1,85 @@
+package provisioner
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+
+	"klonekit/internal/errors"
+	"klonekit/pkg/blueprint"
+	"klonekit/pkg/runtime"
+)
+
+const (
+	// AWSCLIDockerImage is the official AWS CLI image used to verify credentials before provisioning
+	AWSCLIDockerImage = "amazon/aws-cli:2.15.0"
+
+	// SkipCredentialCheckEnv disables the credential check when set to a true value (e.g. for offline use)
+	SkipCredentialCheckEnv = "KLONEKIT_SKIP_CREDENTIAL_CHECK"
+)
+
+// shouldVerifyCredentials reports whether the pre-provisioning credential check is enabled.
+// The check runs by default and can be disabled in the blueprint or via SkipCredentialCheckEnv.
+func shouldVerifyCredentials(spec *blueprint.Spec) bool {
+	return !spec.Provision.SkipCredentialCheck && !SkipCredentialCheckFromEnv()
+}
+
+// SkipCredentialCheckFromEnv reports whether SkipCredentialCheckEnv disables the credential check.
+func SkipCredentialCheckFromEnv() bool {
+	skip, err := strconv.ParseBool(os.Getenv(SkipCredentialCheckEnv))
+	return err == nil && skip
+}
+
+// verifyCredentials makes a cheap STS GetCallerIdentity call in a container with the same
+// credentials mounts and environment as the Terraform commands, so authentication problems
+// surface before terraform runs instead of as a cryptic provider error.
+func (p *TerraformDockerProvisioner) verifyCredentials(ctx context.Context, baseOpts runtime.RunOptions) error {
+	slog.Info("Verifying cloud credentials", "check", "sts get-caller-identity")
+
+	if err := p.pullImage(ctx, AWSCLIDockerImage, "AWS CLI image"); err != nil {
+		return fmt.Errorf("credential check: %w", err)
+	}
+
+	opts := baseOpts
+	opts.Image = AWSCLIDockerImage
+	opts.Entrypoint = nil
+	opts.Command = []string{"sts", "get-caller-identity", "--output", "json"}
+	opts.RetainContainer = false
+	if opts.ContainerName != "" {
+		opts.ContainerName = opts.ContainerName + "-credentials"
+	}
+
+	reader, err := p.containerRuntime.RunContainer(ctx, opts)
+	if err != nil {
+		return fmt.Errorf("failed to run credential check container: %w", err)
+	}
+
+	// Collect the output so an authentication failure can be reported with its reason
+	var output []string
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		if line := cleanDockerLogLine(scanner.Text()); line != "" {
+			output = append(output, line)
+		}
+	}
+
+	if err := reader.Close(); err != nil {
+		reason := strings.Join(output, " ")
+		if reason == "" {
+			reason = err.Error()
+		}
+		return errors.NewConfigError(
+			"Failed to verify AWS credentials before provisioning",
+			fmt.Sprintf("AWS STS GetCallerIdentity was rejected: %s", reason),
+			fmt.Sprintf("Check the credentials and profile in ~/.aws, or skip this check when offline with spec.provision.skipCredentialCheck or %s=true", SkipCredentialCheckEnv),
+			fmt.Errorf("credential check failed: %w", err),
+		)
+	}
+
+	slog.Info("Cloud credentials verified successfully")
+	return nil
+}
@@ -0,0 +1,134 @@
+package provisioner
+
+import (
+	stderrors "errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+
+	"klonekit/internal/errors"
+	"klonekit/pkg/blueprint"
+	runtimePkg "klonekit/pkg/runtime"
+)
+
+func TestTerraformDockerProvisioner_CredentialCheckFailureAbortsProvisioning(t *testing.T) {
+	t.Setenv(SkipCredentialCheckEnv, "")
+
+	spec := &blueprint.Spec{
+		Scaffold: blueprint.Scaffold{
+			Destination: t.TempDir(),
+		},
+		Cloud: blueprint.CloudProvider{
+			Region: "us-east-1",
+		},
+	}
+
+	mockRuntime := new(MockContainerRuntime)
+	mockRuntime.On("PullImage", mock.Anything, TerraformDockerImage).Return(nil)
+	mockRuntime.On("PullImage", mock.Anything, AWSCLIDockerImage).Return(nil)
+	mockRuntime.On("RunContainer", mock.Anything, mock.MatchedBy(func(opts runtimePkg.RunOptions) bool {
+		return opts.Image == AWSCLIDockerImage && opts.Command[0] == "sts" &&
+			opts.EnvVars["AWS_SHARED_CREDENTIALS_FILE"] == "/home/terraform/.aws/credentials"
+	})).Return(&MockReadCloser{
+		data:     []byte("An error occurred (InvalidClientTokenId) when calling the GetCallerIdentity operation"),
+		closeErr: stderrors.New("container exited with code 254"),
+	}, nil)
+
+	provisioner := NewTerraformDockerProvisioner(mockRuntime)
+	err := provisioner.Provision(spec, true)
+	if err == nil {
+		t.Fatal("Expected provisioning to abort on failed credential check")
+	}
+
+	var kkErr *errors.KloneKitError
+	if !stderrors.As(err, &kkErr) || kkErr.Type != errors.ErrConfigInvalid {
+		t.Fatalf("Expected a config error, got: %v", err)
+	}
+	if !strings.Contains(kkErr.Cause, "InvalidClientTokenId") {
+		t.Errorf("Expected cause to include the STS error, got: %s", kkErr.Cause)
+	}
+
+	// Terraform must not run after a failed credential check
+	mockRuntime.AssertNumberOfCalls(t, "RunContainer", 1)
+}
+
+func TestTerraformDockerProvisioner_CredentialCheckPasses(t *testing.T) {
+	t.Setenv(SkipCredentialCheckEnv, "")
+
+	spec := &blueprint.Spec{
+		Scaffold: blueprint.Scaffold{
+			Destination: t.TempDir(),
+		},
+		Cloud: blueprint.CloudProvider{
+			Region: "us-east-1",
+		},
+	}
+
+	mockRuntime := new(MockContainerRuntime)
+	mockRuntime.On("PullImage", mock.Anything, TerraformDockerImage).Return(nil)
+	mockRuntime.On("PullImage", mock.Anything, AWSCLIDockerImage).Return(nil)
+	mockRuntime.On("RunContainer", mock.Anything, mock.Anything).Return(&MockReadCloser{data: []byte("ok")}, nil)
+
+	provisioner := NewTerraformDockerProvisioner(mockRuntime)
+	if err := provisioner.Provision(spec, false); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	// Credential check, terraform init and terraform plan
+	mockRuntime.AssertNumberOfCalls(t, "RunContainer", 3)
+	mockRuntime.AssertCalled(t, "PullImage", mock.Anything, AWSCLIDockerImage)
+}
+
+func TestTerraformDockerProvisioner_CredentialCheckImagePullIsRetriedAndCached(t *testing.T) {
+	t.Setenv(SkipCredentialCheckEnv, "")
+
+	spec := &blueprint.Spec{
+		Scaffold: blueprint.Scaffold{
+			Destination: t.TempDir(),
+		},
+		Cloud: blueprint.CloudProvider{
+			Region: "us-east-1",
+		},
+	}
+
+	mockRuntime := new(MockContainerRuntime)
+	mockRuntime.On("PullImage", mock.Anything, TerraformDockerImage).Return(nil)
+	mockRuntime.On("PullImage", mock.Anything, AWSCLIDockerImage).Return(stderrors.New("registry unavailable")).Once()
+	mockRuntime.On("PullImage", mock.Anything, AWSCLIDockerImage).Return(nil)
+	mockRuntime.On("RunContainer", mock.Anything, mock.Anything).Return(&MockReadCloser{data: []byte("ok")}, nil)
+
+	provisioner := NewTerraformDockerProvisioner(mockRuntime)
+	for range 2 {
+		if err := provisioner.Provision(spec, false); err != nil {
+			t.Fatalf("Expected the failed pull to be retried, got: %s", err)
+		}
+	}
+
+	// One failed and one retried pull; the second run reuses the pulled image
+	mockRuntime.AssertNumberOfCalls(t, "PullImage", 3)
+}
+
+func TestShouldVerifyCredentials(t *testing.T) {
+	tests := []struct {
+		name     string
+		env      string
+		skip     bool
+		expected bool
+	}{
+		{name: "Enabled by default", expected: true},
+		{name: "Skipped in blueprint", skip: true, expected: false},
+		{name: "Skipped via environment", env: "true", expected: false},
+		{name: "Environment false keeps check", env: "false", expected: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(SkipCredentialCheckEnv, tt.env)
+			spec := &blueprint.Spec{Provision: blueprint.Provision{SkipCredentialCheck: tt.skip}}
+			if got := shouldVerifyCredentials(spec); got != tt.expected {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
@@ -1,7 +1,6 @@
 package provisioner
 
 import (
-	"bufio"
 	"context"
 	"fmt"
 	"io"
@@ -13,23 +12,98 @@ import (
 	"strings"
 	"time"
 
+	units "github.com/docker/go-units"
+	"golang.org/x/sync/errgroup"
+
+	"klonekit/internal/retry"
 	"klonekit/pkg/blueprint"
 	"klonekit/pkg/runtime"
 )
 
 const (
+	// TerraformImageRepository is the official HashiCorp Terraform Docker image
+	TerraformImageRepository = "hashicorp/terraform"
+
+	// DefaultTerraformVersion is the Terraform version used when spec.provision.terraform.version is unset
+	DefaultTerraformVersion = "1.8.0"
+
 	// TerraformDockerImage is the official HashiCorp Terraform Docker image version
-	TerraformDockerImage = "hashicorp/terraform:1.8.0"
+	TerraformDockerImage = TerraformImageRepository + ":" + DefaultTerraformVersion
 
 	// WorkingDirectory is the container working directory
 	WorkingDirectory = "/workspace"
+
+	// TerraformVersionFile is the file tfenv reads a module's Terraform version from
+	TerraformVersionFile = ".terraform-version"
+
+	// TerraformDataDirectory is the container path used as TF_DATA_DIR when a data directory is configured
+	TerraformDataDirectory = "/terraform-data"
 )
 
+// ProviderImages maps a cloud provider to the Terraform image used for it, e.g. an image
+// with the provider plugins pre-installed for offline use. Providers without an entry use
+// TerraformDockerImage. For a blueprint, spec.provision.image overrides the mapping, and
+// spec.provision.terraform.version, or else a .terraform-version file in the scaffold source,
+// selects that version of the official image instead. The mapping only applies to the
+// terraform engine.
+var ProviderImages = map[string]string{
+	"aws": TerraformDockerImage,
+}
+
+// TerraformImage returns the Terraform image to run for the spec.
+func TerraformImage(spec *blueprint.Spec) string {
+	if spec.Provision.Image != "" {
+		return spec.Provision.Image
+	}
+	if spec.Provision.Engine == EngineOpenTofu {
+		version := spec.Provision.Terraform.Version
+		if version == "" {
+			version = DefaultOpenTofuVersion
+		}
+		return OpenTofuImageRepository + ":" + version
+	}
+	if version := spec.Provision.Terraform.Version; version != "" {
+		return TerraformImageRepository + ":" + version
+	}
+	if version := SourceTerraformVersion(spec.Scaffold.Source); version != "" {
+		return TerraformImageRepository + ":" + version
+	}
+	if image, ok := ProviderImages[spec.Cloud.Provider]; ok && image != "" {
+		return image
+	}
+	return TerraformDockerImage
+}
+
+// SourceTerraformVersion returns the version pinned by the TerraformVersionFile of a local
+// scaffold source, as kept by tfenv, or "" when there is none. A file that doesn't name an
+// exact version (tfenv also accepts e.g. "latest") is ignored with a warning.
+func SourceTerraformVersion(source string) string {
+	if source == "" {
+		return ""
+	}
+	path := filepath.Join(source, TerraformVersionFile)
+	data, err := os.ReadFile(path) // #nosec G304
+	if err != nil {
+		return ""
+	}
+	version := strings.TrimPrefix(strings.TrimSpace(string(data)), "v")
+	if !blueprint.IsTerraformVersion(version) {
+		slog.Warn("Ignoring Terraform version file that doesn't name an exact version", "file", path, "version", strings.TrimSpace(string(data)))
+		return ""
+	}
+	return version
+}
 
 // TerraformDockerProvisioner implements the Provisioner interface using container runtime.
 type TerraformDockerProvisioner struct {
 	containerRuntime runtime.ContainerRuntime
 	containerName    string // Name for the persistent Terraform container
+	runID            string // Run the plan artifacts are kept under (see SetRunID)
+	outputs          map[string]Output
+	pulledImages     map[string]bool // Images already pulled, e.g. by PrePullImage
+	outputLimit      int64           // Output shown per Terraform command, set from the spec by prepareRun
+	resumedStep      string          // Last step started by an interrupted attempt (see SetResumedStep)
+	onStep           func(step string)
 }
 
 // NewTerraformDockerProvisioner creates a new TerraformDockerProvisioner.
@@ -45,67 +119,334 @@ func NewTerraformDockerProvisioner(containerRuntime runtime.ContainerRuntime) *T
 
 // Provision executes Terraform init and optionally apply commands within a Docker container.
 // If autoApprove is false, only terraform init and plan will be executed for validation.
+// The run is stopped once spec.provision.timeout expires.
 func (p *TerraformDockerProvisioner) Provision(spec *blueprint.Spec, autoApprove bool) error {
-	ctx := context.Background()
+	ctx, cancel := runContext(spec)
+	defer cancel()
 
-	// Validate that scaffold directory exists
-	scaffoldDir := spec.Scaffold.Destination
-	if _, err := os.Stat(scaffoldDir); os.IsNotExist(err) {
-		return fmt.Errorf("scaffold directory does not exist: %s", scaffoldDir)
+	return timeoutError(ctx, spec, p.provision(ctx, spec, autoApprove))
+}
+
+// provision runs Provision within ctx.
+func (p *TerraformDockerProvisioner) provision(ctx context.Context, spec *blueprint.Spec, autoApprove bool) error {
+	slog.Info("Starting infrastructure provisioning", "scaffoldDir", spec.Scaffold.Destination)
+
+	runOpts, absScaffoldDir, err := p.prepareRun(ctx, spec)
+	if err != nil {
+		return err
 	}
 
-	slog.Info("Starting infrastructure provisioning", "scaffoldDir", scaffoldDir)
+	// Hand the files the containers create back to the host user once done, even after a timeout
+	if !spec.Provision.SkipPermissionFix {
+		defer p.fixPermissions(context.WithoutCancel(ctx), runOpts)
+	}
 
-	// Pull Terraform Docker image
-	if err := p.containerRuntime.PullImage(ctx, TerraformDockerImage); err != nil {
-		return fmt.Errorf("failed to pull Terraform image: %w", err)
+	// An apply cut short leaves whatever state it wrote; init and plan reconcile against it
+	if p.resumedStep == StepApply {
+		_, statErr := os.Stat(filepath.Join(absScaffoldDir, StateFileName))
+		slog.Warn("Resuming provisioning interrupted during apply: planning again against the state written so far", "localState", statErr == nil)
 	}
 
-	// Get absolute path of scaffold directory
-	absScaffoldDir, err := filepath.Abs(scaffoldDir)
+	p.startStep(StepInit)
+	if err := p.initialize(ctx, spec, runOpts); err != nil {
+		return err
+	}
+
+	varArgs, err := variableArgs(spec, absScaffoldDir)
 	if err != nil {
-		return fmt.Errorf("failed to get absolute path for scaffold directory: %w", err)
+		return err
+	}
+
+	// A matrix runs plan/apply once per entry, each in its own workspace
+	if len(spec.Provision.Matrix) > 0 {
+		return p.runMatrix(ctx, runOpts, spec, absScaffoldDir, varArgs, autoApprove)
+	}
+
+	if err := p.planAndApply(ctx, runOpts, spec, absScaffoldDir, varArgs, autoApprove, ""); err != nil {
+		return err
 	}
 
-	// Get user's AWS credentials directory
-	awsCredsDir, err := p.getAWSCredentialsDir()
+	if autoApprove {
+		// Capture the outputs exported to the SCM project
+		if len(spec.SCM.Project.ExportOutputs) > 0 {
+			if err := p.captureOutputs(ctx, runOpts); err != nil {
+				return err
+			}
+		}
+
+		// Upload the local state when no remote backend keeps it
+		if spec.Provision.StatePush.URL != "" {
+			if err := p.pushState(ctx, runOpts, spec, absScaffoldDir); err != nil {
+				return err
+			}
+		}
+		slog.Info("Infrastructure provisioning completed successfully")
+	} else {
+		slog.Info("Infrastructure validation completed successfully - use --auto-approve to provision")
+	}
+
+	return nil
+}
+
+// SetResumedStep sets the last step started by the interrupted attempt being resumed. After
+// an interrupted apply, Provision plans again rather than applying a saved plan.
+func (p *TerraformDockerProvisioner) SetResumedStep(step string) {
+	p.resumedStep = step
+}
+
+// OnStep registers a function called as each step of Provision starts.
+func (p *TerraformDockerProvisioner) OnStep(fn func(step string)) {
+	p.onStep = fn
+}
+
+// startStep reports that a step of Provision is starting.
+func (p *TerraformDockerProvisioner) startStep(step string) {
+	if p.onStep != nil {
+		p.onStep(step)
+	}
+}
+
+// initialize verifies the cloud credentials and runs terraform init.
+func (p *TerraformDockerProvisioner) initialize(ctx context.Context, spec *blueprint.Spec, runOpts runtime.RunOptions) error {
+	// Fail early on unusable credentials rather than midway through terraform
+	if shouldVerifyCredentials(spec) {
+		if err := p.verifyCredentials(ctx, runOpts); err != nil {
+			return err
+		}
+	}
+
+	// Backend credentials are only exposed to terraform init
+	initOpts, err := withBackendEnv(runOpts, spec.Provision.BackendEnv)
 	if err != nil {
-		return fmt.Errorf("failed to locate AWS credentials directory: %w", err)
+		return err
 	}
 
-	// Execute Terraform init
-	if err := p.runTerraformCommand(ctx, absScaffoldDir, awsCredsDir, spec.Cloud.Region, false, "init"); err != nil {
+	// Execute Terraform init, retrying since provider and module downloads can fail transiently
+	err = retry.Current().Do(ctx, "terraform init", func() error {
+		return p.runTerraformCommand(ctx, initOpts, false, initArgs(spec)...)
+	})
+	if err != nil {
 		return fmt.Errorf("terraform init failed: %w", err)
 	}
+	return nil
+}
+
+// initArgs returns the terraform init command line. Input is disabled so a changed backend
+// fails instead of waiting at a migration prompt no one can answer, unless
+// provision.backendMigration says how to handle it.
+func initArgs(spec *blueprint.Spec) []string {
+	args := []string{"init", "-input=false"}
+	switch spec.Provision.BackendMigration {
+	case blueprint.BackendMigrationMigrate:
+		// -force-copy answers the prompt to copy the existing state
+		args = append(args, "-migrate-state", "-force-copy")
+	case blueprint.BackendMigrationReconfigure:
+		args = append(args, "-reconfigure")
+	}
+	return args
+}
+
+// planAndApply runs terraform plan with the given variable arguments, the plan JSON export,
+// the plan artifacts, the cost estimate hook, the policy command, and terraform apply when
+// autoApprove is set. When the plan is saved, apply applies that plan rather than planning
+// again. workspace names the matrix entry being planned, if any. With
+// spec.provision.useSavedPlan, a plan saved by Plan is applied as is.
+func (p *TerraformDockerProvisioner) planAndApply(ctx context.Context, runOpts runtime.RunOptions, spec *blueprint.Spec, absScaffoldDir string, varArgs []string, autoApprove bool, workspace string) error {
+	// Apply a plan saved by Plan instead of planning again. After an interrupted apply the
+	// saved plan is stale, so it is planned again instead.
+	if autoApprove && spec.Provision.UseSavedPlan && workspace == "" && p.resumedStep != StepApply {
+		if applied, err := p.applySavedPlan(ctx, runOpts, absScaffoldDir, SavedPlanFileName); applied || err != nil {
+			return err
+		}
+	}
 
 	// Execute Terraform plan for validation
-	if err := p.runTerraformCommand(ctx, absScaffoldDir, awsCredsDir, spec.Cloud.Region, false, "plan"); err != nil {
+	if savesPlan(spec) {
+		defer removePlanFile(absScaffoldDir)
+	}
+	p.startStep(StepPlan)
+	if err := p.runTerraformCommand(ctx, runOpts, false, planArgs(spec, varArgs)...); err != nil {
 		return fmt.Errorf("terraform plan failed: %w", err)
 	}
 
+	// Export the machine-readable plan for policy tools before anything is applied. A policy
+	// command without a plan JSON path gets an export next to the plan file.
+	planJSON := spec.Provision.PlanJSON
+	if planJSON == "" && spec.Provision.PolicyCommand != "" {
+		planJSON = filepath.Join(absScaffoldDir, PolicyPlanJSONFileName)
+		defer removePlanJSON(planJSON)
+	}
+	if planJSON != "" {
+		if err := p.writePlanJSON(ctx, runOpts, planJSON); err != nil {
+			return err
+		}
+	}
+
+	// Keep the plan for audit before anything is applied
+	var artifactsDir string
+	if spec.Provision.Artifacts.Dir != "" {
+		dir, err := p.savePlanArtifacts(ctx, runOpts, spec, absScaffoldDir, workspace)
+		if err != nil {
+			return err
+		}
+		artifactsDir = dir
+	}
+
+	// Estimate the cost of the plan when a command is configured
+	if command := spec.Provision.CostEstimateCommand; command != "" {
+		runCostHook(ctx, command, absScaffoldDir, costHookOutput)
+	}
+
+	// A rejected plan stops the run before anything is applied
+	if command := spec.Provision.PolicyCommand; command != "" {
+		if err := runPolicyCommand(ctx, command, absScaffoldDir, planJSON); err != nil {
+			return err
+		}
+	}
+
 	// Only execute apply if auto-approve is enabled
-	if autoApprove {
+	if !autoApprove {
+		return nil
+	}
+
+	// Apply the saved plan, so what is applied is the plan that was exported, checked and
+	// kept for audit rather than a new one
+	if savesPlan(spec) {
+		applied, err := p.applySavedPlan(ctx, runOpts, absScaffoldDir, PlanFileName)
+		if err != nil {
+			return err
+		}
+		if !applied {
+			return fmt.Errorf("terraform plan did not save the plan to %s", PlanFileName)
+		}
+	} else {
 		// Backup state file before apply operation (critical for safety)
 		if err := p.backupStateFile(absScaffoldDir); err != nil {
 			slog.Warn("Failed to backup state file before apply", "error", err.Error())
 			// Continue anyway - backup failure shouldn't block apply
 		}
 
-		if err := p.runTerraformCommand(ctx, absScaffoldDir, awsCredsDir, spec.Cloud.Region, true, "apply", "-auto-approve"); err != nil {
+		p.startStep(StepApply)
+		if err := p.runTerraformCommand(ctx, runOpts, true, append([]string{"apply", "-auto-approve"}, varArgs...)...); err != nil {
 			return fmt.Errorf("terraform apply failed: %w", err)
 		}
-		slog.Info("Infrastructure provisioning completed successfully")
-	} else {
-		slog.Info("Infrastructure validation completed successfully - use --auto-approve to provision")
 	}
 
+	if artifactsDir != "" {
+		if err := markPlanApplied(artifactsDir); err != nil {
+			slog.Warn("Failed to record the apply in the plan artifacts", "dir", artifactsDir, "error", err)
+		}
+	}
 	return nil
 }
 
+// prepareRun validates the scaffold directory, pulls the Terraform image and builds
+// the container options shared by every Terraform command for the spec.
+// It also returns the absolute scaffold directory on the host.
+// With spec.provision.parallel the image pull overlaps the local setup work.
+func (p *TerraformDockerProvisioner) prepareRun(ctx context.Context, spec *blueprint.Spec) (runtime.RunOptions, string, error) {
+	// Validate that scaffold directory exists
+	scaffoldDir := spec.Scaffold.Destination
+	if _, err := os.Stat(scaffoldDir); os.IsNotExist(err) {
+		return runtime.RunOptions{}, "", fmt.Errorf("scaffold directory does not exist: %s", scaffoldDir)
+	}
+
+	p.outputLimit = spec.Provision.OutputLimit()
+
+	var runOpts runtime.RunOptions
+	var absScaffoldDir string
+
+	image := TerraformImage(spec)
+	slog.Info("Using Terraform image", "image", image, "provider", spec.Cloud.Provider)
+
+	pullImage := func(ctx context.Context) error {
+		if err := p.pullImage(ctx, image, "Terraform image"); err != nil {
+			return err
+		}
+		return p.checkImageEntrypoint(ctx, spec, image)
+	}
+
+	setup := func() error {
+		// Get absolute path of scaffold directory
+		var err error
+		absScaffoldDir, err = filepath.Abs(scaffoldDir)
+		if err != nil {
+			return fmt.Errorf("failed to get absolute path for scaffold directory: %w", err)
+		}
+
+		// Get user's AWS credentials directory
+		awsCredsDir, err := p.getAWSCredentialsDir()
+		if err != nil {
+			return fmt.Errorf("failed to locate AWS credentials directory: %w", err)
+		}
+
+		// Build the container options shared by every Terraform command
+		runOpts, err = p.buildRunOptions(spec, absScaffoldDir, awsCredsDir)
+		if err != nil {
+			return err
+		}
+		if err := createDataDir(runOpts); err != nil {
+			return err
+		}
+
+		// Flag providers the Terraform version is too old for before init fails on them
+		return checkProviderCompatibility(spec, absScaffoldDir, image)
+	}
+
+	if spec.Provision.Parallel {
+		g, gctx := errgroup.WithContext(ctx)
+		g.Go(func() error { return pullImage(gctx) })
+		g.Go(setup)
+		if err := g.Wait(); err != nil {
+			return runtime.RunOptions{}, "", err
+		}
+		return runOpts, absScaffoldDir, nil
+	}
+
+	if err := pullImage(ctx); err != nil {
+		return runtime.RunOptions{}, "", err
+	}
+	if err := setup(); err != nil {
+		return runtime.RunOptions{}, "", err
+	}
+	return runOpts, absScaffoldDir, nil
+}
+
+// pullImage pulls an image used by the run, described by kind (e.g. "Terraform image") in
+// logs and errors, unless it was already pulled, retrying transient failures. Images outside
+// the image allowlist are refused before the pull.
+func (p *TerraformDockerProvisioner) pullImage(ctx context.Context, image, kind string) error {
+	if p.pulledImages[image] {
+		slog.Debug("Image already pulled", "image", image, "kind", kind)
+		return nil
+	}
+	if err := checkImageAllowed(image); err != nil {
+		return err
+	}
+
+	err := retry.Current().Do(ctx, "pull "+kind, func() error {
+		return p.containerRuntime.PullImage(ctx, image)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to pull %s: %w", kind, err)
+	}
+	if p.pulledImages == nil {
+		p.pulledImages = make(map[string]bool)
+	}
+	p.pulledImages[image] = true
+	return nil
+}
+
+// PrePullImage pulls the Terraform image for the spec ahead of Provision, e.g. while the
+// scaffold and scm stages of an apply run.
+func (p *TerraformDockerProvisioner) PrePullImage(ctx context.Context, spec *blueprint.Spec) error {
+	return p.pullImage(ctx, TerraformImage(spec), "Terraform image")
+}
+
 // backupStateFile creates a backup of terraform.tfstate before critical operations.
 // This prevents permanent state loss in case of failures.
 func (p *TerraformDockerProvisioner) backupStateFile(scaffoldDir string) error {
-	stateFile := filepath.Join(scaffoldDir, "terraform.tfstate")
+	stateFile := filepath.Join(scaffoldDir, StateFileName)
 
 	// Check if state file exists
 	if _, err := os.Stat(stateFile); os.IsNotExist(err) {
@@ -197,17 +538,13 @@ func (p *TerraformDockerProvisioner) getAWSCredentialsDir() (string, error) {
 	return awsDir, nil
 }
 
-// runTerraformCommand executes a Terraform command using the container runtime.
-func (p *TerraformDockerProvisioner) runTerraformCommand(ctx context.Context, scaffoldDir, awsCredsDir, region string, retainContainer bool, args ...string) error {
-	// Use args directly since the container's ENTRYPOINT is already 'terraform'
-	cmd := args
-
-	slog.Info("Executing Terraform command", "command", append([]string{"terraform"}, cmd...))
+// buildRunOptions constructs the container options shared by all Terraform commands for a spec.
+func (p *TerraformDockerProvisioner) buildRunOptions(spec *blueprint.Spec, scaffoldDir, awsCredsDir string) (runtime.RunOptions, error) {
+	region := spec.Cloud.Region
 
-	// Create RunOptions for the container
 	opts := runtime.RunOptions{
-		Image:   TerraformDockerImage,
-		Command: cmd,
+		Image:      TerraformImage(spec),
+		Entrypoint: containerEntrypoint(spec),
 		VolumeMounts: map[string]string{
 			scaffoldDir: WorkingDirectory,
 			awsCredsDir: "/home/terraform/.aws", // Use non-root path for AWS credentials
@@ -219,41 +556,194 @@ func (p *TerraformDockerProvisioner) runTerraformCommand(ctx context.Context, sc
 			"AWS_REGION":                  region,
 		},
 		WorkingDirectory: WorkingDirectory,
-		User:             getCurrentUserID(),    // Run container as current user to avoid permission issues
-		RetainContainer:  retainContainer,      // Retain container for state persistence
-		ContainerName:    p.containerName,      // Use consistent container name
+		User:             getCurrentUserID(), // Run container as current user to avoid permission issues
+		ContainerName:    p.containerName,    // Use consistent container name
+		NetworkMode:      spec.Provision.NetworkMode,
+		CPUs:             spec.Provision.Resources.CPUs,
+	}
+	if memory := spec.Provision.Resources.Memory; memory != "" {
+		bytes, err := units.RAMInBytes(memory)
+		if err != nil {
+			return runtime.RunOptions{}, fmt.Errorf("invalid container memory limit %q: %w", memory, err)
+		}
+		opts.Memory = bytes
+	}
+	if profile := spec.Cloud.Profile; profile != "" {
+		opts.EnvVars["AWS_PROFILE"] = profile
+	}
+	if err := mergeEnv(opts.EnvVars, spec.Provision.Env); err != nil {
+		return runtime.RunOptions{}, err
+	}
+
+	// Persist the Terraform data directory on the host when configured
+	if dataDir := spec.Provision.DataDir; dataDir != "" {
+		absDataDir, err := filepath.Abs(dataDir)
+		if err != nil {
+			return runtime.RunOptions{}, fmt.Errorf("failed to get absolute path for terraform data directory: %w", err)
+		}
+		opts.VolumeMounts[absDataDir] = TerraformDataDirectory
+		opts.EnvVars["TF_DATA_DIR"] = TerraformDataDirectory
+	}
+
+	return opts, nil
+}
+
+// createDataDir creates the host Terraform data directory mounted by the run options, if any.
+func createDataDir(opts runtime.RunOptions) error {
+	for hostPath, containerPath := range opts.VolumeMounts {
+		if containerPath != TerraformDataDirectory {
+			continue
+		}
+		if err := os.MkdirAll(hostPath, 0750); err != nil {
+			return fmt.Errorf("failed to create terraform data directory: %w", err)
+		}
+		slog.Info("Using host terraform data directory", "path", hostPath)
+	}
+	return nil
+}
+
+// fixedEnvVars are the container environment variables spec.provision.env can't override,
+// since they point Terraform at the mounted AWS credentials.
+var fixedEnvVars = map[string]bool{
+	"AWS_SHARED_CREDENTIALS_FILE": true,
+	"AWS_CONFIG_FILE":             true,
+}
+
+// mergeEnv sets the spec.provision.env variables in envVars, over the defaults except the
+// fixed ones. Only the names are logged, since values can hold secrets.
+func mergeEnv(envVars map[string]string, env []blueprint.EnvVar) error {
+	if len(env) == 0 {
+		return nil
+	}
+
+	logged := make(map[string]string, len(env))
+	for _, variable := range env {
+		if fixedEnvVars[variable.Name] {
+			slog.Warn("Ignoring environment variable that would move the mounted AWS credentials", "name", variable.Name)
+			continue
+		}
+		value, err := variable.Resolve()
+		if err != nil {
+			return fmt.Errorf("environment variable %s: %w", variable.Name, err)
+		}
+		envVars[variable.Name] = value
+		logged[variable.Name] = maskedVarValue
+	}
+	slog.Info("Passing environment variables to the Terraform container", "env", logged)
+	return nil
+}
+
+// withBackendEnv returns a copy of opts with the backend environment variables merged in.
+// The original options are left untouched so plan and apply never see backend credentials.
+func withBackendEnv(opts runtime.RunOptions, backendEnv []blueprint.EnvVar) (runtime.RunOptions, error) {
+	if len(backendEnv) == 0 {
+		return opts, nil
+	}
+
+	envVars := make(map[string]string, len(opts.EnvVars)+len(backendEnv))
+	for key, value := range opts.EnvVars {
+		envVars[key] = value
+	}
+	for _, env := range backendEnv {
+		value, err := env.Resolve()
+		if err != nil {
+			return runtime.RunOptions{}, fmt.Errorf("backend environment variable %s: %w", env.Name, err)
+		}
+		envVars[env.Name] = value
+	}
+
+	opts.EnvVars = envVars
+	return opts, nil
+}
+
+// variableArgs returns the arguments that pass the spec's variables to plan, apply and
+// destroy: the -var-file argument for the tfvars file and, depending on
+// provision.variablesMode, a -var flag per variable.
+func variableArgs(spec *blueprint.Spec, scaffoldDir string) ([]string, error) {
+	return withVarFlags(spec, tfvarsArgs(spec, scaffoldDir))
+}
+
+// withVarFlags appends a -var flag per variable to args when provision.variablesMode passes
+// the variables as flags.
+func withVarFlags(spec *blueprint.Spec, args []string) ([]string, error) {
+	if !spec.Provision.PassesVarFlags() {
+		return args, nil
+	}
+	flags, err := varFlagArgs(spec.Variables)
+	if err != nil {
+		return nil, err
+	}
+	return append(args, flags...), nil
+}
+
+// tfvarsArgs returns the -var-file arguments needed for the spec's tfvars file.
+// Terraform loads terraform.tfvars(.json) and *.auto.tfvars(.json) on its own, so only
+// other filenames that exist in the scaffold directory need to be passed explicitly.
+func tfvarsArgs(spec *blueprint.Spec, scaffoldDir string) []string {
+	name := spec.Scaffold.TfvarsFile()
+	if spec.Scaffold.TfvarsAutoLoaded() {
+		return nil
+	}
+	if _, err := os.Stat(filepath.Join(scaffoldDir, name)); err != nil {
+		return nil
+	}
+	return []string{"-var-file=" + name}
+}
+
+// runTerraformCommand executes a Terraform command using the container runtime.
+func (p *TerraformDockerProvisioner) runTerraformCommand(ctx context.Context, baseOpts runtime.RunOptions, retainContainer bool, args ...string) error {
+	cmd := args
+
+	slog.Info("Executing Terraform command", "command", commandLine(baseOpts, maskVarFlags(cmd)))
+
+	// Create RunOptions for the container
+	opts := baseOpts
+	opts.Command = cmd
+	opts.RetainContainer = retainContainer // Retain container for state persistence
+
+	// A command stopped because the run timed out or was cancelled isn't retried
+	stopped := func(err error) error {
+		if ctx.Err() != nil {
+			return retry.Permanent(err)
+		}
+		return err
 	}
 
 	// Run the container
 	reader, err := p.containerRuntime.RunContainer(ctx, opts)
 	if err != nil {
-		return fmt.Errorf("failed to run container: %w", err)
+		return stopped(fmt.Errorf("failed to run container: %w", err))
 	}
 
-	// Stream the output
-	scanner := bufio.NewScanner(reader)
-	for scanner.Scan() {
-		line := scanner.Text()
+	// Stream the output, watching for a state lock held by another run
+	var locks lockDetector
+	truncated, err := scanOutput(reader, p.outputLimit, func(line string) {
 		// Clean up Docker log output
 		cleanLine := cleanDockerLogLine(line)
 		if cleanLine != "" {
 			slog.Info("Terraform output", "line", cleanLine)
+			locks.scan(cleanLine)
 		}
-	}
-
-	if err := scanner.Err(); err != nil {
+	})
+	if err != nil {
 		if cerr := reader.Close(); cerr != nil {
 			slog.Debug("Error closing container output reader", "error", cerr)
 		}
-		return fmt.Errorf("error reading container output: %w", err)
+		return stopped(fmt.Errorf("error reading container output: %w", err))
+	}
+	if truncated {
+		slog.Warn("Terraform output truncated: the rest of the output is not shown", "limitBytes", p.outputLimit, "setting", "spec.provision.maxOutputBytes")
 	}
 
 	// Check container exit status
 	if err := reader.Close(); err != nil {
-		return fmt.Errorf("terraform command failed: %w", err)
+		if lockErr := locks.err(err); lockErr != nil {
+			return lockErr
+		}
+		return stopped(fmt.Errorf("terraform command failed: %w", err))
 	}
 
-	slog.Info("Terraform command completed successfully", "command", append([]string{"terraform"}, cmd...))
+	slog.Info("Terraform command completed successfully", "command", commandLine(baseOpts, maskVarFlags(cmd)))
 	return nil
 }
 
@@ -15,6 +15,7 @@ import (
 	"github.com/docker/docker/client"
 	"github.com/stretchr/testify/mock"
 
+	"klonekit/internal/retry"
 	"klonekit/internal/runtime"
 	"klonekit/pkg/blueprint"
 	runtimePkg "klonekit/pkg/runtime"
@@ -121,6 +122,10 @@ region = us-east-1
 	os.Setenv("AWS_ACCESS_KEY_ID", "test-access-key-id")
 	os.Setenv("AWS_SECRET_ACCESS_KEY", "test-secret-access-key")
 	os.Setenv("AWS_DEFAULT_REGION", "us-east-1")
+	// Tests exercise the terraform flow only; the credential check has its own tests
+	os.Setenv(SkipCredentialCheckEnv, "true")
+	// Keep retried failures fast
+	retry.SetPolicy(retry.Policy{MaxRetries: retry.DefaultMaxRetries, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond})
 
 	// Run tests
 	code := m.Run()
@@ -152,8 +157,9 @@ func (m *MockContainerRuntime) RunContainer(ctx context.Context, opts runtimePkg
 
 // MockReadCloser for testing container output
 type MockReadCloser struct {
-	data []byte
-	pos  int
+	data     []byte
+	pos      int
+	closeErr error // Simulates a non-zero container exit status
 }
 
 func (m *MockReadCloser) Read(p []byte) (int, error) {
@@ -166,7 +172,7 @@ func (m *MockReadCloser) Read(p []byte) (int, error) {
 }
 
 func (m *MockReadCloser) Close() error {
-	return nil
+	return m.closeErr
 }
 
 func TestTerraformDockerProvisioner_WithMock(t *testing.T) {
@@ -514,3 +520,637 @@ provider "aws" {
 
 	// Test passes in both cases - success or expected AWS failure
 }
+
+func TestTerraformDockerProvisioner_DataDirMount(t *testing.T) {
+	dataDir := filepath.Join(t.TempDir(), "tf-data")
+	spec := &blueprint.Spec{
+		Scaffold: blueprint.Scaffold{
+			Destination: t.TempDir(),
+		},
+		Cloud: blueprint.CloudProvider{
+			Region: "us-east-1",
+		},
+		Provision: blueprint.Provision{
+			DataDir: dataDir,
+		},
+	}
+
+	mockRuntime := new(MockContainerRuntime)
+	mockRuntime.On("PullImage", mock.Anything, TerraformDockerImage).Return(nil)
+	mockRuntime.On("RunContainer", mock.Anything, mock.MatchedBy(func(opts runtimePkg.RunOptions) bool {
+		return opts.VolumeMounts[dataDir] == TerraformDataDirectory && opts.EnvVars["TF_DATA_DIR"] == TerraformDataDirectory
+	})).Return(&MockReadCloser{data: []byte("ok")}, nil)
+
+	provisioner := NewTerraformDockerProvisioner(mockRuntime)
+	if err := provisioner.Provision(spec, false); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	if _, err := os.Stat(dataDir); err != nil {
+		t.Errorf("Expected terraform data directory to be created: %s", err)
+	}
+	mockRuntime.AssertNumberOfCalls(t, "RunContainer", 2)
+}
+
+func TestTerraformDockerProvisioner_NoDataDirByDefault(t *testing.T) {
+	spec := &blueprint.Spec{
+		Scaffold: blueprint.Scaffold{
+			Destination: t.TempDir(),
+		},
+		Cloud: blueprint.CloudProvider{
+			Region: "us-east-1",
+		},
+	}
+
+	mockRuntime := new(MockContainerRuntime)
+	mockRuntime.On("PullImage", mock.Anything, TerraformDockerImage).Return(nil)
+	mockRuntime.On("RunContainer", mock.Anything, mock.MatchedBy(func(opts runtimePkg.RunOptions) bool {
+		_, hasDataDir := opts.EnvVars["TF_DATA_DIR"]
+		return !hasDataDir && len(opts.VolumeMounts) == 2
+	})).Return(&MockReadCloser{data: []byte("ok")}, nil)
+
+	provisioner := NewTerraformDockerProvisioner(mockRuntime)
+	if err := provisioner.Provision(spec, false); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	mockRuntime.AssertExpectations(t)
+}
+
+func TestTerraformDockerProvisioner_NetworkMode(t *testing.T) {
+	spec := &blueprint.Spec{
+		Scaffold: blueprint.Scaffold{
+			Destination: t.TempDir(),
+		},
+		Cloud: blueprint.CloudProvider{
+			Region: "us-east-1",
+		},
+		Provision: blueprint.Provision{
+			NetworkMode: "host",
+		},
+	}
+
+	mockRuntime := new(MockContainerRuntime)
+	mockRuntime.On("PullImage", mock.Anything, TerraformDockerImage).Return(nil)
+	mockRuntime.On("RunContainer", mock.Anything, mock.MatchedBy(func(opts runtimePkg.RunOptions) bool {
+		return opts.NetworkMode == "host"
+	})).Return(&MockReadCloser{data: []byte("ok")}, nil)
+
+	provisioner := NewTerraformDockerProvisioner(mockRuntime)
+	if err := provisioner.Provision(spec, false); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	mockRuntime.AssertNumberOfCalls(t, "RunContainer", 2)
+}
+
+func TestTerraformDockerProvisioner_Resources(t *testing.T) {
+	spec := &blueprint.Spec{
+		Scaffold: blueprint.Scaffold{
+			Destination: t.TempDir(),
+		},
+		Cloud: blueprint.CloudProvider{
+			Region: "us-east-1",
+		},
+		Provision: blueprint.Provision{
+			Resources: blueprint.ContainerResources{Memory: "512m", CPUs: 2},
+		},
+	}
+
+	mockRuntime := new(MockContainerRuntime)
+	mockRuntime.On("PullImage", mock.Anything, TerraformDockerImage).Return(nil)
+	mockRuntime.On("RunContainer", mock.Anything, mock.MatchedBy(func(opts runtimePkg.RunOptions) bool {
+		return opts.Memory == 512*1024*1024 && opts.CPUs == 2
+	})).Return(&MockReadCloser{data: []byte("ok")}, nil)
+
+	provisioner := NewTerraformDockerProvisioner(mockRuntime)
+	if err := provisioner.Provision(spec, false); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	mockRuntime.AssertNumberOfCalls(t, "RunContainer", 2)
+
+	// The equivalent docker run commands carry the same limits
+	commands, err := DockerRunCommands(spec, false)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if !strings.Contains(commands[0], "--memory 536870912 --cpus 2") {
+		t.Errorf("Expected the resource limits in the docker run command, got:\n%s", commands[0])
+	}
+}
+
+func TestTerraformDockerProvisioner_AWSProfile(t *testing.T) {
+	tests := []struct {
+		name        string
+		profile     string
+		wantProfile bool
+	}{
+		{name: "named profile", profile: "staging", wantProfile: true},
+		{name: "default profile", profile: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := &blueprint.Spec{
+				Scaffold: blueprint.Scaffold{
+					Destination: t.TempDir(),
+				},
+				Cloud: blueprint.CloudProvider{
+					Region:  "us-east-1",
+					Profile: tt.profile,
+				},
+			}
+
+			mockRuntime := new(MockContainerRuntime)
+			mockRuntime.On("PullImage", mock.Anything, TerraformDockerImage).Return(nil)
+			mockRuntime.On("RunContainer", mock.Anything, mock.MatchedBy(func(opts runtimePkg.RunOptions) bool {
+				profile, hasProfile := opts.EnvVars["AWS_PROFILE"]
+				return hasProfile == tt.wantProfile && profile == tt.profile &&
+					opts.EnvVars["AWS_SHARED_CREDENTIALS_FILE"] == "/home/terraform/.aws/credentials"
+			})).Return(&MockReadCloser{data: []byte("ok")}, nil)
+
+			provisioner := NewTerraformDockerProvisioner(mockRuntime)
+			if err := provisioner.Provision(spec, false); err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			mockRuntime.AssertNumberOfCalls(t, "RunContainer", 2)
+		})
+	}
+}
+
+func TestTerraformDockerProvisioner_ParallelSetup(t *testing.T) {
+	scaffoldDir := t.TempDir()
+	spec := &blueprint.Spec{
+		Scaffold: blueprint.Scaffold{
+			Destination: scaffoldDir,
+		},
+		Cloud: blueprint.CloudProvider{
+			Region: "us-east-1",
+		},
+		Provision: blueprint.Provision{
+			Parallel: true,
+		},
+	}
+
+	mockRuntime := new(MockContainerRuntime)
+	mockRuntime.On("PullImage", mock.Anything, TerraformDockerImage).Return(nil)
+	mockRuntime.On("RunContainer", mock.Anything, mock.MatchedBy(func(opts runtimePkg.RunOptions) bool {
+		// Options from the setup work must be complete when terraform runs
+		return opts.VolumeMounts[scaffoldDir] == WorkingDirectory && opts.EnvVars["AWS_REGION"] == "us-east-1"
+	})).Return(&MockReadCloser{data: []byte("ok")}, nil)
+
+	provisioner := NewTerraformDockerProvisioner(mockRuntime)
+	if err := provisioner.Provision(spec, false); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	mockRuntime.AssertCalled(t, "PullImage", mock.Anything, TerraformDockerImage)
+	mockRuntime.AssertNumberOfCalls(t, "RunContainer", 2)
+}
+
+func TestTerraformDockerProvisioner_ParallelPullErrorAborts(t *testing.T) {
+	spec := &blueprint.Spec{
+		Scaffold: blueprint.Scaffold{
+			Destination: t.TempDir(),
+		},
+		Cloud: blueprint.CloudProvider{
+			Region: "us-east-1",
+		},
+		Provision: blueprint.Provision{
+			Parallel: true,
+		},
+	}
+
+	mockRuntime := new(MockContainerRuntime)
+	mockRuntime.On("PullImage", mock.Anything, TerraformDockerImage).Return(errors.New("failed to pull image"))
+
+	provisioner := NewTerraformDockerProvisioner(mockRuntime)
+	err := provisioner.Provision(spec, false)
+	if err == nil || !strings.Contains(err.Error(), "failed to pull Terraform image") {
+		t.Fatalf("Expected pull error, got: %v", err)
+	}
+	mockRuntime.AssertNotCalled(t, "RunContainer", mock.Anything, mock.Anything)
+}
+
+func TestTerraformDockerProvisioner_ParallelSetupErrorAborts(t *testing.T) {
+	t.Setenv("HOME", t.TempDir()) // No AWS credentials directory
+
+	spec := &blueprint.Spec{
+		Scaffold: blueprint.Scaffold{
+			Destination: t.TempDir(),
+		},
+		Cloud: blueprint.CloudProvider{
+			Region: "us-east-1",
+		},
+		Provision: blueprint.Provision{
+			Parallel: true,
+		},
+	}
+
+	mockRuntime := new(MockContainerRuntime)
+	mockRuntime.On("PullImage", mock.Anything, TerraformDockerImage).Return(nil)
+
+	provisioner := NewTerraformDockerProvisioner(mockRuntime)
+	err := provisioner.Provision(spec, false)
+	if err == nil || !strings.Contains(err.Error(), "AWS credentials directory") {
+		t.Fatalf("Expected credentials error, got: %v", err)
+	}
+	mockRuntime.AssertNotCalled(t, "RunContainer", mock.Anything, mock.Anything)
+}
+
+func TestTerraformDockerProvisioner_BackendEnvOnlyOnInit(t *testing.T) {
+	t.Setenv("TEST_BACKEND_SECRET", "backend-secret-key")
+
+	spec := &blueprint.Spec{
+		Scaffold: blueprint.Scaffold{
+			Destination: t.TempDir(),
+		},
+		Cloud: blueprint.CloudProvider{
+			Region: "us-east-1",
+		},
+		Provision: blueprint.Provision{
+			BackendEnv: []blueprint.EnvVar{
+				{Name: "AWS_ACCESS_KEY_ID", Value: "backend-access-key"},
+				{Name: "AWS_SECRET_ACCESS_KEY", FromEnv: "TEST_BACKEND_SECRET"},
+			},
+		},
+	}
+
+	commandEnv := map[string]map[string]string{}
+	mockRuntime := new(MockContainerRuntime)
+	mockRuntime.On("PullImage", mock.Anything, TerraformDockerImage).Return(nil)
+	mockRuntime.On("RunContainer", mock.Anything, mock.MatchedBy(func(opts runtimePkg.RunOptions) bool {
+		commandEnv[opts.Command[0]] = opts.EnvVars
+		return true
+	})).Return(&MockReadCloser{data: []byte("ok")}, nil)
+
+	provisioner := NewTerraformDockerProvisioner(mockRuntime)
+	if err := provisioner.Provision(spec, true); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	initEnv := commandEnv["init"]
+	if initEnv["AWS_ACCESS_KEY_ID"] != "backend-access-key" || initEnv["AWS_SECRET_ACCESS_KEY"] != "backend-secret-key" {
+		t.Errorf("Expected backend env vars on init, got: %v", initEnv)
+	}
+	if initEnv["AWS_REGION"] != "us-east-1" {
+		t.Errorf("Expected provisioning env vars to be kept on init, got: %v", initEnv)
+	}
+
+	for _, command := range []string{"plan", "apply"} {
+		env, ok := commandEnv[command]
+		if !ok {
+			t.Fatalf("Expected terraform %s to run", command)
+		}
+		if _, found := env["AWS_ACCESS_KEY_ID"]; found {
+			t.Errorf("Expected no backend access key on %s, got: %v", command, env)
+		}
+		if _, found := env["AWS_SECRET_ACCESS_KEY"]; found {
+			t.Errorf("Expected no backend secret on %s, got: %v", command, env)
+		}
+	}
+}
+
+func TestTerraformDockerProvisioner_BackendEnvMissingHostVariable(t *testing.T) {
+	spec := &blueprint.Spec{
+		Scaffold: blueprint.Scaffold{
+			Destination: t.TempDir(),
+		},
+		Cloud: blueprint.CloudProvider{
+			Region: "us-east-1",
+		},
+		Provision: blueprint.Provision{
+			BackendEnv: []blueprint.EnvVar{
+				{Name: "AWS_SECRET_ACCESS_KEY", FromEnv: "KLONEKIT_TEST_UNSET_VARIABLE"},
+			},
+		},
+	}
+
+	mockRuntime := new(MockContainerRuntime)
+	mockRuntime.On("PullImage", mock.Anything, TerraformDockerImage).Return(nil)
+
+	provisioner := NewTerraformDockerProvisioner(mockRuntime)
+	err := provisioner.Provision(spec, false)
+	if err == nil || !strings.Contains(err.Error(), "KLONEKIT_TEST_UNSET_VARIABLE is not set") {
+		t.Fatalf("Expected missing host variable error, got: %v", err)
+	}
+	mockRuntime.AssertNotCalled(t, "RunContainer", mock.Anything, mock.Anything)
+}
+
+func TestTerraformDockerProvisioner_Env(t *testing.T) {
+	t.Setenv("TEST_GITHUB_TOKEN", "github-token")
+
+	spec := &blueprint.Spec{
+		Scaffold: blueprint.Scaffold{
+			Destination: t.TempDir(),
+		},
+		Cloud: blueprint.CloudProvider{
+			Region: "us-east-1",
+		},
+		Provision: blueprint.Provision{
+			Env: []blueprint.EnvVar{
+				{Name: "HTTPS_PROXY", Value: "http://proxy.internal:3128"},
+				{Name: "TF_VAR_github_token", FromEnv: "TEST_GITHUB_TOKEN"},
+				{Name: "AWS_REGION", Value: "eu-west-1"},
+				{Name: "AWS_SHARED_CREDENTIALS_FILE", Value: "/tmp/credentials"},
+			},
+		},
+	}
+
+	var envs []map[string]string
+	mockRuntime := new(MockContainerRuntime)
+	mockRuntime.On("PullImage", mock.Anything, TerraformDockerImage).Return(nil)
+	mockRuntime.On("RunContainer", mock.Anything, mock.MatchedBy(func(opts runtimePkg.RunOptions) bool {
+		envs = append(envs, opts.EnvVars)
+		return true
+	})).Return(&MockReadCloser{data: []byte("ok")}, nil)
+
+	provisioner := NewTerraformDockerProvisioner(mockRuntime)
+	if err := provisioner.Provision(spec, false); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	want := map[string]string{
+		"HTTPS_PROXY":                 "http://proxy.internal:3128",
+		"TF_VAR_github_token":         "github-token",
+		"AWS_REGION":                  "eu-west-1",
+		"AWS_DEFAULT_REGION":          "us-east-1",
+		"AWS_SHARED_CREDENTIALS_FILE": "/home/terraform/.aws/credentials",
+		"AWS_CONFIG_FILE":             "/home/terraform/.aws/config",
+	}
+	if len(envs) != 2 {
+		t.Fatalf("Expected init and plan to run, got %d commands", len(envs))
+	}
+	for _, env := range envs {
+		for key, value := range want {
+			if env[key] != value {
+				t.Errorf("Expected %s=%s, got %q", key, value, env[key])
+			}
+		}
+	}
+}
+
+func TestTerraformDockerProvisioner_TfvarsVarFile(t *testing.T) {
+	tests := []struct {
+		name     string
+		filename string
+		wantArg  string
+	}{
+		{name: "default is auto-loaded", filename: "", wantArg: ""},
+		{name: "auto tfvars is auto-loaded", filename: "prod.auto.tfvars", wantArg: ""},
+		{name: "custom name is passed", filename: "prod.tfvars", wantArg: "-var-file=prod.tfvars"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scaffoldDir := t.TempDir()
+			spec := &blueprint.Spec{
+				Scaffold: blueprint.Scaffold{
+					Destination:    scaffoldDir,
+					TfvarsFilename: tt.filename,
+				},
+			}
+			if err := os.WriteFile(filepath.Join(scaffoldDir, spec.Scaffold.TfvarsFile()), []byte(`region = "us-east-1"`), 0600); err != nil {
+				t.Fatal(err)
+			}
+
+			commands := map[string][]string{}
+			mockRuntime := new(MockContainerRuntime)
+			mockRuntime.On("PullImage", mock.Anything, TerraformDockerImage).Return(nil)
+			mockRuntime.On("RunContainer", mock.Anything, mock.MatchedBy(func(opts runtimePkg.RunOptions) bool {
+				commands[opts.Command[0]] = opts.Command
+				return true
+			})).Return(&MockReadCloser{data: []byte("ok")}, nil)
+
+			provisioner := NewTerraformDockerProvisioner(mockRuntime)
+			if err := provisioner.Provision(spec, true); err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+
+			for _, command := range []string{"plan", "apply"} {
+				hasArg := false
+				for _, arg := range commands[command] {
+					if strings.HasPrefix(arg, "-var-file=") {
+						hasArg = arg == tt.wantArg
+						if !hasArg {
+							t.Errorf("Unexpected %s argument on %s", arg, command)
+						}
+					}
+				}
+				if tt.wantArg != "" && !hasArg {
+					t.Errorf("Expected %s on %s, got: %v", tt.wantArg, command, commands[command])
+				}
+			}
+			for _, arg := range commands["init"] {
+				if strings.HasPrefix(arg, "-var-file=") {
+					t.Errorf("Expected no -var-file on init, got: %v", commands["init"])
+				}
+			}
+		})
+	}
+}
+
+func TestTerraformImage(t *testing.T) {
+	original := ProviderImages
+	defer func() { ProviderImages = original }()
+	ProviderImages = map[string]string{
+		"aws": "example.com/terraform-aws:1.8.0",
+	}
+
+	tests := []struct {
+		name     string
+		provider string
+		override string
+		engine   string
+		version  string
+		want     string
+	}{
+		{name: "provider mapping", provider: "aws", want: "example.com/terraform-aws:1.8.0"},
+		{name: "unmapped provider uses generic image", provider: "gcp", want: TerraformDockerImage},
+		{name: "explicit image wins", provider: "aws", override: "example.com/custom:1.0", want: "example.com/custom:1.0"},
+		{name: "terraform version selects the official image", provider: "aws", version: "1.5.7", want: "hashicorp/terraform:1.5.7"},
+		{name: "opentofu ignores the provider mapping", provider: "aws", engine: "opentofu", want: "ghcr.io/opentofu/opentofu:1.8.0"},
+		{name: "opentofu version", provider: "aws", engine: "opentofu", version: "1.7.3", want: "ghcr.io/opentofu/opentofu:1.7.3"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := &blueprint.Spec{
+				Cloud:     blueprint.CloudProvider{Provider: tt.provider},
+				Provision: blueprint.Provision{Image: tt.override, Engine: tt.engine, Terraform: blueprint.TerraformConfig{Version: tt.version}},
+			}
+			if got := TerraformImage(spec); got != tt.want {
+				t.Errorf("Expected image %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestTerraformImage_VersionFile(t *testing.T) {
+	tests := []struct {
+		name     string
+		file     string
+		override string
+		engine   string
+		version  string
+		want     string
+	}{
+		{name: "version file", file: "1.5.7\n", want: "hashicorp/terraform:1.5.7"},
+		{name: "version file with v prefix", file: "v1.6.2", want: "hashicorp/terraform:1.6.2"},
+		{name: "blueprint version wins", file: "1.5.7", version: "1.7.0", want: "hashicorp/terraform:1.7.0"},
+		{name: "explicit image wins", file: "1.5.7", override: "example.com/custom:1.0", want: "example.com/custom:1.0"},
+		{name: "inexact version ignored", file: "latest:^1.5", want: TerraformDockerImage},
+		{name: "opentofu ignores the file", file: "1.5.7", engine: "opentofu", want: "ghcr.io/opentofu/opentofu:1.8.0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := t.TempDir()
+			if err := os.WriteFile(filepath.Join(source, TerraformVersionFile), []byte(tt.file), 0644); err != nil {
+				t.Fatal(err)
+			}
+			spec := &blueprint.Spec{
+				Cloud:     blueprint.CloudProvider{Provider: "gcp"},
+				Scaffold:  blueprint.Scaffold{Source: source},
+				Provision: blueprint.Provision{Image: tt.override, Engine: tt.engine, Terraform: blueprint.TerraformConfig{Version: tt.version}},
+			}
+			if got := TerraformImage(spec); got != tt.want {
+				t.Errorf("Expected image %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestTerraformDockerProvisioner_VersionFile(t *testing.T) {
+	source := t.TempDir()
+	if err := os.WriteFile(filepath.Join(source, TerraformVersionFile), []byte("1.5.7\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	spec := &blueprint.Spec{
+		Scaffold:  blueprint.Scaffold{Source: source, Destination: t.TempDir()},
+		Cloud:     blueprint.CloudProvider{Provider: "aws", Region: "us-east-1"},
+		Provision: blueprint.Provision{SkipPermissionFix: true},
+	}
+
+	mockRuntime := new(MockContainerRuntime)
+	mockRuntime.On("PullImage", mock.Anything, "hashicorp/terraform:1.5.7").Return(nil)
+	mockRuntime.On("RunContainer", mock.Anything, mock.MatchedBy(func(opts runtimePkg.RunOptions) bool {
+		return opts.Image == "hashicorp/terraform:1.5.7"
+	})).Return(&MockReadCloser{data: []byte("ok")}, nil)
+
+	provisioner := NewTerraformDockerProvisioner(mockRuntime)
+	if err := provisioner.Provision(spec, false); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	mockRuntime.AssertNotCalled(t, "PullImage", mock.Anything, TerraformDockerImage)
+}
+
+func TestTerraformDockerProvisioner_ImageOverride(t *testing.T) {
+	spec := &blueprint.Spec{
+		Scaffold: blueprint.Scaffold{
+			Destination: t.TempDir(),
+		},
+		Cloud: blueprint.CloudProvider{
+			Provider: "aws",
+			Region:   "us-east-1",
+		},
+		Provision: blueprint.Provision{
+			Image: "example.com/terraform-aws-offline:1.8.0",
+		},
+	}
+
+	mockRuntime := new(MockContainerRuntime)
+	mockRuntime.On("PullImage", mock.Anything, "example.com/terraform-aws-offline:1.8.0").Return(nil)
+	mockRuntime.On("RunContainer", mock.Anything, mock.MatchedBy(func(opts runtimePkg.RunOptions) bool {
+		return opts.Image == "example.com/terraform-aws-offline:1.8.0"
+	})).Return(&MockReadCloser{data: []byte("ok")}, nil)
+
+	provisioner := NewTerraformDockerProvisioner(mockRuntime)
+	if err := provisioner.Provision(spec, false); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	mockRuntime.AssertNotCalled(t, "PullImage", mock.Anything, TerraformDockerImage)
+}
+
+func TestTerraformDockerProvisioner_Engine(t *testing.T) {
+	tests := []struct {
+		engine     string
+		wantImage  string
+		wantBinary string
+	}{
+		{engine: "", wantImage: TerraformDockerImage, wantBinary: "terraform"},
+		{engine: "terraform", wantImage: TerraformDockerImage, wantBinary: "terraform"},
+		{engine: "opentofu", wantImage: "ghcr.io/opentofu/opentofu:1.8.0", wantBinary: "tofu"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.engine, func(t *testing.T) {
+			spec := &blueprint.Spec{
+				Scaffold:  blueprint.Scaffold{Destination: t.TempDir()},
+				Cloud:     blueprint.CloudProvider{Provider: "aws", Region: "us-east-1"},
+				Provision: blueprint.Provision{Engine: tt.engine, SkipPermissionFix: true},
+			}
+
+			mockRuntime := new(MockContainerRuntime)
+			mockRuntime.On("PullImage", mock.Anything, tt.wantImage).Return(nil)
+			mockRuntime.On("RunContainer", mock.Anything, mock.MatchedBy(func(opts runtimePkg.RunOptions) bool {
+				return opts.Image == tt.wantImage && len(opts.Entrypoint) == 1 && opts.Entrypoint[0] == tt.wantBinary
+			})).Return(&MockReadCloser{data: []byte("ok")}, nil)
+
+			provisioner := NewTerraformDockerProvisioner(mockRuntime)
+			if err := provisioner.Provision(spec, false); err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			mockRuntime.AssertCalled(t, "PullImage", mock.Anything, tt.wantImage)
+			mockRuntime.AssertNumberOfCalls(t, "PullImage", 1)
+		})
+	}
+}
+
+func TestTerraformDockerProvisioner_TerraformVersion(t *testing.T) {
+	spec := &blueprint.Spec{
+		Scaffold:  blueprint.Scaffold{Destination: t.TempDir()},
+		Cloud:     blueprint.CloudProvider{Provider: "aws", Region: "us-east-1"},
+		Provision: blueprint.Provision{Terraform: blueprint.TerraformConfig{Version: "1.9.0-beta2"}},
+	}
+
+	mockRuntime := new(MockContainerRuntime)
+	mockRuntime.On("PullImage", mock.Anything, "hashicorp/terraform:1.9.0-beta2").Return(nil)
+	mockRuntime.On("RunContainer", mock.Anything, mock.MatchedBy(func(opts runtimePkg.RunOptions) bool {
+		return opts.Image == "hashicorp/terraform:1.9.0-beta2"
+	})).Return(&MockReadCloser{data: []byte("ok")}, nil)
+
+	provisioner := NewTerraformDockerProvisioner(mockRuntime)
+	if err := provisioner.Provision(spec, false); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	mockRuntime.AssertNotCalled(t, "PullImage", mock.Anything, TerraformDockerImage)
+}
+
+func TestTerraformDockerProvisioner_BackendMigration(t *testing.T) {
+	tests := []struct {
+		name      string
+		migration string
+		wantInit  string
+	}{
+		{name: "not configured", wantInit: "init -input=false"},
+		{name: "migrate", migration: blueprint.BackendMigrationMigrate, wantInit: "init -input=false -migrate-state -force-copy"},
+		{name: "reconfigure", migration: blueprint.BackendMigrationReconfigure, wantInit: "init -input=false -reconfigure"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := &blueprint.Spec{
+				Scaffold:  blueprint.Scaffold{Destination: t.TempDir()},
+				Cloud:     blueprint.CloudProvider{Region: "us-east-1"},
+				Provision: blueprint.Provision{BackendMigration: tt.migration, SkipPermissionFix: true},
+			}
+
+			var commands []string
+			if err := NewTerraformDockerProvisioner(recordCommands(&commands)).Provision(spec, false); err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			if len(commands) == 0 || commands[0] != tt.wantInit {
+				t.Errorf("Expected %q, got commands %v", tt.wantInit, commands)
+			}
+		})
+	}
+}
@@ -0,0 +1,147 @@
+package provisioner
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"klonekit/pkg/blueprint"
+	"klonekit/pkg/runtime"
+)
+
+// dockerRunVisibleEnv are the container environment variables whose values docker run
+// commands show. All others, such as those of spec.provision.env and backendEnv, can hold
+// secrets and are masked.
+var dockerRunVisibleEnv = map[string]bool{
+	"AWS_SHARED_CREDENTIALS_FILE": true,
+	"AWS_CONFIG_FILE":             true,
+	"AWS_DEFAULT_REGION":          true,
+	"AWS_REGION":                  true,
+	"AWS_PROFILE":                 true,
+	"TF_DATA_DIR":                 true,
+}
+
+// DockerRunCommands returns the docker run commands equivalent to the Terraform commands
+// Provision runs for the spec: init, plan and, with autoApprove, apply. They are built from
+// the same container options, so a dry run can show how to reproduce a run by hand. Secret
+// environment variables and the values of -var flags are masked. The credential check,
+// matrix workspaces and the commands run around plan (e.g. the plan JSON export) are left out.
+func DockerRunCommands(spec *blueprint.Spec, autoApprove bool) ([]string, error) {
+	p := NewTerraformDockerProvisioner(nil)
+
+	absScaffoldDir, err := filepath.Abs(spec.Scaffold.Destination)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get absolute path for scaffold directory: %w", err)
+	}
+	awsCredsDir, err := p.getAWSCredentialsDir()
+	if err != nil {
+		return nil, fmt.Errorf("failed to locate AWS credentials directory: %w", err)
+	}
+	runOpts, err := p.buildRunOptions(spec, absScaffoldDir, awsCredsDir)
+	if err != nil {
+		return nil, err
+	}
+	initOpts, err := withBackendEnv(runOpts, spec.Provision.BackendEnv)
+	if err != nil {
+		return nil, err
+	}
+	// A dry run didn't write the tfvars file, so its argument comes from the spec
+	varArgs, err := withVarFlags(spec, scaffoldedTfvarsArgs(spec))
+	if err != nil {
+		return nil, err
+	}
+
+	commands := []string{
+		dockerRunCommand(initOpts, false, initArgs(spec)),
+		dockerRunCommand(runOpts, false, planArgs(spec, varArgs)),
+	}
+	if autoApprove {
+		commands = append(commands, dockerRunCommand(runOpts, true, append([]string{"apply", "-auto-approve"}, varArgs...)))
+	}
+	return commands, nil
+}
+
+// scaffoldedTfvarsArgs returns the -var-file arguments for the tfvars file the scaffold stage
+// writes for the spec, without looking for the file as tfvarsArgs does.
+func scaffoldedTfvarsArgs(spec *blueprint.Spec) []string {
+	if len(spec.Variables) == 0 || !spec.Provision.WritesTfvars() || spec.Scaffold.TfvarsAutoLoaded() {
+		return nil
+	}
+	return []string{"-var-file=" + spec.Scaffold.TfvarsFile()}
+}
+
+// dockerRunCommand renders the docker run command line running the Terraform command args
+// with opts, as runTerraformCommand would. Mounts and environment variables are sorted for
+// stable output.
+func dockerRunCommand(opts runtime.RunOptions, retainContainer bool, args []string) string {
+	command := []string{"docker", "run"}
+	if !retainContainer {
+		command = append(command, "--rm")
+	}
+	if opts.ContainerName != "" {
+		command = append(command, "--name", opts.ContainerName)
+	}
+	if opts.NetworkMode != "" && opts.NetworkMode != runtime.DefaultNetworkMode {
+		command = append(command, "--network", opts.NetworkMode)
+	}
+	if opts.Memory > 0 {
+		command = append(command, "--memory", strconv.FormatInt(opts.Memory, 10))
+	}
+	if opts.CPUs > 0 {
+		command = append(command, "--cpus", strconv.FormatFloat(opts.CPUs, 'f', -1, 64))
+	}
+	if opts.User != "" {
+		command = append(command, "--user", opts.User)
+	}
+	if opts.WorkingDirectory != "" {
+		command = append(command, "--workdir", opts.WorkingDirectory)
+	}
+
+	hostPaths := make([]string, 0, len(opts.VolumeMounts))
+	for hostPath := range opts.VolumeMounts {
+		hostPaths = append(hostPaths, hostPath)
+	}
+	sort.Strings(hostPaths)
+	for _, hostPath := range hostPaths {
+		command = append(command, "--volume", hostPath+":"+opts.VolumeMounts[hostPath])
+	}
+
+	names := make([]string, 0, len(opts.EnvVars))
+	for name := range opts.EnvVars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		value := opts.EnvVars[name]
+		if !dockerRunVisibleEnv[name] {
+			value = maskedVarValue
+		}
+		command = append(command, "--env", name+"="+value)
+	}
+
+	// docker run takes a single entrypoint executable; its arguments go before the command
+	entrypointArgs := opts.Entrypoint
+	if len(entrypointArgs) > 0 {
+		command = append(command, "--entrypoint", entrypointArgs[0])
+		entrypointArgs = entrypointArgs[1:]
+	}
+	command = append(command, opts.Image)
+	command = append(command, entrypointArgs...)
+	command = append(command, maskVarFlags(args)...)
+
+	quoted := make([]string, len(command))
+	for i, arg := range command {
+		quoted[i] = shellQuote(arg)
+	}
+	return strings.Join(quoted, " ")
+}
+
+// shellQuote quotes arg for a POSIX shell when it contains anything but plain word characters.
+func shellQuote(arg string) string {
+	if arg != "" && strings.Trim(arg, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-_./:=,@%+") == "" {
+		return arg
+	}
+	return "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+}
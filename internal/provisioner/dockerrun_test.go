@@ -0,0 +1,137 @@
+package provisioner
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"klonekit/pkg/blueprint"
+	runtimePkg "klonekit/pkg/runtime"
+)
+
+func TestDockerRunCommands(t *testing.T) {
+	scaffoldDir := t.TempDir()
+	dataDir := filepath.Join(t.TempDir(), "tf-data")
+	t.Setenv("KLONEKIT_TEST_BACKEND_SECRET", "backend-s3cr3t")
+	spec := &blueprint.Spec{
+		Scaffold: blueprint.Scaffold{Destination: scaffoldDir},
+		Cloud:    blueprint.CloudProvider{Provider: "aws", Region: "eu-west-1", Profile: "staging"},
+		Variables: map[string]interface{}{
+			"db_password": "hunter2",
+		},
+		Provision: blueprint.Provision{
+			DataDir:       dataDir,
+			NetworkMode:   "host",
+			VariablesMode: blueprint.VariablesModeFlags,
+			Env: []blueprint.EnvVar{
+				{Name: "AWS_ACCESS_KEY_ID", Value: "AKIAEXAMPLE"},
+				{Name: "AWS_SECRET_ACCESS_KEY", Value: "wJalrXUtnFEMI"},
+			},
+			BackendEnv: []blueprint.EnvVar{{Name: "BACKEND_TOKEN", FromEnv: "KLONEKIT_TEST_BACKEND_SECRET"}},
+		},
+	}
+
+	commands, err := DockerRunCommands(spec, true)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if len(commands) != 3 {
+		t.Fatalf("Expected init, plan and apply commands, got %d:\n%s", len(commands), strings.Join(commands, "\n"))
+	}
+	initCommand, planCommand, applyCommand := commands[0], commands[1], commands[2]
+
+	awsDir := filepath.Join(os.Getenv("HOME"), ".aws")
+	for _, want := range []string{
+		"docker run --rm --name klonekit-terraform-",
+		"--network host",
+		"--user " + getCurrentUserID(),
+		"--workdir " + WorkingDirectory,
+		"--volume " + scaffoldDir + ":" + WorkingDirectory,
+		"--volume " + awsDir + ":/home/terraform/.aws",
+		"--volume " + dataDir + ":" + TerraformDataDirectory,
+		"--env AWS_REGION=eu-west-1",
+		"--env AWS_PROFILE=staging",
+		"--env TF_DATA_DIR=" + TerraformDataDirectory,
+		"--env 'AWS_SECRET_ACCESS_KEY=***'",
+		"--env 'AWS_ACCESS_KEY_ID=***'",
+		"--entrypoint terraform " + TerraformDockerImage + " plan -var 'db_password=***'",
+	} {
+		if !strings.Contains(planCommand, want) {
+			t.Errorf("Expected the plan command to contain %q, got:\n%s", want, planCommand)
+		}
+	}
+
+	// Secrets never appear, and backend credentials are only passed to init
+	for _, command := range commands {
+		for _, secret := range []string{"wJalrXUtnFEMI", "AKIAEXAMPLE", "hunter2", "backend-s3cr3t"} {
+			if strings.Contains(command, secret) {
+				t.Errorf("Expected %q to be masked, got:\n%s", secret, command)
+			}
+		}
+	}
+	if !strings.Contains(initCommand, "--env 'BACKEND_TOKEN=***'") || !strings.HasSuffix(initCommand, " init -input=false") {
+		t.Errorf("Expected the init command with the backend environment, got:\n%s", initCommand)
+	}
+	if strings.Contains(planCommand, "BACKEND_TOKEN") {
+		t.Errorf("Expected no backend environment in the plan command, got:\n%s", planCommand)
+	}
+	if strings.Contains(applyCommand, "--rm") || !strings.HasSuffix(applyCommand, " apply -auto-approve -var 'db_password=***'") {
+		t.Errorf("Expected the apply command to retain its container, got:\n%s", applyCommand)
+	}
+
+	// Building the commands has no side effects
+	if _, err := os.Stat(dataDir); !os.IsNotExist(err) {
+		t.Error("Expected the data directory not to be created")
+	}
+}
+
+func TestDockerRunCommands_ValidateOnly(t *testing.T) {
+	spec := &blueprint.Spec{
+		Scaffold: blueprint.Scaffold{Destination: t.TempDir()},
+		Cloud:    blueprint.CloudProvider{Provider: "aws", Region: "us-east-1"},
+	}
+
+	commands, err := DockerRunCommands(spec, false)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if len(commands) != 2 || !strings.HasSuffix(commands[1], TerraformDockerImage+" plan") {
+		t.Errorf("Expected only init and plan commands, got:\n%s", strings.Join(commands, "\n"))
+	}
+	if strings.Contains(commands[1], "--network") {
+		t.Errorf("Expected no --network flag for the default network, got:\n%s", commands[1])
+	}
+}
+
+func TestDockerRunCommands_CustomTfvarsFileNotYetWritten(t *testing.T) {
+	// In a dry run the scaffold stage didn't write the tfvars file
+	spec := &blueprint.Spec{
+		Scaffold:  blueprint.Scaffold{Destination: t.TempDir(), TfvarsFilename: "prod.tfvars"},
+		Cloud:     blueprint.CloudProvider{Provider: "aws", Region: "us-east-1"},
+		Variables: map[string]interface{}{"region": "us-east-1"},
+	}
+
+	commands, err := DockerRunCommands(spec, true)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if !strings.HasSuffix(commands[1], " plan -var-file=prod.tfvars") || !strings.HasSuffix(commands[2], " apply -auto-approve -var-file=prod.tfvars") {
+		t.Errorf("Expected plan and apply to pass the tfvars file, got:\n%s", strings.Join(commands, "\n"))
+	}
+}
+
+func TestDockerRunCommand_Quoting(t *testing.T) {
+	opts := runtimePkg.RunOptions{
+		Image:        "registry.example.com/terraform:1.8",
+		Entrypoint:   []string{"/usr/local/bin/tf-wrapper", "--log", "it's verbose"},
+		VolumeMounts: map[string]string{"/home/me/my infra": WorkingDirectory},
+	}
+
+	got := dockerRunCommand(opts, false, []string{"plan"})
+
+	want := `docker run --rm --volume '/home/me/my infra:/workspace' --entrypoint /usr/local/bin/tf-wrapper registry.example.com/terraform:1.8 --log 'it'\''s verbose' plan`
+	if got != want {
+		t.Errorf("Expected:\n%s\ngot:\n%s", want, got)
+	}
+}
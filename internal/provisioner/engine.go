@@ -0,0 +1,84 @@
+package provisioner
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"path"
+
+	"klonekit/pkg/blueprint"
+	"klonekit/pkg/runtime"
+)
+
+const (
+	// EngineTerraform provisions with HashiCorp Terraform (the default)
+	EngineTerraform = "terraform"
+
+	// EngineOpenTofu provisions with OpenTofu
+	EngineOpenTofu = "opentofu"
+
+	// OpenTofuImageRepository is the official OpenTofu Docker image
+	OpenTofuImageRepository = "ghcr.io/opentofu/opentofu"
+
+	// DefaultOpenTofuVersion is the OpenTofu version used when spec.provision.terraform.version is unset
+	DefaultOpenTofuVersion = "1.8.0"
+)
+
+// EngineBinary returns the name of the CLI binary of the spec's provisioning engine.
+func EngineBinary(spec *blueprint.Spec) string {
+	if spec.Provision.Engine == EngineOpenTofu {
+		return "tofu"
+	}
+	return "terraform"
+}
+
+// containerEntrypoint returns the command Terraform commands run in the container:
+// spec.provision.entrypoint when set, or else the engine binary, run explicitly rather than
+// relying on the image's entrypoint.
+func containerEntrypoint(spec *blueprint.Spec) []string {
+	if len(spec.Provision.Entrypoint) > 0 {
+		return spec.Provision.Entrypoint
+	}
+	return []string{EngineBinary(spec)}
+}
+
+// checkImageEntrypoint inspects the entrypoint of the pulled Terraform image, when the
+// container runtime can, since the engine binary replaces it. An image built to run the
+// other engine's binary fails the run, as the binary most likely isn't in it; any other
+// entrypoint, such as a shell or a wrapper script, is bypassed and only warned about.
+// Nothing is checked when spec.provision.entrypoint is set.
+func (p *TerraformDockerProvisioner) checkImageEntrypoint(ctx context.Context, spec *blueprint.Spec, image string) error {
+	if len(spec.Provision.Entrypoint) > 0 {
+		return nil
+	}
+	inspector, ok := p.containerRuntime.(runtime.ImageInspector)
+	if !ok {
+		return nil
+	}
+	info, err := inspector.ImageInspect(ctx, image)
+	if err != nil {
+		slog.Warn("Failed to inspect the Terraform image entrypoint", "image", image, "error", err)
+		return nil
+	}
+	if len(info.Entrypoint) == 0 {
+		return nil
+	}
+
+	binary := EngineBinary(spec)
+	switch path.Base(info.Entrypoint[0]) {
+	case binary:
+		return nil
+	case "terraform", "tofu":
+		return fmt.Errorf("terraform image %s runs %s, but the provisioning engine runs %s: set spec.provision.engine to match the image, or spec.provision.entrypoint to the command to run", image, info.Entrypoint[0], binary)
+	}
+	slog.Warn("Terraform image entrypoint is not a terraform or tofu binary; it is bypassed to run the engine binary, set spec.provision.entrypoint to run something else",
+		"image", image, "entrypoint", info.Entrypoint, "binary", binary)
+	return nil
+}
+
+// commandLine returns the command run by opts with args, for logging.
+func commandLine(opts runtime.RunOptions, args []string) []string {
+	command := make([]string, 0, len(opts.Entrypoint)+len(args))
+	command = append(command, opts.Entrypoint...)
+	return append(command, args...)
+}
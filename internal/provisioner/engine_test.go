@@ -0,0 +1,137 @@
+package provisioner
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+
+	"klonekit/pkg/blueprint"
+	runtimePkg "klonekit/pkg/runtime"
+)
+
+// MockImageInspector is a container runtime that can also inspect images
+type MockImageInspector struct {
+	MockContainerRuntime
+}
+
+func (m *MockImageInspector) ImageInspect(ctx context.Context, image string) (runtimePkg.ImageInfo, error) {
+	args := m.Called(ctx, image)
+	return args.Get(0).(runtimePkg.ImageInfo), args.Error(1)
+}
+
+// inspectingRuntime returns a runtime whose Terraform image has the given entrypoint and
+// that records the entrypoint of each command
+func inspectingRuntime(entrypoint []string, ran *[]string) *MockImageInspector {
+	mockRuntime := new(MockImageInspector)
+	mockRuntime.On("PullImage", mock.Anything, mock.Anything).Return(nil)
+	mockRuntime.On("ImageInspect", mock.Anything, mock.Anything).Return(runtimePkg.ImageInfo{Entrypoint: entrypoint}, nil)
+	mockRuntime.On("RunContainer", mock.Anything, mock.MatchedBy(func(opts runtimePkg.RunOptions) bool {
+		*ran = append(*ran, strings.Join(opts.Entrypoint, " "))
+		return true
+	})).Return(&MockReadCloser{data: []byte("ok")}, nil)
+	return mockRuntime
+}
+
+// provisionWithLogs runs Provision without applying and returns the logs it wrote
+func provisionWithLogs(t *testing.T, provisioner *TerraformDockerProvisioner, spec *blueprint.Spec) (string, error) {
+	t.Helper()
+	// The default slog handler writes through the standard logger
+	var logs bytes.Buffer
+	previous := log.Writer()
+	log.SetOutput(&logs)
+	defer log.SetOutput(previous)
+	err := provisioner.Provision(spec, false)
+	return logs.String(), err
+}
+
+func TestTerraformDockerProvisioner_ImageEntrypoint(t *testing.T) {
+	tests := []struct {
+		name       string
+		engine     string
+		entrypoint []string
+		wantWarn   bool
+		wantErr    string
+	}{
+		{name: "terraform", entrypoint: []string{"/bin/terraform"}},
+		{name: "tofu", engine: "opentofu", entrypoint: []string{"/usr/local/bin/tofu"}},
+		{name: "no entrypoint"},
+		{name: "shell", entrypoint: []string{"/bin/sh", "-c"}, wantWarn: true},
+		{name: "wrapper script", entrypoint: []string{"/docker-entrypoint.sh"}, wantWarn: true},
+		{name: "other engine", engine: "opentofu", entrypoint: []string{"/bin/terraform"}, wantErr: "runs /bin/terraform, but the provisioning engine runs tofu"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := &blueprint.Spec{
+				Scaffold:  blueprint.Scaffold{Destination: t.TempDir()},
+				Provision: blueprint.Provision{Engine: tt.engine, SkipPermissionFix: true},
+			}
+
+			var ran []string
+			logs, err := provisionWithLogs(t, NewTerraformDockerProvisioner(inspectingRuntime(tt.entrypoint, &ran)), spec)
+
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Expected an error containing %q, got: %v", tt.wantErr, err)
+				}
+				if len(ran) != 0 {
+					t.Errorf("Expected no Terraform command to run, got %v", ran)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			if warned := strings.Contains(logs, "Terraform image entrypoint is not a terraform or tofu binary"); warned != tt.wantWarn {
+				t.Errorf("Expected entrypoint warning %v, got logs:\n%s", tt.wantWarn, logs)
+			}
+		})
+	}
+}
+
+func TestTerraformDockerProvisioner_EntrypointOverride(t *testing.T) {
+	spec := &blueprint.Spec{
+		Scaffold:  blueprint.Scaffold{Destination: t.TempDir()},
+		Provision: blueprint.Provision{Entrypoint: []string{"/usr/local/bin/tf-wrapper", "--"}, SkipPermissionFix: true},
+	}
+
+	var ran []string
+	mockRuntime := inspectingRuntime([]string{"/bin/sh", "-c"}, &ran)
+	logs, err := provisionWithLogs(t, NewTerraformDockerProvisioner(mockRuntime), spec)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	mockRuntime.AssertNotCalled(t, "ImageInspect", mock.Anything, mock.Anything)
+	if strings.Contains(logs, "entrypoint is not") {
+		t.Errorf("Expected no entrypoint warning with an override, got logs:\n%s", logs)
+	}
+	if len(ran) == 0 || ran[0] != "/usr/local/bin/tf-wrapper --" {
+		t.Errorf("Expected the commands to run the override, got %v", ran)
+	}
+}
+
+func TestTerraformDockerProvisioner_ImageInspectFailure(t *testing.T) {
+	spec := &blueprint.Spec{
+		Scaffold:  blueprint.Scaffold{Destination: t.TempDir()},
+		Provision: blueprint.Provision{SkipPermissionFix: true},
+	}
+
+	mockRuntime := new(MockImageInspector)
+	mockRuntime.On("PullImage", mock.Anything, mock.Anything).Return(nil)
+	mockRuntime.On("ImageInspect", mock.Anything, mock.Anything).Return(runtimePkg.ImageInfo{}, errors.New("no such image"))
+	mockRuntime.On("RunContainer", mock.Anything, mock.Anything).Return(&MockReadCloser{data: []byte("ok")}, nil)
+
+	logs, err := provisionWithLogs(t, NewTerraformDockerProvisioner(mockRuntime), spec)
+	if err != nil {
+		t.Fatalf("Expected a failed inspect not to fail provisioning, got: %s", err)
+	}
+	if !strings.Contains(logs, "Failed to inspect the Terraform image entrypoint") {
+		t.Errorf("Expected a warning about the failed inspect, got logs:\n%s", logs)
+	}
+}
@@ -0,0 +1,143 @@
+package provisioner
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"klonekit/pkg/blueprint"
+)
+
+// varFileCommands are Terraform subcommands that read input variables.
+var varFileCommands = map[string]bool{
+	"plan":    true,
+	"apply":   true,
+	"destroy": true,
+}
+
+// mutatingCommands are Terraform subcommands that change infrastructure or state and require
+// explicit opt-in. Nested subcommands are listed with their parent, e.g. "state rm".
+var mutatingCommands = map[string]bool{
+	"apply":                  true,
+	"destroy":                true,
+	"import":                 true,
+	"refresh":                true,
+	"taint":                  true,
+	"untaint":                true,
+	"force-unlock":           true,
+	"state rm":               true,
+	"state mv":               true,
+	"state push":             true,
+	"state replace-provider": true,
+	"workspace delete":       true,
+}
+
+// splitCommand splits Terraform arguments into the global options before the subcommand
+// (e.g. -chdir=DIR), the subcommand and the subcommand's own arguments.
+func splitCommand(args []string) (globals []string, subcommand string, rest []string) {
+	for i, arg := range args {
+		if !strings.HasPrefix(arg, "-") {
+			return args[:i], arg, args[i+1:]
+		}
+	}
+	return args, "", nil
+}
+
+// commandName returns the subcommand as listed in mutatingCommands, including the nested
+// subcommand of state and workspace.
+func commandName(subcommand string, rest []string) string {
+	if subcommand == "state" || subcommand == "workspace" {
+		_, nested, _ := splitCommand(rest)
+		if nested != "" {
+			return subcommand + " " + nested
+		}
+	}
+	return subcommand
+}
+
+// Exec runs an arbitrary Terraform subcommand in the container against the scaffold destination,
+// using the same mounts and credentials as provisioning. A leading "terraform" (or "tofu" with
+// OpenTofu) argument is ignored, and global options may precede the subcommand.
+// Subcommands that change infrastructure or state (see mutatingCommands) are rejected unless
+// allowMutating is true.
+func (p *TerraformDockerProvisioner) Exec(spec *blueprint.Spec, allowMutating bool, args ...string) error {
+	if len(args) > 0 && (args[0] == "terraform" || args[0] == EngineBinary(spec)) {
+		args = args[1:]
+	}
+	globals, subcommand, rest := splitCommand(args)
+	if subcommand == "" {
+		return fmt.Errorf("no terraform subcommand specified")
+	}
+
+	if name := commandName(subcommand, rest); mutatingCommands[name] && !allowMutating {
+		return fmt.Errorf("terraform %s modifies infrastructure or state and is not allowed by exec; pass --allow-mutating to run it", name)
+	}
+
+	ctx := context.Background()
+
+	slog.Info("Executing Terraform subcommand in workspace", "scaffoldDir", spec.Scaffold.Destination, "subcommand", subcommand)
+
+	runOpts, absScaffoldDir, err := p.prepareRun(ctx, spec)
+	if err != nil {
+		return err
+	}
+
+	// Hand the files the containers create back to the host user once done
+	if !spec.Provision.SkipPermissionFix {
+		defer p.fixPermissions(ctx, runOpts)
+	}
+
+	// Like provisioning, only init receives the backend credentials
+	if subcommand == "init" {
+		if runOpts, err = withBackendEnv(runOpts, spec.Provision.BackendEnv); err != nil {
+			return err
+		}
+	}
+
+	// Pass the variables the same way provisioning does, as options before the positional
+	// arguments. A saved plan already holds its variables, and Terraform refuses new ones.
+	if varFileCommands[subcommand] && !(subcommand == "apply" && hasPositional(rest)) {
+		varArgs, err := variableArgs(spec, absScaffoldDir)
+		if err != nil {
+			return err
+		}
+		rest = append(varArgs, rest...)
+	}
+	args = append(append(append([]string{}, globals...), subcommand), rest...)
+
+	if err := p.runTerraformCommand(ctx, runOpts, false, args...); err != nil {
+		return fmt.Errorf("terraform %s failed: %w", subcommand, err)
+	}
+
+	return nil
+}
+
+// valueOptions are options of plan, apply and destroy that may take their value as the next
+// argument, as in -var "name=value".
+var valueOptions = map[string]bool{
+	"-var":          true,
+	"-var-file":     true,
+	"-target":       true,
+	"-replace":      true,
+	"-out":          true,
+	"-state":        true,
+	"-state-out":    true,
+	"-backup":       true,
+	"-lock-timeout": true,
+	"-parallelism":  true,
+}
+
+// hasPositional reports whether args hold an argument that is neither an option nor an
+// option's value, such as the plan file of apply.
+func hasPositional(args []string) bool {
+	for i := 0; i < len(args); i++ {
+		switch {
+		case valueOptions[args[i]]:
+			i++
+		case !strings.HasPrefix(args[i], "-"):
+			return true
+		}
+	}
+	return false
+}
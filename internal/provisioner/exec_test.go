@@ -0,0 +1,158 @@
+package provisioner
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+
+	"klonekit/pkg/blueprint"
+	runtimePkg "klonekit/pkg/runtime"
+)
+
+func TestTerraformDockerProvisioner_Exec(t *testing.T) {
+	scaffoldDir := t.TempDir()
+	spec := &blueprint.Spec{
+		Scaffold: blueprint.Scaffold{
+			Destination: scaffoldDir,
+		},
+		Cloud: blueprint.CloudProvider{
+			Region: "us-east-1",
+		},
+	}
+	awsDir := filepath.Join(os.Getenv("HOME"), ".aws")
+
+	mockRuntime := new(MockContainerRuntime)
+	mockRuntime.On("PullImage", mock.Anything, TerraformDockerImage).Return(nil)
+	mockRuntime.On("RunContainer", mock.Anything, mock.MatchedBy(func(opts runtimePkg.RunOptions) bool {
+		return reflect.DeepEqual(opts.Command, []string{"state", "list"}) &&
+			opts.WorkingDirectory == WorkingDirectory &&
+			opts.VolumeMounts[scaffoldDir] == WorkingDirectory &&
+			opts.VolumeMounts[awsDir] == "/home/terraform/.aws" &&
+			opts.EnvVars["AWS_SHARED_CREDENTIALS_FILE"] == "/home/terraform/.aws/credentials"
+	})).Return(&MockReadCloser{data: []byte("aws_s3_bucket.this")}, nil)
+
+	provisioner := NewTerraformDockerProvisioner(mockRuntime)
+	if err := provisioner.Exec(spec, false, "terraform", "state", "list"); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	mockRuntime.AssertExpectations(t)
+}
+
+func TestTerraformDockerProvisioner_ExecGuardsMutatingCommands(t *testing.T) {
+	spec := &blueprint.Spec{
+		Scaffold: blueprint.Scaffold{
+			Destination: t.TempDir(),
+		},
+		Cloud: blueprint.CloudProvider{
+			Region: "us-east-1",
+		},
+	}
+
+	for _, subcommand := range []string{"apply", "destroy"} {
+		t.Run(subcommand, func(t *testing.T) {
+			mockRuntime := new(MockContainerRuntime)
+			provisioner := NewTerraformDockerProvisioner(mockRuntime)
+
+			err := provisioner.Exec(spec, false, subcommand, "-auto-approve")
+			if err == nil || !strings.Contains(err.Error(), "--allow-mutating") {
+				t.Fatalf("Expected mutating command to be rejected, got: %v", err)
+			}
+			mockRuntime.AssertNotCalled(t, "RunContainer", mock.Anything, mock.Anything)
+
+			mockRuntime.On("PullImage", mock.Anything, TerraformDockerImage).Return(nil)
+			mockRuntime.On("RunContainer", mock.Anything, mock.MatchedBy(func(opts runtimePkg.RunOptions) bool {
+				return opts.Command[0] == subcommand
+			})).Return(&MockReadCloser{data: []byte("ok")}, nil)
+
+			if err := provisioner.Exec(spec, true, subcommand, "-auto-approve"); err != nil {
+				t.Fatalf("Expected mutating command to run when allowed, got: %s", err)
+			}
+			mockRuntime.AssertNumberOfCalls(t, "RunContainer", 1)
+		})
+	}
+}
+
+func TestTerraformDockerProvisioner_ExecGuardsMutatingCommandsAfterGlobalOptions(t *testing.T) {
+	spec := &blueprint.Spec{
+		Scaffold: blueprint.Scaffold{
+			Destination: t.TempDir(),
+		},
+	}
+
+	for _, args := range [][]string{
+		{"-chdir=.", "apply"},
+		{"terraform", "-chdir=.", "destroy", "-auto-approve"},
+		{"import", "aws_s3_bucket.this", "my-bucket"},
+		{"taint", "aws_s3_bucket.this"},
+		{"force-unlock", "-force", "1234"},
+		{"state", "rm", "aws_s3_bucket.this"},
+		{"state", "mv", "aws_s3_bucket.a", "aws_s3_bucket.b"},
+		{"state", "push", "terraform.tfstate"},
+		{"-chdir=.", "workspace", "delete", "staging"},
+	} {
+		t.Run(strings.Join(args, " "), func(t *testing.T) {
+			mockRuntime := new(MockContainerRuntime)
+			provisioner := NewTerraformDockerProvisioner(mockRuntime)
+
+			err := provisioner.Exec(spec, false, args...)
+			if err == nil || !strings.Contains(err.Error(), "--allow-mutating") {
+				t.Fatalf("Expected the command to be rejected, got: %v", err)
+			}
+			mockRuntime.AssertNotCalled(t, "RunContainer", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestTerraformDockerProvisioner_ExecArguments(t *testing.T) {
+	scaffoldDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(scaffoldDir, "prod.tfvars"), []byte(`region = "us-east-1"`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	spec := &blueprint.Spec{
+		Scaffold: blueprint.Scaffold{
+			Destination:    scaffoldDir,
+			TfvarsFilename: "prod.tfvars",
+		},
+	}
+
+	tests := []struct {
+		name          string
+		args          []string
+		allowMutating bool
+		want          []string
+	}{
+		{name: "read-only state command", args: []string{"state", "list"}, want: []string{"state", "list"}},
+		{name: "workspace list", args: []string{"workspace", "list"}, want: []string{"workspace", "list"}},
+		{name: "var file before positional arguments", args: []string{"-chdir=.", "plan", "-out=tfplan"}, want: []string{"-chdir=.", "plan", "-var-file=prod.tfvars", "-out=tfplan"}},
+		{name: "saved plan takes no variables", args: []string{"apply", "tfplan"}, allowMutating: true, want: []string{"apply", "tfplan"}},
+		{name: "var option value isn't a plan file", args: []string{"apply", "-var", "env=prod"}, allowMutating: true, want: []string{"apply", "-var-file=prod.tfvars", "-var", "env=prod"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRuntime := new(MockContainerRuntime)
+			mockRuntime.On("PullImage", mock.Anything, TerraformDockerImage).Return(nil)
+			mockRuntime.On("RunContainer", mock.Anything, mock.MatchedBy(func(opts runtimePkg.RunOptions) bool {
+				return reflect.DeepEqual(opts.Command, tt.want)
+			})).Return(&MockReadCloser{data: []byte("ok")}, nil)
+
+			provisioner := NewTerraformDockerProvisioner(mockRuntime)
+			if err := provisioner.Exec(spec, tt.allowMutating, tt.args...); err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			mockRuntime.AssertNumberOfCalls(t, "RunContainer", 1)
+		})
+	}
+}
+
+func TestTerraformDockerProvisioner_ExecRequiresSubcommand(t *testing.T) {
+	provisioner := NewTerraformDockerProvisioner(new(MockContainerRuntime))
+
+	if err := provisioner.Exec(&blueprint.Spec{}, false, "terraform"); err == nil {
+		t.Error("Expected error when no subcommand is given")
+	}
+}
@@ -0,0 +1,111 @@
+package provisioner
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"klonekit/pkg/blueprint"
+	"klonekit/pkg/runtime"
+)
+
+// matrixVarFileSuffix names the variables file written for each matrix entry.
+const matrixVarFileSuffix = ".matrix.tfvars.json"
+
+// defaultWorkspace is the workspace Terraform starts in, selected again after a matrix run.
+const defaultWorkspace = "default"
+
+// runMatrix runs plan/apply for each matrix entry in its own workspace, created if needed.
+// A failing entry doesn't stop the others; the failures are reported together at the end.
+// The default workspace is selected again afterwards, so later Terraform commands in the
+// scaffold directory don't act on the last entry's workspace.
+func (p *TerraformDockerProvisioner) runMatrix(ctx context.Context, runOpts runtime.RunOptions, spec *blueprint.Spec, absScaffoldDir string, varArgs []string, autoApprove bool) error {
+	if spec.Provision.StatePush.URL != "" {
+		slog.Warn("State push is not supported with a provision matrix and is skipped", "url", spec.Provision.StatePush.URL)
+	}
+	if len(spec.SCM.Project.ExportOutputs) > 0 {
+		slog.Warn("Exporting outputs is not supported with a provision matrix and is skipped", "outputs", len(spec.SCM.Project.ExportOutputs))
+	}
+
+	var failures []string
+	for _, entry := range spec.Provision.Matrix {
+		slog.Info("Provisioning matrix entry", "workspace", entry.Name)
+		if err := p.runMatrixEntry(ctx, runOpts, spec, absScaffoldDir, varArgs, autoApprove, entry); err != nil {
+			slog.Error("Matrix entry failed", "workspace", entry.Name, "error", err)
+			failures = append(failures, fmt.Sprintf("%s: %v", entry.Name, err))
+			continue
+		}
+		slog.Info("Matrix entry completed successfully", "workspace", entry.Name)
+	}
+
+	resetErr := p.runTerraformCommand(ctx, runOpts, false, "workspace", "select", defaultWorkspace)
+	if resetErr != nil {
+		slog.Error("Failed to select the default workspace after the matrix", "error", resetErr)
+	}
+
+	slog.Info("Provision matrix completed", "entries", len(spec.Provision.Matrix), "failed", len(failures))
+	if len(failures) > 0 {
+		return fmt.Errorf("%d of %d matrix entries failed: %s", len(failures), len(spec.Provision.Matrix), strings.Join(failures, "; "))
+	}
+	if resetErr != nil {
+		return fmt.Errorf("failed to select the %s workspace after the matrix: %w", defaultWorkspace, resetErr)
+	}
+	return nil
+}
+
+// runMatrixEntry selects the entry's workspace and runs plan/apply with its variables, passed
+// as provision.variablesMode says: in a variables file, as -var flags or both.
+func (p *TerraformDockerProvisioner) runMatrixEntry(ctx context.Context, runOpts runtime.RunOptions, spec *blueprint.Spec, absScaffoldDir string, varArgs []string, autoApprove bool, entry blueprint.MatrixEntry) error {
+	if err := p.runTerraformCommand(ctx, runOpts, false, "workspace", "select", "-or-create", entry.Name); err != nil {
+		return fmt.Errorf("failed to select workspace: %w", err)
+	}
+
+	// The entry's variables are passed last so they take precedence over the base variables
+	entryArgs := append([]string{}, varArgs...)
+	if spec.Provision.WritesTfvars() {
+		varFile := entry.Name + matrixVarFileSuffix
+		varFilePath := filepath.Join(absScaffoldDir, varFile)
+		content, err := json.MarshalIndent(mergeVariables(spec.Variables, entry.Variables), "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal variables: %w", err)
+		}
+		if err := os.WriteFile(varFilePath, content, 0600); err != nil {
+			return fmt.Errorf("failed to write %s: %w", varFile, err)
+		}
+		defer os.Remove(varFilePath)
+		entryArgs = append(entryArgs, "-var-file="+varFile)
+	}
+	if spec.Provision.PassesVarFlags() {
+		flags, err := varFlagArgs(entry.Variables)
+		if err != nil {
+			return err
+		}
+		entryArgs = append(entryArgs, flags...)
+	}
+
+	// Keep each entry's plan JSON apart
+	entrySpec := spec
+	if spec.Provision.PlanJSON != "" {
+		specCopy := *spec
+		specCopy.Provision.PlanJSON = matrixPlanJSONPath(spec.Provision.PlanJSON, entry.Name)
+		entrySpec = &specCopy
+	}
+
+	return p.planAndApply(ctx, runOpts, entrySpec, absScaffoldDir, entryArgs, autoApprove, entry.Name)
+}
+
+// mergeVariables returns base with overlay's values taking precedence.
+func mergeVariables(base, overlay map[string]interface{}) map[string]interface{} {
+	merged := make(map[string]interface{}, len(base)+len(overlay))
+	for key, value := range base {
+		merged[key] = value
+	}
+	for key, value := range overlay {
+		merged[key] = value
+	}
+	return merged
+}
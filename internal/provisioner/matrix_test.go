@@ -0,0 +1,183 @@
+package provisioner
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+
+	"klonekit/pkg/blueprint"
+	runtimePkg "klonekit/pkg/runtime"
+)
+
+func TestTerraformDockerProvisioner_Matrix(t *testing.T) {
+	scaffoldDir := t.TempDir()
+	spec := &blueprint.Spec{
+		Scaffold: blueprint.Scaffold{Destination: scaffoldDir},
+		Variables: map[string]interface{}{
+			"region":         "us-east-1",
+			"instance_count": 1,
+		},
+		Provision: blueprint.Provision{
+			Matrix: []blueprint.MatrixEntry{
+				{Name: "dev", Variables: map[string]interface{}{"instance_count": 1, "env": "dev"}},
+				{Name: "prod", Variables: map[string]interface{}{"instance_count": 3, "env": "prod"}},
+			},
+		},
+	}
+
+	var commands []string
+	planVariables := map[string]map[string]interface{}{}
+	workspace := ""
+	mockRuntime := new(MockContainerRuntime)
+	mockRuntime.On("PullImage", mock.Anything, TerraformDockerImage).Return(nil)
+	mockRuntime.On("RunContainer", mock.Anything, mock.MatchedBy(func(opts runtimePkg.RunOptions) bool {
+		commands = append(commands, strings.Join(opts.Command, " "))
+		if len(opts.Command) == 4 && opts.Command[0] == "workspace" {
+			workspace = opts.Command[3]
+		}
+		if opts.Command[0] == "plan" {
+			for _, arg := range opts.Command {
+				if name, ok := strings.CutPrefix(arg, "-var-file="); ok {
+					content, err := os.ReadFile(filepath.Join(scaffoldDir, name))
+					if err != nil {
+						t.Errorf("Expected the matrix variables file to exist during plan: %s", err)
+						continue
+					}
+					var vars map[string]interface{}
+					if err := json.Unmarshal(content, &vars); err != nil {
+						t.Errorf("Failed to parse matrix variables file: %s", err)
+					}
+					planVariables[workspace] = vars
+				}
+			}
+		}
+		return true
+	})).Return(&MockReadCloser{data: []byte("ok")}, nil)
+
+	if err := NewTerraformDockerProvisioner(mockRuntime).Provision(spec, true); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	want := []string{
+		"init -input=false",
+		"workspace select -or-create dev",
+		"plan -var-file=dev" + matrixVarFileSuffix,
+		"apply -auto-approve -var-file=dev" + matrixVarFileSuffix,
+		"workspace select -or-create prod",
+		"plan -var-file=prod" + matrixVarFileSuffix,
+		"apply -auto-approve -var-file=prod" + matrixVarFileSuffix,
+		"workspace select default",
+	}
+	if !reflect.DeepEqual(commands, want) {
+		t.Errorf("Expected commands:\n%s\ngot:\n%s", strings.Join(want, "\n"), strings.Join(commands, "\n"))
+	}
+
+	wantVariables := map[string]map[string]interface{}{
+		"dev":  {"region": "us-east-1", "instance_count": float64(1), "env": "dev"},
+		"prod": {"region": "us-east-1", "instance_count": float64(3), "env": "prod"},
+	}
+	if !reflect.DeepEqual(planVariables, wantVariables) {
+		t.Errorf("Expected merged variables %v, got %v", wantVariables, planVariables)
+	}
+
+	matches, _ := filepath.Glob(filepath.Join(scaffoldDir, "*"+matrixVarFileSuffix))
+	if len(matches) != 0 {
+		t.Errorf("Expected the matrix variables files to be removed, found: %v", matches)
+	}
+}
+
+func TestTerraformDockerProvisioner_MatrixAggregatesFailures(t *testing.T) {
+	spec := &blueprint.Spec{
+		Scaffold: blueprint.Scaffold{Destination: t.TempDir()},
+		Provision: blueprint.Provision{
+			Matrix: []blueprint.MatrixEntry{{Name: "dev"}, {Name: "staging"}, {Name: "prod"}},
+		},
+	}
+
+	var workspaces []string
+	failingRuntime := &scriptedRuntime{run: func(opts runtimePkg.RunOptions) error {
+		if opts.Command[0] != "workspace" || opts.Command[2] != "-or-create" {
+			return nil
+		}
+		workspaces = append(workspaces, opts.Command[3])
+		if opts.Command[3] == "staging" {
+			return fmt.Errorf("container exited with non-zero status: 1")
+		}
+		return nil
+	}}
+
+	err := NewTerraformDockerProvisioner(failingRuntime).Provision(spec, false)
+	if err == nil {
+		t.Fatal("Expected an error when a matrix entry fails, got nil")
+	}
+	if !strings.Contains(err.Error(), "1 of 3 matrix entries failed") || !strings.Contains(err.Error(), "staging") {
+		t.Errorf("Expected the failed entry to be reported, got: %s", err)
+	}
+	if !reflect.DeepEqual(workspaces, []string{"dev", "staging", "prod"}) {
+		t.Errorf("Expected every entry to run despite the failure, got: %v", workspaces)
+	}
+}
+
+func TestTerraformDockerProvisioner_MatrixVarFlags(t *testing.T) {
+	scaffoldDir := t.TempDir()
+	spec := &blueprint.Spec{
+		Scaffold:        blueprint.Scaffold{Destination: scaffoldDir},
+		Variables:       map[string]interface{}{"db_password": "hunter2"},
+		SecretVariables: []string{"db_password"},
+		Provision: blueprint.Provision{
+			VariablesMode: blueprint.VariablesModeFlags,
+			Matrix:        []blueprint.MatrixEntry{{Name: "dev", Variables: map[string]interface{}{"env": "dev"}}},
+		},
+	}
+
+	var commands []string
+	mockRuntime := new(MockContainerRuntime)
+	mockRuntime.On("PullImage", mock.Anything, TerraformDockerImage).Return(nil)
+	mockRuntime.On("RunContainer", mock.Anything, mock.MatchedBy(func(opts runtimePkg.RunOptions) bool {
+		commands = append(commands, strings.Join(opts.Command, " "))
+		if opts.Command[0] == "plan" {
+			// No variables file holds the secret while Terraform runs
+			matches, _ := filepath.Glob(filepath.Join(scaffoldDir, "*.tfvars.json"))
+			if len(matches) != 0 {
+				t.Errorf("Expected no variables file with variablesMode flags, found: %v", matches)
+			}
+		}
+		return true
+	})).Return(&MockReadCloser{data: []byte("ok")}, nil)
+
+	if err := NewTerraformDockerProvisioner(mockRuntime).Provision(spec, true); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	want := []string{
+		"init -input=false",
+		"workspace select -or-create dev",
+		"plan -var db_password=hunter2 -var env=dev",
+		"apply -auto-approve -var db_password=hunter2 -var env=dev",
+		"workspace select default",
+	}
+	if !reflect.DeepEqual(commands, want) {
+		t.Errorf("Expected commands:\n%s\ngot:\n%s", strings.Join(want, "\n"), strings.Join(commands, "\n"))
+	}
+}
+
+// scriptedRuntime is a container runtime whose containers exit with the error returned by run
+type scriptedRuntime struct {
+	run func(opts runtimePkg.RunOptions) error
+}
+
+func (r *scriptedRuntime) PullImage(ctx context.Context, image string) error {
+	return nil
+}
+
+func (r *scriptedRuntime) RunContainer(ctx context.Context, opts runtimePkg.RunOptions) (io.ReadCloser, error) {
+	return &MockReadCloser{data: []byte("ok"), closeErr: r.run(opts)}, nil
+}
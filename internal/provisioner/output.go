@@ -0,0 +1,75 @@
+package provisioner
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+)
+
+// maxOutputLineBytes is the longest output line kept; longer lines are cut short with
+// truncatedLineMarker appended.
+const maxOutputLineBytes = 1 << 20
+
+// truncatedLineMarker is appended to output lines cut short at maxOutputLineBytes.
+const truncatedLineMarker = " ... [line truncated]"
+
+// scanOutput calls handle with each line of the container output, without its line ending.
+// Lines longer than maxOutputLineBytes are cut short, and once limit bytes have been read
+// the rest of the output is drained and discarded, so a command printing huge output
+// neither fails nor exhausts memory. It reports whether the output was cut at limit; a
+// limit of zero or less reads everything.
+func scanOutput(r io.Reader, limit int64, handle func(line string)) (bool, error) {
+	reader := bufio.NewReader(r)
+	var read int64
+	for {
+		line, n, err := readOutputLine(reader)
+		read += n
+		if n > 0 {
+			handle(line)
+		}
+		if err == io.EOF {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		if limit > 0 && read >= limit {
+			if _, err := io.Copy(io.Discard, reader); err != nil {
+				return true, err
+			}
+			return true, nil
+		}
+	}
+}
+
+// readOutputLine reads the next line, keeping at most maxOutputLineBytes of it, and returns
+// it with the number of bytes consumed. It returns io.EOF once the output ends.
+func readOutputLine(reader *bufio.Reader) (string, int64, error) {
+	var line []byte
+	var n int64
+	truncated := false
+	for {
+		chunk, err := reader.ReadSlice('\n')
+		n += int64(len(chunk))
+		if room := maxOutputLineBytes - len(line); room > 0 {
+			if len(chunk) > room {
+				chunk, truncated = chunk[:room], true
+			}
+			line = append(line, chunk...)
+		} else if len(chunk) > 0 {
+			truncated = true
+		}
+		if errors.Is(err, bufio.ErrBufferFull) {
+			continue
+		}
+
+		line = bytes.TrimSuffix(line, []byte("\n"))
+		line = bytes.TrimSuffix(line, []byte("\r"))
+		text := string(line)
+		if truncated {
+			text += truncatedLineMarker
+		}
+		return text, n, err
+	}
+}
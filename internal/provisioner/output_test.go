@@ -0,0 +1,124 @@
+package provisioner
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+
+	runtimePkg "klonekit/pkg/runtime"
+)
+
+// repeatReader streams size bytes of repeated lines without holding them in memory
+type repeatReader struct {
+	line      []byte
+	remaining int64
+	pos       int
+}
+
+func (r *repeatReader) Read(p []byte) (int, error) {
+	if r.remaining <= 0 {
+		return 0, io.EOF
+	}
+	n := 0
+	for n < len(p) && r.remaining > 0 {
+		c := copy(p[n:], r.line[r.pos:])
+		if int64(c) > r.remaining {
+			c = int(r.remaining)
+		}
+		n += c
+		r.remaining -= int64(c)
+		r.pos = (r.pos + c) % len(r.line)
+	}
+	return n, nil
+}
+
+func collectLines(t *testing.T, r io.Reader, limit int64) ([]string, bool) {
+	t.Helper()
+	var lines []string
+	truncated, err := scanOutput(r, limit, func(line string) { lines = append(lines, line) })
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	return lines, truncated
+}
+
+func TestScanOutput_Lines(t *testing.T) {
+	lines, truncated := collectLines(t, strings.NewReader("Initializing...\r\n\nApply complete!"), 0)
+
+	want := []string{"Initializing...", "", "Apply complete!"}
+	if strings.Join(lines, "|") != strings.Join(want, "|") || truncated {
+		t.Errorf("Expected lines %q, got %q (truncated %v)", want, lines, truncated)
+	}
+}
+
+func TestScanOutput_LongLine(t *testing.T) {
+	longLine := bytes.Repeat([]byte("x"), 5*maxOutputLineBytes)
+	input := append(append(longLine, '\n'), "next line\n"...)
+
+	lines, truncated := collectLines(t, bytes.NewReader(input), 0)
+
+	if len(lines) != 2 {
+		t.Fatalf("Expected the long line and the next one, got %d lines", len(lines))
+	}
+	if !strings.HasSuffix(lines[0], truncatedLineMarker) || len(lines[0]) != maxOutputLineBytes+len(truncatedLineMarker) {
+		t.Errorf("Expected the long line cut at %d bytes with a marker, got %d bytes", maxOutputLineBytes, len(lines[0]))
+	}
+	if lines[1] != "next line" {
+		t.Errorf("Expected the line after the long one to be read, got %q", lines[1])
+	}
+	if truncated {
+		t.Error("Expected the output not to be cut without a limit")
+	}
+}
+
+func TestScanOutput_LargeStream(t *testing.T) {
+	const limit = 1 << 20
+	stream := &repeatReader{line: []byte("aws_instance.web: Still creating... [10s elapsed]\n"), remaining: 256 << 20}
+
+	var shown int
+	truncated, err := scanOutput(stream, limit, func(line string) { shown += len(line) + 1 })
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	if !truncated {
+		t.Error("Expected the output to be cut at the limit")
+	}
+	if shown < limit || shown > limit+len(stream.line) {
+		t.Errorf("Expected about %d bytes shown, got %d", limit, shown)
+	}
+	if stream.remaining != 0 {
+		t.Errorf("Expected the rest of the output to be drained, %d bytes left", stream.remaining)
+	}
+}
+
+func TestTerraformDockerProvisioner_runTerraformCommand_TruncatesOutput(t *testing.T) {
+	output := bytes.Repeat([]byte("Refreshing state...\n"), 10000)
+	mockRuntime := new(MockContainerRuntime)
+	mockRuntime.On("RunContainer", mock.Anything, mock.Anything).Return(&MockReadCloser{data: output}, nil)
+
+	provisioner := NewTerraformDockerProvisioner(mockRuntime)
+	provisioner.outputLimit = 1024
+
+	// The default slog handler writes through the standard logger
+	var logs bytes.Buffer
+	previous := log.Writer()
+	log.SetOutput(&logs)
+	err := provisioner.runTerraformCommand(context.Background(), runtimePkg.RunOptions{}, false, "plan")
+	log.SetOutput(previous)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	if !strings.Contains(logs.String(), "Terraform output truncated") {
+		t.Errorf("Expected an output truncated warning, got:\n%s", logs.String())
+	}
+	if shown := strings.Count(logs.String(), "Refreshing state..."); shown == 0 || shown > 100 {
+		t.Errorf("Expected only the output within the limit to be shown, got %d lines", shown)
+	}
+}
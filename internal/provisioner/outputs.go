@@ -0,0 +1,33 @@
+package provisioner
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+
+	"klonekit/pkg/runtime"
+)
+
+// Outputs returns the root module outputs captured after the last apply. They are only
+// captured when spec.scm.project.exportOutputs is set.
+func (p *TerraformDockerProvisioner) Outputs() map[string]Output {
+	return p.outputs
+}
+
+// captureOutputs reads the root module outputs with terraform output -json, which also works
+// with remote backends.
+func (p *TerraformDockerProvisioner) captureOutputs(ctx context.Context, baseOpts runtime.RunOptions) error {
+	content, err := p.captureTerraformCommand(ctx, baseOpts, "output", "-json")
+	if err != nil {
+		return err
+	}
+
+	var outputs map[string]Output
+	if err := json.Unmarshal(content, &outputs); err != nil {
+		return fmt.Errorf("failed to parse terraform output: %w", err)
+	}
+	p.outputs = outputs
+	slog.Info("Captured Terraform outputs", "count", len(outputs))
+	return nil
+}
@@ -0,0 +1,133 @@
+package provisioner
+
+import (
+	"bufio"
+	"context"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"klonekit/pkg/runtime"
+)
+
+// PermissionFixImage is the image used to hand files created by the container back to the
+// host user when they aren't owned by it (e.g. provider downloads of an image running as root).
+const PermissionFixImage = "busybox:1.36"
+
+// ownedByOther reports whether a file isn't owned by the host user; tests replace it to
+// simulate root-owned files.
+var ownedByOther = fileOwnedByOther
+
+// fixPermissions makes the files Terraform containers created in the mounted host
+// directories owned by and writable for the host user, so later runs and cleanup don't
+// fail on them. Failures are logged and never fail the run.
+func (p *TerraformDockerProvisioner) fixPermissions(ctx context.Context, baseOpts runtime.RunOptions) {
+	dirs := make([]string, 0, len(baseOpts.VolumeMounts))
+	for hostPath, containerPath := range baseOpts.VolumeMounts {
+		if containerPath == WorkingDirectory || containerPath == TerraformDataDirectory {
+			dirs = append(dirs, hostPath)
+		}
+	}
+	sort.Strings(dirs)
+
+	foreign := false
+	for _, dir := range dirs {
+		_ = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return nil
+			}
+			if info, err := d.Info(); err == nil && ownedByOther(info) {
+				foreign = true
+				return filepath.SkipAll
+			}
+			return nil
+		})
+	}
+	if foreign {
+		p.chownInContainer(ctx, baseOpts, dirs)
+	}
+
+	for _, dir := range dirs {
+		if err := makeOwnerWritable(dir); err != nil {
+			slog.Warn("Failed to fix permissions of files created by Terraform", "dir", dir, "error", err)
+		}
+	}
+}
+
+// chownInContainer runs PermissionFixImage as root to change the owner of the mounted
+// directories to the host user, which the host user can't do itself.
+func (p *TerraformDockerProvisioner) chownInContainer(ctx context.Context, baseOpts runtime.RunOptions, dirs []string) {
+	slog.Info("Changing the owner of files created by Terraform to the host user", "dirs", dirs)
+
+	if err := checkImageAllowed(PermissionFixImage); err != nil {
+		slog.Warn("Skipping the ownership fix, its image is not allowed", "image", PermissionFixImage, "error", err)
+		return
+	}
+	if err := p.containerRuntime.PullImage(ctx, PermissionFixImage); err != nil {
+		slog.Warn("Failed to pull image to fix file ownership", "image", PermissionFixImage, "error", err)
+		return
+	}
+
+	command := []string{"chown", "-R", getCurrentUserID()}
+	mounts := make(map[string]string, len(dirs))
+	for _, dir := range dirs {
+		mounts[dir] = baseOpts.VolumeMounts[dir]
+		command = append(command, baseOpts.VolumeMounts[dir])
+	}
+
+	opts := runtime.RunOptions{
+		Image:        PermissionFixImage,
+		Command:      command,
+		VolumeMounts: mounts,
+		User:         "0:0",
+		NetworkMode:  "none",
+	}
+	if baseOpts.ContainerName != "" {
+		opts.ContainerName = baseOpts.ContainerName + "-permissions"
+	}
+
+	reader, err := p.containerRuntime.RunContainer(ctx, opts)
+	if err != nil {
+		slog.Warn("Failed to run container to fix file ownership", "error", err)
+		return
+	}
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		if line := cleanDockerLogLine(scanner.Text()); line != "" {
+			slog.Info("Permission fix output", "line", line)
+		}
+	}
+	if err := reader.Close(); err != nil {
+		slog.Warn("Failed to fix file ownership", "error", err)
+	}
+}
+
+// makeOwnerWritable adds owner read and write permission to every file below dir, and
+// owner execute permission to every directory, so the files can be updated and removed.
+func makeOwnerWritable(dir string) error {
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.Type()&fs.ModeSymlink != 0 {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		required := fs.FileMode(0600)
+		if d.IsDir() {
+			required = 0700
+		}
+		if mode := info.Mode().Perm(); mode&required != required {
+			if err := os.Chmod(path, mode|required); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,10 @@
+//go:build !unix
+
+package provisioner
+
+import "io/fs"
+
+// fileOwnedByOther reports false, as file ownership doesn't map to a host UID on this platform.
+func fileOwnedByOther(info fs.FileInfo) bool {
+	return false
+}
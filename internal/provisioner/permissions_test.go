@@ -0,0 +1,96 @@
+package provisioner
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+
+	"klonekit/pkg/runtime"
+)
+
+func TestMakeOwnerWritable_RestrictedFilesBecomeRemovable(t *testing.T) {
+	scaffoldDir := t.TempDir()
+	pluginDir := filepath.Join(scaffoldDir, ".terraform", "providers")
+	if err := os.MkdirAll(pluginDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	plugin := filepath.Join(pluginDir, "terraform-provider-aws")
+	if err := os.WriteFile(plugin, []byte("binary"), 0400); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(pluginDir, 0500); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := makeOwnerWritable(scaffoldDir); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	for path, want := range map[string]fs.FileMode{pluginDir: 0700, plugin: 0600} {
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Mode().Perm()&want != want {
+			t.Errorf("Expected %s to have owner permissions %v, got %v", path, want, info.Mode().Perm())
+		}
+	}
+	if err := os.RemoveAll(scaffoldDir); err != nil {
+		t.Errorf("Expected the fixed files to be removable, got: %s", err)
+	}
+}
+
+func TestFixPermissions_ChownsForeignFilesInContainer(t *testing.T) {
+	scaffoldDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(scaffoldDir, "terraform.tfstate"), []byte("{}"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	original := ownedByOther
+	ownedByOther = func(info fs.FileInfo) bool { return info.Name() == "terraform.tfstate" }
+	defer func() { ownedByOther = original }()
+
+	var command []string
+	mockRuntime := new(MockContainerRuntime)
+	mockRuntime.On("PullImage", mock.Anything, PermissionFixImage).Return(nil)
+	mockRuntime.On("RunContainer", mock.Anything, mock.MatchedBy(func(opts runtime.RunOptions) bool {
+		command = opts.Command
+		return opts.Image == PermissionFixImage && opts.User == "0:0" && opts.VolumeMounts[scaffoldDir] == WorkingDirectory
+	})).Return(&MockReadCloser{}, nil)
+
+	provisioner := NewTerraformDockerProvisioner(mockRuntime)
+	provisioner.fixPermissions(t.Context(), runtime.RunOptions{
+		VolumeMounts: map[string]string{
+			scaffoldDir:  WorkingDirectory,
+			"/home/.aws": "/home/terraform/.aws",
+		},
+	})
+
+	mockRuntime.AssertExpectations(t)
+	want := "chown -R " + getCurrentUserID() + " " + WorkingDirectory
+	if strings.Join(command, " ") != want {
+		t.Errorf("Expected %q, got %v", want, command)
+	}
+}
+
+func TestFixPermissions_NoContainerForOwnFiles(t *testing.T) {
+	scaffoldDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(scaffoldDir, "main.tf"), []byte("# main"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	original := ownedByOther
+	ownedByOther = func(fs.FileInfo) bool { return false }
+	defer func() { ownedByOther = original }()
+
+	mockRuntime := new(MockContainerRuntime)
+	provisioner := NewTerraformDockerProvisioner(mockRuntime)
+	provisioner.fixPermissions(t.Context(), runtime.RunOptions{VolumeMounts: map[string]string{scaffoldDir: WorkingDirectory}})
+
+	mockRuntime.AssertNotCalled(t, "PullImage", mock.Anything, mock.Anything)
+	mockRuntime.AssertNotCalled(t, "RunContainer", mock.Anything, mock.Anything)
+}
@@ -0,0 +1,15 @@
+//go:build unix
+
+package provisioner
+
+import (
+	"io/fs"
+	"os"
+	"syscall"
+)
+
+// fileOwnedByOther reports whether info belongs to a user other than the host user.
+func fileOwnedByOther(info fs.FileInfo) bool {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	return ok && int(stat.Uid) != os.Getuid()
+}
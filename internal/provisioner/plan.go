@@ -0,0 +1,90 @@
+package provisioner
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	"klonekit/pkg/blueprint"
+	"klonekit/pkg/runtime"
+)
+
+// SavedPlanFileName is the plan file Plan leaves in the scaffold destination for review
+const SavedPlanFileName = "tfplan"
+
+// Plan runs terraform init and plan, saving the plan to SavedPlanFileName in the scaffold
+// destination so it can be reviewed and later applied as is with spec.provision.useSavedPlan.
+// Plans of a matrix are not supported, since every entry plans its own workspace. Like
+// Provision, the run is stopped once spec.provision.timeout expires.
+func (p *TerraformDockerProvisioner) Plan(spec *blueprint.Spec) error {
+	if len(spec.Provision.Matrix) > 0 {
+		return fmt.Errorf("saving a plan is not supported with spec.provision.matrix, as each entry plans its own workspace")
+	}
+
+	ctx, cancel := runContext(spec)
+	defer cancel()
+
+	return timeoutError(ctx, spec, p.plan(ctx, spec))
+}
+
+// plan runs Plan within ctx.
+func (p *TerraformDockerProvisioner) plan(ctx context.Context, spec *blueprint.Spec) error {
+	slog.Info("Planning infrastructure changes", "scaffoldDir", spec.Scaffold.Destination)
+
+	runOpts, absScaffoldDir, err := p.prepareRun(ctx, spec)
+	if err != nil {
+		return err
+	}
+
+	// Hand the files the containers create back to the host user once done, even after a timeout
+	if !spec.Provision.SkipPermissionFix {
+		defer p.fixPermissions(context.WithoutCancel(ctx), runOpts)
+	}
+
+	if err := p.initialize(ctx, spec, runOpts); err != nil {
+		return err
+	}
+
+	varArgs, err := variableArgs(spec, absScaffoldDir)
+	if err != nil {
+		return err
+	}
+
+	args := append([]string{"plan", "-out=" + SavedPlanFileName}, varArgs...)
+	if err := p.runTerraformCommand(ctx, runOpts, false, args...); err != nil {
+		return fmt.Errorf("terraform plan failed: %w", err)
+	}
+
+	slog.Info("Plan saved", "path", filepath.Join(absScaffoldDir, SavedPlanFileName))
+	return nil
+}
+
+// applySavedPlan applies the plan saved to planName in the scaffold directory, by Plan or by
+// the plan of this run, reporting whether there was one. The plan is removed once applied,
+// since terraform refuses to apply it a second time.
+func (p *TerraformDockerProvisioner) applySavedPlan(ctx context.Context, runOpts runtime.RunOptions, absScaffoldDir, planName string) (bool, error) {
+	planFile := filepath.Join(absScaffoldDir, planName)
+	if _, err := os.Stat(planFile); err != nil {
+		slog.Info("No saved plan found", "path", planFile)
+		return false, nil
+	}
+	slog.Info("Applying saved plan", "path", planFile)
+
+	// Backup state file before apply operation (critical for safety)
+	if err := p.backupStateFile(absScaffoldDir); err != nil {
+		slog.Warn("Failed to backup state file before apply", "error", err.Error())
+	}
+
+	p.startStep(StepApply)
+	// Variables are part of the saved plan, and terraform rejects them alongside it
+	if err := p.runTerraformCommand(ctx, runOpts, true, "apply", "-auto-approve", planName); err != nil {
+		return true, fmt.Errorf("terraform apply of the saved plan failed: %w", err)
+	}
+
+	if err := os.Remove(planFile); err != nil {
+		slog.Warn("Failed to remove the applied plan file", "path", planFile, "error", err)
+	}
+	return true, nil
+}
@@ -0,0 +1,119 @@
+package provisioner
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+
+	"klonekit/pkg/blueprint"
+	runtimePkg "klonekit/pkg/runtime"
+)
+
+// recordCommands returns a mock runtime recording every terraform command it runs
+func recordCommands(commands *[]string) *MockContainerRuntime {
+	mockRuntime := new(MockContainerRuntime)
+	mockRuntime.On("PullImage", mock.Anything, TerraformDockerImage).Return(nil)
+	mockRuntime.On("RunContainer", mock.Anything, mock.MatchedBy(func(opts runtimePkg.RunOptions) bool {
+		*commands = append(*commands, strings.Join(opts.Command, " "))
+		return true
+	})).Return(&MockReadCloser{data: []byte("ok")}, nil)
+	return mockRuntime
+}
+
+func TestTerraformDockerProvisioner_Plan(t *testing.T) {
+	spec := &blueprint.Spec{
+		Scaffold:  blueprint.Scaffold{Destination: t.TempDir()},
+		Variables: map[string]interface{}{"region": "us-east-1"},
+		Provision: blueprint.Provision{VariablesMode: blueprint.VariablesModeFlags, SkipPermissionFix: true},
+	}
+
+	var commands []string
+	if err := NewTerraformDockerProvisioner(recordCommands(&commands)).Plan(spec); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	want := []string{"init -input=false", "plan -out=tfplan -var region=us-east-1"}
+	if strings.Join(commands, ",") != strings.Join(want, ",") {
+		t.Errorf("Expected commands %v, got %v", want, commands)
+	}
+}
+
+func TestTerraformDockerProvisioner_Plan_RejectsMatrix(t *testing.T) {
+	spec := &blueprint.Spec{
+		Scaffold:  blueprint.Scaffold{Destination: t.TempDir()},
+		Provision: blueprint.Provision{Matrix: []blueprint.MatrixEntry{{Name: "dev"}}},
+	}
+
+	mockRuntime := new(MockContainerRuntime)
+	err := NewTerraformDockerProvisioner(mockRuntime).Plan(spec)
+	if err == nil || !strings.Contains(err.Error(), "not supported with spec.provision.matrix") {
+		t.Errorf("Expected a matrix error, got: %v", err)
+	}
+	mockRuntime.AssertNotCalled(t, "PullImage", mock.Anything, mock.Anything)
+}
+
+func TestTerraformDockerProvisioner_AppliesSavedPlan(t *testing.T) {
+	scaffoldDir := t.TempDir()
+	planFile := filepath.Join(scaffoldDir, SavedPlanFileName)
+	if err := os.WriteFile(planFile, []byte("saved plan"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	spec := &blueprint.Spec{
+		Scaffold:  blueprint.Scaffold{Destination: scaffoldDir},
+		Variables: map[string]interface{}{"region": "us-east-1"},
+		Provision: blueprint.Provision{VariablesMode: blueprint.VariablesModeFlags, UseSavedPlan: true, SkipPermissionFix: true},
+	}
+
+	var commands []string
+	if err := NewTerraformDockerProvisioner(recordCommands(&commands)).Provision(spec, true); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	want := []string{"init -input=false", "apply -auto-approve tfplan"}
+	if strings.Join(commands, ",") != strings.Join(want, ",") {
+		t.Errorf("Expected the saved plan to be applied without planning again, got %v", commands)
+	}
+	if _, err := os.Stat(planFile); !os.IsNotExist(err) {
+		t.Error("Expected the applied plan file to be removed")
+	}
+}
+
+func TestTerraformDockerProvisioner_SavedPlanIgnored(t *testing.T) {
+	tests := []struct {
+		name         string
+		useSavedPlan bool
+		writePlan    bool
+		autoApprove  bool
+		want         []string
+	}{
+		{name: "no saved plan", useSavedPlan: true, autoApprove: true, want: []string{"init -input=false", "plan", "apply -auto-approve"}},
+		{name: "not requested", writePlan: true, autoApprove: true, want: []string{"init -input=false", "plan", "apply -auto-approve"}},
+		{name: "without auto-approve", useSavedPlan: true, writePlan: true, want: []string{"init -input=false", "plan"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scaffoldDir := t.TempDir()
+			if tt.writePlan {
+				if err := os.WriteFile(filepath.Join(scaffoldDir, SavedPlanFileName), []byte("saved plan"), 0600); err != nil {
+					t.Fatal(err)
+				}
+			}
+			spec := &blueprint.Spec{
+				Scaffold:  blueprint.Scaffold{Destination: scaffoldDir},
+				Provision: blueprint.Provision{UseSavedPlan: tt.useSavedPlan, SkipPermissionFix: true},
+			}
+
+			var commands []string
+			if err := NewTerraformDockerProvisioner(recordCommands(&commands)).Provision(spec, tt.autoApprove); err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			if strings.Join(commands, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("Expected commands %v, got %v", tt.want, commands)
+			}
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package provisioner
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/docker/docker/pkg/stdcopy"
+
+	"klonekit/pkg/runtime"
+)
+
+const (
+	// PolicyPlanJSONFileName is the plan JSON written next to the plan file for a policy
+	// command when spec.provision.planJSON is not set
+	PolicyPlanJSONFileName = "klonekit.tfplan.json"
+
+	// PlanJSONEnv is the environment variable holding the host path of the plan JSON for the
+	// policy command
+	PlanJSONEnv = "KLONEKIT_PLAN_JSON"
+)
+
+// writePlanJSON runs terraform show -json on the saved plan and writes the output to path,
+// so policy tools can check the plan between plan and apply. The file is created with mode
+// 0600, since the plan can contain sensitive values.
+func (p *TerraformDockerProvisioner) writePlanJSON(ctx context.Context, baseOpts runtime.RunOptions, path string) error {
+	output, err := p.captureTerraformCommand(ctx, baseOpts, "show", "-json", PlanFileName)
+	if err != nil {
+		return err
+	}
+
+	plan := bytes.TrimSpace(output)
+	if !json.Valid(plan) {
+		return fmt.Errorf("terraform show did not return a JSON plan")
+	}
+	if err := os.WriteFile(path, append(plan, '\n'), 0600); err != nil {
+		return fmt.Errorf("failed to write plan JSON: %w", err)
+	}
+
+	slog.Info("Plan JSON written", "path", path)
+	return nil
+}
+
+// captureTerraformCommand runs a Terraform command and returns its stdout instead of
+// logging it.
+func (p *TerraformDockerProvisioner) captureTerraformCommand(ctx context.Context, baseOpts runtime.RunOptions, args ...string) ([]byte, error) {
+	opts := baseOpts
+	opts.Command = args
+	opts.RetainContainer = false
+
+	slog.Info("Executing Terraform command", "command", commandLine(baseOpts, args))
+	reader, err := p.containerRuntime.RunContainer(ctx, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to run container: %w", err)
+	}
+
+	// Output such as a JSON plan is a single line that can exceed any line buffer, so the
+	// multiplexed container output is split into stdout and stderr as a whole
+	var stdout, stderr bytes.Buffer
+	_, copyErr := stdcopy.StdCopy(&stdout, &stderr, reader)
+	if err := reader.Close(); err != nil {
+		if detail := strings.TrimSpace(stderr.String()); detail != "" {
+			return nil, fmt.Errorf("terraform %s failed: %w: %s", args[0], err, detail)
+		}
+		return nil, fmt.Errorf("terraform %s failed: %w", args[0], err)
+	}
+	if copyErr != nil {
+		return nil, fmt.Errorf("error reading container output: %w", copyErr)
+	}
+	return stdout.Bytes(), nil
+}
+
+// matrixPlanJSONPath adds the workspace name before the extension of a plan JSON path, so
+// each matrix entry's plan is kept, e.g. plan.json becomes plan.staging.json.
+func matrixPlanJSONPath(path, workspace string) string {
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + "." + workspace + ext
+}
+
+// runPolicyCommand runs the configured policy command on the host after the plan JSON export,
+// with the plan JSON path in KLONEKIT_PLAN_JSON and the plan file path in KLONEKIT_PLAN_FILE.
+// Unlike the cost estimate, a failing command rejects the plan and stops the run before apply.
+func runPolicyCommand(ctx context.Context, command, scaffoldDir, planJSON string) error {
+	slog.Info("Running policy command", "command", command, "planJSON", planJSON)
+
+	cmd := exec.CommandContext(ctx, "sh", "-c", command) // #nosec G204 -- the command comes from the user's blueprint
+	cmd.Dir = scaffoldDir
+	cmd.Env = append(os.Environ(),
+		PlanJSONEnv+"="+planJSON,
+		PlanFileEnv+"="+filepath.Join(scaffoldDir, PlanFileName),
+		ScaffoldDirEnv+"="+scaffoldDir,
+	)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		if detail := strings.TrimSpace(string(output)); detail != "" {
+			return fmt.Errorf("policy command rejected the plan: %w: %s", err, detail)
+		}
+		return fmt.Errorf("policy command rejected the plan: %w", err)
+	}
+
+	slog.Info("Policy command accepted the plan", "output", strings.TrimSpace(string(output)))
+	return nil
+}
+
+// removePlanJSON removes a plan JSON written only for the policy command, since it can
+// contain sensitive values.
+func removePlanJSON(path string) {
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		slog.Warn("Failed to remove plan JSON", "path", path, "error", err)
+	}
+}
@@ -0,0 +1,223 @@
+package provisioner
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/pkg/stdcopy"
+	"github.com/stretchr/testify/mock"
+
+	"klonekit/pkg/blueprint"
+	runtimePkg "klonekit/pkg/runtime"
+)
+
+// multiplexed frames stdout and stderr the way Docker returns container logs
+func multiplexed(stdout, stderr string) []byte {
+	var buf bytes.Buffer
+	_, _ = stdcopy.NewStdWriter(&buf, stdcopy.Stdout).Write([]byte(stdout))
+	if stderr != "" {
+		_, _ = stdcopy.NewStdWriter(&buf, stdcopy.Stderr).Write([]byte(stderr))
+	}
+	return buf.Bytes()
+}
+
+func isShowCommand(opts runtimePkg.RunOptions) bool {
+	return len(opts.Command) > 0 && opts.Command[0] == "show"
+}
+
+func TestTerraformDockerProvisioner_WritesPlanJSON(t *testing.T) {
+	scaffoldDir := t.TempDir()
+	planJSON := filepath.Join(t.TempDir(), "plan.json")
+	plan := `{"format_version":"1.2","resource_changes":[{"address":"aws_s3_bucket.state","change":{"actions":["create"]}}]}`
+
+	spec := &blueprint.Spec{
+		Scaffold:  blueprint.Scaffold{Destination: scaffoldDir},
+		Cloud:     blueprint.CloudProvider{Provider: "aws", Region: "us-east-1"},
+		Provision: blueprint.Provision{PlanJSON: planJSON, SkipCredentialCheck: true},
+	}
+
+	var planCommand, showCommand []string
+	mockRuntime := new(MockContainerRuntime)
+	mockRuntime.On("PullImage", mock.Anything, TerraformDockerImage).Return(nil)
+	mockRuntime.On("RunContainer", mock.Anything, mock.MatchedBy(func(opts runtimePkg.RunOptions) bool {
+		if !isShowCommand(opts) {
+			return false
+		}
+		showCommand = opts.Command
+		return true
+	})).Return(&MockReadCloser{data: multiplexed(plan+"\n", "")}, nil)
+	mockRuntime.On("RunContainer", mock.Anything, mock.MatchedBy(func(opts runtimePkg.RunOptions) bool {
+		if opts.Command[0] == "plan" {
+			planCommand = opts.Command
+			// Stand in for the plan file terraform writes into the mounted workspace
+			_ = os.WriteFile(filepath.Join(scaffoldDir, PlanFileName), []byte("plan"), 0600)
+		}
+		return true
+	})).Return(&MockReadCloser{data: []byte("ok")}, nil)
+
+	if err := NewTerraformDockerProvisioner(mockRuntime).Provision(spec, false); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	if strings.Join(planCommand, " ") != "plan -out="+PlanFileName {
+		t.Errorf("Expected plan to be saved to %s, got: %v", PlanFileName, planCommand)
+	}
+	if strings.Join(showCommand, " ") != "show -json "+PlanFileName {
+		t.Errorf("Expected terraform show -json on the plan file, got: %v", showCommand)
+	}
+
+	content, err := os.ReadFile(planJSON)
+	if err != nil {
+		t.Fatalf("Expected the plan JSON to be written: %s", err)
+	}
+	if strings.TrimSpace(string(content)) != plan {
+		t.Errorf("Expected the plan JSON %s, got: %s", plan, content)
+	}
+	if info, err := os.Stat(planJSON); err == nil && info.Mode().Perm() != 0600 {
+		t.Errorf("Expected the plan JSON to have mode 0600, got %v", info.Mode().Perm())
+	}
+	if _, err := os.Stat(filepath.Join(scaffoldDir, PlanFileName)); !os.IsNotExist(err) {
+		t.Error("Expected the plan file to be removed after the export")
+	}
+}
+
+func TestTerraformDockerProvisioner_PlanJSONFailureStopsApply(t *testing.T) {
+	planJSON := filepath.Join(t.TempDir(), "plan.json")
+	spec := &blueprint.Spec{
+		Scaffold:  blueprint.Scaffold{Destination: t.TempDir()},
+		Cloud:     blueprint.CloudProvider{Provider: "aws", Region: "us-east-1"},
+		Provision: blueprint.Provision{PlanJSON: planJSON, SkipCredentialCheck: true},
+	}
+
+	applied := false
+	mockRuntime := new(MockContainerRuntime)
+	mockRuntime.On("PullImage", mock.Anything, TerraformDockerImage).Return(nil)
+	mockRuntime.On("RunContainer", mock.Anything, mock.MatchedBy(isShowCommand)).Return(&MockReadCloser{
+		data:     multiplexed("", "Error: Failed to read the given file as a state or plan file\n"),
+		closeErr: errors.New("container exited with non-zero status: 1"),
+	}, nil)
+	mockRuntime.On("RunContainer", mock.Anything, mock.MatchedBy(func(opts runtimePkg.RunOptions) bool {
+		if opts.Command[0] == "apply" {
+			applied = true
+		}
+		return true
+	})).Return(&MockReadCloser{data: []byte("ok")}, nil)
+
+	err := NewTerraformDockerProvisioner(mockRuntime).Provision(spec, true)
+	if err == nil {
+		t.Fatal("Expected an error when terraform show fails, got nil")
+	}
+	if !strings.Contains(err.Error(), "Failed to read the given file") {
+		t.Errorf("Expected the terraform show error output, got: %s", err)
+	}
+	if applied {
+		t.Error("Expected apply to be skipped when the plan JSON couldn't be written")
+	}
+	if _, err := os.Stat(planJSON); !os.IsNotExist(err) {
+		t.Error("Expected no plan JSON to be written")
+	}
+}
+
+func TestMatrixPlanJSONPath(t *testing.T) {
+	tests := map[string]string{
+		"plan.json":          "plan.staging.json",
+		"out/policy/tf.json": "out/policy/tf.staging.json",
+		"plan":               "plan.staging",
+		"/tmp/run.plan.json": "/tmp/run.plan.staging.json",
+	}
+	for path, want := range tests {
+		if got := matrixPlanJSONPath(path, "staging"); got != want {
+			t.Errorf("matrixPlanJSONPath(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestTerraformDockerProvisioner_PolicyCommandRejectsPlan(t *testing.T) {
+	scaffoldDir := t.TempDir()
+	received := filepath.Join(t.TempDir(), "received-plan-json")
+	spec := &blueprint.Spec{
+		Scaffold: blueprint.Scaffold{Destination: scaffoldDir},
+		Cloud:    blueprint.CloudProvider{Provider: "aws", Region: "us-east-1"},
+		Provision: blueprint.Provision{
+			PolicyCommand:       `cp "$KLONEKIT_PLAN_JSON" ` + received + `; echo "deny: bucket is public"; exit 3`,
+			SkipCredentialCheck: true,
+		},
+	}
+
+	applied := false
+	mockRuntime := new(MockContainerRuntime)
+	mockRuntime.On("PullImage", mock.Anything, TerraformDockerImage).Return(nil)
+	mockRuntime.On("RunContainer", mock.Anything, mock.MatchedBy(isShowCommand)).
+		Return(&MockReadCloser{data: multiplexed(`{"format_version":"1.2"}`+"\n", "")}, nil)
+	mockRuntime.On("RunContainer", mock.Anything, mock.MatchedBy(func(opts runtimePkg.RunOptions) bool {
+		switch opts.Command[0] {
+		case "plan":
+			_ = os.WriteFile(filepath.Join(scaffoldDir, PlanFileName), []byte("plan"), 0600)
+		case "apply":
+			applied = true
+		}
+		return true
+	})).Return(&MockReadCloser{data: []byte("ok")}, nil)
+
+	err := NewTerraformDockerProvisioner(mockRuntime).Provision(spec, true)
+	if err == nil || !strings.Contains(err.Error(), "policy command rejected the plan") {
+		t.Fatalf("Expected the policy command to reject the plan, got: %v", err)
+	}
+	if !strings.Contains(err.Error(), "deny: bucket is public") {
+		t.Errorf("Expected the policy command's output in the error, got: %s", err)
+	}
+	if applied {
+		t.Error("Expected apply to be skipped when the policy command rejects the plan")
+	}
+
+	content, err := os.ReadFile(received)
+	if err != nil || strings.TrimSpace(string(content)) != `{"format_version":"1.2"}` {
+		t.Errorf("Expected the policy command to receive the plan JSON, got %q (%v)", content, err)
+	}
+	if _, err := os.Stat(filepath.Join(scaffoldDir, PolicyPlanJSONFileName)); !os.IsNotExist(err) {
+		t.Error("Expected the plan JSON written for the policy command to be removed")
+	}
+}
+
+func TestTerraformDockerProvisioner_PolicyCommandAcceptsPlan(t *testing.T) {
+	scaffoldDir := t.TempDir()
+	planJSON := filepath.Join(t.TempDir(), "plan.json")
+	spec := &blueprint.Spec{
+		Scaffold: blueprint.Scaffold{Destination: scaffoldDir},
+		Cloud:    blueprint.CloudProvider{Provider: "aws", Region: "us-east-1"},
+		Provision: blueprint.Provision{
+			PlanJSON:            planJSON,
+			PolicyCommand:       `test "$KLONEKIT_PLAN_JSON" = "` + planJSON + `"`,
+			SkipCredentialCheck: true,
+		},
+	}
+
+	var applyCommand []string
+	mockRuntime := new(MockContainerRuntime)
+	mockRuntime.On("PullImage", mock.Anything, TerraformDockerImage).Return(nil)
+	mockRuntime.On("RunContainer", mock.Anything, mock.MatchedBy(isShowCommand)).
+		Return(&MockReadCloser{data: multiplexed(`{"format_version":"1.2"}`+"\n", "")}, nil)
+	mockRuntime.On("RunContainer", mock.Anything, mock.MatchedBy(func(opts runtimePkg.RunOptions) bool {
+		switch opts.Command[0] {
+		case "plan":
+			_ = os.WriteFile(filepath.Join(scaffoldDir, PlanFileName), []byte("plan"), 0600)
+		case "apply":
+			applyCommand = opts.Command
+		}
+		return true
+	})).Return(&MockReadCloser{data: []byte("ok")}, nil)
+
+	if err := NewTerraformDockerProvisioner(mockRuntime).Provision(spec, true); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if strings.Join(applyCommand, " ") != "apply -auto-approve "+PlanFileName {
+		t.Errorf("Expected the checked plan to be applied, got: %v", applyCommand)
+	}
+	if _, err := os.Stat(planJSON); err != nil {
+		t.Errorf("Expected the configured plan JSON to be kept: %s", err)
+	}
+}
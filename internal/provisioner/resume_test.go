@@ -0,0 +1,97 @@
+package provisioner
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+
+	"klonekit/pkg/blueprint"
+	runtimePkg "klonekit/pkg/runtime"
+)
+
+func TestTerraformDockerProvisioner_ResumesInterruptedApply(t *testing.T) {
+	scaffoldDir := t.TempDir()
+	planFile := filepath.Join(scaffoldDir, SavedPlanFileName)
+	if err := os.WriteFile(planFile, []byte("saved plan"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	spec := &blueprint.Spec{
+		Scaffold:  blueprint.Scaffold{Destination: scaffoldDir},
+		Provision: blueprint.Provision{UseSavedPlan: true, SkipPermissionFix: true},
+	}
+
+	// The first attempt is killed midway through applying the saved plan, after writing
+	// part of the state
+	var commands []string
+	crashing := new(MockContainerRuntime)
+	crashing.On("PullImage", mock.Anything, TerraformDockerImage).Return(nil)
+	crashing.On("RunContainer", mock.Anything, mock.MatchedBy(func(opts runtimePkg.RunOptions) bool {
+		return opts.Command[0] == "apply"
+	})).Run(func(args mock.Arguments) {
+		commands = append(commands, strings.Join(args.Get(1).(runtimePkg.RunOptions).Command, " "))
+		if err := os.WriteFile(filepath.Join(scaffoldDir, StateFileName), []byte(`{"version":4}`), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}).Return(&MockReadCloser{data: []byte("Creating..."), closeErr: errors.New("container exited with status 137")}, nil)
+	crashing.On("RunContainer", mock.Anything, mock.MatchedBy(func(opts runtimePkg.RunOptions) bool {
+		commands = append(commands, strings.Join(opts.Command, " "))
+		return true
+	})).Return(&MockReadCloser{data: []byte("ok")}, nil)
+
+	var steps []string
+	first := NewTerraformDockerProvisioner(crashing)
+	first.OnStep(func(step string) { steps = append(steps, step) })
+	if err := first.Provision(spec, true); err == nil {
+		t.Fatal("Expected the killed apply to fail provisioning")
+	}
+	if strings.Join(steps, ",") != "init,apply" {
+		t.Fatalf("Expected the steps up to the apply to be reported, got %v", steps)
+	}
+
+	// The resumed attempt plans against the partial state rather than applying the stale plan
+	commands = nil
+	resumed := NewTerraformDockerProvisioner(recordCommands(&commands))
+	resumed.SetResumedStep(steps[len(steps)-1])
+	if err := resumed.Provision(spec, true); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	want := []string{"init -input=false", "plan", "apply -auto-approve"}
+	if strings.Join(commands, ",") != strings.Join(want, ",") {
+		t.Errorf("Expected the resumed run to reconcile with init and plan, got %v", commands)
+	}
+}
+
+func TestTerraformDockerProvisioner_ReportsSteps(t *testing.T) {
+	spec := &blueprint.Spec{
+		Scaffold:  blueprint.Scaffold{Destination: t.TempDir()},
+		Provision: blueprint.Provision{SkipPermissionFix: true},
+	}
+
+	tests := []struct {
+		name        string
+		autoApprove bool
+		want        string
+	}{
+		{name: "apply", autoApprove: true, want: "init,plan,apply"},
+		{name: "validate only", want: "init,plan"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var commands, steps []string
+			provisioner := NewTerraformDockerProvisioner(recordCommands(&commands))
+			provisioner.OnStep(func(step string) { steps = append(steps, step) })
+			if err := provisioner.Provision(spec, tt.autoApprove); err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			if strings.Join(steps, ",") != tt.want {
+				t.Errorf("Expected steps %s, got %v", tt.want, steps)
+			}
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package provisioner
+
+import (
+	"fmt"
+	"strings"
+
+	"klonekit/internal/errors"
+)
+
+// stateLockError is the message terraform prints when the state lock is held by another run.
+const stateLockError = "Error acquiring the state lock"
+
+// stateLock is the lock info terraform prints when it can't acquire the state lock.
+type stateLock struct {
+	ID        string
+	Path      string
+	Operation string
+	Who       string
+	Created   string
+}
+
+// lockDetector recognizes a failed state lock acquisition in terraform output, collecting the
+// lock info printed with it.
+type lockDetector struct {
+	locked bool
+	lock   stateLock
+}
+
+// scan inspects a cleaned line of terraform output.
+func (d *lockDetector) scan(line string) {
+	// Newer terraform versions frame diagnostics with a box-drawing border
+	line = strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(line), "│╷╵"))
+	if strings.Contains(line, stateLockError) {
+		d.locked = true
+		return
+	}
+	if !d.locked {
+		return
+	}
+
+	key, value, ok := strings.Cut(line, ":")
+	if !ok {
+		return
+	}
+	value = strings.TrimSpace(value)
+	switch strings.TrimSpace(key) {
+	case "ID":
+		d.lock.ID = value
+	case "Path":
+		d.lock.Path = value
+	case "Operation":
+		d.lock.Operation = value
+	case "Who":
+		d.lock.Who = value
+	case "Created":
+		d.lock.Created = value
+	}
+}
+
+// err returns a provision error explaining who holds the lock if the output showed a failed
+// lock acquisition, or nil.
+func (d *lockDetector) err(cause error) error {
+	if !d.locked {
+		return nil
+	}
+
+	holder := d.lock.Who
+	if holder == "" {
+		holder = "another run"
+	}
+	var details []string
+	if d.lock.Operation != "" {
+		details = append(details, "operation "+d.lock.Operation)
+	}
+	if d.lock.Created != "" {
+		details = append(details, "since "+d.lock.Created)
+	}
+	if d.lock.Path != "" {
+		details = append(details, "state "+d.lock.Path)
+	}
+	reason := fmt.Sprintf("The Terraform state is locked by %s", holder)
+	if len(details) > 0 {
+		reason += " (" + strings.Join(details, ", ") + ")"
+	}
+
+	unlock := "klonekit exec -- force-unlock -force <lock ID>"
+	if d.lock.ID != "" {
+		unlock = "klonekit exec -- force-unlock -force " + d.lock.ID
+	}
+	return errors.NewProvisionError(
+		"Terraform could not acquire the state lock",
+		reason,
+		fmt.Sprintf("Wait for the other run to finish and retry. If it was interrupted and no longer runs, release the lock with '%s'", unlock),
+		fmt.Errorf("terraform state is locked by %s: %w", holder, cause),
+	)
+}
@@ -0,0 +1,117 @@
+package provisioner
+
+import (
+	stderrors "errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+
+	"klonekit/internal/errors"
+	"klonekit/pkg/blueprint"
+	runtimePkg "klonekit/pkg/runtime"
+)
+
+// lockedStateOutput is the output of terraform plan while another run holds the state lock
+const lockedStateOutput = `╷
+│ Error: Error acquiring the state lock
+│
+│ Error message: ConditionalCheckFailedException: The conditional request failed
+│ Lock Info:
+│   ID:        8e9d1c7b-5d2e-4c41-9a0f-2f6c1f3d7a10
+│   Path:      acme-terraform-state/payments/terraform.tfstate
+│   Operation: OperationTypeApply
+│   Who:       alice@build-agent-7
+│   Version:   1.8.0
+│   Created:   2024-05-14 09:12:33.123456 +0000 UTC
+│   Info:
+│
+│
+│ Terraform acquires a state lock to protect the state from being written
+│ by multiple users at the same time. Please resolve the issue above and try
+│ again. For most commands, you can disable locking with the "-lock=false"
+│ flag, but this is not recommended.
+╵
+`
+
+func TestTerraformDockerProvisioner_StateLockHeld(t *testing.T) {
+	spec := &blueprint.Spec{
+		Scaffold:  blueprint.Scaffold{Destination: t.TempDir()},
+		Cloud:     blueprint.CloudProvider{Provider: "aws", Region: "us-east-1"},
+		Provision: blueprint.Provision{SkipPermissionFix: true},
+	}
+
+	mockRuntime := new(MockContainerRuntime)
+	mockRuntime.On("PullImage", mock.Anything, TerraformDockerImage).Return(nil)
+	mockRuntime.On("RunContainer", mock.Anything, mock.MatchedBy(func(opts runtimePkg.RunOptions) bool {
+		return opts.Command[0] == "plan"
+	})).Return(&MockReadCloser{
+		data:     multiplexed("", lockedStateOutput),
+		closeErr: stderrors.New("container exited with code 1"),
+	}, nil)
+	mockRuntime.On("RunContainer", mock.Anything, mock.Anything).Return(&MockReadCloser{data: []byte("ok")}, nil)
+
+	err := NewTerraformDockerProvisioner(mockRuntime).Provision(spec, true)
+
+	var kkErr *errors.KloneKitError
+	if !stderrors.As(err, &kkErr) || kkErr.Type != errors.ErrProvisionFailed {
+		t.Fatalf("Expected a provision error, got: %v", err)
+	}
+	wantCause := "The Terraform state is locked by alice@build-agent-7 (operation OperationTypeApply, since 2024-05-14 09:12:33.123456 +0000 UTC, state acme-terraform-state/payments/terraform.tfstate)"
+	if kkErr.Cause != wantCause {
+		t.Errorf("Expected cause %q, got %q", wantCause, kkErr.Cause)
+	}
+	if !strings.Contains(kkErr.Suggestion, "klonekit exec -- force-unlock -force 8e9d1c7b-5d2e-4c41-9a0f-2f6c1f3d7a10") {
+		t.Errorf("Expected the suggestion to show how to force-unlock, got: %s", kkErr.Suggestion)
+	}
+	if !strings.Contains(err.Error(), "container exited with code 1") {
+		t.Errorf("Expected the container error to be kept, got: %s", err)
+	}
+}
+
+func TestLockDetector(t *testing.T) {
+	tests := []struct {
+		name       string
+		output     string
+		wantLocked bool
+		wantCause  string
+		wantUnlock string
+	}{
+		{
+			name:       "plain output without lock info",
+			output:     "Error: Error acquiring the state lock\n\nError message: resource temporarily unavailable\n",
+			wantLocked: true,
+			wantCause:  "The Terraform state is locked by another run",
+			wantUnlock: "force-unlock -force <lock ID>",
+		},
+		{
+			name:       "other failure",
+			output:     "Error: No valid credential sources found\n\nID: not a lock\n",
+			wantLocked: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var detector lockDetector
+			for _, line := range strings.Split(tt.output, "\n") {
+				detector.scan(line)
+			}
+
+			err := detector.err(stderrors.New("exit status 1"))
+			if !tt.wantLocked {
+				if err != nil {
+					t.Errorf("Expected no lock error, got: %v", err)
+				}
+				return
+			}
+			var kkErr *errors.KloneKitError
+			if !stderrors.As(err, &kkErr) {
+				t.Fatalf("Expected a lock error, got: %v", err)
+			}
+			if kkErr.Cause != tt.wantCause || !strings.Contains(kkErr.Suggestion, tt.wantUnlock) {
+				t.Errorf("Unexpected lock error: cause %q, suggestion %q", kkErr.Cause, kkErr.Suggestion)
+			}
+		})
+	}
+}
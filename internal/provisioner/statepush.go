@@ -0,0 +1,265 @@
+package provisioner
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"klonekit/internal/errors"
+	"klonekit/pkg/blueprint"
+	"klonekit/pkg/runtime"
+)
+
+const (
+	// StateFileName is the local Terraform state file in the scaffold directory
+	StateFileName = "terraform.tfstate"
+
+	// OutputsFileName is the file the root module outputs are pushed as
+	OutputsFileName = "outputs.json"
+
+	// EncryptedFileSuffix is appended to the names of encrypted state push uploads
+	EncryptedFileSuffix = ".enc"
+
+	// statePushDir is the scratch directory, inside the scaffold directory, used for S3 uploads
+	statePushDir = ".klonekit-state-push"
+
+	// statePushTimeout bounds each HTTP upload of the state push
+	statePushTimeout = 60 * time.Second
+)
+
+// stateArtifact is a file uploaded by the state push.
+type stateArtifact struct {
+	name    string
+	content []byte
+}
+
+// pushState uploads the local state and the root module outputs to spec.provision.statePush.url
+// after a successful apply, so the state isn't confined to this machine. The uploads are
+// encrypted when an encryption key is configured; plaintext uploads log a warning.
+func (p *TerraformDockerProvisioner) pushState(ctx context.Context, baseOpts runtime.RunOptions, spec *blueprint.Spec, scaffoldDir string) error {
+	pushURL := spec.Provision.StatePush.URL
+	// The URL may carry credentials in its userinfo, so only its redacted form is logged
+	logURL := spec.Provision.StatePush.RedactedURL()
+
+	state, err := os.ReadFile(filepath.Join(scaffoldDir, StateFileName)) // #nosec G304
+	if os.IsNotExist(err) {
+		slog.Warn("No state file to push; the project may use a remote backend", "dir", scaffoldDir)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", StateFileName, err)
+	}
+
+	outputs, err := extractOutputs(state)
+	if err != nil {
+		return err
+	}
+	artifacts := []stateArtifact{
+		{name: StateFileName, content: state},
+		{name: OutputsFileName, content: outputs},
+	}
+
+	key, err := statePushKey(spec.Provision.StatePush.EncryptionKeyFromEnv)
+	if err != nil {
+		return err
+	}
+	if key == nil {
+		slog.Warn("Pushing Terraform state unencrypted; it may contain secrets. Set spec.provision.statePush.encryptionKeyFromEnv to encrypt it", "url", logURL)
+	} else {
+		for i := range artifacts {
+			encrypted, err := encryptArtifact(key, artifacts[i].content)
+			if err != nil {
+				return fmt.Errorf("failed to encrypt %s: %w", artifacts[i].name, err)
+			}
+			artifacts[i].name += EncryptedFileSuffix
+			artifacts[i].content = encrypted
+		}
+	}
+
+	slog.Info("Pushing Terraform state", "url", logURL, "encrypted", key != nil)
+
+	if strings.HasPrefix(pushURL, "s3://") {
+		err = p.pushStateS3(ctx, baseOpts, scaffoldDir, pushURL, artifacts)
+	} else {
+		err = putStateHTTP(ctx, pushURL, logURL, artifacts, key != nil)
+	}
+	if err != nil {
+		return errors.NewProvisionError(
+			"Failed to push Terraform state",
+			fmt.Sprintf("Uploading the state to %s failed: %v", logURL, err),
+			fmt.Sprintf("The apply succeeded; upload %s from %s manually or check spec.provision.statePush.url", StateFileName, scaffoldDir),
+			fmt.Errorf("state push failed: %w", err),
+		)
+	}
+
+	slog.Info("Terraform state pushed successfully", "url", logURL)
+	return nil
+}
+
+// extractOutputs returns the root module outputs recorded in the state as a JSON object of
+// name to value. Sensitive outputs are left out so they aren't exposed in plaintext.
+func extractOutputs(state []byte) ([]byte, error) {
+	var parsed struct {
+		Outputs map[string]struct {
+			Value     interface{} `json:"value"`
+			Sensitive bool        `json:"sensitive"`
+		} `json:"outputs"`
+	}
+	if err := json.Unmarshal(state, &parsed); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", StateFileName, err)
+	}
+
+	outputs := make(map[string]interface{}, len(parsed.Outputs))
+	for name, output := range parsed.Outputs {
+		if output.Sensitive {
+			continue
+		}
+		outputs[name] = output.Value
+	}
+
+	content, err := json.MarshalIndent(outputs, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal outputs: %w", err)
+	}
+	return content, nil
+}
+
+// statePushKey reads the encryption key from the named host environment variable.
+// It returns nil when no variable is configured.
+func statePushKey(envName string) ([]byte, error) {
+	if envName == "" {
+		return nil, nil
+	}
+
+	encoded := os.Getenv(envName)
+	if encoded == "" {
+		return nil, fmt.Errorf("state push encryption key variable %s is not set", envName)
+	}
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("state push encryption key in %s is not valid base64: %w", envName, err)
+	}
+	if len(key) != 32 {
+		return nil, fmt.Errorf("state push encryption key in %s must be 32 bytes, got %d", envName, len(key))
+	}
+	return key, nil
+}
+
+// encryptArtifact encrypts content with AES-256-GCM, prefixing the random nonce.
+func encryptArtifact(key, content []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return gcm.Seal(nonce, nonce, content, nil), nil
+}
+
+// artifactURL joins the push URL prefix and an artifact name.
+func artifactURL(pushURL, name string) string {
+	return strings.TrimSuffix(pushURL, "/") + "/" + url.PathEscape(name)
+}
+
+// putStateHTTP uploads each artifact with an HTTP PUT below pushURL, logging the uploads
+// below logURL, its redacted form.
+func putStateHTTP(ctx context.Context, pushURL, logURL string, artifacts []stateArtifact, encrypted bool) error {
+	client := &http.Client{Timeout: statePushTimeout}
+
+	contentType := "application/json"
+	if encrypted {
+		contentType = "application/octet-stream"
+	}
+
+	for _, artifact := range artifacts {
+		target := artifactURL(pushURL, artifact.name)
+		req, err := http.NewRequestWithContext(ctx, http.MethodPut, target, bytes.NewReader(artifact.content))
+		if err != nil {
+			return fmt.Errorf("failed to create request for %s: %w", artifact.name, err)
+		}
+		req.Header.Set("Content-Type", contentType)
+
+		resp, err := client.Do(req)
+		if err != nil {
+			// The client's error quotes the request URL, credentials included
+			var urlErr *url.Error
+			if stderrors.As(err, &urlErr) {
+				urlErr.URL = artifactURL(logURL, artifact.name)
+			}
+			return fmt.Errorf("failed to upload %s: %w", artifact.name, err)
+		}
+		_ = resp.Body.Close()
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			return fmt.Errorf("upload of %s returned %s", artifact.name, resp.Status)
+		}
+		slog.Info("Uploaded state artifact", "url", artifactURL(logURL, artifact.name), "bytes", len(artifact.content))
+	}
+	return nil
+}
+
+// pushStateS3 uploads the artifacts with the AWS CLI image, using the same credentials
+// mounts and environment as the Terraform commands.
+func (p *TerraformDockerProvisioner) pushStateS3(ctx context.Context, baseOpts runtime.RunOptions, scaffoldDir, pushURL string, artifacts []stateArtifact) error {
+	// Stage the artifacts in a scratch directory the container can read
+	stageDir := filepath.Join(scaffoldDir, statePushDir)
+	if err := os.MkdirAll(stageDir, 0700); err != nil {
+		return fmt.Errorf("failed to create state push directory: %w", err)
+	}
+	defer os.RemoveAll(stageDir)
+
+	for _, artifact := range artifacts {
+		if err := os.WriteFile(filepath.Join(stageDir, artifact.name), artifact.content, 0600); err != nil {
+			return fmt.Errorf("failed to stage %s: %w", artifact.name, err)
+		}
+	}
+
+	if err := p.pullImage(ctx, AWSCLIDockerImage, "AWS CLI image"); err != nil {
+		return fmt.Errorf("state push: %w", err)
+	}
+
+	opts := baseOpts
+	opts.Image = AWSCLIDockerImage
+	opts.Entrypoint = nil
+	opts.Command = []string{"s3", "cp", "--recursive", WorkingDirectory + "/" + statePushDir, strings.TrimSuffix(pushURL, "/") + "/"}
+	opts.RetainContainer = false
+	if opts.ContainerName != "" {
+		opts.ContainerName = opts.ContainerName + "-state-push"
+	}
+
+	reader, err := p.containerRuntime.RunContainer(ctx, opts)
+	if err != nil {
+		return fmt.Errorf("failed to run state push container: %w", err)
+	}
+
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		if line := cleanDockerLogLine(scanner.Text()); line != "" {
+			slog.Info("State push output", "line", line)
+		}
+	}
+	if err := reader.Close(); err != nil {
+		return fmt.Errorf("aws s3 cp failed: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,230 @@
+package provisioner
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/base64"
+	"encoding/json"
+	stderrors "errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+
+	"klonekit/internal/errors"
+	"klonekit/pkg/blueprint"
+	runtimePkg "klonekit/pkg/runtime"
+)
+
+const testState = `{
+  "version": 4,
+  "outputs": {
+    "bucket_name": {"value": "my-bucket", "type": "string"},
+    "db_password": {"value": "hunter2", "type": "string", "sensitive": true}
+  },
+  "resources": []
+}`
+
+// stateStore is an httptest handler recording the bodies of PUT requests by path
+type stateStore struct {
+	mu      sync.Mutex
+	uploads map[string][]byte
+	status  int
+}
+
+func (s *stateStore) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	body, _ := io.ReadAll(r.Body)
+	s.mu.Lock()
+	s.uploads[r.URL.Path] = body
+	s.mu.Unlock()
+	if s.status != 0 {
+		w.WriteHeader(s.status)
+	}
+}
+
+// provisionWithStatePush runs a mocked successful apply for a scaffold directory holding testState
+func provisionWithStatePush(t *testing.T, statePush blueprint.StatePush) error {
+	t.Helper()
+
+	scaffoldDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(scaffoldDir, StateFileName), []byte(testState), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	spec := &blueprint.Spec{
+		Scaffold:  blueprint.Scaffold{Destination: scaffoldDir},
+		Cloud:     blueprint.CloudProvider{Provider: "aws", Region: "us-east-1"},
+		Provision: blueprint.Provision{StatePush: statePush},
+	}
+
+	mockRuntime := new(MockContainerRuntime)
+	mockRuntime.On("PullImage", mock.Anything, TerraformDockerImage).Return(nil)
+	mockRuntime.On("RunContainer", mock.Anything, mock.Anything).Return(&MockReadCloser{data: []byte("ok")}, nil)
+
+	return NewTerraformDockerProvisioner(mockRuntime).Provision(spec, true)
+}
+
+func TestTerraformDockerProvisioner_StatePushHTTP(t *testing.T) {
+	store := &stateStore{uploads: map[string][]byte{}}
+	server := httptest.NewServer(store)
+	defer server.Close()
+
+	if err := provisionWithStatePush(t, blueprint.StatePush{URL: server.URL + "/projects/demo/"}); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	state, ok := store.uploads["/projects/demo/"+StateFileName]
+	if !ok {
+		t.Fatalf("Expected state to be PUT, got uploads: %v", store.uploads)
+	}
+	if string(state) != testState {
+		t.Errorf("Expected uploaded state to match the local state, got: %s", state)
+	}
+
+	var outputs map[string]interface{}
+	if err := json.Unmarshal(store.uploads["/projects/demo/"+OutputsFileName], &outputs); err != nil {
+		t.Fatalf("Expected outputs to be PUT as JSON: %s", err)
+	}
+	if outputs["bucket_name"] != "my-bucket" {
+		t.Errorf("Expected bucket_name output, got: %v", outputs)
+	}
+	if _, found := outputs["db_password"]; found {
+		t.Errorf("Expected sensitive outputs to be left out, got: %v", outputs)
+	}
+}
+
+func TestTerraformDockerProvisioner_StatePushEncrypted(t *testing.T) {
+	key := []byte("0123456789abcdef0123456789abcdef")
+	t.Setenv("TEST_STATE_KEY", base64.StdEncoding.EncodeToString(key))
+
+	store := &stateStore{uploads: map[string][]byte{}}
+	server := httptest.NewServer(store)
+	defer server.Close()
+
+	err := provisionWithStatePush(t, blueprint.StatePush{URL: server.URL, EncryptionKeyFromEnv: "TEST_STATE_KEY"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	encrypted, ok := store.uploads["/"+StateFileName+EncryptedFileSuffix]
+	if !ok {
+		t.Fatalf("Expected encrypted state to be PUT, got uploads: %v", store.uploads)
+	}
+	if strings.Contains(string(encrypted), "my-bucket") {
+		t.Error("Expected uploaded state to be encrypted")
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		t.Fatal(err)
+	}
+	nonce, ciphertext := encrypted[:gcm.NonceSize()], encrypted[gcm.NonceSize():]
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		t.Fatalf("Failed to decrypt uploaded state: %s", err)
+	}
+	if string(plaintext) != testState {
+		t.Errorf("Expected decrypted state to match the local state, got: %s", plaintext)
+	}
+}
+
+func TestTerraformDockerProvisioner_StatePushFailure(t *testing.T) {
+	store := &stateStore{uploads: map[string][]byte{}, status: http.StatusForbidden}
+	server := httptest.NewServer(store)
+	defer server.Close()
+
+	err := provisionWithStatePush(t, blueprint.StatePush{URL: server.URL})
+	if err == nil {
+		t.Fatal("Expected error when the state upload is rejected, got nil")
+	}
+	var kkErr *errors.KloneKitError
+	if !stderrors.As(err, &kkErr) || kkErr.Type != errors.ErrProvisionFailed {
+		t.Errorf("Expected a provision error, got: %v", err)
+	}
+}
+
+func TestTerraformDockerProvisioner_StatePushRedactsCredentials(t *testing.T) {
+	var logs bytes.Buffer
+	previous := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(&logs, nil)))
+	defer slog.SetDefault(previous)
+
+	store := &stateStore{uploads: map[string][]byte{}}
+	server := httptest.NewServer(store)
+	defer server.Close()
+
+	for _, userinfo := range []string{"uploader:s3cr3t-pass@", "s3cr3t-token@"} {
+		pushURL := strings.Replace(server.URL, "://", "://"+userinfo, 1)
+		if err := provisionWithStatePush(t, blueprint.StatePush{URL: pushURL}); err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+	}
+
+	if _, ok := store.uploads["/"+StateFileName]; !ok {
+		t.Fatalf("Expected state to be PUT, got uploads: %v", store.uploads)
+	}
+	if strings.Contains(logs.String(), "s3cr3t") {
+		t.Errorf("Expected the push URL credentials to be redacted in logs, got:\n%s", logs.String())
+	}
+	if !strings.Contains(logs.String(), "xxxxx@") {
+		t.Errorf("Expected the redacted push URL to be logged, got:\n%s", logs.String())
+	}
+}
+
+func TestTerraformDockerProvisioner_StatePushS3(t *testing.T) {
+	scaffoldDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(scaffoldDir, StateFileName), []byte(testState), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	spec := &blueprint.Spec{
+		Scaffold: blueprint.Scaffold{Destination: scaffoldDir},
+		Cloud:    blueprint.CloudProvider{Provider: "aws", Region: "us-east-1"},
+		Provision: blueprint.Provision{
+			StatePush: blueprint.StatePush{URL: "s3://state-bucket/demo"},
+		},
+	}
+
+	var pushCommand []string
+	var stagedState []byte
+	mockRuntime := new(MockContainerRuntime)
+	mockRuntime.On("PullImage", mock.Anything, mock.Anything).Return(nil)
+	mockRuntime.On("RunContainer", mock.Anything, mock.MatchedBy(func(opts runtimePkg.RunOptions) bool {
+		if opts.Image == AWSCLIDockerImage {
+			pushCommand = opts.Command
+			stagedState, _ = os.ReadFile(filepath.Join(scaffoldDir, statePushDir, StateFileName))
+		}
+		return true
+	})).Return(&MockReadCloser{data: []byte("ok")}, nil)
+
+	if err := NewTerraformDockerProvisioner(mockRuntime).Provision(spec, true); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	want := []string{"s3", "cp", "--recursive", WorkingDirectory + "/" + statePushDir, "s3://state-bucket/demo/"}
+	if strings.Join(pushCommand, " ") != strings.Join(want, " ") {
+		t.Errorf("Expected aws %v, got %v", want, pushCommand)
+	}
+	if string(stagedState) != testState {
+		t.Errorf("Expected the state to be staged for upload, got: %s", stagedState)
+	}
+	if _, err := os.Stat(filepath.Join(scaffoldDir, statePushDir)); !os.IsNotExist(err) {
+		t.Error("Expected the state push scratch directory to be removed")
+	}
+}
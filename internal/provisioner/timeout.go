@@ -0,0 +1,35 @@
+package provisioner
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+
+	"klonekit/internal/errors"
+	"klonekit/pkg/blueprint"
+)
+
+// runContext returns the context a provisioning run executes in, which expires after
+// spec.provision.timeout when it is set.
+func runContext(spec *blueprint.Spec) (context.Context, context.CancelFunc) {
+	if timeout := spec.Provision.RunTimeout(); timeout > 0 {
+		return context.WithTimeout(context.Background(), timeout)
+	}
+	return context.WithCancel(context.Background())
+}
+
+// timeoutError reports the error of a run whose context expired as a provisioning timeout.
+// The runtime removes the container of the command that was stopped.
+func timeoutError(ctx context.Context, spec *blueprint.Spec, err error) error {
+	if err == nil || !stderrors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return err
+	}
+
+	timeout := spec.Provision.RunTimeout()
+	return errors.NewProvisionError(
+		"Provisioning timed out",
+		fmt.Sprintf("Terraform did not finish within the %s timeout", timeout),
+		"Check the Terraform output for the resource it was waiting on, or raise spec.provision.timeout (or --timeout). An apply that was stopped may have left resources partially created",
+		fmt.Errorf("provisioning timed out after %s: %w", timeout, err),
+	)
+}
@@ -0,0 +1,100 @@
+package provisioner
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+
+	"klonekit/internal/errors"
+	"klonekit/pkg/blueprint"
+	runtimePkg "klonekit/pkg/runtime"
+)
+
+// slowReadCloser simulates a Terraform command that hangs after its first line of output,
+// until the context of its run is cancelled, as the Docker runtime's reader does.
+type slowReadCloser struct {
+	ctx    context.Context
+	sent   bool
+	closed bool
+}
+
+func (r *slowReadCloser) Read(p []byte) (int, error) {
+	if !r.sent {
+		r.sent = true
+		return copy(p, "aws_instance.web: Still creating...\n"), nil
+	}
+	<-r.ctx.Done()
+	return 0, r.ctx.Err()
+}
+
+func (r *slowReadCloser) Close() error {
+	r.closed = true
+	if err := r.ctx.Err(); err != nil {
+		return fmt.Errorf("container stopped before it finished: %w", err)
+	}
+	return nil
+}
+
+func TestTerraformDockerProvisioner_Timeout(t *testing.T) {
+	spec := &blueprint.Spec{
+		Scaffold: blueprint.Scaffold{Destination: t.TempDir()},
+		Cloud:    blueprint.CloudProvider{Provider: "aws", Region: "us-east-1"},
+		Provision: blueprint.Provision{
+			Timeout:             "100ms",
+			SkipCredentialCheck: true,
+			SkipPermissionFix:   true,
+		},
+	}
+
+	isApply := func(opts runtimePkg.RunOptions) bool { return opts.Command[0] == "apply" }
+	apply := &slowReadCloser{}
+	mockRuntime := new(MockContainerRuntime)
+	mockRuntime.On("PullImage", mock.Anything, mock.Anything).Return(nil)
+	mockRuntime.On("RunContainer", mock.Anything, mock.MatchedBy(isApply)).
+		Run(func(args mock.Arguments) { apply.ctx = args.Get(0).(context.Context) }).
+		Return(apply, nil).Once()
+	mockRuntime.On("RunContainer", mock.Anything, mock.MatchedBy(func(opts runtimePkg.RunOptions) bool { return !isApply(opts) })).
+		Return(&MockReadCloser{data: []byte("ok\n")}, nil)
+
+	start := time.Now()
+	err := NewTerraformDockerProvisioner(mockRuntime).Provision(spec, true)
+	elapsed := time.Since(start)
+
+	var kloneKitErr *errors.KloneKitError
+	if !stderrors.As(err, &kloneKitErr) || kloneKitErr.Context != "Provisioning timed out" {
+		t.Fatalf("Expected a provisioning timeout error, got: %v", err)
+	}
+	if !strings.Contains(kloneKitErr.Cause, "within the 100ms timeout") {
+		t.Errorf("Expected the cause to name the timeout, got %q", kloneKitErr.Cause)
+	}
+	if kloneKitErr.Type != errors.ErrProvisionFailed || !stderrors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected a provision error wrapping the deadline, got: %v", err)
+	}
+	if !apply.closed {
+		t.Error("Expected the stopped apply's output to be closed, so its container is removed")
+	}
+	if elapsed > 5*time.Second {
+		t.Errorf("Expected Provision to stop at the timeout, took %s", elapsed)
+	}
+	mockRuntime.AssertExpectations(t)
+}
+
+func TestTerraformDockerProvisioner_NoTimeout(t *testing.T) {
+	spec := &blueprint.Spec{Provision: blueprint.Provision{}}
+
+	ctx, cancel := runContext(spec)
+	defer cancel()
+
+	if _, ok := ctx.Deadline(); ok {
+		t.Error("Expected no deadline without spec.provision.timeout")
+	}
+	failure := stderrors.New("terraform command failed")
+	if err := timeoutError(ctx, spec, failure); err != failure {
+		t.Errorf("Expected errors of runs that didn't time out to be returned as is, got: %v", err)
+	}
+}
@@ -1,6 +1,11 @@
 package provisioner
 
-import "klonekit/pkg/blueprint"
+import (
+	"context"
+	"encoding/json"
+
+	"klonekit/pkg/blueprint"
+)
 
 // Provisioner defines the interface for infrastructure provisioning operations.
 // This interface is provider-agnostic and can be implemented by any provisioning tool
@@ -9,4 +14,48 @@ type Provisioner interface {
 	// Provision executes the infrastructure provisioning based on the blueprint specification.
 	// The autoApprove parameter controls whether to automatically apply changes or just validate.
 	Provision(spec *blueprint.Spec, autoApprove bool) error
-}
\ No newline at end of file
+}
+
+// RunScoped is implemented by provisioners that keep artifacts per run.
+type RunScoped interface {
+	// SetRunID sets the ID of the run whose artifacts are written.
+	SetRunID(runID string)
+}
+
+// Steps of Provision reported to ResumableProvisioner.OnStep, each as it starts.
+const (
+	StepInit  = "init"
+	StepPlan  = "plan"
+	StepApply = "apply"
+)
+
+// ResumableProvisioner is implemented by provisioners that report how far Provision got, so
+// a run interrupted mid-provision (e.g. the container was killed) resumes knowing an apply
+// may have partially changed the infrastructure.
+type ResumableProvisioner interface {
+	// SetResumedStep sets the last step the interrupted attempt started, if any.
+	SetResumedStep(step string)
+	// OnStep registers a function called as each step starts.
+	OnStep(fn func(step string))
+}
+
+// ImagePrePuller is implemented by provisioners that can pull their image ahead of
+// Provision, so the pull overlaps earlier work.
+type ImagePrePuller interface {
+	// PrePullImage pulls the image Provision will run for the spec; Provision then doesn't
+	// pull it again.
+	PrePullImage(ctx context.Context, spec *blueprint.Spec) error
+}
+
+// Output is a root module output captured after an apply.
+type Output struct {
+	Value     json.RawMessage `json:"value"`
+	Sensitive bool            `json:"sensitive"`
+}
+
+// OutputReporter is implemented by provisioners that capture the root module outputs after
+// an apply.
+type OutputReporter interface {
+	// Outputs returns the outputs captured by the last apply, or nil.
+	Outputs() map[string]Output
+}
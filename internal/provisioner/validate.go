@@ -0,0 +1,43 @@
+package provisioner
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"klonekit/internal/errors"
+)
+
+// ValidateScaffold checks that the scaffold destination exists and contains Terraform
+// configuration, so provisioning an unscaffolded directory fails with clear guidance
+// instead of a confusing terraform init error.
+func ValidateScaffold(scaffoldDir string) error {
+	entries, err := os.ReadDir(scaffoldDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return errors.NewProvisionError(
+				"Cannot provision infrastructure",
+				fmt.Sprintf("Scaffold directory does not exist: %s", scaffoldDir),
+				"Run 'klonekit scaffold' (or 'klonekit apply') first to generate the Terraform files",
+				fmt.Errorf("scaffold directory does not exist: %s", scaffoldDir),
+			)
+		}
+		return fmt.Errorf("failed to read scaffold directory %s: %w", scaffoldDir, err)
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		if name := entry.Name(); strings.HasSuffix(name, ".tf") || strings.HasSuffix(name, ".tf.json") {
+			return nil
+		}
+	}
+
+	return errors.NewProvisionError(
+		"Cannot provision infrastructure",
+		fmt.Sprintf("Scaffold directory %s contains no Terraform (.tf) files", scaffoldDir),
+		"Run 'klonekit scaffold' (or 'klonekit apply') first to generate the Terraform files",
+		fmt.Errorf("no terraform files found in scaffold directory: %s", scaffoldDir),
+	)
+}
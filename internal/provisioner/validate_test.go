@@ -0,0 +1,107 @@
+package provisioner
+
+import (
+	stderrors "errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+
+	"klonekit/internal/errors"
+	"klonekit/pkg/blueprint"
+	runtimePkg "klonekit/pkg/runtime"
+)
+
+func TestValidateScaffold(t *testing.T) {
+	tests := []struct {
+		name        string
+		files       map[string]string
+		expectError bool
+	}{
+		{
+			name:        "Empty destination",
+			files:       map[string]string{},
+			expectError: true,
+		},
+		{
+			name:        "Only non-terraform files",
+			files:       map[string]string{"README.md": "# project", "modules/vpc/main.tf": "# nested"},
+			expectError: true,
+		},
+		{
+			name:  "Terraform file present",
+			files: map[string]string{"main.tf": "# terraform"},
+		},
+		{
+			name:  "Terraform JSON file present",
+			files: map[string]string{"main.tf.json": "{}"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, content := range tt.files {
+				path := filepath.Join(dir, name)
+				if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			err := ValidateScaffold(dir)
+			if !tt.expectError {
+				if err != nil {
+					t.Errorf("Unexpected error: %s", err)
+				}
+				return
+			}
+
+			var kkErr *errors.KloneKitError
+			if !stderrors.As(err, &kkErr) || kkErr.Type != errors.ErrProvisionFailed {
+				t.Fatalf("Expected a provision error, got: %v", err)
+			}
+			if !strings.Contains(kkErr.Suggestion, "klonekit scaffold") {
+				t.Errorf("Expected suggestion to run scaffold first, got: %s", kkErr.Suggestion)
+			}
+		})
+	}
+}
+
+func TestValidateScaffold_MissingDirectory(t *testing.T) {
+	err := ValidateScaffold(filepath.Join(t.TempDir(), "missing"))
+
+	var kkErr *errors.KloneKitError
+	if !stderrors.As(err, &kkErr) || kkErr.Type != errors.ErrProvisionFailed {
+		t.Fatalf("Expected a provision error, got: %v", err)
+	}
+}
+
+func TestValidateScaffold_ThenProvisionProceeds(t *testing.T) {
+	scaffoldDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(scaffoldDir, "main.tf"), []byte("# terraform"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ValidateScaffold(scaffoldDir); err != nil {
+		t.Fatalf("Unexpected validation error: %s", err)
+	}
+
+	mockRuntime := new(MockContainerRuntime)
+	mockRuntime.On("PullImage", mock.Anything, TerraformDockerImage).Return(nil)
+	mockRuntime.On("RunContainer", mock.Anything, mock.MatchedBy(func(opts runtimePkg.RunOptions) bool {
+		return opts.VolumeMounts[scaffoldDir] == WorkingDirectory
+	})).Return(&MockReadCloser{data: []byte("ok")}, nil)
+
+	spec := &blueprint.Spec{
+		Scaffold: blueprint.Scaffold{Destination: scaffoldDir},
+		Cloud:    blueprint.CloudProvider{Region: "us-east-1"},
+	}
+	if err := NewTerraformDockerProvisioner(mockRuntime).Provision(spec, false); err != nil {
+		t.Fatalf("Expected provisioning to proceed, got: %s", err)
+	}
+	mockRuntime.AssertNumberOfCalls(t, "RunContainer", 2)
+}
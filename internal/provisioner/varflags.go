@@ -0,0 +1,64 @@
+package provisioner
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// maskedVarValue replaces -var values in logged commands, since variables can hold secrets.
+const maskedVarValue = "***"
+
+// varFlagArgs returns a "-var", "name=value" pair per variable, sorted by name for
+// reproducible commands. Strings are passed as is; numbers, bools, lists and maps are
+// JSON-encoded, which Terraform parses as the equivalent HCL expression.
+func varFlagArgs(vars map[string]interface{}) ([]string, error) {
+	names := make([]string, 0, len(vars))
+	for name := range vars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	args := make([]string, 0, 2*len(names))
+	for _, name := range names {
+		value, err := varFlagValue(vars[name])
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode variable %s as a -var flag: %w", name, err)
+		}
+		args = append(args, "-var", name+"="+value)
+	}
+	return args, nil
+}
+
+// varFlagValue encodes a variable value for a -var flag.
+func varFlagValue(value interface{}) (string, error) {
+	if s, ok := value.(string); ok {
+		return s, nil
+	}
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	return string(encoded), nil
+}
+
+// maskVarFlags returns a copy of a Terraform command with the values of -var flags masked.
+func maskVarFlags(args []string) []string {
+	masked := make([]string, len(args))
+	copy(masked, args)
+	for i, arg := range masked {
+		if arg == "-var" && i+1 < len(masked) {
+			masked[i+1] = maskVarAssignment(masked[i+1])
+		} else if assignment, ok := strings.CutPrefix(arg, "-var="); ok {
+			masked[i] = "-var=" + maskVarAssignment(assignment)
+		}
+	}
+	return masked
+}
+
+// maskVarAssignment masks the value of a name=value assignment.
+func maskVarAssignment(assignment string) string {
+	name, _, _ := strings.Cut(assignment, "=")
+	return name + "=" + maskedVarValue
+}
@@ -0,0 +1,98 @@
+package provisioner
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+
+	"klonekit/pkg/blueprint"
+	runtimePkg "klonekit/pkg/runtime"
+)
+
+func TestVarFlagArgs(t *testing.T) {
+	vars := map[string]interface{}{
+		"region":         "us-east-1",
+		"instance_count": 3,
+		"enabled":        true,
+		"azs":            []interface{}{"us-east-1a", "us-east-1b"},
+		"tags":           map[string]interface{}{"Team": "platform", "Env": "prod"},
+	}
+
+	args, err := varFlagArgs(vars)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	want := []string{
+		"-var", `azs=["us-east-1a","us-east-1b"]`,
+		"-var", "enabled=true",
+		"-var", "instance_count=3",
+		"-var", "region=us-east-1",
+		"-var", `tags={"Env":"prod","Team":"platform"}`,
+	}
+	if !reflect.DeepEqual(args, want) {
+		t.Errorf("Expected %v, got %v", want, args)
+	}
+}
+
+func TestMaskVarFlags(t *testing.T) {
+	args := []string{"plan", "-var", "db_password=hunter2", "-var=token=abc", "-var-file=prod.tfvars"}
+	want := []string{"plan", "-var", "db_password=***", "-var=token=***", "-var-file=prod.tfvars"}
+
+	if got := maskVarFlags(args); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+	if args[2] != "db_password=hunter2" {
+		t.Error("Expected maskVarFlags to leave the command itself unchanged")
+	}
+}
+
+func TestTerraformDockerProvisioner_VariablesMode(t *testing.T) {
+	tests := []struct {
+		mode         string
+		wantVarFlags bool
+	}{
+		{mode: "", wantVarFlags: false},
+		{mode: blueprint.VariablesModeTfvars, wantVarFlags: false},
+		{mode: blueprint.VariablesModeFlags, wantVarFlags: true},
+		{mode: blueprint.VariablesModeBoth, wantVarFlags: true},
+	}
+
+	for _, tt := range tests {
+		t.Run("mode "+tt.mode, func(t *testing.T) {
+			spec := &blueprint.Spec{
+				Scaffold:  blueprint.Scaffold{Destination: t.TempDir()},
+				Provision: blueprint.Provision{VariablesMode: tt.mode},
+				Variables: map[string]interface{}{
+					"region": "us-east-1",
+					"tags":   map[string]interface{}{"Team": "platform"},
+				},
+			}
+
+			commands := map[string][]string{}
+			mockRuntime := new(MockContainerRuntime)
+			mockRuntime.On("PullImage", mock.Anything, TerraformDockerImage).Return(nil)
+			mockRuntime.On("RunContainer", mock.Anything, mock.MatchedBy(func(opts runtimePkg.RunOptions) bool {
+				commands[opts.Command[0]] = opts.Command
+				return true
+			})).Return(&MockReadCloser{data: []byte("ok")}, nil)
+
+			if err := NewTerraformDockerProvisioner(mockRuntime).Provision(spec, true); err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+
+			wantFlags := `-var region=us-east-1 -var tags={"Team":"platform"}`
+			for _, command := range []string{"plan", "apply"} {
+				joined := strings.Join(commands[command], " ")
+				if got := strings.Contains(joined, wantFlags); got != tt.wantVarFlags {
+					t.Errorf("Expected -var flags on %s: %t, got: %s", command, tt.wantVarFlags, joined)
+				}
+			}
+			if strings.Contains(strings.Join(commands["init"], " "), "-var") {
+				t.Errorf("Expected no -var flags on init, got: %v", commands["init"])
+			}
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// DefaultMaxRetries is the number of times a failing network operation is retried by default.
+const DefaultMaxRetries = 3
+
+// Policy controls how failing network operations are retried.
+type Policy struct {
+	// MaxRetries is the number of retries after the first attempt; 0 disables retrying.
+	MaxRetries int
+	// InitialBackoff is the wait before the first retry; it doubles for each later retry.
+	InitialBackoff time.Duration
+	// MaxBackoff caps the wait between retries.
+	MaxBackoff time.Duration
+}
+
+// DefaultPolicy returns the policy used unless --max-retries is given.
+func DefaultPolicy() Policy {
+	return Policy{
+		MaxRetries:     DefaultMaxRetries,
+		InitialBackoff: time.Second,
+		MaxBackoff:     30 * time.Second,
+	}
+}
+
+var (
+	mu      sync.RWMutex
+	current = DefaultPolicy()
+)
+
+// Current returns the shared policy used by the SCM client, image pulls and terraform init.
+func Current() Policy {
+	mu.RLock()
+	defer mu.RUnlock()
+	return current
+}
+
+// SetPolicy replaces the shared policy.
+func SetPolicy(p Policy) {
+	mu.Lock()
+	defer mu.Unlock()
+	current = p
+}
+
+// SetMaxRetries changes the number of retries of the shared policy.
+func SetMaxRetries(n int) error {
+	if n < 0 {
+		return fmt.Errorf("max retries must not be negative, got %d", n)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	current.MaxRetries = n
+	return nil
+}
+
+// permanentError marks an error that retrying cannot fix.
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent wraps err so Do returns it without retrying, e.g. for authentication failures.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// Do runs fn, retrying failures with exponential backoff up to p.MaxRetries times.
+// It stops early when fn returns a Permanent error or ctx is done, and returns the last error.
+func (p Policy) Do(ctx context.Context, operation string, fn func() error) error {
+	backoff := p.InitialBackoff
+	for attempt := 0; ; attempt++ {
+		err := fn()
+		if err == nil {
+			return nil
+		}
+
+		var permanent *permanentError
+		if errors.As(err, &permanent) {
+			return permanent.err
+		}
+		if attempt >= p.MaxRetries {
+			if attempt > 0 {
+				return fmt.Errorf("%s failed after %d attempts: %w", operation, attempt+1, err)
+			}
+			return err
+		}
+
+		slog.Warn("Retrying failed operation", "operation", operation, "attempt", attempt+1, "maxRetries", p.MaxRetries, "backoff", backoff, "error", err)
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(backoff):
+		}
+
+		backoff *= 2
+		if p.MaxBackoff > 0 && backoff > p.MaxBackoff {
+			backoff = p.MaxBackoff
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func fastPolicy(maxRetries int) Policy {
+	return Policy{MaxRetries: maxRetries, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond}
+}
+
+func TestPolicy_Do_RetriesUntilSuccess(t *testing.T) {
+	attempts := 0
+	err := fastPolicy(3).Do(context.Background(), "flaky", func() error {
+		attempts++
+		if attempts < 3 {
+			return errors.New("temporary failure")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Expected success after retries, got: %v", err)
+	}
+	if attempts != 3 {
+		t.Errorf("Expected 3 attempts, got %d", attempts)
+	}
+}
+
+func TestPolicy_Do_GivesUpAfterMaxRetries(t *testing.T) {
+	attempts := 0
+	err := fastPolicy(2).Do(context.Background(), "always failing", func() error {
+		attempts++
+		return errors.New("temporary failure")
+	})
+	if err == nil {
+		t.Fatal("Expected error after exhausting retries, got nil")
+	}
+	if attempts != 3 {
+		t.Errorf("Expected 3 attempts (1 + 2 retries), got %d", attempts)
+	}
+	if !strings.Contains(err.Error(), "always failing failed after 3 attempts") {
+		t.Errorf("Expected error to report the attempts, got: %v", err)
+	}
+}
+
+func TestPolicy_Do_PermanentErrorIsNotRetried(t *testing.T) {
+	cause := errors.New("unauthorized")
+	attempts := 0
+	err := fastPolicy(3).Do(context.Background(), "auth", func() error {
+		attempts++
+		return Permanent(cause)
+	})
+	if attempts != 1 {
+		t.Errorf("Expected a single attempt, got %d", attempts)
+	}
+	if err != cause {
+		t.Errorf("Expected the unwrapped cause, got: %v", err)
+	}
+}
+
+func TestPolicy_Do_StopsWhenContextIsDone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	attempts := 0
+	policy := Policy{MaxRetries: 3, InitialBackoff: time.Hour}
+	if err := policy.Do(ctx, "cancelled", func() error {
+		attempts++
+		return errors.New("temporary failure")
+	}); err == nil {
+		t.Fatal("Expected error, got nil")
+	}
+	if attempts != 1 {
+		t.Errorf("Expected no retries after cancellation, got %d attempts", attempts)
+	}
+}
+
+func TestSetMaxRetries(t *testing.T) {
+	original := Current()
+	defer SetPolicy(original)
+
+	if err := SetMaxRetries(5); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if Current().MaxRetries != 5 {
+		t.Errorf("Expected max retries 5, got %d", Current().MaxRetries)
+	}
+	if err := SetMaxRetries(-1); err == nil {
+		t.Error("Expected error for negative max retries")
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"log/slog"
 	"os"
 	"path/filepath"
+	"sync"
 	"time"
 
 	"github.com/docker/docker/api/types/container"
@@ -17,8 +18,15 @@ import (
 	"klonekit/pkg/runtime"
 )
 
+// pingTimeout bounds the daemon check made before each Docker operation.
+const pingTimeout = 5 * time.Second
+
+// resolveDockerClient creates a connected Docker client; tests replace it.
+var resolveDockerClient = createDockerClientWithDynamicSocket
+
 // DockerRuntime implements the ContainerRuntime interface using Docker client.
 type DockerRuntime struct {
+	mu     sync.Mutex
 	client *client.Client
 }
 
@@ -41,6 +49,13 @@ func NewDockerRuntime() (*DockerRuntime, error) {
 	}, nil
 }
 
+// Close releases the connection to the Docker daemon.
+func (d *DockerRuntime) Close() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.client.Close()
+}
+
 // createDockerClientWithDynamicSocket creates a Docker client with dynamic socket detection.
 // It tries multiple socket locations in order of preference for different Docker setups.
 func createDockerClientWithDynamicSocket() (*client.Client, error) {
@@ -107,6 +122,34 @@ func createDockerClientWithDynamicSocket() (*client.Client, error) {
 	return dockerClient, nil
 }
 
+// ensureClient pings the Docker daemon before an operation. If the daemon is unreachable,
+// e.g. because the user switched from Colima to Docker Desktop since the client was created,
+// the socket is re-resolved once and the new client replaces the stale one.
+func (d *DockerRuntime) ensureClient(ctx context.Context) (*client.Client, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	pingCtx, cancel := context.WithTimeout(ctx, pingTimeout)
+	_, pingErr := d.client.Ping(pingCtx)
+	cancel()
+	if pingErr == nil {
+		return d.client, nil
+	}
+
+	slog.Warn("Docker daemon is unreachable, re-resolving the Docker socket", "host", d.client.DaemonHost(), "error", pingErr)
+	newClient, err := resolveDockerClient()
+	if err != nil {
+		return nil, fmt.Errorf("failed to reconnect to Docker daemon after %v: %w", pingErr, err)
+	}
+
+	if cerr := d.client.Close(); cerr != nil {
+		slog.Debug("Error closing stale Docker client", "error", cerr)
+	}
+	d.client = newClient
+	slog.Info("Reconnected to Docker daemon", "host", newClient.DaemonHost())
+	return newClient, nil
+}
+
 // getDockerSocketPaths returns a list of potential Docker socket paths in order of preference.
 func getDockerSocketPaths() []string {
 	var socketPaths []string
@@ -151,7 +194,12 @@ func getDockerSocketPaths() []string {
 func (d *DockerRuntime) PullImage(ctx context.Context, imageName string) error {
 	slog.Info("Pulling Docker image", "image", imageName)
 
-	reader, err := d.client.ImagePull(ctx, imageName, image.PullOptions{})
+	dockerClient, err := d.ensureClient(ctx)
+	if err != nil {
+		return err
+	}
+
+	reader, err := dockerClient.ImagePull(ctx, imageName, image.PullOptions{})
 	if err != nil {
 		return fmt.Errorf("failed to pull image %s: %w", imageName, err)
 	}
@@ -167,9 +215,34 @@ func (d *DockerRuntime) PullImage(ctx context.Context, imageName string) error {
 	return nil
 }
 
+// ImageInspect returns the entrypoint of a pulled image.
+func (d *DockerRuntime) ImageInspect(ctx context.Context, imageName string) (runtime.ImageInfo, error) {
+	dockerClient, err := d.ensureClient(ctx)
+	if err != nil {
+		return runtime.ImageInfo{}, err
+	}
+
+	inspect, err := dockerClient.ImageInspect(ctx, imageName)
+	if err != nil {
+		return runtime.ImageInfo{}, fmt.Errorf("failed to inspect image %s: %w", imageName, err)
+	}
+
+	var info runtime.ImageInfo
+	if inspect.Config != nil {
+		info.Entrypoint = inspect.Config.Entrypoint
+	}
+	return info, nil
+}
+
 // RunContainer runs a container and returns the output reader.
 func (d *DockerRuntime) RunContainer(ctx context.Context, opts runtime.RunOptions) (io.ReadCloser, error) {
-	slog.Info("Running container", "image", opts.Image, "command", opts.Command)
+	// The command isn't logged here since it can carry -var values; callers log a masked form
+	slog.Info("Running container", "image", opts.Image, "name", opts.ContainerName)
+
+	dockerClient, err := d.ensureClient(ctx)
+	if err != nil {
+		return nil, err
+	}
 
 	// Create volume mounts
 	var mounts []mount.Mount
@@ -190,16 +263,15 @@ func (d *DockerRuntime) RunContainer(ctx context.Context, opts runtime.RunOption
 	// Create container configuration
 	containerConfig := &container.Config{
 		Image:      opts.Image,
+		Entrypoint: opts.Entrypoint,
 		Cmd:        opts.Command,
 		Env:        envVars,
 		WorkingDir: opts.WorkingDirectory,
 	}
 
-	hostConfig := &container.HostConfig{
-		Mounts:      mounts,
-		NetworkMode: "default", // Use default Docker network for internet access
-		DNS:         []string{"8.8.8.8", "8.8.4.4"}, // Add public DNS servers
-		DNSOptions:  []string{"ndots:0"}, // Improve DNS resolution performance
+	hostConfig, err := buildHostConfig(opts, mounts)
+	if err != nil {
+		return nil, err
 	}
 
 	// Set container user if specified to avoid permission issues
@@ -209,7 +281,7 @@ func (d *DockerRuntime) RunContainer(ctx context.Context, opts runtime.RunOption
 
 	// Create container with optional name
 	containerName := opts.ContainerName
-	resp, err := d.client.ContainerCreate(ctx, containerConfig, hostConfig, nil, nil, containerName)
+	resp, err := dockerClient.ContainerCreate(ctx, containerConfig, hostConfig, nil, nil, containerName)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create container: %w", err)
 	}
@@ -217,9 +289,11 @@ func (d *DockerRuntime) RunContainer(ctx context.Context, opts runtime.RunOption
 	containerID := resp.ID
 
 	// Start container
-	if err := d.client.ContainerStart(ctx, containerID, container.StartOptions{}); err != nil {
-		// Clean up on start failure
-		if removeErr := d.client.ContainerRemove(ctx, containerID, container.RemoveOptions{Force: true}); removeErr != nil {
+	if err := dockerClient.ContainerStart(ctx, containerID, container.StartOptions{}); err != nil {
+		// Clean up on start failure, with a fresh context in case the start was cancelled
+		removeCtx, removeCancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer removeCancel()
+		if removeErr := dockerClient.ContainerRemove(removeCtx, containerID, container.RemoveOptions{Force: true}); removeErr != nil {
 			slog.Error("Failed to remove container after start failure", "containerID", containerID, "error", removeErr)
 		}
 		return nil, fmt.Errorf("failed to start container: %w", err)
@@ -227,13 +301,36 @@ func (d *DockerRuntime) RunContainer(ctx context.Context, opts runtime.RunOption
 
 	// Create a reader that will automatically clean up the container when closed
 	return &containerReader{
-		client:         d.client,
-		containerID:    containerID,
-		ctx:            ctx,
+		client:          dockerClient,
+		containerID:     containerID,
+		ctx:             ctx,
 		retainContainer: opts.RetainContainer,
 	}, nil
 }
 
+// buildHostConfig creates the host configuration for a container, applying the requested network
+// mode and resource limits.
+func buildHostConfig(opts runtime.RunOptions, mounts []mount.Mount) (*container.HostConfig, error) {
+	networkMode := opts.NetworkMode
+	if networkMode == "" {
+		networkMode = runtime.DefaultNetworkMode // Use default Docker network for internet access
+	}
+	if err := runtime.ValidateNetworkMode(networkMode); err != nil {
+		return nil, err
+	}
+
+	return &container.HostConfig{
+		Mounts:      mounts,
+		NetworkMode: container.NetworkMode(networkMode),
+		DNS:         []string{"8.8.8.8", "8.8.4.4"}, // Add public DNS servers
+		DNSOptions:  []string{"ndots:0"},            // Improve DNS resolution performance
+		Resources: container.Resources{
+			Memory:   opts.Memory,
+			NanoCPUs: int64(opts.CPUs * 1e9),
+		},
+	}, nil
+}
+
 // containerReader wraps container output and handles cleanup.
 type containerReader struct {
 	client          *client.Client
@@ -302,8 +399,16 @@ func (cr *containerReader) Close() error {
 		slog.Debug("Container wait timeout", "containerID", cr.containerID)
 	}
 
+	// A cancelled run, e.g. one that timed out, leaves the container running: report why it
+	// stopped and remove it even when it would be retained
+	stopped := cr.ctx.Err() != nil
+	if stopped {
+		cr.exitError = fmt.Errorf("container stopped before it finished: %w", cr.ctx.Err())
+		slog.Warn("Removing container of a cancelled run", "containerID", cr.containerID, "reason", cr.ctx.Err())
+	}
+
 	// Remove container only if not retaining - use a fresh context in case the original was cancelled
-	if !cr.retainContainer {
+	if !cr.retainContainer || stopped {
 		removeCtx, removeCancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer removeCancel()
 
@@ -1,7 +1,15 @@
 package runtime
 
 import (
+	"context"
+	"fmt"
+	"path/filepath"
 	"testing"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/client"
+
+	"klonekit/pkg/runtime"
 )
 
 func TestGetDockerSocketPaths(t *testing.T) {
@@ -38,4 +46,118 @@ func TestNewDockerRuntime_RequiresDockerDaemon(t *testing.T) {
 			t.Errorf("Unexpected error format: %s", errorMsg)
 		}
 	}
-}
\ No newline at end of file
+}
+
+func TestBuildHostConfig_NetworkMode(t *testing.T) {
+	tests := []struct {
+		name        string
+		networkMode string
+		expected    container.NetworkMode
+		expectError bool
+	}{
+		{name: "Defaults when unset", networkMode: "", expected: "default"},
+		{name: "Host network", networkMode: "host", expected: "host"},
+		{name: "Named network", networkMode: "ci-private", expected: "ci-private"},
+		{name: "Container network", networkMode: "container:vpn", expected: "container:vpn"},
+		{name: "Invalid network name", networkMode: "bad network", expectError: true},
+		{name: "Missing container name", networkMode: "container:", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hostConfig, err := buildHostConfig(runtime.RunOptions{NetworkMode: tt.networkMode}, nil)
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("Expected error for network mode %q, got nil", tt.networkMode)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if hostConfig.NetworkMode != tt.expected {
+				t.Errorf("Expected network mode %q, got %q", tt.expected, hostConfig.NetworkMode)
+			}
+		})
+	}
+}
+
+func TestBuildHostConfig_Resources(t *testing.T) {
+	hostConfig, err := buildHostConfig(runtime.RunOptions{Memory: 2 * 1024 * 1024 * 1024, CPUs: 1.5}, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if hostConfig.Resources.Memory != 2*1024*1024*1024 {
+		t.Errorf("Expected a 2 GiB memory limit, got %d", hostConfig.Resources.Memory)
+	}
+	if hostConfig.Resources.NanoCPUs != 1_500_000_000 {
+		t.Errorf("Expected 1.5 CPUs, got %d nano CPUs", hostConfig.Resources.NanoCPUs)
+	}
+
+	// Unset limits leave the container unlimited
+	hostConfig, err = buildHostConfig(runtime.RunOptions{}, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if hostConfig.Resources.Memory != 0 || hostConfig.Resources.NanoCPUs != 0 {
+		t.Errorf("Expected no resource limits, got %+v", hostConfig.Resources)
+	}
+}
+
+// deadDockerClient returns a client for a socket nothing listens on
+func deadDockerClient(t *testing.T) *client.Client {
+	t.Helper()
+	dockerClient, err := client.NewClientWithOpts(client.WithHost("unix://" + filepath.Join(t.TempDir(), "docker.sock")))
+	if err != nil {
+		t.Fatalf("Failed to create Docker client: %s", err)
+	}
+	return dockerClient
+}
+
+func TestDockerRuntime_ReResolvesStaleClient(t *testing.T) {
+	stale := deadDockerClient(t)
+	replacement := deadDockerClient(t)
+
+	resolves := 0
+	originalResolve := resolveDockerClient
+	defer func() { resolveDockerClient = originalResolve }()
+	resolveDockerClient = func() (*client.Client, error) {
+		resolves++
+		return replacement, nil
+	}
+
+	d := &DockerRuntime{client: stale}
+	got, err := d.ensureClient(context.Background())
+	if err != nil {
+		t.Fatalf("Expected the socket to be re-resolved, got: %s", err)
+	}
+	if got != replacement || d.client != replacement {
+		t.Error("Expected the stale client to be replaced by the re-resolved one")
+	}
+	if resolves != 1 {
+		t.Errorf("Expected exactly one re-resolution, got %d", resolves)
+	}
+}
+
+func TestDockerRuntime_ReResolveFailure(t *testing.T) {
+	stale := deadDockerClient(t)
+
+	resolves := 0
+	originalResolve := resolveDockerClient
+	defer func() { resolveDockerClient = originalResolve }()
+	resolveDockerClient = func() (*client.Client, error) {
+		resolves++
+		return nil, fmt.Errorf("no Docker socket found")
+	}
+
+	d := &DockerRuntime{client: stale}
+	if err := d.PullImage(context.Background(), "hashicorp/terraform:1.8.0"); err == nil {
+		t.Fatal("Expected an error when the daemon stays unreachable, got nil")
+	}
+	if resolves != 1 {
+		t.Errorf("Expected the socket to be re-resolved once, got %d", resolves)
+	}
+	if d.client != stale {
+		t.Error("Expected the client to be kept when re-resolution fails")
+	}
+}
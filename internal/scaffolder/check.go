@@ -0,0 +1,132 @@
+package scaffolder
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"klonekit/pkg/blueprint"
+)
+
+// DriftKind describes how a file in the destination differs from the scaffold output.
+type DriftKind string
+
+const (
+	// DriftModified is a scaffolded file whose content was changed in the destination
+	DriftModified DriftKind = "modified"
+	// DriftMissing is a file scaffolding would produce that is absent from the destination
+	DriftMissing DriftKind = "missing"
+	// DriftUnexpected is a file in the destination that scaffolding doesn't produce
+	DriftUnexpected DriftKind = "unexpected"
+)
+
+// Drift is a file of the destination that differs from the scaffold output, identified by
+// its slash-separated path relative to the destination.
+type Drift struct {
+	Path string
+	Kind DriftKind
+}
+
+// CheckResult holds the differences between the destination and the scaffold output, in
+// lexical order of their paths.
+type CheckResult struct {
+	Drift []Drift
+}
+
+// Diverged reports whether a scaffolded file was modified or removed. Unexpected files are
+// reported but don't count, as terraform itself writes files such as the lock file and local
+// state next to the scaffolded ones.
+func (r *CheckResult) Diverged() bool {
+	for _, drift := range r.Drift {
+		if drift.Kind != DriftUnexpected {
+			return true
+		}
+	}
+	return false
+}
+
+// Check scaffolds the spec into a temporary directory and compares the result with the
+// existing destination, without writing to it, to catch edits made to the generated files
+// after scaffolding. The .git and .terraform directories and the manifest are not compared.
+func Check(spec *blueprint.Spec) (*CheckResult, error) {
+	if spec == nil {
+		return nil, fmt.Errorf("spec cannot be nil")
+	}
+
+	destPath := spec.Scaffold.Destination
+	if info, err := os.Stat(destPath); err != nil || !info.IsDir() {
+		return nil, fmt.Errorf("destination %s does not exist; scaffold it before checking it", destPath)
+	}
+	absManifest, err := filepath.Abs(spec.Scaffold.ManifestPath())
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve manifest path: %w", err)
+	}
+	actual, err := buildManifest(destPath, absManifest)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read destination %s: %w", destPath, err)
+	}
+
+	var result *CheckResult
+	err = scaffoldToTemp(spec, "klonekit-check-*", func(tmpSpec *blueprint.Spec) error {
+		absTmpManifest, err := filepath.Abs(tmpSpec.Scaffold.ManifestPath())
+		if err != nil {
+			return err
+		}
+		expected, err := buildManifest(tmpSpec.Scaffold.Destination, absTmpManifest)
+		if err != nil {
+			return fmt.Errorf("failed to read scaffold output: %w", err)
+		}
+		result = compareManifests(expected, actual)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("scaffold check failed: %w", err)
+	}
+	return result, nil
+}
+
+// scaffoldToTemp scaffolds a copy of the spec into a temporary directory, with the manifest
+// written inside it, calls fn with that copy and removes the directory afterwards.
+func scaffoldToTemp(spec *blueprint.Spec, pattern string, fn func(*blueprint.Spec) error) error {
+	tmpDir, err := os.MkdirTemp("", pattern)
+	if err != nil {
+		return fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	tmpSpec := *spec
+	tmpSpec.Scaffold.Destination = filepath.Join(tmpDir, filepath.Base(spec.Scaffold.Destination))
+	tmpSpec.Scaffold.Manifest = filepath.Join(tmpDir, blueprint.DefaultManifestFilename)
+
+	if err := Scaffold(&tmpSpec, false, false); err != nil {
+		return err
+	}
+	return fn(&tmpSpec)
+}
+
+// compareManifests lists the files of actual that differ from expected.
+func compareManifests(expected, actual *Manifest) *CheckResult {
+	actualFiles := make(map[string]ManifestFile, len(actual.Files))
+	for _, file := range actual.Files {
+		actualFiles[file.Path] = file
+	}
+
+	result := &CheckResult{Drift: []Drift{}}
+	for _, file := range expected.Files {
+		actualFile, ok := actualFiles[file.Path]
+		switch {
+		case !ok:
+			result.Drift = append(result.Drift, Drift{Path: file.Path, Kind: DriftMissing})
+		case actualFile.SHA256 != file.SHA256:
+			result.Drift = append(result.Drift, Drift{Path: file.Path, Kind: DriftModified})
+		}
+		delete(actualFiles, file.Path)
+	}
+	for path := range actualFiles {
+		result.Drift = append(result.Drift, Drift{Path: path, Kind: DriftUnexpected})
+	}
+
+	sort.Slice(result.Drift, func(i, j int) bool { return result.Drift[i].Path < result.Drift[j].Path })
+	return result
+}
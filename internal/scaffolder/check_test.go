@@ -0,0 +1,134 @@
+package scaffolder
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"klonekit/pkg/blueprint"
+)
+
+// scaffoldForCheck scaffolds a small module and returns its spec
+func scaffoldForCheck(t *testing.T) *blueprint.Spec {
+	t.Helper()
+
+	t.Setenv("TMPDIR", t.TempDir())
+	tmpDir := t.TempDir()
+	srcDir := filepath.Join(tmpDir, "source")
+	if err := os.MkdirAll(filepath.Join(srcDir, "modules", "network"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		"main.tf":                    `variable "region" {}`,
+		"backend.tf.tftpl":           `region = "{{ .Cloud.Region }}"`,
+		"modules/network/network.tf": `resource "null_resource" "network" {}`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(srcDir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	spec := &blueprint.Spec{
+		Cloud:     blueprint.CloudProvider{Provider: "aws", Region: "us-east-1"},
+		Scaffold:  blueprint.Scaffold{Source: srcDir, Destination: filepath.Join(tmpDir, "destination")},
+		Variables: map[string]interface{}{"region": "us-east-1"},
+	}
+	if err := Scaffold(spec, false, false); err != nil {
+		t.Fatalf("Failed to scaffold: %s", err)
+	}
+	return spec
+}
+
+func TestCheck_MatchingDestination(t *testing.T) {
+	spec := scaffoldForCheck(t)
+
+	result, err := Check(spec)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if result.Diverged() || len(result.Drift) != 0 {
+		t.Errorf("Expected a freshly scaffolded destination to match, got %+v", result.Drift)
+	}
+}
+
+func TestCheck_ReportsDrift(t *testing.T) {
+	spec := scaffoldForCheck(t)
+	destDir := spec.Scaffold.Destination
+	manifestBefore, err := os.ReadFile(spec.Scaffold.ManifestPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Edit a rendered template, remove a copied file and leave a file terraform would write
+	if err := os.WriteFile(filepath.Join(destDir, "backend.tf"), []byte(`region = "eu-west-1"`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(filepath.Join(destDir, "modules", "network", "network.tf")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(destDir, ".terraform.lock.hcl"), []byte("# lock"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := Check(spec)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if !result.Diverged() {
+		t.Error("Expected the destination to have diverged")
+	}
+
+	want := []Drift{
+		{Path: ".terraform.lock.hcl", Kind: DriftUnexpected},
+		{Path: "backend.tf", Kind: DriftModified},
+		{Path: "modules/network/network.tf", Kind: DriftMissing},
+	}
+	if len(result.Drift) != len(want) {
+		t.Fatalf("Expected drift %+v, got %+v", want, result.Drift)
+	}
+	for i, drift := range want {
+		if result.Drift[i] != drift {
+			t.Errorf("Expected drift %+v, got %+v", drift, result.Drift[i])
+		}
+	}
+
+	// The check writes nothing
+	if content, _ := os.ReadFile(filepath.Join(destDir, "backend.tf")); string(content) != `region = "eu-west-1"` {
+		t.Error("Expected the modified file to be left as is")
+	}
+	if manifestAfter, _ := os.ReadFile(spec.Scaffold.ManifestPath()); string(manifestAfter) != string(manifestBefore) {
+		t.Error("Expected the manifest to be left unchanged")
+	}
+	if entries, _ := os.ReadDir(os.Getenv("TMPDIR")); len(entries) != 0 {
+		t.Errorf("Expected the check directory to be removed, found: %v", entries)
+	}
+}
+
+func TestCheck_UnexpectedFilesOnly(t *testing.T) {
+	spec := scaffoldForCheck(t)
+	if err := os.WriteFile(filepath.Join(spec.Scaffold.Destination, "terraform.tfstate"), []byte("{}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := Check(spec)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if result.Diverged() {
+		t.Errorf("Expected files scaffolding doesn't produce not to count as drift, got %+v", result.Drift)
+	}
+	if len(result.Drift) != 1 || result.Drift[0].Kind != DriftUnexpected {
+		t.Errorf("Expected the state file to be reported, got %+v", result.Drift)
+	}
+}
+
+func TestCheck_MissingDestination(t *testing.T) {
+	spec := &blueprint.Spec{Scaffold: blueprint.Scaffold{Source: t.TempDir(), Destination: filepath.Join(t.TempDir(), "missing")}}
+
+	_, err := Check(spec)
+	if err == nil || !strings.Contains(err.Error(), "does not exist") {
+		t.Errorf("Expected an error for a missing destination, got: %v", err)
+	}
+}
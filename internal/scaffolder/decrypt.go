@@ -0,0 +1,95 @@
+package scaffolder
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"os/exec"
+	"sort"
+	"strings"
+	"time"
+
+	"klonekit/pkg/blueprint"
+)
+
+// EncryptedValuePrefix marks a blueprint variable value as encrypted, e.g.
+// "sops:ENC[AES256_GCM,data:...]". The rest of the value is decrypted with spec.decryption.command.
+const EncryptedValuePrefix = "sops:"
+
+// decryptTimeout bounds each run of the decrypt command.
+const decryptTimeout = 30 * time.Second
+
+// IsEncrypted reports whether a variable value is marked with EncryptedValuePrefix.
+func IsEncrypted(value interface{}) bool {
+	s, ok := value.(string)
+	return ok && strings.HasPrefix(s, EncryptedValuePrefix)
+}
+
+// DecryptVariables replaces the encrypted variable values of spec with their plaintext. The
+// plaintext is kept in memory only; the decrypted keys are added to spec.SecretVariables so
+// they are redacted in the tfvars pushed to SCM and masked in logs. It is a no-op when no
+// value is encrypted.
+func DecryptVariables(spec *blueprint.Spec) error {
+	var keys []string
+	for key, value := range spec.Variables {
+		if IsEncrypted(value) {
+			keys = append(keys, key)
+		}
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+	sort.Strings(keys)
+
+	if len(spec.Decryption.Command) == 0 {
+		return fmt.Errorf("variables %s are encrypted but spec.decryption.command is not set", strings.Join(keys, ", "))
+	}
+
+	variables := make(map[string]interface{}, len(spec.Variables))
+	for key, value := range spec.Variables {
+		variables[key] = value
+	}
+
+	secret := make(map[string]bool, len(spec.SecretVariables))
+	for _, key := range spec.SecretVariables {
+		secret[key] = true
+	}
+
+	for _, key := range keys {
+		ciphertext := strings.TrimPrefix(variables[key].(string), EncryptedValuePrefix)
+		plaintext, err := runDecryptCommand(spec.Decryption.Command, ciphertext)
+		if err != nil {
+			return fmt.Errorf("failed to decrypt variable %s: %w", key, err)
+		}
+		variables[key] = plaintext
+		if !secret[key] {
+			spec.SecretVariables = append(spec.SecretVariables, key)
+		}
+	}
+	spec.Variables = variables
+
+	slog.Info("Decrypted blueprint variables", "keys", keys)
+	return nil
+}
+
+// runDecryptCommand passes ciphertext on stdin to the decrypt command and returns its stdout
+// without the trailing newline. The plaintext is never included in errors.
+func runDecryptCommand(command []string, ciphertext string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), decryptTimeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, command[0], command[1:]...) // #nosec G204
+	cmd.Stdin = strings.NewReader(ciphertext)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if detail := strings.TrimSpace(stderr.String()); detail != "" {
+			return "", fmt.Errorf("%s: %w: %s", command[0], err, detail)
+		}
+		return "", fmt.Errorf("%s: %w", command[0], err)
+	}
+	return strings.TrimRight(stdout.String(), "\r\n"), nil
+}
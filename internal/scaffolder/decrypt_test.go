@@ -0,0 +1,107 @@
+package scaffolder
+
+import (
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"klonekit/pkg/blueprint"
+)
+
+// encrypted returns a value the test decrypt command (base64 -d) decrypts to plaintext
+func encrypted(plaintext string) string {
+	return EncryptedValuePrefix + base64.StdEncoding.EncodeToString([]byte(plaintext))
+}
+
+func TestDecryptVariables(t *testing.T) {
+	spec := &blueprint.Spec{
+		Variables: map[string]interface{}{
+			"db_password": encrypted("hunter2"),
+			"region":      "us-east-1",
+		},
+		Decryption: blueprint.Decryption{Command: []string{"base64", "-d"}},
+	}
+
+	if err := DecryptVariables(spec); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	if spec.Variables["db_password"] != "hunter2" {
+		t.Errorf("Expected db_password to be decrypted, got %v", spec.Variables["db_password"])
+	}
+	if spec.Variables["region"] != "us-east-1" {
+		t.Errorf("Expected plaintext variables to be unchanged, got %v", spec.Variables["region"])
+	}
+	if len(spec.SecretVariables) != 1 || spec.SecretVariables[0] != "db_password" {
+		t.Errorf("Expected the decrypted variable to be marked secret, got %v", spec.SecretVariables)
+	}
+
+	// Decrypting again is a no-op
+	if err := DecryptVariables(spec); err != nil {
+		t.Fatalf("Unexpected error on second call: %s", err)
+	}
+	if len(spec.SecretVariables) != 1 {
+		t.Errorf("Expected the secret variables to be unchanged, got %v", spec.SecretVariables)
+	}
+}
+
+func TestDecryptVariables_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		command []string
+		wantErr string
+	}{
+		{name: "no command", wantErr: "spec.decryption.command is not set"},
+		{name: "failing command", command: []string{"sh", "-c", "echo 'no key' >&2; exit 1"}, wantErr: "no key"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := &blueprint.Spec{
+				Variables:  map[string]interface{}{"db_password": encrypted("hunter2")},
+				Decryption: blueprint.Decryption{Command: tt.command},
+			}
+			err := DecryptVariables(spec)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Expected error containing %q, got: %v", tt.wantErr, err)
+			}
+			if !strings.Contains(err.Error(), "db_password") {
+				t.Errorf("Expected the error to name the variable, got: %s", err)
+			}
+		})
+	}
+}
+
+func TestScaffold_WritesDecryptedVariablesLocally(t *testing.T) {
+	tmpDir := t.TempDir()
+	srcDir := filepath.Join(tmpDir, "source")
+	dstDir := filepath.Join(tmpDir, "destination")
+	if err := os.MkdirAll(srcDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(srcDir, "main.tf"), []byte("# module"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	spec := &blueprint.Spec{
+		Scaffold:   blueprint.Scaffold{Source: srcDir, Destination: dstDir},
+		Variables:  map[string]interface{}{"db_password": encrypted("hunter2")},
+		Decryption: blueprint.Decryption{Command: []string{"base64", "-d"}},
+	}
+	if err := DecryptVariables(spec); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if err := Scaffold(spec, false, false); err != nil {
+		t.Fatalf("Scaffold failed: %s", err)
+	}
+
+	content, err := os.ReadFile(filepath.Join(dstDir, blueprint.DefaultTfvarsFilename))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(content), `"db_password": "hunter2"`) {
+		t.Errorf("Expected the local tfvars to hold the decrypted value, got: %s", content)
+	}
+}
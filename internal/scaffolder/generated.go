@@ -0,0 +1,160 @@
+package scaffolder
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"klonekit/pkg/blueprint"
+)
+
+// VersionsFileName is the name of the generated provider constraints file.
+const VersionsFileName = "versions.tf"
+
+// ProviderFileName is the name of the generated provider configuration file.
+const ProviderFileName = "provider.tf"
+
+// generateVersionsFile writes a versions.tf with the blueprint's provider constraints.
+// It is skipped when no providers are configured, when versions.tf already exists, or
+// when the module already declares a required_providers block.
+func generateVersionsFile(spec *blueprint.Spec, destPath string) error {
+	if len(spec.Scaffold.RequiredProviders) == 0 {
+		return nil
+	}
+
+	versionsPath := filepath.Join(destPath, VersionsFileName)
+	if _, err := os.Stat(versionsPath); err == nil {
+		return nil
+	}
+
+	declared, err := declaresRequiredProviders(os.DirFS(destPath))
+	if err != nil {
+		return fmt.Errorf("failed to inspect module for required_providers: %w", err)
+	}
+	if declared {
+		return nil
+	}
+
+	content := renderVersionsFile(spec.Scaffold.RequiredProviders)
+	if err := os.WriteFile(versionsPath, []byte(content), 0600); err != nil {
+		return fmt.Errorf("failed to write %s: %w", VersionsFileName, err)
+	}
+
+	return nil
+}
+
+// renderVersionsFile renders the terraform block with providers sorted by name for reproducible output.
+func renderVersionsFile(providers map[string]blueprint.ProviderRequirement) string {
+	names := make([]string, 0, len(providers))
+	for name := range providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	b.WriteString("# Generated by KloneKit\n")
+	b.WriteString("terraform {\n")
+	b.WriteString("  required_providers {\n")
+	for _, name := range names {
+		provider := providers[name]
+		fmt.Fprintf(&b, "    %s = {\n", name)
+		fmt.Fprintf(&b, "      source  = %q\n", provider.Source)
+		if provider.Version != "" {
+			fmt.Fprintf(&b, "      version = %q\n", provider.Version)
+		}
+		b.WriteString("    }\n")
+	}
+	b.WriteString("  }\n")
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// declaresRequiredProviders reports whether any top-level .tf file in fsys contains a required_providers block.
+func declaresRequiredProviders(fsys fs.FS) (bool, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return false, err
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".tf" {
+			continue
+		}
+		content, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return false, err
+		}
+		if strings.Contains(string(content), "required_providers") {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// generateProviderFile writes a provider.tf configuring the cloud provider with the
+// blueprint's region. It is skipped unless scaffold.generateProvider is set, and when
+// provider.tf already exists or the module already declares a provider block for it.
+func generateProviderFile(spec *blueprint.Spec, destPath string) error {
+	if !spec.Scaffold.GenerateProvider {
+		return nil
+	}
+
+	providerPath := filepath.Join(destPath, ProviderFileName)
+	if _, err := os.Stat(providerPath); err == nil {
+		return nil
+	}
+
+	declared, err := declaresProvider(os.DirFS(destPath), spec.Cloud.Provider)
+	if err != nil {
+		return fmt.Errorf("failed to inspect module for a %s provider block: %w", spec.Cloud.Provider, err)
+	}
+	if declared {
+		return nil
+	}
+
+	content := renderProviderFile(spec.Cloud)
+	if err := os.WriteFile(providerPath, []byte(content), 0600); err != nil {
+		return fmt.Errorf("failed to write %s: %w", ProviderFileName, err)
+	}
+
+	return nil
+}
+
+// renderProviderFile renders a minimal provider block for the cloud provider and region.
+func renderProviderFile(cloud blueprint.CloudProvider) string {
+	var b strings.Builder
+	b.WriteString("# Generated by KloneKit\n")
+	fmt.Fprintf(&b, "provider %q {\n", cloud.Provider)
+	fmt.Fprintf(&b, "  region = %q\n", cloud.Region)
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// declaresProvider reports whether any top-level .tf file in fsys contains a provider block
+// for the named provider.
+func declaresProvider(fsys fs.FS, name string) (bool, error) {
+	block := regexp.MustCompile(`(?m)^\s*provider\s+"?` + regexp.QuoteMeta(name) + `"?\s*\{`)
+
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return false, err
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".tf" {
+			continue
+		}
+		content, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return false, err
+		}
+		if block.Match(content) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
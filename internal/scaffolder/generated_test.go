@@ -0,0 +1,437 @@
+package scaffolder
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"klonekit/pkg/blueprint"
+)
+
+func TestScaffold_GeneratesVersionsFile(t *testing.T) {
+	tmpDir := t.TempDir()
+	srcDir := filepath.Join(tmpDir, "source")
+	dstDir := filepath.Join(tmpDir, "destination")
+
+	if err := os.MkdirAll(srcDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(srcDir, "main.tf"), []byte("resource \"aws_s3_bucket\" \"this\" {}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	spec := &blueprint.Spec{
+		Scaffold: blueprint.Scaffold{
+			Source:      srcDir,
+			Destination: dstDir,
+			RequiredProviders: map[string]blueprint.ProviderRequirement{
+				"aws":    {Source: "hashicorp/aws", Version: "~> 5.0"},
+				"random": {Source: "hashicorp/random", Version: ">= 3.5.0"},
+			},
+		},
+	}
+
+	if err := Scaffold(spec, false, false); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	content, err := os.ReadFile(filepath.Join(dstDir, VersionsFileName))
+	if err != nil {
+		t.Fatalf("%s not created: %v", VersionsFileName, err)
+	}
+
+	expectedParts := []string{
+		"required_providers {",
+		"aws = {",
+		`source  = "hashicorp/aws"`,
+		`version = "~> 5.0"`,
+		"random = {",
+		`source  = "hashicorp/random"`,
+		`version = ">= 3.5.0"`,
+	}
+	for _, part := range expectedParts {
+		if !strings.Contains(string(content), part) {
+			t.Errorf("Expected %s to contain %q, got:\n%s", VersionsFileName, part, content)
+		}
+	}
+
+	// Providers are rendered in sorted order for reproducible output
+	if strings.Index(string(content), "aws = {") > strings.Index(string(content), "random = {") {
+		t.Errorf("Expected providers to be sorted by name, got:\n%s", content)
+	}
+}
+
+func TestScaffold_SkipsVersionsFileWhenDeclared(t *testing.T) {
+	tests := []struct {
+		name        string
+		sourceFiles map[string]string
+		expected    string
+	}{
+		{
+			name: "Existing versions.tf is not overwritten",
+			sourceFiles: map[string]string{
+				"main.tf":        "resource \"aws_s3_bucket\" \"this\" {}",
+				VersionsFileName: "# custom versions",
+			},
+			expected: "# custom versions",
+		},
+		{
+			name: "Module already declares required_providers",
+			sourceFiles: map[string]string{
+				"main.tf":      "resource \"aws_s3_bucket\" \"this\" {}",
+				"terraform.tf": "terraform {\n  required_providers {\n    aws = { source = \"hashicorp/aws\" }\n  }\n}\n",
+			},
+			expected: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			srcDir := filepath.Join(tmpDir, "source")
+			dstDir := filepath.Join(tmpDir, "destination")
+
+			if err := os.MkdirAll(srcDir, 0755); err != nil {
+				t.Fatal(err)
+			}
+			for name, content := range tt.sourceFiles {
+				if err := os.WriteFile(filepath.Join(srcDir, name), []byte(content), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			spec := &blueprint.Spec{
+				Scaffold: blueprint.Scaffold{
+					Source:      srcDir,
+					Destination: dstDir,
+					RequiredProviders: map[string]blueprint.ProviderRequirement{
+						"aws": {Source: "hashicorp/aws", Version: "~> 5.0"},
+					},
+				},
+			}
+
+			if err := Scaffold(spec, false, false); err != nil {
+				t.Fatalf("Expected no error, got: %v", err)
+			}
+
+			content, err := os.ReadFile(filepath.Join(dstDir, VersionsFileName))
+			if tt.expected == "" {
+				if !os.IsNotExist(err) {
+					t.Errorf("Expected %s not to be generated, got: %s", VersionsFileName, content)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Failed to read %s: %v", VersionsFileName, err)
+			}
+			if string(content) != tt.expected {
+				t.Errorf("Expected existing %s to be preserved, got: %s", VersionsFileName, content)
+			}
+		})
+	}
+}
+
+func TestScaffold_GeneratesProviderFile(t *testing.T) {
+	tests := []struct {
+		name             string
+		generateProvider bool
+		sourceFiles      map[string]string
+		expected         string
+	}{
+		{
+			name:             "Provider block generated with the configured region",
+			generateProvider: true,
+			sourceFiles:      map[string]string{"main.tf": "resource \"aws_s3_bucket\" \"this\" {}"},
+			expected:         "# Generated by KloneKit\nprovider \"aws\" {\n  region = \"eu-central-1\"\n}\n",
+		},
+		{
+			name:             "Module already declares the provider",
+			generateProvider: true,
+			sourceFiles: map[string]string{
+				"main.tf":      "resource \"aws_s3_bucket\" \"this\" {}",
+				"providers.tf": "provider \"aws\" {\n  region = var.region\n}\n",
+			},
+		},
+		{
+			name:             "Existing provider.tf is not overwritten",
+			generateProvider: true,
+			sourceFiles: map[string]string{
+				"main.tf":        "resource \"aws_s3_bucket\" \"this\" {}",
+				ProviderFileName: "# custom provider",
+			},
+			expected: "# custom provider",
+		},
+		{
+			name:             "Commented out provider block is not a declaration",
+			generateProvider: true,
+			sourceFiles:      map[string]string{"main.tf": "# provider \"aws\" {}\nresource \"aws_s3_bucket\" \"this\" {}"},
+			expected:         "# Generated by KloneKit\nprovider \"aws\" {\n  region = \"eu-central-1\"\n}\n",
+		},
+		{
+			name:        "Not configured",
+			sourceFiles: map[string]string{"main.tf": "resource \"aws_s3_bucket\" \"this\" {}"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			srcDir := filepath.Join(tmpDir, "source")
+			dstDir := filepath.Join(tmpDir, "destination")
+
+			if err := os.MkdirAll(srcDir, 0755); err != nil {
+				t.Fatal(err)
+			}
+			for name, content := range tt.sourceFiles {
+				if err := os.WriteFile(filepath.Join(srcDir, name), []byte(content), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			spec := &blueprint.Spec{
+				Cloud: blueprint.CloudProvider{Provider: "aws", Region: "eu-central-1"},
+				Scaffold: blueprint.Scaffold{
+					Source:           srcDir,
+					Destination:      dstDir,
+					GenerateProvider: tt.generateProvider,
+				},
+			}
+
+			if err := Scaffold(spec, false, false); err != nil {
+				t.Fatalf("Expected no error, got: %v", err)
+			}
+
+			content, err := os.ReadFile(filepath.Join(dstDir, ProviderFileName))
+			if tt.expected == "" {
+				if !os.IsNotExist(err) {
+					t.Errorf("Expected %s not to be generated, got: %s", ProviderFileName, content)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Failed to read %s: %v", ProviderFileName, err)
+			}
+			if string(content) != tt.expected {
+				t.Errorf("Expected %s:\n%s\ngot:\n%s", ProviderFileName, tt.expected, content)
+			}
+		})
+	}
+}
+
+func TestScaffold_GeneratesTaskFile(t *testing.T) {
+	tests := []struct {
+		taskFile      string
+		fileName      string
+		tfvarsFile    string
+		expectedParts []string
+	}{
+		{
+			taskFile: TaskFileMakefile,
+			fileName: MakefileName,
+			expectedParts: []string{
+				"AWS_REGION ?= eu-central-1",
+				"init:\n\tterraform -chdir=$(TF_DIR) init",
+				"plan: init\n\tterraform -chdir=$(TF_DIR) plan\n",
+				"apply: init\n\tterraform -chdir=$(TF_DIR) apply\n",
+			},
+		},
+		{
+			taskFile:   TaskFileJustfile,
+			fileName:   JustfileName,
+			tfvarsFile: "prod.tfvars",
+			expectedParts: []string{
+				`export AWS_REGION := env_var_or_default("AWS_REGION", "eu-central-1")`,
+				"init:\n    terraform -chdir={{tf_dir}} init",
+				"plan: init\n    terraform -chdir={{tf_dir}} plan -var-file=prod.tfvars",
+				"apply: init\n    terraform -chdir={{tf_dir}} apply -var-file=prod.tfvars",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.taskFile, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			srcDir := filepath.Join(tmpDir, "source")
+			dstDir := filepath.Join(tmpDir, "destination")
+			if err := os.MkdirAll(srcDir, 0755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(filepath.Join(srcDir, "main.tf"), []byte("# main"), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			spec := &blueprint.Spec{
+				Cloud: blueprint.CloudProvider{Provider: "aws", Region: "eu-central-1"},
+				Scaffold: blueprint.Scaffold{
+					Source:         srcDir,
+					Destination:    dstDir,
+					TaskFile:       tt.taskFile,
+					TfvarsFilename: tt.tfvarsFile,
+				},
+			}
+			if err := Scaffold(spec, false, false); err != nil {
+				t.Fatalf("Expected no error, got: %v", err)
+			}
+
+			content, err := os.ReadFile(filepath.Join(dstDir, tt.fileName))
+			if err != nil {
+				t.Fatalf("%s not created: %v", tt.fileName, err)
+			}
+			for _, part := range tt.expectedParts {
+				if !strings.Contains(string(content), part) {
+					t.Errorf("Expected %s to contain %q, got:\n%s", tt.fileName, part, content)
+				}
+			}
+		})
+	}
+}
+
+func TestRenderTaskFile_OpenTofu(t *testing.T) {
+	for _, taskFile := range []string{TaskFileMakefile, TaskFileJustfile} {
+		t.Run(taskFile, func(t *testing.T) {
+			spec := &blueprint.Spec{
+				Cloud:     blueprint.CloudProvider{Provider: "aws", Region: "eu-central-1"},
+				Scaffold:  blueprint.Scaffold{TaskFile: taskFile},
+				Provision: blueprint.Provision{Engine: "opentofu"},
+			}
+
+			content := renderTaskFile(spec)
+			for _, want := range []string{"tofu -chdir=", " init\n", " plan", " apply"} {
+				if !strings.Contains(content, want) {
+					t.Errorf("Expected the %s to contain %q, got:\n%s", taskFile, want, content)
+				}
+			}
+			if strings.Contains(content, "terraform") {
+				t.Errorf("Expected no terraform commands with OpenTofu, got:\n%s", content)
+			}
+		})
+	}
+}
+
+func TestScaffold_KeepsExistingTaskFile(t *testing.T) {
+	tmpDir := t.TempDir()
+	srcDir := filepath.Join(tmpDir, "source")
+	dstDir := filepath.Join(tmpDir, "destination")
+	if err := os.MkdirAll(srcDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(srcDir, "main.tf"), []byte("# module"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	existing := "plan:\n\t./scripts/plan.sh\n"
+	if err := os.WriteFile(filepath.Join(srcDir, MakefileName), []byte(existing), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	spec := &blueprint.Spec{
+		Cloud:    blueprint.CloudProvider{Provider: "aws", Region: "eu-central-1"},
+		Scaffold: blueprint.Scaffold{Source: srcDir, Destination: dstDir, TaskFile: TaskFileMakefile},
+	}
+	if err := Scaffold(spec, false, false); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	content, err := os.ReadFile(filepath.Join(dstDir, MakefileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != existing {
+		t.Errorf("Expected the module's Makefile to be kept, got:\n%s", content)
+	}
+}
+
+func TestScaffold_GeneratesGitLabCI(t *testing.T) {
+	tmpDir := t.TempDir()
+	srcDir := filepath.Join(tmpDir, "source")
+	dstDir := filepath.Join(tmpDir, "destination")
+	if err := os.MkdirAll(srcDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(srcDir, "main.tf"), []byte("# main"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	spec := &blueprint.Spec{
+		Cloud: blueprint.CloudProvider{Provider: "aws", Region: "eu-central-1"},
+		Scaffold: blueprint.Scaffold{
+			Source:         srcDir,
+			Destination:    dstDir,
+			TfvarsFilename: "prod.tfvars",
+			GitLabCI:       true,
+		},
+		Provision: blueprint.Provision{Image: "registry.example.com/terraform:1.9.5"},
+	}
+	if err := Scaffold(spec, false, false); err != nil {
+		t.Fatalf("Scaffold failed: %v", err)
+	}
+
+	content, err := os.ReadFile(filepath.Join(dstDir, GitLabCIFileName))
+	if err != nil {
+		t.Fatalf("Expected %s to be generated: %v", GitLabCIFileName, err)
+	}
+	for _, want := range []string{
+		`name: "registry.example.com/terraform:1.9.5"`,
+		`entrypoint: [""]`,
+		`AWS_DEFAULT_REGION: "eu-central-1"`,
+		"- terraform validate",
+		"- terraform plan -input=false -out=plan.tfplan -var-file=prod.tfvars",
+	} {
+		if !strings.Contains(string(content), want) {
+			t.Errorf("Expected %s to contain %q, got:\n%s", GitLabCIFileName, want, content)
+		}
+	}
+}
+
+func TestRenderGitLabCI_OpenTofu(t *testing.T) {
+	spec := &blueprint.Spec{
+		Cloud:     blueprint.CloudProvider{Provider: "aws", Region: "eu-central-1"},
+		Provision: blueprint.Provision{Engine: "opentofu"},
+	}
+
+	content := renderGitLabCI(spec)
+	for _, want := range []string{
+		`name: "ghcr.io/opentofu/opentofu:1.8.0"`,
+		"- tofu init -backend=false",
+		"- tofu plan -input=false -out=plan.tfplan",
+	} {
+		if !strings.Contains(content, want) {
+			t.Errorf("Expected %s to contain %q, got:\n%s", GitLabCIFileName, want, content)
+		}
+	}
+	if strings.Contains(content, "- terraform") {
+		t.Errorf("Expected no terraform commands with OpenTofu, got:\n%s", content)
+	}
+}
+
+func TestScaffold_KeepsExistingGitLabCI(t *testing.T) {
+	tmpDir := t.TempDir()
+	srcDir := filepath.Join(tmpDir, "source")
+	dstDir := filepath.Join(tmpDir, "destination")
+	if err := os.MkdirAll(srcDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(srcDir, "main.tf"), []byte("# module"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	existing := "include:\n  - project: platform/ci-templates\n"
+	if err := os.WriteFile(filepath.Join(srcDir, GitLabCIFileName), []byte(existing), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	spec := &blueprint.Spec{
+		Cloud:    blueprint.CloudProvider{Provider: "aws", Region: "eu-central-1"},
+		Scaffold: blueprint.Scaffold{Source: srcDir, Destination: dstDir, GitLabCI: true},
+	}
+	if err := Scaffold(spec, false, false); err != nil {
+		t.Fatalf("Scaffold failed: %v", err)
+	}
+
+	content, err := os.ReadFile(filepath.Join(dstDir, GitLabCIFileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != existing {
+		t.Errorf("Expected the existing %s to be kept, got:\n%s", GitLabCIFileName, content)
+	}
+}
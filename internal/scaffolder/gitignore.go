@@ -0,0 +1,96 @@
+package scaffolder
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-git/go-billy/v5/osfs"
+	"github.com/go-git/go-git/v5/plumbing/format/gitignore"
+
+	"klonekit/pkg/blueprint"
+)
+
+// IgnoreFileName is the file in the root of a local source module listing, in gitignore
+// syntax, the paths that are not scaffolded.
+const IgnoreFileName = ".klonekitignore"
+
+// defaultIgnorePatterns are never scaffolded from a local source module: the artifacts of
+// running Terraform in it (provider cache, state, crash logs), local override files, and the
+// ignore file itself. A .klonekitignore can re-include them with a negated pattern.
+var defaultIgnorePatterns = []string{
+	".terraform/",
+	"*.tfstate",
+	"*.tfstate.*",
+	"crash.log",
+	"crash.*.log",
+	"override.tf",
+	"override.tf.json",
+	"*_override.tf",
+	"*_override.tf.json",
+	"/" + IgnoreFileName,
+}
+
+// sourceIgnoreMatcher returns a matcher for the paths of a local source module that are not
+// copied: the default Terraform artifacts, the paths listed in its .klonekitignore and, when
+// scaffold.respectGitignore is set, its .git directory and the paths ignored by its
+// .gitignore files. Built-in templates are copied whole, so their matcher is nil.
+func sourceIgnoreMatcher(spec *blueprint.Spec, isTemplate bool) (gitignore.Matcher, error) {
+	if isTemplate {
+		return nil, nil
+	}
+
+	var patterns []gitignore.Pattern
+	for _, pattern := range defaultIgnorePatterns {
+		patterns = append(patterns, gitignore.ParsePattern(pattern, nil))
+	}
+
+	if spec.Scaffold.RespectGitignore {
+		gitPatterns, err := gitignore.ReadPatterns(osfs.New(spec.Scaffold.Source), nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read .gitignore files of %s: %w", spec.Scaffold.Source, err)
+		}
+		patterns = append(patterns, gitignore.ParsePattern(".git/", nil))
+		patterns = append(patterns, gitPatterns...)
+	}
+
+	// The .klonekitignore comes last, so its rules take precedence
+	ignorePatterns, err := readIgnoreFile(filepath.Join(spec.Scaffold.Source, IgnoreFileName))
+	if err != nil {
+		return nil, err
+	}
+	patterns = append(patterns, ignorePatterns...)
+
+	return gitignore.NewMatcher(patterns), nil
+}
+
+// readIgnoreFile parses an ignore file in gitignore syntax. A missing file has no patterns.
+func readIgnoreFile(path string) ([]gitignore.Pattern, error) {
+	content, err := os.ReadFile(path) // #nosec G304
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	var patterns []gitignore.Pattern
+	for _, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimRight(line, "\r")
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		patterns = append(patterns, gitignore.ParsePattern(line, nil))
+	}
+	return patterns, nil
+}
+
+// isIgnored reports whether a path relative to the source is skipped by matcher. A nil
+// matcher ignores nothing.
+func isIgnored(matcher gitignore.Matcher, relPath string, isDir bool) bool {
+	if matcher == nil || relPath == "." {
+		return false
+	}
+	return matcher.Match(strings.Split(filepath.ToSlash(relPath), "/"), isDir)
+}
@@ -0,0 +1,202 @@
+package scaffolder
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"klonekit/pkg/blueprint"
+)
+
+// gitignoreSource creates a source module with a .gitignore ignoring build output, local
+// Terraform state and a nested ignore rule
+func gitignoreSource(t *testing.T, srcDir string) {
+	t.Helper()
+
+	files := map[string]string{
+		".gitignore":             ".terraform/\n*.tfstate\nbuild/\n",
+		"main.tf":                `resource "aws_s3_bucket" "state" {}`,
+		"terraform.tfstate":      `{"version": 4}`,
+		".terraform/modules.txt": "cached modules",
+		"build/plan.zip":         "artifact",
+		"modules/vpc/main.tf":    `resource "aws_vpc" "main" {}`,
+		"modules/vpc/.gitignore": "*.log\n",
+		"modules/vpc/debug.log":  "debug output",
+		".git/HEAD":              "ref: refs/heads/main\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(srcDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestScaffold_RespectsSourceGitignore(t *testing.T) {
+	tmpDir := t.TempDir()
+	srcDir := filepath.Join(tmpDir, "source")
+	dstDir := filepath.Join(tmpDir, "destination")
+	gitignoreSource(t, srcDir)
+
+	spec := &blueprint.Spec{
+		Scaffold: blueprint.Scaffold{Source: srcDir, Destination: dstDir, RespectGitignore: true},
+	}
+	if err := Scaffold(spec, false, false); err != nil {
+		t.Fatalf("Scaffold failed: %v", err)
+	}
+
+	for _, name := range []string{".gitignore", "main.tf", "modules/vpc/main.tf", "modules/vpc/.gitignore"} {
+		if _, err := os.Stat(filepath.Join(dstDir, filepath.FromSlash(name))); err != nil {
+			t.Errorf("Expected %s to be copied: %v", name, err)
+		}
+	}
+	for _, name := range []string{"terraform.tfstate", ".terraform", "build", "modules/vpc/debug.log", ".git"} {
+		if _, err := os.Stat(filepath.Join(dstDir, filepath.FromSlash(name))); !os.IsNotExist(err) {
+			t.Errorf("Expected ignored %s not to be copied", name)
+		}
+	}
+}
+
+func TestScaffold_CopiesIgnoredFilesByDefault(t *testing.T) {
+	tmpDir := t.TempDir()
+	srcDir := filepath.Join(tmpDir, "source")
+	dstDir := filepath.Join(tmpDir, "destination")
+	gitignoreSource(t, srcDir)
+
+	spec := &blueprint.Spec{
+		Scaffold: blueprint.Scaffold{Source: srcDir, Destination: dstDir},
+	}
+	if err := Scaffold(spec, false, false); err != nil {
+		t.Fatalf("Scaffold failed: %v", err)
+	}
+
+	for _, name := range []string{"build/plan.zip", "modules/vpc/debug.log", ".git/HEAD"} {
+		if _, err := os.Stat(filepath.Join(dstDir, filepath.FromSlash(name))); err != nil {
+			t.Errorf("Expected %s to be copied without respectGitignore: %v", name, err)
+		}
+	}
+	// Terraform artifacts are skipped regardless
+	for _, name := range []string{"terraform.tfstate", ".terraform"} {
+		if _, err := os.Stat(filepath.Join(dstDir, filepath.FromSlash(name))); !os.IsNotExist(err) {
+			t.Errorf("Expected the Terraform artifact %s not to be copied", name)
+		}
+	}
+}
+
+// klonekitignoreSource creates a source module with a .klonekitignore, Terraform artifacts
+// and local overrides
+func klonekitignoreSource(t *testing.T, srcDir string) {
+	t.Helper()
+
+	files := map[string]string{
+		IgnoreFileName:                        "# Local-only files\n*.md\ndocs/\n/scripts/*.sh\n!scripts/keep.sh\nexamples/**/*.tfvars\n!dev_override.tf\n",
+		"main.tf":                             `resource "aws_s3_bucket" "state" {}`,
+		"README.md":                           "# Module",
+		"docs/usage.txt":                      "usage",
+		"scripts/setup.sh":                    "#!/bin/sh",
+		"scripts/keep.sh":                     "#!/bin/sh",
+		"modules/vpc/scripts/setup.sh":        "#!/bin/sh",
+		"modules/vpc/NOTES.md":                "notes",
+		"examples/simple/main.tf":             `module "simple" {}`,
+		"examples/simple/dev/local.tfvars":    `region = "us-east-1"`,
+		"terraform.tfstate":                   `{"version": 4}`,
+		"terraform.tfstate.backup":            `{"version": 4}`,
+		".terraform/providers/aws":            "provider binary",
+		"modules/vpc/.terraform/modules.json": "{}",
+		"override.tf":                         "# local override",
+		"dev_override.tf":                     "# kept by a negated pattern",
+		"crash.log":                           "panic",
+		".terraform.lock.hcl":                 "# provider lock",
+	}
+	for name, content := range files {
+		path := filepath.Join(srcDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestScaffold_Klonekitignore(t *testing.T) {
+	tmpDir := t.TempDir()
+	srcDir := filepath.Join(tmpDir, "source")
+	dstDir := filepath.Join(tmpDir, "destination")
+	klonekitignoreSource(t, srcDir)
+
+	spec := &blueprint.Spec{
+		Scaffold: blueprint.Scaffold{Source: srcDir, Destination: dstDir},
+	}
+	if err := Scaffold(spec, false, false); err != nil {
+		t.Fatalf("Scaffold failed: %v", err)
+	}
+
+	copied := []string{"main.tf", "scripts/keep.sh", "modules/vpc/scripts/setup.sh", "examples/simple/main.tf", "dev_override.tf", ".terraform.lock.hcl"}
+	for _, name := range copied {
+		if _, err := os.Stat(filepath.Join(dstDir, filepath.FromSlash(name))); err != nil {
+			t.Errorf("Expected %s to be copied: %v", name, err)
+		}
+	}
+	skipped := []string{IgnoreFileName, "README.md", "docs", "scripts/setup.sh", "modules/vpc/NOTES.md", "examples/simple/dev/local.tfvars",
+		"terraform.tfstate", "terraform.tfstate.backup", ".terraform", "modules/vpc/.terraform", "override.tf", "crash.log"}
+	for _, name := range skipped {
+		if _, err := os.Stat(filepath.Join(dstDir, filepath.FromSlash(name))); !os.IsNotExist(err) {
+			t.Errorf("Expected ignored %s not to be copied", name)
+		}
+	}
+}
+
+// captureStdout returns what fn prints to stdout
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	previous := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = previous }()
+
+	done := make(chan string)
+	go func() {
+		output, _ := io.ReadAll(r)
+		done <- string(output)
+	}()
+	fn()
+	w.Close()
+	return <-done
+}
+
+func TestScaffold_KlonekitignoreDryRun(t *testing.T) {
+	tmpDir := t.TempDir()
+	srcDir := filepath.Join(tmpDir, "source")
+	dstDir := filepath.Join(tmpDir, "destination")
+	klonekitignoreSource(t, srcDir)
+
+	spec := &blueprint.Spec{
+		Scaffold: blueprint.Scaffold{Source: srcDir, Destination: dstDir},
+	}
+	output := captureStdout(t, func() {
+		if err := Scaffold(spec, true, false); err != nil {
+			t.Errorf("Scaffold failed: %v", err)
+		}
+	})
+
+	for _, name := range []string{"scripts/keep.sh", "modules/vpc/scripts/setup.sh", "dev_override.tf"} {
+		if !strings.Contains(output, "Would copy file: "+filepath.Join(dstDir, filepath.FromSlash(name))+"\n") {
+			t.Errorf("Expected the dry run to list %s, got:\n%s", name, output)
+		}
+	}
+	for _, name := range []string{IgnoreFileName, "README.md", "docs", "scripts/setup.sh", "terraform.tfstate", ".terraform", "override.tf"} {
+		if strings.Contains(output, filepath.Join(dstDir, filepath.FromSlash(name))+"\n") {
+			t.Errorf("Expected the dry run not to list ignored %s, got:\n%s", name, output)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package scaffolder
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"klonekit/internal/provisioner"
+	"klonekit/pkg/blueprint"
+)
+
+// GitLabCIFileName is the name of the generated GitLab pipeline.
+const GitLabCIFileName = ".gitlab-ci.yml"
+
+// generateGitLabCI writes a .gitlab-ci.yml running terraform validate and plan, so the
+// repository pushed to GitLab carries the baseline pipeline. It is skipped when not
+// configured or the module already has a pipeline.
+func generateGitLabCI(spec *blueprint.Spec, destPath string) error {
+	if !spec.Scaffold.GitLabCI {
+		return nil
+	}
+
+	ciPath := filepath.Join(destPath, GitLabCIFileName)
+	if _, err := os.Stat(ciPath); err == nil {
+		return nil
+	}
+
+	if err := os.WriteFile(ciPath, []byte(renderGitLabCI(spec)), 0644); err != nil { // #nosec G306
+		return fmt.Errorf("failed to write %s: %w", GitLabCIFileName, err)
+	}
+	return nil
+}
+
+// renderGitLabCI renders the pipeline for the spec. Jobs run in the Terraform image used
+// for provisioning, with its terraform entrypoint cleared so GitLab can run the script, and
+// call the binary of the provisioning engine.
+func renderGitLabCI(spec *blueprint.Spec) string {
+	varFile := ""
+	if spec.Provision.WritesTfvars() && !spec.Scaffold.TfvarsAutoLoaded() {
+		varFile = " -var-file=" + spec.Scaffold.TfvarsFile()
+	}
+
+	binary := provisioner.EngineBinary(spec)
+
+	var b strings.Builder
+	b.WriteString("# Generated by KloneKit\n")
+	b.WriteString("image:\n")
+	fmt.Fprintf(&b, "  name: %q\n", provisioner.TerraformImage(spec))
+	b.WriteString("  entrypoint: [\"\"]\n")
+	b.WriteString("\nvariables:\n")
+	fmt.Fprintf(&b, "  AWS_DEFAULT_REGION: %q\n", spec.Cloud.Region)
+	b.WriteString("\nstages:\n  - validate\n  - plan\n")
+	b.WriteString("\nvalidate:\n  stage: validate\n  script:\n")
+	fmt.Fprintf(&b, "    - %s init -backend=false\n", binary)
+	fmt.Fprintf(&b, "    - %s validate\n", binary)
+	b.WriteString("\nplan:\n  stage: plan\n  script:\n")
+	fmt.Fprintf(&b, "    - %s init\n", binary)
+	fmt.Fprintf(&b, "    - %s plan -input=false -out=plan.tfplan%s\n", binary, varFile)
+	b.WriteString("  artifacts:\n    paths:\n      - plan.tfplan\n")
+	return b.String()
+}
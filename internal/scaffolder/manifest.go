@@ -0,0 +1,127 @@
+package scaffolder
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"klonekit/pkg/blueprint"
+)
+
+// Manifest lists the files of a scaffolded tree, so downstream tooling can verify them and
+// detect changes made after scaffolding.
+type Manifest struct {
+	Destination string         `json:"destination"`
+	GeneratedAt time.Time      `json:"generated_at"`
+	Files       []ManifestFile `json:"files"`
+}
+
+// ManifestFile is a scaffolded file, identified by its slash-separated path relative to the
+// destination.
+type ManifestFile struct {
+	Path   string `json:"path"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// writeManifest writes the manifest of every file under destPath to the configured manifest
+// path. The .git and .terraform directories and the manifest itself are left out.
+func writeManifest(spec *blueprint.Spec, destPath string) error {
+	manifestPath := spec.Scaffold.ManifestPath()
+	absManifest, err := filepath.Abs(manifestPath)
+	if err != nil {
+		return fmt.Errorf("failed to resolve manifest path: %w", err)
+	}
+
+	manifest, err := buildManifest(destPath, absManifest)
+	if err != nil {
+		return fmt.Errorf("failed to build scaffold manifest: %w", err)
+	}
+
+	content, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to serialize scaffold manifest: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(manifestPath), 0750); err != nil {
+		return fmt.Errorf("failed to create manifest directory: %w", err)
+	}
+	if err := os.WriteFile(manifestPath, append(content, '\n'), 0600); err != nil {
+		return fmt.Errorf("failed to write %s: %w", manifestPath, err)
+	}
+	return nil
+}
+
+// ReadManifest reads the manifest written when the spec was scaffolded.
+func ReadManifest(spec *blueprint.Spec) (*Manifest, error) {
+	manifestPath := spec.Scaffold.ManifestPath()
+	content, err := os.ReadFile(manifestPath) // #nosec G304
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", manifestPath, err)
+	}
+	var manifest Manifest
+	if err := json.Unmarshal(content, &manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", manifestPath, err)
+	}
+	return &manifest, nil
+}
+
+// buildManifest hashes the files under destPath in lexical order, skipping the file at
+// absManifest.
+func buildManifest(destPath, absManifest string) (*Manifest, error) {
+	manifest := &Manifest{Destination: destPath, GeneratedAt: time.Now().UTC(), Files: []ManifestFile{}}
+
+	err := filepath.WalkDir(destPath, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if d.Name() == ".git" || d.Name() == ".terraform" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		if absPath, err := filepath.Abs(path); err == nil && absPath == absManifest {
+			return nil
+		}
+
+		relPath, err := filepath.Rel(destPath, path)
+		if err != nil {
+			return err
+		}
+		size, sum, err := hashFile(path)
+		if err != nil {
+			return err
+		}
+		manifest.Files = append(manifest.Files, ManifestFile{Path: filepath.ToSlash(relPath), Size: size, SHA256: sum})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+// hashFile returns the size and hex-encoded SHA-256 hash of a file.
+func hashFile(path string) (int64, string, error) {
+	file, err := os.Open(path) // #nosec G304
+	if err != nil {
+		return 0, "", err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	size, err := io.Copy(hash, file)
+	if err != nil {
+		return 0, "", fmt.Errorf("failed to hash %s: %w", path, err)
+	}
+	return size, hex.EncodeToString(hash.Sum(nil)), nil
+}
@@ -0,0 +1,184 @@
+package scaffolder
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"klonekit/pkg/blueprint"
+)
+
+func readManifest(t *testing.T, path string) Manifest {
+	t.Helper()
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Expected a manifest at %s: %v", path, err)
+	}
+	var manifest Manifest
+	if err := json.Unmarshal(content, &manifest); err != nil {
+		t.Fatalf("Invalid manifest JSON: %v", err)
+	}
+	return manifest
+}
+
+func TestScaffold_WritesManifest(t *testing.T) {
+	tmpDir := t.TempDir()
+	srcDir := filepath.Join(tmpDir, "source")
+	dstDir := filepath.Join(tmpDir, "destination")
+	sourceFiles := map[string]string{
+		"main.tf":                 `module "network" { source = "./modules/network" }`,
+		"modules/network/main.tf": `resource "aws_vpc" "main" {}`,
+		"backend.tf.tftpl":        `# region {{ .Cloud.Region }}`,
+	}
+	for name, content := range sourceFiles {
+		path := filepath.Join(srcDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	spec := &blueprint.Spec{
+		Cloud: blueprint.CloudProvider{Provider: "aws", Region: "eu-west-1"},
+		Scaffold: blueprint.Scaffold{
+			Source:      srcDir,
+			Destination: dstDir,
+			RequiredProviders: map[string]blueprint.ProviderRequirement{
+				"aws": {Source: "hashicorp/aws", Version: "~> 5.0"},
+			},
+			TaskFile: TaskFileMakefile,
+			GitLabCI: true,
+		},
+		Variables: map[string]interface{}{"environment": "prod"},
+	}
+	if err := Scaffold(spec, false, false); err != nil {
+		t.Fatalf("Scaffold failed: %v", err)
+	}
+
+	// The default manifest is written next to the destination, outside the pushed tree
+	manifestPath := filepath.Join(tmpDir, "destination."+blueprint.DefaultManifestFilename)
+	manifest := readManifest(t, manifestPath)
+	if manifest.Destination != dstDir || manifest.GeneratedAt.IsZero() {
+		t.Errorf("Expected the manifest to record the destination and time, got %+v", manifest)
+	}
+
+	var paths []string
+	for _, file := range manifest.Files {
+		paths = append(paths, file.Path)
+
+		content, err := os.ReadFile(filepath.Join(dstDir, filepath.FromSlash(file.Path)))
+		if err != nil {
+			t.Errorf("Manifest lists %s, which wasn't written: %v", file.Path, err)
+			continue
+		}
+		sum := sha256.Sum256(content)
+		if file.SHA256 != hex.EncodeToString(sum[:]) || file.Size != int64(len(content)) {
+			t.Errorf("Expected %s to have size %d and hash %x, got %d and %s", file.Path, len(content), sum, file.Size, file.SHA256)
+		}
+	}
+
+	// Copied, rendered and generated files are all listed, in lexical order
+	want := []string{
+		GitLabCIFileName,
+		MakefileName,
+		"backend.tf",
+		"main.tf",
+		"modules/network/main.tf",
+		"terraform.tfvars.json",
+		VersionsFileName,
+	}
+	sort.Strings(want)
+	if strings.Join(paths, ",") != strings.Join(want, ",") {
+		t.Errorf("Expected the manifest to list %v, got %v", want, paths)
+	}
+}
+
+func TestScaffold_ConfiguredManifestPath(t *testing.T) {
+	tmpDir := t.TempDir()
+	srcDir := filepath.Join(tmpDir, "source")
+	dstDir := filepath.Join(tmpDir, "destination")
+	if err := os.MkdirAll(srcDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(srcDir, "main.tf"), []byte(`resource "aws_s3_bucket" "state" {}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// Stand in for the history of an earlier push, which isn't scaffolded
+	if err := os.MkdirAll(filepath.Join(dstDir, ".git"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dstDir, ".git", "HEAD"), []byte("ref: refs/heads/main\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	manifestPath := filepath.Join(dstDir, "klonekit.manifest.json")
+	spec := &blueprint.Spec{
+		Scaffold: blueprint.Scaffold{Source: srcDir, Destination: dstDir, Manifest: manifestPath},
+	}
+	if err := Scaffold(spec, false, false); err != nil {
+		t.Fatalf("Scaffold failed: %v", err)
+	}
+
+	manifest := readManifest(t, manifestPath)
+	if len(manifest.Files) != 1 || manifest.Files[0].Path != "main.tf" {
+		t.Errorf("Expected only main.tf, without .git or the manifest itself, got %+v", manifest.Files)
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, "destination."+blueprint.DefaultManifestFilename)); !os.IsNotExist(err) {
+		t.Error("Expected no manifest at the default path")
+	}
+}
+
+func TestScaffold_DryRunWritesNoManifest(t *testing.T) {
+	tmpDir := t.TempDir()
+	srcDir := filepath.Join(tmpDir, "source")
+	if err := os.MkdirAll(srcDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(srcDir, "main.tf"), []byte("# module"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	spec := &blueprint.Spec{
+		Scaffold: blueprint.Scaffold{Source: srcDir, Destination: filepath.Join(tmpDir, "destination")},
+	}
+	if err := Scaffold(spec, true, false); err != nil {
+		t.Fatalf("Scaffold failed: %v", err)
+	}
+	if _, err := os.Stat(spec.Scaffold.ManifestPath()); !os.IsNotExist(err) {
+		t.Error("Expected no manifest to be written during a dry run")
+	}
+}
+
+func TestScaffold_DefaultManifestPath(t *testing.T) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		destination string
+		want        string
+	}{
+		{destination: "build/network", want: filepath.Join("build", "network.klonekit.manifest.json")},
+		{destination: "build/database/", want: filepath.Join("build", "database.klonekit.manifest.json")},
+		// The current directory's manifest goes to its parent, outside the pushed tree
+		{destination: ".", want: filepath.Join(filepath.Dir(cwd), filepath.Base(cwd)+".klonekit.manifest.json")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.destination, func(t *testing.T) {
+			scaffold := blueprint.Scaffold{Destination: tt.destination}
+			if got := scaffold.ManifestPath(); got != tt.want {
+				t.Errorf("ManifestPath() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
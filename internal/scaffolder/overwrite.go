@@ -0,0 +1,85 @@
+package scaffolder
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing/format/gitignore"
+)
+
+// ErrDestinationNotEmpty is returned by Scaffold when the destination already contains files
+// and overwriting them was not forced.
+var ErrDestinationNotEmpty = errors.New("scaffold destination is not empty")
+
+// maxListedConflicts is how many existing files the destination error lists by name.
+const maxListedConflicts = 10
+
+// existingFiles returns the slash-separated paths of the files already in destPath that
+// scaffolding could overwrite, in lexical order. The .git directory and the Terraform
+// artifacts never copied from a source module (see defaultIgnorePatterns), such as the state
+// of earlier runs, are left out. A missing destination has no files.
+func existingFiles(destPath string) ([]string, error) {
+	var patterns []gitignore.Pattern
+	for _, pattern := range defaultIgnorePatterns {
+		patterns = append(patterns, gitignore.ParsePattern(pattern, nil))
+	}
+	artifacts := gitignore.NewMatcher(patterns)
+
+	var files []string
+	err := filepath.WalkDir(destPath, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path == destPath && errors.Is(err, fs.ErrNotExist) {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		relPath, err := filepath.Rel(destPath, path)
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if relPath != "." && (d.Name() == ".git" || isIgnored(artifacts, relPath, true)) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !isIgnored(artifacts, relPath, false) {
+			files = append(files, filepath.ToSlash(relPath))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the files in destination %s: %w", destPath, err)
+	}
+	return files, nil
+}
+
+// checkDestination refuses to scaffold into a destination that already contains files,
+// listing them, unless force is set. A dry run reports them instead of failing.
+func checkDestination(destPath string, isDryRun, force bool) error {
+	if force {
+		return nil
+	}
+	files, err := existingFiles(destPath)
+	if err != nil || len(files) == 0 {
+		return err
+	}
+
+	listed := files
+	if len(listed) > maxListedConflicts {
+		listed = listed[:maxListedConflicts]
+	}
+	conflicts := strings.Join(listed, ", ")
+	if more := len(files) - len(listed); more > 0 {
+		conflicts += fmt.Sprintf(" and %d more", more)
+	}
+
+	if isDryRun {
+		fmt.Printf("DRY RUN: Destination %s already contains %d files, which would be overwritten only with --force: %s\n", destPath, len(files), conflicts)
+		return nil
+	}
+	return fmt.Errorf("%w: %s already contains %d files (%s); pass --force to overwrite them or choose another destination", ErrDestinationNotEmpty, destPath, len(files), conflicts)
+}
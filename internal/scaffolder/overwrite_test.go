@@ -0,0 +1,133 @@
+package scaffolder
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"klonekit/pkg/blueprint"
+)
+
+// overwriteSpec returns a spec scaffolding a one-file module into a destination that already
+// holds the given files
+func overwriteSpec(t *testing.T, existing map[string]string) *blueprint.Spec {
+	t.Helper()
+
+	tmpDir := t.TempDir()
+	srcDir := filepath.Join(tmpDir, "source")
+	dstDir := filepath.Join(tmpDir, "destination")
+	if err := os.MkdirAll(srcDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(srcDir, "main.tf"), []byte(`resource "aws_s3_bucket" "new" {}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range existing {
+		path := filepath.Join(dstDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return &blueprint.Spec{
+		Scaffold: blueprint.Scaffold{Source: srcDir, Destination: dstDir, Manifest: filepath.Join(tmpDir, blueprint.DefaultManifestFilename)},
+	}
+}
+
+func TestScaffold_RefusesNonEmptyDestination(t *testing.T) {
+	spec := overwriteSpec(t, map[string]string{
+		"main.tf":           "# edited by hand",
+		"notes/todo.txt":    "keep me",
+		".git/HEAD":         "ref: refs/heads/main\n",
+		"terraform.tfstate": `{"version": 4}`,
+	})
+
+	err := Scaffold(spec, false, false)
+	if !errors.Is(err, ErrDestinationNotEmpty) {
+		t.Fatalf("Expected ErrDestinationNotEmpty, got: %v", err)
+	}
+	if !strings.Contains(err.Error(), "contains 2 files (main.tf, notes/todo.txt)") || !strings.Contains(err.Error(), "--force") {
+		t.Errorf("Expected the conflicting files and --force in the error, got: %s", err)
+	}
+
+	content, err := os.ReadFile(filepath.Join(spec.Scaffold.Destination, "main.tf"))
+	if err != nil || string(content) != "# edited by hand" {
+		t.Errorf("Expected the existing file to be left untouched, got %q (%v)", content, err)
+	}
+}
+
+func TestScaffold_ListsFirstConflicts(t *testing.T) {
+	existing := map[string]string{}
+	for i := 0; i < maxListedConflicts+3; i++ {
+		existing[fmt.Sprintf("file%02d.tf", i)] = "# existing"
+	}
+	spec := overwriteSpec(t, existing)
+
+	err := Scaffold(spec, false, false)
+	if err == nil || !strings.Contains(err.Error(), "file09.tf and 3 more") {
+		t.Errorf("Expected the conflict list to be cut short, got: %v", err)
+	}
+}
+
+func TestScaffold_ForceOverwrites(t *testing.T) {
+	spec := overwriteSpec(t, map[string]string{"main.tf": "# edited by hand"})
+
+	if err := Scaffold(spec, false, true); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	content, err := os.ReadFile(filepath.Join(spec.Scaffold.Destination, "main.tf"))
+	if err != nil || !strings.Contains(string(content), `"new"`) {
+		t.Errorf("Expected the existing file to be overwritten, got %q (%v)", content, err)
+	}
+}
+
+func TestScaffold_AllowsDestinationWithoutConflicts(t *testing.T) {
+	tests := []struct {
+		name     string
+		existing map[string]string
+	}{
+		{name: "missing destination"},
+		{name: "empty destination", existing: map[string]string{}},
+		{name: "only earlier run artifacts", existing: map[string]string{
+			".git/HEAD":                "ref: refs/heads/main\n",
+			".terraform/modules.json":  "{}",
+			"terraform.tfstate":        `{"version": 4}`,
+			"terraform.tfstate.backup": `{"version": 4}`,
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := overwriteSpec(t, tt.existing)
+			if tt.existing != nil {
+				if err := os.MkdirAll(spec.Scaffold.Destination, 0755); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := Scaffold(spec, false, false); err != nil {
+				t.Errorf("Unexpected error: %s", err)
+			}
+		})
+	}
+}
+
+func TestScaffold_DryRunReportsConflicts(t *testing.T) {
+	spec := overwriteSpec(t, map[string]string{"main.tf": "# edited by hand"})
+
+	output := captureStdout(t, func() {
+		if err := Scaffold(spec, true, false); err != nil {
+			t.Errorf("Expected the dry run not to fail, got: %s", err)
+		}
+	})
+
+	if !strings.Contains(output, "already contains 1 files, which would be overwritten only with --force: main.tf") {
+		t.Errorf("Expected the dry run to report the conflicting files, got:\n%s", output)
+	}
+}
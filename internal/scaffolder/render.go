@@ -0,0 +1,131 @@
+package scaffolder
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"klonekit/pkg/blueprint"
+)
+
+// TemplateFileSuffix marks module files rendered with text/template during scaffolding.
+// The rendered file is written without the suffix, e.g. backend.tf.tftpl becomes backend.tf.
+const TemplateFileSuffix = ".tftpl"
+
+// TemplateData is the data available to .tftpl files. It holds the resolved spec without
+// secrets: the SCM token is left out, and secret variables are omitted from Variables so
+// they can't end up in files pushed to SCM.
+//
+// Example: bucket = "{{ .SCM.Project.Namespace }}-{{ .SCM.Project.Name }}-state" and
+// region = "{{ .Cloud.Region }}".
+type TemplateData struct {
+	Cloud     TemplateCloud
+	SCM       TemplateSCM
+	Variables map[string]interface{}
+}
+
+// TemplateCloud is the cloud provider configuration exposed to templates.
+type TemplateCloud struct {
+	Provider string
+	Region   string
+}
+
+// TemplateSCM is the SCM configuration exposed to templates.
+type TemplateSCM struct {
+	Provider string
+	URL      string
+	Project  TemplateProject
+}
+
+// TemplateProject is the SCM project configuration exposed to templates.
+type TemplateProject struct {
+	Name        string
+	Namespace   string
+	Description string
+	Visibility  string
+}
+
+// NewTemplateData builds the template data for spec.
+func NewTemplateData(spec *blueprint.Spec) TemplateData {
+	secret := make(map[string]bool, len(spec.SecretVariables))
+	for _, key := range spec.SecretVariables {
+		secret[key] = true
+	}
+	variables := make(map[string]interface{}, len(spec.Variables))
+	for key, value := range spec.Variables {
+		if !secret[key] {
+			variables[key] = value
+		}
+	}
+
+	return TemplateData{
+		Cloud: TemplateCloud{
+			Provider: spec.Cloud.Provider,
+			Region:   spec.Cloud.Region,
+		},
+		SCM: TemplateSCM{
+			Provider: spec.SCM.Provider,
+			URL:      spec.SCM.URL,
+			Project: TemplateProject{
+				Name:        spec.SCM.Project.Name,
+				Namespace:   spec.SCM.Project.Namespace,
+				Description: spec.SCM.Project.Description,
+				Visibility:  spec.SCM.Project.Visibility,
+			},
+		},
+		Variables: variables,
+	}
+}
+
+// renderTemplate executes the template content with data. Referencing a missing
+// variable fails rather than rendering "<no value>".
+func renderTemplate(name string, content []byte, data TemplateData) ([]byte, error) {
+	tmpl, err := template.New(name).Option("missingkey=error").Parse(string(content))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse template %s: %w", name, err)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("failed to render template %s: %w", name, err)
+	}
+	return buf.Bytes(), nil
+}
+
+// renderTemplateFiles renders every .tftpl file copied to destPath and replaces it with
+// the rendered file.
+func renderTemplateFiles(spec *blueprint.Spec, destPath string) error {
+	data := NewTemplateData(spec)
+
+	return filepath.WalkDir(destPath, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !strings.HasSuffix(path, TemplateFileSuffix) {
+			return nil
+		}
+
+		content, err := os.ReadFile(path) // #nosec G304
+		if err != nil {
+			return fmt.Errorf("failed to read template %s: %w", path, err)
+		}
+		rendered, err := renderTemplate(d.Name(), content, data)
+		if err != nil {
+			return err
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		target := strings.TrimSuffix(path, TemplateFileSuffix)
+		if err := os.WriteFile(target, rendered, info.Mode().Perm()); err != nil {
+			return fmt.Errorf("failed to write %s: %w", target, err)
+		}
+		return os.Remove(path)
+	})
+}
@@ -0,0 +1,90 @@
+package scaffolder
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"klonekit/pkg/blueprint"
+)
+
+func TestScaffold_RendersTemplateFiles(t *testing.T) {
+	tmpDir := t.TempDir()
+	srcDir := filepath.Join(tmpDir, "source")
+	dstDir := filepath.Join(tmpDir, "destination")
+	if err := os.MkdirAll(srcDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	backend := `terraform {
+  backend "s3" {
+    bucket = "{{ .SCM.Project.Namespace }}-{{ .SCM.Project.Name }}-state"
+    region = "{{ .Cloud.Region }}"
+    key    = "{{ .Variables.environment }}/terraform.tfstate"
+  }
+}
+# {{ . }}
+`
+	if err := os.WriteFile(filepath.Join(srcDir, "backend.tf.tftpl"), []byte(backend), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	spec := &blueprint.Spec{
+		SCM: blueprint.SCMProvider{
+			Provider: "gitlab",
+			URL:      "https://gitlab.example.com",
+			Token:    "glpat-secret-token",
+			Project:  blueprint.ProjectConfig{Name: "infra", Namespace: "platform"},
+		},
+		Cloud:    blueprint.CloudProvider{Provider: "aws", Region: "eu-west-1"},
+		Scaffold: blueprint.Scaffold{Source: srcDir, Destination: dstDir},
+		Variables: map[string]interface{}{
+			"environment": "prod",
+			"db_password": "hunter2",
+		},
+		SecretVariables: []string{"db_password"},
+	}
+
+	if err := Scaffold(spec, false, false); err != nil {
+		t.Fatalf("Scaffold failed: %v", err)
+	}
+
+	content, err := os.ReadFile(filepath.Join(dstDir, "backend.tf"))
+	if err != nil {
+		t.Fatalf("Expected backend.tf to be rendered: %v", err)
+	}
+	rendered := string(content)
+
+	for _, want := range []string{
+		`bucket = "platform-infra-state"`,
+		`region = "eu-west-1"`,
+		`key    = "prod/terraform.tfstate"`,
+	} {
+		if !strings.Contains(rendered, want) {
+			t.Errorf("Expected rendered template to contain %q, got:\n%s", want, rendered)
+		}
+	}
+	if strings.Contains(rendered, "glpat-secret-token") {
+		t.Error("Expected the SCM token to be absent from the template context")
+	}
+	if strings.Contains(rendered, "hunter2") {
+		t.Error("Expected secret variables to be absent from the template context")
+	}
+
+	if _, err := os.Stat(filepath.Join(dstDir, "backend.tf.tftpl")); !os.IsNotExist(err) {
+		t.Error("Expected the .tftpl source to be removed after rendering")
+	}
+}
+
+func TestRenderTemplate_MissingVariable(t *testing.T) {
+	data := NewTemplateData(&blueprint.Spec{
+		Variables:       map[string]interface{}{"db_password": "hunter2"},
+		SecretVariables: []string{"db_password"},
+	})
+
+	_, err := renderTemplate("main.tf.tftpl", []byte(`password = "{{ .Variables.db_password }}"`), data)
+	if err == nil {
+		t.Fatal("Expected an error when a template references a missing variable, got nil")
+	}
+}
@@ -1,7 +1,6 @@
 package scaffolder
 
 import (
-	"encoding/json"
 	"fmt"
 	"io"
 	"io/fs"
@@ -9,26 +8,117 @@ import (
 	"path/filepath"
 	"strings"
 
+	"github.com/go-git/go-git/v5/plumbing/format/gitignore"
+
 	"klonekit/pkg/blueprint"
 )
 
 // Scaffold processes a blueprint spec and generates Terraform files.
-// It copies the source module directory to the destination and creates terraform.tfvars.json.
-func Scaffold(spec *blueprint.Spec, isDryRun bool) error {
+// It copies the source module directory to the destination, renders .tftpl files, creates
+// the tfvars file (terraform.tfvars.json unless scaffold.tfvarsFilename is set) and writes
+// the manifest of the scaffolded files. Each of scaffold.targets is scaffolded the same way
+// into its subdirectory of the destination, and the manifest covers them all.
+// A destination that already contains files is refused, listing them, unless force is set.
+func Scaffold(spec *blueprint.Spec, isDryRun, force bool) error {
 	if spec == nil {
 		return fmt.Errorf("spec cannot be nil")
 	}
 
+	// Don't silently clobber files already in the destination
+	if err := checkDestination(spec.Scaffold.Destination, isDryRun, force); err != nil {
+		return err
+	}
+
+	if spec.Scaffold.Source != "" {
+		if err := scaffoldModule(spec, isDryRun); err != nil {
+			return err
+		}
+	}
+
+	for _, target := range spec.Scaffold.Targets {
+		moduleSpec, err := targetSpec(spec, target)
+		if err == nil {
+			err = scaffoldModule(moduleSpec, isDryRun)
+		}
+		if err != nil {
+			return fmt.Errorf("scaffold target '%s': %w", target.Name, err)
+		}
+	}
+
+	if isDryRun {
+		return nil
+	}
+
+	// Generate the .gitlab-ci.yml pipeline if configured; it runs in the destination's module
+	if spec.Scaffold.Source != "" {
+		if err := generateGitLabCI(spec, spec.Scaffold.Destination); err != nil {
+			return err
+		}
+	}
+
+	// List every scaffolded file with its size and hash in the manifest
+	return writeManifest(spec, spec.Scaffold.Destination)
+}
+
+// targetSpec returns the spec scaffolding target: the blueprint's spec with the target's
+// source, its directory below the destination as the destination, and its variables added
+// to the blueprint's.
+func targetSpec(spec *blueprint.Spec, target blueprint.ScaffoldTarget) (*blueprint.Spec, error) {
+	dir := filepath.Clean(filepath.FromSlash(target.Destination))
+	if filepath.IsAbs(dir) || dir == "." || dir == ".." || strings.HasPrefix(dir, ".."+string(filepath.Separator)) {
+		return nil, fmt.Errorf("destination %s must be a subdirectory of the scaffold destination", target.Destination)
+	}
+
+	targetSpec := *spec
+	targetSpec.Scaffold.Source = target.Source
+	targetSpec.Scaffold.Destination = filepath.Join(spec.Scaffold.Destination, dir)
+	targetSpec.Scaffold.Targets = nil
+	if len(target.Variables) > 0 {
+		targetSpec.Variables = make(map[string]interface{}, len(spec.Variables)+len(target.Variables))
+		for key, value := range spec.Variables {
+			targetSpec.Variables[key] = value
+		}
+		for key, value := range target.Variables {
+			targetSpec.Variables[key] = value
+		}
+	}
+	return &targetSpec, nil
+}
+
+// scaffoldModule scaffolds the spec's source module into its destination.
+func scaffoldModule(spec *blueprint.Spec, isDryRun bool) error {
 	sourcePath := spec.Scaffold.Source
 	destPath := spec.Scaffold.Destination
 
-	// Validate source path exists
-	if _, err := os.Stat(sourcePath); os.IsNotExist(err) {
-		return fmt.Errorf("source module directory not found: %s", sourcePath)
+	// Resolve the source to a local module directory or a built-in template
+	sourceFS, isTemplate, err := resolveSource(sourcePath)
+	if err != nil {
+		return err
+	}
+
+	// Flag blueprint variables the module doesn't declare (usually typos)
+	if err := checkVariables(spec, sourceFS); err != nil {
+		return err
+	}
+
+	// Refuse variables a tfvars file can't hold, and flag complex values
+	if err := checkVariableValues(spec); err != nil {
+		return err
+	}
+
+	// Skip the files ignored by the source's .gitignore if configured
+	matcher, err := sourceIgnoreMatcher(spec, isTemplate)
+	if err != nil {
+		return err
+	}
+
+	// Refuse a source without Terraform files, e.g. a wrong or empty directory
+	if err := checkSourceHasTerraform(spec, sourceFS, matcher); err != nil {
+		return err
 	}
 
 	if isDryRun {
-		return performDryRun(spec)
+		return performDryRun(spec, sourceFS, matcher)
 	}
 
 	// Create destination directory
@@ -36,40 +126,63 @@ func Scaffold(spec *blueprint.Spec, isDryRun bool) error {
 		return fmt.Errorf("failed to create destination directory: %w", err)
 	}
 
-	// Copy source directory to destination
-	if err := copyDirectory(sourcePath, destPath); err != nil {
+	// Copy source directory or built-in template to destination
+	if isTemplate {
+		if err := copyTemplate(sourceFS, destPath); err != nil {
+			return fmt.Errorf("failed to copy template %s: %w", sourcePath, err)
+		}
+	} else if err := copyDirectory(sourcePath, destPath, matcher); err != nil {
 		return fmt.Errorf("failed to copy source directory: %w", err)
 	}
 
-	// Generate terraform.tfvars.json file
+	// Render .tftpl files with the cloud, SCM and variable context
+	if err := renderTemplateFiles(spec, destPath); err != nil {
+		return err
+	}
+
+	// Generate the tfvars file
 	if err := generateTerraformVars(spec, destPath); err != nil {
-		return fmt.Errorf("failed to generate terraform.tfvars.json: %w", err)
+		return fmt.Errorf("failed to generate %s: %w", spec.Scaffold.TfvarsFile(), err)
 	}
 
-	return nil
+	// Generate versions.tf with provider constraints if the module lacks them
+	if err := generateVersionsFile(spec, destPath); err != nil {
+		return fmt.Errorf("failed to generate %s: %w", VersionsFileName, err)
+	}
+
+	// Generate provider.tf for the cloud provider if configured and the module lacks it
+	if err := generateProviderFile(spec, destPath); err != nil {
+		return fmt.Errorf("failed to generate %s: %w", ProviderFileName, err)
+	}
+
+	// Generate the Makefile or justfile wrapping terraform if configured
+	return generateTaskFile(spec, destPath)
 }
 
 // performDryRun logs what would be done without actually performing the operations.
-func performDryRun(spec *blueprint.Spec) error {
+func performDryRun(spec *blueprint.Spec, sourceFS fs.FS, matcher gitignore.Matcher) error {
 	sourcePath := spec.Scaffold.Source
 	destPath := spec.Scaffold.Destination
 
 	fmt.Printf("DRY RUN: Would copy directory from %s to %s\n", sourcePath, destPath)
 
 	// Walk through source directory to show what would be copied
-	err := filepath.WalkDir(sourcePath, func(path string, d fs.DirEntry, err error) error {
+	err := fs.WalkDir(sourceFS, ".", func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return err
 		}
-
-		relPath, err := filepath.Rel(sourcePath, path)
-		if err != nil {
-			return err
+		if isIgnored(matcher, path, d.IsDir()) {
+			if d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
 		}
 
-		destFile := filepath.Join(destPath, relPath)
+		destFile := filepath.Join(destPath, filepath.FromSlash(path))
 		if d.IsDir() {
 			fmt.Printf("DRY RUN: Would create directory: %s\n", destFile)
+		} else if strings.HasSuffix(destFile, TemplateFileSuffix) {
+			fmt.Printf("DRY RUN: Would render template: %s\n", strings.TrimSuffix(destFile, TemplateFileSuffix))
 		} else {
 			fmt.Printf("DRY RUN: Would copy file: %s\n", destFile)
 		}
@@ -80,24 +193,62 @@ func performDryRun(spec *blueprint.Spec) error {
 		return fmt.Errorf("failed to walk source directory: %w", err)
 	}
 
-	// Show terraform.tfvars.json that would be generated
-	tfvarsPath := filepath.Join(destPath, "terraform.tfvars.json")
-	fmt.Printf("DRY RUN: Would create file: %s\n", tfvarsPath)
+	// Show the tfvars file that would be generated
+	tfvarsFile := spec.Scaffold.TfvarsFile()
+	tfvarsPath := filepath.Join(destPath, tfvarsFile)
+	if spec.Provision.WritesTfvars() {
+		fmt.Printf("DRY RUN: Would create file: %s\n", tfvarsPath)
+	}
+
+	// Use only user-defined variables, without printing the values of secret ones
+	allVars := blueprint.MaskSecretVariables(spec.Variables, spec.SecretVariables)
+	if len(allVars) > 0 && spec.Provision.WritesTfvars() {
+		fmt.Printf("DRY RUN: %s content would be:\n", tfvarsFile)
+		if content, err := RenderTfvars(allVars, tfvarsFile); err == nil {
+			fmt.Println(strings.TrimSuffix(string(content), "\n"))
+		}
+	}
 
-	// Use only user-defined variables
-	allVars := spec.Variables
-	if len(allVars) > 0 {
-		fmt.Println("DRY RUN: terraform.tfvars.json content would be:")
-		if jsonBytes, err := json.MarshalIndent(allVars, "", "  "); err == nil {
-			fmt.Println(string(jsonBytes))
+	// Show versions.tf that would be generated
+	if len(spec.Scaffold.RequiredProviders) > 0 {
+		if _, err := fs.Stat(sourceFS, VersionsFileName); os.IsNotExist(err) {
+			if declared, err := declaresRequiredProviders(sourceFS); err == nil && !declared {
+				fmt.Printf("DRY RUN: Would create file: %s\n", filepath.Join(destPath, VersionsFileName))
+			}
 		}
 	}
 
+	// Show provider.tf that would be generated
+	if spec.Scaffold.GenerateProvider {
+		if _, err := fs.Stat(sourceFS, ProviderFileName); os.IsNotExist(err) {
+			if declared, err := declaresProvider(sourceFS, spec.Cloud.Provider); err == nil && !declared {
+				fmt.Printf("DRY RUN: Would create file: %s\n", filepath.Join(destPath, ProviderFileName))
+			}
+		}
+	}
+
+	// Show the task file that would be generated
+	if name := taskFileName(spec); name != "" {
+		if _, err := fs.Stat(sourceFS, name); os.IsNotExist(err) {
+			fmt.Printf("DRY RUN: Would create file: %s\n", filepath.Join(destPath, name))
+		}
+	}
+
+	// Show the pipeline that would be generated
+	if spec.Scaffold.GitLabCI {
+		if _, err := fs.Stat(sourceFS, GitLabCIFileName); os.IsNotExist(err) {
+			fmt.Printf("DRY RUN: Would create file: %s\n", filepath.Join(destPath, GitLabCIFileName))
+		}
+	}
+
+	fmt.Printf("DRY RUN: Would create manifest: %s\n", spec.Scaffold.ManifestPath())
+
 	return nil
 }
 
-// copyDirectory recursively copies a directory from src to dst.
-func copyDirectory(src, dst string) error {
+// copyDirectory recursively copies a directory from src to dst, skipping the paths ignored
+// by matcher.
+func copyDirectory(src, dst string, matcher gitignore.Matcher) error {
 	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return err
@@ -107,6 +258,12 @@ func copyDirectory(src, dst string) error {
 		if err != nil {
 			return err
 		}
+		if isIgnored(matcher, relPath, d.IsDir()) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
 
 		destPath := filepath.Join(dst, relPath)
 
@@ -163,26 +320,26 @@ func copyFile(src, dst string) error {
 	return os.Chmod(dst, srcInfo.Mode())
 }
 
-// generateTerraformVars creates a terraform.tfvars.json file with the variables from the blueprint.
+// generateTerraformVars creates the tfvars file with the variables from the blueprint.
 func generateTerraformVars(spec *blueprint.Spec, destPath string) error {
 	// Use only user-defined variables
 	allVars := spec.Variables
 
-	if len(allVars) == 0 {
+	if len(allVars) == 0 || !spec.Provision.WritesTfvars() {
 		return nil
 	}
 
-	tfvarsPath := filepath.Join(destPath, "terraform.tfvars.json")
+	tfvarsFile := spec.Scaffold.TfvarsFile()
+	tfvarsPath := filepath.Join(destPath, tfvarsFile)
 
-	jsonBytes, err := json.MarshalIndent(allVars, "", "  ")
+	content, err := RenderTfvars(allVars, tfvarsFile)
 	if err != nil {
-		return fmt.Errorf("failed to marshal variables to JSON: %w", err)
+		return err
 	}
 
-	if err := os.WriteFile(tfvarsPath, jsonBytes, 0600); err != nil {
-		return fmt.Errorf("failed to write terraform.tfvars.json: %w", err)
+	if err := os.WriteFile(tfvarsPath, content, 0600); err != nil {
+		return fmt.Errorf("failed to write %s: %w", tfvarsFile, err)
 	}
 
 	return nil
 }
-
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
 
@@ -53,7 +54,7 @@ func TestScaffold_ValidSpec(t *testing.T) {
 	}
 
 	// Execute scaffold
-	err = Scaffold(spec, false)
+	err = Scaffold(spec, false, false)
 	if err != nil {
 		t.Fatalf("Expected no error, got: %v", err)
 	}
@@ -129,7 +130,7 @@ func TestScaffold_DryRun(t *testing.T) {
 	}
 
 	// Execute dry run
-	err = Scaffold(spec, true)
+	err = Scaffold(spec, true, false)
 	if err != nil {
 		t.Fatalf("Expected no error from dry run, got: %v", err)
 	}
@@ -154,7 +155,7 @@ func TestScaffold_SourceNotFound(t *testing.T) {
 		},
 	}
 
-	err := Scaffold(spec, false)
+	err := Scaffold(spec, false, false)
 	if err == nil {
 		t.Fatal("Expected error for non-existent source directory, got nil")
 	}
@@ -165,7 +166,7 @@ func TestScaffold_SourceNotFound(t *testing.T) {
 }
 
 func TestScaffold_NilSpec(t *testing.T) {
-	err := Scaffold(nil, false)
+	err := Scaffold(nil, false, false)
 	if err == nil {
 		t.Fatal("Expected error for nil spec, got nil")
 	}
@@ -204,7 +205,7 @@ func TestScaffold_NoVariables(t *testing.T) {
 	}
 
 	// Execute scaffold
-	err = Scaffold(spec, false)
+	err = Scaffold(spec, false, false)
 	if err != nil {
 		t.Fatalf("Expected no error, got: %v", err)
 	}
@@ -261,7 +262,7 @@ func TestScaffold_NestedDirectories(t *testing.T) {
 	}
 
 	// Execute scaffold
-	err = Scaffold(spec, false)
+	err = Scaffold(spec, false, false)
 	if err != nil {
 		t.Fatalf("Expected no error, got: %v", err)
 	}
@@ -278,3 +279,280 @@ func TestScaffold_NestedDirectories(t *testing.T) {
 		t.Error("Root file was not copied")
 	}
 }
+
+func TestScaffold_TfvarsFilename(t *testing.T) {
+	tests := []struct {
+		name     string
+		filename string
+		want     []string
+	}{
+		{
+			name:     "json",
+			filename: "prod.tfvars.json",
+			want:     []string{`"region": "us-east-1"`},
+		},
+		{
+			name:     "hcl",
+			filename: "prod.tfvars",
+			want:     []string{`region = "us-east-1"`, `instance_count = 2`, `tags = {`, `  Team = "platform"`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			srcDir := filepath.Join(tmpDir, "source")
+			dstDir := filepath.Join(tmpDir, "destination")
+			if err := os.MkdirAll(srcDir, 0755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(filepath.Join(srcDir, "main.tf"), []byte("# main"), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			spec := &blueprint.Spec{
+				Scaffold: blueprint.Scaffold{
+					Source:         srcDir,
+					Destination:    dstDir,
+					TfvarsFilename: tt.filename,
+				},
+				Variables: map[string]interface{}{
+					"region":         "us-east-1",
+					"instance_count": 2,
+					"tags":           map[string]interface{}{"Team": "platform"},
+				},
+			}
+
+			if err := Scaffold(spec, false, false); err != nil {
+				t.Fatalf("Expected no error, got: %v", err)
+			}
+
+			if _, err := os.Stat(filepath.Join(dstDir, "terraform.tfvars.json")); !os.IsNotExist(err) {
+				t.Error("terraform.tfvars.json should not be created when another filename is configured")
+			}
+
+			content, err := os.ReadFile(filepath.Join(dstDir, tt.filename))
+			if err != nil {
+				t.Fatalf("Failed to read %s: %v", tt.filename, err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(string(content), want) {
+					t.Errorf("Expected %s to contain %q, got:\n%s", tt.filename, want, content)
+				}
+			}
+		})
+	}
+}
+
+func TestScaffold_VariablesModeFlagsSkipsTfvars(t *testing.T) {
+	tmpDir := t.TempDir()
+	srcDir := filepath.Join(tmpDir, "source")
+	dstDir := filepath.Join(tmpDir, "destination")
+	if err := os.MkdirAll(srcDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(srcDir, "main.tf"), []byte(`variable "region" {}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	spec := &blueprint.Spec{
+		Scaffold:  blueprint.Scaffold{Source: srcDir, Destination: dstDir},
+		Provision: blueprint.Provision{VariablesMode: blueprint.VariablesModeFlags},
+		Variables: map[string]interface{}{"region": "us-east-1"},
+	}
+	if err := Scaffold(spec, false, false); err != nil {
+		t.Fatalf("Scaffold failed: %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(dstDir, blueprint.DefaultTfvarsFilename)); !os.IsNotExist(err) {
+		t.Error("Expected no tfvars file when variables are passed as -var flags")
+	}
+}
+
+func TestScaffold_Targets(t *testing.T) {
+	tmpDir := t.TempDir()
+	dstDir := filepath.Join(tmpDir, "repo")
+	sources := map[string]string{}
+	for _, name := range []string{"root", "network", "compute"} {
+		srcDir := filepath.Join(tmpDir, name)
+		if err := os.MkdirAll(srcDir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(srcDir, "main.tf"), []byte("# "+name), 0644); err != nil {
+			t.Fatal(err)
+		}
+		sources[name] = srcDir
+	}
+
+	spec := &blueprint.Spec{
+		Scaffold: blueprint.Scaffold{
+			Source:      sources["root"],
+			Destination: dstDir,
+			Targets: []blueprint.ScaffoldTarget{
+				{Name: "network", Source: sources["network"], Destination: "infra/network"},
+				{Name: "compute", Source: sources["compute"], Destination: "infra/compute"},
+			},
+		},
+		Variables: map[string]interface{}{"region": "us-east-1"},
+	}
+	if err := Scaffold(spec, false, false); err != nil {
+		t.Fatalf("Scaffold failed: %v", err)
+	}
+
+	for name, dir := range map[string]string{
+		"root":    dstDir,
+		"network": filepath.Join(dstDir, "infra", "network"),
+		"compute": filepath.Join(dstDir, "infra", "compute"),
+	} {
+		content, err := os.ReadFile(filepath.Join(dir, "main.tf"))
+		if err != nil || string(content) != "# "+name {
+			t.Errorf("Expected %s to be scaffolded to %s, got %q (%v)", name, dir, content, err)
+		}
+		if _, err := os.Stat(filepath.Join(dir, blueprint.DefaultTfvarsFilename)); err != nil {
+			t.Errorf("Expected a tfvars file in %s: %v", dir, err)
+		}
+	}
+
+	// The manifest covers every target, so the check sees them all
+	result, err := Check(spec)
+	if err != nil {
+		t.Fatalf("Check failed: %v", err)
+	}
+	if result.Diverged() {
+		t.Errorf("Expected no drift right after scaffolding, got %+v", result.Drift)
+	}
+	if err := os.Remove(filepath.Join(dstDir, "infra", "compute", "main.tf")); err != nil {
+		t.Fatal(err)
+	}
+	if result, err := Check(spec); err != nil || !result.Diverged() {
+		t.Errorf("Expected a removed target file to be reported as drift, got %+v (%v)", result, err)
+	}
+}
+
+func TestScaffold_TargetVariables(t *testing.T) {
+	tmpDir := t.TempDir()
+	dstDir := filepath.Join(tmpDir, "repo")
+	sources := map[string]string{}
+	for _, name := range []string{"network", "compute"} {
+		srcDir := filepath.Join(tmpDir, name)
+		if err := os.MkdirAll(srcDir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(srcDir, "main.tf"), []byte("# "+name), 0644); err != nil {
+			t.Fatal(err)
+		}
+		sources[name] = srcDir
+	}
+
+	spec := &blueprint.Spec{
+		Scaffold: blueprint.Scaffold{
+			Destination: dstDir,
+			Targets: []blueprint.ScaffoldTarget{
+				{Name: "network", Source: sources["network"], Destination: "network", Variables: map[string]interface{}{"cidr": "10.0.0.0/16"}},
+				{Name: "compute", Source: sources["compute"], Destination: "compute", Variables: map[string]interface{}{"region": "eu-west-1", "instance_type": "t3.small"}},
+			},
+		},
+		Variables: map[string]interface{}{"region": "us-east-1"},
+	}
+	if err := Scaffold(spec, false, false); err != nil {
+		t.Fatalf("Scaffold failed: %v", err)
+	}
+
+	for name, want := range map[string]map[string]interface{}{
+		"network": {"region": "us-east-1", "cidr": "10.0.0.0/16"},
+		"compute": {"region": "eu-west-1", "instance_type": "t3.small"},
+	} {
+		content, err := os.ReadFile(filepath.Join(dstDir, name, blueprint.DefaultTfvarsFilename))
+		if err != nil {
+			t.Fatalf("Expected a tfvars file for %s: %v", name, err)
+		}
+		var got map[string]interface{}
+		if err := json.Unmarshal(content, &got); err != nil {
+			t.Fatalf("Failed to parse the tfvars of %s: %v", name, err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("Expected the variables of %s to be %v, got %v", name, want, got)
+		}
+	}
+
+	// The blueprint's own variables are left as they are
+	if len(spec.Variables) != 1 || spec.Variables["region"] != "us-east-1" {
+		t.Errorf("Expected the spec variables to be unchanged, got %v", spec.Variables)
+	}
+}
+
+func TestScaffold_TargetsOnly(t *testing.T) {
+	tmpDir := t.TempDir()
+	srcDir := filepath.Join(tmpDir, "network")
+	if err := os.MkdirAll(srcDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(srcDir, "main.tf"), []byte("# network"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	dstDir := filepath.Join(tmpDir, "repo")
+	spec := &blueprint.Spec{
+		Scaffold: blueprint.Scaffold{
+			Destination: dstDir,
+			Targets:     []blueprint.ScaffoldTarget{{Name: "network", Source: srcDir, Destination: "infra/network"}},
+		},
+	}
+	if err := Scaffold(spec, false, false); err != nil {
+		t.Fatalf("Scaffold failed: %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(dstDir, "infra", "network", "main.tf")); err != nil {
+		t.Errorf("Expected the target to be scaffolded: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dstDir, "main.tf")); !os.IsNotExist(err) {
+		t.Error("Expected nothing to be scaffolded into the destination itself")
+	}
+}
+
+func TestScaffold_TargetFailureIdentifiesTarget(t *testing.T) {
+	tmpDir := t.TempDir()
+	srcDir := filepath.Join(tmpDir, "network")
+	if err := os.MkdirAll(srcDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(srcDir, "main.tf"), []byte("# module"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		target  blueprint.ScaffoldTarget
+		wantErr string
+	}{
+		{
+			name:    "missing source",
+			target:  blueprint.ScaffoldTarget{Name: "compute", Source: filepath.Join(tmpDir, "missing"), Destination: "infra/compute"},
+			wantErr: "scaffold target 'compute': source module directory not found",
+		},
+		{
+			name:    "destination outside the scaffold destination",
+			target:  blueprint.ScaffoldTarget{Name: "compute", Source: srcDir, Destination: "../compute"},
+			wantErr: "scaffold target 'compute': destination ../compute must be a subdirectory of the scaffold destination",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := &blueprint.Spec{
+				Scaffold: blueprint.Scaffold{
+					Destination: filepath.Join(t.TempDir(), "repo"),
+					Targets: []blueprint.ScaffoldTarget{
+						{Name: "network", Source: srcDir, Destination: "infra/network"},
+						tt.target,
+					},
+				},
+			}
+
+			err := Scaffold(spec, false, false)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error containing %q, got: %v", tt.wantErr, err)
+			}
+		})
+	}
+}
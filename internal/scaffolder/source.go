@@ -0,0 +1,43 @@
+package scaffolder
+
+import (
+	"fmt"
+	"io/fs"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing/format/gitignore"
+
+	"klonekit/internal/errors"
+	"klonekit/pkg/blueprint"
+)
+
+// checkSourceHasTerraform checks that the source module has a Terraform file (.tf, .tf.json,
+// or a .tftpl template rendering to one) in its root directory that isn't ignored, so a
+// source pointing at an empty or wrong directory fails instead of scaffolding nothing useful.
+// It is skipped with scaffold.skipSourceCheck.
+func checkSourceHasTerraform(spec *blueprint.Spec, sourceFS fs.FS, matcher gitignore.Matcher) error {
+	if spec.Scaffold.SkipSourceCheck {
+		return nil
+	}
+
+	entries, err := fs.ReadDir(sourceFS, ".")
+	if err != nil {
+		return fmt.Errorf("failed to read source module directory %s: %w", spec.Scaffold.Source, err)
+	}
+	for _, entry := range entries {
+		name := strings.TrimSuffix(entry.Name(), TemplateFileSuffix)
+		if entry.IsDir() || isIgnored(matcher, entry.Name(), false) {
+			continue
+		}
+		if strings.HasSuffix(name, ".tf") || strings.HasSuffix(name, ".tf.json") {
+			return nil
+		}
+	}
+
+	return errors.NewScaffoldError(
+		"Cannot scaffold the source module",
+		fmt.Sprintf("Source %s contains no Terraform (.tf) files in its root directory", spec.Scaffold.Source),
+		"Point spec.scaffold.source at the directory holding the module's .tf files, or set spec.scaffold.skipSourceCheck (--skip-source-check) if it only holds modules in subdirectories",
+		fmt.Errorf("no terraform files found in scaffold source: %s", spec.Scaffold.Source),
+	)
+}
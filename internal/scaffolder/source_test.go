@@ -0,0 +1,84 @@
+package scaffolder
+
+import (
+	stderrors "errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"klonekit/internal/errors"
+	"klonekit/pkg/blueprint"
+)
+
+func TestScaffold_SourceTerraformCheck(t *testing.T) {
+	tests := []struct {
+		name            string
+		sourceFiles     map[string]string
+		skipSourceCheck bool
+		wantErr         bool
+	}{
+		{name: "empty source", wantErr: true},
+		{name: "no Terraform files", sourceFiles: map[string]string{"README.md": "# docs"}, wantErr: true},
+		{name: "only modules in subdirectories", sourceFiles: map[string]string{"modules/vpc/main.tf": "# vpc"}, wantErr: true},
+		{name: "only ignored Terraform files", sourceFiles: map[string]string{"main.tf": "# module", IgnoreFileName: "main.tf\n"}, wantErr: true},
+		{name: "tf file", sourceFiles: map[string]string{"main.tf": "# module"}},
+		{name: "tf.json file", sourceFiles: map[string]string{"main.tf.json": "{}"}},
+		{name: "tf template", sourceFiles: map[string]string{"main.tf" + TemplateFileSuffix: "# module"}},
+		{name: "skipped", sourceFiles: map[string]string{"modules/vpc/main.tf": "# vpc"}, skipSourceCheck: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			srcDir := filepath.Join(tmpDir, "source")
+			dstDir := filepath.Join(tmpDir, "destination")
+			if err := os.MkdirAll(srcDir, 0755); err != nil {
+				t.Fatal(err)
+			}
+			for name, content := range tt.sourceFiles {
+				path := filepath.Join(srcDir, filepath.FromSlash(name))
+				if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			spec := &blueprint.Spec{
+				Scaffold: blueprint.Scaffold{Source: srcDir, Destination: dstDir, SkipSourceCheck: tt.skipSourceCheck},
+			}
+			err := Scaffold(spec, false, false)
+
+			if !tt.wantErr {
+				if err != nil {
+					t.Fatalf("Expected no error, got: %v", err)
+				}
+				return
+			}
+
+			var kloneKitErr *errors.KloneKitError
+			if !stderrors.As(err, &kloneKitErr) || !stderrors.Is(kloneKitErr.Type, errors.ErrScaffoldFailed) {
+				t.Fatalf("Expected a scaffold error, got: %v", err)
+			}
+			if !strings.Contains(kloneKitErr.Cause, srcDir) || !strings.Contains(kloneKitErr.Suggestion, "--skip-source-check") {
+				t.Errorf("Expected the cause to name the source and the suggestion to mention --skip-source-check, got %q and %q", kloneKitErr.Cause, kloneKitErr.Suggestion)
+			}
+			if _, err := os.Stat(dstDir); !os.IsNotExist(err) {
+				t.Error("Expected nothing to be written to the destination")
+			}
+		})
+	}
+}
+
+func TestScaffold_DryRunChecksSource(t *testing.T) {
+	tmpDir := t.TempDir()
+	spec := &blueprint.Spec{
+		Scaffold: blueprint.Scaffold{Source: tmpDir, Destination: filepath.Join(tmpDir, "destination")},
+	}
+
+	if err := Scaffold(spec, true, false); err == nil || !strings.Contains(err.Error(), "no terraform files found") {
+		t.Errorf("Expected a dry run of an empty source to fail, got: %v", err)
+	}
+}
@@ -0,0 +1,85 @@
+package scaffolder
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"klonekit/internal/provisioner"
+	"klonekit/pkg/blueprint"
+)
+
+// Task file kinds of scaffold.taskFile and the names they are written as.
+const (
+	TaskFileMakefile = "makefile"
+	TaskFileJustfile = "justfile"
+
+	MakefileName = "Makefile"
+	JustfileName = "justfile"
+)
+
+// taskFileName returns the file name generated for the spec's scaffold.taskFile, or "" when
+// no task file is configured.
+func taskFileName(spec *blueprint.Spec) string {
+	switch spec.Scaffold.TaskFile {
+	case TaskFileMakefile:
+		return MakefileName
+	case TaskFileJustfile:
+		return JustfileName
+	default:
+		return ""
+	}
+}
+
+// generateTaskFile writes a Makefile or justfile with init, plan and apply targets wrapping
+// terraform. It is skipped when no task file is configured or the module already has one.
+func generateTaskFile(spec *blueprint.Spec, destPath string) error {
+	name := taskFileName(spec)
+	if name == "" {
+		return nil
+	}
+
+	taskFilePath := filepath.Join(destPath, name)
+	if _, err := os.Stat(taskFilePath); err == nil {
+		return nil
+	}
+
+	content := renderTaskFile(spec)
+	if err := os.WriteFile(taskFilePath, []byte(content), 0644); err != nil { // #nosec G306
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	return nil
+}
+
+// renderTaskFile renders the task file for the spec, calling the binary of the provisioning
+// engine. The working directory and region can be overridden with the TF_DIR and AWS_REGION
+// environment variables.
+func renderTaskFile(spec *blueprint.Spec) string {
+	varFile := ""
+	if spec.Provision.WritesTfvars() && !spec.Scaffold.TfvarsAutoLoaded() {
+		varFile = " -var-file=" + spec.Scaffold.TfvarsFile()
+	}
+	region := spec.Cloud.Region
+	binary := provisioner.EngineBinary(spec)
+
+	var b strings.Builder
+	b.WriteString("# Generated by KloneKit\n")
+	if spec.Scaffold.TaskFile == TaskFileJustfile {
+		b.WriteString("tf_dir := env_var_or_default(\"TF_DIR\", \".\")\n")
+		fmt.Fprintf(&b, "export AWS_REGION := env_var_or_default(\"AWS_REGION\", %q)\n", region)
+		fmt.Fprintf(&b, "\ninit:\n    %s -chdir={{tf_dir}} init\n", binary)
+		fmt.Fprintf(&b, "\nplan: init\n    %s -chdir={{tf_dir}} plan%s\n", binary, varFile)
+		fmt.Fprintf(&b, "\napply: init\n    %s -chdir={{tf_dir}} apply%s\n", binary, varFile)
+		return b.String()
+	}
+
+	b.WriteString("TF_DIR ?= .\n")
+	fmt.Fprintf(&b, "AWS_REGION ?= %s\n", region)
+	b.WriteString("export AWS_REGION\n")
+	b.WriteString("\n.PHONY: init plan apply\n")
+	fmt.Fprintf(&b, "\ninit:\n\t%s -chdir=$(TF_DIR) init\n", binary)
+	fmt.Fprintf(&b, "\nplan: init\n\t%s -chdir=$(TF_DIR) plan%s\n", binary, varFile)
+	fmt.Fprintf(&b, "\napply: init\n\t%s -chdir=$(TF_DIR) apply%s\n", binary, varFile)
+	return b.String()
+}
@@ -0,0 +1,101 @@
+package scaffolder
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// templatesFS holds the built-in Terraform templates, one directory per template.
+//
+//go:embed templates
+var templatesFS embed.FS
+
+// TemplateNames returns the names of the built-in templates in sorted order.
+func TemplateNames() []string {
+	entries, err := templatesFS.ReadDir("templates")
+	if err != nil {
+		return nil
+	}
+
+	var names []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// IsTemplate reports whether name identifies a built-in template.
+func IsTemplate(name string) bool {
+	for _, template := range TemplateNames() {
+		if template == name {
+			return true
+		}
+	}
+	return false
+}
+
+// isTemplateIdentifier reports whether source looks like a template name rather than a path.
+func isTemplateIdentifier(source string) bool {
+	return source != "" && !strings.ContainsAny(source, `/\`) && !strings.HasPrefix(source, ".")
+}
+
+// templateFS returns the file system of the named built-in template.
+func templateFS(name string) (fs.FS, error) {
+	return fs.Sub(templatesFS, "templates/"+name)
+}
+
+// resolveSource returns the file system to scaffold from and whether it is a built-in template.
+// A local directory takes precedence; otherwise a source that names a built-in template
+// resolves to the embedded files.
+func resolveSource(source string) (fs.FS, bool, error) {
+	if _, err := os.Stat(source); err == nil {
+		return os.DirFS(source), false, nil
+	} else if !os.IsNotExist(err) {
+		return nil, false, fmt.Errorf("failed to access source module directory %s: %w", source, err)
+	}
+
+	if isTemplateIdentifier(source) {
+		if !IsTemplate(source) {
+			return nil, false, fmt.Errorf("source module directory not found: %s is neither a directory nor a built-in template (available templates: %s)",
+				source, strings.Join(TemplateNames(), ", "))
+		}
+		templateFiles, err := templateFS(source)
+		if err != nil {
+			return nil, false, err
+		}
+		return templateFiles, true, nil
+	}
+
+	return nil, false, fmt.Errorf("source module directory not found: %s", source)
+}
+
+// copyTemplate writes the files of a built-in template to dst.
+func copyTemplate(srcFS fs.FS, dst string) error {
+	return fs.WalkDir(srcFS, ".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		destPath := filepath.Join(dst, filepath.FromSlash(path))
+		if d.IsDir() {
+			return os.MkdirAll(destPath, 0750)
+		}
+
+		content, err := fs.ReadFile(srcFS, path)
+		if err != nil {
+			return fmt.Errorf("failed to read template file %s: %w", path, err)
+		}
+		if err := os.WriteFile(destPath, content, 0644); err != nil { // #nosec G306
+			return fmt.Errorf("failed to write template file %s: %w", destPath, err)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,29 @@
+variable "region" {
+  description = "AWS region to create the VPC in"
+  type        = string
+  default     = "us-east-1"
+}
+
+variable "name" {
+  description = "Name used for the VPC and its resources"
+  type        = string
+  default     = "klonekit-vpc"
+}
+
+variable "vpc_cidr" {
+  description = "CIDR block of the VPC"
+  type        = string
+  default     = "10.0.0.0/16"
+}
+
+variable "public_subnet_cidrs" {
+  description = "CIDR blocks of the public subnets"
+  type        = list(string)
+  default     = ["10.0.1.0/24", "10.0.2.0/24"]
+}
+
+variable "tags" {
+  description = "Tags applied to all resources"
+  type        = map(string)
+  default     = {}
+}
@@ -0,0 +1,22 @@
+variable "region" {
+  description = "AWS region to create the bucket in"
+  type        = string
+  default     = "us-east-1"
+}
+
+variable "bucket_name" {
+  description = "Globally unique name of the bucket"
+  type        = string
+}
+
+variable "versioning" {
+  description = "Whether object versioning is enabled"
+  type        = bool
+  default     = true
+}
+
+variable "tags" {
+  description = "Tags applied to the bucket"
+  type        = map(string)
+  default     = {}
+}
@@ -0,0 +1,109 @@
+package scaffolder
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"klonekit/pkg/blueprint"
+)
+
+func TestScaffold_BuiltinTemplate(t *testing.T) {
+	for _, name := range []string{"aws-vpc", "s3-bucket"} {
+		t.Run(name, func(t *testing.T) {
+			dstDir := filepath.Join(t.TempDir(), "destination")
+			spec := &blueprint.Spec{
+				Scaffold: blueprint.Scaffold{
+					Source:      name,
+					Destination: dstDir,
+				},
+				Variables: map[string]interface{}{
+					"region": "eu-west-1",
+				},
+			}
+
+			if err := Scaffold(spec, false, false); err != nil {
+				t.Fatalf("Expected no error, got: %v", err)
+			}
+
+			for _, file := range []string{"main.tf", "variables.tf", "outputs.tf", "terraform.tfvars.json"} {
+				if _, err := os.Stat(filepath.Join(dstDir, file)); err != nil {
+					t.Errorf("Expected %s to be scaffolded from template %s: %v", file, name, err)
+				}
+			}
+
+			embedded, err := templatesFS.ReadFile("templates/" + name + "/main.tf")
+			if err != nil {
+				t.Fatalf("Failed to read embedded template: %v", err)
+			}
+			scaffolded, err := os.ReadFile(filepath.Join(dstDir, "main.tf"))
+			if err != nil {
+				t.Fatalf("Failed to read scaffolded main.tf: %v", err)
+			}
+			if string(scaffolded) != string(embedded) {
+				t.Errorf("Expected scaffolded main.tf to match the embedded template")
+			}
+		})
+	}
+}
+
+func TestScaffold_UnknownTemplate(t *testing.T) {
+	spec := &blueprint.Spec{
+		Scaffold: blueprint.Scaffold{
+			Source:      "gcp-network",
+			Destination: filepath.Join(t.TempDir(), "destination"),
+		},
+	}
+
+	err := Scaffold(spec, false, false)
+	if err == nil {
+		t.Fatal("Expected error for unknown template, got nil")
+	}
+	for _, part := range []string{"gcp-network", "aws-vpc", "s3-bucket"} {
+		if !strings.Contains(err.Error(), part) {
+			t.Errorf("Expected error to mention %q, got: %v", part, err)
+		}
+	}
+}
+
+func TestScaffold_LocalDirectoryTakesPrecedenceOverTemplate(t *testing.T) {
+	workDir := t.TempDir()
+	originalDir, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(workDir); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = os.Chdir(originalDir) }()
+
+	// A local directory named like a template is used as-is
+	if err := os.MkdirAll("aws-vpc", 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join("aws-vpc", "main.tf"), []byte("# local module"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	spec := &blueprint.Spec{
+		Scaffold: blueprint.Scaffold{
+			Source:      "aws-vpc",
+			Destination: "destination",
+		},
+	}
+	if err := Scaffold(spec, false, false); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	content, err := os.ReadFile(filepath.Join("destination", "main.tf"))
+	if err != nil {
+		t.Fatalf("Failed to read scaffolded main.tf: %v", err)
+	}
+	if string(content) != "# local module" {
+		t.Errorf("Expected local directory to be scaffolded, got: %s", content)
+	}
+	if _, err := os.Stat(filepath.Join("destination", "outputs.tf")); !os.IsNotExist(err) {
+		t.Error("Expected embedded template files not to be used")
+	}
+}
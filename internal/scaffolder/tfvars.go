@@ -0,0 +1,104 @@
+package scaffolder
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// identifierRegex matches HCL identifiers, which can be used as unquoted object keys.
+var identifierRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_-]*$`)
+
+// RenderTfvars renders the variables in the format implied by filename:
+// JSON for .tfvars.json files and HCL for .tfvars files.
+func RenderTfvars(vars map[string]interface{}, filename string) ([]byte, error) {
+	if strings.HasSuffix(filename, ".json") {
+		jsonBytes, err := json.MarshalIndent(vars, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal variables to JSON: %w", err)
+		}
+		return jsonBytes, nil
+	}
+
+	var b strings.Builder
+	for _, key := range sortedKeys(vars) {
+		value, err := renderHCLValue(vars[key], "")
+		if err != nil {
+			return nil, fmt.Errorf("failed to render variable %s: %w", key, err)
+		}
+		fmt.Fprintf(&b, "%s = %s\n", key, value)
+	}
+	return []byte(b.String()), nil
+}
+
+// renderHCLValue renders a YAML-decoded value as an HCL expression.
+func renderHCLValue(value interface{}, indent string) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "null", nil
+	case string:
+		return quoteHCLString(v)
+	case bool, int, int64, uint64, float64:
+		return fmt.Sprintf("%v", v), nil
+	case []interface{}:
+		items := make([]string, 0, len(v))
+		for _, item := range v {
+			rendered, err := renderHCLValue(item, indent)
+			if err != nil {
+				return "", err
+			}
+			items = append(items, rendered)
+		}
+		return "[" + strings.Join(items, ", ") + "]", nil
+	case map[string]interface{}:
+		if len(v) == 0 {
+			return "{}", nil
+		}
+		inner := indent + "  "
+		var b strings.Builder
+		b.WriteString("{\n")
+		for _, key := range sortedKeys(v) {
+			rendered, err := renderHCLValue(v[key], inner)
+			if err != nil {
+				return "", err
+			}
+			if !identifierRegex.MatchString(key) {
+				if key, err = quoteHCLString(key); err != nil {
+					return "", err
+				}
+			}
+			fmt.Fprintf(&b, "%s%s = %s\n", inner, key, rendered)
+		}
+		b.WriteString(indent + "}")
+		return b.String(), nil
+	default:
+		return "", fmt.Errorf("unsupported value type %T", value)
+	}
+}
+
+// quoteHCLString quotes s as an HCL string literal, escaping template sequences.
+func quoteHCLString(s string) (string, error) {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(s); err != nil {
+		return "", err
+	}
+	quoted := strings.TrimSuffix(buf.String(), "\n")
+	quoted = strings.ReplaceAll(quoted, "${", "$${")
+	quoted = strings.ReplaceAll(quoted, "%{", "%%{")
+	return quoted, nil
+}
+
+// sortedKeys returns the keys of m in sorted order for reproducible output.
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
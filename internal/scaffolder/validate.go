@@ -0,0 +1,23 @@
+package scaffolder
+
+import (
+	"fmt"
+
+	"klonekit/pkg/blueprint"
+)
+
+// Validate runs the complete scaffolding (copying, template rendering, tfvars and generated
+// files) into a temporary directory that is removed afterwards, so nothing is written to the
+// destination. Unlike a dry run, which only prints the plan, it fails on anything a real
+// scaffold would fail on, such as a template that doesn't render.
+func Validate(spec *blueprint.Spec) error {
+	if spec == nil {
+		return fmt.Errorf("spec cannot be nil")
+	}
+
+	err := scaffoldToTemp(spec, "klonekit-validate-*", func(*blueprint.Spec) error { return nil })
+	if err != nil {
+		return fmt.Errorf("scaffold validation failed: %w", err)
+	}
+	return nil
+}
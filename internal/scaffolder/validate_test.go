@@ -0,0 +1,65 @@
+package scaffolder
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"klonekit/pkg/blueprint"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		wantErr  bool
+	}{
+		{name: "valid template", template: `region = "{{ .Cloud.Region }}"`, wantErr: false},
+		{name: "unparseable template", template: `region = "{{ .Cloud.Region "`, wantErr: true},
+		{name: "unknown field", template: `region = "{{ .Cloud.Zone }}"`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			validationTmp := t.TempDir()
+			t.Setenv("TMPDIR", validationTmp)
+			srcDir := filepath.Join(tmpDir, "source")
+			dstDir := filepath.Join(tmpDir, "destination")
+			if err := os.MkdirAll(srcDir, 0755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(filepath.Join(srcDir, "backend.tf.tftpl"), []byte(tt.template), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			spec := &blueprint.Spec{
+				Cloud:     blueprint.CloudProvider{Provider: "aws", Region: "us-east-1"},
+				Scaffold:  blueprint.Scaffold{Source: srcDir, Destination: dstDir},
+				Variables: map[string]interface{}{"region": "us-east-1"},
+			}
+
+			err := Validate(spec)
+			if tt.wantErr && err == nil {
+				t.Fatal("Expected validation to fail, got nil")
+			}
+			if !tt.wantErr && err != nil {
+				t.Fatalf("Expected validation to pass, got: %v", err)
+			}
+			if err != nil && !strings.Contains(err.Error(), "backend.tf.tftpl") {
+				t.Errorf("Expected the error to name the template, got: %v", err)
+			}
+
+			if _, statErr := os.Stat(dstDir); !os.IsNotExist(statErr) {
+				t.Error("Expected nothing to be written to the destination")
+			}
+			if entries, _ := os.ReadDir(validationTmp); len(entries) != 0 {
+				t.Errorf("Expected the validation directory to be removed, found: %v", entries)
+			}
+			if spec.Scaffold.Destination != dstDir {
+				t.Error("Expected the spec's destination to be left unchanged")
+			}
+		})
+	}
+}
@@ -0,0 +1,172 @@
+package scaffolder
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"regexp"
+	"sort"
+	"strings"
+
+	"klonekit/internal/ui"
+	"klonekit/pkg/blueprint"
+)
+
+// variableBlockRegex matches the opening line of a variable block in HCL.
+var variableBlockRegex = regexp.MustCompile(`(?m)^\s*variable\s+"([^"]+)"`)
+
+// checkVariables reports blueprint variables that the source module doesn't declare,
+// which Terraform would otherwise only warn about. It logs a warning, or fails when
+// scaffold.strictVariables is set.
+func checkVariables(spec *blueprint.Spec, sourceFS fs.FS) error {
+	undeclared, err := undeclaredVariables(spec.Variables, sourceFS)
+	if err != nil {
+		return fmt.Errorf("failed to read module variable declarations: %w", err)
+	}
+	if len(undeclared) == 0 {
+		return nil
+	}
+
+	if spec.Scaffold.StrictVariables {
+		return fmt.Errorf("blueprint variables not declared by module %s: %s", spec.Scaffold.Source, strings.Join(undeclared, ", "))
+	}
+	slog.Warn("Blueprint variables are not declared by the module and will be ignored by Terraform",
+		"source", spec.Scaffold.Source, "variables", undeclared)
+	return nil
+}
+
+// printWarning shows a warning on the console; tests replace it to capture the warnings.
+var printWarning = ui.NewConsole().PrintWarning
+
+// checkVariableValues fails on blueprint variables Terraform can't read from a tfvars file:
+// names that aren't identifiers, and values other than strings, numbers, bools, null and
+// lists and maps of them. It warns on the console about list and map values, which the
+// module must declare with a matching type. Secret variables are warned about without
+// their values.
+func checkVariableValues(spec *blueprint.Spec) error {
+	masked := blueprint.MaskSecretVariables(spec.Variables, spec.SecretVariables)
+
+	var invalidNames, unsupported []string
+	for _, name := range sortedKeys(spec.Variables) {
+		if !identifierRegex.MatchString(name) {
+			invalidNames = append(invalidNames, name)
+			continue
+		}
+		if typeName := unsupportedValueType(spec.Variables[name]); typeName != "" {
+			unsupported = append(unsupported, fmt.Sprintf("%s (%s)", name, typeName))
+			continue
+		}
+
+		var kind string
+		switch spec.Variables[name].(type) {
+		case []interface{}:
+			kind = "a list"
+		case map[string]interface{}:
+			kind = "a map"
+		default:
+			continue
+		}
+		preview, err := json.Marshal(masked[name])
+		if err != nil {
+			return fmt.Errorf("failed to render variable %s: %w", name, err)
+		}
+		printWarning(fmt.Sprintf("Variable '%s' is %s (%s); make sure the module declares it with a matching type, e.g. list(string) or object({...})", name, kind, preview))
+	}
+
+	if len(invalidNames) > 0 {
+		return fmt.Errorf("invalid variable names: %s; Terraform variable names start with a letter or underscore and contain only letters, digits, underscores and dashes", strings.Join(invalidNames, ", "))
+	}
+	if len(unsupported) > 0 {
+		return fmt.Errorf("variables with unsupported values: %s; quote a value to pass it as a string", strings.Join(unsupported, ", "))
+	}
+	return nil
+}
+
+// unsupportedValueType returns the type of the first value in value, a YAML-decoded variable
+// value, that can't be written to a tfvars file, or "" when all of them can.
+func unsupportedValueType(value interface{}) string {
+	switch v := value.(type) {
+	case nil, string, bool, int, int64, uint64, float64:
+		return ""
+	case []interface{}:
+		for _, item := range v {
+			if typeName := unsupportedValueType(item); typeName != "" {
+				return typeName
+			}
+		}
+		return ""
+	case map[string]interface{}:
+		for _, item := range v {
+			if typeName := unsupportedValueType(item); typeName != "" {
+				return typeName
+			}
+		}
+		return ""
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
+
+// undeclaredVariables returns the sorted names of vars with no matching variable block in fsys.
+func undeclaredVariables(vars map[string]interface{}, fsys fs.FS) ([]string, error) {
+	if len(vars) == 0 {
+		return nil, nil
+	}
+
+	declared, err := declaredVariables(fsys)
+	if err != nil {
+		return nil, err
+	}
+
+	var undeclared []string
+	for name := range vars {
+		if !declared[name] {
+			undeclared = append(undeclared, name)
+		}
+	}
+	sort.Strings(undeclared)
+	return undeclared, nil
+}
+
+// declaredVariables returns the names of the variables declared in the top-level
+// .tf and .tf.json files of fsys.
+func declaredVariables(fsys fs.FS) (map[string]bool, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	declared := make(map[string]bool)
+	for _, entry := range entries {
+		name := entry.Name()
+		isHCL := strings.HasSuffix(name, ".tf")
+		isJSON := strings.HasSuffix(name, ".tf.json")
+		if entry.IsDir() || (!isHCL && !isJSON) {
+			continue
+		}
+
+		content, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return nil, err
+		}
+
+		if isHCL {
+			for _, match := range variableBlockRegex.FindAllSubmatch(content, -1) {
+				declared[string(match[1])] = true
+			}
+			continue
+		}
+
+		var config struct {
+			Variable map[string]json.RawMessage `json:"variable"`
+		}
+		if err := json.Unmarshal(content, &config); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", name, err)
+		}
+		for variable := range config.Variable {
+			declared[variable] = true
+		}
+	}
+	return declared, nil
+}
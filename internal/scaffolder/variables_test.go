@@ -0,0 +1,204 @@
+package scaffolder
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"klonekit/pkg/blueprint"
+)
+
+func TestUndeclaredVariables(t *testing.T) {
+	module := fstest.MapFS{
+		"variables.tf": {Data: []byte(`variable "region" {
+  type = string
+}
+
+variable "instance_count" {}
+`)},
+		"extra.tf.json":       {Data: []byte(`{"variable": {"tags": {"type": "map(string)"}}}`)},
+		"nested/variables.tf": {Data: []byte(`variable "nested_only" {}`)},
+	}
+
+	vars := map[string]interface{}{
+		"region":         "us-east-1",
+		"instance_count": 2,
+		"tags":           map[string]interface{}{"Team": "platform"},
+		"regoin":         "us-west-2",
+		"nested_only":    true,
+	}
+
+	undeclared, err := undeclaredVariables(vars, module)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	want := []string{"nested_only", "regoin"}
+	if !reflect.DeepEqual(undeclared, want) {
+		t.Errorf("Expected undeclared variables %v, got %v", want, undeclared)
+	}
+}
+
+func TestScaffold_UndeclaredVariables(t *testing.T) {
+	tests := []struct {
+		name    string
+		strict  bool
+		wantErr bool
+	}{
+		{name: "warns by default", strict: false, wantErr: false},
+		{name: "fails in strict mode", strict: true, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			srcDir := filepath.Join(tmpDir, "source")
+			dstDir := filepath.Join(tmpDir, "destination")
+			if err := os.MkdirAll(srcDir, 0755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(filepath.Join(srcDir, "variables.tf"), []byte(`variable "region" {}`), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			spec := &blueprint.Spec{
+				Scaffold: blueprint.Scaffold{
+					Source:          srcDir,
+					Destination:     dstDir,
+					StrictVariables: tt.strict,
+				},
+				Variables: map[string]interface{}{
+					"region": "us-east-1",
+					"regoin": "us-west-2",
+				},
+			}
+
+			err := Scaffold(spec, false, false)
+			if !tt.wantErr {
+				if err != nil {
+					t.Fatalf("Expected undeclared variables to only warn, got: %v", err)
+				}
+				return
+			}
+
+			if err == nil {
+				t.Fatal("Expected error for undeclared variable in strict mode, got nil")
+			}
+			if !strings.Contains(err.Error(), "regoin") || strings.Contains(err.Error(), "region,") {
+				t.Errorf("Expected error to name only the undeclared variable, got: %v", err)
+			}
+			if _, statErr := os.Stat(dstDir); !os.IsNotExist(statErr) {
+				t.Error("Expected nothing to be scaffolded when strict variable validation fails")
+			}
+		})
+	}
+}
+
+// captureWarnings records the console warnings printed during the test
+func captureWarnings(t *testing.T) *[]string {
+	t.Helper()
+	var warnings []string
+	previous := printWarning
+	printWarning = func(message string) { warnings = append(warnings, message) }
+	t.Cleanup(func() { printWarning = previous })
+	return &warnings
+}
+
+func TestCheckVariableValues_Identifiers(t *testing.T) {
+	tests := []struct {
+		name    string
+		key     string
+		wantErr bool
+	}{
+		{name: "lowercase", key: "region"},
+		{name: "underscore and digits", key: "_subnet_2"},
+		{name: "dash", key: "instance-type"},
+		{name: "leading digit", key: "2fa_enabled", wantErr: true},
+		{name: "dot", key: "db.password", wantErr: true},
+		{name: "space", key: "instance type", wantErr: true},
+		{name: "empty", key: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			captureWarnings(t)
+			spec := &blueprint.Spec{Variables: map[string]interface{}{tt.key: "value"}}
+
+			err := checkVariableValues(spec)
+			if !tt.wantErr {
+				if err != nil {
+					t.Errorf("Expected no error, got: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), "invalid variable names: "+tt.key) {
+				t.Errorf("Expected an invalid name error for %q, got: %v", tt.key, err)
+			}
+		})
+	}
+}
+
+func TestCheckVariableValues_UnsupportedValue(t *testing.T) {
+	captureWarnings(t)
+	spec := &blueprint.Spec{Variables: map[string]interface{}{
+		"windows": []interface{}{map[string]interface{}{"start": time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}},
+	}}
+
+	err := checkVariableValues(spec)
+	if err == nil || !strings.Contains(err.Error(), "windows (time.Time)") {
+		t.Errorf("Expected an unsupported value error naming the type, got: %v", err)
+	}
+}
+
+func TestCheckVariableValues_WarnsOnComplexValues(t *testing.T) {
+	warnings := captureWarnings(t)
+	spec := &blueprint.Spec{
+		Variables: map[string]interface{}{
+			"region":      "us-east-1",
+			"zones":       []interface{}{"us-east-1a", "us-east-1b"},
+			"tags":        map[string]interface{}{"team": "platform"},
+			"db_settings": map[string]interface{}{"password": "hunter2"},
+		},
+		SecretVariables: []string{"db_settings"},
+	}
+
+	if err := checkVariableValues(spec); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	want := []string{
+		`Variable 'db_settings' is a map ("` + blueprint.RedactedValue + `"); make sure the module declares it with a matching type, e.g. list(string) or object({...})`,
+		`Variable 'tags' is a map ({"team":"platform"}); make sure the module declares it with a matching type, e.g. list(string) or object({...})`,
+		`Variable 'zones' is a list (["us-east-1a","us-east-1b"]); make sure the module declares it with a matching type, e.g. list(string) or object({...})`,
+	}
+	if !reflect.DeepEqual(*warnings, want) {
+		t.Errorf("Expected warnings:\n%s\ngot:\n%s", strings.Join(want, "\n"), strings.Join(*warnings, "\n"))
+	}
+}
+
+func TestScaffold_InvalidVariableNameWritesNothing(t *testing.T) {
+	captureWarnings(t)
+	tmpDir := t.TempDir()
+	srcDir := filepath.Join(tmpDir, "source")
+	dstDir := filepath.Join(tmpDir, "destination")
+	if err := os.MkdirAll(srcDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(srcDir, "main.tf"), []byte(`variable "region" {}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	spec := &blueprint.Spec{
+		Scaffold:  blueprint.Scaffold{Source: srcDir, Destination: dstDir},
+		Variables: map[string]interface{}{"region": "us-east-1", "db.password": "secret"},
+	}
+	if err := Scaffold(spec, false, false); err == nil || !strings.Contains(err.Error(), "invalid variable names: db.password") {
+		t.Fatalf("Expected an invalid variable name error, got: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dstDir, blueprint.DefaultTfvarsFilename)); !os.IsNotExist(err) {
+		t.Error("Expected no tfvars file to be written")
+	}
+}
@@ -0,0 +1,60 @@
+package scm
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	nethttp "net/http"
+
+	gitlab "github.com/xanzy/go-gitlab"
+
+	"klonekit/pkg/blueprint"
+)
+
+// ExportVariables creates or updates CI/CD variables of the spec's project, e.g. with the
+// Terraform outputs of an apply. Every variable is attempted; the failures are returned
+// together.
+func (g *GitLabProvider) ExportVariables(spec *blueprint.Spec, variables []blueprint.CIVariable) error {
+	repoPath := fmt.Sprintf("%s/%s", spec.SCM.Project.Namespace, spec.SCM.Project.Name)
+
+	var errs []error
+	for _, variable := range variables {
+		if err := g.setVariable(repoPath, variable); err != nil {
+			errs = append(errs, fmt.Errorf("CI variable %s: %w", variable.Key, err))
+		}
+	}
+
+	slog.Info("Exported CI variables", "path", repoPath, "exported", len(variables)-len(errs), "failed", len(errs))
+	if len(errs) > 0 {
+		return fmt.Errorf("failed to export %d of %d CI variables to %s: %w", len(errs), len(variables), repoPath, errors.Join(errs...))
+	}
+	return nil
+}
+
+// setVariable updates a CI/CD variable of the project, creating it when it doesn't exist yet.
+// The value is never logged.
+func (g *GitLabProvider) setVariable(repoPath string, variable blueprint.CIVariable) error {
+	opts := &gitlab.UpdateProjectVariableOptions{
+		Value:     gitlab.String(variable.Value),
+		Protected: gitlab.Bool(variable.Protected),
+		Masked:    gitlab.Bool(variable.Masked),
+	}
+	if variable.EnvironmentScope != "" {
+		opts.EnvironmentScope = gitlab.String(variable.EnvironmentScope)
+	}
+
+	var notFound bool
+	err := retryAPI("update GitLab CI variable", func() (*gitlab.Response, error) {
+		_, resp, err := g.client.ProjectVariables.UpdateVariable(repoPath, variable.Key, opts)
+		notFound = resp != nil && resp.StatusCode == nethttp.StatusNotFound
+		return resp, err
+	})
+	if notFound {
+		return g.createVariable(repoPath, variable)
+	}
+	if err != nil {
+		return err
+	}
+	slog.Info("Updated CI variable", "project", repoPath, "key", variable.Key)
+	return nil
+}
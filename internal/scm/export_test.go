@@ -0,0 +1,91 @@
+package scm
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"klonekit/pkg/blueprint"
+)
+
+// variablesServer fakes the GitLab project variable endpoints of test-user/test-repo,
+// holding the variables by key
+type variablesServer struct {
+	t         *testing.T
+	variables map[string]map[string]interface{}
+	created   []string
+	updated   []string
+}
+
+func (s *variablesServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	const prefix = "/api/v4/projects/test-user/test-repo/variables"
+
+	var body map[string]interface{}
+	if r.Method == http.MethodPost || r.Method == http.MethodPut {
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			s.t.Errorf("Failed to decode request to %s: %s", r.URL.Path, err)
+		}
+	}
+
+	switch {
+	case r.Method == http.MethodPut && strings.HasPrefix(r.URL.Path, prefix+"/"):
+		key := strings.TrimPrefix(r.URL.Path, prefix+"/")
+		if _, ok := s.variables[key]; !ok {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"message":"404 Variable Not Found"}`)
+			return
+		}
+		body["key"] = key
+		s.variables[key] = body
+		s.updated = append(s.updated, key)
+		fmt.Fprintf(w, `{"key":%q}`, key)
+	case r.Method == http.MethodPost && r.URL.Path == prefix:
+		key, _ := body["key"].(string)
+		s.variables[key] = body
+		s.created = append(s.created, key)
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprintf(w, `{"key":%q}`, key)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"message":"404 Not Found"}`)
+	}
+}
+
+func TestGitLabProvider_ExportVariables(t *testing.T) {
+	server := &variablesServer{t: t, variables: map[string]map[string]interface{}{
+		"API_URL": {"key": "API_URL", "value": "https://old.example.com"},
+	}}
+	httpServer := httptest.NewServer(server)
+	defer httpServer.Close()
+
+	client, err := newGitLabClient("test-token", httpServer.URL+"/api/v4")
+	if err != nil {
+		t.Fatalf("Failed to create test client: %s", err)
+	}
+	provider := &GitLabProvider{client: client, token: "test-token"}
+
+	spec := &blueprint.Spec{
+		SCM: blueprint.SCMProvider{Project: blueprint.ProjectConfig{Name: "test-repo", Namespace: "test-user"}},
+	}
+	err = provider.ExportVariables(spec, []blueprint.CIVariable{
+		{Key: "API_URL", Value: "https://api.example.com"},
+		{Key: "DB_PASSWORD", Value: "s3cr3t-value", Masked: true, Protected: true},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	if strings.Join(server.updated, ",") != "API_URL" || strings.Join(server.created, ",") != "DB_PASSWORD" {
+		t.Errorf("Expected API_URL to be updated and DB_PASSWORD created, got updated %v and created %v", server.updated, server.created)
+	}
+	if value := server.variables["API_URL"]["value"]; value != "https://api.example.com" {
+		t.Errorf("Expected API_URL to be updated to the new value, got %v", value)
+	}
+	if masked := server.variables["DB_PASSWORD"]["masked"]; masked != true {
+		t.Errorf("Expected DB_PASSWORD to be masked, got %v", masked)
+	}
+}
@@ -0,0 +1,220 @@
+package scm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	nethttp "net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing/transport/http"
+
+	"klonekit/internal/retry"
+	"klonekit/pkg/blueprint"
+)
+
+const (
+	// DefaultGitHubURL is the GitHub instance used when the blueprint doesn't name one
+	DefaultGitHubURL = "https://github.com"
+
+	// gitHubAPIURL is the REST API of github.com; GitHub Enterprise Server serves it under /api/v3
+	gitHubAPIURL = "https://api.github.com"
+)
+
+// GitHubProvider implements the ScmProvider interface for GitHub.
+type GitHubProvider struct {
+	client  *nethttp.Client
+	baseURL string // REST API base URL, without a trailing slash
+	token   string
+	runID   string // apply run recorded in the commit trailer, if any
+
+	lastPush *PushResult // commit pushed by the last CreateRepo, if any
+}
+
+// gitHubRepository is the part of a GitHub repository KloneKit uses.
+type gitHubRepository struct {
+	ID       int    `json:"id"`
+	CloneURL string `json:"clone_url"`
+	SSHURL   string `json:"ssh_url"`
+}
+
+// gitHubAPIError is an error response of the GitHub API.
+type gitHubAPIError struct {
+	StatusCode int
+	Message    string `json:"message"`
+}
+
+func (e *gitHubAPIError) Error() string {
+	return fmt.Sprintf("GitHub API returned %d: %s", e.StatusCode, e.Message)
+}
+
+// NewGitHubProviderWithURL creates a new GitHubProvider for the GitHub instance at url
+// (spec.scm.url), authenticated with the GITHUB_TOKEN environment variable. An empty url or
+// https://github.com uses github.com; any other url is a GitHub Enterprise Server.
+func NewGitHubProviderWithURL(url string) (*GitHubProvider, error) {
+	token := os.Getenv("GITHUB_TOKEN")
+	if token == "" {
+		return nil, fmt.Errorf("GITHUB_TOKEN environment variable is required")
+	}
+
+	return &GitHubProvider{
+		client:  &nethttp.Client{Timeout: 30 * time.Second},
+		baseURL: gitHubAPIBaseURL(url),
+		token:   token,
+	}, nil
+}
+
+// gitHubAPIBaseURL returns the REST API base URL of the GitHub instance at url.
+func gitHubAPIBaseURL(url string) string {
+	url = strings.TrimRight(strings.TrimSpace(url), "/")
+	switch {
+	case url == "" || url == DefaultGitHubURL || url == gitHubAPIURL:
+		return gitHubAPIURL
+	case strings.HasSuffix(url, "/api/v3"):
+		return url
+	default:
+		return url + "/api/v3"
+	}
+}
+
+// CreateRepo creates a GitHub repository under the user or organization named by
+// spec.scm.project.namespace and pushes the scaffolded files to it. An existing repository is
+// updated in place. GitLab-only project settings are not applied.
+func (g *GitHubProvider) CreateRepo(spec *blueprint.Spec) error {
+	g.lastPush = nil
+	project := spec.SCM.Project
+	slog.Info("Creating GitHub repository", "name", project.Name, "owner", project.Namespace)
+	warnUnsupportedSettings(project)
+
+	// Check if repository already exists
+	repoPath := fmt.Sprintf("%s/%s", project.Namespace, project.Name)
+	var existing gitHubRepository
+	err := g.do(nethttp.MethodGet, "/repos/"+repoPath, nil, &existing)
+	if err == nil {
+		slog.Info("Repository already exists, pushing the scaffolded files to it", "path", repoPath, "id", existing.ID)
+		return g.push(spec, existing)
+	}
+	var apiErr *gitHubAPIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != nethttp.StatusNotFound {
+		return fmt.Errorf("failed to look up GitHub repository %s: %w", repoPath, err)
+	}
+
+	createPath, err := g.createRepoPath(project.Namespace)
+	if err != nil {
+		return err
+	}
+	body := map[string]interface{}{
+		"name":        project.Name,
+		"description": project.Description,
+		"private":     project.Visibility != "public",
+		"auto_init":   false,
+	}
+	if project.Visibility == "internal" {
+		body["visibility"] = "internal"
+	}
+
+	var repo gitHubRepository
+	if err := g.do(nethttp.MethodPost, createPath, body, &repo); err != nil {
+		return fmt.Errorf("failed to create GitHub repository: %w", err)
+	}
+	slog.Info("GitHub repository created successfully", "id", repo.ID, "url", repo.CloneURL)
+	return g.push(spec, repo)
+}
+
+// push pushes the scaffolded files to repo.
+func (g *GitHubProvider) push(spec *blueprint.Spec, repo gitHubRepository) error {
+	// GitHub takes an installation or personal access token as the password of x-access-token
+	pushed, err := pushScaffold(spec, pushURL(spec, repo.CloneURL, repo.SSHURL), &http.BasicAuth{Username: "x-access-token", Password: g.token}, g.runID)
+	if err != nil {
+		return fmt.Errorf("failed to initialize and push repository: %w", err)
+	}
+	g.lastPush = pushed
+	slog.Info("Successfully pushed repository to GitHub", "url", repo.CloneURL, "commit", pushed.CommitHash)
+	return nil
+}
+
+// createRepoPath returns the API path creating a repository under owner: the authenticated
+// user's own repositories, or an organization's.
+func (g *GitHubProvider) createRepoPath(owner string) (string, error) {
+	var user struct {
+		Login string `json:"login"`
+	}
+	if err := g.do(nethttp.MethodGet, "/user", nil, &user); err != nil {
+		return "", fmt.Errorf("failed to look up the authenticated GitHub user: %w", err)
+	}
+	if strings.EqualFold(user.Login, owner) {
+		return "/user/repos", nil
+	}
+	return "/orgs/" + owner + "/repos", nil
+}
+
+// do sends a request to the GitHub API under the shared retry policy and decodes the JSON
+// response into out. Client errors other than rate limiting are returned immediately, since
+// repeating the request cannot fix them.
+func (g *GitHubProvider) do(method, path string, body, out interface{}) error {
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = json.Marshal(body); err != nil {
+			return fmt.Errorf("failed to encode request: %w", err)
+		}
+	}
+
+	operation := fmt.Sprintf("GitHub %s %s", method, path)
+	return retry.Current().Do(context.Background(), operation, func() error {
+		req, err := nethttp.NewRequest(method, g.baseURL+path, bytes.NewReader(payload))
+		if err != nil {
+			return retry.Permanent(err)
+		}
+		req.Header.Set("Accept", "application/vnd.github+json")
+		req.Header.Set("Authorization", "Bearer "+g.token)
+		if body != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+
+		resp, err := g.client.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode >= nethttp.StatusBadRequest {
+			apiErr := &gitHubAPIError{StatusCode: resp.StatusCode}
+			_ = json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(apiErr)
+			if resp.StatusCode < nethttp.StatusInternalServerError && resp.StatusCode != nethttp.StatusTooManyRequests {
+				return retry.Permanent(apiErr)
+			}
+			return apiErr
+		}
+		if out == nil {
+			return nil
+		}
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return retry.Permanent(fmt.Errorf("failed to decode response: %w", err))
+		}
+		return nil
+	})
+}
+
+// warnUnsupportedSettings logs the configured project settings only GitLab supports.
+func warnUnsupportedSettings(project blueprint.ProjectConfig) {
+	unsupported := []struct {
+		setting    string
+		configured bool
+	}{
+		{"ciVariables", len(project.CIVariables) > 0},
+		{"webhooks", len(project.Webhooks) > 0},
+		{"branchProtection", len(project.BranchProtection.Branches) > 0},
+	}
+	for _, u := range unsupported {
+		if u.configured {
+			slog.Warn("Project setting is not supported on GitHub, skipped", "setting", "spec.scm.project."+u.setting)
+		}
+	}
+}
@@ -0,0 +1,190 @@
+package scm
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	git "github.com/go-git/go-git/v5"
+
+	"klonekit/pkg/blueprint"
+)
+
+func TestNewGitHubProviderWithURL(t *testing.T) {
+	t.Setenv("GITHUB_TOKEN", "")
+	if _, err := NewGitHubProviderWithURL(""); err == nil || !strings.Contains(err.Error(), "GITHUB_TOKEN environment variable is required") {
+		t.Errorf("Expected a missing token error, got: %v", err)
+	}
+
+	t.Setenv("GITHUB_TOKEN", "test-token")
+	provider, err := NewGitHubProviderWithURL("https://github.com/")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if provider.token != "test-token" || provider.baseURL != "https://api.github.com" {
+		t.Errorf("Expected a github.com provider with the token, got %+v", provider)
+	}
+}
+
+func TestGitHubAPIBaseURL(t *testing.T) {
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{url: "", want: "https://api.github.com"},
+		{url: "https://github.com", want: "https://api.github.com"},
+		{url: "https://github.com/", want: "https://api.github.com"},
+		{url: "https://github.example.com", want: "https://github.example.com/api/v3"},
+		{url: "https://github.example.com/api/v3/", want: "https://github.example.com/api/v3"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			if got := gitHubAPIBaseURL(tt.url); got != tt.want {
+				t.Errorf("gitHubAPIBaseURL(%q) = %q, want %q", tt.url, got, tt.want)
+			}
+		})
+	}
+}
+
+// gitHubServer fakes the GitHub API for the repository octo-org/test-repo, authenticated as
+// octocat. The repository exists when exists is set, and is created with remoteDir as its
+// clone URL otherwise.
+type gitHubServer struct {
+	t         *testing.T
+	exists    bool
+	remoteDir string
+	requests  []string
+	created   map[string]interface{}
+}
+
+func (s *gitHubServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.requests = append(s.requests, r.Method+" "+r.URL.Path)
+	w.Header().Set("Content-Type", "application/json")
+	if r.Header.Get("Authorization") != "Bearer test-token" {
+		w.WriteHeader(http.StatusUnauthorized)
+		fmt.Fprint(w, `{"message":"Bad credentials"}`)
+		return
+	}
+
+	switch r.Method + " " + r.URL.Path {
+	case "GET /repos/octo-org/test-repo":
+		if !s.exists {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"message":"Not Found"}`)
+			return
+		}
+		fmt.Fprintf(w, `{"id":1,"clone_url":%q}`, s.remoteDir)
+	case "GET /user":
+		fmt.Fprint(w, `{"login":"octocat"}`)
+	case "POST /orgs/octo-org/repos":
+		if err := json.NewDecoder(r.Body).Decode(&s.created); err != nil {
+			s.t.Errorf("Failed to decode create request: %s", err)
+		}
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprintf(w, `{"id":42,"clone_url":%q}`, s.remoteDir)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"message":"Not Found"}`)
+	}
+}
+
+// newGitHubTestProvider returns a provider for server and a spec with a scaffold directory
+func newGitHubTestProvider(t *testing.T, server *gitHubServer) (*GitHubProvider, *blueprint.Spec) {
+	t.Helper()
+
+	server.remoteDir = t.TempDir()
+	if _, err := git.PlainInit(server.remoteDir, true); err != nil {
+		t.Fatalf("Failed to create bare remote repository: %s", err)
+	}
+	scaffoldDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(scaffoldDir, "main.tf"), []byte("# Test Terraform file"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	httpServer := httptest.NewServer(server)
+	t.Cleanup(httpServer.Close)
+
+	spec := &blueprint.Spec{
+		SCM: blueprint.SCMProvider{
+			Provider: "github",
+			Project:  blueprint.ProjectConfig{Name: "test-repo", Namespace: "octo-org", Description: "Test repository", Visibility: "internal"},
+		},
+		Scaffold: blueprint.Scaffold{Destination: scaffoldDir},
+	}
+	return &GitHubProvider{client: httpServer.Client(), baseURL: httpServer.URL, token: "test-token"}, spec
+}
+
+func TestGitHubProvider_CreateRepo(t *testing.T) {
+	server := &gitHubServer{t: t}
+	provider, spec := newGitHubTestProvider(t, server)
+
+	if err := provider.CreateRepo(spec); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	if server.created["name"] != "test-repo" || server.created["description"] != "Test repository" ||
+		server.created["private"] != true || server.created["visibility"] != "internal" {
+		t.Errorf("Unexpected create request: %v", server.created)
+	}
+	if !remoteHasMain(t, server.remoteDir) {
+		t.Error("Expected the scaffolded files to be pushed")
+	}
+	if pushed := provider.LastPush(); pushed == nil || pushed.RemoteURL != server.remoteDir || len(pushed.CommitHash) != 40 {
+		t.Errorf("Expected the pushed commit to be reported, got %+v", pushed)
+	}
+}
+
+func TestGitHubProvider_CreateRepo_RepositoryExists(t *testing.T) {
+	server := &gitHubServer{t: t, exists: true}
+	provider, spec := newGitHubTestProvider(t, server)
+
+	if err := provider.CreateRepo(spec); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	if strings.Join(server.requests, ",") != "GET /repos/octo-org/test-repo" {
+		t.Errorf("Expected only the repository lookup, got %v", server.requests)
+	}
+	if !remoteHasMain(t, server.remoteDir) {
+		t.Error("Expected the scaffolded files to be pushed to the existing repository")
+	}
+	if pushed := provider.LastPush(); pushed == nil || pushed.RemoteURL != server.remoteDir {
+		t.Errorf("Expected the pushed commit to be reported, got %+v", pushed)
+	}
+}
+
+func TestGitHubProvider_CreateRepo_UserRepository(t *testing.T) {
+	var createPath string
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/user":
+			fmt.Fprint(w, `{"login":"OctoCat"}`)
+		case r.Method == http.MethodPost:
+			createPath = r.URL.Path
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			fmt.Fprint(w, `{"message":"Repository creation failed."}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"message":"Not Found"}`)
+		}
+	}))
+	defer httpServer.Close()
+
+	provider := &GitHubProvider{client: httpServer.Client(), baseURL: httpServer.URL, token: "test-token"}
+	spec := &blueprint.Spec{SCM: blueprint.SCMProvider{Project: blueprint.ProjectConfig{Name: "test-repo", Namespace: "octocat"}}}
+
+	err := provider.CreateRepo(spec)
+	if err == nil || !strings.Contains(err.Error(), "GitHub API returned 422: Repository creation failed.") {
+		t.Errorf("Expected the API error to be returned, got: %v", err)
+	}
+	if createPath != "/user/repos" {
+		t.Errorf("Expected a repository of the authenticated user to be created under /user/repos, got %q", createPath)
+	}
+}
@@ -1,36 +1,63 @@
 package scm
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	nethttp "net/http"
 	"os"
+	"strings"
 
 	git "github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/go-git/go-git/v5/plumbing/object"
 	"github.com/go-git/go-git/v5/plumbing/transport/http"
 	gitlab "github.com/xanzy/go-gitlab"
 
+	"klonekit/internal/retry"
 	"klonekit/pkg/blueprint"
 )
 
+const (
+	// DefaultGitLabURL is the GitLab instance used when the blueprint doesn't name one
+	DefaultGitLabURL = "https://gitlab.com"
+
+	// gitLabAPIPath is the path of the REST API on a GitLab instance
+	gitLabAPIPath = "/api/v4"
+)
 
 // GitLabProvider implements the ScmProvider interface for GitLab.
 type GitLabProvider struct {
 	client *gitlab.Client
 	token  string
+
+	// createdProject is the project an interrupted run created, whose push is resumed
+	createdProject *CreatedProject
+	// onProjectCreated is called with a new project before the push
+	onProjectCreated func(CreatedProject)
+	// runID is the apply run recorded in the commit trailer, if any
+	runID string
+	// lastPush is the commit pushed by the last CreateRepo, if any
+	lastPush *PushResult
 }
 
-// NewGitLabProvider creates a new GitLabProvider with authentication.
+// NewGitLabProvider creates a new GitLabProvider for gitlab.com with authentication.
 func NewGitLabProvider() (*GitLabProvider, error) {
+	return NewGitLabProviderWithURL(DefaultGitLabURL)
+}
+
+// NewGitLabProviderWithURL creates a new GitLabProvider for the GitLab instance at url
+// (spec.scm.url), e.g. a self-managed "https://gitlab.example.com". An empty url uses
+// gitlab.com.
+func NewGitLabProviderWithURL(url string) (*GitLabProvider, error) {
 	token := os.Getenv("GITLAB_PRIVATE_TOKEN")
 	if token == "" {
 		return nil, fmt.Errorf("GITLAB_PRIVATE_TOKEN environment variable is required")
 	}
 
-	// For now, use gitlab.com as the default URL
-	// In production, this should be configurable from the blueprint
-	client, err := gitlab.NewClient(token, gitlab.WithBaseURL("https://gitlab.com/api/v4"))
+	client, err := newGitLabClient(token, apiBaseURL(url))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create GitLab client: %w", err)
 	}
@@ -41,43 +68,80 @@ func NewGitLabProvider() (*GitLabProvider, error) {
 	}, nil
 }
 
-// CreateRepo creates a GitLab repository and pushes the scaffolded files to it.
+// apiBaseURL returns the REST API base URL of the GitLab instance at url, with or without a
+// trailing slash or the /api/v4 suffix.
+func apiBaseURL(url string) string {
+	url = strings.TrimRight(strings.TrimSpace(url), "/")
+	if url == "" {
+		url = DefaultGitLabURL
+	}
+	if strings.HasSuffix(url, gitLabAPIPath) {
+		return url
+	}
+	return url + gitLabAPIPath
+}
+
+// newGitLabClient creates a GitLab API client. The client's built-in retries are disabled
+// so API calls follow the shared retry policy (--max-retries) instead, and each request is
+// bounded by GitLabTimeout so a hung API call can't block the SCM stage.
+func newGitLabClient(token, baseURL string) (*gitlab.Client, error) {
+	return gitlab.NewClient(token,
+		gitlab.WithBaseURL(baseURL),
+		gitlab.WithoutRetries(),
+		gitlab.WithHTTPClient(&nethttp.Client{Timeout: GitLabTimeout()}),
+	)
+}
+
+// retryAPI runs a GitLab API call under the shared retry policy. Client errors other than
+// rate limiting are returned immediately, since repeating the request cannot fix them. A call
+// whose last attempt timed out fails with a network error.
+func retryAPI(operation string, call func() (*gitlab.Response, error)) error {
+	err := retry.Current().Do(context.Background(), operation, func() error {
+		resp, err := call()
+		if err != nil && resp != nil && resp.StatusCode < nethttp.StatusInternalServerError && resp.StatusCode != nethttp.StatusTooManyRequests {
+			return retry.Permanent(err)
+		}
+		return err
+	})
+	return apiTimeoutError(operation, err)
+}
+
+// CreateRepo creates a GitLab repository and pushes the scaffolded files to it. An existing
+// repository is updated in place: the scaffolded files are pushed to it without changing its
+// settings.
 func (g *GitLabProvider) CreateRepo(spec *blueprint.Spec) error {
+	g.lastPush = nil
 	slog.Info("Creating GitLab repository", "name", spec.SCM.Project.Name, "namespace", spec.SCM.Project.Namespace)
 
 	// Check if repository already exists
 	repoPath := fmt.Sprintf("%s/%s", spec.SCM.Project.Namespace, spec.SCM.Project.Name)
-	existingProject, _, err := g.client.Projects.GetProject(repoPath, nil)
+	var existingProject *gitlab.Project
+	err := retryAPI("get GitLab project", func() (resp *gitlab.Response, err error) {
+		existingProject, resp, err = g.client.Projects.GetProject(repoPath, nil)
+		return resp, err
+	})
+	if isAPITimeout(err) {
+		// Whether the project exists is unknown, so creating it could fail or duplicate work
+		return fmt.Errorf("failed to look up GitLab project: %w", err)
+	}
 	if err == nil && existingProject != nil {
-		slog.Warn("Repository already exists, skipping creation", "path", repoPath)
-		return nil
+		// The project's settings were configured when it was created
+		if g.createdByInterruptedRun(existingProject) {
+			slog.Info("Resuming push to the repository created by the interrupted run", "path", repoPath, "id", existingProject.ID)
+		} else {
+			slog.Info("Repository already exists, pushing the scaffolded files to it", "path", repoPath, "id", existingProject.ID)
+		}
+		return g.pushAndProtect(spec, existingProject)
 	}
 
-	// Set default visibility to private if not specified
-	visibility := spec.SCM.Project.Visibility
-	if visibility == "" {
-		visibility = "private"
-	}
-
-	// Convert visibility string to GitLab visibility level
-	var visibilityLevel gitlab.VisibilityValue
-	switch visibility {
-	case "private":
-		visibilityLevel = gitlab.PrivateVisibility
-	case "public":
-		visibilityLevel = gitlab.PublicVisibility
-	case "internal":
-		visibilityLevel = gitlab.InternalVisibility
-	default:
-		visibilityLevel = gitlab.PrivateVisibility
-	}
+	visibility := visibilityLevel(spec.SCM.Project.Visibility)
 
 	// Create the project
 	createOpts := &gitlab.CreateProjectOptions{
 		Name:                     &spec.SCM.Project.Name,
 		Path:                     &spec.SCM.Project.Name,
 		Description:              &spec.SCM.Project.Description,
-		Visibility:               &visibilityLevel,
+		Visibility:               &visibility,
 		InitializeWithReadme:     gitlab.Bool(false),
 		IssuesEnabled:            gitlab.Bool(true),
 		MergeRequestsEnabled:     gitlab.Bool(true),
@@ -87,86 +151,280 @@ func (g *GitLabProvider) CreateRepo(spec *blueprint.Spec) error {
 		SharedRunnersEnabled:     gitlab.Bool(true),
 		ContainerRegistryEnabled: gitlab.Bool(true),
 		PackagesEnabled:          gitlab.Bool(true),
+		LFSEnabled:               gitlab.Bool(spec.SCM.Project.LFS()),
+		RequestAccessEnabled:     gitlab.Bool(spec.SCM.Project.RequestAccessEnabled),
+	}
+	if spec.SCM.Project.DefaultBranch != "" {
+		createOpts.DefaultBranch = &spec.SCM.Project.DefaultBranch
 	}
 
-	project, _, err := g.client.Projects.CreateProject(createOpts)
+	var project *gitlab.Project
+	err = retryAPI("create GitLab project", func() (resp *gitlab.Response, err error) {
+		project, resp, err = g.client.Projects.CreateProject(createOpts)
+		return resp, err
+	})
 	if err != nil {
 		return fmt.Errorf("failed to create GitLab project: %w", err)
 	}
 
 	slog.Info("GitLab repository created successfully", "id", project.ID, "url", project.HTTPURLToRepo)
+	if g.onProjectCreated != nil {
+		g.onProjectCreated(CreatedProject{ID: project.ID, Path: repoPath, URL: project.HTTPURLToRepo})
+	}
 
+	// Create CI variables and webhooks before the push, so the pipeline and hooks it triggers
+	// see them. A failure is reported after the push, which shouldn't be held back by it.
+	settingsErr := g.configureProject(project.ID, spec.SCM.Project)
+
+	return errors.Join(settingsErr, g.pushAndProtect(spec, project))
+}
+
+// pushAndProtect pushes the scaffolded files to project and then protects its branches.
+func (g *GitLabProvider) pushAndProtect(spec *blueprint.Spec, project *gitlab.Project) error {
 	// Initialize git repository and push files
-	if err := g.initializeAndPushRepo(spec, project.HTTPURLToRepo); err != nil {
+	if err := g.initializeAndPushRepo(spec, pushURL(spec, project.HTTPURLToRepo, project.SSHURLToRepo)); err != nil {
 		return fmt.Errorf("failed to initialize and push repository: %w", err)
 	}
 
-	return nil
+	// Protect branches only after the push, so protection can't reject it
+	return g.protectBranches(project.ID, spec.SCM.Project.BranchProtection)
+}
+
+// ValidateVisibility checks that visibility is a GitLab project visibility level.
+func ValidateVisibility(visibility string) error {
+	switch visibility {
+	case "private", "public", "internal":
+		return nil
+	default:
+		return fmt.Errorf("invalid visibility '%s': must be private, public or internal", visibility)
+	}
+}
+
+// visibilityLevel converts a visibility string to a GitLab visibility level, defaulting to private.
+func visibilityLevel(visibility string) gitlab.VisibilityValue {
+	switch visibility {
+	case "public":
+		return gitlab.PublicVisibility
+	case "internal":
+		return gitlab.InternalVisibility
+	default:
+		return gitlab.PrivateVisibility
+	}
 }
 
 // initializeAndPushRepo initializes a git repository in the scaffolded directory and pushes to GitLab.
 func (g *GitLabProvider) initializeAndPushRepo(spec *blueprint.Spec, repoURL string) error {
+	// GitLab uses oauth2 as username for token auth
+	pushed, err := pushScaffold(spec, repoURL, &http.BasicAuth{Username: "oauth2", Password: g.token}, g.runID)
+	if err != nil {
+		return err
+	}
+	g.lastPush = pushed
+	slog.Info("Successfully pushed repository to GitLab", "url", repoURL, "commit", pushed.CommitHash)
+	return nil
+}
+
+// pushScaffold commits the scaffolded directory to a new or existing git repository in it and
+// pushes it to repoURL, with auth for an HTTPS remote. A non-empty runID is recorded in the
+// commit trailer. It returns the pushed commit, confirmed against the remote branch.
+func pushScaffold(spec *blueprint.Spec, repoURL string, auth *http.BasicAuth, runID string) (*PushResult, error) {
 	scaffoldDir := spec.Scaffold.Destination
 
 	// Check if the scaffold directory exists
 	if _, err := os.Stat(scaffoldDir); os.IsNotExist(err) {
-		return fmt.Errorf("scaffold directory does not exist: %s", scaffoldDir)
+		return nil, fmt.Errorf("scaffold directory does not exist: %s", scaffoldDir)
 	}
 
-	slog.Info("Initializing git repository", "directory", scaffoldDir)
-
-	// Initialize git repository
-	repo, err := git.PlainInit(scaffoldDir, false)
+	// Reuse an existing repository so prior history is preserved
+	branch := spec.SCM.Project.Branch()
+	repo, isExisting, err := openOrInitRepo(scaffoldDir, branch)
 	if err != nil {
-		return fmt.Errorf("failed to initialize git repository: %w", err)
+		return nil, err
 	}
 
 	// Get the working tree
 	worktree, err := repo.Worktree()
 	if err != nil {
-		return fmt.Errorf("failed to get worktree: %w", err)
+		return nil, fmt.Errorf("failed to get worktree: %w", err)
+	}
+
+	// Commit on the branch that is pushed, whichever branch an existing repository is on
+	if isExisting {
+		if err := checkoutBranch(repo, branch); err != nil {
+			return nil, err
+		}
+	}
+
+	// Stage the scaffolded files
+	if err := stageFiles(worktree, scaffoldDir, spec.SCM.Staging); err != nil {
+		return nil, err
 	}
 
-	// Add all files
-	_, err = worktree.Add(".")
+	// Publish placeholders instead of the real values of secret variables
+	if err := redactStagedTfvars(repo, scaffoldDir, spec); err != nil {
+		return nil, err
+	}
+
+	commitMessage := "Initial commit - scaffolded from KloneKit"
+	if isExisting {
+		commitMessage = "Update scaffolded files from KloneKit"
+	}
+	if spec.SCM.Commit.Message != "" {
+		commitMessage = spec.SCM.Commit.Message
+	}
+	if runID != "" && !spec.SCM.SkipRunIDTrailer {
+		commitMessage = withRunIDTrailer(commitMessage, runID)
+	}
+
+	// Sign the commit when a signing key is configured
+	signKey, err := loadSigningKey(spec.SCM.Signing)
 	if err != nil {
-		return fmt.Errorf("failed to add files to git: %w", err)
+		return nil, err
 	}
 
-	// Create initial commit
-	commit, err := worktree.Commit("Initial commit - scaffolded from KloneKit", &git.CommitOptions{
-		Author: &object.Signature{
-			Name:  "KloneKit",
-			Email: "noreply@klonekit.dev",
-		},
+	// Create commit on top of any existing history
+	commit, err := worktree.Commit(commitMessage, &git.CommitOptions{
+		Author:  commitAuthor(spec.SCM.Commit),
+		SignKey: signKey,
 	})
 	if err != nil {
-		return fmt.Errorf("failed to create initial commit: %w", err)
+		if !isExisting || !errors.Is(err, git.ErrEmptyCommit) {
+			return nil, fmt.Errorf("failed to create initial commit: %w", err)
+		}
+		// Nothing changed since the last commit - push the existing history as-is
+		slog.Info("No changes to commit in existing repository", "directory", scaffoldDir)
+	} else {
+		slog.Info("Created commit", "hash", commit, "existingRepo", isExisting)
 	}
 
-	slog.Info("Created initial commit", "hash", commit)
-
 	// Add remote origin
-	_, err = repo.CreateRemote(&config.RemoteConfig{
-		Name: "origin",
-		URLs: []string{repoURL},
-	})
-	if err != nil {
-		return fmt.Errorf("failed to add remote origin: %w", err)
+	if err := ensureOriginRemote(repo, repoURL); err != nil {
+		return nil, err
 	}
 
-	// Push to remote
+	// Push to remote, with the token over HTTPS or a key over SSH
+	remoteAuth, err := pushAuth(spec, repoURL, auth)
+	if err != nil {
+		return nil, err
+	}
 	err = repo.Push(&git.PushOptions{
 		RemoteName: "origin",
-		Auth: &http.BasicAuth{
-			Username: "oauth2", // GitLab uses oauth2 as username for token auth
-			Password: g.token,
-		},
+		RefSpecs:   []config.RefSpec{branchRefSpec(branch)},
+		Auth:       remoteAuth,
 	})
 	if err != nil {
-		return fmt.Errorf("failed to push to remote repository: %w", err)
+		return nil, fmt.Errorf("failed to push to remote repository: %w", err)
+	}
+	return confirmPush(repo, repoURL, branch, remoteAuth)
+}
+
+// Default author of the commits pushed by KloneKit
+const (
+	defaultCommitAuthor = "KloneKit"
+	defaultCommitEmail  = "noreply@klonekit.dev"
+)
+
+// commitAuthor returns the author configured in spec.scm.commit, falling back to the
+// KloneKit defaults for unset fields.
+func commitAuthor(commit blueprint.CommitConfig) *object.Signature {
+	author := &object.Signature{Name: defaultCommitAuthor, Email: defaultCommitEmail}
+	if commit.Author != "" {
+		author.Name = commit.Author
+	}
+	if commit.Email != "" {
+		author.Email = commit.Email
+	}
+	return author
+}
+
+// branchRefSpec returns the refspec pushing the local branch to the branch of the same name.
+func branchRefSpec(branch string) config.RefSpec {
+	ref := plumbing.NewBranchReferenceName(branch)
+	return config.RefSpec(fmt.Sprintf("%s:%s", ref, ref))
+}
+
+// checkoutBranch points HEAD of an existing repository at branch, creating the branch at the
+// current commit when it doesn't exist. Like "git checkout -b", the index and working tree
+// are left as they are, so the scaffolded files are committed on the branch.
+func checkoutBranch(repo *git.Repository, branch string) error {
+	ref := plumbing.NewBranchReferenceName(branch)
+
+	head, err := repo.Storer.Reference(plumbing.HEAD)
+	if err != nil {
+		return fmt.Errorf("failed to read HEAD: %w", err)
+	}
+	if head.Type() == plumbing.SymbolicReference && head.Target() == ref {
+		return nil
+	}
+
+	// An unborn HEAD has no commit to start the branch from; the first commit creates it
+	if current, err := repo.Head(); err == nil {
+		if _, err := repo.Reference(ref, false); errors.Is(err, plumbing.ErrReferenceNotFound) {
+			if err := repo.Storer.SetReference(plumbing.NewHashReference(ref, current.Hash())); err != nil {
+				return fmt.Errorf("failed to create branch '%s': %w", branch, err)
+			}
+		} else if err != nil {
+			return fmt.Errorf("failed to look up branch '%s': %w", branch, err)
+		}
+	} else if !errors.Is(err, plumbing.ErrReferenceNotFound) {
+		return fmt.Errorf("failed to read HEAD: %w", err)
+	}
+
+	if err := repo.Storer.SetReference(plumbing.NewSymbolicReference(plumbing.HEAD, ref)); err != nil {
+		return fmt.Errorf("failed to switch to branch '%s': %w", branch, err)
 	}
+	slog.Info("Switched to branch", "branch", branch)
+	return nil
+}
 
-	slog.Info("Successfully pushed repository to GitLab", "url", repoURL)
+// openOrInitRepo opens the git repository in dir if one already exists, otherwise it initializes a new one
+// whose initial branch is defaultBranch (go-git's "master" when empty).
+// The returned bool reports whether an existing repository was opened.
+func openOrInitRepo(dir, defaultBranch string) (*git.Repository, bool, error) {
+	repo, err := git.PlainOpen(dir)
+	if err == nil {
+		slog.Info("Using existing git repository", "directory", dir)
+		return repo, true, nil
+	}
+	if !errors.Is(err, git.ErrRepositoryNotExists) {
+		return nil, false, fmt.Errorf("failed to open existing git repository: %w", err)
+	}
+
+	slog.Info("Initializing git repository", "directory", dir)
+
+	initOpts := &git.PlainInitOptions{}
+	if defaultBranch != "" {
+		initOpts.InitOptions.DefaultBranch = plumbing.NewBranchReferenceName(defaultBranch)
+	}
+	repo, err = git.PlainInitWithOptions(dir, initOpts)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to initialize git repository: %w", err)
+	}
+	return repo, false, nil
+}
+
+// ensureOriginRemote makes sure the "origin" remote points at repoURL, replacing it if it points elsewhere.
+func ensureOriginRemote(repo *git.Repository, repoURL string) error {
+	remote, err := repo.Remote("origin")
+	if err == nil {
+		urls := remote.Config().URLs
+		if len(urls) > 0 && urls[0] == repoURL {
+			return nil
+		}
+		slog.Info("Replacing existing origin remote", "old", urls, "new", repoURL)
+		if err := repo.DeleteRemote("origin"); err != nil {
+			return fmt.Errorf("failed to remove existing remote origin: %w", err)
+		}
+	} else if !errors.Is(err, git.ErrRemoteNotFound) {
+		return fmt.Errorf("failed to look up remote origin: %w", err)
+	}
+
+	_, err = repo.CreateRemote(&config.RemoteConfig{
+		Name: "origin",
+		URLs: []string{repoURL},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to add remote origin: %w", err)
+	}
 	return nil
 }
@@ -1,20 +1,34 @@
 package scm
 
 import (
+	"encoding/json"
 	"fmt"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
+	"time"
 
 	git "github.com/go-git/go-git/v5"
-	gitlab "github.com/xanzy/go-gitlab"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
 
+	"klonekit/internal/provisioner"
+	"klonekit/internal/retry"
+	"klonekit/internal/scaffolder"
 	"klonekit/pkg/blueprint"
 )
 
+// TestMain keeps retried API failures fast
+func TestMain(m *testing.M) {
+	retry.SetPolicy(retry.Policy{MaxRetries: retry.DefaultMaxRetries, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond})
+	os.Exit(m.Run())
+}
+
 func TestNewGitLabProvider(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -135,41 +149,6 @@ func TestGitLabProvider_CreateRepo(t *testing.T) {
 			},
 			expectError: false,
 		},
-		{
-			name: "Repository already exists",
-			spec: &blueprint.Spec{
-				SCM: blueprint.SCMProvider{
-					Provider: "gitlab",
-					URL:      "https://gitlab.com",
-					Token:    "test-token",
-					Project: blueprint.ProjectConfig{
-						Name:        "existing-repo",
-						Namespace:   "test-user",
-						Description: "Existing repository",
-						Visibility:  "private",
-					},
-				},
-				Scaffold: blueprint.Scaffold{
-					Source:      "/source/path",
-					Destination: tempDir,
-				},
-			},
-			mockResponse: func(w http.ResponseWriter, r *http.Request) {
-				if strings.Contains(r.URL.Path, "existing-repo") {
-					// Repository exists - return project data
-					w.Header().Set("Content-Type", "application/json")
-					w.WriteHeader(http.StatusOK)
-					fmt.Fprint(w, `{
-						"id": 456,
-						"name": "existing-repo",
-						"http_url_to_repo": "https://gitlab.com/test-user/existing-repo.git"
-					}`)
-				} else {
-					w.WriteHeader(http.StatusOK)
-				}
-			},
-			expectError: false,
-		},
 		{
 			name: "Scaffold directory does not exist",
 			spec: &blueprint.Spec{
@@ -250,7 +229,7 @@ func TestGitLabProvider_CreateRepo(t *testing.T) {
 			defer server.Close()
 
 			// Create GitLab client with mock server
-			client, err := gitlab.NewClient("test-token", gitlab.WithBaseURL(server.URL+"/api/v4"))
+			client, err := newGitLabClient("test-token", server.URL+"/api/v4")
 			if err != nil {
 				t.Fatalf("Failed to create test client: %s", err)
 			}
@@ -376,9 +355,865 @@ func TestVisibilityLevelConversion(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(fmt.Sprintf("visibility_%s", tt.input), func(t *testing.T) {
-			// This is tested implicitly in the CreateRepo function
-			// The actual visibility conversion logic is part of the implementation
-			// We're testing it through the main function behavior
+			if got := string(visibilityLevel(tt.input)); got != tt.expected {
+				t.Errorf("Expected visibility %s, got %s", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestGitLabProvider_initializeAndPushRepo_ExistingRepo(t *testing.T) {
+	scaffoldDir := t.TempDir()
+	remoteDir := t.TempDir()
+
+	// Bare repository acting as the remote
+	if _, err := git.PlainInit(remoteDir, true); err != nil {
+		t.Fatalf("Failed to create bare remote repository: %s", err)
+	}
+
+	// Pre-existing repository with prior history in the destination
+	repo, err := git.PlainInit(scaffoldDir, false)
+	if err != nil {
+		t.Fatalf("Failed to initialize existing repository: %s", err)
+	}
+	if err := os.WriteFile(filepath.Join(scaffoldDir, "README.md"), []byte("# Existing project"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %s", err)
+	}
+	worktree, err := repo.Worktree()
+	if err != nil {
+		t.Fatalf("Failed to get worktree: %s", err)
+	}
+	if _, err := worktree.Add("."); err != nil {
+		t.Fatalf("Failed to stage files: %s", err)
+	}
+	priorCommit, err := worktree.Commit("Existing history", &git.CommitOptions{
+		Author: &object.Signature{Name: "Test", Email: "test@example.com", When: time.Now()},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create prior commit: %s", err)
+	}
+
+	// Scaffolded changes on top of the existing history
+	if err := os.WriteFile(filepath.Join(scaffoldDir, "main.tf"), []byte("# Test Terraform file"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %s", err)
+	}
+
+	provider := &GitLabProvider{
+		token: "test-token",
+	}
+	spec := &blueprint.Spec{
+		Scaffold: blueprint.Scaffold{
+			Source:      "/source/path",
+			Destination: scaffoldDir,
+		},
+	}
+
+	if err := provider.initializeAndPushRepo(spec, remoteDir); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	head, err := repo.Head()
+	if err != nil {
+		t.Fatalf("Failed to read HEAD: %s", err)
+	}
+	headCommit, err := repo.CommitObject(head.Hash())
+	if err != nil {
+		t.Fatalf("Failed to read HEAD commit: %s", err)
+	}
+
+	if headCommit.Hash == priorCommit {
+		t.Fatal("Expected a new commit on top of the existing history")
+	}
+	if len(headCommit.ParentHashes) != 1 || headCommit.ParentHashes[0] != priorCommit {
+		t.Errorf("Expected new commit to have parent %s, got %v", priorCommit, headCommit.ParentHashes)
+	}
+	if _, err := headCommit.File("main.tf"); err != nil {
+		t.Errorf("Expected scaffolded file in new commit: %s", err)
+	}
+
+	// Verify the remote received the full history
+	remoteRepo, err := git.PlainOpen(remoteDir)
+	if err != nil {
+		t.Fatalf("Failed to open remote repository: %s", err)
+	}
+	remoteRef, err := remoteRepo.Reference(head.Name(), true)
+	if err != nil {
+		t.Fatalf("Expected pushed branch on remote: %s", err)
+	}
+	if remoteRef.Hash() != headCommit.Hash {
+		t.Errorf("Expected remote branch at %s, got %s", headCommit.Hash, remoteRef.Hash())
+	}
+}
+
+func TestGitLabProvider_initializeAndPushRepo_CommitAuthor(t *testing.T) {
+	tests := []struct {
+		name        string
+		commit      blueprint.CommitConfig
+		wantName    string
+		wantEmail   string
+		wantMessage string
+	}{
+		{
+			name:        "defaults",
+			wantName:    "KloneKit",
+			wantEmail:   "noreply@klonekit.dev",
+			wantMessage: "Initial commit - scaffolded from KloneKit",
+		},
+		{
+			name:        "configured",
+			commit:      blueprint.CommitConfig{Author: "Platform Bot", Email: "platform-bot@example.com", Message: "Scaffold network module"},
+			wantName:    "Platform Bot",
+			wantEmail:   "platform-bot@example.com",
+			wantMessage: "Scaffold network module",
+		},
+		{
+			name:        "email only",
+			commit:      blueprint.CommitConfig{Email: "platform-bot@example.com"},
+			wantName:    "KloneKit",
+			wantEmail:   "platform-bot@example.com",
+			wantMessage: "Initial commit - scaffolded from KloneKit",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scaffoldDir := t.TempDir()
+			remoteDir := t.TempDir()
+			if _, err := git.PlainInit(remoteDir, true); err != nil {
+				t.Fatalf("Failed to create bare remote repository: %s", err)
+			}
+			if err := os.WriteFile(filepath.Join(scaffoldDir, "main.tf"), []byte("# Test Terraform file"), 0644); err != nil {
+				t.Fatalf("Failed to create test file: %s", err)
+			}
+
+			provider := &GitLabProvider{token: "test-token"}
+			spec := &blueprint.Spec{
+				SCM:      blueprint.SCMProvider{Commit: tt.commit},
+				Scaffold: blueprint.Scaffold{Source: "/source/path", Destination: scaffoldDir},
+			}
+			if err := provider.initializeAndPushRepo(spec, remoteDir); err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+
+			commit := pushedCommit(t, remoteDir, blueprint.DefaultBranchName)
+
+			if commit.Author.Name != tt.wantName || commit.Author.Email != tt.wantEmail {
+				t.Errorf("Expected author %s <%s>, got %s <%s>", tt.wantName, tt.wantEmail, commit.Author.Name, commit.Author.Email)
+			}
+			if commit.Message != tt.wantMessage {
+				t.Errorf("Expected message %q, got %q", tt.wantMessage, commit.Message)
+			}
+		})
+	}
+}
+
+func TestGitLabProvider_initializeAndPushRepo_StagingModes(t *testing.T) {
+	tests := []struct {
+		name        string
+		staging     string
+		expectError bool
+		errorMsg    string
+	}{
+		{
+			name:        "Strict staging fails on special file",
+			staging:     StagingStrict,
+			expectError: true,
+			errorMsg:    "cannot stage special file",
+		},
+		{
+			name:        "Default staging is strict",
+			staging:     "",
+			expectError: true,
+			errorMsg:    "cannot stage special file",
+		},
+		{
+			name:        "Best-effort staging skips special file",
+			staging:     StagingBestEffort,
+			expectError: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scaffoldDir := t.TempDir()
+			remoteDir := t.TempDir()
+
+			if _, err := git.PlainInit(remoteDir, true); err != nil {
+				t.Fatalf("Failed to create bare remote repository: %s", err)
+			}
+			if err := os.WriteFile(filepath.Join(scaffoldDir, "main.tf"), []byte("# Test Terraform file"), 0644); err != nil {
+				t.Fatalf("Failed to create test file: %s", err)
+			}
+
+			// A stray unix socket can't be read or committed
+			listener, err := net.Listen("unix", filepath.Join(scaffoldDir, "agent.sock"))
+			if err != nil {
+				t.Skipf("Skipping test: unix sockets not supported: %s", err)
+			}
+			defer listener.Close()
+
+			provider := &GitLabProvider{
+				token: "test-token",
+			}
+			spec := &blueprint.Spec{
+				SCM: blueprint.SCMProvider{
+					Staging: tt.staging,
+				},
+				Scaffold: blueprint.Scaffold{
+					Source:      "/source/path",
+					Destination: scaffoldDir,
+				},
+			}
+
+			err = provider.initializeAndPushRepo(spec, remoteDir)
+
+			if tt.expectError {
+				if err == nil {
+					t.Fatal("Expected error but got none")
+				}
+				if !strings.Contains(err.Error(), tt.errorMsg) {
+					t.Errorf("Expected error message to contain '%s', got: %s", tt.errorMsg, err.Error())
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+
+			repo, err := git.PlainOpen(scaffoldDir)
+			if err != nil {
+				t.Fatalf("Failed to open repository: %s", err)
+			}
+			head, err := repo.Head()
+			if err != nil {
+				t.Fatalf("Failed to read HEAD: %s", err)
+			}
+			commit, err := repo.CommitObject(head.Hash())
+			if err != nil {
+				t.Fatalf("Failed to read HEAD commit: %s", err)
+			}
+			if _, err := commit.File("main.tf"); err != nil {
+				t.Errorf("Expected main.tf to be committed: %s", err)
+			}
+			if _, err := commit.File("agent.sock"); err == nil {
+				t.Error("Expected special file to be skipped")
+			}
+		})
+	}
+}
+
+func TestGitLabProvider_initializeAndPushRepo_RedactsSecretVariables(t *testing.T) {
+	scaffoldDir := t.TempDir()
+	remoteDir := t.TempDir()
+
+	if _, err := git.PlainInit(remoteDir, true); err != nil {
+		t.Fatalf("Failed to create bare remote repository: %s", err)
+	}
+
+	tfvars := "{\n  \"db_password\": \"hunter2\",\n  \"region\": \"us-east-1\"\n}"
+	if err := os.WriteFile(filepath.Join(scaffoldDir, "terraform.tfvars.json"), []byte(tfvars), 0600); err != nil {
+		t.Fatalf("Failed to create tfvars file: %s", err)
+	}
+	if err := os.WriteFile(filepath.Join(scaffoldDir, "main.tf"), []byte("# Test Terraform file"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %s", err)
+	}
+
+	provider := &GitLabProvider{
+		token: "test-token",
+	}
+	spec := &blueprint.Spec{
+		Scaffold: blueprint.Scaffold{
+			Source:      "/source/path",
+			Destination: scaffoldDir,
+		},
+		SecretVariables: []string{"db_password"},
+	}
+
+	if err := provider.initializeAndPushRepo(spec, remoteDir); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	// The file on disk keeps the real values for local provisioning
+	onDisk, err := os.ReadFile(filepath.Join(scaffoldDir, "terraform.tfvars.json"))
+	if err != nil {
+		t.Fatalf("Failed to read tfvars file: %s", err)
+	}
+	if string(onDisk) != tfvars {
+		t.Errorf("Expected on-disk tfvars to be unchanged, got: %s", onDisk)
+	}
+
+	// The committed version has placeholders for secret keys
+	repo, err := git.PlainOpen(scaffoldDir)
+	if err != nil {
+		t.Fatalf("Failed to open repository: %s", err)
+	}
+	head, err := repo.Head()
+	if err != nil {
+		t.Fatalf("Failed to read HEAD: %s", err)
+	}
+	headCommit, err := repo.CommitObject(head.Hash())
+	if err != nil {
+		t.Fatalf("Failed to read HEAD commit: %s", err)
+	}
+	file, err := headCommit.File("terraform.tfvars.json")
+	if err != nil {
+		t.Fatalf("Expected tfvars in commit: %s", err)
+	}
+	committed, err := file.Contents()
+	if err != nil {
+		t.Fatalf("Failed to read committed tfvars: %s", err)
+	}
+
+	if strings.Contains(committed, "hunter2") {
+		t.Errorf("Expected secret value to be redacted, got: %s", committed)
+	}
+	if !strings.Contains(committed, `"db_password": "`+RedactedPlaceholder+`"`) {
+		t.Errorf("Expected placeholder for secret key, got: %s", committed)
+	}
+	if !strings.Contains(committed, `"region": "us-east-1"`) {
+		t.Errorf("Expected non-secret values to be kept, got: %s", committed)
+	}
+}
+
+func TestGitLabProvider_CreateRepo_RetriesFlakyAPI(t *testing.T) {
+	tests := []struct {
+		name        string
+		maxRetries  int
+		failures    int
+		expectError bool
+	}{
+		{name: "recovers within the retry budget", maxRetries: 2, failures: 2, expectError: false},
+		{name: "gives up when retries are exhausted", maxRetries: 1, failures: 2, expectError: true},
+		{name: "no retries", maxRetries: 0, failures: 1, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			original := retry.Current()
+			defer retry.SetPolicy(original)
+			if err := retry.SetMaxRetries(tt.maxRetries); err != nil {
+				t.Fatal(err)
+			}
+
+			createCalls := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != http.MethodPost {
+					w.WriteHeader(http.StatusNotFound)
+					fmt.Fprint(w, `{"message":"404 Project Not Found"}`)
+					return
+				}
+				createCalls++
+				if createCalls <= tt.failures {
+					w.WriteHeader(http.StatusServiceUnavailable)
+					fmt.Fprint(w, `{"message":"Service Unavailable"}`)
+					return
+				}
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusCreated)
+				fmt.Fprint(w, `{"id":1,"name":"test-repo","http_url_to_repo":"https://gitlab.invalid/test-user/test-repo.git"}`)
+			}))
+			defer server.Close()
+
+			client, err := newGitLabClient("test-token", server.URL+"/api/v4")
+			if err != nil {
+				t.Fatalf("Failed to create test client: %s", err)
+			}
+			provider := &GitLabProvider{client: client, token: "test-token"}
+
+			spec := &blueprint.Spec{
+				SCM: blueprint.SCMProvider{
+					Project: blueprint.ProjectConfig{Name: "test-repo", Namespace: "test-user"},
+				},
+				Scaffold: blueprint.Scaffold{Destination: filepath.Join(t.TempDir(), "missing")},
+			}
+			err = provider.CreateRepo(spec)
+			if err == nil {
+				t.Fatal("Expected the push of the missing scaffold directory to fail")
+			}
+
+			expectedCalls := tt.maxRetries + 1
+			if !tt.expectError {
+				expectedCalls = tt.failures + 1
+			}
+			if createCalls != expectedCalls {
+				t.Errorf("Expected %d create calls, got %d", expectedCalls, createCalls)
+			}
+			createFailed := strings.Contains(err.Error(), "failed to create GitLab project")
+			if createFailed != tt.expectError {
+				t.Errorf("Expected project creation failure to be %t, got: %s", tt.expectError, err)
+			}
+		})
+	}
+}
+
+func TestGitLabProvider_CreateRepo_DoesNotRetryClientErrors(t *testing.T) {
+	original := retry.Current()
+	defer retry.SetPolicy(original)
+	if err := retry.SetMaxRetries(3); err != nil {
+		t.Fatal(err)
+	}
+
+	createCalls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			createCalls++
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"message":"name has already been taken"}`)
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"message":"404 Project Not Found"}`)
+	}))
+	defer server.Close()
+
+	client, err := newGitLabClient("test-token", server.URL+"/api/v4")
+	if err != nil {
+		t.Fatalf("Failed to create test client: %s", err)
+	}
+	provider := &GitLabProvider{client: client, token: "test-token"}
+
+	spec := &blueprint.Spec{
+		SCM: blueprint.SCMProvider{
+			Project: blueprint.ProjectConfig{Name: "test-repo", Namespace: "test-user"},
+		},
+	}
+	if err := provider.CreateRepo(spec); err == nil {
+		t.Fatal("Expected error for rejected project creation, got nil")
+	}
+	if createCalls != 1 {
+		t.Errorf("Expected a client error not to be retried, got %d create calls", createCalls)
+	}
+}
+
+func TestGitLabProvider_CreateRepo_Visibility(t *testing.T) {
+	tests := []struct {
+		visibility string
+		expected   string
+	}{
+		{visibility: "public", expected: "public"},
+		{visibility: "internal", expected: "internal"},
+		{visibility: "", expected: "private"},
+	}
+
+	for _, tt := range tests {
+		t.Run("visibility_"+tt.visibility, func(t *testing.T) {
+			var requested string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != http.MethodPost {
+					w.WriteHeader(http.StatusNotFound)
+					fmt.Fprint(w, `{"message":"404 Project Not Found"}`)
+					return
+				}
+				var body struct {
+					Visibility string `json:"visibility"`
+				}
+				if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+					t.Errorf("Failed to decode create request: %s", err)
+				}
+				requested = body.Visibility
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusCreated)
+				fmt.Fprint(w, `{"id":1,"name":"test-repo","http_url_to_repo":"https://gitlab.invalid/test-user/test-repo.git"}`)
+			}))
+			defer server.Close()
+
+			client, err := newGitLabClient("test-token", server.URL+"/api/v4")
+			if err != nil {
+				t.Fatalf("Failed to create test client: %s", err)
+			}
+			provider := &GitLabProvider{client: client, token: "test-token"}
+
+			spec := &blueprint.Spec{
+				SCM: blueprint.SCMProvider{
+					Project: blueprint.ProjectConfig{Name: "test-repo", Namespace: "test-user", Visibility: tt.visibility},
+				},
+				Scaffold: blueprint.Scaffold{Destination: filepath.Join(t.TempDir(), "missing")},
+			}
+			// The push fails on the missing scaffold directory; only the create request matters here
+			_ = provider.CreateRepo(spec)
+
+			if requested != tt.expected {
+				t.Errorf("Expected visibility %s in the create request, got %q", tt.expected, requested)
+			}
+		})
+	}
+}
+
+func TestValidateVisibility(t *testing.T) {
+	for _, visibility := range []string{"private", "public", "internal"} {
+		if err := ValidateVisibility(visibility); err != nil {
+			t.Errorf("Expected %s to be valid, got: %s", visibility, err)
+		}
+	}
+	if err := ValidateVisibility("secret"); err == nil {
+		t.Error("Expected an invalid visibility to be rejected")
+	}
+}
+
+func TestGitLabProvider_CreateRepo_ProjectOptions(t *testing.T) {
+	lfsDisabled := false
+	tests := []struct {
+		name                  string
+		project               blueprint.ProjectConfig
+		expectedLFS           bool
+		expectedRequestAccess bool
+		expectedBranch        string
+	}{
+		{
+			name:    "defaults",
+			project: blueprint.ProjectConfig{Name: "test-repo", Namespace: "test-user"},
+
+			expectedLFS: true,
+		},
+		{
+			name: "configured",
+			project: blueprint.ProjectConfig{
+				Name: "test-repo", Namespace: "test-user",
+				DefaultBranch: "main", LFSEnabled: &lfsDisabled, RequestAccessEnabled: true,
+			},
+			expectedLFS:           false,
+			expectedRequestAccess: true,
+			expectedBranch:        "main",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var body struct {
+				DefaultBranch        string `json:"default_branch"`
+				LFSEnabled           *bool  `json:"lfs_enabled"`
+				RequestAccessEnabled *bool  `json:"request_access_enabled"`
+			}
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != http.MethodPost {
+					w.WriteHeader(http.StatusNotFound)
+					fmt.Fprint(w, `{"message":"404 Project Not Found"}`)
+					return
+				}
+				if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+					t.Errorf("Failed to decode create request: %s", err)
+				}
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusCreated)
+				fmt.Fprint(w, `{"id":1,"name":"test-repo","http_url_to_repo":"https://gitlab.invalid/test-user/test-repo.git"}`)
+			}))
+			defer server.Close()
+
+			client, err := newGitLabClient("test-token", server.URL+"/api/v4")
+			if err != nil {
+				t.Fatalf("Failed to create test client: %s", err)
+			}
+			provider := &GitLabProvider{client: client, token: "test-token"}
+
+			spec := &blueprint.Spec{
+				SCM:      blueprint.SCMProvider{Project: tt.project},
+				Scaffold: blueprint.Scaffold{Destination: filepath.Join(t.TempDir(), "missing")},
+			}
+			// The push fails on the missing scaffold directory; only the create request matters here
+			_ = provider.CreateRepo(spec)
+
+			if body.LFSEnabled == nil || *body.LFSEnabled != tt.expectedLFS {
+				t.Errorf("Expected lfs_enabled %t in the create request, got %v", tt.expectedLFS, body.LFSEnabled)
+			}
+			if body.RequestAccessEnabled == nil || *body.RequestAccessEnabled != tt.expectedRequestAccess {
+				t.Errorf("Expected request_access_enabled %t in the create request, got %v", tt.expectedRequestAccess, body.RequestAccessEnabled)
+			}
+			if body.DefaultBranch != tt.expectedBranch {
+				t.Errorf("Expected default_branch %q in the create request, got %q", tt.expectedBranch, body.DefaultBranch)
+			}
+		})
+	}
+}
+
+func TestOpenOrInitRepo_DefaultBranch(t *testing.T) {
+	dir := t.TempDir()
+
+	repo, isExisting, err := openOrInitRepo(dir, "main")
+	if err != nil {
+		t.Fatalf("Failed to initialize repository: %s", err)
+	}
+	if isExisting {
+		t.Error("Expected a new repository to be initialized")
+	}
+
+	head, err := repo.Storer.Reference(plumbing.HEAD)
+	if err != nil {
+		t.Fatalf("Failed to read HEAD: %s", err)
+	}
+	if head.Target() != plumbing.NewBranchReferenceName("main") {
+		t.Errorf("Expected HEAD to point at refs/heads/main, got %s", head.Target())
+	}
+}
+
+// pushedCommit returns the commit pushed to branch of the bare remote repository
+func pushedCommit(t *testing.T, remoteDir, branch string) *object.Commit {
+	t.Helper()
+
+	remote, err := git.PlainOpen(remoteDir)
+	if err != nil {
+		t.Fatalf("Failed to open remote repository: %s", err)
+	}
+	ref, err := remote.Reference(plumbing.NewBranchReferenceName(branch), true)
+	if err != nil {
+		t.Fatalf("Expected branch %s on the remote: %s", branch, err)
+	}
+	commit, err := remote.CommitObject(ref.Hash())
+	if err != nil {
+		t.Fatalf("Failed to read the pushed commit: %s", err)
+	}
+	return commit
+}
+
+func TestBranchRefSpec(t *testing.T) {
+	if got := branchRefSpec("develop"); got != "refs/heads/develop:refs/heads/develop" {
+		t.Errorf("Expected the push refspec for develop, got %s", got)
+	}
+}
+
+func TestGitLabProvider_initializeAndPushRepo_PushesDefaultBranch(t *testing.T) {
+	tests := []struct {
+		name          string
+		defaultBranch string
+		existingRepo  bool
+		wantBranch    string
+	}{
+		{name: "new repository", wantBranch: "main"},
+		{name: "configured branch", defaultBranch: "develop", wantBranch: "develop"},
+		{name: "existing repository on another branch", existingRepo: true, wantBranch: "main"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scaffoldDir := t.TempDir()
+			remoteDir := t.TempDir()
+			if _, err := git.PlainInit(remoteDir, true); err != nil {
+				t.Fatalf("Failed to create bare remote repository: %s", err)
+			}
+
+			var priorCommit plumbing.Hash
+			if tt.existingRepo {
+				repo, err := git.PlainInit(scaffoldDir, false)
+				if err != nil {
+					t.Fatalf("Failed to initialize existing repository: %s", err)
+				}
+				if err := os.WriteFile(filepath.Join(scaffoldDir, "README.md"), []byte("# Existing project"), 0644); err != nil {
+					t.Fatalf("Failed to create test file: %s", err)
+				}
+				worktree, err := repo.Worktree()
+				if err != nil {
+					t.Fatalf("Failed to get worktree: %s", err)
+				}
+				if _, err := worktree.Add("README.md"); err != nil {
+					t.Fatalf("Failed to stage files: %s", err)
+				}
+				priorCommit, err = worktree.Commit("Existing history", &git.CommitOptions{
+					Author: &object.Signature{Name: "Test", Email: "test@example.com", When: time.Now()},
+				})
+				if err != nil {
+					t.Fatalf("Failed to create prior commit: %s", err)
+				}
+			}
+			if err := os.WriteFile(filepath.Join(scaffoldDir, "main.tf"), []byte("# Test Terraform file"), 0644); err != nil {
+				t.Fatalf("Failed to create test file: %s", err)
+			}
+
+			provider := &GitLabProvider{token: "test-token"}
+			spec := &blueprint.Spec{
+				SCM:      blueprint.SCMProvider{Project: blueprint.ProjectConfig{DefaultBranch: tt.defaultBranch}},
+				Scaffold: blueprint.Scaffold{Source: "/source/path", Destination: scaffoldDir},
+			}
+			if err := provider.initializeAndPushRepo(spec, remoteDir); err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+
+			commit := pushedCommit(t, remoteDir, tt.wantBranch)
+			if _, err := commit.File("main.tf"); err != nil {
+				t.Errorf("Expected the scaffolded files on %s: %s", tt.wantBranch, err)
+			}
+			if tt.existingRepo && (len(commit.ParentHashes) != 1 || commit.ParentHashes[0] != priorCommit) {
+				t.Errorf("Expected the commit on top of the existing history, got parents %v", commit.ParentHashes)
+			}
+
+			remote, err := git.PlainOpen(remoteDir)
+			if err != nil {
+				t.Fatalf("Failed to open remote repository: %s", err)
+			}
+			if _, err := remote.Reference(plumbing.NewBranchReferenceName("master"), false); err == nil {
+				t.Error("Expected only the default branch to be pushed, got master as well")
+			}
+		})
+	}
+}
+
+func TestGitLabProvider_initializeAndPushRepo_CommitsGeneratedGitLabCI(t *testing.T) {
+	sourceDir := t.TempDir()
+	scaffoldDir := filepath.Join(t.TempDir(), "destination")
+	remoteDir := t.TempDir()
+
+	if _, err := git.PlainInit(remoteDir, true); err != nil {
+		t.Fatalf("Failed to create bare remote repository: %s", err)
+	}
+	if err := os.WriteFile(filepath.Join(sourceDir, "main.tf"), []byte("# Test Terraform file"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %s", err)
+	}
+
+	spec := &blueprint.Spec{
+		Cloud: blueprint.CloudProvider{Provider: "aws", Region: "us-east-1"},
+		Scaffold: blueprint.Scaffold{
+			Source:      sourceDir,
+			Destination: scaffoldDir,
+			GitLabCI:    true,
+		},
+	}
+	if err := scaffolder.Scaffold(spec, false, false); err != nil {
+		t.Fatalf("Scaffold failed: %s", err)
+	}
+
+	provider := &GitLabProvider{token: "test-token"}
+	if err := provider.initializeAndPushRepo(spec, remoteDir); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	repo, err := git.PlainOpen(remoteDir)
+	if err != nil {
+		t.Fatalf("Failed to open remote repository: %s", err)
+	}
+	ref, err := repo.Reference(plumbing.NewBranchReferenceName(blueprint.DefaultBranchName), true)
+	if err != nil {
+		t.Fatalf("Expected the branch to be pushed: %s", err)
+	}
+	commit, err := repo.CommitObject(ref.Hash())
+	if err != nil {
+		t.Fatalf("Failed to read pushed commit: %s", err)
+	}
+	file, err := commit.File(scaffolder.GitLabCIFileName)
+	if err != nil {
+		t.Fatalf("Expected %s in the pushed commit: %s", scaffolder.GitLabCIFileName, err)
+	}
+	content, err := file.Contents()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(content, provisioner.TerraformDockerImage) {
+		t.Errorf("Expected the committed pipeline to use %s, got:\n%s", provisioner.TerraformDockerImage, content)
+	}
+}
+
+func TestGitLabProvider_initializeAndPushRepo_RedactsDecryptedVariables(t *testing.T) {
+	sourceDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(sourceDir, "main.tf"), []byte("# module"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	scaffoldDir := filepath.Join(t.TempDir(), "destination")
+	remoteDir := t.TempDir()
+
+	if _, err := git.PlainInit(remoteDir, true); err != nil {
+		t.Fatalf("Failed to create bare remote repository: %s", err)
+	}
+
+	spec := &blueprint.Spec{
+		Scaffold: blueprint.Scaffold{Source: sourceDir, Destination: scaffoldDir},
+		Variables: map[string]interface{}{
+			"db_password": scaffolder.EncryptedValuePrefix + "aHVudGVyMg==",
+		},
+		Decryption: blueprint.Decryption{Command: []string{"base64", "-d"}},
+	}
+	if err := scaffolder.DecryptVariables(spec); err != nil {
+		t.Fatalf("Failed to decrypt variables: %s", err)
+	}
+	if err := scaffolder.Scaffold(spec, false, false); err != nil {
+		t.Fatalf("Scaffold failed: %s", err)
+	}
+
+	provider := &GitLabProvider{token: "test-token"}
+	if err := provider.initializeAndPushRepo(spec, remoteDir); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	repo, err := git.PlainOpen(scaffoldDir)
+	if err != nil {
+		t.Fatalf("Failed to open repository: %s", err)
+	}
+	head, err := repo.Head()
+	if err != nil {
+		t.Fatalf("Failed to read HEAD: %s", err)
+	}
+	headCommit, err := repo.CommitObject(head.Hash())
+	if err != nil {
+		t.Fatalf("Failed to read HEAD commit: %s", err)
+	}
+	file, err := headCommit.File(blueprint.DefaultTfvarsFilename)
+	if err != nil {
+		t.Fatalf("Expected tfvars in commit: %s", err)
+	}
+	committed, err := file.Contents()
+	if err != nil {
+		t.Fatalf("Failed to read committed tfvars: %s", err)
+	}
+	if strings.Contains(committed, "hunter2") {
+		t.Errorf("Expected the decrypted value to be redacted in the commit, got: %s", committed)
+	}
+	if !strings.Contains(committed, `"db_password": "`+RedactedPlaceholder+`"`) {
+		t.Errorf("Expected placeholder for the decrypted key, got: %s", committed)
+	}
+}
+
+func TestAPIBaseURL(t *testing.T) {
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{url: "", want: "https://gitlab.com/api/v4"},
+		{url: "https://gitlab.example.com", want: "https://gitlab.example.com/api/v4"},
+		{url: "https://gitlab.example.com/", want: "https://gitlab.example.com/api/v4"},
+		{url: " https://gitlab.example.com/api/v4/ ", want: "https://gitlab.example.com/api/v4"},
+		{url: "https://example.com/gitlab", want: "https://example.com/gitlab/api/v4"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			if got := apiBaseURL(tt.url); got != tt.want {
+				t.Errorf("apiBaseURL(%q) = %q, want %q", tt.url, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewGitLabProviderWithURL_UsesConfiguredHost(t *testing.T) {
+	t.Setenv("GITLAB_PRIVATE_TOKEN", "test-token")
+
+	for _, suffix := range []string{"", "/", "/api/v4"} {
+		t.Run("url"+suffix, func(t *testing.T) {
+			var requested []string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requested = append(requested, r.URL.Path)
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusForbidden)
+				fmt.Fprint(w, `{"message":"403 Forbidden"}`)
+			}))
+			defer server.Close()
+
+			provider, err := NewGitLabProviderWithURL(server.URL + suffix)
+			if err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			spec := &blueprint.Spec{
+				SCM: blueprint.SCMProvider{Project: blueprint.ProjectConfig{Name: "test-repo", Namespace: "test-user"}},
+			}
+			// Every request is refused, which fails the run after the lookup
+			if err := provider.CreateRepo(spec); err == nil {
+				t.Fatal("Expected the refused lookup to fail")
+			}
+
+			if !slices.Contains(requested, "/api/v4/projects/test-user/test-repo") {
+				t.Errorf("Expected the project to be looked up on the configured host, got requests %v", requested)
+			}
+			for _, path := range requested {
+				if !strings.HasPrefix(path, "/api/v4/") {
+					t.Errorf("Expected every request under /api/v4/, got %s", path)
+				}
+			}
 		})
 	}
 }
@@ -0,0 +1,83 @@
+package scm
+
+import (
+	"fmt"
+	"log/slog"
+	nethttp "net/http"
+	"time"
+
+	gitlab "github.com/xanzy/go-gitlab"
+
+	"klonekit/pkg/blueprint"
+)
+
+// DefaultBranchWaitSeconds is how long to wait for a pushed branch to appear when
+// spec.scm.project.branchProtection.waitSeconds is not set.
+const DefaultBranchWaitSeconds = 30
+
+// branchPollInterval is the delay between checks for a pushed branch; tests shorten it.
+var branchPollInterval = time.Second
+
+// protectBranches protects the configured branches of the project. Each branch is first
+// verified to exist, since GitLab may not list a branch immediately after the push. A branch
+// that is already protected, such as the default branch, keeps its protection.
+func (g *GitLabProvider) protectBranches(projectID int, protection blueprint.BranchProtection) error {
+	if len(protection.Branches) == 0 {
+		return nil
+	}
+
+	wait := time.Duration(protection.WaitSeconds) * time.Second
+	if protection.WaitSeconds == 0 {
+		wait = DefaultBranchWaitSeconds * time.Second
+	}
+
+	for _, branch := range protection.Branches {
+		if err := g.waitForBranch(projectID, branch, wait); err != nil {
+			return fmt.Errorf("failed to protect branch '%s' (the files were pushed; protect it in GitLab manually): %w", branch, err)
+		}
+
+		opts := &gitlab.ProtectRepositoryBranchesOptions{
+			Name:             gitlab.String(branch),
+			PushAccessLevel:  gitlab.AccessLevel(gitlab.MaintainerPermissions),
+			MergeAccessLevel: gitlab.AccessLevel(gitlab.MaintainerPermissions),
+		}
+		var status int
+		err := retryAPI("protect GitLab branch", func() (*gitlab.Response, error) {
+			_, resp, err := g.client.ProtectedBranches.ProtectRepositoryBranches(projectID, opts)
+			if resp != nil {
+				status = resp.StatusCode
+			}
+			return resp, err
+		})
+		if err != nil && status == nethttp.StatusConflict {
+			// GitLab protects the default branch of a new project itself, and a resumed run
+			// finds the protection it applied before
+			slog.Info("Branch is already protected, keeping its protection settings", "project", projectID, "branch", branch)
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to protect branch '%s' (the files were pushed; protect it in GitLab manually): %w", branch, err)
+		}
+		slog.Info("Protected branch", "project", projectID, "branch", branch)
+	}
+	return nil
+}
+
+// waitForBranch polls GitLab until the branch exists or wait elapses.
+func (g *GitLabProvider) waitForBranch(projectID int, branch string, wait time.Duration) error {
+	deadline := time.Now().Add(wait)
+	for {
+		_, resp, err := g.client.Branches.GetBranch(projectID, branch)
+		if err == nil {
+			return nil
+		}
+		if resp == nil || resp.StatusCode != nethttp.StatusNotFound {
+			return fmt.Errorf("failed to look up branch: %w", err)
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("branch did not appear in GitLab within %s of the push", wait)
+		}
+		slog.Debug("Waiting for pushed branch to appear", "project", projectID, "branch", branch)
+		time.Sleep(branchPollInterval)
+	}
+}
@@ -0,0 +1,184 @@
+package scm
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	git "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+
+	"klonekit/pkg/blueprint"
+)
+
+// protectionServer fakes the GitLab API for a new project whose branch appears after
+// branchMisses lookups, recording the branches protected. With alreadyProtected, protecting
+// a branch fails as GitLab does for the default branch it protected itself.
+type protectionServer struct {
+	t                *testing.T
+	remoteDir        string
+	branchMisses     int
+	alreadyProtected bool
+
+	mu             sync.Mutex
+	branchLookups  int
+	protected      []string
+	pushedAtRecord bool
+}
+
+func (s *protectionServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	switch {
+	case r.Method == http.MethodPost && r.URL.Path == "/api/v4/projects":
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprintf(w, `{"id":1,"name":"test-repo","http_url_to_repo":%q}`, s.remoteDir)
+	case r.Method == http.MethodGet && r.URL.Path == "/api/v4/projects/1/repository/branches/main":
+		s.branchLookups++
+		if s.branchLookups <= s.branchMisses {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"message":"404 Branch Not Found"}`)
+			return
+		}
+		fmt.Fprint(w, `{"name":"main"}`)
+	case r.Method == http.MethodPost && r.URL.Path == "/api/v4/projects/1/protected_branches":
+		var body struct {
+			Name string `json:"name"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			s.t.Errorf("Failed to decode protect request: %s", err)
+		}
+		name := body.Name
+		s.protected = append(s.protected, name)
+
+		// Protection must only be applied once the branch was pushed
+		if repo, err := git.PlainOpen(s.remoteDir); err == nil {
+			if _, err := repo.Reference(plumbing.NewBranchReferenceName(name), false); err == nil {
+				s.pushedAtRecord = true
+			}
+		}
+		if s.alreadyProtected {
+			w.WriteHeader(http.StatusConflict)
+			fmt.Fprint(w, `{"message":"Protected branch 'main' already exists"}`)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprintf(w, `{"name":%q}`, name)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"message":"404 Project Not Found"}`)
+	}
+}
+
+// createRepoWithProtection runs CreateRepo against a protectionServer
+func createRepoWithProtection(t *testing.T, server *protectionServer, protection blueprint.BranchProtection) error {
+	t.Helper()
+
+	scaffoldDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(scaffoldDir, "main.tf"), []byte("# Test Terraform file"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	httpServer := httptest.NewServer(server)
+	defer httpServer.Close()
+
+	client, err := newGitLabClient("test-token", httpServer.URL+"/api/v4")
+	if err != nil {
+		t.Fatalf("Failed to create test client: %s", err)
+	}
+	provider := &GitLabProvider{client: client, token: "test-token"}
+
+	spec := &blueprint.Spec{
+		SCM: blueprint.SCMProvider{
+			Project: blueprint.ProjectConfig{Name: "test-repo", Namespace: "test-user", BranchProtection: protection},
+		},
+		Scaffold: blueprint.Scaffold{Destination: scaffoldDir},
+	}
+	return provider.CreateRepo(spec)
+}
+
+func TestGitLabProvider_CreateRepo_ProtectsBranchAfterPush(t *testing.T) {
+	originalInterval := branchPollInterval
+	defer func() { branchPollInterval = originalInterval }()
+	branchPollInterval = time.Millisecond
+
+	tests := []struct {
+		name         string
+		branchMisses int
+	}{
+		{name: "branch available immediately", branchMisses: 0},
+		{name: "branch not yet listed after push", branchMisses: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			remoteDir := t.TempDir()
+			if _, err := git.PlainInit(remoteDir, true); err != nil {
+				t.Fatalf("Failed to create bare remote repository: %s", err)
+			}
+
+			server := &protectionServer{t: t, remoteDir: remoteDir, branchMisses: tt.branchMisses}
+			if err := createRepoWithProtection(t, server, blueprint.BranchProtection{Branches: []string{"main"}}); err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+
+			if len(server.protected) != 1 || server.protected[0] != "main" {
+				t.Errorf("Expected main to be protected once, got: %v", server.protected)
+			}
+			if !server.pushedAtRecord {
+				t.Error("Expected the branch to be pushed before it was protected")
+			}
+			if server.branchLookups != tt.branchMisses+1 {
+				t.Errorf("Expected %d branch lookups, got %d", tt.branchMisses+1, server.branchLookups)
+			}
+		})
+	}
+}
+
+func TestGitLabProvider_CreateRepo_BranchNeverAppears(t *testing.T) {
+	originalInterval := branchPollInterval
+	defer func() { branchPollInterval = originalInterval }()
+	branchPollInterval = 10 * time.Millisecond
+
+	remoteDir := t.TempDir()
+	if _, err := git.PlainInit(remoteDir, true); err != nil {
+		t.Fatalf("Failed to create bare remote repository: %s", err)
+	}
+
+	server := &protectionServer{t: t, remoteDir: remoteDir, branchMisses: 1 << 30}
+	err := createRepoWithProtection(t, server, blueprint.BranchProtection{Branches: []string{"main"}, WaitSeconds: 1})
+	if err == nil {
+		t.Fatal("Expected an error when the branch never appears, got nil")
+	}
+	if !strings.Contains(err.Error(), "failed to protect branch 'main'") || !strings.Contains(err.Error(), "did not appear") {
+		t.Errorf("Expected a clear protection error, got: %s", err)
+	}
+	if len(server.protected) != 0 {
+		t.Errorf("Expected no protection request, got: %v", server.protected)
+	}
+}
+
+func TestGitLabProvider_CreateRepo_BranchAlreadyProtected(t *testing.T) {
+	remoteDir := t.TempDir()
+	if _, err := git.PlainInit(remoteDir, true); err != nil {
+		t.Fatalf("Failed to create bare remote repository: %s", err)
+	}
+
+	// GitLab protects the default branch of a new project itself
+	server := &protectionServer{t: t, remoteDir: remoteDir, alreadyProtected: true}
+	if err := createRepoWithProtection(t, server, blueprint.BranchProtection{Branches: []string{"main"}}); err != nil {
+		t.Fatalf("Expected an already protected branch to be accepted, got: %s", err)
+	}
+	if len(server.protected) != 1 || server.protected[0] != "main" {
+		t.Errorf("Expected a single protection request for main, got: %v", server.protected)
+	}
+}
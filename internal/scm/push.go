@@ -0,0 +1,58 @@
+package scm
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+
+	git "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+)
+
+// LastPush returns the commit pushed by the last CreateRepo, or nil if nothing was pushed.
+func (g *GitLabProvider) LastPush() *PushResult {
+	return g.lastPush
+}
+
+// LastPush returns the commit pushed by the last CreateRepo, or nil if nothing was pushed.
+func (g *GitHubProvider) LastPush() *PushResult {
+	return g.lastPush
+}
+
+// confirmPush reads the branch back from the origin remote after a push and checks that it
+// points at the local branch's commit, returning the pushed commit. When the remote can't be
+// listed, go-git's successful push is trusted and the local commit is returned.
+func confirmPush(repo *git.Repository, repoURL, branch string, auth transport.AuthMethod) (*PushResult, error) {
+	ref := plumbing.NewBranchReferenceName(branch)
+	local, err := repo.Reference(ref, true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read pushed branch '%s': %w", branch, err)
+	}
+	result := &PushResult{CommitHash: local.Hash().String(), RemoteURL: repoURL, Branch: branch}
+
+	remote, err := repo.Remote("origin")
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up remote origin: %w", err)
+	}
+	refs, err := remote.List(&git.ListOptions{Auth: auth})
+	if errors.Is(err, transport.ErrEmptyRemoteRepository) {
+		refs, err = nil, nil // No branches at all, so the pushed one is missing
+	}
+	if err != nil {
+		slog.Warn("Could not read the pushed branch back from the remote, trusting the push result", "url", repoURL, "branch", branch, "error", err)
+		return result, nil
+	}
+
+	for _, remoteRef := range refs {
+		if remoteRef.Name() != ref {
+			continue
+		}
+		if remoteRef.Hash() != local.Hash() {
+			return nil, fmt.Errorf("push could not be confirmed: remote branch '%s' is at %s, expected %s", branch, remoteRef.Hash(), local.Hash())
+		}
+		slog.Info("Confirmed pushed commit on the remote", "hash", result.CommitHash, "url", repoURL, "branch", branch)
+		return result, nil
+	}
+	return nil, fmt.Errorf("push could not be confirmed: branch '%s' not found on the remote", branch)
+}
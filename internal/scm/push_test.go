@@ -0,0 +1,100 @@
+package scm
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	git "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+
+	"klonekit/pkg/blueprint"
+)
+
+// pushToBareRemote pushes a scaffold with one file to the main branch of a new bare repository,
+// returning the provider, the scaffold repository and the remote directory
+func pushToBareRemote(t *testing.T) (*GitLabProvider, *git.Repository, string) {
+	t.Helper()
+	scaffoldDir := t.TempDir()
+	remoteDir := t.TempDir()
+	if _, err := git.PlainInit(remoteDir, true); err != nil {
+		t.Fatalf("Failed to create bare remote repository: %s", err)
+	}
+	if err := os.WriteFile(filepath.Join(scaffoldDir, "main.tf"), []byte("# Test Terraform file"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %s", err)
+	}
+
+	provider := &GitLabProvider{token: "test-token"}
+	spec := &blueprint.Spec{
+		Scaffold: blueprint.Scaffold{Destination: scaffoldDir},
+	}
+	if err := provider.initializeAndPushRepo(spec, remoteDir); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	repo, err := git.PlainOpen(scaffoldDir)
+	if err != nil {
+		t.Fatalf("Failed to open scaffold repository: %s", err)
+	}
+	return provider, repo, remoteDir
+}
+
+func TestGitLabProvider_ReportsPushedCommit(t *testing.T) {
+	provider, repo, remoteDir := pushToBareRemote(t)
+
+	pushed := provider.LastPush()
+	if pushed == nil {
+		t.Fatal("Expected the pushed commit to be reported")
+	}
+
+	head, err := repo.Head()
+	if err != nil {
+		t.Fatalf("Failed to read HEAD: %s", err)
+	}
+	remoteRepo, err := git.PlainOpen(remoteDir)
+	if err != nil {
+		t.Fatalf("Failed to open remote repository: %s", err)
+	}
+	remoteRef, err := remoteRepo.Reference(plumbing.NewBranchReferenceName("main"), true)
+	if err != nil {
+		t.Fatalf("Expected the branch on the remote: %s", err)
+	}
+
+	if pushed.CommitHash != head.Hash().String() || pushed.CommitHash != remoteRef.Hash().String() {
+		t.Errorf("Expected the reported hash %s to match the local %s and remote %s commits", pushed.CommitHash, head.Hash(), remoteRef.Hash())
+	}
+	if pushed.RemoteURL != remoteDir {
+		t.Errorf("Expected remote URL %s, got %s", remoteDir, pushed.RemoteURL)
+	}
+	if pushed.Branch != "main" {
+		t.Errorf("Expected branch main, got %s", pushed.Branch)
+	}
+}
+
+func TestConfirmPush_RemoteBranchMismatch(t *testing.T) {
+	_, repo, remoteDir := pushToBareRemote(t)
+
+	// Another push moves the remote branch away from the local commit
+	remoteRepo, err := git.PlainOpen(remoteDir)
+	if err != nil {
+		t.Fatalf("Failed to open remote repository: %s", err)
+	}
+	other := plumbing.NewHash("0123456789abcdef0123456789abcdef01234567")
+	if err := remoteRepo.Storer.SetReference(plumbing.NewHashReference(plumbing.NewBranchReferenceName("main"), other)); err != nil {
+		t.Fatalf("Failed to move remote branch: %s", err)
+	}
+
+	_, err = confirmPush(repo, remoteDir, "main", nil)
+	if err == nil || !strings.Contains(err.Error(), "remote branch 'main' is at "+other.String()) {
+		t.Errorf("Expected a mismatch error naming the remote commit, got: %v", err)
+	}
+
+	// A branch missing from the remote can't be confirmed either
+	if err := remoteRepo.Storer.RemoveReference(plumbing.NewBranchReferenceName("main")); err != nil {
+		t.Fatalf("Failed to delete remote branch: %s", err)
+	}
+	if _, err := confirmPush(repo, remoteDir, "main", nil); err == nil || !strings.Contains(err.Error(), "not found on the remote") {
+		t.Errorf("Expected a missing branch error, got: %v", err)
+	}
+}
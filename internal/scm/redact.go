@@ -0,0 +1,107 @@
+package scm
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	git "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+
+	"klonekit/internal/scaffolder"
+	"klonekit/pkg/blueprint"
+)
+
+// RedactedPlaceholder replaces the value of secret variables in the committed tfvars.
+const RedactedPlaceholder = blueprint.RedactedValue
+
+// redactStagedTfvars replaces the staged tfvars file with a copy whose secret keys
+// hold RedactedPlaceholder. The file on disk keeps the real values for local provisioning;
+// only the version recorded in the index (and therefore pushed) is redacted.
+func redactStagedTfvars(repo *git.Repository, dir string, spec *blueprint.Spec) error {
+	secretKeys := spec.SecretVariables
+	if len(secretKeys) == 0 {
+		return nil
+	}
+
+	tfvarsFileName := spec.Scaffold.TfvarsFile()
+	content, err := os.ReadFile(filepath.Join(dir, tfvarsFileName)) // #nosec G304
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", tfvarsFileName, err)
+	}
+
+	var redacted []byte
+	if strings.HasSuffix(tfvarsFileName, ".json") {
+		redacted, err = redactVariables(content, tfvarsFileName, secretKeys)
+	} else {
+		// HCL tfvars are regenerated from the blueprint variables rather than parsed
+		redacted, err = scaffolder.RenderTfvars(blueprint.MaskSecretVariables(spec.Variables, secretKeys), tfvarsFileName)
+	}
+	if err != nil {
+		return err
+	}
+
+	idx, err := repo.Storer.Index()
+	if err != nil {
+		return fmt.Errorf("failed to read git index: %w", err)
+	}
+	entry, err := idx.Entry(tfvarsFileName)
+	if err != nil {
+		// The file isn't staged (e.g. ignored), so there is nothing to redact
+		return nil
+	}
+
+	hash, err := writeBlob(repo, redacted)
+	if err != nil {
+		return fmt.Errorf("failed to store redacted %s: %w", tfvarsFileName, err)
+	}
+	entry.Hash = hash
+	entry.Size = uint32(len(redacted)) // #nosec G115
+
+	if err := repo.Storer.SetIndex(idx); err != nil {
+		return fmt.Errorf("failed to update git index: %w", err)
+	}
+
+	slog.Info("Redacted secret variables in committed tfvars", "file", tfvarsFileName, "keys", len(secretKeys))
+	return nil
+}
+
+// redactVariables returns the tfvars JSON with the values of the given keys replaced by RedactedPlaceholder.
+func redactVariables(content []byte, tfvarsFileName string, secretKeys []string) ([]byte, error) {
+	var vars map[string]interface{}
+	if err := json.Unmarshal(content, &vars); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", tfvarsFileName, err)
+	}
+
+	redacted, err := json.MarshalIndent(blueprint.MaskSecretVariables(vars, secretKeys), "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal redacted variables: %w", err)
+	}
+	return redacted, nil
+}
+
+// writeBlob stores content as a blob object in the repository and returns its hash.
+func writeBlob(repo *git.Repository, content []byte) (plumbing.Hash, error) {
+	obj := repo.Storer.NewEncodedObject()
+	obj.SetType(plumbing.BlobObject)
+	obj.SetSize(int64(len(content)))
+
+	w, err := obj.Writer()
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+	if _, err := w.Write(content); err != nil {
+		return plumbing.ZeroHash, err
+	}
+	if err := w.Close(); err != nil {
+		return plumbing.ZeroHash, err
+	}
+
+	return repo.Storer.SetEncodedObject(obj)
+}
@@ -0,0 +1,21 @@
+package scm
+
+import gitlab "github.com/xanzy/go-gitlab"
+
+// SetCreatedProject sets the project an interrupted run created, so CreateRepo pushes to it
+// instead of skipping it as already existing.
+func (g *GitLabProvider) SetCreatedProject(project *CreatedProject) {
+	g.createdProject = project
+}
+
+// OnProjectCreated sets a function called with a new project once it is created, before the
+// push, e.g. to record it in the execution state.
+func (g *GitLabProvider) OnProjectCreated(fn func(CreatedProject)) {
+	g.onProjectCreated = fn
+}
+
+// createdByInterruptedRun reports whether an existing project is the one the interrupted run
+// created. The ID is compared, so a project recreated under the same path doesn't match.
+func (g *GitLabProvider) createdByInterruptedRun(project *gitlab.Project) bool {
+	return g.createdProject != nil && g.createdProject.ID == project.ID
+}
@@ -0,0 +1,201 @@
+package scm
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	git "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+
+	"klonekit/pkg/blueprint"
+)
+
+// resumeServer fakes the GitLab API for a project that exists when existingID is set, and
+// is created otherwise, recording whether it was created
+type resumeServer struct {
+	remoteDir  string
+	existingID int
+	created    bool
+}
+
+func (s *resumeServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == "/api/v4/projects/test-user/test-repo" && s.existingID != 0:
+		fmt.Fprintf(w, `{"id":%d,"name":"test-repo","http_url_to_repo":%q}`, s.existingID, s.remoteDir)
+	case r.Method == http.MethodPost && r.URL.Path == "/api/v4/projects":
+		s.created = true
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprintf(w, `{"id":7,"name":"test-repo","http_url_to_repo":%q}`, s.remoteDir)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"message":"404 Project Not Found"}`)
+	}
+}
+
+// newResumeProvider returns a provider for a resumeServer, a bare remote for the project and
+// a scaffold directory to push
+func newResumeProvider(t *testing.T, server *resumeServer) (*GitLabProvider, *blueprint.Spec) {
+	t.Helper()
+
+	server.remoteDir = t.TempDir()
+	if _, err := git.PlainInit(server.remoteDir, true); err != nil {
+		t.Fatalf("Failed to create bare remote repository: %s", err)
+	}
+	scaffoldDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(scaffoldDir, "main.tf"), []byte("# Test Terraform file"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	httpServer := httptest.NewServer(server)
+	t.Cleanup(httpServer.Close)
+	client, err := newGitLabClient("test-token", httpServer.URL+"/api/v4")
+	if err != nil {
+		t.Fatalf("Failed to create test client: %s", err)
+	}
+
+	spec := &blueprint.Spec{
+		SCM:      blueprint.SCMProvider{Project: blueprint.ProjectConfig{Name: "test-repo", Namespace: "test-user"}},
+		Scaffold: blueprint.Scaffold{Destination: scaffoldDir},
+	}
+	return &GitLabProvider{client: client, token: "test-token"}, spec
+}
+
+// remoteHasMain reports whether the scaffolded files were pushed to the remote
+func remoteHasMain(t *testing.T, remoteDir string) bool {
+	t.Helper()
+
+	repo, err := git.PlainOpen(remoteDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = repo.Reference(plumbing.NewBranchReferenceName(blueprint.DefaultBranchName), false)
+	return err == nil
+}
+
+func TestGitLabProvider_CreateRepo_PushesToExistingProject(t *testing.T) {
+	tests := []struct {
+		name    string
+		created *CreatedProject
+	}{
+		{name: "project created by the interrupted run", created: &CreatedProject{ID: 7, Path: "test-user/test-repo"}},
+		{name: "project not created by KloneKit", created: nil},
+		{name: "project recreated under the same path", created: &CreatedProject{ID: 3, Path: "test-user/test-repo"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := &resumeServer{existingID: 7}
+			provider, spec := newResumeProvider(t, server)
+			provider.SetCreatedProject(tt.created)
+
+			if err := provider.CreateRepo(spec); err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			if server.created {
+				t.Error("Expected the existing project not to be created again")
+			}
+			if !remoteHasMain(t, server.remoteDir) {
+				t.Error("Expected the scaffolded files to be pushed to the existing project")
+			}
+		})
+	}
+}
+
+func TestGitLabProvider_CreateRepo_UpdatesExistingProjectInPlace(t *testing.T) {
+	server := &resumeServer{existingID: 7}
+	provider, spec := newResumeProvider(t, server)
+
+	// The existing project has history, and the destination is a clone of it
+	if err := os.RemoveAll(spec.Scaffold.Destination); err != nil {
+		t.Fatal(err)
+	}
+	seed := t.TempDir()
+	seedRepo, err := git.PlainInit(seed, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(seed, "README.md"), []byte("# Managed project"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	seedTree, err := seedRepo.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := seedTree.Add("README.md"); err != nil {
+		t.Fatal(err)
+	}
+	priorCommit, err := seedTree.Commit("Existing history", &git.CommitOptions{
+		Author: &object.Signature{Name: "Test", Email: "test@example.com", When: time.Now()},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := seedRepo.CreateRemote(&config.RemoteConfig{Name: "origin", URLs: []string{server.remoteDir}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := seedRepo.Push(&git.PushOptions{RefSpecs: []config.RefSpec{"refs/heads/master:refs/heads/" + blueprint.DefaultBranchName}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := git.PlainClone(spec.Scaffold.Destination, false, &git.CloneOptions{URL: server.remoteDir, ReferenceName: plumbing.NewBranchReferenceName(blueprint.DefaultBranchName)}); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(spec.Scaffold.Destination, "main.tf"), []byte("# Test Terraform file"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := provider.CreateRepo(spec); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	remoteRepo, err := git.PlainOpen(server.remoteDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ref, err := remoteRepo.Reference(plumbing.NewBranchReferenceName(blueprint.DefaultBranchName), true)
+	if err != nil {
+		t.Fatalf("Expected the branch to be pushed: %s", err)
+	}
+	head, err := remoteRepo.CommitObject(ref.Hash())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(head.ParentHashes) != 1 || head.ParentHashes[0] != priorCommit {
+		t.Errorf("Expected the pushed commit to build on %s, got parents %v", priorCommit, head.ParentHashes)
+	}
+	if _, err := head.File("main.tf"); err != nil {
+		t.Errorf("Expected the scaffolded file in the pushed commit: %s", err)
+	}
+}
+
+func TestGitLabProvider_CreateRepo_RecordsProjectBeforePush(t *testing.T) {
+	server := &resumeServer{}
+	provider, spec := newResumeProvider(t, server)
+
+	var recorded *CreatedProject
+	pushedAtRecord := true
+	provider.OnProjectCreated(func(project CreatedProject) {
+		recorded = &project
+		pushedAtRecord = remoteHasMain(t, server.remoteDir)
+	})
+
+	if err := provider.CreateRepo(spec); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if recorded == nil || recorded.ID != 7 || recorded.Path != "test-user/test-repo" || recorded.URL != server.remoteDir {
+		t.Fatalf("Expected the created project to be recorded, got %+v", recorded)
+	}
+	if pushedAtRecord {
+		t.Error("Expected the project to be recorded before the push")
+	}
+	if !remoteHasMain(t, server.remoteDir) {
+		t.Error("Expected the scaffolded files to be pushed")
+	}
+}
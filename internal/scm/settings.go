@@ -0,0 +1,145 @@
+package scm
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+
+	gitlab "github.com/xanzy/go-gitlab"
+	"golang.org/x/sync/errgroup"
+
+	"klonekit/pkg/blueprint"
+)
+
+// maxConcurrentSettingsCalls bounds the CI variable and webhook requests in flight at once.
+const maxConcurrentSettingsCalls = 4
+
+// configureProject creates the CI variables and webhooks of a new project. The calls don't
+// depend on each other, so they run concurrently; every call is attempted and the failures
+// are returned together, naming the settings that were applied.
+func (g *GitLabProvider) configureProject(projectID int, project blueprint.ProjectConfig) error {
+	if len(project.CIVariables) == 0 && len(project.Webhooks) == 0 {
+		return nil
+	}
+
+	var (
+		mu      sync.Mutex
+		applied []string
+		errs    []error
+	)
+	record := func(name string, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+			return
+		}
+		applied = append(applied, name)
+	}
+
+	var group errgroup.Group
+	group.SetLimit(maxConcurrentSettingsCalls)
+	for _, variable := range project.CIVariables {
+		group.Go(func() error {
+			record("CI variable "+variable.Key, g.createVariable(projectID, variable))
+			return nil
+		})
+	}
+	for _, hook := range project.Webhooks {
+		group.Go(func() error {
+			record("webhook "+webhookHost(hook.URL), g.addWebhook(projectID, hook))
+			return nil
+		})
+	}
+	_ = group.Wait()
+
+	slog.Info("Configured GitLab project settings", "project", projectID, "applied", len(applied), "failed", len(errs))
+	if len(errs) == 0 {
+		return nil
+	}
+
+	done := "none"
+	if len(applied) > 0 {
+		sort.Strings(applied)
+		done = strings.Join(applied, ", ")
+	}
+	return fmt.Errorf("failed to configure %d of %d project settings (applied: %s; add the rest in GitLab manually): %w",
+		len(errs), len(errs)+len(applied), done, errors.Join(errs...))
+}
+
+// createVariable creates a CI/CD variable of the project, given by ID or path. The value is
+// never logged.
+func (g *GitLabProvider) createVariable(projectID interface{}, variable blueprint.CIVariable) error {
+	opts := &gitlab.CreateProjectVariableOptions{
+		Key:       gitlab.String(variable.Key),
+		Value:     gitlab.String(variable.Value),
+		Protected: gitlab.Bool(variable.Protected),
+		Masked:    gitlab.Bool(variable.Masked),
+	}
+	if variable.EnvironmentScope != "" {
+		opts.EnvironmentScope = gitlab.String(variable.EnvironmentScope)
+	}
+
+	err := retryAPI("create GitLab CI variable", func() (*gitlab.Response, error) {
+		_, resp, err := g.client.ProjectVariables.CreateVariable(projectID, opts)
+		return resp, err
+	})
+	if err != nil {
+		return err
+	}
+	slog.Info("Created CI variable", "project", projectID, "key", variable.Key)
+	return nil
+}
+
+// addWebhook adds a project hook triggered by the configured events.
+func (g *GitLabProvider) addWebhook(projectID int, hook blueprint.Webhook) error {
+	events := hook.Events
+	if len(events) == 0 {
+		events = []string{"push"}
+	}
+
+	opts := &gitlab.AddProjectHookOptions{
+		URL:                   gitlab.String(hook.URL),
+		PushEvents:            gitlab.Bool(false),
+		EnableSSLVerification: gitlab.Bool(true),
+	}
+	if hook.Token != "" {
+		opts.Token = gitlab.String(hook.Token)
+	}
+	for _, event := range events {
+		switch event {
+		case "push":
+			opts.PushEvents = gitlab.Bool(true)
+		case "merge_requests":
+			opts.MergeRequestsEvents = gitlab.Bool(true)
+		case "tag_push":
+			opts.TagPushEvents = gitlab.Bool(true)
+		case "pipeline":
+			opts.PipelineEvents = gitlab.Bool(true)
+		}
+	}
+
+	err := retryAPI("add GitLab webhook", func() (*gitlab.Response, error) {
+		_, resp, err := g.client.Projects.AddProjectHook(projectID, opts)
+		return resp, err
+	})
+	if err != nil {
+		return err
+	}
+	slog.Info("Added webhook", "project", projectID, "host", webhookHost(hook.URL), "events", events)
+	return nil
+}
+
+// webhookHost returns the host of a webhook URL for logs and errors, since the path or query
+// of a webhook URL often embeds a secret.
+func webhookHost(rawURL string) string {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || parsed.Host == "" {
+		return "(invalid URL)"
+	}
+	return parsed.Host
+}
@@ -0,0 +1,166 @@
+package scm
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"klonekit/pkg/blueprint"
+)
+
+// settingsServer fakes the GitLab variable and hook endpoints, rejecting the variable keys in
+// failKeys and recording what was created and how many requests were in flight at once
+type settingsServer struct {
+	t        *testing.T
+	failKeys map[string]bool
+
+	mu          sync.Mutex
+	inFlight    int
+	maxInFlight int
+	variables   []string
+	hooks       []string
+}
+
+func (s *settingsServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"message":"404 Not Found"}`)
+		return
+	}
+
+	s.mu.Lock()
+	s.inFlight++
+	s.maxInFlight = max(s.maxInFlight, s.inFlight)
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		s.inFlight--
+		s.mu.Unlock()
+	}()
+
+	// Hold each request briefly so concurrent calls overlap
+	time.Sleep(20 * time.Millisecond)
+
+	var body map[string]interface{}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		s.t.Errorf("Failed to decode request to %s: %s", r.URL.Path, err)
+	}
+
+	switch r.URL.Path {
+	case "/api/v4/projects/1/variables":
+		key, _ := body["key"].(string)
+		if s.failKeys[key] {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"message":{"key":["has already been taken"]}}`)
+			return
+		}
+		s.mu.Lock()
+		s.variables = append(s.variables, key)
+		s.mu.Unlock()
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprintf(w, `{"key":%q}`, key)
+	case "/api/v4/projects/1/hooks":
+		hookURL, _ := body["url"].(string)
+		s.mu.Lock()
+		s.hooks = append(s.hooks, hookURL)
+		s.mu.Unlock()
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprintf(w, `{"id":1,"url":%q}`, hookURL)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"message":"404 Not Found"}`)
+	}
+}
+
+// configureWithServer runs configureProject for project against a settingsServer
+func configureWithServer(t *testing.T, server *settingsServer, project blueprint.ProjectConfig) error {
+	t.Helper()
+
+	httpServer := httptest.NewServer(server)
+	defer httpServer.Close()
+
+	client, err := newGitLabClient("test-token", httpServer.URL+"/api/v4")
+	if err != nil {
+		t.Fatalf("Failed to create test client: %s", err)
+	}
+	provider := &GitLabProvider{client: client, token: "test-token"}
+	return provider.configureProject(1, project)
+}
+
+func settingsProject() blueprint.ProjectConfig {
+	return blueprint.ProjectConfig{
+		CIVariables: []blueprint.CIVariable{
+			{Key: "AWS_ROLE_ARN", Value: "arn:aws:iam::123456789012:role/ci"},
+			{Key: "TF_VAR_environment", Value: "prod"},
+			{Key: "DEPLOY_TOKEN", Value: "s3cr3t", Masked: true, Protected: true},
+			{Key: "STATE_BUCKET", Value: "platform-state"},
+		},
+		Webhooks: []blueprint.Webhook{
+			{URL: "https://chat.example.com/hooks/abc123", Events: []string{"push", "pipeline"}},
+			{URL: "https://ci.example.com/gitlab", Token: "hook-token", Events: []string{"merge_requests"}},
+		},
+	}
+}
+
+func TestConfigureProject_CreatesVariablesAndWebhooksConcurrently(t *testing.T) {
+	server := &settingsServer{t: t}
+	if err := configureWithServer(t, server, settingsProject()); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	sort.Strings(server.variables)
+	want := []string{"AWS_ROLE_ARN", "DEPLOY_TOKEN", "STATE_BUCKET", "TF_VAR_environment"}
+	if strings.Join(server.variables, ",") != strings.Join(want, ",") {
+		t.Errorf("Expected variables %v, got %v", want, server.variables)
+	}
+	if len(server.hooks) != 2 {
+		t.Errorf("Expected 2 webhooks, got %v", server.hooks)
+	}
+	if server.maxInFlight < 2 || server.maxInFlight > maxConcurrentSettingsCalls {
+		t.Errorf("Expected between 2 and %d concurrent requests, got %d", maxConcurrentSettingsCalls, server.maxInFlight)
+	}
+}
+
+func TestConfigureProject_ReportsFailureWithAppliedSettings(t *testing.T) {
+	server := &settingsServer{t: t, failKeys: map[string]bool{"DEPLOY_TOKEN": true}}
+	err := configureWithServer(t, server, settingsProject())
+	if err == nil {
+		t.Fatal("Expected an error for the rejected variable, got nil")
+	}
+
+	// The other settings are still created and named in the error
+	if len(server.variables) != 3 || len(server.hooks) != 2 {
+		t.Errorf("Expected the other settings to be created, got variables %v and hooks %v", server.variables, server.hooks)
+	}
+	for _, want := range []string{
+		"failed to configure 1 of 6 project settings",
+		"CI variable DEPLOY_TOKEN:",
+		"CI variable AWS_ROLE_ARN",
+		"webhook chat.example.com",
+		"webhook ci.example.com",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected the error to contain %q, got: %s", want, err)
+		}
+	}
+	if strings.Contains(err.Error(), "abc123") {
+		t.Errorf("Expected the webhook path to be left out of the error, got: %s", err)
+	}
+}
+
+func TestConfigureProject_NoSettings(t *testing.T) {
+	server := &settingsServer{t: t}
+	if err := configureWithServer(t, server, blueprint.ProjectConfig{}); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if server.maxInFlight != 0 {
+		t.Errorf("Expected no requests without settings, got %d in flight", server.maxInFlight)
+	}
+}
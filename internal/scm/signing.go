@@ -0,0 +1,57 @@
+package scm
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+
+	"klonekit/pkg/blueprint"
+)
+
+// loadSigningKey reads the GPG key that signs KloneKit's commits. It returns nil when
+// signing isn't configured, so commits stay unsigned.
+func loadSigningKey(signing blueprint.CommitSigning) (*openpgp.Entity, error) {
+	var armored io.Reader
+	switch {
+	case signing.KeyFile != "":
+		content, err := os.ReadFile(signing.KeyFile) // #nosec G304
+		if err != nil {
+			return nil, fmt.Errorf("failed to read signing key file: %w", err)
+		}
+		armored = bytes.NewReader(content)
+	case signing.KeyFromEnv != "":
+		content := os.Getenv(signing.KeyFromEnv)
+		if content == "" {
+			return nil, fmt.Errorf("signing key variable %s is not set", signing.KeyFromEnv)
+		}
+		armored = bytes.NewReader([]byte(content))
+	default:
+		return nil, nil
+	}
+
+	keyRing, err := openpgp.ReadArmoredKeyRing(armored)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse signing key: %w", err)
+	}
+	if len(keyRing) == 0 || keyRing[0].PrivateKey == nil {
+		return nil, fmt.Errorf("signing key does not contain a private key")
+	}
+	entity := keyRing[0]
+
+	if entity.PrivateKey.Encrypted {
+		if signing.PassphraseFromEnv == "" {
+			return nil, fmt.Errorf("signing key is encrypted; set spec.scm.signing.passphraseFromEnv")
+		}
+		passphrase := []byte(os.Getenv(signing.PassphraseFromEnv))
+		if err := entity.DecryptPrivateKeys(passphrase); err != nil {
+			return nil, fmt.Errorf("failed to decrypt signing key: %w", err)
+		}
+	}
+
+	slog.Info("Signing commits with GPG key", "keyId", entity.PrimaryKey.KeyIdString())
+	return entity, nil
+}
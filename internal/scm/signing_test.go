@@ -0,0 +1,122 @@
+package scm
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	"github.com/ProtonMail/go-crypto/openpgp/packet"
+	git "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+
+	"klonekit/pkg/blueprint"
+)
+
+// testSigningKey generates a GPG key and returns its armored private and public parts
+func testSigningKey(t *testing.T) (string, string) {
+	t.Helper()
+
+	entity, err := openpgp.NewEntity("KloneKit Test", "", "test@klonekit.dev", &packet.Config{Algorithm: packet.PubKeyAlgoEdDSA})
+	if err != nil {
+		t.Fatalf("Failed to generate signing key: %s", err)
+	}
+
+	var private, public bytes.Buffer
+	w, err := armor.Encode(&private, openpgp.PrivateKeyType, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := entity.SerializePrivate(w, nil); err != nil {
+		t.Fatal(err)
+	}
+	w.Close()
+
+	w, err = armor.Encode(&public, openpgp.PublicKeyType, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := entity.Serialize(w); err != nil {
+		t.Fatal(err)
+	}
+	w.Close()
+
+	return private.String(), public.String()
+}
+
+// pushAndReadHead pushes a scaffold directory to a bare remote and returns the HEAD commit
+func pushAndReadHead(t *testing.T, signing blueprint.CommitSigning) *object.Commit {
+	t.Helper()
+
+	scaffoldDir := t.TempDir()
+	remoteDir := t.TempDir()
+	if _, err := git.PlainInit(remoteDir, true); err != nil {
+		t.Fatalf("Failed to create bare remote repository: %s", err)
+	}
+	if err := os.WriteFile(filepath.Join(scaffoldDir, "main.tf"), []byte("# Test Terraform file"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %s", err)
+	}
+
+	provider := &GitLabProvider{token: "test-token"}
+	spec := &blueprint.Spec{
+		SCM:      blueprint.SCMProvider{Signing: signing},
+		Scaffold: blueprint.Scaffold{Source: "/source/path", Destination: scaffoldDir},
+	}
+	if err := provider.initializeAndPushRepo(spec, remoteDir); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	repo, err := git.PlainOpen(scaffoldDir)
+	if err != nil {
+		t.Fatalf("Failed to open repository: %s", err)
+	}
+	head, err := repo.Head()
+	if err != nil {
+		t.Fatalf("Failed to read HEAD: %s", err)
+	}
+	commit, err := repo.CommitObject(head.Hash())
+	if err != nil {
+		t.Fatalf("Failed to read HEAD commit: %s", err)
+	}
+	return commit
+}
+
+func TestGitLabProvider_initializeAndPushRepo_SignsCommit(t *testing.T) {
+	privateKey, publicKey := testSigningKey(t)
+
+	keyFile := filepath.Join(t.TempDir(), "signing.asc")
+	if err := os.WriteFile(keyFile, []byte(privateKey), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("TEST_SIGNING_KEY", privateKey)
+
+	for name, signing := range map[string]blueprint.CommitSigning{
+		"key file": {KeyFile: keyFile},
+		"key env":  {KeyFromEnv: "TEST_SIGNING_KEY"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			commit := pushAndReadHead(t, signing)
+			if commit.PGPSignature == "" {
+				t.Fatal("Expected the commit to carry a signature")
+			}
+			if _, err := commit.Verify(publicKey); err != nil {
+				t.Errorf("Expected the signature to verify with the signing key: %s", err)
+			}
+		})
+	}
+}
+
+func TestGitLabProvider_initializeAndPushRepo_UnsignedByDefault(t *testing.T) {
+	commit := pushAndReadHead(t, blueprint.CommitSigning{})
+	if commit.PGPSignature != "" {
+		t.Errorf("Expected an unsigned commit, got signature: %s", commit.PGPSignature)
+	}
+}
+
+func TestLoadSigningKey_MissingVariable(t *testing.T) {
+	if _, err := loadSigningKey(blueprint.CommitSigning{KeyFromEnv: "KLONEKIT_TEST_UNSET_SIGNING_KEY"}); err == nil {
+		t.Error("Expected an error when the signing key variable is not set, got nil")
+	}
+}
@@ -0,0 +1,66 @@
+package scm
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/go-git/go-git/v5/plumbing/transport/http"
+	"github.com/go-git/go-git/v5/plumbing/transport/ssh"
+
+	"klonekit/pkg/blueprint"
+)
+
+// SSHKeyEnv names the environment variable holding the path of the private key used to push
+// over SSH when spec.scm.sshKeyPath is not set.
+const SSHKeyEnv = "KLONEKIT_SSH_KEY"
+
+// defaultSSHUser is the user of SSH remotes that don't name one, as GitLab and GitHub expect.
+const defaultSSHUser = "git"
+
+// pushURL returns the URL the scaffolded files are pushed to: the repository's SSH URL when
+// spec.scm.protocol is ssh, and its HTTPS URL otherwise.
+func pushURL(spec *blueprint.Spec, httpsURL, sshURL string) string {
+	if spec.SCM.Protocol == blueprint.SCMProtocolSSH && sshURL != "" {
+		return sshURL
+	}
+	return httpsURL
+}
+
+// isSSHURL reports whether repoURL is an SSH remote, either "ssh://" or scp-like
+// ("git@gitlab.com:group/project.git").
+func isSSHURL(repoURL string) bool {
+	endpoint, err := transport.NewEndpoint(repoURL)
+	return err == nil && endpoint.Protocol == "ssh"
+}
+
+// pushAuth returns the authentication of a push to repoURL: tokenAuth for HTTPS remotes, and
+// for SSH remotes the key at spec.scm.sshKeyPath or KLONEKIT_SSH_KEY, or else the SSH agent.
+func pushAuth(spec *blueprint.Spec, repoURL string, tokenAuth *http.BasicAuth) (transport.AuthMethod, error) {
+	if !isSSHURL(repoURL) {
+		return tokenAuth, nil
+	}
+
+	user := defaultSSHUser
+	if endpoint, err := transport.NewEndpoint(repoURL); err == nil && endpoint.User != "" {
+		user = endpoint.User
+	}
+
+	keyPath := spec.SCM.SSHKeyPath
+	if keyPath == "" {
+		keyPath = os.Getenv(SSHKeyEnv)
+	}
+	if keyPath != "" {
+		auth, err := ssh.NewPublicKeysFromFile(user, keyPath, "")
+		if err != nil {
+			return nil, fmt.Errorf("failed to load SSH key %s: %w", keyPath, err)
+		}
+		return auth, nil
+	}
+
+	auth, err := ssh.NewSSHAgentAuth(user)
+	if err != nil {
+		return nil, fmt.Errorf("failed to use the SSH agent (set spec.scm.sshKeyPath or %s to push with a key file): %w", SSHKeyEnv, err)
+	}
+	return auth, nil
+}
@@ -0,0 +1,130 @@
+package scm
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-git/go-git/v5/plumbing/transport/http"
+	"github.com/go-git/go-git/v5/plumbing/transport/ssh"
+
+	"klonekit/pkg/blueprint"
+)
+
+// testSSHKey writes an unencrypted ed25519 private key and returns its path
+func testSSHKey(t *testing.T) string {
+	t.Helper()
+
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate SSH key: %s", err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "id_ed25519")
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestIsSSHURL(t *testing.T) {
+	tests := []struct {
+		url  string
+		want bool
+	}{
+		{url: "git@gitlab.com:platform/network.git", want: true},
+		{url: "ssh://git@gitlab.example.com:2222/platform/network.git", want: true},
+		{url: "https://gitlab.com/platform/network.git", want: false},
+		{url: "http://gitlab.internal/platform/network.git", want: false},
+		{url: "/tmp/remote.git", want: false},
+	}
+
+	for _, tt := range tests {
+		if got := isSSHURL(tt.url); got != tt.want {
+			t.Errorf("isSSHURL(%q) = %v, want %v", tt.url, got, tt.want)
+		}
+	}
+}
+
+func TestPushURL(t *testing.T) {
+	const httpsURL, sshURL = "https://gitlab.com/platform/network.git", "git@gitlab.com:platform/network.git"
+
+	if got := pushURL(&blueprint.Spec{}, httpsURL, sshURL); got != httpsURL {
+		t.Errorf("Expected HTTPS by default, got %s", got)
+	}
+	sshSpec := &blueprint.Spec{SCM: blueprint.SCMProvider{Protocol: blueprint.SCMProtocolSSH}}
+	if got := pushURL(sshSpec, httpsURL, sshURL); got != sshURL {
+		t.Errorf("Expected the SSH URL with protocol ssh, got %s", got)
+	}
+}
+
+func TestPushAuth(t *testing.T) {
+	keyPath := testSSHKey(t)
+	envKeyPath := testSSHKey(t)
+	tokenAuth := &http.BasicAuth{Username: "oauth2", Password: "token"}
+
+	tests := []struct {
+		name     string
+		url      string
+		spec     blueprint.SCMProvider
+		envKey   string
+		wantUser string
+		wantKey  string
+		wantErr  string
+	}{
+		{name: "https uses the token", url: "https://gitlab.com/platform/network.git"},
+		{name: "key from the blueprint", url: "git@gitlab.com:platform/network.git", spec: blueprint.SCMProvider{SSHKeyPath: keyPath}, envKey: envKeyPath, wantUser: "git", wantKey: keyPath},
+		{name: "key from the environment", url: "git@gitlab.com:platform/network.git", envKey: envKeyPath, wantUser: "git", wantKey: envKeyPath},
+		{name: "user from the URL", url: "ssh://deploy@gitlab.example.com:2222/platform/network.git", spec: blueprint.SCMProvider{SSHKeyPath: keyPath}, wantUser: "deploy", wantKey: keyPath},
+		{name: "missing key file", url: "git@gitlab.com:platform/network.git", spec: blueprint.SCMProvider{SSHKeyPath: filepath.Join(t.TempDir(), "missing")}, wantErr: "failed to load SSH key"},
+		{name: "agent without a key", url: "git@gitlab.com:platform/network.git", wantErr: "failed to use the SSH agent"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(SSHKeyEnv, tt.envKey)
+			// No agent is reachable, so falling back to it fails
+			t.Setenv("SSH_AUTH_SOCK", "")
+
+			auth, err := pushAuth(&blueprint.Spec{SCM: tt.spec}, tt.url, tokenAuth)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Expected an error containing %q, got: %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+
+			if tt.wantKey == "" {
+				if auth != tokenAuth {
+					t.Errorf("Expected the token auth, got %T", auth)
+				}
+				return
+			}
+			keys, ok := auth.(*ssh.PublicKeys)
+			if !ok {
+				t.Fatalf("Expected SSH public key auth, got %T", auth)
+			}
+			if keys.User != tt.wantUser {
+				t.Errorf("Expected user %q, got %q", tt.wantUser, keys.User)
+			}
+			wantKeys, err := ssh.NewPublicKeysFromFile(tt.wantUser, tt.wantKey, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(keys.Signer.PublicKey().Marshal()) != string(wantKeys.Signer.PublicKey().Marshal()) {
+				t.Errorf("Expected the key at %s to be used", tt.wantKey)
+			}
+		})
+	}
+}
@@ -0,0 +1,151 @@
+package scm
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	git "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/format/gitignore"
+)
+
+const (
+	// StagingStrict aborts the push if any file in the scaffold directory cannot be staged.
+	StagingStrict = "strict"
+
+	// StagingBestEffort logs and skips files that cannot be staged while still pushing the rest.
+	StagingBestEffort = "best-effort"
+)
+
+// stageFiles stages the scaffold directory contents in the worktree according to the staging mode.
+func stageFiles(worktree *git.Worktree, dir string, mode string) error {
+	matcher, err := newIgnoreMatcher(worktree)
+	if err != nil {
+		return fmt.Errorf("failed to read ignore patterns: %w", err)
+	}
+
+	if mode == StagingBestEffort {
+		return stageBestEffort(worktree, dir, matcher)
+	}
+
+	// Strict mode: refuse to push if special files (sockets, pipes, devices) are present,
+	// since they can't be committed and usually indicate a stray transient file.
+	if err := walkStageable(dir, matcher, func(relPath string, d fs.DirEntry) error {
+		if !isStageableType(d.Type()) {
+			return fmt.Errorf("cannot stage special file %s (use best-effort staging to skip it)", relPath)
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	if _, err := worktree.Add("."); err != nil {
+		return fmt.Errorf("failed to add files to git: %w", err)
+	}
+	return nil
+}
+
+// stageBestEffort stages each file individually, skipping files that can't be staged.
+func stageBestEffort(worktree *git.Worktree, dir string, matcher gitignore.Matcher) error {
+	var skipped []string
+
+	err := walkStageable(dir, matcher, func(relPath string, d fs.DirEntry) error {
+		if !isStageableType(d.Type()) {
+			slog.Warn("Skipping special file during staging", "path", relPath, "mode", d.Type().String())
+			skipped = append(skipped, relPath)
+			return nil
+		}
+
+		if err := worktree.AddWithOptions(&git.AddOptions{Path: filepath.ToSlash(relPath), SkipStatus: true}); err != nil {
+			slog.Warn("Skipping file that could not be staged", "path", relPath, "error", err)
+			skipped = append(skipped, relPath)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// Unstage tracked files that no longer exist in the scaffold directory
+	if err := removeDeletedFiles(worktree, dir); err != nil {
+		slog.Warn("Failed to unstage deleted files", "error", err)
+	}
+
+	if len(skipped) > 0 {
+		slog.Warn("Best-effort staging skipped files", "count", len(skipped), "files", skipped)
+	}
+	return nil
+}
+
+// removeDeletedFiles removes index entries whose files are no longer present on disk.
+func removeDeletedFiles(worktree *git.Worktree, dir string) error {
+	status, err := worktree.Status()
+	if err != nil {
+		return err
+	}
+
+	for path, fileStatus := range status {
+		if fileStatus.Worktree != git.Deleted {
+			continue
+		}
+		if _, err := os.Lstat(filepath.Join(dir, path)); !errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if _, err := worktree.Remove(path); err != nil {
+			return fmt.Errorf("failed to unstage %s: %w", path, err)
+		}
+	}
+	return nil
+}
+
+// walkStageable walks dir, skipping the .git directory and ignored paths, and calls fn for every non-directory entry.
+func walkStageable(dir string, matcher gitignore.Matcher, fn func(relPath string, d fs.DirEntry) error) error {
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		relPath, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		if relPath == "." {
+			return nil
+		}
+
+		if d.IsDir() {
+			if d.Name() == git.GitDirName || matcher.Match(splitPath(relPath), true) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if matcher.Match(splitPath(relPath), false) {
+			return nil
+		}
+		return fn(relPath, d)
+	})
+}
+
+// newIgnoreMatcher builds a matcher from the worktree's .gitignore files and configured excludes.
+func newIgnoreMatcher(worktree *git.Worktree) (gitignore.Matcher, error) {
+	patterns, err := gitignore.ReadPatterns(worktree.Filesystem, nil)
+	if err != nil {
+		return nil, err
+	}
+	patterns = append(patterns, worktree.Excludes...)
+	return gitignore.NewMatcher(patterns), nil
+}
+
+// isStageableType reports whether a file mode can be committed to git (regular files and symlinks).
+func isStageableType(mode fs.FileMode) bool {
+	return mode.IsRegular() || mode&fs.ModeSymlink != 0
+}
+
+func splitPath(relPath string) []string {
+	return strings.Split(filepath.ToSlash(relPath), "/")
+}
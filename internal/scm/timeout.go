@@ -0,0 +1,71 @@
+package scm
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"klonekit/internal/errors"
+)
+
+const (
+	// DefaultGitLabTimeout is how long a GitLab API request may take unless configured otherwise
+	DefaultGitLabTimeout = 30 * time.Second
+
+	// GitLabTimeoutEnv names the environment variable setting the GitLab API request timeout,
+	// e.g. "1m", when --gitlab-timeout isn't given
+	GitLabTimeoutEnv = "KLONEKIT_GITLAB_TIMEOUT"
+)
+
+var (
+	gitLabTimeoutMu sync.RWMutex
+	gitLabTimeout   = DefaultGitLabTimeout
+)
+
+// SetGitLabTimeout sets how long each GitLab API request may take before it fails. A zero
+// timeout restores DefaultGitLabTimeout.
+func SetGitLabTimeout(timeout time.Duration) error {
+	if timeout < 0 {
+		return fmt.Errorf("GitLab timeout must not be negative, got %s", timeout)
+	}
+	if timeout == 0 {
+		timeout = DefaultGitLabTimeout
+	}
+	gitLabTimeoutMu.Lock()
+	defer gitLabTimeoutMu.Unlock()
+	gitLabTimeout = timeout
+	return nil
+}
+
+// GitLabTimeout returns how long each GitLab API request may take.
+func GitLabTimeout() time.Duration {
+	gitLabTimeoutMu.RLock()
+	defer gitLabTimeoutMu.RUnlock()
+	return gitLabTimeout
+}
+
+// isAPITimeout reports whether err is a GitLab API call that timed out.
+func isAPITimeout(err error) bool {
+	var kloneKitErr *errors.KloneKitError
+	return stderrors.As(err, &kloneKitErr) && kloneKitErr.Type == errors.ErrNetworkFailed
+}
+
+// apiTimeoutError reports a GitLab API call that failed because a request timed out as a
+// network error, and returns other errors as they are.
+func apiTimeoutError(operation string, err error) error {
+	var netErr net.Error
+	timedOut := stderrors.As(err, &netErr) && netErr.Timeout()
+	if err == nil || !timedOut && !stderrors.Is(err, context.DeadlineExceeded) {
+		return err
+	}
+
+	return errors.NewNetworkError(
+		"GitLab API request timed out",
+		fmt.Sprintf("GitLab did not respond to '%s' within %s", operation, GitLabTimeout()),
+		fmt.Sprintf("Check that the GitLab instance is reachable, or raise the timeout with --gitlab-timeout or %s", GitLabTimeoutEnv),
+		fmt.Errorf("%s timed out: %w", operation, err),
+	)
+}
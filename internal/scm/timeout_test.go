@@ -0,0 +1,101 @@
+package scm
+
+import (
+	stderrors "errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"klonekit/internal/errors"
+	"klonekit/internal/retry"
+	"klonekit/pkg/blueprint"
+)
+
+// withGitLabTimeout sets the GitLab API timeout for the test
+func withGitLabTimeout(t *testing.T, timeout time.Duration) {
+	t.Helper()
+	previous := GitLabTimeout()
+	if err := SetGitLabTimeout(timeout); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = SetGitLabTimeout(previous) })
+}
+
+func TestGitLabProvider_CreateRepo_TimesOut(t *testing.T) {
+	withGitLabTimeout(t, 50*time.Millisecond)
+	original := retry.Current()
+	defer retry.SetPolicy(original)
+	if err := retry.SetMaxRetries(1); err != nil {
+		t.Fatal(err)
+	}
+
+	var getCalls, createCalls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The client also probes the API root once for its rate limits
+		switch {
+		case r.Method == http.MethodGet && strings.Contains(r.URL.Path, "/projects/"):
+			getCalls.Add(1)
+		case r.Method == http.MethodPost:
+			createCalls.Add(1)
+		}
+		// Hang until the client gives up
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	}))
+	defer server.Close()
+
+	client, err := newGitLabClient("test-token", server.URL+"/api/v4")
+	if err != nil {
+		t.Fatalf("Failed to create test client: %s", err)
+	}
+	provider := &GitLabProvider{client: client, token: "test-token"}
+	spec := &blueprint.Spec{
+		SCM: blueprint.SCMProvider{
+			Project: blueprint.ProjectConfig{Name: "test-repo", Namespace: "test-user"},
+		},
+	}
+
+	start := time.Now()
+	err = provider.CreateRepo(spec)
+	elapsed := time.Since(start)
+
+	var kloneKitErr *errors.KloneKitError
+	if !stderrors.As(err, &kloneKitErr) || kloneKitErr.Type != errors.ErrNetworkFailed {
+		t.Fatalf("Expected a network error, got: %v", err)
+	}
+	if !strings.Contains(kloneKitErr.Cause, "'get GitLab project' within 50ms") {
+		t.Errorf("Expected the cause to name the call and timeout, got %q", kloneKitErr.Cause)
+	}
+	if got := getCalls.Load(); got != 2 {
+		t.Errorf("Expected the timed out lookup to be retried once, got %d attempts", got)
+	}
+	if got := createCalls.Load(); got != 0 {
+		t.Errorf("Expected no project to be created when the lookup timed out, got %d create calls", got)
+	}
+	if elapsed > 3*time.Second {
+		t.Errorf("Expected the call to fail at the timeout, took %s", elapsed)
+	}
+}
+
+func TestSetGitLabTimeout(t *testing.T) {
+	withGitLabTimeout(t, time.Minute)
+	if got := GitLabTimeout(); got != time.Minute {
+		t.Errorf("Expected a 1m timeout, got %s", got)
+	}
+
+	if err := SetGitLabTimeout(0); err != nil {
+		t.Fatal(err)
+	}
+	if got := GitLabTimeout(); got != DefaultGitLabTimeout {
+		t.Errorf("Expected a zero timeout to restore the default, got %s", got)
+	}
+
+	if err := SetGitLabTimeout(-time.Second); err == nil {
+		t.Error("Expected an error for a negative timeout")
+	}
+}
@@ -0,0 +1,23 @@
+package scm
+
+import "fmt"
+
+// RunIDTrailer is the git trailer linking a commit pushed by KloneKit to the apply run that
+// pushed it.
+const RunIDTrailer = "Klonekit-Run-Id"
+
+// SetRunID sets the apply run recorded in the trailer of the pushed commit.
+func (g *GitLabProvider) SetRunID(runID string) {
+	g.runID = runID
+}
+
+// SetRunID sets the apply run recorded in the trailer of the pushed commit.
+func (g *GitHubProvider) SetRunID(runID string) {
+	g.runID = runID
+}
+
+// withRunIDTrailer appends the run ID trailer to a commit message, separated from the
+// message by a blank line as git expects.
+func withRunIDTrailer(message, runID string) string {
+	return fmt.Sprintf("%s\n\n%s: %s\n", message, RunIDTrailer, runID)
+}
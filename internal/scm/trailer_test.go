@@ -0,0 +1,63 @@
+package scm
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	git "github.com/go-git/go-git/v5"
+
+	"klonekit/pkg/blueprint"
+)
+
+func TestGitLabProvider_initializeAndPushRepo_RunIDTrailer(t *testing.T) {
+	const runID = "0b6f3a52-8c1e-4d7a-9f2b-5e4c3d2a1b0f"
+
+	tests := []struct {
+		name        string
+		runID       string
+		skip        bool
+		wantTrailer bool
+	}{
+		{name: "run ID set", runID: runID, wantTrailer: true},
+		{name: "opted out", runID: runID, skip: true},
+		{name: "outside an apply run"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scaffoldDir := t.TempDir()
+			remoteDir := t.TempDir()
+			if _, err := git.PlainInit(remoteDir, true); err != nil {
+				t.Fatalf("Failed to create bare remote repository: %s", err)
+			}
+			if err := os.WriteFile(filepath.Join(scaffoldDir, "main.tf"), []byte("# Test Terraform file"), 0644); err != nil {
+				t.Fatalf("Failed to create test file: %s", err)
+			}
+
+			provider := &GitLabProvider{token: "test-token"}
+			provider.SetRunID(tt.runID)
+			spec := &blueprint.Spec{
+				SCM:      blueprint.SCMProvider{SkipRunIDTrailer: tt.skip},
+				Scaffold: blueprint.Scaffold{Source: "/source/path", Destination: scaffoldDir},
+			}
+			if err := provider.initializeAndPushRepo(spec, remoteDir); err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+
+			commit := pushedCommit(t, remoteDir, blueprint.DefaultBranchName)
+
+			if !strings.HasPrefix(commit.Message, "Initial commit - scaffolded from KloneKit") {
+				t.Errorf("Expected the scaffold commit message to be kept, got %q", commit.Message)
+			}
+			hasTrailer := strings.Contains(commit.Message, "\n\nKlonekit-Run-Id: "+runID+"\n")
+			if hasTrailer != tt.wantTrailer {
+				t.Errorf("Expected trailer %v, got message %q", tt.wantTrailer, commit.Message)
+			}
+			if !tt.wantTrailer && strings.Contains(commit.Message, RunIDTrailer) {
+				t.Errorf("Expected no run ID trailer, got message %q", commit.Message)
+			}
+		})
+	}
+}
@@ -9,4 +9,49 @@ type ScmProvider interface {
 	// CreateRepo creates a repository based on the blueprint specification.
 	// It handles repository creation, initialization, and pushing scaffolded files.
 	CreateRepo(spec *blueprint.Spec) error
-}
\ No newline at end of file
+}
+
+// CreatedProject identifies a repository KloneKit created. It is recorded before the push, so
+// a run interrupted between creating the repository and pushing to it can resume the push.
+type CreatedProject struct {
+	ID   int    `json:"id"`
+	Path string `json:"path"`
+	URL  string `json:"url"`
+}
+
+// ResumableProvider is implemented by SCM providers that can resume the push to a repository
+// created by an interrupted run, instead of skipping it as already existing.
+type ResumableProvider interface {
+	// SetCreatedProject sets the repository created by the interrupted run, or nil.
+	SetCreatedProject(project *CreatedProject)
+	// OnProjectCreated sets a function called with a new repository before the push.
+	OnProjectCreated(fn func(CreatedProject))
+}
+
+// PushResult identifies the commit KloneKit pushed, confirmed against the remote branch, so
+// users have a verifiable reference to it.
+type PushResult struct {
+	CommitHash string `json:"commit_hash"`
+	RemoteURL  string `json:"remote_url"`
+	Branch     string `json:"branch"`
+}
+
+// PushReporter is implemented by SCM providers that report the commit they pushed.
+type PushReporter interface {
+	// LastPush returns the commit pushed by the last CreateRepo, or nil if nothing was pushed,
+	// e.g. because the repository already existed.
+	LastPush() *PushResult
+}
+
+// RunScoped is implemented by SCM providers that link the commits they push to a run.
+type RunScoped interface {
+	// SetRunID sets the ID of the run recorded in the commit trailer.
+	SetRunID(runID string)
+}
+
+// VariableExporter is implemented by SCM providers that can write CI/CD variables to an
+// existing repository, e.g. to export Terraform outputs after provisioning.
+type VariableExporter interface {
+	// ExportVariables creates the variables in the spec's repository, updating those that exist.
+	ExportVariables(spec *blueprint.Spec, variables []blueprint.CIVariable) error
+}
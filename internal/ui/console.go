@@ -25,17 +25,78 @@ const (
 	colorBold   = "\033[1m"
 )
 
+// NoColorEnv disables colored output when set to a non-empty value (see https://no-color.org).
+const NoColorEnv = "NO_COLOR"
+
+// ColorMode selects when output is colored, as set with --color.
+type ColorMode string
+
+const (
+	// ColorAuto colors output on a terminal unless NO_COLOR is set.
+	ColorAuto ColorMode = "auto"
+	// ColorAlways colors output even when it is piped or redirected, and despite NO_COLOR.
+	ColorAlways ColorMode = "always"
+	// ColorNever prints plain text, even on a terminal.
+	ColorNever ColorMode = "never"
+)
+
+// colorMode is set with --color, or --no-color for ColorNever
+var colorMode = ColorAuto
+
+// ParseColorMode parses a --color value.
+func ParseColorMode(value string) (ColorMode, error) {
+	switch mode := ColorMode(value); mode {
+	case ColorAuto, ColorAlways, ColorNever:
+		return mode, nil
+	}
+	return "", fmt.Errorf("invalid color mode %q: must be %s, %s or %s", value, ColorAuto, ColorAlways, ColorNever)
+}
+
+// SetColorMode sets when output is colored.
+func SetColorMode(mode ColorMode) {
+	colorMode = mode
+}
+
+// SetColorsDisabled disables colored output even on a terminal, e.g. for --no-color; it is
+// the same as SetColorMode(ColorNever).
+func SetColorsDisabled(disabled bool) {
+	if disabled {
+		colorMode = ColorNever
+	} else {
+		colorMode = ColorAuto
+	}
+}
+
+// ColorsDisabled reports whether colored output was disabled with --color=never, --no-color
+// or NO_COLOR.
+func ColorsDisabled() bool {
+	return colorMode == ColorNever || (colorMode == ColorAuto && os.Getenv(NoColorEnv) != "")
+}
+
+// ColorsEnabled reports whether output is colored under the color mode: always, never, or
+// in auto mode when writing to a terminal and colors were not disabled with NO_COLOR.
+func ColorsEnabled() bool {
+	switch colorMode {
+	case ColorAlways:
+		return true
+	case ColorNever:
+		return false
+	}
+	return !ColorsDisabled() && isTerminal()
+}
+
 type Console struct {
 	useColors bool
 }
 
 func NewConsole() *Console {
 	return &Console{
-		useColors: isTerminal(),
+		useColors: ColorsEnabled(),
 	}
 }
 
-func isTerminal() bool {
+// isTerminal reports whether stderr is a terminal; tests replace it to simulate one
+var isTerminal = func() bool {
 	stat, _ := os.Stderr.Stat() // #nosec G104
 	return (stat.Mode() & os.ModeCharDevice) != 0
 }
@@ -1,6 +1,8 @@
 package ui
 
 import (
+	"io"
+	"os"
 	"strings"
 	"testing"
 )
@@ -56,6 +58,138 @@ func TestConsole_formatMessage_NoColors(t *testing.T) {
 	}
 }
 
+func TestColorsDisabled(t *testing.T) {
+	tests := []struct {
+		name     string
+		noColor  string
+		flag     bool
+		disabled bool
+	}{
+		{name: "enabled by default"},
+		{name: "NO_COLOR set", noColor: "1", disabled: true},
+		{name: "--no-color", flag: true, disabled: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Setenv(NoColorEnv, test.noColor)
+			SetColorsDisabled(test.flag)
+			defer SetColorsDisabled(false)
+
+			if got := ColorsDisabled(); got != test.disabled {
+				t.Errorf("ColorsDisabled() = %v, want %v", got, test.disabled)
+			}
+			if test.disabled && NewConsole().useColors {
+				t.Error("NewConsole() should not use colors when they are disabled")
+			}
+		})
+	}
+}
+
+// withTerminal runs the test as if stderr were a terminal or not
+func withTerminal(t *testing.T, terminal bool) {
+	t.Helper()
+	previous := isTerminal
+	isTerminal = func() bool { return terminal }
+	t.Cleanup(func() { isTerminal = previous })
+}
+
+// captureStderr returns what fn writes to stderr, which is a pipe rather than a terminal
+func captureStderr(t *testing.T, fn func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	previous := os.Stderr
+	os.Stderr = w
+	fn()
+	os.Stderr = previous
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	output, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(output)
+}
+
+func TestParseColorMode(t *testing.T) {
+	for _, value := range []string{"auto", "always", "never"} {
+		if mode, err := ParseColorMode(value); err != nil || string(mode) != value {
+			t.Errorf("ParseColorMode(%q) = %q, %v", value, mode, err)
+		}
+	}
+	if _, err := ParseColorMode("sometimes"); err == nil || !strings.Contains(err.Error(), "auto, always or never") {
+		t.Errorf("ParseColorMode(\"sometimes\") should fail listing the valid modes, got %v", err)
+	}
+}
+
+func TestColorsEnabled(t *testing.T) {
+	tests := []struct {
+		name     string
+		mode     ColorMode
+		terminal bool
+		noColor  string
+		enabled  bool
+	}{
+		{name: "auto on a terminal", mode: ColorAuto, terminal: true, enabled: true},
+		{name: "auto when piped", mode: ColorAuto},
+		{name: "auto with NO_COLOR", mode: ColorAuto, terminal: true, noColor: "1"},
+		{name: "always when piped", mode: ColorAlways, enabled: true},
+		{name: "always with NO_COLOR", mode: ColorAlways, noColor: "1", enabled: true},
+		{name: "never on a terminal", mode: ColorNever, terminal: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			withTerminal(t, test.terminal)
+			t.Setenv(NoColorEnv, test.noColor)
+			SetColorMode(test.mode)
+			defer SetColorMode(ColorAuto)
+
+			if got := ColorsEnabled(); got != test.enabled {
+				t.Errorf("ColorsEnabled() = %v, want %v", got, test.enabled)
+			}
+		})
+	}
+}
+
+func TestConsole_ColorAlwaysWhenPiped(t *testing.T) {
+	SetColorMode(ColorAlways)
+	defer SetColorMode(ColorAuto)
+
+	output := captureStderr(t, func() { NewConsole().PrintError("boom") })
+
+	if !strings.Contains(output, colorRed) || !strings.Contains(output, colorReset) {
+		t.Errorf("--color=always should print ANSI colors to a pipe, got %q", output)
+	}
+}
+
+func TestConsole_ColorNeverOnTerminal(t *testing.T) {
+	withTerminal(t, true)
+	SetColorMode(ColorNever)
+	defer SetColorMode(ColorAuto)
+
+	output := captureStderr(t, func() { NewConsole().PrintWarning("careful") })
+
+	if output != "Warning: careful\n" {
+		t.Errorf("--color=never should print plain text on a terminal, got %q", output)
+	}
+}
+
+func TestConsole_formatMessage_ColorsDisabled(t *testing.T) {
+	t.Setenv(NoColorEnv, "1")
+
+	console := NewConsole()
+	for _, style := range []ConsoleStyle{StyleError, StyleWarning, StyleSuccess, StyleInfo} {
+		if result := console.formatMessage(style, "message"); result != "message" {
+			t.Errorf("formatMessage(%v) with NO_COLOR set = %q, want plain text", style, result)
+		}
+	}
+}
+
 func TestConsole_FormatErrorMessage(t *testing.T) {
 	console := NewConsole()
 
@@ -0,0 +1,192 @@
+package blueprint
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// DefaultTfvarsFilename is the variables file written when scaffold.tfvarsFilename is not set.
+const DefaultTfvarsFilename = "terraform.tfvars.json"
+
+// DefaultManifestFilename names the scaffold manifest written next to the destination when
+// scaffold.manifest is not set, after the destination: build/infra has its manifest in
+// build/infra.klonekit.manifest.json.
+const DefaultManifestFilename = "klonekit.manifest.json"
+
+// DefaultBranchName is the branch the scaffolded files are pushed to when
+// scm.project.defaultBranch is not set.
+const DefaultBranchName = "main"
+
+// DefaultMaxOutputBytes is the output shown per Terraform command when
+// provision.maxOutputBytes is not set.
+const DefaultMaxOutputBytes int64 = 64 << 20
+
+// Variables modes of provision.variablesMode.
+const (
+	VariablesModeTfvars = "tfvars"
+	VariablesModeFlags  = "flags"
+	VariablesModeBoth   = "both"
+)
+
+// Push protocols of scm.protocol.
+const (
+	SCMProtocolHTTPS = "https"
+	SCMProtocolSSH   = "ssh"
+)
+
+// Backend migration modes of provision.backendMigration.
+const (
+	BackendMigrationMigrate     = "migrate"
+	BackendMigrationReconfigure = "reconfigure"
+)
+
+// TerraformVersionPattern matches a semantic version with an optional pre-release suffix, such
+// as 1.8.0 or 1.9.0-beta2.
+const TerraformVersionPattern = `^(0|[1-9][0-9]*)\.(0|[1-9][0-9]*)\.(0|[1-9][0-9]*)(-[0-9A-Za-z]+(\.[0-9A-Za-z]+)*)?$`
+
+var terraformVersionPattern = regexp.MustCompile(TerraformVersionPattern)
+
+// IsTerraformVersion reports whether version can select a Terraform image. Versions become
+// image tags, so nothing but a semantic version is accepted.
+func IsTerraformVersion(version string) bool {
+	return terraformVersionPattern.MatchString(version)
+}
+
+// TfvarsFile returns the configured variables filename, falling back to DefaultTfvarsFilename.
+func (s Scaffold) TfvarsFile() string {
+	if s.TfvarsFilename != "" {
+		return s.TfvarsFilename
+	}
+	return DefaultTfvarsFilename
+}
+
+// VariableKey returns the key of the CI/CD variable the output is exported as.
+func (o ExportedOutput) VariableKey() string {
+	if o.Key != "" {
+		return o.Key
+	}
+	return o.Output
+}
+
+// ManifestPath returns the configured manifest path, falling back to a file named after the
+// destination with DefaultManifestFilename in its parent directory. Keying the default on the
+// destination keeps sibling destinations from sharing a manifest, and placing it outside the
+// destination keeps it out of the pushed tree.
+func (s Scaffold) ManifestPath() string {
+	if s.Manifest != "" {
+		return s.Manifest
+	}
+	destination := filepath.Clean(s.Destination)
+	// A destination such as "." or ".." is resolved to find its name and parent
+	if base := filepath.Base(destination); base == "." || base == ".." {
+		if abs, err := filepath.Abs(destination); err == nil {
+			destination = abs
+		}
+	}
+	return filepath.Join(filepath.Dir(destination), filepath.Base(destination)+"."+DefaultManifestFilename)
+}
+
+// Configured reports whether the blueprint publishes to an SCM provider.
+func (s SCMProvider) Configured() bool {
+	return s.Provider != ""
+}
+
+// RedactedValue replaces the values of secret variables wherever they are printed or committed.
+const RedactedValue = "REDACTED"
+
+// MaskSecretVariables returns a copy of variables with the values of the given secret keys
+// replaced by RedactedValue. Secret keys without a value stay absent.
+func MaskSecretVariables(variables map[string]interface{}, secretKeys []string) map[string]interface{} {
+	masked := make(map[string]interface{}, len(variables))
+	for key, value := range variables {
+		masked[key] = value
+	}
+	for _, key := range secretKeys {
+		if _, ok := masked[key]; ok {
+			masked[key] = RedactedValue
+		}
+	}
+	return masked
+}
+
+// RedactedURL returns the push URL for logging, with the credentials of its userinfo replaced
+// by "xxxxx". A URL that doesn't parse is replaced as a whole.
+func (s StatePush) RedactedURL() string {
+	parsed, err := url.Parse(s.URL)
+	if err != nil {
+		return "xxxxx"
+	}
+	if parsed.User != nil {
+		if _, hasPassword := parsed.User.Password(); !hasPassword {
+			// A lone username, such as https://TOKEN@host, is the credential itself
+			parsed.User = url.User("xxxxx")
+		}
+	}
+	return parsed.Redacted()
+}
+
+// TfvarsAutoLoaded reports whether Terraform loads the variables file on its own, i.e. it is
+// terraform.tfvars(.json) or *.auto.tfvars(.json); other names need an explicit -var-file.
+func (s Scaffold) TfvarsAutoLoaded() bool {
+	name := s.TfvarsFile()
+	return name == "terraform.tfvars" || name == "terraform.tfvars.json" ||
+		strings.HasSuffix(name, ".auto.tfvars") || strings.HasSuffix(name, ".auto.tfvars.json")
+}
+
+// WritesTfvars reports whether the variables are written to the tfvars file.
+func (p Provision) WritesTfvars() bool {
+	return p.VariablesMode != VariablesModeFlags
+}
+
+// PassesVarFlags reports whether the variables are passed to Terraform as -var flags.
+func (p Provision) PassesVarFlags() bool {
+	return p.VariablesMode == VariablesModeFlags || p.VariablesMode == VariablesModeBoth
+}
+
+// OutputLimit returns how many bytes of each Terraform command's output are shown.
+func (p Provision) OutputLimit() int64 {
+	if p.MaxOutputBytes > 0 {
+		return p.MaxOutputBytes
+	}
+	return DefaultMaxOutputBytes
+}
+
+// RunTimeout returns how long a provisioning run may take, or 0 when it has no timeout.
+// The timeout is validated as a duration when the blueprint is parsed.
+func (p Provision) RunTimeout() time.Duration {
+	timeout, err := time.ParseDuration(p.Timeout)
+	if err != nil {
+		return 0
+	}
+	return timeout
+}
+
+// LFS reports whether Git LFS is enabled for the project. It is enabled unless lfsEnabled is false.
+func (p ProjectConfig) LFS() bool {
+	return p.LFSEnabled == nil || *p.LFSEnabled
+}
+
+// Branch returns the branch the scaffolded files are pushed to.
+func (p ProjectConfig) Branch() string {
+	if p.DefaultBranch != "" {
+		return p.DefaultBranch
+	}
+	return DefaultBranchName
+}
+
+// Resolve returns the value of the variable, reading it from the host environment for FromEnv.
+func (e EnvVar) Resolve() (string, error) {
+	if e.FromEnv == "" {
+		return e.Value, nil
+	}
+	value, ok := os.LookupEnv(e.FromEnv)
+	if !ok {
+		return "", fmt.Errorf("host environment variable %s is not set", e.FromEnv)
+	}
+	return value, nil
+}
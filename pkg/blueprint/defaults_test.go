@@ -0,0 +1,18 @@
+package blueprint
+
+import "testing"
+
+func TestMaskSecretVariables(t *testing.T) {
+	variables := map[string]interface{}{"db_password": "hunter2", "region": "us-east-1"}
+
+	masked := MaskSecretVariables(variables, []string{"db_password", "unset"})
+	if masked["db_password"] != RedactedValue || masked["region"] != "us-east-1" {
+		t.Errorf("Expected only secret values to be masked, got %v", masked)
+	}
+	if _, ok := masked["unset"]; ok {
+		t.Error("Expected secret keys without a value to stay absent")
+	}
+	if variables["db_password"] != "hunter2" {
+		t.Error("Expected the original variables to be left untouched")
+	}
+}
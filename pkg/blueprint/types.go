@@ -18,36 +18,348 @@ type Metadata struct {
 
 // Spec contains the detailed specifications for the orchestration.
 type Spec struct {
-	SCM       SCMProvider            `yaml:"scm" validate:"required"`
+	// SCM may be omitted to scaffold and provision without publishing the files; the scm
+	// stage is then skipped.
+	SCM       SCMProvider            `yaml:"scm,omitempty" validate:"omitempty"`
 	Cloud     CloudProvider          `yaml:"cloud" validate:"required"`
 	Scaffold  Scaffold               `yaml:"scaffold" validate:"required"`
+	Provision Provision              `yaml:"provision,omitempty"`
 	Variables map[string]interface{} `yaml:"variables,omitempty"`
+	// SecretVariables lists variable keys whose values are written to the local tfvars
+	// but replaced with a placeholder in the version pushed to SCM, dry runs and warnings.
+	SecretVariables []string `yaml:"secretVariables,omitempty"`
+	// Decryption decrypts variable values stored encrypted in the blueprint with the
+	// "sops:" prefix. Decrypted variables are treated as secret variables.
+	Decryption Decryption `yaml:"decryption,omitempty"`
+	// StageEnv holds environment variables for a single stage, keyed by stage name: the
+	// scaffold and scm stages run with them set in the environment their clients read (e.g.
+	// GITLAB_PRIVATE_TOKEN), and the provision stage passes them to its containers.
+	StageEnv map[string][]EnvVar `yaml:"stageEnv,omitempty" validate:"omitempty,dive,keys,oneof=scaffold scm provision,endkeys,dive"`
+}
+
+// Decryption defines the command that decrypts encrypted variable values. The value, without
+// its "sops:" prefix, is passed on stdin and the plaintext is read from stdout.
+type Decryption struct {
+	Command []string `yaml:"command,omitempty"`
 }
 
 // SCMProvider configuration for the Source Control Management provider.
 type SCMProvider struct {
-	Provider string        `yaml:"provider" validate:"required,oneof=gitlab"`
+	Provider string        `yaml:"provider" validate:"required,oneof=gitlab github"`
 	URL      string        `yaml:"url" validate:"required,url"`
 	Token    string        `yaml:"token" validate:"required"`
 	Project  ProjectConfig `yaml:"project" validate:"required"`
+	// Staging controls how files are staged before the push: "strict" (default) fails on any
+	// file that can't be staged, "best-effort" logs and skips such files.
+	Staging string `yaml:"staging,omitempty" validate:"omitempty,oneof=strict best-effort"`
+	// Signing GPG-signs the commits pushed by KloneKit, for projects requiring signed commits.
+	// Commits are unsigned when no key is configured.
+	Signing CommitSigning `yaml:"signing,omitempty"`
+	// Commit sets the author and message of the commits pushed by KloneKit, for audit.
+	Commit CommitConfig `yaml:"commit,omitempty"`
+	// Protocol selects how the scaffolded files are pushed: "https" (default) with the API
+	// token, or "ssh" with SSHKeyPath, falling back to the SSH agent.
+	Protocol string `yaml:"protocol,omitempty" validate:"omitempty,oneof=https ssh"`
+	// SSHKeyPath is the private key pushes over SSH use, e.g. a deploy key. The
+	// KLONEKIT_SSH_KEY environment variable is used when it is not set.
+	SSHKeyPath string `yaml:"sshKeyPath,omitempty"`
+	// SkipRunIDTrailer leaves out the Klonekit-Run-Id trailer linking the commits pushed by
+	// an apply run back to the run.
+	SkipRunIDTrailer bool `yaml:"skipRunIdTrailer,omitempty"`
+}
+
+// CommitConfig overrides the author and message of the commits pushed by KloneKit. Unset
+// fields keep the defaults: "KloneKit <noreply@klonekit.dev>" and a message describing
+// whether the repository was created or updated.
+type CommitConfig struct {
+	Author  string `yaml:"author,omitempty"`
+	Email   string `yaml:"email,omitempty" validate:"omitempty,email"`
+	Message string `yaml:"message,omitempty"`
+}
+
+// CommitSigning defines the GPG key used to sign commits. The key is read from KeyFile or
+// from the host environment variable named by KeyFromEnv, in ASCII-armored form.
+type CommitSigning struct {
+	KeyFile    string `yaml:"keyFile,omitempty" validate:"excluded_with=KeyFromEnv"`
+	KeyFromEnv string `yaml:"keyFromEnv,omitempty"`
+	// PassphraseFromEnv names a host environment variable holding the key's passphrase,
+	// for encrypted keys.
+	PassphraseFromEnv string `yaml:"passphraseFromEnv,omitempty"`
 }
 
 // ProjectConfig defines the SCM project configuration.
 type ProjectConfig struct {
+	// Name and Namespace may be templates over the blueprint metadata and non-secret
+	// variables, e.g. "{{ .Metadata.Name }}-infra" or "platform/{{ .Variables.team }}".
+	// They are expanded when the blueprint is parsed, and checked against the path rules of
+	// the SCM provider: a GitLab project path and group, or a GitHub repository and owner.
 	Name        string `yaml:"name" validate:"required"`
 	Namespace   string `yaml:"namespace" validate:"required"`
 	Description string `yaml:"description"`
 	Visibility  string `yaml:"visibility" validate:"oneof=private public internal"`
+	// DefaultBranch is the project's default branch (default "main"). The scaffolded files
+	// are committed on it and pushed to it, so the first push creates it.
+	DefaultBranch string `yaml:"defaultBranch,omitempty"`
+	// LFSEnabled enables Git LFS for the project (default true).
+	LFSEnabled *bool `yaml:"lfsEnabled,omitempty"`
+	// RequestAccessEnabled lets users request access to the project (default false).
+	RequestAccessEnabled bool `yaml:"requestAccessEnabled,omitempty"`
+	// BranchProtection protects branches of a newly created project once the initial push
+	// has completed.
+	BranchProtection BranchProtection `yaml:"branchProtection,omitempty"`
+	// CIVariables are created as CI/CD variables of a newly created project.
+	CIVariables []CIVariable `yaml:"ciVariables,omitempty" validate:"omitempty,dive"`
+	// Webhooks are added to a newly created project.
+	Webhooks []Webhook `yaml:"webhooks,omitempty" validate:"omitempty,dive"`
+	// ExportOutputs writes Terraform outputs back to the project as CI/CD variables after
+	// each apply, so downstream pipelines can use them. Only GitLab projects support them.
+	ExportOutputs []ExportedOutput `yaml:"exportOutputs,omitempty" validate:"omitempty,dive"`
+}
+
+// CIVariable defines a CI/CD variable of the project.
+type CIVariable struct {
+	Key       string `yaml:"key" validate:"required"`
+	Value     string `yaml:"value"`
+	Protected bool   `yaml:"protected,omitempty"`
+	Masked    bool   `yaml:"masked,omitempty"`
+	// EnvironmentScope limits the variable to matching environments (default "*").
+	EnvironmentScope string `yaml:"environmentScope,omitempty"`
+}
+
+// ExportedOutput names a Terraform output exported as a CI/CD variable. Key defaults to the
+// output name. Sensitive outputs are only exported as masked variables.
+type ExportedOutput struct {
+	Output    string `yaml:"output" validate:"required"`
+	Key       string `yaml:"key,omitempty"`
+	Protected bool   `yaml:"protected,omitempty"`
+	Masked    bool   `yaml:"masked,omitempty"`
+	// EnvironmentScope limits the variable to matching environments (default "*").
+	EnvironmentScope string `yaml:"environmentScope,omitempty"`
+}
+
+// Webhook defines a project hook. Events selects the triggers: push, merge_requests,
+// tag_push and pipeline (default push).
+type Webhook struct {
+	URL    string   `yaml:"url" validate:"required,url"`
+	Token  string   `yaml:"token,omitempty"`
+	Events []string `yaml:"events,omitempty" validate:"omitempty,dive,oneof=push merge_requests tag_push pipeline"`
+}
+
+// BranchProtection defines the branches protected after the initial push. Protection is only
+// applied once the push has succeeded and GitLab reports the branch, so it can't block the push.
+type BranchProtection struct {
+	Branches []string `yaml:"branches,omitempty"`
+	// WaitSeconds is how long to wait for a pushed branch to appear in GitLab before
+	// protecting it (default 30).
+	WaitSeconds int `yaml:"waitSeconds,omitempty" validate:"gte=0"`
 }
 
 // CloudProvider configuration for the Cloud provider.
 type CloudProvider struct {
 	Provider string `yaml:"provider" validate:"required,oneof=aws"`
 	Region   string `yaml:"region" validate:"required"`
+	// Profile selects a named profile from the mounted ~/.aws files instead of the default one.
+	Profile string `yaml:"profile,omitempty"`
 }
 
-// Scaffold configuration for the file scaffolding process.
+// Scaffold configuration for the file scaffolding process. In a blueprint, spec.scaffold may
+// also be a list of modules, each with a source and destination; the parser turns it into
+// targets below the modules' common parent directory.
 type Scaffold struct {
-	Source      string `yaml:"source" validate:"required"`
+	// Source is a local module directory or the name of a built-in template (e.g. "aws-vpc", "s3-bucket").
+	// It may be omitted when Targets scaffold every root module, if the provision stage, which
+	// runs in the destination alone, is skipped.
+	Source      string `yaml:"source" validate:"required_without=Targets"`
 	Destination string `yaml:"destination" validate:"required"`
+	// Targets scaffolds further Terraform root modules into subdirectories of the destination,
+	// for repositories holding several roots (e.g. infra/network and infra/compute). They are
+	// pushed with the rest of the destination; the provision stage only runs in the destination.
+	Targets []ScaffoldTarget `yaml:"targets,omitempty" validate:"omitempty,unique=Name,dive"`
+	// RequiredProviders generates a versions.tf with these provider constraints when the
+	// source module doesn't declare a required_providers block.
+	RequiredProviders map[string]ProviderRequirement `yaml:"requiredProviders,omitempty" validate:"omitempty,dive"`
+	// GenerateProvider generates a provider.tf configuring cloud.provider with cloud.region
+	// when the source module doesn't declare a provider block for it. An existing provider.tf
+	// is kept.
+	GenerateProvider bool `yaml:"generateProvider,omitempty"`
+	// TfvarsFilename is the name of the generated variables file (default "terraform.tfvars.json").
+	// Names ending in .tfvars are written in HCL syntax, .tfvars.json in JSON.
+	TfvarsFilename string `yaml:"tfvarsFilename,omitempty" validate:"omitempty,tfvarsfilename"`
+	// StrictVariables fails scaffolding when a blueprint variable isn't declared by the
+	// source module, instead of only logging a warning.
+	StrictVariables bool `yaml:"strictVariables,omitempty"`
+	// TaskFile generates a "makefile" or "justfile" with init/plan/apply targets wrapping
+	// terraform for the configured region. An existing file of that name is kept.
+	TaskFile string `yaml:"taskFile,omitempty" validate:"omitempty,oneof=makefile justfile"`
+	// GitLabCI generates a .gitlab-ci.yml running terraform validate and plan with the
+	// Terraform image used for provisioning. An existing .gitlab-ci.yml is kept.
+	GitLabCI bool `yaml:"gitlabCI,omitempty"`
+	// RespectGitignore skips the files ignored by the .gitignore files of a local source
+	// module, and its .git directory, when copying it to the destination. Terraform artifacts
+	// and the paths listed in the module's .klonekitignore are always skipped.
+	RespectGitignore bool `yaml:"respectGitignore,omitempty"`
+	// SkipSourceCheck disables the check that a local source module has Terraform files in
+	// its root directory, for sources holding only modules in subdirectories.
+	SkipSourceCheck bool `yaml:"skipSourceCheck,omitempty"`
+	// Manifest is the path of the manifest listing every scaffolded file with its size and
+	// SHA-256 hash (default <destination>.klonekit.manifest.json next to the destination, so
+	// it isn't pushed).
+	Manifest string `yaml:"manifest,omitempty"`
+}
+
+// ScaffoldTarget is a Terraform root module scaffolded into a subdirectory of the destination.
+type ScaffoldTarget struct {
+	Name string `yaml:"name" validate:"required"`
+	// Source is a local module directory or the name of a built-in template.
+	Source string `yaml:"source" validate:"required"`
+	// Destination is the target's directory relative to scaffold.destination, e.g. "infra/network".
+	Destination string `yaml:"destination" validate:"required"`
+	// Variables are written to the target's tfvars file in addition to spec.variables,
+	// replacing those with the same key.
+	Variables map[string]interface{} `yaml:"variables,omitempty"`
+}
+
+// ProviderRequirement defines the source and version constraint of a Terraform provider.
+type ProviderRequirement struct {
+	Source  string `yaml:"source" validate:"required"`
+	Version string `yaml:"version,omitempty"`
+}
+
+// Provision configuration for the containerized Terraform execution.
+type Provision struct {
+	// Image overrides the Terraform container image chosen for the cloud provider.
+	Image string `yaml:"image,omitempty" validate:"excluded_with=Terraform.Version"`
+	// Engine is the infrastructure-as-code tool to provision with: "terraform" (default)
+	// or "opentofu", which runs tofu from the ghcr.io/opentofu/opentofu image.
+	Engine string `yaml:"engine,omitempty" validate:"omitempty,oneof=terraform opentofu"`
+	// Entrypoint is the command each Terraform command runs in the container, with the
+	// Terraform arguments appended, e.g. a wrapper script of a custom image. It defaults to
+	// the engine binary, which replaces the image's own entrypoint.
+	Entrypoint []string `yaml:"entrypoint,omitempty"`
+	// Terraform selects the Terraform (or OpenTofu) version to provision with.
+	Terraform TerraformConfig `yaml:"terraform,omitempty"`
+	// DataDir is an optional host directory mounted as the Terraform data directory (TF_DATA_DIR),
+	// so provider plugins and modules persist between runs.
+	DataDir string `yaml:"dataDir,omitempty"`
+	// NetworkMode is the Docker network the Terraform container joins ("default", "host",
+	// "bridge", "none", "container:<name>" or a named network). Defaults to "default".
+	NetworkMode string `yaml:"networkMode,omitempty" validate:"omitempty,networkmode"`
+	// Resources limits the memory and CPUs of the Terraform container. Unset limits leave
+	// the container unlimited.
+	Resources ContainerResources `yaml:"resources,omitempty"`
+	// Timeout bounds the whole provisioning run, e.g. "45m" or "2h". A run still going when
+	// it expires is stopped and its container removed. Runs have no timeout by default.
+	Timeout string `yaml:"timeout,omitempty" validate:"omitempty,duration"`
+	// SkipCredentialCheck disables the cloud credential check made before provisioning,
+	// e.g. for offline scenarios.
+	SkipCredentialCheck bool `yaml:"skipCredentialCheck,omitempty"`
+	// Parallel overlaps the Terraform image pull with the local setup work.
+	Parallel bool `yaml:"parallel,omitempty"`
+	// PrePull starts pulling the Terraform image when an apply starts, so the pull overlaps
+	// the scaffold and scm stages. A failed pull is reported when the provision stage starts.
+	PrePull bool `yaml:"prePull,omitempty"`
+	// SkipPermissionFix disables handing the files Terraform containers create in the
+	// scaffold and data directories back to the host user after a run.
+	SkipPermissionFix bool `yaml:"skipPermissionFix,omitempty"`
+	// BackendEnv holds environment variables passed only to terraform init, so the
+	// backend can authenticate with credentials distinct from the provisioning ones.
+	BackendEnv []EnvVar `yaml:"backendEnv,omitempty" validate:"omitempty,dive"`
+	// Env holds environment variables passed to every Terraform command, e.g. TF_VAR_* or
+	// proxy settings. They take precedence over KloneKit's defaults, except the paths of the
+	// mounted AWS credentials, which can't be changed.
+	Env []EnvVar `yaml:"env,omitempty" validate:"omitempty,unique=Name,dive"`
+	// StatePush uploads the local state and outputs after a successful apply, for
+	// projects that don't use a Terraform remote backend.
+	StatePush StatePush `yaml:"statePush,omitempty"`
+	// CostEstimateCommand is a shell command run on the host after terraform plan (e.g.
+	// "infracost breakdown --path $KLONEKIT_PLAN_FILE"). The plan is saved to a file whose
+	// path is passed in KLONEKIT_PLAN_FILE, and the command's output is shown in the console.
+	CostEstimateCommand string `yaml:"costEstimateCommand,omitempty"`
+	// PlanJSON is a path the plan is written to as JSON (terraform show -json) after
+	// terraform plan and before apply, for policy tools such as OPA or Sentinel. With a
+	// matrix, the workspace name is added before the extension (plan.json becomes plan.<name>.json).
+	PlanJSON string `yaml:"planJSON,omitempty"`
+	// PolicyCommand is a shell command run on the host after the plan JSON export (e.g.
+	// "conftest test $KLONEKIT_PLAN_JSON"), with the plan JSON path in KLONEKIT_PLAN_JSON. A
+	// non-zero exit rejects the plan and stops the run before apply. Without planJSON, the
+	// plan JSON is written next to the plan file and removed afterwards.
+	PolicyCommand string `yaml:"policyCommand,omitempty"`
+	// UseSavedPlan applies the plan saved to tfplan in the scaffold destination by
+	// 'klonekit plan', when present, instead of planning again.
+	UseSavedPlan bool `yaml:"useSavedPlan,omitempty"`
+	// Artifacts keeps the plan of each run for audit.
+	Artifacts Artifacts `yaml:"artifacts,omitempty"`
+	// ConfirmApply marks a production blueprint: applying it requires typing the SCM project
+	// name at a prompt, unless --skip-apply-confirmation is given.
+	ConfirmApply bool `yaml:"confirmApply,omitempty"`
+	// VariablesMode controls how spec.variables reach Terraform: "tfvars" (default) writes
+	// the tfvars file, "flags" passes them as -var flags to plan/apply/destroy without
+	// writing a file, and "both" does both. Lists and maps are passed JSON-encoded.
+	VariablesMode string `yaml:"variablesMode,omitempty" validate:"omitempty,oneof=tfvars flags both"`
+	// BackendMigration tells terraform init what to do when the backend configuration
+	// changed since the last init: "migrate" copies the existing state to the new backend,
+	// "reconfigure" starts from the new backend's state. Without it, such an init fails
+	// rather than prompting.
+	BackendMigration string `yaml:"backendMigration,omitempty" validate:"omitempty,oneof=migrate reconfigure"`
+	// MaxOutputBytes caps how much of each Terraform command's output is shown (default
+	// 64 MiB). Output beyond it is discarded with an "output truncated" warning.
+	MaxOutputBytes int64 `yaml:"maxOutputBytes,omitempty" validate:"omitempty,min=1"`
+	// Matrix runs plan/apply once per entry, each in a Terraform workspace named after the
+	// entry and with the entry's variables merged over spec.variables.
+	Matrix []MatrixEntry `yaml:"matrix,omitempty" validate:"omitempty,unique=Name,dive"`
+}
+
+// ContainerResources limits the resources of the Terraform container.
+type ContainerResources struct {
+	// Memory is the memory limit, in bytes or with a unit as for docker run --memory, e.g. "2g".
+	Memory string `yaml:"memory,omitempty" validate:"omitempty,memorysize"`
+	// CPUs is the number of CPUs the container may use, e.g. 1.5, as for docker run --cpus.
+	CPUs float64 `yaml:"cpus,omitempty" validate:"omitempty,gt=0"`
+}
+
+// TerraformConfig selects the Terraform release used for provisioning.
+type TerraformConfig struct {
+	// Version is the tag of the engine's official image to run, e.g. "1.5.7". It must be a
+	// semantic version, optionally with a pre-release suffix. For terraform it defaults to the
+	// version in a .terraform-version file of the scaffold source, then to 1.8.0.
+	Version string `yaml:"version,omitempty" validate:"omitempty,terraformversion"`
+	// StrictCompatibility fails provisioning when the version is older than a provider the
+	// module requires supports, instead of only logging a warning before init.
+	StrictCompatibility bool `yaml:"strictCompatibility,omitempty"`
+}
+
+// MatrixEntry is a named variable overlay provisioned into its own workspace.
+type MatrixEntry struct {
+	// Name is the workspace name, so it follows Terraform's rules for workspace names: it must
+	// be a valid URL path segment.
+	Name      string                 `yaml:"name" validate:"required,workspacename"`
+	Variables map[string]interface{} `yaml:"variables,omitempty"`
+}
+
+// StatePush defines where the local Terraform state is uploaded after apply.
+type StatePush struct {
+	// URL is the destination prefix: an s3://bucket/prefix URL or an http(s) URL
+	// that accepts PUT requests. terraform.tfstate and outputs.json are uploaded below it.
+	URL string `yaml:"url,omitempty" validate:"omitempty,statepushurl"`
+	// EncryptionKeyFromEnv names a host environment variable holding a base64-encoded
+	// 32-byte key. When set the uploads are AES-256-GCM encrypted; otherwise they are plaintext.
+	EncryptionKeyFromEnv string `yaml:"encryptionKeyFromEnv,omitempty"`
+}
+
+// Artifacts defines where the plan of each run is kept. Every run gets a directory named
+// after its run ID holding the plan file, its text rendering and a summary recording
+// whether it was applied.
+type Artifacts struct {
+	Dir string `yaml:"dir,omitempty"`
+	// Retain is the number of runs whose artifacts are kept (default 10); older runs are
+	// pruned once a new run's plan is saved.
+	Retain int `yaml:"retain,omitempty" validate:"gte=0"`
+}
+
+// EnvVar defines an environment variable for the Terraform container. The value is
+// given inline or read from a host environment variable with FromEnv.
+type EnvVar struct {
+	Name    string `yaml:"name" validate:"required"`
+	Value   string `yaml:"value,omitempty" validate:"required_without=FromEnv"`
+	FromEnv string `yaml:"fromEnv,omitempty"`
 }
@@ -3,19 +3,32 @@ package runtime
 
 import (
 	"context"
+	"fmt"
 	"io"
+	"regexp"
+	"strings"
 )
 
+// DefaultNetworkMode is the Docker network mode used when none is configured.
+const DefaultNetworkMode = "default"
+
+// networkNamePattern matches valid user-defined Docker network names.
+var networkNamePattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]*$`)
+
 // RunOptions defines the parameters for running a container.
 type RunOptions struct {
 	Image            string
+	Entrypoint       []string // Overrides the image's entrypoint when set
 	Command          []string
 	VolumeMounts     map[string]string
 	EnvVars          map[string]string
 	WorkingDirectory string
-	User             string // User ID in format "uid:gid" (e.g., "1000:1000")
-	RetainContainer  bool   // If true, container will not be automatically removed after execution
-	ContainerName    string // Optional container name for reuse/management
+	User             string  // User ID in format "uid:gid" (e.g., "1000:1000")
+	RetainContainer  bool    // If true, container will not be automatically removed after execution
+	ContainerName    string  // Optional container name for reuse/management
+	NetworkMode      string  // Docker network mode: default, bridge, host, none, container:<name> or a named network
+	Memory           int64   // Memory limit in bytes, unlimited when 0
+	CPUs             float64 // Number of CPUs the container may use, unlimited when 0
 }
 
 // ContainerRuntime defines the contract for container operations.
@@ -23,3 +36,34 @@ type ContainerRuntime interface {
 	PullImage(ctx context.Context, image string) error
 	RunContainer(ctx context.Context, opts RunOptions) (io.ReadCloser, error)
 }
+
+// ImageInfo describes a pulled image.
+type ImageInfo struct {
+	Entrypoint []string // The image's default entrypoint, empty when it has none
+}
+
+// ImageInspector is implemented by container runtimes that can inspect pulled images.
+type ImageInspector interface {
+	ImageInspect(ctx context.Context, image string) (ImageInfo, error)
+}
+
+// ValidateNetworkMode checks that mode is a known Docker network mode, a container:<name>
+// reference, or a valid user-defined network name. An empty mode selects DefaultNetworkMode.
+func ValidateNetworkMode(mode string) error {
+	switch mode {
+	case "", DefaultNetworkMode, "bridge", "host", "none":
+		return nil
+	}
+
+	if target, ok := strings.CutPrefix(mode, "container:"); ok {
+		if target == "" {
+			return fmt.Errorf("invalid network mode %q: container name is required", mode)
+		}
+		return nil
+	}
+
+	if !networkNamePattern.MatchString(mode) {
+		return fmt.Errorf("invalid network mode %q: must be default, bridge, host, none, container:<name> or a network name", mode)
+	}
+	return nil
+}
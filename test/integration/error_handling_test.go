@@ -53,7 +53,7 @@ func TestCLI_ErrorHandling_BlueprintNotFound(t *testing.T) {
 		"Error:",
 		"Failed to locate blueprint file",
 		"Cause:",
-		"No klonekit.yml or klonekit.yaml file found",
+		"No blueprint file (klonekit.yml, klonekit.yaml) found",
 		"Suggestion:",
 		"Create a blueprint file",
 	}